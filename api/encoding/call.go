@@ -22,6 +22,7 @@ package encoding
 
 import (
 	"context"
+	"crypto/x509"
 	"errors"
 	"sort"
 
@@ -140,3 +141,13 @@ func (c *Call) RoutingDelegate() string {
 	}
 	return c.ic.req.RoutingDelegate
 }
+
+// PeerCertificate returns the verified certificate presented by the caller
+// over a mutually authenticated TLS connection, or nil if the caller did not
+// present a verified certificate.
+func (c *Call) PeerCertificate() *x509.Certificate {
+	if c == nil {
+		return nil
+	}
+	return c.ic.peerCert
+}
@@ -41,6 +41,7 @@ func TestNilCall(t *testing.T) {
 	assert.Equal(t, "", call.RoutingDelegate())
 	assert.Equal(t, "", call.Header("foo"))
 	assert.Empty(t, call.HeaderNames())
+	assert.Nil(t, call.PeerCertificate())
 
 	assert.Error(t, call.WriteResponseHeader("foo", "bar"))
 }
@@ -22,6 +22,7 @@ package encoding
 
 import (
 	"context"
+	"crypto/x509"
 
 	"go.uber.org/yarpc/api/transport"
 )
@@ -34,6 +35,7 @@ import (
 type InboundCall struct {
 	resHeaders []keyValuePair
 	req        *transport.Request
+	peerCert   *x509.Certificate
 }
 
 type inboundCallKey struct{} // context key for *InboundCall
@@ -42,7 +44,7 @@ type inboundCallKey struct{} // context key for *InboundCall
 //
 // A request context is returned and must be used in place of the original.
 func NewInboundCall(ctx context.Context) (context.Context, *InboundCall) {
-	call := &InboundCall{}
+	call := &InboundCall{peerCert: transport.PeerCertificateFromContext(ctx)}
 	return context.WithValue(ctx, inboundCallKey{}, call), call
 }
 
@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: go.uber.org/yarpc/api/middleware (interfaces: Router,UnaryInbound,UnaryOutbound,OnewayInbound,OnewayOutbound)
+// Source: go.uber.org/yarpc/api/middleware (interfaces: Router,UnaryInbound,UnaryOutbound,OnewayInbound,OnewayOutbound,StreamInbound,StreamOutbound)
 
 // Copyright (c) 2017 Uber Technologies, Inc.
 //
@@ -219,3 +219,74 @@ func (_m *MockOnewayOutbound) CallOneway(_param0 context.Context, _param1 *trans
 func (_mr *MockOnewayOutboundMockRecorder) CallOneway(arg0, arg1, arg2 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CallOneway", reflect.TypeOf((*MockOnewayOutbound)(nil).CallOneway), arg0, arg1, arg2)
 }
+
+// MockStreamInbound is a mock of StreamInbound interface
+type MockStreamInbound struct {
+	ctrl     *gomock.Controller
+	recorder *MockStreamInboundMockRecorder
+}
+
+// MockStreamInboundMockRecorder is the mock recorder for MockStreamInbound
+type MockStreamInboundMockRecorder struct {
+	mock *MockStreamInbound
+}
+
+// NewMockStreamInbound creates a new mock instance
+func NewMockStreamInbound(ctrl *gomock.Controller) *MockStreamInbound {
+	mock := &MockStreamInbound{ctrl: ctrl}
+	mock.recorder = &MockStreamInboundMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (_m *MockStreamInbound) EXPECT() *MockStreamInboundMockRecorder {
+	return _m.recorder
+}
+
+// HandleStream mocks base method
+func (_m *MockStreamInbound) HandleStream(_param0 *transport.ServerStream, _param1 transport.StreamHandler) error {
+	ret := _m.ctrl.Call(_m, "HandleStream", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HandleStream indicates an expected call of HandleStream
+func (_mr *MockStreamInboundMockRecorder) HandleStream(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "HandleStream", reflect.TypeOf((*MockStreamInbound)(nil).HandleStream), arg0, arg1)
+}
+
+// MockStreamOutbound is a mock of StreamOutbound interface
+type MockStreamOutbound struct {
+	ctrl     *gomock.Controller
+	recorder *MockStreamOutboundMockRecorder
+}
+
+// MockStreamOutboundMockRecorder is the mock recorder for MockStreamOutbound
+type MockStreamOutboundMockRecorder struct {
+	mock *MockStreamOutbound
+}
+
+// NewMockStreamOutbound creates a new mock instance
+func NewMockStreamOutbound(ctrl *gomock.Controller) *MockStreamOutbound {
+	mock := &MockStreamOutbound{ctrl: ctrl}
+	mock.recorder = &MockStreamOutboundMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (_m *MockStreamOutbound) EXPECT() *MockStreamOutboundMockRecorder {
+	return _m.recorder
+}
+
+// CallStream mocks base method
+func (_m *MockStreamOutbound) CallStream(_param0 context.Context, _param1 *transport.StreamRequest, _param2 transport.StreamOutbound) (*transport.ClientStream, error) {
+	ret := _m.ctrl.Call(_m, "CallStream", _param0, _param1, _param2)
+	ret0, _ := ret[0].(*transport.ClientStream)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CallStream indicates an expected call of CallStream
+func (_mr *MockStreamOutboundMockRecorder) CallStream(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CallStream", reflect.TypeOf((*MockStreamOutbound)(nil).CallStream), arg0, arg1, arg2)
+}
@@ -85,3 +85,29 @@ func TestOnewayNopOutboundMiddleware(t *testing.T) {
 		assert.Equal(t, nil, got)
 	}
 }
+
+func TestStreamNopOutboundMiddleware(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	o := transporttest.NewMockStreamOutbound(mockCtrl)
+	wrappedO := middleware.ApplyStreamOutbound(o, middleware.NopStreamOutbound)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+	defer cancel()
+	req := &transport.StreamRequest{
+		Meta: &transport.RequestMeta{
+			Caller:    "somecaller",
+			Service:   "someservice",
+			Encoding:  raw.Encoding,
+			Procedure: "hello",
+		},
+	}
+
+	o.EXPECT().CallStream(ctx, req).Return(nil, nil)
+
+	got, err := wrappedO.CallStream(ctx, req)
+	if assert.NoError(t, err) {
+		assert.Nil(t, got)
+	}
+}
@@ -107,6 +107,17 @@ func (e ErrPeerRemoveNotInList) Error() string {
 	return fmt.Sprintf("can't remove peer (%s) because it is not in peerlist", string(e))
 }
 
+// ErrInvalidPeerWeight is returned to peer list updater if the weight of a
+// peer is not positive
+type ErrInvalidPeerWeight struct {
+	PeerIdentifier string
+	Weight         int
+}
+
+func (e ErrInvalidPeerWeight) Error() string {
+	return fmt.Sprintf("invalid weight %d for peer %q, weights must be positive", e.Weight, e.PeerIdentifier)
+}
+
 // ErrChooseContextHasNoDeadline is returned when a context is sent to a peerlist with no deadline
 // DEPRECATED use yarpcerrors api instead.
 type ErrChooseContextHasNoDeadline string
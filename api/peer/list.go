@@ -50,6 +50,9 @@ type ChooserList interface {
 	List
 }
 
+// DefaultWeight is the weight of peers added to a List without one.
+const DefaultWeight = 1
+
 // ListUpdates specifies the updates to be made to a List
 type ListUpdates struct {
 	// Additions are the identifiers that should be added to the list
@@ -57,6 +60,26 @@ type ListUpdates struct {
 
 	// Removals are the identifiers that should be removed to the list
 	Removals []Identifier
+
+	// Weights optionally specifies the relative weights of peers, keyed by
+	// their identifier. Peers with twice the weight of others receive twice
+	// the traffic. Weights must be positive.
+	//
+	// Weights of peers in Additions apply to the added peers, which otherwise
+	// have the DefaultWeight. Weights of other peers in the list change their
+	// weight without adding them again.
+	//
+	// Lists which don't support weights ignore them.
+	Weights map[string]int
+}
+
+// Weight returns the weight of the peer with the given identifier in the
+// updates, or DefaultWeight if the updates don't specify one.
+func (u ListUpdates) Weight(id string) int {
+	if w, ok := u.Weights[id]; ok {
+		return w
+	}
+	return DefaultWeight
 }
 
 // Binder is a callback for peer.Bind that accepts a peer list and binds it to
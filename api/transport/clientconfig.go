@@ -45,3 +45,17 @@ type ClientConfig interface {
 	GetUnaryOutbound() UnaryOutbound
 	GetOnewayOutbound() OnewayOutbound
 }
+
+// StreamClientConfig is a ClientConfig that can also provide a
+// StreamOutbound. Clients that need to make streaming requests should
+// upcast their ClientConfig to this interface.
+type StreamClientConfig interface {
+	ClientConfig
+
+	// Returns a stream outbound to send the request through or panics if
+	// there is no stream outbound for this service.
+	//
+	// MAY be called multiple times for a request. The returned outbound MUST
+	// have already been started.
+	GetStreamOutbound() StreamOutbound
+}
@@ -39,6 +39,9 @@ const (
 	Unary Type = iota + 1
 	// Oneway types are fire and forget RPCs (no response)
 	Oneway
+	// Streaming types are Stream based RPCs (bidirectional messages over long
+	// lived connections)
+	Streaming
 )
 
 // HandlerSpec holds a handler and its Type
@@ -48,6 +51,7 @@ type HandlerSpec struct {
 
 	unaryHandler  UnaryHandler
 	onewayHandler OnewayHandler
+	streamHandler StreamHandler
 }
 
 // MarshalLogObject implements zap.ObjectMarshaler.
@@ -65,6 +69,9 @@ func (h HandlerSpec) Unary() UnaryHandler { return h.unaryHandler }
 // Oneway returns the Oneway Handler or nil
 func (h HandlerSpec) Oneway() OnewayHandler { return h.onewayHandler }
 
+// Stream returns the Stream Handler or nil
+func (h HandlerSpec) Stream() StreamHandler { return h.streamHandler }
+
 // NewUnaryHandlerSpec returns an new HandlerSpec with a UnaryHandler
 func NewUnaryHandlerSpec(handler UnaryHandler) HandlerSpec {
 	return HandlerSpec{t: Unary, unaryHandler: handler}
@@ -75,6 +82,11 @@ func NewOnewayHandlerSpec(handler OnewayHandler) HandlerSpec {
 	return HandlerSpec{t: Oneway, onewayHandler: handler}
 }
 
+// NewStreamHandlerSpec returns an new HandlerSpec with a StreamHandler
+func NewStreamHandlerSpec(handler StreamHandler) HandlerSpec {
+	return HandlerSpec{t: Streaming, streamHandler: handler}
+}
+
 // UnaryHandler handles a single, transport-level, unary request.
 type UnaryHandler interface {
 	// Handle the given request, writing the response to the given
@@ -96,6 +108,15 @@ type OnewayHandler interface {
 	HandleOneway(ctx context.Context, req *Request) error
 }
 
+// StreamHandler handles a stream connection request.
+type StreamHandler interface {
+	// Handle the given stream connection. The stream will close when the
+	// function returns.
+	//
+	// An error may be returned in case of failures.
+	HandleStream(stream *ServerStream) error
+}
+
 // DispatchUnaryHandler calls the handler h, recovering panics and timeout errors,
 // converting them to yarpc errors. All other errors are passed trough.
 func DispatchUnaryHandler(
@@ -141,3 +162,19 @@ func DispatchOnewayHandler(
 
 	return h.HandleOneway(ctx, req)
 }
+
+// DispatchStreamHandler calls the stream handler, recovering from panics as
+// errors.
+func DispatchStreamHandler(
+	h StreamHandler,
+	stream *ServerStream,
+) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Stream handler panicked: %v\n%s", r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return h.HandleStream(stream)
+}
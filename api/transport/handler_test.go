@@ -32,6 +32,7 @@ import (
 
 type unaryHandlerFunc func(context.Context, *Request, ResponseWriter) error
 type onewayHandlerFunc func(context.Context, *Request) error
+type streamHandlerFunc func(*ServerStream) error
 
 func (f unaryHandlerFunc) Handle(ctx context.Context, r *Request, w ResponseWriter) error {
 	return f(ctx, r, w)
@@ -39,6 +40,9 @@ func (f unaryHandlerFunc) Handle(ctx context.Context, r *Request, w ResponseWrit
 func (f onewayHandlerFunc) HandleOneway(ctx context.Context, r *Request) error {
 	return f(ctx, r)
 }
+func (f streamHandlerFunc) HandleStream(s *ServerStream) error {
+	return f(s)
+}
 
 func TestHandlerSpecLogMarshaling(t *testing.T) {
 	tests := []struct {
@@ -60,6 +64,13 @@ func TestHandlerSpecLogMarshaling(t *testing.T) {
 			})),
 			want: map[string]interface{}{"rpcType": "Oneway"},
 		},
+		{
+			desc: "streaming",
+			spec: NewStreamHandlerSpec(streamHandlerFunc(func(_ *ServerStream) error {
+				return nil
+			})),
+			want: map[string]interface{}{"rpcType": "Streaming"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -100,3 +111,16 @@ func TestDispatchOnewayHandlerWithPanic(t *testing.T) {
 	expectMsg := fmt.Sprintf("panic: %s", msg)
 	assert.Equal(t, err.Error(), expectMsg)
 }
+
+func TestDispatchStreamHandlerWithPanic(t *testing.T) {
+	msg := "I'm panicking in a stream handler!"
+	handler := func(*ServerStream) error {
+		panic(msg)
+	}
+
+	err := DispatchStreamHandler(
+		streamHandlerFunc(handler),
+		nil)
+	expectMsg := fmt.Sprintf("panic: %s", msg)
+	assert.Equal(t, err.Error(), expectMsg)
+}
@@ -64,6 +64,21 @@ type OnewayOutbound interface {
 	CallOneway(ctx context.Context, request *Request) (Ack, error)
 }
 
+// StreamOutbound is an outbound transport that can send streaming requests.
+type StreamOutbound interface {
+	Outbound
+
+	// CallStream creates a stream connection based on the metadata in the
+	// request passed in. If there is a timeout on the context, this timeout
+	// is for establishing a connection, and not for the lifetime of the
+	// stream.
+	//
+	// This MUST NOT be called before Start() has been called successfully. This
+	// MAY panic if called without calling Start(). This MUST be safe to call
+	// concurrently.
+	CallStream(ctx context.Context, request *StreamRequest) (*ClientStream, error)
+}
+
 // Outbounds encapsulates the outbound specification for a service.
 //
 // This includes the service name that will be used for outbound requests as
@@ -79,4 +94,8 @@ type Outbounds struct {
 	// If set, this is the oneway outbound which sends the request and
 	// continues once the message has been delivered.
 	Oneway OnewayOutbound
+
+	// If set, this is the stream outbound which creates a ClientStream that
+	// can be used to continuously send and receive messages.
+	Stream StreamOutbound
 }
@@ -78,6 +78,72 @@ func (r *Request) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 	return nil
 }
 
+// ToRequestMeta converts a Request into a RequestMeta.
+func (r *Request) ToRequestMeta() *RequestMeta {
+	return &RequestMeta{
+		Caller:          r.Caller,
+		Service:         r.Service,
+		Encoding:        r.Encoding,
+		Procedure:       r.Procedure,
+		Headers:         r.Headers,
+		ShardKey:        r.ShardKey,
+		RoutingKey:      r.RoutingKey,
+		RoutingDelegate: r.RoutingDelegate,
+	}
+}
+
+// RequestMeta is the low level request metadata representation.  It does not
+// include any "body" information, and should only be used for information
+// about a connection's metadata.
+type RequestMeta struct {
+	// Name of the service making the request.
+	Caller string
+
+	// Name of the service to which the request is being made.
+	// The service refers to the canonical traffic group for the service.
+	Service string
+
+	// Name of the encoding used for the request body.
+	Encoding Encoding
+
+	// Name of the procedure being called.
+	Procedure string
+
+	// Headers for the request.
+	Headers Headers
+
+	// ShardKey is an opaque string that is meaningful to the destined service
+	// for how to relay a request within a cluster to the shard that owns the
+	// key.
+	ShardKey string
+
+	// RoutingKey refers to a traffic group for the destined service, and when
+	// present may override the service name for purposes of routing.
+	RoutingKey string
+
+	// RoutingDelegate refers to the traffic group for a service that proxies
+	// for the destined service for routing purposes. The routing delegate may
+	// override the routing key and service.
+	RoutingDelegate string
+}
+
+// ToRequest converts a RequestMeta into a Request with an empty body.
+func (r *RequestMeta) ToRequest() *Request {
+	if r == nil {
+		return &Request{}
+	}
+	return &Request{
+		Caller:          r.Caller,
+		Service:         r.Service,
+		Encoding:        r.Encoding,
+		Procedure:       r.Procedure,
+		Headers:         r.Headers,
+		ShardKey:        r.ShardKey,
+		RoutingKey:      r.RoutingKey,
+		RoutingDelegate: r.RoutingDelegate,
+	}
+}
+
 // Encoding represents an encoding format for requests.
 type Encoding string
 
@@ -149,3 +149,20 @@ func TestRequestLogMarshaling(t *testing.T) {
 		"routingDelegate": "routing-delegate",
 	}, enc.Fields, "Unexpected output after marshaling request.")
 }
+
+func TestRequestMetaRoundTrip(t *testing.T) {
+	req := &transport.Request{
+		Caller:          "caller",
+		Service:         "service",
+		Encoding:        "raw",
+		Procedure:       "procedure",
+		Headers:         transport.NewHeaders().With("password", "super-secret"),
+		ShardKey:        "shard01",
+		RoutingKey:      "routing-key",
+		RoutingDelegate: "routing-delegate",
+	}
+	assert.Equal(t, req, req.ToRequestMeta().ToRequest())
+
+	var nilMeta *transport.RequestMeta
+	assert.Equal(t, &transport.Request{}, nilMeta.ToRequest())
+}
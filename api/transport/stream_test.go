@@ -0,0 +1,92 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transport_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+)
+
+func TestNewStreamsRequireStream(t *testing.T) {
+	_, err := transport.NewServerStream(nil)
+	assert.Error(t, err)
+
+	_, err = transport.NewClientStream(nil)
+	assert.Error(t, err)
+}
+
+func TestServerStreamDelegates(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx := context.Background()
+	req := &transport.StreamRequest{Meta: &transport.RequestMeta{Procedure: "hello"}}
+	msg := &transport.StreamMessage{}
+
+	mockStream := transporttest.NewMockStream(mockCtrl)
+	mockStream.EXPECT().Context().Return(ctx)
+	mockStream.EXPECT().Request().Return(req)
+	mockStream.EXPECT().SendMessage(ctx, msg).Return(nil)
+	mockStream.EXPECT().ReceiveMessage(ctx).Return(nil, io.EOF)
+
+	s, err := transport.NewServerStream(mockStream)
+	require.NoError(t, err)
+
+	assert.Equal(t, ctx, s.Context())
+	assert.Equal(t, req, s.Request())
+	assert.NoError(t, s.SendMessage(ctx, msg))
+	_, err = s.ReceiveMessage(ctx)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestClientStreamDelegates(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx := context.Background()
+	req := &transport.StreamRequest{Meta: &transport.RequestMeta{Procedure: "hello"}}
+	msg := &transport.StreamMessage{}
+
+	mockStream := transporttest.NewMockStreamCloser(mockCtrl)
+	mockStream.EXPECT().Context().Return(ctx)
+	mockStream.EXPECT().Request().Return(req)
+	mockStream.EXPECT().SendMessage(ctx, msg).Return(nil)
+	mockStream.EXPECT().ReceiveMessage(ctx).Return(msg, nil)
+	mockStream.EXPECT().Close(ctx).Return(nil)
+
+	s, err := transport.NewClientStream(mockStream)
+	require.NoError(t, err)
+
+	assert.Equal(t, ctx, s.Context())
+	assert.Equal(t, req, s.Request())
+	assert.NoError(t, s.SendMessage(ctx, msg))
+	got, err := s.ReceiveMessage(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, got)
+	assert.NoError(t, s.Close(ctx))
+}
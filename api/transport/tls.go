@@ -0,0 +1,49 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transport
+
+import (
+	"context"
+	"crypto/x509"
+)
+
+type peerCertificateKey struct{} // context key for *x509.Certificate
+
+// WithPeerCertificate returns a copy of the given context that carries the
+// verified certificate of the peer that sent an inbound request.
+//
+// Transports call this for requests received over TLS connections on which
+// the client presented a certificate that was verified. The given context is
+// returned unchanged if cert is nil.
+func WithPeerCertificate(ctx context.Context, cert *x509.Certificate) context.Context {
+	if cert == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, peerCertificateKey{}, cert)
+}
+
+// PeerCertificateFromContext returns the verified certificate of the peer
+// that sent the inbound request associated with the given context, or nil if
+// the request was not made over a mutually authenticated TLS connection.
+func PeerCertificateFromContext(ctx context.Context) *x509.Certificate {
+	cert, _ := ctx.Value(peerCertificateKey{}).(*x509.Certificate)
+	return cert
+}
@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: go.uber.org/yarpc/api/transport (interfaces: UnaryHandler,OnewayHandler)
+// Source: go.uber.org/yarpc/api/transport (interfaces: UnaryHandler,OnewayHandler,StreamHandler)
 
 // Copyright (c) 2017 Uber Technologies, Inc.
 //
@@ -99,3 +99,38 @@ func (_m *MockOnewayHandler) HandleOneway(_param0 context.Context, _param1 *tran
 func (_mr *MockOnewayHandlerMockRecorder) HandleOneway(arg0, arg1 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "HandleOneway", reflect.TypeOf((*MockOnewayHandler)(nil).HandleOneway), arg0, arg1)
 }
+
+// MockStreamHandler is a mock of StreamHandler interface
+type MockStreamHandler struct {
+	ctrl     *gomock.Controller
+	recorder *MockStreamHandlerMockRecorder
+}
+
+// MockStreamHandlerMockRecorder is the mock recorder for MockStreamHandler
+type MockStreamHandlerMockRecorder struct {
+	mock *MockStreamHandler
+}
+
+// NewMockStreamHandler creates a new mock instance
+func NewMockStreamHandler(ctrl *gomock.Controller) *MockStreamHandler {
+	mock := &MockStreamHandler{ctrl: ctrl}
+	mock.recorder = &MockStreamHandlerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (_m *MockStreamHandler) EXPECT() *MockStreamHandlerMockRecorder {
+	return _m.recorder
+}
+
+// HandleStream mocks base method
+func (_m *MockStreamHandler) HandleStream(_param0 *transport.ServerStream) error {
+	ret := _m.ctrl.Call(_m, "HandleStream", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HandleStream indicates an expected call of HandleStream
+func (_mr *MockStreamHandlerMockRecorder) HandleStream(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "HandleStream", reflect.TypeOf((*MockStreamHandler)(nil).HandleStream), arg0)
+}
@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: go.uber.org/yarpc/api/transport (interfaces: UnaryOutbound,OnewayOutbound)
+// Source: go.uber.org/yarpc/api/transport (interfaces: UnaryOutbound,OnewayOutbound,StreamOutbound)
 
 // Copyright (c) 2017 Uber Technologies, Inc.
 //
@@ -197,3 +197,87 @@ func (_m *MockOnewayOutbound) Transports() []transport.Transport {
 func (_mr *MockOnewayOutboundMockRecorder) Transports() *gomock.Call {
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "Transports", reflect.TypeOf((*MockOnewayOutbound)(nil).Transports))
 }
+
+// MockStreamOutbound is a mock of StreamOutbound interface
+type MockStreamOutbound struct {
+	ctrl     *gomock.Controller
+	recorder *MockStreamOutboundMockRecorder
+}
+
+// MockStreamOutboundMockRecorder is the mock recorder for MockStreamOutbound
+type MockStreamOutboundMockRecorder struct {
+	mock *MockStreamOutbound
+}
+
+// NewMockStreamOutbound creates a new mock instance
+func NewMockStreamOutbound(ctrl *gomock.Controller) *MockStreamOutbound {
+	mock := &MockStreamOutbound{ctrl: ctrl}
+	mock.recorder = &MockStreamOutboundMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (_m *MockStreamOutbound) EXPECT() *MockStreamOutboundMockRecorder {
+	return _m.recorder
+}
+
+// CallStream mocks base method
+func (_m *MockStreamOutbound) CallStream(_param0 context.Context, _param1 *transport.StreamRequest) (*transport.ClientStream, error) {
+	ret := _m.ctrl.Call(_m, "CallStream", _param0, _param1)
+	ret0, _ := ret[0].(*transport.ClientStream)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CallStream indicates an expected call of CallStream
+func (_mr *MockStreamOutboundMockRecorder) CallStream(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CallStream", reflect.TypeOf((*MockStreamOutbound)(nil).CallStream), arg0, arg1)
+}
+
+// IsRunning mocks base method
+func (_m *MockStreamOutbound) IsRunning() bool {
+	ret := _m.ctrl.Call(_m, "IsRunning")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsRunning indicates an expected call of IsRunning
+func (_mr *MockStreamOutboundMockRecorder) IsRunning() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "IsRunning", reflect.TypeOf((*MockStreamOutbound)(nil).IsRunning))
+}
+
+// Start mocks base method
+func (_m *MockStreamOutbound) Start() error {
+	ret := _m.ctrl.Call(_m, "Start")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Start indicates an expected call of Start
+func (_mr *MockStreamOutboundMockRecorder) Start() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "Start", reflect.TypeOf((*MockStreamOutbound)(nil).Start))
+}
+
+// Stop mocks base method
+func (_m *MockStreamOutbound) Stop() error {
+	ret := _m.ctrl.Call(_m, "Stop")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Stop indicates an expected call of Stop
+func (_mr *MockStreamOutboundMockRecorder) Stop() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "Stop", reflect.TypeOf((*MockStreamOutbound)(nil).Stop))
+}
+
+// Transports mocks base method
+func (_m *MockStreamOutbound) Transports() []transport.Transport {
+	ret := _m.ctrl.Call(_m, "Transports")
+	ret0, _ := ret[0].([]transport.Transport)
+	return ret0
+}
+
+// Transports indicates an expected call of Transports
+func (_mr *MockStreamOutboundMockRecorder) Transports() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "Transports", reflect.TypeOf((*MockStreamOutbound)(nil).Transports))
+}
@@ -22,6 +22,7 @@ package yarpc
 
 import (
 	"context"
+	"crypto/x509"
 
 	"go.uber.org/yarpc/api/encoding"
 	"go.uber.org/yarpc/api/transport"
@@ -155,3 +156,17 @@ func (c *Call) RoutingKey() string {
 func (c *Call) RoutingDelegate() string {
 	return (*encoding.Call)(c).RoutingDelegate()
 }
+
+// PeerCertificate returns the verified certificate presented by the caller
+// over a mutually authenticated TLS connection, or nil if the caller did not
+// present a verified certificate.
+//
+// Use this to authorize requests based on the identity of the caller.
+//
+// 	cert := yarpc.CallFromContext(ctx).PeerCertificate()
+// 	if cert == nil || cert.Subject.CommonName != "myclient" {
+// 		return nil, yarpcerrors.Newf(yarpcerrors.CodePermissionDenied, "unknown caller")
+// 	}
+func (c *Call) PeerCertificate() *x509.Certificate {
+	return (*encoding.Call)(c).PeerCertificate()
+}
@@ -22,6 +22,8 @@ package yarpc_test
 
 import (
 	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -74,4 +76,13 @@ func TestCallFromContext(t *testing.T) {
 	assert.Equal(t, "one", call.ShardKey())
 	assert.Equal(t, "two", call.RoutingKey())
 	assert.Equal(t, "three", call.RoutingDelegate())
+	assert.Nil(t, call.PeerCertificate())
+}
+
+func TestCallFromContextPeerCertificate(t *testing.T) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "foo"}}
+	ctx := transport.WithPeerCertificate(context.Background(), cert)
+	ctx, inboundCall := encoding.NewInboundCall(ctx)
+	assert.NoError(t, inboundCall.ReadFromRequest(&transport.Request{Caller: "foo"}))
+	assert.Equal(t, cert, yarpc.CallFromContext(ctx).PeerCertificate())
 }
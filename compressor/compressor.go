@@ -0,0 +1,106 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package compressor provides the compressors used by YARPC transports to
+// compress request and response bodies.
+//
+// Compressors are identified by name. The name is sent over the wire (for
+// example in the Content-Encoding header of HTTP requests, or the
+// grpc-encoding header of gRPC requests) so that the receiving side can find
+// the matching compressor to decompress the body. Gzip is registered by
+// default; other compressors may be made available to all transports with
+// Register.
+package compressor
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Compressor compresses and decompresses bodies.
+//
+// Implementations must be safe for concurrent use.
+type Compressor interface {
+	// Name of the compressor, used to identify it over the wire. This must
+	// be a valid HTTP token, such as "gzip" or "snappy".
+	Name() string
+
+	// Compress returns a writer which compresses everything written to it
+	// into w. The compressed data is only guaranteed to have been fully
+	// written to w after the returned writer is closed.
+	Compress(w io.Writer) (io.WriteCloser, error)
+
+	// Decompress returns a reader which decompresses the data read from r.
+	Decompress(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	_registryLock sync.RWMutex
+	_registry     = make(map[string]Compressor)
+)
+
+func init() {
+	Register(Gzip)
+}
+
+// Register makes the given compressor available to all transports under its
+// name.
+//
+// Register panics if a compressor with the same name was already registered.
+// It is meant to be called during initialization, typically from the init
+// function of the package providing the compressor.
+func Register(c Compressor) {
+	name := c.Name()
+	if name == "" {
+		panic("compressor: cannot register a compressor without a name")
+	}
+
+	_registryLock.Lock()
+	defer _registryLock.Unlock()
+
+	if _, ok := _registry[name]; ok {
+		panic(fmt.Sprintf("compressor: a compressor named %q is already registered", name))
+	}
+	_registry[name] = c
+}
+
+// Get returns the registered compressor with the given name.
+func Get(name string) (Compressor, bool) {
+	_registryLock.RLock()
+	defer _registryLock.RUnlock()
+
+	c, ok := _registry[name]
+	return c, ok
+}
+
+// Names returns the sorted names of all registered compressors.
+func Names() []string {
+	_registryLock.RLock()
+	defer _registryLock.RUnlock()
+
+	names := make([]string, 0, len(_registry))
+	for name := range _registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package compressor
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCompressor struct{ name string }
+
+func (c fakeCompressor) Name() string { return c.name }
+
+func (fakeCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
+
+func (fakeCompressor) Decompress(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(r), nil
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestGzipIsRegistered(t *testing.T) {
+	c, ok := Get(GzipName)
+	require.True(t, ok)
+	assert.Equal(t, Gzip, c)
+	assert.Contains(t, Names(), GzipName)
+}
+
+func TestRegister(t *testing.T) {
+	c := fakeCompressor{name: "fake"}
+	Register(c)
+	defer func() {
+		_registryLock.Lock()
+		delete(_registry, c.name)
+		_registryLock.Unlock()
+	}()
+
+	got, ok := Get("fake")
+	require.True(t, ok)
+	assert.Equal(t, c, got)
+	assert.Equal(t, []string{"fake", "gzip"}, Names())
+
+	assert.Panics(t, func() { Register(fakeCompressor{name: "fake"}) })
+	assert.Panics(t, func() { Register(fakeCompressor{}) })
+
+	_, ok = Get("snappy")
+	assert.False(t, ok)
+}
+
+func TestGzipRoundTrip(t *testing.T) {
+	payload := strings.Repeat("hello world ", 1000)
+
+	// Run several times so that pooled readers and writers are reused.
+	for i := 0; i < 3; i++ {
+		var compressed bytes.Buffer
+		w, err := Gzip.Compress(&compressed)
+		require.NoError(t, err)
+		_, err = io.WriteString(w, payload)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		require.NoError(t, w.Close(), "closing twice must be safe")
+		assert.True(t, compressed.Len() < len(payload), "body was not compressed")
+
+		r, err := Gzip.Decompress(&compressed)
+		require.NoError(t, err)
+		got, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.NoError(t, r.Close(), "closing twice must be safe")
+		assert.Equal(t, payload, string(got))
+	}
+}
+
+func TestGzipDecompressInvalid(t *testing.T) {
+	_, err := Gzip.Decompress(strings.NewReader("not gzip"))
+	assert.Error(t, err)
+
+	// A reader that failed to reset must not break later decompressions.
+	var compressed bytes.Buffer
+	w, err := Gzip.Compress(&compressed)
+	require.NoError(t, err)
+	_, err = io.WriteString(w, "hello")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r, err := Gzip.Decompress(&compressed)
+	require.NoError(t, err)
+	got, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(got))
+	require.NoError(t, r.Close())
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package compressor
+
+import (
+	"compress/gzip"
+	"io"
+	"sync"
+)
+
+// GzipName is the name of the gzip compressor.
+const GzipName = "gzip"
+
+// Gzip compresses bodies with gzip at the default compression level.
+//
+// It is registered by default.
+var Gzip Compressor = gzipCompressor{}
+
+var (
+	_gzipWriterPool = sync.Pool{New: func() interface{} {
+		return gzip.NewWriter(nil)
+	}}
+	_gzipReaderPool sync.Pool
+)
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Name() string {
+	return GzipName
+}
+
+func (gzipCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	gw := _gzipWriterPool.Get().(*gzip.Writer)
+	gw.Reset(w)
+	return &gzipWriter{Writer: gw}, nil
+}
+
+func (gzipCompressor) Decompress(r io.Reader) (io.ReadCloser, error) {
+	if gr, ok := _gzipReaderPool.Get().(*gzip.Reader); ok {
+		if err := gr.Reset(r); err != nil {
+			_gzipReaderPool.Put(gr)
+			return nil, err
+		}
+		return &gzipReader{Reader: gr}, nil
+	}
+
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &gzipReader{Reader: gr}, nil
+}
+
+// gzipWriter returns its gzip.Writer to the pool once closed.
+type gzipWriter struct {
+	*gzip.Writer
+}
+
+func (w *gzipWriter) Close() error {
+	if w.Writer == nil {
+		return nil
+	}
+	err := w.Writer.Close()
+	_gzipWriterPool.Put(w.Writer)
+	w.Writer = nil
+	return err
+}
+
+// gzipReader returns its gzip.Reader to the pool once closed.
+type gzipReader struct {
+	*gzip.Reader
+}
+
+func (r *gzipReader) Close() error {
+	if r.Reader == nil {
+		return nil
+	}
+	err := r.Reader.Close()
+	_gzipReaderPool.Put(r.Reader)
+	r.Reader = nil
+	return err
+}
@@ -36,6 +36,7 @@ import (
 	"go.uber.org/yarpc/internal/outboundmiddleware"
 	"go.uber.org/yarpc/internal/pally"
 	"go.uber.org/yarpc/internal/request"
+	"go.uber.org/yarpc/internal/sharedmetrics"
 	"go.uber.org/zap"
 )
 
@@ -51,12 +52,14 @@ type Outbounds map[string]transport.Outbounds
 type OutboundMiddleware struct {
 	Unary  middleware.UnaryOutbound
 	Oneway middleware.OnewayOutbound
+	Stream middleware.StreamOutbound
 }
 
 // InboundMiddleware contains the different types of inbound middlewares.
 type InboundMiddleware struct {
 	Unary  middleware.UnaryInbound
 	Oneway middleware.OnewayInbound
+	Stream middleware.StreamInbound
 }
 
 // RouterMiddleware wraps the Router middleware
@@ -80,17 +83,20 @@ func NewDispatcher(cfg Config) *Dispatcher {
 
 	registry, stopPush := cfg.Metrics.registry(cfg.Name, logger)
 	cfg = addObservingMiddleware(cfg, registry, logger, extractor)
+	transports := collectTransports(cfg.Inbounds, cfg.Outbounds)
+	stopSharedMetrics := sharedmetrics.Report(registry, logger, componentMetrics(cfg, transports)...)
 
 	return &Dispatcher{
 		name:              cfg.Name,
 		table:             middleware.ApplyRouteTable(NewMapRouter(cfg.Name), cfg.RouterMiddleware),
 		inbounds:          cfg.Inbounds,
 		outbounds:         convertOutbounds(cfg.Outbounds, cfg.OutboundMiddleware),
-		transports:        collectTransports(cfg.Inbounds, cfg.Outbounds),
+		transports:        transports,
 		inboundMiddleware: cfg.InboundMiddleware,
 		log:               logger,
 		registry:          registry,
 		stopRegistryPush:  stopPush,
+		stopSharedMetrics: stopSharedMetrics,
 	}
 }
 
@@ -111,13 +117,14 @@ func convertOutbounds(outbounds Outbounds, mw OutboundMiddleware) Outbounds {
 	outboundSpecs := make(Outbounds, len(outbounds))
 
 	for outboundKey, outs := range outbounds {
-		if outs.Unary == nil && outs.Oneway == nil {
+		if outs.Unary == nil && outs.Oneway == nil && outs.Stream == nil {
 			panic(fmt.Sprintf("no outbound set for outbound key %q in dispatcher", outboundKey))
 		}
 
 		var (
 			unaryOutbound  transport.UnaryOutbound
 			onewayOutbound transport.OnewayOutbound
+			streamOutbound transport.StreamOutbound
 		)
 		serviceName := outboundKey
 
@@ -132,6 +139,11 @@ func convertOutbounds(outbounds Outbounds, mw OutboundMiddleware) Outbounds {
 			onewayOutbound = request.OnewayValidatorOutbound{OnewayOutbound: onewayOutbound}
 		}
 
+		if outs.Stream != nil {
+			streamOutbound = middleware.ApplyStreamOutbound(outs.Stream, mw.Stream)
+			streamOutbound = request.StreamValidatorOutbound{StreamOutbound: streamOutbound}
+		}
+
 		if outs.ServiceName != "" {
 			serviceName = outs.ServiceName
 		}
@@ -140,6 +152,7 @@ func convertOutbounds(outbounds Outbounds, mw OutboundMiddleware) Outbounds {
 			ServiceName: serviceName,
 			Unary:       unaryOutbound,
 			Oneway:      onewayOutbound,
+			Stream:      streamOutbound,
 		}
 	}
 
@@ -169,6 +182,11 @@ func collectTransports(inbounds Inbounds, outbounds Outbounds) []transport.Trans
 				transports[transport] = struct{}{}
 			}
 		}
+		if stream := outbound.Stream; stream != nil {
+			for _, transport := range stream.Transports() {
+				transports[transport] = struct{}{}
+			}
+		}
 	}
 	keys := make([]transport.Transport, 0, len(transports))
 	for key := range transports {
@@ -177,6 +195,30 @@ func collectTransports(inbounds Inbounds, outbounds Outbounds) []transport.Trans
 	return keys
 }
 
+// componentMetrics collects the metrics of the middleware, inbounds,
+// outbounds and transports the Dispatcher is configured with, which the
+// Dispatcher reports alongside its own.
+func componentMetrics(cfg Config, transports []transport.Transport) []*sharedmetrics.Set {
+	components := []interface{}{
+		cfg.InboundMiddleware.Unary,
+		cfg.InboundMiddleware.Oneway,
+		cfg.InboundMiddleware.Stream,
+		cfg.OutboundMiddleware.Unary,
+		cfg.OutboundMiddleware.Oneway,
+		cfg.OutboundMiddleware.Stream,
+	}
+	for _, inbound := range cfg.Inbounds {
+		components = append(components, inbound)
+	}
+	for _, outbound := range cfg.Outbounds {
+		components = append(components, outbound.Unary, outbound.Oneway, outbound.Stream)
+	}
+	for _, t := range transports {
+		components = append(components, t)
+	}
+	return sharedmetrics.Collect(components...)
+}
+
 // Dispatcher encapsulates a YARPC application. It acts as the entry point to
 // send and receive YARPC requests in a transport and encoding agnostic way.
 type Dispatcher struct {
@@ -188,9 +230,10 @@ type Dispatcher struct {
 
 	inboundMiddleware InboundMiddleware
 
-	log              *zap.Logger
-	registry         *pally.Registry
-	stopRegistryPush context.CancelFunc
+	log               *zap.Logger
+	registry          *pally.Registry
+	stopRegistryPush  context.CancelFunc
+	stopSharedMetrics func()
 }
 
 // Inbounds returns a copy of the list of inbounds for this RPC object.
@@ -240,6 +283,10 @@ func (d *Dispatcher) Register(rs []transport.Procedure) {
 			h := middleware.ApplyOnewayInbound(r.HandlerSpec.Oneway(),
 				d.inboundMiddleware.Oneway)
 			r.HandlerSpec = transport.NewOnewayHandlerSpec(h)
+		case transport.Streaming:
+			h := middleware.ApplyStreamInbound(r.HandlerSpec.Stream(),
+				d.inboundMiddleware.Stream)
+			r.HandlerSpec = transport.NewStreamHandlerSpec(h)
 		default:
 			panic(fmt.Sprintf("unknown handler type %q for service %q, procedure %q",
 				r.HandlerSpec.Type(), r.Service, r.Name))
@@ -335,6 +382,7 @@ func (d *Dispatcher) Start() error {
 	for _, o := range d.outbounds {
 		wait.Submit(start(o.Unary))
 		wait.Submit(start(o.Oneway))
+		wait.Submit(start(o.Stream))
 	}
 	if errs := wait.Wait(); len(errs) != 0 {
 		return abort(errs)
@@ -396,6 +444,9 @@ func (d *Dispatcher) Stop() error {
 		if o.Oneway != nil {
 			wait.Submit(o.Oneway.Stop)
 		}
+		if o.Stream != nil {
+			wait.Submit(o.Stream.Stop)
+		}
 	}
 	if errs := wait.Wait(); len(errs) > 0 {
 		allErrs = append(allErrs, errs...)
@@ -417,8 +468,10 @@ func (d *Dispatcher) Stop() error {
 		return err
 	}
 
-	// Stop pushing metrics to Tally.
+	// Stop reporting metrics of middleware, outbounds and transports, and
+	// pushing metrics to Tally.
 	d.log.Debug("Stopping metrics push loop, if any.")
+	d.stopSharedMetrics()
 	d.stopRegistryPush()
 	d.log.Debug("Stopped metrics push loop, if any.")
 
@@ -21,22 +21,27 @@
 package yarpc_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"runtime"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/uber-go/tally"
 	tchannelgo "github.com/uber/tchannel-go"
 	thriftrwversion "go.uber.org/thriftrw/version"
 	. "go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/middleware"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/api/transport/transporttest"
 	"go.uber.org/yarpc/internal/introspection"
 	"go.uber.org/yarpc/internal/observability"
+	"go.uber.org/yarpc/internal/pally"
+	"go.uber.org/yarpc/internal/sharedmetrics"
 	"go.uber.org/yarpc/transport/http"
 	"go.uber.org/yarpc/transport/tchannel"
 	"go.uber.org/zap"
@@ -359,6 +364,44 @@ func TestInboundMiddleware(t *testing.T) {
 	assert.NotNil(t, mw)
 }
 
+func TestRegisterStreamHandlerAppliesMiddleware(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var called bool
+	dispatcher := NewDispatcher(Config{
+		Name: "test",
+		InboundMiddleware: InboundMiddleware{
+			Stream: middleware.StreamInboundFunc(func(s *transport.ServerStream, h transport.StreamHandler) error {
+				called = true
+				return h.HandleStream(s)
+			}),
+		},
+	})
+
+	handler := transporttest.NewMockStreamHandler(mockCtrl)
+	dispatcher.Register([]transport.Procedure{
+		{
+			Name:        "stream",
+			HandlerSpec: transport.NewStreamHandlerSpec(handler),
+		},
+	})
+
+	spec, err := dispatcher.Router().Choose(context.Background(), &transport.Request{
+		Service:   "test",
+		Procedure: "stream",
+	})
+	require.NoError(t, err)
+	require.Equal(t, transport.Streaming, spec.Type())
+
+	stream, err := transport.NewServerStream(transporttest.NewMockStream(mockCtrl))
+	require.NoError(t, err)
+	handler.EXPECT().HandleStream(stream).Return(nil)
+
+	assert.NoError(t, spec.Stream().HandleStream(stream))
+	assert.True(t, called, "expected stream inbound middleware to be called")
+}
+
 func TestClientConfigWithOutboundServiceNameOverride(t *testing.T) {
 	dispatcher := NewDispatcher(Config{
 		Name: "test",
@@ -404,6 +447,84 @@ func TestObservabilityConfig(t *testing.T) {
 	}
 }
 
+type countingOutboundMiddleware struct {
+	metrics *sharedmetrics.Set
+	calls   *sharedmetrics.CounterVector
+}
+
+func newCountingOutboundMiddleware() *countingOutboundMiddleware {
+	metrics := sharedmetrics.NewSet()
+	return &countingOutboundMiddleware{
+		metrics: metrics,
+		calls: metrics.NewCounterVector(pally.Opts{
+			Name:           "dispatcher_test_component_calls",
+			Help:           "Calls counted by a middleware under test.",
+			VariableLabels: []string{"procedure"},
+		}),
+	}
+}
+
+func (m *countingOutboundMiddleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	m.calls.Inc(req.Procedure)
+	return out.Call(ctx, req)
+}
+
+func (m *countingOutboundMiddleware) Metrics() []*sharedmetrics.Set {
+	return []*sharedmetrics.Set{m.metrics}
+}
+
+func TestComponentMetrics(t *testing.T) {
+	// Gather the total of the component's counter reported by the given
+	// Dispatcher.
+	gather := func(dispatcher string) float64 {
+		families, err := prometheus.DefaultGatherer.Gather()
+		require.NoError(t, err)
+		var total float64
+		for _, family := range families {
+			if family.GetName() != "dispatcher_test_component_calls" {
+				continue
+			}
+			for _, metric := range family.GetMetric() {
+				for _, label := range metric.GetLabel() {
+					if label.GetName() == "dispatcher" && label.GetValue() == dispatcher {
+						total += metric.GetCounter().GetValue()
+					}
+				}
+			}
+		}
+		return total
+	}
+
+	mw := newCountingOutboundMiddleware()
+	mw.calls.Inc("before")
+
+	newDispatcher := func(name string) *Dispatcher {
+		cfg := basicConfig(t)
+		cfg.Name = name
+		cfg.OutboundMiddleware = OutboundMiddleware{Unary: mw}
+		return NewDispatcher(cfg)
+	}
+	first := newDispatcher("component-metrics-first")
+	second := newDispatcher("component-metrics-second")
+	unrelated := NewDispatcher(basicConfig(t))
+	defer unrelated.Stop()
+
+	mw.calls.Inc("after")
+	assert.Equal(t, float64(2), gather("component-metrics-first"),
+		"Dispatchers must report the metrics of their middleware")
+	assert.Equal(t, float64(2), gather("component-metrics-second"),
+		"Dispatchers sharing middleware must each report its metrics once")
+	assert.Equal(t, float64(0), gather("test"),
+		"Dispatchers must not report the metrics of middleware they don't use")
+
+	require.NoError(t, first.Stop())
+	mw.calls.Inc("after")
+	assert.Equal(t, float64(2), gather("component-metrics-first"),
+		"stopped Dispatchers must stop reporting metrics")
+	assert.Equal(t, float64(3), gather("component-metrics-second"))
+	require.NoError(t, second.Stop())
+}
+
 func TestIntrospect(t *testing.T) {
 	httpTransport := http.NewTransport()
 	tchannelChannelTransport, err := tchannel.NewChannelTransport(tchannel.ServiceName("test"), tchannel.ListenAddr(":4040"))
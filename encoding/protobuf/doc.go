@@ -80,6 +80,28 @@
 //     Fire(context.Context, *FireRequest) error
 //   }
 //
+// Streaming methods are supported by transports that provide a
+// transport.StreamOutbound, such as gRPC. For a method declared as
+//
+//   service Qux {
+//     rpc Sync(stream SyncRequest) returns (stream SyncResponse) {}
+//   }
+//
+// QuxYARPCClient will have a method returning a QuxServiceSyncYARPCClient,
+// which can Send SyncRequests, Recv SyncResponses and CloseSend, and
+// QuxYARPCServer will have a method accepting a QuxServiceSyncYARPCServer.
+//
+//   type QuxYARPCClient interface {
+//     Sync(context.Context, ...yarpc.CallOption) (QuxServiceSyncYARPCClient, error)
+//   }
+//
+//   type QuxYARPCServer interface {
+//     Sync(QuxServiceSyncYARPCServer) error
+//   }
+//
+// Client-streaming and server-streaming methods are generated similarly,
+// with CloseAndRecv and Recv-only stream interfaces respectively.
+//
 // Except for any ClientOptions (such as UseJSON), the types and functions
 // defined in this package should not be directly used in applications,
 // instead use the code generated from protoc-gen-yarpc-go.
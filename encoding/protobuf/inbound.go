@@ -93,6 +93,26 @@ func (o *onewayHandler) HandleOneway(ctx context.Context, transportRequest *tran
 	return o.handleOneway(ctx, request)
 }
 
+type streamHandler struct {
+	handle func(*ServerStream) error
+}
+
+func newStreamHandler(handle func(*ServerStream) error) *streamHandler {
+	return &streamHandler{handle}
+}
+
+func (s *streamHandler) HandleStream(stream *transport.ServerStream) error {
+	transportRequest := stream.Request().Meta.ToRequest()
+	if err := errors.ExpectEncodings(transportRequest, Encoding, JSONEncoding); err != nil {
+		return err
+	}
+	ctx, call := apiencoding.NewInboundCall(stream.Context())
+	if err := call.ReadFromRequest(transportRequest); err != nil {
+		return err
+	}
+	return s.handle(newServerStream(ctx, transportRequest, stream))
+}
+
 func getProtoRequest(ctx context.Context, transportRequest *transport.Request, newRequest func() proto.Message) (context.Context, *apiencoding.InboundCall, proto.Message, error) {
 	if err := errors.ExpectEncodings(transportRequest, Encoding, JSONEncoding); err != nil {
 		return nil, nil, nil, err
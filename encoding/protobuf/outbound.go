@@ -98,6 +98,29 @@ func (c *client) CallOneway(
 	return c.clientConfig.GetOnewayOutbound().CallOneway(ctx, transportRequest)
 }
 
+func (c *client) CallStream(
+	ctx context.Context,
+	requestMethodName string,
+	options ...yarpc.CallOption,
+) (*ClientStream, error) {
+	streamClientConfig, ok := c.clientConfig.(transport.StreamClientConfig)
+	if !ok {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeInternal, "client config for service %q does not support streaming", c.clientConfig.Service())
+	}
+	ctx, _, transportRequest, _, err := c.buildTransportRequest(ctx, requestMethodName, nil, options)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := streamClientConfig.GetStreamOutbound().CallStream(
+		ctx,
+		&transport.StreamRequest{Meta: transportRequest.ToRequestMeta()},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return newClientStream(transportRequest, stream), nil
+}
+
 func (c *client) buildTransportRequest(ctx context.Context, requestMethodName string, request proto.Message, options []yarpc.CallOption) (context.Context, *apiencoding.OutboundCall, *transport.Request, func(), error) {
 	transportRequest := &transport.Request{
 		Caller:    c.clientConfig.Caller(),
@@ -52,6 +52,7 @@ type BuildProceduresParams struct {
 	ServiceName         string
 	UnaryHandlerParams  []BuildProceduresUnaryHandlerParams
 	OnewayHandlerParams []BuildProceduresOnewayHandlerParams
+	StreamHandlerParams []BuildProceduresStreamHandlerParams
 }
 
 // BuildProceduresUnaryHandlerParams contains the parameters for a UnaryHandler for BuildProcedures.
@@ -66,9 +67,15 @@ type BuildProceduresOnewayHandlerParams struct {
 	Handler    transport.OnewayHandler
 }
 
+// BuildProceduresStreamHandlerParams contains the parameters for a StreamHandler for BuildProcedures.
+type BuildProceduresStreamHandlerParams struct {
+	MethodName string
+	Handler    transport.StreamHandler
+}
+
 // BuildProcedures builds the transport.Procedures.
 func BuildProcedures(params BuildProceduresParams) []transport.Procedure {
-	procedures := make([]transport.Procedure, 0, 2*(len(params.UnaryHandlerParams)+len(params.OnewayHandlerParams)))
+	procedures := make([]transport.Procedure, 0, 2*(len(params.UnaryHandlerParams)+len(params.OnewayHandlerParams)+len(params.StreamHandlerParams)))
 	for _, unaryHandlerParams := range params.UnaryHandlerParams {
 		procedures = append(
 			procedures,
@@ -99,6 +106,21 @@ func BuildProcedures(params BuildProceduresParams) []transport.Procedure {
 			},
 		)
 	}
+	for _, streamHandlerParams := range params.StreamHandlerParams {
+		procedures = append(
+			procedures,
+			transport.Procedure{
+				Name:        procedure.ToName(params.ServiceName, streamHandlerParams.MethodName),
+				HandlerSpec: transport.NewStreamHandlerSpec(streamHandlerParams.Handler),
+				Encoding:    Encoding,
+			},
+			transport.Procedure{
+				Name:        procedure.ToName(params.ServiceName, streamHandlerParams.MethodName),
+				HandlerSpec: transport.NewStreamHandlerSpec(streamHandlerParams.Handler),
+				Encoding:    JSONEncoding,
+			},
+		)
+	}
 	return procedures
 }
 
@@ -119,6 +141,19 @@ type Client interface {
 	) (transport.Ack, error)
 }
 
+// StreamClient is a protobuf client with streaming capabilities.
+type StreamClient interface {
+	Client
+
+	// CallStream starts a stream for the given method. The ClientConfig
+	// backing the client must provide a transport.StreamOutbound.
+	CallStream(
+		ctx context.Context,
+		requestMethodName string,
+		options ...yarpc.CallOption,
+	) (*ClientStream, error)
+}
+
 // ClientOption is an option for a new Client.
 type ClientOption interface {
 	apply(*client)
@@ -136,6 +171,11 @@ func NewClient(params ClientParams) Client {
 	return newClient(params.ServiceName, params.ClientConfig, params.Options...)
 }
 
+// NewStreamClient creates a new stream client.
+func NewStreamClient(params ClientParams) StreamClient {
+	return newClient(params.ServiceName, params.ClientConfig, params.Options...)
+}
+
 // UnaryHandlerParams contains the parameters for creating a new UnaryHandler.
 type UnaryHandlerParams struct {
 	Handle     func(context.Context, proto.Message) (proto.Message, error)
@@ -158,6 +198,16 @@ func NewOnewayHandler(params OnewayHandlerParams) transport.OnewayHandler {
 	return newOnewayHandler(params.Handle, params.NewRequest)
 }
 
+// StreamHandlerParams contains the parameters for creating a new StreamHandler.
+type StreamHandlerParams struct {
+	Handle func(*ServerStream) error
+}
+
+// NewStreamHandler returns a new StreamHandler.
+func NewStreamHandler(params StreamHandlerParams) transport.StreamHandler {
+	return newStreamHandler(params.Handle)
+}
+
 // ClientBuilderOptions returns ClientOptions that yarpc.InjectClients should use for a
 // specific client given information about the field into which the client is being injected.
 func ClientBuilderOptions(_ transport.ClientConfig, structField reflect.StructField) []ClientOption {
@@ -50,12 +50,39 @@ type {{$service.GetName}}YARPCClient interface {
 	{{range $method := unaryMethods $service}}{{$method.GetName}}(context.Context, *{{$method.RequestType.GoType $packagePath}}, ...yarpc.CallOption) (*{{$method.ResponseType.GoType $packagePath}}, error)
 	{{end}}
 	{{range $method := onewayMethods $service}}{{$method.GetName}}(context.Context, *{{$method.RequestType.GoType $packagePath}}, ...yarpc.CallOption) (yarpc.Ack, error)
+	{{end}}{{range $method := serverStreamingMethods $service}}{{$method.GetName}}(context.Context, *{{$method.RequestType.GoType $packagePath}}, ...yarpc.CallOption) ({{$service.GetName}}Service{{$method.GetName}}YARPCClient, error)
+	{{end}}{{range $method := clientStreamingMethods $service}}{{$method.GetName}}(context.Context, ...yarpc.CallOption) ({{$service.GetName}}Service{{$method.GetName}}YARPCClient, error)
+	{{end}}{{range $method := clientServerStreamingMethods $service}}{{$method.GetName}}(context.Context, ...yarpc.CallOption) ({{$service.GetName}}Service{{$method.GetName}}YARPCClient, error)
 	{{end}}
 }
+{{range $method := serverStreamingMethods $service}}
+// {{$service.GetName}}Service{{$method.GetName}}YARPCClient receives {{$method.ResponseType.GoType $packagePath}}s.
+type {{$service.GetName}}Service{{$method.GetName}}YARPCClient interface {
+	Context() context.Context
+	Recv() (*{{$method.ResponseType.GoType $packagePath}}, error)
+}
+{{end}}
+{{range $method := clientStreamingMethods $service}}
+// {{$service.GetName}}Service{{$method.GetName}}YARPCClient sends {{$method.RequestType.GoType $packagePath}}s and receives the single {{$method.ResponseType.GoType $packagePath}} when sending is done.
+type {{$service.GetName}}Service{{$method.GetName}}YARPCClient interface {
+	Context() context.Context
+	Send(*{{$method.RequestType.GoType $packagePath}}) error
+	CloseAndRecv() (*{{$method.ResponseType.GoType $packagePath}}, error)
+}
+{{end}}
+{{range $method := clientServerStreamingMethods $service}}
+// {{$service.GetName}}Service{{$method.GetName}}YARPCClient sends {{$method.RequestType.GoType $packagePath}}s and receives {{$method.ResponseType.GoType $packagePath}}s.
+type {{$service.GetName}}Service{{$method.GetName}}YARPCClient interface {
+	Context() context.Context
+	Send(*{{$method.RequestType.GoType $packagePath}}) error
+	Recv() (*{{$method.ResponseType.GoType $packagePath}}, error)
+	CloseSend() error
+}
+{{end}}
 
 // New{{$service.GetName}}YARPCClient builds a new YARPC client for the {{$service.GetName}} service.
 func New{{$service.GetName}}YARPCClient(clientConfig transport.ClientConfig, options ...protobuf.ClientOption) {{$service.GetName}}YARPCClient {
-	return &_{{$service.GetName}}YARPCCaller{protobuf.NewClient(
+	return &_{{$service.GetName}}YARPCCaller{protobuf.NewStreamClient(
 		protobuf.ClientParams{
 			ServiceName: "{{trimPrefixPeriod $service.FQSN}}",
 			ClientConfig: clientConfig,
@@ -69,8 +96,33 @@ type {{$service.GetName}}YARPCServer interface {
 	{{range $method := unaryMethods $service}}{{$method.GetName}}(context.Context, *{{$method.RequestType.GoType $packagePath}}) (*{{$method.ResponseType.GoType $packagePath}}, error)
 	{{end}}
 	{{range $method := onewayMethods $service}}{{$method.GetName}}(context.Context, *{{$method.RequestType.GoType $packagePath}}) error
+	{{end}}{{range $method := serverStreamingMethods $service}}{{$method.GetName}}(*{{$method.RequestType.GoType $packagePath}}, {{$service.GetName}}Service{{$method.GetName}}YARPCServer) error
+	{{end}}{{range $method := clientStreamingMethods $service}}{{$method.GetName}}({{$service.GetName}}Service{{$method.GetName}}YARPCServer) (*{{$method.ResponseType.GoType $packagePath}}, error)
+	{{end}}{{range $method := clientServerStreamingMethods $service}}{{$method.GetName}}({{$service.GetName}}Service{{$method.GetName}}YARPCServer) error
 	{{end}}
 }
+{{range $method := serverStreamingMethods $service}}
+// {{$service.GetName}}Service{{$method.GetName}}YARPCServer sends {{$method.ResponseType.GoType $packagePath}}s.
+type {{$service.GetName}}Service{{$method.GetName}}YARPCServer interface {
+	Context() context.Context
+	Send(*{{$method.ResponseType.GoType $packagePath}}) error
+}
+{{end}}
+{{range $method := clientStreamingMethods $service}}
+// {{$service.GetName}}Service{{$method.GetName}}YARPCServer receives {{$method.RequestType.GoType $packagePath}}s.
+type {{$service.GetName}}Service{{$method.GetName}}YARPCServer interface {
+	Context() context.Context
+	Recv() (*{{$method.RequestType.GoType $packagePath}}, error)
+}
+{{end}}
+{{range $method := clientServerStreamingMethods $service}}
+// {{$service.GetName}}Service{{$method.GetName}}YARPCServer receives {{$method.RequestType.GoType $packagePath}}s and sends {{$method.ResponseType.GoType $packagePath}}s.
+type {{$service.GetName}}Service{{$method.GetName}}YARPCServer interface {
+	Context() context.Context
+	Recv() (*{{$method.RequestType.GoType $packagePath}}, error)
+	Send(*{{$method.ResponseType.GoType $packagePath}}) error
+}
+{{end}}
 
 // Build{{$service.GetName}}YARPCProcedures prepares an implementation of the {{$service.GetName}} service for YARPC registration.
 func Build{{$service.GetName}}YARPCProcedures(server {{$service.GetName}}YARPCServer) []transport.Procedure {
@@ -102,17 +154,60 @@ func Build{{$service.GetName}}YARPCProcedures(server {{$service.GetName}}YARPCSe
 				},
 			{{end}}
 			},
+			StreamHandlerParams: []protobuf.BuildProceduresStreamHandlerParams{
+			{{range $method := streamMethods $service}}{
+					MethodName: "{{$method.GetName}}",
+					Handler: protobuf.NewStreamHandler(
+						protobuf.StreamHandlerParams{
+							Handle: handler.{{$method.GetName}},
+						},
+					),
+				},
+			{{end}}
+			},
 		},
 	)
 }
 
+// Fx{{$service.GetName}}YARPCClientParams defines the input for
+// NewFx{{$service.GetName}}YARPCClient.
+type Fx{{$service.GetName}}YARPCClientParams struct {
+	fx.In
+
+	Provider yarpc.ClientConfig
+}
+
+// Fx{{$service.GetName}}YARPCClientResult defines the output of
+// NewFx{{$service.GetName}}YARPCClient. It provides a
+// {{$service.GetName}}YARPCClient to an Fx application.
+type Fx{{$service.GetName}}YARPCClientResult struct {
+	fx.Out
+
+	Client {{$service.GetName}}YARPCClient
+
+	// We are using an fx.Out struct here instead of just returning a client
+	// so that we can add more values or add named versions of the client in
+	// the future without breaking any existing code.
+}
+
+// NewFx{{$service.GetName}}YARPCClient provides a {{$service.GetName}}YARPCClient
+// to an Fx application using the given name for routing. The returned value
+// should be passed to fx.Provide.
+func NewFx{{$service.GetName}}YARPCClient(name string, options ...protobuf.ClientOption) interface{} {
+	return func(params Fx{{$service.GetName}}YARPCClientParams) Fx{{$service.GetName}}YARPCClientResult {
+		return Fx{{$service.GetName}}YARPCClientResult{
+			Client: New{{$service.GetName}}YARPCClient(params.Provider.ClientConfig(name), options...),
+		}
+	}
+}
+
 type _{{$service.GetName}}YARPCCaller struct {
-	client protobuf.Client
+	streamClient protobuf.StreamClient
 }
 
 {{range $method := unaryMethods $service}}
 func (c *_{{$service.GetName}}YARPCCaller) {{$method.GetName}}(ctx context.Context, request *{{$method.RequestType.GoType $packagePath}}, options ...yarpc.CallOption) (*{{$method.ResponseType.GoType $packagePath}}, error) {
-	responseMessage, err := c.client.Call(ctx, "{{$method.GetName}}", request, new{{$service.GetName}}_{{$method.GetName}}YARPCResponse, options...)
+	responseMessage, err := c.streamClient.Call(ctx, "{{$method.GetName}}", request, new{{$service.GetName}}_{{$method.GetName}}YARPCResponse, options...)
 	if responseMessage == nil {
 		return nil, err
 	}
@@ -125,7 +220,40 @@ func (c *_{{$service.GetName}}YARPCCaller) {{$method.GetName}}(ctx context.Conte
 {{end}}
 {{range $method := onewayMethods $service}}
 func (c *_{{$service.GetName}}YARPCCaller) {{$method.GetName}}(ctx context.Context, request *{{$method.RequestType.GoType $packagePath}}, options ...yarpc.CallOption) (yarpc.Ack, error) {
-	return c.client.CallOneway(ctx, "{{$method.GetName}}", request, options...)
+	return c.streamClient.CallOneway(ctx, "{{$method.GetName}}", request, options...)
+}
+{{end}}
+{{range $method := serverStreamingMethods $service}}
+func (c *_{{$service.GetName}}YARPCCaller) {{$method.GetName}}(ctx context.Context, request *{{$method.RequestType.GoType $packagePath}}, options ...yarpc.CallOption) ({{$service.GetName}}Service{{$method.GetName}}YARPCClient, error) {
+	stream, err := c.streamClient.CallStream(ctx, "{{$method.GetName}}", options...)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.Send(request); err != nil {
+		return nil, err
+	}
+	if err := stream.Close(); err != nil {
+		return nil, err
+	}
+	return &_{{$service.GetName}}Service{{$method.GetName}}YARPCClient{stream: stream}, nil
+}
+{{end}}
+{{range $method := clientStreamingMethods $service}}
+func (c *_{{$service.GetName}}YARPCCaller) {{$method.GetName}}(ctx context.Context, options ...yarpc.CallOption) ({{$service.GetName}}Service{{$method.GetName}}YARPCClient, error) {
+	stream, err := c.streamClient.CallStream(ctx, "{{$method.GetName}}", options...)
+	if err != nil {
+		return nil, err
+	}
+	return &_{{$service.GetName}}Service{{$method.GetName}}YARPCClient{stream: stream}, nil
+}
+{{end}}
+{{range $method := clientServerStreamingMethods $service}}
+func (c *_{{$service.GetName}}YARPCCaller) {{$method.GetName}}(ctx context.Context, options ...yarpc.CallOption) ({{$service.GetName}}Service{{$method.GetName}}YARPCClient, error) {
+	stream, err := c.streamClient.CallStream(ctx, "{{$method.GetName}}", options...)
+	if err != nil {
+		return nil, err
+	}
+	return &_{{$service.GetName}}Service{{$method.GetName}}YARPCClient{stream: stream}, nil
 }
 {{end}}
 
@@ -163,6 +291,167 @@ func (h *_{{$service.GetName}}YARPCHandler) {{$method.GetName}}(ctx context.Cont
 	return h.server.{{$method.GetName}}(ctx, request)
 }
 {{end}}
+{{range $method := serverStreamingMethods $service}}
+func (h *_{{$service.GetName}}YARPCHandler) {{$method.GetName}}(serverStream *protobuf.ServerStream) error {
+	requestMessage, err := serverStream.Receive(new{{$service.GetName}}_{{$method.GetName}}YARPCRequest)
+	if requestMessage == nil {
+		return err
+	}
+	request, ok := requestMessage.(*{{$method.RequestType.GoType $packagePath}})
+	if !ok {
+		return protobuf.CastError(empty{{$service.GetName}}_{{$method.GetName}}YARPCRequest, requestMessage)
+	}
+	return h.server.{{$method.GetName}}(request, &_{{$service.GetName}}Service{{$method.GetName}}YARPCServer{serverStream: serverStream})
+}
+{{end}}
+{{range $method := clientStreamingMethods $service}}
+func (h *_{{$service.GetName}}YARPCHandler) {{$method.GetName}}(serverStream *protobuf.ServerStream) error {
+	response, err := h.server.{{$method.GetName}}(&_{{$service.GetName}}Service{{$method.GetName}}YARPCServer{serverStream: serverStream})
+	if err != nil {
+		return err
+	}
+	return serverStream.Send(response)
+}
+{{end}}
+{{range $method := clientServerStreamingMethods $service}}
+func (h *_{{$service.GetName}}YARPCHandler) {{$method.GetName}}(serverStream *protobuf.ServerStream) error {
+	return h.server.{{$method.GetName}}(&_{{$service.GetName}}Service{{$method.GetName}}YARPCServer{serverStream: serverStream})
+}
+{{end}}
+{{range $method := serverStreamingMethods $service}}
+type _{{$service.GetName}}Service{{$method.GetName}}YARPCClient struct {
+	stream *protobuf.ClientStream
+}
+
+func (c *_{{$service.GetName}}Service{{$method.GetName}}YARPCClient) Context() context.Context {
+	return c.stream.Context()
+}
+
+func (c *_{{$service.GetName}}Service{{$method.GetName}}YARPCClient) Recv() (*{{$method.ResponseType.GoType $packagePath}}, error) {
+	responseMessage, err := c.stream.Receive(new{{$service.GetName}}_{{$method.GetName}}YARPCResponse)
+	if responseMessage == nil {
+		return nil, err
+	}
+	response, ok := responseMessage.(*{{$method.ResponseType.GoType $packagePath}})
+	if !ok {
+		return nil, protobuf.CastError(empty{{$service.GetName}}_{{$method.GetName}}YARPCResponse, responseMessage)
+	}
+	return response, err
+}
+
+type _{{$service.GetName}}Service{{$method.GetName}}YARPCServer struct {
+	serverStream *protobuf.ServerStream
+}
+
+func (s *_{{$service.GetName}}Service{{$method.GetName}}YARPCServer) Context() context.Context {
+	return s.serverStream.Context()
+}
+
+func (s *_{{$service.GetName}}Service{{$method.GetName}}YARPCServer) Send(response *{{$method.ResponseType.GoType $packagePath}}) error {
+	return s.serverStream.Send(response)
+}
+{{end}}
+{{range $method := clientStreamingMethods $service}}
+type _{{$service.GetName}}Service{{$method.GetName}}YARPCClient struct {
+	stream *protobuf.ClientStream
+}
+
+func (c *_{{$service.GetName}}Service{{$method.GetName}}YARPCClient) Context() context.Context {
+	return c.stream.Context()
+}
+
+func (c *_{{$service.GetName}}Service{{$method.GetName}}YARPCClient) Send(request *{{$method.RequestType.GoType $packagePath}}) error {
+	return c.stream.Send(request)
+}
+
+func (c *_{{$service.GetName}}Service{{$method.GetName}}YARPCClient) CloseAndRecv() (*{{$method.ResponseType.GoType $packagePath}}, error) {
+	if err := c.stream.Close(); err != nil {
+		return nil, err
+	}
+	responseMessage, err := c.stream.Receive(new{{$service.GetName}}_{{$method.GetName}}YARPCResponse)
+	if responseMessage == nil {
+		return nil, err
+	}
+	response, ok := responseMessage.(*{{$method.ResponseType.GoType $packagePath}})
+	if !ok {
+		return nil, protobuf.CastError(empty{{$service.GetName}}_{{$method.GetName}}YARPCResponse, responseMessage)
+	}
+	return response, err
+}
+
+type _{{$service.GetName}}Service{{$method.GetName}}YARPCServer struct {
+	serverStream *protobuf.ServerStream
+}
+
+func (s *_{{$service.GetName}}Service{{$method.GetName}}YARPCServer) Context() context.Context {
+	return s.serverStream.Context()
+}
+
+func (s *_{{$service.GetName}}Service{{$method.GetName}}YARPCServer) Recv() (*{{$method.RequestType.GoType $packagePath}}, error) {
+	requestMessage, err := s.serverStream.Receive(new{{$service.GetName}}_{{$method.GetName}}YARPCRequest)
+	if requestMessage == nil {
+		return nil, err
+	}
+	request, ok := requestMessage.(*{{$method.RequestType.GoType $packagePath}})
+	if !ok {
+		return nil, protobuf.CastError(empty{{$service.GetName}}_{{$method.GetName}}YARPCRequest, requestMessage)
+	}
+	return request, err
+}
+{{end}}
+{{range $method := clientServerStreamingMethods $service}}
+type _{{$service.GetName}}Service{{$method.GetName}}YARPCClient struct {
+	stream *protobuf.ClientStream
+}
+
+func (c *_{{$service.GetName}}Service{{$method.GetName}}YARPCClient) Context() context.Context {
+	return c.stream.Context()
+}
+
+func (c *_{{$service.GetName}}Service{{$method.GetName}}YARPCClient) Send(request *{{$method.RequestType.GoType $packagePath}}) error {
+	return c.stream.Send(request)
+}
+
+func (c *_{{$service.GetName}}Service{{$method.GetName}}YARPCClient) Recv() (*{{$method.ResponseType.GoType $packagePath}}, error) {
+	responseMessage, err := c.stream.Receive(new{{$service.GetName}}_{{$method.GetName}}YARPCResponse)
+	if responseMessage == nil {
+		return nil, err
+	}
+	response, ok := responseMessage.(*{{$method.ResponseType.GoType $packagePath}})
+	if !ok {
+		return nil, protobuf.CastError(empty{{$service.GetName}}_{{$method.GetName}}YARPCResponse, responseMessage)
+	}
+	return response, err
+}
+
+func (c *_{{$service.GetName}}Service{{$method.GetName}}YARPCClient) CloseSend() error {
+	return c.stream.Close()
+}
+
+type _{{$service.GetName}}Service{{$method.GetName}}YARPCServer struct {
+	serverStream *protobuf.ServerStream
+}
+
+func (s *_{{$service.GetName}}Service{{$method.GetName}}YARPCServer) Context() context.Context {
+	return s.serverStream.Context()
+}
+
+func (s *_{{$service.GetName}}Service{{$method.GetName}}YARPCServer) Recv() (*{{$method.RequestType.GoType $packagePath}}, error) {
+	requestMessage, err := s.serverStream.Receive(new{{$service.GetName}}_{{$method.GetName}}YARPCRequest)
+	if requestMessage == nil {
+		return nil, err
+	}
+	request, ok := requestMessage.(*{{$method.RequestType.GoType $packagePath}})
+	if !ok {
+		return nil, protobuf.CastError(empty{{$service.GetName}}_{{$method.GetName}}YARPCRequest, requestMessage)
+	}
+	return request, err
+}
+
+func (s *_{{$service.GetName}}Service{{$method.GetName}}YARPCServer) Send(response *{{$method.ResponseType.GoType $packagePath}}) error {
+	return s.serverStream.Send(response)
+}
+{{end}}
 
 {{range $method := $service.Methods}}
 func new{{$service.GetName}}_{{$method.GetName}}YARPCRequest() proto.Message {
@@ -192,15 +481,20 @@ var (
 var Runner = protoplugin.NewRunner(
 	template.Must(template.New("tmpl").Funcs(
 		template.FuncMap{
-			"unaryMethods":     unaryMethods,
-			"onewayMethods":    onewayMethods,
-			"trimPrefixPeriod": trimPrefixPeriod,
+			"unaryMethods":                 unaryMethods,
+			"onewayMethods":                onewayMethods,
+			"streamMethods":                streamMethods,
+			"clientStreamingMethods":       clientStreamingMethods,
+			"serverStreamingMethods":       serverStreamingMethods,
+			"clientServerStreamingMethods": clientServerStreamingMethods,
+			"trimPrefixPeriod":             trimPrefixPeriod,
 		}).Parse(tmpl)),
 	checkTemplateInfo,
 	[]string{
 		"context",
 		"reflect",
 		"github.com/gogo/protobuf/proto",
+		"go.uber.org/fx",
 		"go.uber.org/yarpc",
 		"go.uber.org/yarpc/api/transport",
 		"go.uber.org/yarpc/encoding/protobuf",
@@ -211,8 +505,8 @@ var Runner = protoplugin.NewRunner(
 func checkTemplateInfo(templateInfo *protoplugin.TemplateInfo) error {
 	for _, service := range templateInfo.Services {
 		for _, method := range service.Methods {
-			if method.GetClientStreaming() || method.GetServerStreaming() {
-				return fmt.Errorf("yarpc does not support streaming methods and %s:%s is a streaming method", service.GetName(), method.GetName())
+			if isStreaming(method) && isOneway(method) {
+				return fmt.Errorf("yarpc does not support oneway streaming methods and %s:%s is a oneway streaming method", service.GetName(), method.GetName())
 			}
 		}
 	}
@@ -220,23 +514,55 @@ func checkTemplateInfo(templateInfo *protoplugin.TemplateInfo) error {
 }
 
 func unaryMethods(service *protoplugin.Service) ([]*protoplugin.Method, error) {
-	methods := make([]*protoplugin.Method, 0, len(service.Methods))
-	for _, method := range service.Methods {
-		if !method.GetClientStreaming() && !method.GetServerStreaming() && method.ResponseType.FQMN() != ".uber.yarpc.Oneway" {
-			methods = append(methods, method)
-		}
-	}
-	return methods, nil
+	return filterMethods(service, func(method *protoplugin.Method) bool {
+		return !isStreaming(method) && !isOneway(method)
+	}), nil
 }
 
 func onewayMethods(service *protoplugin.Service) ([]*protoplugin.Method, error) {
+	return filterMethods(service, func(method *protoplugin.Method) bool {
+		return !isStreaming(method) && isOneway(method)
+	}), nil
+}
+
+func streamMethods(service *protoplugin.Service) ([]*protoplugin.Method, error) {
+	return filterMethods(service, isStreaming), nil
+}
+
+func clientStreamingMethods(service *protoplugin.Service) ([]*protoplugin.Method, error) {
+	return filterMethods(service, func(method *protoplugin.Method) bool {
+		return method.GetClientStreaming() && !method.GetServerStreaming()
+	}), nil
+}
+
+func serverStreamingMethods(service *protoplugin.Service) ([]*protoplugin.Method, error) {
+	return filterMethods(service, func(method *protoplugin.Method) bool {
+		return !method.GetClientStreaming() && method.GetServerStreaming()
+	}), nil
+}
+
+func clientServerStreamingMethods(service *protoplugin.Service) ([]*protoplugin.Method, error) {
+	return filterMethods(service, func(method *protoplugin.Method) bool {
+		return method.GetClientStreaming() && method.GetServerStreaming()
+	}), nil
+}
+
+func filterMethods(service *protoplugin.Service, f func(*protoplugin.Method) bool) []*protoplugin.Method {
 	methods := make([]*protoplugin.Method, 0, len(service.Methods))
 	for _, method := range service.Methods {
-		if !method.GetClientStreaming() && !method.GetServerStreaming() && method.ResponseType.FQMN() == ".uber.yarpc.Oneway" {
+		if f(method) {
 			methods = append(methods, method)
 		}
 	}
-	return methods, nil
+	return methods
+}
+
+func isStreaming(method *protoplugin.Method) bool {
+	return method.GetClientStreaming() || method.GetServerStreaming()
+}
+
+func isOneway(method *protoplugin.Method) bool {
+	return method.ResponseType.FQMN() == ".uber.yarpc.Oneway"
 }
 
 func trimPrefixPeriod(s string) string {
@@ -523,6 +523,229 @@ var _Sink_serviceDesc = grpc.ServiceDesc{
 	Metadata: "encoding/protobuf/protoc-gen-yarpc-go/internal/testing/testing.proto",
 }
 
+// Client API for KeyValueStreaming service
+
+type KeyValueStreamingClient interface {
+	WatchValue(ctx context.Context, in *GetValueRequest, opts ...grpc.CallOption) (KeyValueStreaming_WatchValueClient, error)
+	SetValues(ctx context.Context, opts ...grpc.CallOption) (KeyValueStreaming_SetValuesClient, error)
+	SyncValues(ctx context.Context, opts ...grpc.CallOption) (KeyValueStreaming_SyncValuesClient, error)
+}
+
+type keyValueStreamingClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewKeyValueStreamingClient(cc *grpc.ClientConn) KeyValueStreamingClient {
+	return &keyValueStreamingClient{cc}
+}
+
+func (c *keyValueStreamingClient) WatchValue(ctx context.Context, in *GetValueRequest, opts ...grpc.CallOption) (KeyValueStreaming_WatchValueClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_KeyValueStreaming_serviceDesc.Streams[0], c.cc, "/uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.KeyValueStreaming/WatchValue", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &keyValueStreamingWatchValueClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type KeyValueStreaming_WatchValueClient interface {
+	Recv() (*GetValueResponse, error)
+	grpc.ClientStream
+}
+
+type keyValueStreamingWatchValueClient struct {
+	grpc.ClientStream
+}
+
+func (x *keyValueStreamingWatchValueClient) Recv() (*GetValueResponse, error) {
+	m := new(GetValueResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *keyValueStreamingClient) SetValues(ctx context.Context, opts ...grpc.CallOption) (KeyValueStreaming_SetValuesClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_KeyValueStreaming_serviceDesc.Streams[1], c.cc, "/uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.KeyValueStreaming/SetValues", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &keyValueStreamingSetValuesClient{stream}
+	return x, nil
+}
+
+type KeyValueStreaming_SetValuesClient interface {
+	Send(*SetValueRequest) error
+	CloseAndRecv() (*SetValueResponse, error)
+	grpc.ClientStream
+}
+
+type keyValueStreamingSetValuesClient struct {
+	grpc.ClientStream
+}
+
+func (x *keyValueStreamingSetValuesClient) Send(m *SetValueRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *keyValueStreamingSetValuesClient) CloseAndRecv() (*SetValueResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(SetValueResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *keyValueStreamingClient) SyncValues(ctx context.Context, opts ...grpc.CallOption) (KeyValueStreaming_SyncValuesClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_KeyValueStreaming_serviceDesc.Streams[2], c.cc, "/uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.KeyValueStreaming/SyncValues", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &keyValueStreamingSyncValuesClient{stream}
+	return x, nil
+}
+
+type KeyValueStreaming_SyncValuesClient interface {
+	Send(*SetValueRequest) error
+	Recv() (*GetValueResponse, error)
+	grpc.ClientStream
+}
+
+type keyValueStreamingSyncValuesClient struct {
+	grpc.ClientStream
+}
+
+func (x *keyValueStreamingSyncValuesClient) Send(m *SetValueRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *keyValueStreamingSyncValuesClient) Recv() (*GetValueResponse, error) {
+	m := new(GetValueResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Server API for KeyValueStreaming service
+
+type KeyValueStreamingServer interface {
+	WatchValue(*GetValueRequest, KeyValueStreaming_WatchValueServer) error
+	SetValues(KeyValueStreaming_SetValuesServer) error
+	SyncValues(KeyValueStreaming_SyncValuesServer) error
+}
+
+func RegisterKeyValueStreamingServer(s *grpc.Server, srv KeyValueStreamingServer) {
+	s.RegisterService(&_KeyValueStreaming_serviceDesc, srv)
+}
+
+func _KeyValueStreaming_WatchValue_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetValueRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KeyValueStreamingServer).WatchValue(m, &keyValueStreamingWatchValueServer{stream})
+}
+
+type KeyValueStreaming_WatchValueServer interface {
+	Send(*GetValueResponse) error
+	grpc.ServerStream
+}
+
+type keyValueStreamingWatchValueServer struct {
+	grpc.ServerStream
+}
+
+func (x *keyValueStreamingWatchValueServer) Send(m *GetValueResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _KeyValueStreaming_SetValues_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(KeyValueStreamingServer).SetValues(&keyValueStreamingSetValuesServer{stream})
+}
+
+type KeyValueStreaming_SetValuesServer interface {
+	SendAndClose(*SetValueResponse) error
+	Recv() (*SetValueRequest, error)
+	grpc.ServerStream
+}
+
+type keyValueStreamingSetValuesServer struct {
+	grpc.ServerStream
+}
+
+func (x *keyValueStreamingSetValuesServer) SendAndClose(m *SetValueResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *keyValueStreamingSetValuesServer) Recv() (*SetValueRequest, error) {
+	m := new(SetValueRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _KeyValueStreaming_SyncValues_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(KeyValueStreamingServer).SyncValues(&keyValueStreamingSyncValuesServer{stream})
+}
+
+type KeyValueStreaming_SyncValuesServer interface {
+	Send(*GetValueResponse) error
+	Recv() (*SetValueRequest, error)
+	grpc.ServerStream
+}
+
+type keyValueStreamingSyncValuesServer struct {
+	grpc.ServerStream
+}
+
+func (x *keyValueStreamingSyncValuesServer) Send(m *GetValueResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *keyValueStreamingSyncValuesServer) Recv() (*SetValueRequest, error) {
+	m := new(SetValueRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _KeyValueStreaming_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.KeyValueStreaming",
+	HandlerType: (*KeyValueStreamingServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchValue",
+			Handler:       _KeyValueStreaming_WatchValue_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SetValues",
+			Handler:       _KeyValueStreaming_SetValues_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "SyncValues",
+			Handler:       _KeyValueStreaming_SyncValues_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "encoding/protobuf/protoc-gen-yarpc-go/internal/testing/testing.proto",
+}
+
 func (m *GetValueRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -1296,28 +1519,31 @@ func init() {
 }
 
 var fileDescriptorTesting = []byte{
-	// 355 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x91, 0x3f, 0x4f, 0xc2, 0x40,
-	0x18, 0xc6, 0x7b, 0x88, 0x8a, 0xe7, 0x00, 0xb9, 0x38, 0x10, 0x86, 0x8b, 0x29, 0x0b, 0x0b, 0xd7,
-	0x04, 0xe3, 0xe0, 0xe2, 0x60, 0x8c, 0x0e, 0x0c, 0x18, 0x9a, 0x38, 0xb8, 0x95, 0xfa, 0x7a, 0x69,
-	0x20, 0x77, 0xd8, 0x3f, 0x9a, 0x6e, 0x7e, 0x04, 0x3f, 0x86, 0x8b, 0xdf, 0xc3, 0x91, 0xd1, 0x51,
-	0xce, 0xc5, 0x38, 0xf1, 0x11, 0x4c, 0xef, 0x5a, 0x24, 0x24, 0xc6, 0x81, 0x2e, 0xed, 0xd3, 0xe6,
-	0x79, 0x7e, 0xef, 0x93, 0xf7, 0xc5, 0xe7, 0x20, 0x7c, 0x79, 0x1b, 0x08, 0xee, 0x4c, 0x43, 0x19,
-	0xcb, 0x51, 0x72, 0x67, 0x84, 0xdf, 0xe5, 0x20, 0xba, 0xa9, 0x17, 0x4e, 0xfd, 0x2e, 0x97, 0x4e,
-	0x20, 0x62, 0x08, 0x85, 0x37, 0x71, 0x62, 0x88, 0xe2, 0xcc, 0x9d, 0xbf, 0x99, 0x36, 0x93, 0xd3,
-	0x64, 0x04, 0x21, 0xd3, 0x6e, 0x56, 0x00, 0x59, 0x01, 0x34, 0xc2, 0xe7, 0x20, 0xb4, 0x81, 0x4b,
-	0x56, 0xd0, 0x58, 0x4e, 0x69, 0x75, 0xb8, 0x64, 0x1a, 0x21, 0x43, 0xee, 0x68, 0x97, 0x79, 0xea,
-	0xa4, 0x91, 0x86, 0x62, 0xb7, 0x71, 0xfd, 0x12, 0xe2, 0x6b, 0x6f, 0x92, 0xc0, 0x10, 0xee, 0x13,
-	0x88, 0x62, 0xd2, 0xc0, 0x5b, 0x63, 0x48, 0x9b, 0xe8, 0x10, 0x75, 0xf6, 0x86, 0x99, 0xb4, 0x3b,
-	0xb8, 0xf1, 0x6b, 0x8a, 0xa6, 0x52, 0x44, 0x40, 0x0e, 0xf0, 0xf6, 0x43, 0xf6, 0xa3, 0x59, 0xd1,
-	0x3e, 0xf3, 0x61, 0x9f, 0xe0, 0xba, 0xfb, 0x1f, 0xee, 0x8f, 0x28, 0xc1, 0x0d, 0x77, 0x6d, 0x88,
-	0xdd, 0xc6, 0xfb, 0x17, 0x41, 0xb8, 0x44, 0x2d, 0x83, 0x68, 0x25, 0xd8, 0xfb, 0xae, 0xe0, 0x5a,
-	0x1f, 0x52, 0x9d, 0x24, 0xaf, 0x08, 0xd7, 0x8a, 0xae, 0x64, 0xc0, 0x36, 0xdb, 0x23, 0x5b, 0x5b,
-	0x4d, 0xeb, 0xaa, 0x3c, 0x60, 0xbe, 0xc6, 0xac, 0xaf, 0x5b, 0x5a, 0x5f, 0xb7, 0xec, 0xbe, 0xeb,
-	0x17, 0xe9, 0x49, 0x5c, 0x75, 0x03, 0x31, 0x26, 0x1c, 0x57, 0xb3, 0xcb, 0x90, 0xfe, 0xa6, 0x13,
-	0x56, 0xee, 0xdb, 0x22, 0xab, 0xb0, 0x81, 0x80, 0x47, 0x2f, 0x3d, 0x3b, 0x9e, 0xcd, 0xa9, 0xf5,
-	0x3e, 0xa7, 0xd6, 0x62, 0x4e, 0xd1, 0x93, 0xa2, 0xe8, 0x45, 0x51, 0xf4, 0xa6, 0x28, 0x9a, 0x29,
-	0x8a, 0x3e, 0x14, 0x45, 0x5f, 0x8a, 0x5a, 0x0b, 0x45, 0xd1, 0xf3, 0x27, 0xb5, 0x6e, 0x76, 0x73,
-	0xec, 0x68, 0x47, 0x4f, 0x3e, 0xfa, 0x09, 0x00, 0x00, 0xff, 0xff, 0x9c, 0x67, 0x4c, 0x99, 0x90,
-	0x03, 0x00, 0x00,
+	// 409 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x91, 0x31, 0xcf, 0xd2, 0x50,
+	0x14, 0x86, 0x7b, 0x10, 0x15, 0x8e, 0x03, 0x78, 0xe3, 0x40, 0x18, 0x6e, 0x4c, 0x59, 0xba, 0x70,
+	0x4b, 0x30, 0x0e, 0x2e, 0x0e, 0xc6, 0xc8, 0xc0, 0x80, 0xa1, 0x89, 0x26, 0x6e, 0xa5, 0x5e, 0xaf,
+	0x0d, 0x78, 0x2f, 0xb6, 0xb7, 0x9a, 0x6e, 0xfe, 0x04, 0x7f, 0x86, 0x8b, 0x09, 0xc6, 0x3f, 0xe1,
+	0xc8, 0xe8, 0x28, 0x75, 0x31, 0x4e, 0xfc, 0x04, 0xd3, 0xdb, 0x16, 0x09, 0x5f, 0xbe, 0x7c, 0x03,
+	0x0c, 0x1f, 0x4b, 0x7b, 0xda, 0xbc, 0xef, 0x73, 0xde, 0x9c, 0x17, 0x9f, 0x72, 0x19, 0xa8, 0xd7,
+	0xa1, 0x14, 0xee, 0x32, 0x52, 0x5a, 0xcd, 0x92, 0x37, 0xc5, 0x10, 0xf4, 0x05, 0x97, 0xfd, 0xd4,
+	0x8f, 0x96, 0x41, 0x5f, 0x28, 0x37, 0x94, 0x9a, 0x47, 0xd2, 0x5f, 0xb8, 0x9a, 0xc7, 0x3a, 0x57,
+	0x97, 0x6f, 0x66, 0xc4, 0xe4, 0x71, 0x32, 0xe3, 0x11, 0x33, 0x6a, 0x56, 0x01, 0x59, 0x05, 0x2c,
+	0x86, 0x40, 0x70, 0x69, 0x04, 0x42, 0xb1, 0x8a, 0xc6, 0x4a, 0x4a, 0xd7, 0x11, 0x8a, 0x19, 0x84,
+	0x8a, 0x84, 0x6b, 0x54, 0xc5, 0xd3, 0x38, 0x8b, 0xb1, 0xa0, 0xd8, 0x3d, 0x6c, 0x8d, 0xb8, 0x7e,
+	0xe1, 0x2f, 0x12, 0x3e, 0xe5, 0xef, 0x13, 0x1e, 0x6b, 0xd2, 0xc6, 0x1b, 0x73, 0x9e, 0x76, 0xe0,
+	0x3e, 0x38, 0xcd, 0x69, 0x3e, 0xda, 0x0e, 0xb6, 0xff, 0x8b, 0xe2, 0xa5, 0x92, 0x31, 0x27, 0xf7,
+	0xf0, 0xe6, 0x87, 0xfc, 0x47, 0xa7, 0x66, 0x74, 0xc5, 0x87, 0xfd, 0x08, 0x5b, 0xde, 0x55, 0xb8,
+	0x4b, 0xac, 0x04, 0xdb, 0xde, 0xc1, 0x12, 0xbb, 0x87, 0x77, 0x9e, 0x85, 0xd1, 0x0e, 0xb5, 0x33,
+	0xc2, 0x9e, 0x71, 0xf8, 0xb7, 0x86, 0x8d, 0x31, 0x4f, 0x8d, 0x93, 0x7c, 0x05, 0x6c, 0x54, 0x59,
+	0xc9, 0x84, 0x1d, 0x77, 0x47, 0x76, 0x70, 0x9a, 0xee, 0xf3, 0xd3, 0x01, 0xcb, 0x33, 0xe6, 0x79,
+	0xbd, 0x93, 0xe5, 0xf5, 0x4e, 0x9d, 0xf7, 0xb0, 0x91, 0xa1, 0xc2, 0xba, 0x17, 0xca, 0x39, 0x11,
+	0x58, 0xcf, 0x9b, 0x21, 0xe3, 0x63, 0x37, 0xec, 0xf5, 0xdb, 0x25, 0xfb, 0xb0, 0x89, 0xe4, 0x1f,
+	0xfd, 0x74, 0xb8, 0xaa, 0xe3, 0xdd, 0xaa, 0x5d, 0x4f, 0x47, 0xdc, 0x7f, 0x17, 0x4a, 0x41, 0xbe,
+	0x01, 0xe2, 0x4b, 0x5f, 0x07, 0x6f, 0xcf, 0xa5, 0xe8, 0x01, 0x90, 0x15, 0x60, 0xb3, 0xba, 0x67,
+	0x7c, 0x06, 0x5d, 0x3b, 0x40, 0xbe, 0x03, 0xa2, 0x97, 0xca, 0xe0, 0xda, 0x66, 0x1e, 0x5d, 0xc8,
+	0x3c, 0x80, 0x27, 0x0f, 0xd7, 0x1b, 0x6a, 0xfd, 0xdc, 0x50, 0x6b, 0xbb, 0xa1, 0xf0, 0x29, 0xa3,
+	0xf0, 0x25, 0xa3, 0xf0, 0x23, 0xa3, 0xb0, 0xce, 0x28, 0xfc, 0xca, 0x28, 0xfc, 0xc9, 0xa8, 0xb5,
+	0xcd, 0x28, 0x7c, 0xfe, 0x4d, 0xad, 0x57, 0xb7, 0x4b, 0xd6, 0xec, 0x96, 0x59, 0xf7, 0xe0, 0xdf,
+	0x00, 0x9c, 0x3c, 0x0c, 0x62, 0xc3, 0x05, 0x00, 0x00,
 }
@@ -29,6 +29,7 @@ import (
 	"reflect"
 
 	"github.com/gogo/protobuf/proto"
+	"go.uber.org/fx"
 	"go.uber.org/yarpc"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/encoding/protobuf"
@@ -43,7 +44,7 @@ type KeyValueYARPCClient interface {
 
 // NewKeyValueYARPCClient builds a new YARPC client for the KeyValue service.
 func NewKeyValueYARPCClient(clientConfig transport.ClientConfig, options ...protobuf.ClientOption) KeyValueYARPCClient {
-	return &_KeyValueYARPCCaller{protobuf.NewClient(
+	return &_KeyValueYARPCCaller{protobuf.NewStreamClient(
 		protobuf.ClientParams{
 			ServiceName:  "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.KeyValue",
 			ClientConfig: clientConfig,
@@ -85,16 +86,49 @@ func BuildKeyValueYARPCProcedures(server KeyValueYARPCServer) []transport.Proced
 				},
 			},
 			OnewayHandlerParams: []protobuf.BuildProceduresOnewayHandlerParams{},
+			StreamHandlerParams: []protobuf.BuildProceduresStreamHandlerParams{},
 		},
 	)
 }
 
+// FxKeyValueYARPCClientParams defines the input for
+// NewFxKeyValueYARPCClient.
+type FxKeyValueYARPCClientParams struct {
+	fx.In
+
+	Provider yarpc.ClientConfig
+}
+
+// FxKeyValueYARPCClientResult defines the output of
+// NewFxKeyValueYARPCClient. It provides a
+// KeyValueYARPCClient to an Fx application.
+type FxKeyValueYARPCClientResult struct {
+	fx.Out
+
+	Client KeyValueYARPCClient
+
+	// We are using an fx.Out struct here instead of just returning a client
+	// so that we can add more values or add named versions of the client in
+	// the future without breaking any existing code.
+}
+
+// NewFxKeyValueYARPCClient provides a KeyValueYARPCClient
+// to an Fx application using the given name for routing. The returned value
+// should be passed to fx.Provide.
+func NewFxKeyValueYARPCClient(name string, options ...protobuf.ClientOption) interface{} {
+	return func(params FxKeyValueYARPCClientParams) FxKeyValueYARPCClientResult {
+		return FxKeyValueYARPCClientResult{
+			Client: NewKeyValueYARPCClient(params.Provider.ClientConfig(name), options...),
+		}
+	}
+}
+
 type _KeyValueYARPCCaller struct {
-	client protobuf.Client
+	streamClient protobuf.StreamClient
 }
 
 func (c *_KeyValueYARPCCaller) GetValue(ctx context.Context, request *GetValueRequest, options ...yarpc.CallOption) (*GetValueResponse, error) {
-	responseMessage, err := c.client.Call(ctx, "GetValue", request, newKeyValue_GetValueYARPCResponse, options...)
+	responseMessage, err := c.streamClient.Call(ctx, "GetValue", request, newKeyValue_GetValueYARPCResponse, options...)
 	if responseMessage == nil {
 		return nil, err
 	}
@@ -106,7 +140,7 @@ func (c *_KeyValueYARPCCaller) GetValue(ctx context.Context, request *GetValueRe
 }
 
 func (c *_KeyValueYARPCCaller) SetValue(ctx context.Context, request *SetValueRequest, options ...yarpc.CallOption) (*SetValueResponse, error) {
-	responseMessage, err := c.client.Call(ctx, "SetValue", request, newKeyValue_SetValueYARPCResponse, options...)
+	responseMessage, err := c.streamClient.Call(ctx, "SetValue", request, newKeyValue_SetValueYARPCResponse, options...)
 	if responseMessage == nil {
 		return nil, err
 	}
@@ -183,7 +217,7 @@ type SinkYARPCClient interface {
 
 // NewSinkYARPCClient builds a new YARPC client for the Sink service.
 func NewSinkYARPCClient(clientConfig transport.ClientConfig, options ...protobuf.ClientOption) SinkYARPCClient {
-	return &_SinkYARPCCaller{protobuf.NewClient(
+	return &_SinkYARPCCaller{protobuf.NewStreamClient(
 		protobuf.ClientParams{
 			ServiceName:  "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.Sink",
 			ClientConfig: clientConfig,
@@ -215,16 +249,49 @@ func BuildSinkYARPCProcedures(server SinkYARPCServer) []transport.Procedure {
 					),
 				},
 			},
+			StreamHandlerParams: []protobuf.BuildProceduresStreamHandlerParams{},
 		},
 	)
 }
 
+// FxSinkYARPCClientParams defines the input for
+// NewFxSinkYARPCClient.
+type FxSinkYARPCClientParams struct {
+	fx.In
+
+	Provider yarpc.ClientConfig
+}
+
+// FxSinkYARPCClientResult defines the output of
+// NewFxSinkYARPCClient. It provides a
+// SinkYARPCClient to an Fx application.
+type FxSinkYARPCClientResult struct {
+	fx.Out
+
+	Client SinkYARPCClient
+
+	// We are using an fx.Out struct here instead of just returning a client
+	// so that we can add more values or add named versions of the client in
+	// the future without breaking any existing code.
+}
+
+// NewFxSinkYARPCClient provides a SinkYARPCClient
+// to an Fx application using the given name for routing. The returned value
+// should be passed to fx.Provide.
+func NewFxSinkYARPCClient(name string, options ...protobuf.ClientOption) interface{} {
+	return func(params FxSinkYARPCClientParams) FxSinkYARPCClientResult {
+		return FxSinkYARPCClientResult{
+			Client: NewSinkYARPCClient(params.Provider.ClientConfig(name), options...),
+		}
+	}
+}
+
 type _SinkYARPCCaller struct {
-	client protobuf.Client
+	streamClient protobuf.StreamClient
 }
 
 func (c *_SinkYARPCCaller) Fire(ctx context.Context, request *FireRequest, options ...yarpc.CallOption) (yarpc.Ack, error) {
-	return c.client.CallOneway(ctx, "Fire", request, options...)
+	return c.streamClient.CallOneway(ctx, "Fire", request, options...)
 }
 
 type _SinkYARPCHandler struct {
@@ -256,6 +323,367 @@ var (
 	emptySink_FireYARPCResponse = &yarpcproto.Oneway{}
 )
 
+// KeyValueStreamingYARPCClient is the YARPC client-side interface for the KeyValueStreaming service.
+type KeyValueStreamingYARPCClient interface {
+	WatchValue(context.Context, *GetValueRequest, ...yarpc.CallOption) (KeyValueStreamingServiceWatchValueYARPCClient, error)
+	SetValues(context.Context, ...yarpc.CallOption) (KeyValueStreamingServiceSetValuesYARPCClient, error)
+	SyncValues(context.Context, ...yarpc.CallOption) (KeyValueStreamingServiceSyncValuesYARPCClient, error)
+}
+
+// KeyValueStreamingServiceWatchValueYARPCClient receives GetValueResponses.
+type KeyValueStreamingServiceWatchValueYARPCClient interface {
+	Context() context.Context
+	Recv() (*GetValueResponse, error)
+}
+
+// KeyValueStreamingServiceSetValuesYARPCClient sends SetValueRequests and receives the single SetValueResponse when sending is done.
+type KeyValueStreamingServiceSetValuesYARPCClient interface {
+	Context() context.Context
+	Send(*SetValueRequest) error
+	CloseAndRecv() (*SetValueResponse, error)
+}
+
+// KeyValueStreamingServiceSyncValuesYARPCClient sends SetValueRequests and receives GetValueResponses.
+type KeyValueStreamingServiceSyncValuesYARPCClient interface {
+	Context() context.Context
+	Send(*SetValueRequest) error
+	Recv() (*GetValueResponse, error)
+	CloseSend() error
+}
+
+// NewKeyValueStreamingYARPCClient builds a new YARPC client for the KeyValueStreaming service.
+func NewKeyValueStreamingYARPCClient(clientConfig transport.ClientConfig, options ...protobuf.ClientOption) KeyValueStreamingYARPCClient {
+	return &_KeyValueStreamingYARPCCaller{protobuf.NewStreamClient(
+		protobuf.ClientParams{
+			ServiceName:  "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.KeyValueStreaming",
+			ClientConfig: clientConfig,
+			Options:      options,
+		},
+	)}
+}
+
+// KeyValueStreamingYARPCServer is the YARPC server-side interface for the KeyValueStreaming service.
+type KeyValueStreamingYARPCServer interface {
+	WatchValue(*GetValueRequest, KeyValueStreamingServiceWatchValueYARPCServer) error
+	SetValues(KeyValueStreamingServiceSetValuesYARPCServer) (*SetValueResponse, error)
+	SyncValues(KeyValueStreamingServiceSyncValuesYARPCServer) error
+}
+
+// KeyValueStreamingServiceWatchValueYARPCServer sends GetValueResponses.
+type KeyValueStreamingServiceWatchValueYARPCServer interface {
+	Context() context.Context
+	Send(*GetValueResponse) error
+}
+
+// KeyValueStreamingServiceSetValuesYARPCServer receives SetValueRequests.
+type KeyValueStreamingServiceSetValuesYARPCServer interface {
+	Context() context.Context
+	Recv() (*SetValueRequest, error)
+}
+
+// KeyValueStreamingServiceSyncValuesYARPCServer receives SetValueRequests and sends GetValueResponses.
+type KeyValueStreamingServiceSyncValuesYARPCServer interface {
+	Context() context.Context
+	Recv() (*SetValueRequest, error)
+	Send(*GetValueResponse) error
+}
+
+// BuildKeyValueStreamingYARPCProcedures prepares an implementation of the KeyValueStreaming service for YARPC registration.
+func BuildKeyValueStreamingYARPCProcedures(server KeyValueStreamingYARPCServer) []transport.Procedure {
+	handler := &_KeyValueStreamingYARPCHandler{server}
+	return protobuf.BuildProcedures(
+		protobuf.BuildProceduresParams{
+			ServiceName:         "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.KeyValueStreaming",
+			UnaryHandlerParams:  []protobuf.BuildProceduresUnaryHandlerParams{},
+			OnewayHandlerParams: []protobuf.BuildProceduresOnewayHandlerParams{},
+			StreamHandlerParams: []protobuf.BuildProceduresStreamHandlerParams{
+				{
+					MethodName: "WatchValue",
+					Handler: protobuf.NewStreamHandler(
+						protobuf.StreamHandlerParams{
+							Handle: handler.WatchValue,
+						},
+					),
+				},
+				{
+					MethodName: "SetValues",
+					Handler: protobuf.NewStreamHandler(
+						protobuf.StreamHandlerParams{
+							Handle: handler.SetValues,
+						},
+					),
+				},
+				{
+					MethodName: "SyncValues",
+					Handler: protobuf.NewStreamHandler(
+						protobuf.StreamHandlerParams{
+							Handle: handler.SyncValues,
+						},
+					),
+				},
+			},
+		},
+	)
+}
+
+// FxKeyValueStreamingYARPCClientParams defines the input for
+// NewFxKeyValueStreamingYARPCClient.
+type FxKeyValueStreamingYARPCClientParams struct {
+	fx.In
+
+	Provider yarpc.ClientConfig
+}
+
+// FxKeyValueStreamingYARPCClientResult defines the output of
+// NewFxKeyValueStreamingYARPCClient. It provides a
+// KeyValueStreamingYARPCClient to an Fx application.
+type FxKeyValueStreamingYARPCClientResult struct {
+	fx.Out
+
+	Client KeyValueStreamingYARPCClient
+
+	// We are using an fx.Out struct here instead of just returning a client
+	// so that we can add more values or add named versions of the client in
+	// the future without breaking any existing code.
+}
+
+// NewFxKeyValueStreamingYARPCClient provides a KeyValueStreamingYARPCClient
+// to an Fx application using the given name for routing. The returned value
+// should be passed to fx.Provide.
+func NewFxKeyValueStreamingYARPCClient(name string, options ...protobuf.ClientOption) interface{} {
+	return func(params FxKeyValueStreamingYARPCClientParams) FxKeyValueStreamingYARPCClientResult {
+		return FxKeyValueStreamingYARPCClientResult{
+			Client: NewKeyValueStreamingYARPCClient(params.Provider.ClientConfig(name), options...),
+		}
+	}
+}
+
+type _KeyValueStreamingYARPCCaller struct {
+	streamClient protobuf.StreamClient
+}
+
+func (c *_KeyValueStreamingYARPCCaller) WatchValue(ctx context.Context, request *GetValueRequest, options ...yarpc.CallOption) (KeyValueStreamingServiceWatchValueYARPCClient, error) {
+	stream, err := c.streamClient.CallStream(ctx, "WatchValue", options...)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.Send(request); err != nil {
+		return nil, err
+	}
+	if err := stream.Close(); err != nil {
+		return nil, err
+	}
+	return &_KeyValueStreamingServiceWatchValueYARPCClient{stream: stream}, nil
+}
+
+func (c *_KeyValueStreamingYARPCCaller) SetValues(ctx context.Context, options ...yarpc.CallOption) (KeyValueStreamingServiceSetValuesYARPCClient, error) {
+	stream, err := c.streamClient.CallStream(ctx, "SetValues", options...)
+	if err != nil {
+		return nil, err
+	}
+	return &_KeyValueStreamingServiceSetValuesYARPCClient{stream: stream}, nil
+}
+
+func (c *_KeyValueStreamingYARPCCaller) SyncValues(ctx context.Context, options ...yarpc.CallOption) (KeyValueStreamingServiceSyncValuesYARPCClient, error) {
+	stream, err := c.streamClient.CallStream(ctx, "SyncValues", options...)
+	if err != nil {
+		return nil, err
+	}
+	return &_KeyValueStreamingServiceSyncValuesYARPCClient{stream: stream}, nil
+}
+
+type _KeyValueStreamingYARPCHandler struct {
+	server KeyValueStreamingYARPCServer
+}
+
+func (h *_KeyValueStreamingYARPCHandler) WatchValue(serverStream *protobuf.ServerStream) error {
+	requestMessage, err := serverStream.Receive(newKeyValueStreaming_WatchValueYARPCRequest)
+	if requestMessage == nil {
+		return err
+	}
+	request, ok := requestMessage.(*GetValueRequest)
+	if !ok {
+		return protobuf.CastError(emptyKeyValueStreaming_WatchValueYARPCRequest, requestMessage)
+	}
+	return h.server.WatchValue(request, &_KeyValueStreamingServiceWatchValueYARPCServer{serverStream: serverStream})
+}
+
+func (h *_KeyValueStreamingYARPCHandler) SetValues(serverStream *protobuf.ServerStream) error {
+	response, err := h.server.SetValues(&_KeyValueStreamingServiceSetValuesYARPCServer{serverStream: serverStream})
+	if err != nil {
+		return err
+	}
+	return serverStream.Send(response)
+}
+
+func (h *_KeyValueStreamingYARPCHandler) SyncValues(serverStream *protobuf.ServerStream) error {
+	return h.server.SyncValues(&_KeyValueStreamingServiceSyncValuesYARPCServer{serverStream: serverStream})
+}
+
+type _KeyValueStreamingServiceWatchValueYARPCClient struct {
+	stream *protobuf.ClientStream
+}
+
+func (c *_KeyValueStreamingServiceWatchValueYARPCClient) Context() context.Context {
+	return c.stream.Context()
+}
+
+func (c *_KeyValueStreamingServiceWatchValueYARPCClient) Recv() (*GetValueResponse, error) {
+	responseMessage, err := c.stream.Receive(newKeyValueStreaming_WatchValueYARPCResponse)
+	if responseMessage == nil {
+		return nil, err
+	}
+	response, ok := responseMessage.(*GetValueResponse)
+	if !ok {
+		return nil, protobuf.CastError(emptyKeyValueStreaming_WatchValueYARPCResponse, responseMessage)
+	}
+	return response, err
+}
+
+type _KeyValueStreamingServiceWatchValueYARPCServer struct {
+	serverStream *protobuf.ServerStream
+}
+
+func (s *_KeyValueStreamingServiceWatchValueYARPCServer) Context() context.Context {
+	return s.serverStream.Context()
+}
+
+func (s *_KeyValueStreamingServiceWatchValueYARPCServer) Send(response *GetValueResponse) error {
+	return s.serverStream.Send(response)
+}
+
+type _KeyValueStreamingServiceSetValuesYARPCClient struct {
+	stream *protobuf.ClientStream
+}
+
+func (c *_KeyValueStreamingServiceSetValuesYARPCClient) Context() context.Context {
+	return c.stream.Context()
+}
+
+func (c *_KeyValueStreamingServiceSetValuesYARPCClient) Send(request *SetValueRequest) error {
+	return c.stream.Send(request)
+}
+
+func (c *_KeyValueStreamingServiceSetValuesYARPCClient) CloseAndRecv() (*SetValueResponse, error) {
+	if err := c.stream.Close(); err != nil {
+		return nil, err
+	}
+	responseMessage, err := c.stream.Receive(newKeyValueStreaming_SetValuesYARPCResponse)
+	if responseMessage == nil {
+		return nil, err
+	}
+	response, ok := responseMessage.(*SetValueResponse)
+	if !ok {
+		return nil, protobuf.CastError(emptyKeyValueStreaming_SetValuesYARPCResponse, responseMessage)
+	}
+	return response, err
+}
+
+type _KeyValueStreamingServiceSetValuesYARPCServer struct {
+	serverStream *protobuf.ServerStream
+}
+
+func (s *_KeyValueStreamingServiceSetValuesYARPCServer) Context() context.Context {
+	return s.serverStream.Context()
+}
+
+func (s *_KeyValueStreamingServiceSetValuesYARPCServer) Recv() (*SetValueRequest, error) {
+	requestMessage, err := s.serverStream.Receive(newKeyValueStreaming_SetValuesYARPCRequest)
+	if requestMessage == nil {
+		return nil, err
+	}
+	request, ok := requestMessage.(*SetValueRequest)
+	if !ok {
+		return nil, protobuf.CastError(emptyKeyValueStreaming_SetValuesYARPCRequest, requestMessage)
+	}
+	return request, err
+}
+
+type _KeyValueStreamingServiceSyncValuesYARPCClient struct {
+	stream *protobuf.ClientStream
+}
+
+func (c *_KeyValueStreamingServiceSyncValuesYARPCClient) Context() context.Context {
+	return c.stream.Context()
+}
+
+func (c *_KeyValueStreamingServiceSyncValuesYARPCClient) Send(request *SetValueRequest) error {
+	return c.stream.Send(request)
+}
+
+func (c *_KeyValueStreamingServiceSyncValuesYARPCClient) Recv() (*GetValueResponse, error) {
+	responseMessage, err := c.stream.Receive(newKeyValueStreaming_SyncValuesYARPCResponse)
+	if responseMessage == nil {
+		return nil, err
+	}
+	response, ok := responseMessage.(*GetValueResponse)
+	if !ok {
+		return nil, protobuf.CastError(emptyKeyValueStreaming_SyncValuesYARPCResponse, responseMessage)
+	}
+	return response, err
+}
+
+func (c *_KeyValueStreamingServiceSyncValuesYARPCClient) CloseSend() error {
+	return c.stream.Close()
+}
+
+type _KeyValueStreamingServiceSyncValuesYARPCServer struct {
+	serverStream *protobuf.ServerStream
+}
+
+func (s *_KeyValueStreamingServiceSyncValuesYARPCServer) Context() context.Context {
+	return s.serverStream.Context()
+}
+
+func (s *_KeyValueStreamingServiceSyncValuesYARPCServer) Recv() (*SetValueRequest, error) {
+	requestMessage, err := s.serverStream.Receive(newKeyValueStreaming_SyncValuesYARPCRequest)
+	if requestMessage == nil {
+		return nil, err
+	}
+	request, ok := requestMessage.(*SetValueRequest)
+	if !ok {
+		return nil, protobuf.CastError(emptyKeyValueStreaming_SyncValuesYARPCRequest, requestMessage)
+	}
+	return request, err
+}
+
+func (s *_KeyValueStreamingServiceSyncValuesYARPCServer) Send(response *GetValueResponse) error {
+	return s.serverStream.Send(response)
+}
+
+func newKeyValueStreaming_WatchValueYARPCRequest() proto.Message {
+	return &GetValueRequest{}
+}
+
+func newKeyValueStreaming_WatchValueYARPCResponse() proto.Message {
+	return &GetValueResponse{}
+}
+
+func newKeyValueStreaming_SetValuesYARPCRequest() proto.Message {
+	return &SetValueRequest{}
+}
+
+func newKeyValueStreaming_SetValuesYARPCResponse() proto.Message {
+	return &SetValueResponse{}
+}
+
+func newKeyValueStreaming_SyncValuesYARPCRequest() proto.Message {
+	return &SetValueRequest{}
+}
+
+func newKeyValueStreaming_SyncValuesYARPCResponse() proto.Message {
+	return &GetValueResponse{}
+}
+
+var (
+	emptyKeyValueStreaming_WatchValueYARPCRequest  = &GetValueRequest{}
+	emptyKeyValueStreaming_WatchValueYARPCResponse = &GetValueResponse{}
+	emptyKeyValueStreaming_SetValuesYARPCRequest   = &SetValueRequest{}
+	emptyKeyValueStreaming_SetValuesYARPCResponse  = &SetValueResponse{}
+	emptyKeyValueStreaming_SyncValuesYARPCRequest  = &SetValueRequest{}
+	emptyKeyValueStreaming_SyncValuesYARPCResponse = &GetValueResponse{}
+)
+
 func init() {
 	yarpc.RegisterClientBuilder(
 		func(clientConfig transport.ClientConfig, structField reflect.StructField) KeyValueYARPCClient {
@@ -267,4 +695,9 @@ func init() {
 			return NewSinkYARPCClient(clientConfig, protobuf.ClientBuilderOptions(clientConfig, structField)...)
 		},
 	)
+	yarpc.RegisterClientBuilder(
+		func(clientConfig transport.ClientConfig, structField reflect.StructField) KeyValueStreamingYARPCClient {
+			return NewKeyValueStreamingYARPCClient(clientConfig, protobuf.ClientBuilderOptions(clientConfig, structField)...)
+		},
+	)
 }
@@ -0,0 +1,147 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package protobuf
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+
+	"github.com/gogo/protobuf/proto"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/errors"
+)
+
+// ServerStream is a protobuf-specific server stream.
+//
+// This type should only be used by generated code.
+type ServerStream struct {
+	ctx              context.Context
+	transportRequest *transport.Request
+	stream           *transport.ServerStream
+}
+
+func newServerStream(ctx context.Context, transportRequest *transport.Request, stream *transport.ServerStream) *ServerStream {
+	return &ServerStream{
+		ctx:              ctx,
+		transportRequest: transportRequest,
+		stream:           stream,
+	}
+}
+
+// Context returns the context of the stream.
+func (s *ServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// Receive reads the next message sent by the client. It returns io.EOF once
+// the client has closed its side of the stream.
+func (s *ServerStream) Receive(newMessage func() proto.Message) (proto.Message, error) {
+	streamMessage, err := s.stream.ReceiveMessage(s.ctx)
+	if err != nil {
+		return nil, err
+	}
+	message, err := unmarshalStreamMessage(s.transportRequest.Encoding, streamMessage, newMessage)
+	if err != nil {
+		return nil, errors.RequestBodyDecodeError(s.transportRequest, err)
+	}
+	return message, nil
+}
+
+// Send sends the given message to the client.
+func (s *ServerStream) Send(message proto.Message) error {
+	data, cleanup, err := marshal(s.transportRequest.Encoding, message)
+	if cleanup != nil {
+		defer cleanup()
+	}
+	if err != nil {
+		return errors.ResponseBodyEncodeError(s.transportRequest, err)
+	}
+	return s.stream.SendMessage(s.ctx, newStreamMessage(data))
+}
+
+// ClientStream is a protobuf-specific client stream.
+//
+// This type should only be used by generated code.
+type ClientStream struct {
+	transportRequest *transport.Request
+	stream           *transport.ClientStream
+}
+
+func newClientStream(transportRequest *transport.Request, stream *transport.ClientStream) *ClientStream {
+	return &ClientStream{
+		transportRequest: transportRequest,
+		stream:           stream,
+	}
+}
+
+// Context returns the context of the stream.
+func (c *ClientStream) Context() context.Context {
+	return c.stream.Context()
+}
+
+// Receive reads the next message sent by the server. It returns io.EOF once
+// the server has finished sending messages.
+func (c *ClientStream) Receive(newMessage func() proto.Message) (proto.Message, error) {
+	streamMessage, err := c.stream.ReceiveMessage(c.Context())
+	if err != nil {
+		return nil, err
+	}
+	message, err := unmarshalStreamMessage(c.transportRequest.Encoding, streamMessage, newMessage)
+	if err != nil {
+		return nil, errors.ResponseBodyDecodeError(c.transportRequest, err)
+	}
+	return message, nil
+}
+
+// Send sends the given message to the server.
+func (c *ClientStream) Send(message proto.Message) error {
+	data, cleanup, err := marshal(c.transportRequest.Encoding, message)
+	if cleanup != nil {
+		defer cleanup()
+	}
+	if err != nil {
+		return errors.RequestBodyEncodeError(c.transportRequest, err)
+	}
+	return c.stream.SendMessage(c.Context(), newStreamMessage(data))
+}
+
+// Close closes the sending side of the stream. Messages sent by the server
+// may still be read with Receive.
+func (c *ClientStream) Close() error {
+	return c.stream.Close(c.Context())
+}
+
+func unmarshalStreamMessage(encoding transport.Encoding, streamMessage *transport.StreamMessage, newMessage func() proto.Message) (proto.Message, error) {
+	message := newMessage()
+	if streamMessage == nil || streamMessage.Body == nil {
+		return message, nil
+	}
+	defer streamMessage.Body.Close()
+	if err := unmarshal(encoding, streamMessage.Body, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func newStreamMessage(data []byte) *transport.StreamMessage {
+	return &transport.StreamMessage{Body: ioutil.NopCloser(bytes.NewReader(data))}
+}
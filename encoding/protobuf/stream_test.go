@@ -0,0 +1,240 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package protobuf
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func TestBuildProceduresStream(t *testing.T) {
+	procedures := BuildProcedures(BuildProceduresParams{
+		ServiceName: "foo",
+		StreamHandlerParams: []BuildProceduresStreamHandlerParams{
+			{
+				MethodName: "Bar",
+				Handler:    NewStreamHandler(StreamHandlerParams{Handle: func(*ServerStream) error { return nil }}),
+			},
+		},
+	})
+	require.Len(t, procedures, 2)
+	for _, p := range procedures {
+		assert.Equal(t, "foo::Bar", p.Name)
+		assert.Equal(t, transport.Streaming, p.HandlerSpec.Type())
+	}
+	assert.Equal(t, Encoding, procedures[0].Encoding)
+	assert.Equal(t, JSONEncoding, procedures[1].Encoding)
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	for _, encoding := range []transport.Encoding{Encoding, JSONEncoding} {
+		t.Run(string(encoding), func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			var (
+				caller     string
+				serverDone = make(chan error, 1)
+			)
+			handler := NewStreamHandler(StreamHandlerParams{
+				Handle: func(stream *ServerStream) error {
+					caller = yarpc.CallFromContext(stream.Context()).Caller()
+					for {
+						message, err := stream.Receive(newTestMessage)
+						if err == io.EOF {
+							return nil
+						}
+						if err != nil {
+							return err
+						}
+						value := message.(*testMessage).Value
+						if err := stream.Send(&testMessage{Value: value + value}); err != nil {
+							return err
+						}
+					}
+				},
+			})
+
+			outbound := transporttest.NewMockStreamOutbound(mockCtrl)
+			outbound.EXPECT().CallStream(gomock.Any(), gomock.Any()).Do(
+				func(_ context.Context, req *transport.StreamRequest) {
+					assert.Equal(t, "foo::Echo", req.Meta.Procedure)
+					assert.Equal(t, encoding, req.Meta.Encoding)
+				},
+			).Return(newStreamPipe(t, encoding, handler, serverDone), nil)
+
+			client := newClient("foo", &testStreamClientConfig{
+				testClientConfig: newTestClientConfig("caller", "service"),
+				outbound:         outbound,
+			})
+			client.encoding = encoding
+
+			stream, err := client.CallStream(context.Background(), "Echo")
+			require.NoError(t, err)
+			require.NoError(t, stream.Send(&testMessage{Value: "a"}))
+			require.NoError(t, stream.Send(&testMessage{Value: "b"}))
+			require.NoError(t, stream.Close())
+
+			for _, want := range []string{"aa", "bb"} {
+				message, err := stream.Receive(newTestMessage)
+				require.NoError(t, err)
+				assert.Equal(t, want, message.(*testMessage).Value)
+			}
+			_, err = stream.Receive(newTestMessage)
+			assert.Equal(t, io.EOF, err)
+			assert.NoError(t, <-serverDone)
+			assert.Equal(t, "caller", caller)
+		})
+	}
+}
+
+func TestCallStreamWithoutStreamOutbound(t *testing.T) {
+	client := newClient("foo", newTestClientConfig("bar", "baz"))
+	_, err := client.CallStream(context.Background(), "hello")
+	assert.Equal(t, yarpcerrors.CodeInternal, yarpcerrors.FromError(err).Code())
+}
+
+func TestStreamHandlerInvalidEncoding(t *testing.T) {
+	handler := newStreamHandler(func(*ServerStream) error {
+		t.Fatal("handler must not be called")
+		return nil
+	})
+	stream, err := transport.NewServerStream(&pipeStream{
+		ctx: context.Background(),
+		req: &transport.StreamRequest{Meta: &transport.RequestMeta{Encoding: "raw"}},
+	})
+	require.NoError(t, err)
+	assert.Error(t, handler.HandleStream(stream))
+}
+
+func TestStreamReceiveDecodeError(t *testing.T) {
+	in := make(chan *transport.StreamMessage, 1)
+	in <- &transport.StreamMessage{Body: ioutil.NopCloser(bytes.NewReader([]byte("not json")))}
+	transportStream, err := transport.NewServerStream(&pipeStream{ctx: context.Background(), in: in})
+	require.NoError(t, err)
+
+	stream := newServerStream(context.Background(), &transport.Request{Encoding: JSONEncoding}, transportStream)
+	_, err = stream.Receive(newTestMessage)
+	assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+}
+
+type testMessage struct {
+	Value string `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func newTestMessage() proto.Message { return &testMessage{} }
+
+func (m *testMessage) Reset()         { *m = testMessage{} }
+func (m *testMessage) String() string { return proto.CompactTextString(m) }
+func (*testMessage) ProtoMessage()    {}
+
+type testStreamClientConfig struct {
+	*testClientConfig
+
+	outbound transport.StreamOutbound
+}
+
+func (c *testStreamClientConfig) GetStreamOutbound() transport.StreamOutbound {
+	return c.outbound
+}
+
+// newStreamPipe starts the given handler on one end of an in-memory stream
+// and returns the client end. The result of the handler is sent to done.
+func newStreamPipe(t *testing.T, encoding transport.Encoding, handler transport.StreamHandler, done chan<- error) *transport.ClientStream {
+	var (
+		ctx = context.Background()
+		req = &transport.StreamRequest{Meta: &transport.RequestMeta{
+			Caller:    "caller",
+			Service:   "service",
+			Procedure: "foo::Echo",
+			Encoding:  encoding,
+		}}
+		requests = make(chan *transport.StreamMessage, 10)
+		replies  = make(chan *transport.StreamMessage, 10)
+	)
+
+	clientStream, err := transport.NewClientStream(&pipeStream{ctx: ctx, req: req, in: replies, out: requests})
+	require.NoError(t, err)
+	server := &pipeStream{ctx: ctx, req: req, in: requests, out: replies}
+	serverStream, err := transport.NewServerStream(server)
+	require.NoError(t, err)
+
+	go func() {
+		err := transport.DispatchStreamHandler(handler, serverStream)
+		server.Close(ctx)
+		done <- err
+	}()
+	return clientStream
+}
+
+// pipeStream is one end of an in-memory transport.StreamCloser.
+type pipeStream struct {
+	ctx context.Context
+	req *transport.StreamRequest
+	in  <-chan *transport.StreamMessage
+	out chan<- *transport.StreamMessage
+
+	closeOnce sync.Once
+}
+
+func (p *pipeStream) Context() context.Context {
+	return p.ctx
+}
+
+func (p *pipeStream) Request() *transport.StreamRequest {
+	return p.req
+}
+
+func (p *pipeStream) SendMessage(_ context.Context, m *transport.StreamMessage) error {
+	// The body is only valid until SendMessage returns.
+	body, err := ioutil.ReadAll(m.Body)
+	if err != nil {
+		return err
+	}
+	p.out <- &transport.StreamMessage{Body: ioutil.NopCloser(bytes.NewReader(body))}
+	return nil
+}
+
+func (p *pipeStream) ReceiveMessage(context.Context) (*transport.StreamMessage, error) {
+	m, ok := <-p.in
+	if !ok {
+		return nil, io.EOF
+	}
+	return m, nil
+}
+
+func (p *pipeStream) Close(context.Context) error {
+	p.closeOnce.Do(func() { close(p.out) })
+	return nil
+}
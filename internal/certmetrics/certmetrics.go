@@ -0,0 +1,170 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package certmetrics tracks the expiration of the TLS certificates presented
+// by transports, so that the Dispatchers using them report it.
+package certmetrics
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sync"
+
+	"go.uber.org/yarpc/internal/pally"
+	"go.uber.org/yarpc/internal/sharedmetrics"
+)
+
+// Observer records the expiration of the certificates presented by the TLS
+// configurations it observes. Transports and inbounds each have their own.
+type Observer struct {
+	metrics     *sharedmetrics.Set
+	expirations *sharedmetrics.GaugeVector
+
+	mu sync.Mutex
+	// users counts the observed configurations which last presented each
+	// certificate, so that certificates are no longer reported once every
+	// configuration has moved on from them.
+	users map[string]int
+}
+
+// NewObserver builds a new Observer.
+func NewObserver() *Observer {
+	metrics := sharedmetrics.NewSet()
+	return &Observer{
+		metrics: metrics,
+		expirations: metrics.NewGaugeVector(pally.Opts{
+			Name:           "tls_certificate_expiration",
+			Help:           "Expiration time of TLS certificates in seconds since the Unix epoch.",
+			VariableLabels: []string{"certificate"},
+		}),
+		users: make(map[string]int),
+	}
+}
+
+// Metrics returns the metrics of the Observer.
+func (o *Observer) Metrics() []*sharedmetrics.Set {
+	return []*sharedmetrics.Set{o.metrics}
+}
+
+// Observe returns a copy of the given configuration which records the
+// certificates it presents: its static certificate right away, and those
+// returned by its callbacks for every handshake. It returns nil if the
+// configuration is nil.
+func (o *Observer) Observe(config *tls.Config) *tls.Config {
+	if config == nil {
+		return nil
+	}
+
+	config = config.Clone()
+	presented := &presented{}
+	if len(config.Certificates) > 0 {
+		o.observe(presented, &config.Certificates[0])
+	}
+	if getCertificate := config.GetCertificate; getCertificate != nil {
+		config.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, err := getCertificate(hello)
+			if err == nil {
+				o.observe(presented, cert)
+			}
+			return cert, err
+		}
+	}
+	if getClientCertificate := config.GetClientCertificate; getClientCertificate != nil {
+		config.GetClientCertificate = func(req *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := getClientCertificate(req)
+			if err == nil {
+				o.observe(presented, cert)
+			}
+			return cert, err
+		}
+	}
+	if getConfigForClient := config.GetConfigForClient; getConfigForClient != nil {
+		config.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			handshakeConfig, err := getConfigForClient(hello)
+			if err == nil && handshakeConfig != nil && len(handshakeConfig.Certificates) > 0 {
+				o.observe(presented, &handshakeConfig.Certificates[0])
+			}
+			return handshakeConfig, err
+		}
+	}
+	return config
+}
+
+// presented is the name of the certificate last presented by an observed
+// configuration.
+type presented struct{ name string }
+
+func (o *Observer) observe(p *presented, cert *tls.Certificate) {
+	leaf := leafCertificate(cert)
+	if leaf == nil {
+		return
+	}
+	name := certificateName(leaf)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.expirations.Store(leaf.NotAfter.Unix(), name)
+	if p.name == name {
+		return
+	}
+	o.users[name]++
+	if old := p.name; old != "" {
+		o.users[old]--
+		if o.users[old] == 0 {
+			delete(o.users, old)
+			o.expirations.Delete(old)
+		}
+	}
+	p.name = name
+}
+
+// leafCertificate returns the parsed leaf of the given certificate chain, or
+// nil if the chain is empty or cannot be parsed.
+func leafCertificate(cert *tls.Certificate) *x509.Certificate {
+	if cert == nil {
+		return nil
+	}
+	if cert.Leaf != nil {
+		return cert.Leaf
+	}
+	if len(cert.Certificate) == 0 {
+		return nil
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil
+	}
+	return leaf
+}
+
+// certificateName identifies a certificate in metrics. This is the
+// certificate's common name, its first DNS name if it doesn't have one, or
+// its serial number otherwise.
+func certificateName(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+	return fmt.Sprintf("serial:%x", cert.SerialNumber)
+}
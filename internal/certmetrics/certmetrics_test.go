@@ -0,0 +1,197 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package certmetrics
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/internal/tlstest"
+)
+
+func newCertificate(name string, notAfter time.Time) *tls.Certificate {
+	return &tls.Certificate{Leaf: &x509.Certificate{
+		Subject:  pkix.Name{CommonName: name},
+		NotAfter: notAfter,
+	}}
+}
+
+func TestObserver(t *testing.T) {
+	o := NewObserver()
+	expirations := make(map[string]int64)
+	defer o.expirations.Subscribe(func(labels []string, notAfter int64) {
+		if notAfter == 0 {
+			delete(expirations, labels[0])
+			return
+		}
+		expirations[labels[0]] = notAfter
+	})()
+
+	assert.Nil(t, o.Observe(nil))
+
+	static := &tls.Config{Certificates: []tls.Certificate{*newCertificate("static", time.Unix(1500000000, 0))}}
+	observed := o.Observe(static)
+	assert.False(t, observed == static, "configurations must be copied")
+	assert.Equal(t, map[string]int64{"static": 1500000000}, expirations,
+		"static certificates must be observed right away")
+
+	cert := newCertificate("foo", time.Unix(1600000000, 0))
+	observed = o.Observe(&tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return cert, nil
+		},
+	})
+	assert.Len(t, expirations, 1, "certificates must only be observed once presented")
+
+	_, err := observed.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1600000000), expirations["foo"])
+
+	cert = newCertificate("bar", time.Unix(1700000000, 0))
+	_, err = observed.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"static": 1500000000, "bar": 1700000000}, expirations,
+		"certificates which are no longer presented must not be reported")
+
+	observed = o.Observe(&tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return &tls.Config{Certificates: []tls.Certificate{*newCertificate("baz", time.Unix(1800000000, 0))}}, nil
+		},
+	})
+	_, err = observed.GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1800000000), expirations["baz"])
+
+	observed = o.Observe(&tls.Config{
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return &tls.Certificate{}, nil
+		},
+	})
+	_, err = observed.GetClientCertificate(&tls.CertificateRequestInfo{})
+	require.NoError(t, err)
+	assert.Len(t, expirations, 3, "empty certificates must not be observed")
+}
+
+func TestObserverSharedCertificate(t *testing.T) {
+	o := NewObserver()
+	first := newCertificate("foo", time.Unix(1500000000, 0))
+	second := first
+	getFirst := o.Observe(&tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return first, nil },
+	}).GetCertificate
+	getSecond := o.Observe(&tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return second, nil },
+	}).GetCertificate
+
+	_, err := getFirst(nil)
+	require.NoError(t, err)
+	_, err = getSecond(nil)
+	require.NoError(t, err)
+
+	first = newCertificate("bar", time.Unix(1600000000, 0))
+	_, err = getFirst(nil)
+	require.NoError(t, err)
+
+	expirations := make(map[string]int64)
+	o.expirations.Subscribe(func(labels []string, notAfter int64) {
+		expirations[labels[0]] = notAfter
+	})()
+	assert.Equal(t, map[string]int64{"foo": 1500000000, "bar": 1600000000}, expirations,
+		"certificates must be reported while any configuration presents them")
+}
+
+func TestObserverLoadedCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certmetrics")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := tlstest.WriteFiles(t, dir)
+	cert, err := tls.LoadX509KeyPair(files.ServerCertFile, files.ServerKeyFile)
+	require.NoError(t, err)
+
+	o := NewObserver()
+	o.Observe(&tls.Config{Certificates: []tls.Certificate{cert}})
+
+	var names []string
+	o.expirations.Subscribe(func(labels []string, _ int64) {
+		names = append(names, labels[0])
+	})()
+	assert.Equal(t, []string{"localhost"}, names, "leaves must be parsed from the chain")
+}
+
+func TestLeafCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certmetrics")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := tlstest.WriteFiles(t, dir)
+	cert, err := tls.LoadX509KeyPair(files.ServerCertFile, files.ServerKeyFile)
+	require.NoError(t, err)
+
+	leaf := leafCertificate(&cert)
+	require.NotNil(t, leaf, "leaf must be parsed from the chain")
+	assert.Equal(t, "localhost", leaf.Subject.CommonName)
+
+	assert.Nil(t, leafCertificate(nil))
+	assert.Nil(t, leafCertificate(&tls.Certificate{}))
+	assert.Nil(t, leafCertificate(&tls.Certificate{Certificate: [][]byte{[]byte("not a certificate")}}))
+}
+
+func TestCertificateName(t *testing.T) {
+	tests := []struct {
+		desc string
+		give *x509.Certificate
+		want string
+	}{
+		{
+			desc: "common name",
+			give: &x509.Certificate{
+				Subject:  pkix.Name{CommonName: "foo"},
+				DNSNames: []string{"bar"},
+			},
+			want: "foo",
+		},
+		{
+			desc: "DNS name",
+			give: &x509.Certificate{DNSNames: []string{"bar", "baz"}},
+			want: "bar",
+		},
+		{
+			desc: "serial number",
+			give: &x509.Certificate{SerialNumber: big.NewInt(42)},
+			want: "serial:2a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.want, certificateName(tt.give))
+		})
+	}
+}
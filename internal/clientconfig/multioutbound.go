@@ -32,6 +32,8 @@ type multiOutbound struct {
 	Outbounds transport.Outbounds
 }
 
+var _ transport.StreamClientConfig = multiOutbound{}
+
 // MultiOutbound constructs a ClientConfig backed by multiple outbound types
 func MultiOutbound(caller, service string, Outbounds transport.Outbounds) transport.ClientConfig {
 	return multiOutbound{caller: caller, service: service, Outbounds: Outbounds}
@@ -55,3 +57,11 @@ func (c multiOutbound) GetOnewayOutbound() transport.OnewayOutbound {
 
 	return c.Outbounds.Oneway
 }
+
+func (c multiOutbound) GetStreamOutbound() transport.StreamOutbound {
+	if c.Outbounds.Stream == nil {
+		panic(fmt.Sprintf("Service %q does not have a stream outbound", c.service))
+	}
+
+	return c.Outbounds.Stream
+}
@@ -29,6 +29,7 @@ import (
 	"reflect"
 
 	"github.com/gogo/protobuf/proto"
+	"go.uber.org/fx"
 	"go.uber.org/yarpc"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/encoding/protobuf"
@@ -42,7 +43,7 @@ type EchoYARPCClient interface {
 
 // NewEchoYARPCClient builds a new YARPC client for the Echo service.
 func NewEchoYARPCClient(clientConfig transport.ClientConfig, options ...protobuf.ClientOption) EchoYARPCClient {
-	return &_EchoYARPCCaller{protobuf.NewClient(
+	return &_EchoYARPCCaller{protobuf.NewStreamClient(
 		protobuf.ClientParams{
 			ServiceName:  "uber.yarpc.internal.crossdock.Echo",
 			ClientConfig: clientConfig,
@@ -74,16 +75,49 @@ func BuildEchoYARPCProcedures(server EchoYARPCServer) []transport.Procedure {
 				},
 			},
 			OnewayHandlerParams: []protobuf.BuildProceduresOnewayHandlerParams{},
+			StreamHandlerParams: []protobuf.BuildProceduresStreamHandlerParams{},
 		},
 	)
 }
 
+// FxEchoYARPCClientParams defines the input for
+// NewFxEchoYARPCClient.
+type FxEchoYARPCClientParams struct {
+	fx.In
+
+	Provider yarpc.ClientConfig
+}
+
+// FxEchoYARPCClientResult defines the output of
+// NewFxEchoYARPCClient. It provides a
+// EchoYARPCClient to an Fx application.
+type FxEchoYARPCClientResult struct {
+	fx.Out
+
+	Client EchoYARPCClient
+
+	// We are using an fx.Out struct here instead of just returning a client
+	// so that we can add more values or add named versions of the client in
+	// the future without breaking any existing code.
+}
+
+// NewFxEchoYARPCClient provides a EchoYARPCClient
+// to an Fx application using the given name for routing. The returned value
+// should be passed to fx.Provide.
+func NewFxEchoYARPCClient(name string, options ...protobuf.ClientOption) interface{} {
+	return func(params FxEchoYARPCClientParams) FxEchoYARPCClientResult {
+		return FxEchoYARPCClientResult{
+			Client: NewEchoYARPCClient(params.Provider.ClientConfig(name), options...),
+		}
+	}
+}
+
 type _EchoYARPCCaller struct {
-	client protobuf.Client
+	streamClient protobuf.StreamClient
 }
 
 func (c *_EchoYARPCCaller) Echo(ctx context.Context, request *Ping, options ...yarpc.CallOption) (*Pong, error) {
-	responseMessage, err := c.client.Call(ctx, "Echo", request, newEcho_EchoYARPCResponse, options...)
+	responseMessage, err := c.streamClient.Call(ctx, "Echo", request, newEcho_EchoYARPCResponse, options...)
 	if responseMessage == nil {
 		return nil, err
 	}
@@ -134,7 +168,7 @@ type OnewayYARPCClient interface {
 
 // NewOnewayYARPCClient builds a new YARPC client for the Oneway service.
 func NewOnewayYARPCClient(clientConfig transport.ClientConfig, options ...protobuf.ClientOption) OnewayYARPCClient {
-	return &_OnewayYARPCCaller{protobuf.NewClient(
+	return &_OnewayYARPCCaller{protobuf.NewStreamClient(
 		protobuf.ClientParams{
 			ServiceName:  "uber.yarpc.internal.crossdock.Oneway",
 			ClientConfig: clientConfig,
@@ -166,16 +200,49 @@ func BuildOnewayYARPCProcedures(server OnewayYARPCServer) []transport.Procedure
 					),
 				},
 			},
+			StreamHandlerParams: []protobuf.BuildProceduresStreamHandlerParams{},
 		},
 	)
 }
 
+// FxOnewayYARPCClientParams defines the input for
+// NewFxOnewayYARPCClient.
+type FxOnewayYARPCClientParams struct {
+	fx.In
+
+	Provider yarpc.ClientConfig
+}
+
+// FxOnewayYARPCClientResult defines the output of
+// NewFxOnewayYARPCClient. It provides a
+// OnewayYARPCClient to an Fx application.
+type FxOnewayYARPCClientResult struct {
+	fx.Out
+
+	Client OnewayYARPCClient
+
+	// We are using an fx.Out struct here instead of just returning a client
+	// so that we can add more values or add named versions of the client in
+	// the future without breaking any existing code.
+}
+
+// NewFxOnewayYARPCClient provides a OnewayYARPCClient
+// to an Fx application using the given name for routing. The returned value
+// should be passed to fx.Provide.
+func NewFxOnewayYARPCClient(name string, options ...protobuf.ClientOption) interface{} {
+	return func(params FxOnewayYARPCClientParams) FxOnewayYARPCClientResult {
+		return FxOnewayYARPCClientResult{
+			Client: NewOnewayYARPCClient(params.Provider.ClientConfig(name), options...),
+		}
+	}
+}
+
 type _OnewayYARPCCaller struct {
-	client protobuf.Client
+	streamClient protobuf.StreamClient
 }
 
 func (c *_OnewayYARPCCaller) Echo(ctx context.Context, request *Token, options ...yarpc.CallOption) (yarpc.Ack, error) {
-	return c.client.CallOneway(ctx, "Echo", request, options...)
+	return c.streamClient.CallOneway(ctx, "Echo", request, options...)
 }
 
 type _OnewayYARPCHandler struct {
@@ -25,6 +25,7 @@ import (
 
 	"go.uber.org/yarpc/api/middleware"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/sharedmetrics"
 )
 
 // UnaryChain combines a series of `UnaryInbound`s into a single `InboundMiddleware`.
@@ -60,6 +61,15 @@ func (c unaryChain) Handle(ctx context.Context, req *transport.Request, resw tra
 	}.Handle(ctx, req, resw)
 }
 
+// Metrics returns the metrics of the middleware in the chain.
+func (c unaryChain) Metrics() []*sharedmetrics.Set {
+	mw := make([]interface{}, len(c))
+	for i, m := range c {
+		mw[i] = m
+	}
+	return sharedmetrics.Collect(mw...)
+}
+
 // unaryChainExec adapts a series of `UnaryInbound`s into a UnaryHandler.
 // It is scoped to a single request to the `Handler` and is not thread-safe.
 type unaryChainExec struct {
@@ -109,6 +119,15 @@ func (c onewayChain) HandleOneway(ctx context.Context, req *transport.Request, h
 	}.HandleOneway(ctx, req)
 }
 
+// Metrics returns the metrics of the middleware in the chain.
+func (c onewayChain) Metrics() []*sharedmetrics.Set {
+	mw := make([]interface{}, len(c))
+	for i, m := range c {
+		mw[i] = m
+	}
+	return sharedmetrics.Collect(mw...)
+}
+
 // onewayChainExec adapts a series of `OnewayInbound`s into a OnewayHandler.
 // It is scoped to a single request to the `Handler` and is not thread-safe.
 type onewayChainExec struct {
@@ -124,3 +143,61 @@ func (x onewayChainExec) HandleOneway(ctx context.Context, req *transport.Reques
 	x.Chain = x.Chain[1:]
 	return next.HandleOneway(ctx, req, x)
 }
+
+// StreamChain combines a series of `StreamInbound`s into a single `InboundMiddleware`.
+func StreamChain(mw ...middleware.StreamInbound) middleware.StreamInbound {
+	unchained := make([]middleware.StreamInbound, 0, len(mw))
+	for _, m := range mw {
+		if m == nil {
+			continue
+		}
+		if c, ok := m.(streamChain); ok {
+			unchained = append(unchained, c...)
+			continue
+		}
+		unchained = append(unchained, m)
+	}
+
+	switch len(unchained) {
+	case 0:
+		return middleware.NopStreamInbound
+	case 1:
+		return unchained[0]
+	default:
+		return streamChain(unchained)
+	}
+}
+
+type streamChain []middleware.StreamInbound
+
+func (c streamChain) HandleStream(s *transport.ServerStream, h transport.StreamHandler) error {
+	return streamChainExec{
+		Chain: []middleware.StreamInbound(c),
+		Final: h,
+	}.HandleStream(s)
+}
+
+// Metrics returns the metrics of the middleware in the chain.
+func (c streamChain) Metrics() []*sharedmetrics.Set {
+	mw := make([]interface{}, len(c))
+	for i, m := range c {
+		mw[i] = m
+	}
+	return sharedmetrics.Collect(mw...)
+}
+
+// streamChainExec adapts a series of `StreamInbound`s into a StreamHandler.
+// It is scoped to a single request to the `Handler` and is not thread-safe.
+type streamChainExec struct {
+	Chain []middleware.StreamInbound
+	Final transport.StreamHandler
+}
+
+func (x streamChainExec) HandleStream(s *transport.ServerStream) error {
+	if len(x.Chain) == 0 {
+		return x.Final.HandleStream(s)
+	}
+	next := x.Chain[0]
+	x.Chain = x.Chain[1:]
+	return next.HandleStream(s, x)
+}
@@ -34,6 +34,33 @@ type OutboundStatus struct {
 	Chooser     ChooserStatus `json:"chooser"`
 	Service     string        `json:"service"`
 	OutboundKey string        `json:"outboundkey"`
+
+	// Outbounds lists the outbounds a composite outbound splits requests
+	// between.
+	Outbounds []WeightedOutboundStatus `json:"outbounds,omitempty"`
+
+	// Queue describes the local queue of an outbound which delivers
+	// requests in the background.
+	Queue *QueueStatus `json:"queue,omitempty"`
+
+	// Compressor is the name of the compressor used for request bodies, if
+	// any.
+	Compressor string `json:"compressor,omitempty"`
+}
+
+// WeightedOutboundStatus is the status of one of the outbounds of a composite
+// outbound, along with the weight of the requests it receives.
+type WeightedOutboundStatus struct {
+	Name     string         `json:"name"`
+	Weight   int            `json:"weight"`
+	Outbound OutboundStatus `json:"outbound"`
+}
+
+// QueueStatus is the status of the local queue of an outbound.
+type QueueStatus struct {
+	Name      string `json:"name"`
+	Directory string `json:"directory"`
+	Depth     int64  `json:"depth"`
 }
 
 // OutboundStatusNotSupported is returned when not valid OutboundStatus can be
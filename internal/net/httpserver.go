@@ -21,6 +21,7 @@
 package net
 
 import (
+	"crypto/tls"
 	"errors"
 	"net"
 	"net/http"
@@ -64,7 +65,8 @@ func (h *HTTPServer) Listener() net.Listener {
 
 // ListenAndServe starts the given HTTP server up in the background and
 // returns immediately. The server listens on the configured Addr or ":http"
-// if unconfigured.
+// if unconfigured. If the server has a TLSConfig, connections are served
+// over TLS.
 //
 // An error is returned if the server failed to start up, if the server was
 // already listening, or if the server was stopped with Stop().
@@ -90,6 +92,9 @@ func (h *HTTPServer) ListenAndServe() error {
 	if err != nil {
 		return err
 	}
+	if h.Server.TLSConfig != nil {
+		h.listener = tls.NewListener(h.listener, h.Server.TLSConfig)
+	}
 
 	go h.serve(h.listener)
 	return nil
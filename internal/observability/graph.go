@@ -133,6 +133,18 @@ func (g *graph) begin(ctx context.Context, rpcType transport.Type, isInbound boo
 	e := g.getOrCreateEdge(d.Digest(), req, direction)
 	d.Free()
 
+	if !isInbound {
+		if isRetryAttempt(ctx) {
+			e.retries.Inc()
+		}
+		if isHedgeAttempt(ctx) {
+			e.hedges.Inc()
+		}
+		if ref := edgeRefFromContext(ctx); ref != nil {
+			ref.bind(e)
+		}
+	}
+
 	return call{
 		edge:    e,
 		extract: g.extract,
@@ -180,6 +192,9 @@ type edge struct {
 
 	calls          pally.Counter
 	successes      pally.Counter
+	retries        pally.Counter
+	hedges         pally.Counter
+	hedgesWon      pally.Counter
 	callerFailures pally.CounterVector
 	serverFailures pally.CounterVector
 
@@ -218,6 +233,33 @@ func newEdge(logger *zap.Logger, reg *pally.Registry, req *transport.Request, di
 		logger.Error("Failed to create successes counter.", zap.Error(err))
 		successes = pally.NewNopCounter()
 	}
+	retries, err := reg.NewCounter(pally.Opts{
+		Name:        "retries",
+		Help:        "Number of RPC attempts that were retries of a failed attempt.",
+		ConstLabels: labels,
+	})
+	if err != nil {
+		logger.Error("Failed to create retries counter.", zap.Error(err))
+		retries = pally.NewNopCounter()
+	}
+	hedges, err := reg.NewCounter(pally.Opts{
+		Name:        "hedges",
+		Help:        "Number of RPC attempts that duplicated an attempt still in flight.",
+		ConstLabels: labels,
+	})
+	if err != nil {
+		logger.Error("Failed to create hedges counter.", zap.Error(err))
+		hedges = pally.NewNopCounter()
+	}
+	hedgesWon, err := reg.NewCounter(pally.Opts{
+		Name:        "hedges_won",
+		Help:        "Number of hedged RPC attempts that completed before the attempt they duplicated.",
+		ConstLabels: labels,
+	})
+	if err != nil {
+		logger.Error("Failed to create hedges won counter.", zap.Error(err))
+		hedgesWon = pally.NewNopCounter()
+	}
 	callerFailures, err := reg.NewCounterVector(pally.Opts{
 		Name:           "caller_failures",
 		Help:           "Number of RPCs failed because of caller error.",
@@ -290,6 +332,9 @@ func newEdge(logger *zap.Logger, reg *pally.Registry, req *transport.Request, di
 		logger:             logger,
 		calls:              calls,
 		successes:          successes,
+		retries:            retries,
+		hedges:             hedges,
+		hedgesWon:          hedgesWon,
 		callerFailures:     callerFailures,
 		serverFailures:     serverFailures,
 		latencies:          latencies,
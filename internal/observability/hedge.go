@@ -0,0 +1,104 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package observability
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/yarpc/internal/pally"
+)
+
+// _minLatencySamples is the number of recent calls an edge must observe
+// before its latency quantiles are used: quantiles estimated from fewer calls
+// are too noisy to derive hedging delays from.
+const _minLatencySamples = 20
+
+type hedgeAttemptKey struct{}
+
+// WithHedgeAttempt marks an outbound call made with the returned context as a
+// hedge: a duplicate of a call that is still in flight.
+func WithHedgeAttempt(ctx context.Context) context.Context {
+	return context.WithValue(ctx, hedgeAttemptKey{}, true)
+}
+
+func isHedgeAttempt(ctx context.Context) bool {
+	hedge, _ := ctx.Value(hedgeAttemptKey{}).(bool)
+	return hedge
+}
+
+type edgeRefKey struct{}
+
+// EdgeRef refers to the outbound edge under which calls are recorded. It
+// allows middleware placed before the observing middleware to read the
+// latencies of an edge and to update its hedging metrics.
+//
+// An EdgeRef is bound to an edge by the first outbound call made with a
+// context returned by WithEdgeRef. Until then, its methods are no-ops.
+type EdgeRef struct {
+	edge atomic.Value // *edge
+}
+
+// WithEdgeRef returns a context which binds the given EdgeRef to the edge of
+// outbound calls made with it.
+func WithEdgeRef(ctx context.Context, ref *EdgeRef) context.Context {
+	return context.WithValue(ctx, edgeRefKey{}, ref)
+}
+
+func edgeRefFromContext(ctx context.Context) *EdgeRef {
+	ref, _ := ctx.Value(edgeRefKey{}).(*EdgeRef)
+	return ref
+}
+
+func (r *EdgeRef) bind(e *edge) {
+	if r.load() != e {
+		r.edge.Store(e)
+	}
+}
+
+func (r *EdgeRef) load() *edge {
+	e, _ := r.edge.Load().(*edge)
+	return e
+}
+
+// LatencyQuantile estimates the q-quantile of the latency of successful calls
+// along the edge. It returns false if the edge is not yet known or has
+// observed fewer than 20 recent successful calls.
+func (r *EdgeRef) LatencyQuantile(q float64) (time.Duration, bool) {
+	e := r.load()
+	if e == nil {
+		return 0, false
+	}
+	quantiler, ok := e.latencies.(pally.Quantiler)
+	if !ok || quantiler.Observations() < _minLatencySamples {
+		return 0, false
+	}
+	return quantiler.Quantile(q)
+}
+
+// HedgeWon records that a hedged call completed before the call it
+// duplicated.
+func (r *EdgeRef) HedgeWon() {
+	if e := r.load(); e != nil {
+		e.hedgesWon.Inc()
+	}
+}
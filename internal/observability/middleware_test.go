@@ -361,6 +361,74 @@ func TestUnaryInboundApplicationErrors(t *testing.T) {
 	assert.Equal(t, expected, entry, "Unexpected log entry written.")
 }
 
+func TestMiddlewareRetryMetrics(t *testing.T) {
+	defer stubTime()()
+	req := &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: "procedure",
+		Body:      strings.NewReader("body"),
+	}
+
+	mw := NewMiddleware(zap.NewNop(), pally.NewRegistry(), NewNopContextExtractor())
+	ctx := context.Background()
+	mw.Call(ctx, req, fakeOutbound{})
+	mw.Call(WithRetryAttempt(ctx), req, fakeOutbound{})
+	mw.Call(WithRetryAttempt(ctx), req, fakeOutbound{})
+	mw.Handle(WithRetryAttempt(ctx), req, &transporttest.FakeResponseWriter{}, fakeHandler{nil, false})
+
+	key, free := getKey(req, _directionOutbound)
+	edge := mw.graph.getEdge(key)
+	free()
+	assert.Equal(t, int64(3), edge.calls.Load(), "Unexpected outbound calls.")
+	assert.Equal(t, int64(2), edge.retries.Load(), "Unexpected outbound retries.")
+
+	key, free = getKey(req, _directionInbound)
+	edge = mw.graph.getEdge(key)
+	free()
+	assert.Equal(t, int64(0), edge.retries.Load(), "Inbound calls must not count retries.")
+}
+
+func TestMiddlewareHedgeMetrics(t *testing.T) {
+	defer stubTime()()
+	req := &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: "procedure",
+		Body:      strings.NewReader("body"),
+	}
+
+	var ref EdgeRef
+	_, ok := ref.LatencyQuantile(0.5)
+	assert.False(t, ok, "Unbound edge must not report latencies.")
+	ref.HedgeWon() // must not panic
+
+	mw := NewMiddleware(zap.NewNop(), pally.NewRegistry(), NewNopContextExtractor())
+	ctx := WithEdgeRef(context.Background(), &ref)
+	mw.Call(ctx, req, fakeOutbound{})
+	mw.Call(WithHedgeAttempt(ctx), req, fakeOutbound{})
+	ref.HedgeWon()
+
+	key, free := getKey(req, _directionOutbound)
+	edge := mw.graph.getEdge(key)
+	free()
+	assert.Equal(t, int64(2), edge.calls.Load(), "Unexpected outbound calls.")
+	assert.Equal(t, int64(1), edge.hedges.Load(), "Unexpected outbound hedges.")
+	assert.Equal(t, int64(1), edge.hedgesWon.Load(), "Unexpected outbound hedges won.")
+
+	_, ok = ref.LatencyQuantile(0.5)
+	assert.False(t, ok, "Expected no latencies before enough calls are observed.")
+
+	for i := 2; i < _minLatencySamples; i++ {
+		mw.Call(ctx, req, fakeOutbound{})
+	}
+	latency, ok := ref.LatencyQuantile(0.5)
+	assert.True(t, ok, "Expected latencies once enough calls are observed.")
+	assert.True(t, latency > 0, "Expected a positive latency quantile.")
+}
+
 func TestMiddlewareStats(t *testing.T) {
 	defer stubTime()()
 	reg := pally.NewRegistry()
@@ -63,11 +63,59 @@ func (bs buckets) get(val int64) *bucket {
 	return bs[i]
 }
 
+// _quantileWindow is the length of the intervals over which quantiles are
+// estimated: quantiles cover the observations of the current and previous
+// intervals, so that they follow changes in latency.
+const _quantileWindow = time.Minute
+
+// quantileWindow keeps snapshots of the bucket counts at the start of the
+// current and previous intervals.
+type quantileWindow struct {
+	sync.Mutex
+
+	start time.Time // start of the current interval
+	prev  []int64   // counts at the start of the previous interval
+	cur   []int64   // counts at the start of the current interval
+}
+
+// recent turns cumulative bucket counts into the counts observed since the
+// start of the previous interval, starting a new interval if the current one
+// is over. Intervals start lazily, when quantiles are requested.
+func (w *quantileWindow) recent(now time.Time, counts []int64) []int64 {
+	w.Lock()
+	defer w.Unlock()
+
+	switch elapsed := now.Sub(w.start); {
+	case w.start.IsZero():
+		// Observations made before the first call belong to the current
+		// interval.
+		w.start = now
+	case elapsed >= 2*_quantileWindow:
+		// Nothing observed before now is recent.
+		w.start, w.prev, w.cur = now, counts, counts
+	case elapsed >= _quantileWindow:
+		w.start, w.prev, w.cur = now, w.cur, counts
+	}
+
+	recent := make([]int64, len(counts))
+	for i, n := range counts {
+		recent[i] = n
+		if w.prev != nil {
+			recent[i] -= w.prev[i]
+		}
+	}
+	return recent
+}
+
 type histogram struct {
 	buckets buckets
 	// Prometheus requires us to track the sum of all observed values.
 	sum atomic.Int64
 
+	// window limits quantiles to recent observations, using now as its clock.
+	window quantileWindow
+	now    func() time.Time
+
 	opts              LatencyOpts
 	desc              *prometheus.Desc
 	tally             tally.Histogram
@@ -91,6 +139,55 @@ func (h *histogram) Observe(d time.Duration) {
 	h.sum.Add(n)
 }
 
+func (h *histogram) Quantile(q float64) (time.Duration, bool) {
+	counts, total := h.recent()
+	if total == 0 {
+		return 0, false
+	}
+
+	rank := int64(math.Ceil(q * float64(total)))
+	if rank < 1 {
+		rank = 1
+	}
+	var n int64
+	for i, b := range h.buckets {
+		n += counts[i]
+		if n < rank {
+			continue
+		}
+		if b.upper == math.MaxInt64 {
+			break
+		}
+		return time.Duration(b.upper) * h.opts.Unit, true
+	}
+	return 0, false
+}
+
+func (h *histogram) Observations() int64 {
+	_, total := h.recent()
+	return total
+}
+
+// recent returns the bucket counts of the recent observations, and their
+// total.
+func (h *histogram) recent() ([]int64, int64) {
+	counts := make([]int64, len(h.buckets))
+	for i, b := range h.buckets {
+		counts[i] = b.Load()
+	}
+	now := time.Now
+	if h.now != nil {
+		now = h.now
+	}
+	counts = h.window.recent(now(), counts)
+
+	var total int64
+	for _, n := range counts {
+		total += n
+	}
+	return counts, total
+}
+
 func (h *histogram) push(scope tally.Scope) {
 	if h.opts.DisableTally {
 		return
@@ -25,6 +25,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/yarpc/internal/pally/pallytest"
 )
@@ -78,6 +79,93 @@ func TestLatencies(t *testing.T) {
 		`test_latency_ns_count{foo="bar",service="users"} 5`)
 }
 
+func TestLatenciesQuantile(t *testing.T) {
+	r := NewRegistry()
+	lat, err := r.NewLatencies(LatencyOpts{
+		Opts: Opts{
+			Name: "test_latency_ms",
+			Help: "Some help.",
+		},
+		Unit:    time.Millisecond,
+		Buckets: []time.Duration{10 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond},
+	})
+	require.NoError(t, err, "Unexpected error constructing latencies.")
+
+	q, ok := lat.(Quantiler)
+	require.True(t, ok, "Expected latencies to implement Quantiler.")
+
+	_, ok = q.Quantile(0.5)
+	assert.False(t, ok, "Expected no quantile without observations.")
+
+	for i := 0; i < 8; i++ {
+		lat.Observe(5 * time.Millisecond)
+	}
+	lat.Observe(75 * time.Millisecond)
+	lat.Observe(time.Second)
+	assert.Equal(t, int64(10), q.Observations(), "Unexpected number of observations.")
+
+	tests := []struct {
+		q      float64
+		want   time.Duration
+		wantOK bool
+	}{
+		{q: 0, want: 10 * time.Millisecond, wantOK: true},
+		{q: 0.5, want: 10 * time.Millisecond, wantOK: true},
+		{q: 0.75, want: 10 * time.Millisecond, wantOK: true},
+		{q: 0.85, want: 100 * time.Millisecond, wantOK: true},
+		{q: 0.99, wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := q.Quantile(tt.q)
+		assert.Equal(t, tt.wantOK, ok, "Unexpected ok for quantile %v.", tt.q)
+		assert.Equal(t, tt.want, got, "Unexpected value for quantile %v.", tt.q)
+	}
+}
+
+func TestLatenciesQuantileWindow(t *testing.T) {
+	lat, err := NewRegistry().NewLatencies(LatencyOpts{
+		Opts: Opts{
+			Name: "test_latency_ms",
+			Help: "Some help.",
+		},
+		Unit:    time.Millisecond,
+		Buckets: []time.Duration{10 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond},
+	})
+	require.NoError(t, err, "Unexpected error constructing latencies.")
+
+	now := time.Unix(0, 0)
+	h := lat.(*histogram)
+	h.now = func() time.Time { return now }
+
+	observe := func(d time.Duration) {
+		for i := 0; i < 10; i++ {
+			lat.Observe(d)
+		}
+	}
+	assertMedian := func(want time.Duration, msg string) {
+		got, ok := h.Quantile(0.5)
+		if assert.True(t, ok, msg) {
+			assert.Equal(t, want, got, msg)
+		}
+	}
+
+	observe(5 * time.Millisecond)
+	assertMedian(10*time.Millisecond, "only fast observations")
+
+	now = now.Add(_quantileWindow)
+	assertMedian(10*time.Millisecond, "previous interval must still count")
+	observe(75 * time.Millisecond)
+	assertMedian(10*time.Millisecond, "both intervals must count")
+
+	now = now.Add(_quantileWindow)
+	assertMedian(100*time.Millisecond, "observations older than the previous interval must not count")
+
+	now = now.Add(2 * _quantileWindow)
+	_, ok := h.Quantile(0.5)
+	assert.False(t, ok, "Expected no quantile without recent observations.")
+	assert.Equal(t, int64(0), h.Observations(), "Expected no recent observations.")
+}
+
 func TestLatenciesVector(t *testing.T) {
 	tests := []struct {
 		desc      string
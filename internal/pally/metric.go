@@ -76,6 +76,20 @@ type Latencies interface {
 	Observe(time.Duration)
 }
 
+// A Quantiler estimates quantiles of a latency distribution. Latencies
+// created by a Registry implement Quantiler.
+type Quantiler interface {
+	// Quantile returns an upper bound for the q-quantile of the latencies
+	// observed recently, where q is between 0 and 1. It returns false if there
+	// are no recent observations or if the quantile lies beyond the largest
+	// bucket.
+	Quantile(q float64) (time.Duration, bool)
+
+	// Observations returns the number of latencies observed recently, over
+	// which quantiles are estimated.
+	Observations() int64
+}
+
 // A LatenciesVector is a collection of Latencies that share a name and some
 // constant labels, but also have an enumerated set of variable labels.
 type LatenciesVector interface {
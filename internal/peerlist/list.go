@@ -0,0 +1,388 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package peerlist implements the bookkeeping shared by peer lists which
+// choose among their available peers, leaving the choice to an
+// Implementation.
+package peerlist
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.uber.org/atomic"
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/internal/triedpeers"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// Implementation chooses among the available peers of a List.
+//
+// The List calls Add and Remove while holding its write lock, and Choose
+// while holding its read lock, so Choose must be safe for concurrent use
+// with other calls to Choose.
+type Implementation interface {
+	// Add adds a peer which became available.
+	Add(peer.Peer)
+
+	// Remove removes a peer which became unavailable or left the list.
+	Remove(peer.Peer)
+
+	// Choose returns one of the available peers, or nil if there are none.
+	Choose(ctx context.Context, req *transport.Request) peer.Peer
+}
+
+// List is a peer list which retains the peers of the list when started,
+// tracks which are available, and chooses among the available peers with
+// its Implementation.
+type List struct {
+	lock sync.RWMutex
+	name string
+
+	shouldRetainPeers  atomic.Bool
+	uninitializedPeers map[string]peer.Identifier
+
+	availablePeers     map[string]peer.Peer
+	unavailablePeers   map[string]peer.Peer
+	peerAvailableEvent chan struct{}
+	transport          peer.Transport
+	impl               Implementation
+
+	once *lifecycle.Once
+}
+
+// New returns a List of peers retained from the given transport. The name
+// of the list is used in errors and introspection.
+func New(name string, transport peer.Transport, impl Implementation, capacity int) *List {
+	return &List{
+		once:               lifecycle.NewOnce(),
+		name:               name,
+		uninitializedPeers: make(map[string]peer.Identifier, capacity),
+		availablePeers:     make(map[string]peer.Peer, capacity),
+		unavailablePeers:   make(map[string]peer.Peer, capacity),
+		peerAvailableEvent: make(chan struct{}, 1),
+		transport:          transport,
+		impl:               impl,
+	}
+}
+
+// Update applies the additions and removals of peer Identifiers to the list
+// it returns a multi-error result of every failure that happened without
+// circuit breaking due to failures.
+func (pl *List) Update(updates peer.ListUpdates) error {
+	if len(updates.Additions) == 0 && len(updates.Removals) == 0 {
+		return nil
+	}
+
+	pl.lock.Lock()
+	defer pl.lock.Unlock()
+
+	if pl.shouldRetainPeers.Load() {
+		return pl.updateInitialized(updates)
+	}
+	return pl.updateUninitialized(updates)
+}
+
+// updateInitialized applies peer list updates when the peer list
+// is able to retain peers, putting the updates into the available
+// or unavailable containers.
+//
+// Must be run inside a mutex.Lock()
+func (pl *List) updateInitialized(updates peer.ListUpdates) error {
+	var errs error
+	for _, pid := range updates.Removals {
+		errs = multierr.Append(errs, pl.removePeerIdentifier(pid))
+	}
+	for _, pid := range updates.Additions {
+		errs = multierr.Append(errs, pl.addPeerIdentifier(pid))
+	}
+	return errs
+}
+
+// updateUninitialized applies peer list updates when the peer list
+// is **not** able to retain peers, putting the updates into a single
+// uninitialized peer list.
+//
+// Must be run inside a mutex.Lock()
+func (pl *List) updateUninitialized(updates peer.ListUpdates) error {
+	var errs error
+	for _, pid := range updates.Removals {
+		if _, ok := pl.uninitializedPeers[pid.Identifier()]; ok {
+			delete(pl.uninitializedPeers, pid.Identifier())
+		} else {
+			errs = multierr.Append(errs, peer.ErrPeerRemoveNotInList(pid.Identifier()))
+		}
+	}
+	for _, pid := range updates.Additions {
+		pl.uninitializedPeers[pid.Identifier()] = pid
+	}
+	return errs
+}
+
+// Must be run inside a mutex.Lock()
+func (pl *List) addPeerIdentifier(pid peer.Identifier) error {
+	id := pid.Identifier()
+	if _, ok := pl.availablePeers[id]; ok {
+		return peer.ErrPeerAddAlreadyInList(id)
+	}
+	if _, ok := pl.unavailablePeers[id]; ok {
+		return peer.ErrPeerAddAlreadyInList(id)
+	}
+
+	p, err := pl.transport.RetainPeer(pid, pl)
+	if err != nil {
+		return err
+	}
+
+	if p.Status().ConnectionStatus == peer.Available {
+		pl.addToAvailablePeers(p)
+	} else {
+		pl.unavailablePeers[id] = p
+	}
+	return nil
+}
+
+// Must be run inside a mutex.Lock()
+func (pl *List) addToAvailablePeers(p peer.Peer) {
+	pl.availablePeers[p.Identifier()] = p
+	pl.impl.Add(p)
+	pl.notifyPeerAvailable()
+}
+
+// Must be run inside a mutex.Lock()
+func (pl *List) removeFromAvailablePeers(p peer.Peer) {
+	delete(pl.availablePeers, p.Identifier())
+	pl.impl.Remove(p)
+}
+
+// removePeerIdentifier will go remove references to the peer identifier and release
+// it from the transport
+// Must be run in a mutex.Lock()
+func (pl *List) removePeerIdentifier(pid peer.Identifier) error {
+	id := pid.Identifier()
+	if p, ok := pl.availablePeers[id]; ok {
+		pl.removeFromAvailablePeers(p)
+	} else if _, ok := pl.unavailablePeers[id]; ok {
+		delete(pl.unavailablePeers, id)
+	} else {
+		return peer.ErrPeerRemoveNotInList(id)
+	}
+	return pl.transport.ReleasePeer(pid, pl)
+}
+
+// Start notifies the List that requests will start coming
+func (pl *List) Start() error {
+	return pl.once.Start(pl.start)
+}
+
+func (pl *List) start() error {
+	pl.lock.Lock()
+	defer pl.lock.Unlock()
+
+	// Add peers in a deterministic order, so that implementations with an
+	// injected source of randomness choose peers deterministically.
+	ids := make([]string, 0, len(pl.uninitializedPeers))
+	for id := range pl.uninitializedPeers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var errs error
+	for _, id := range ids {
+		errs = multierr.Append(errs, pl.addPeerIdentifier(pl.uninitializedPeers[id]))
+		delete(pl.uninitializedPeers, id)
+	}
+
+	pl.shouldRetainPeers.Store(true)
+
+	return errs
+}
+
+// Stop notifies the List that requests will stop coming
+func (pl *List) Stop() error {
+	return pl.once.Stop(pl.clearPeers)
+}
+
+// clearPeers will release all the peers from the list
+func (pl *List) clearPeers() error {
+	pl.lock.Lock()
+	defer pl.lock.Unlock()
+
+	var errs error
+	for id, p := range pl.availablePeers {
+		pl.removeFromAvailablePeers(p)
+		errs = multierr.Append(errs, pl.transport.ReleasePeer(p, pl))
+		pl.uninitializedPeers[id] = p
+	}
+	for id, p := range pl.unavailablePeers {
+		delete(pl.unavailablePeers, id)
+		errs = multierr.Append(errs, pl.transport.ReleasePeer(p, pl))
+		pl.uninitializedPeers[id] = p
+	}
+
+	pl.shouldRetainPeers.Store(false)
+
+	return errs
+}
+
+// IsRunning returns whether the peer list is running.
+func (pl *List) IsRunning() bool {
+	return pl.once.IsRunning()
+}
+
+// Choose selects an available peer with the Implementation, waiting for one
+// to become available until the context finishes.
+func (pl *List) Choose(ctx context.Context, req *transport.Request) (peer.Peer, func(error), error) {
+	if err := pl.once.WaitUntilRunning(ctx); err != nil {
+		return nil, nil, yarpcerrors.Newf(yarpcerrors.CodeFailedPrecondition,
+			"%s peer list is not running: %s", pl.name, err.Error())
+	}
+
+	tried := triedpeers.FromContext(ctx)
+	for {
+		pl.lock.RLock()
+		p := pl.choose(ctx, req, tried)
+		pl.lock.RUnlock()
+
+		if p != nil {
+			pl.notifyPeerAvailable()
+			tried.Add(p.Identifier())
+			p.StartRequest()
+			return p, func(error) { p.EndRequest() }, nil
+		}
+
+		if err := pl.waitForPeerAddedEvent(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// choose asks the Implementation for a peer. If it returns a peer already
+// tried for the request, choose picks an available peer not yet tried
+// instead, if there is one.
+// Must be run in a mutex.RLock()
+func (pl *List) choose(ctx context.Context, req *transport.Request, tried *triedpeers.Set) peer.Peer {
+	p := pl.impl.Choose(ctx, req)
+	if p == nil || !tried.Contains(p.Identifier()) {
+		return p
+	}
+	for id, other := range pl.availablePeers {
+		if !tried.Contains(id) {
+			return other
+		}
+	}
+	return p
+}
+
+// notifyPeerAvailable writes to a channel indicating that a Peer is currently
+// available for requests
+func (pl *List) notifyPeerAvailable() {
+	select {
+	case pl.peerAvailableEvent <- struct{}{}:
+	default:
+	}
+}
+
+// waitForPeerAddedEvent waits until a peer is added to the peer list or the
+// given context finishes.
+// Must NOT be run in a mutex.Lock()
+func (pl *List) waitForPeerAddedEvent(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument,
+			"can't wait for peer without a context deadline for %s list", pl.name)
+	}
+
+	select {
+	case <-pl.peerAvailableEvent:
+		return nil
+	case <-ctx.Done():
+		return yarpcerrors.Newf(yarpcerrors.CodeUnavailable,
+			"%s peer list timed out waiting for peer: %s", pl.name, ctx.Err().Error())
+	}
+}
+
+// NotifyStatusChanged when the peer's status changes
+func (pl *List) NotifyStatusChanged(pid peer.Identifier) {
+	pl.lock.Lock()
+	defer pl.lock.Unlock()
+
+	id := pid.Identifier()
+	if p, ok := pl.availablePeers[id]; ok {
+		if p.Status().ConnectionStatus != peer.Available {
+			pl.removeFromAvailablePeers(p)
+			pl.unavailablePeers[id] = p
+		}
+		return
+	}
+
+	if p, ok := pl.unavailablePeers[id]; ok {
+		if p.Status().ConnectionStatus == peer.Available {
+			delete(pl.unavailablePeers, id)
+			pl.addToAvailablePeers(p)
+		}
+	}
+	// No action required
+}
+
+// Introspect returns a ChooserStatus with a summary of the Peers.
+func (pl *List) Introspect() introspection.ChooserStatus {
+	state := "Stopped"
+	if pl.IsRunning() {
+		state = "Running"
+	}
+
+	pl.lock.RLock()
+	available := len(pl.availablePeers)
+	peers := make([]peer.Peer, 0, len(pl.availablePeers)+len(pl.unavailablePeers))
+	for _, p := range pl.availablePeers {
+		peers = append(peers, p)
+	}
+	for _, p := range pl.unavailablePeers {
+		peers = append(peers, p)
+	}
+	pl.lock.RUnlock()
+
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Identifier() < peers[j].Identifier()
+	})
+
+	peersStatus := make([]introspection.PeerStatus, 0, len(peers))
+	for _, p := range peers {
+		ps := p.Status()
+		peersStatus = append(peersStatus, introspection.PeerStatus{
+			Identifier: p.Identifier(),
+			State: fmt.Sprintf("%s, %d pending request(s)",
+				ps.ConnectionStatus.String(),
+				ps.PendingRequestCount),
+		})
+	}
+
+	return introspection.ChooserStatus{
+		Name:  pl.name,
+		State: fmt.Sprintf("%s (%d/%d available)", state, available, len(peers)),
+		Peers: peersStatus,
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peerlist
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/peer"
+	. "go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/internal/triedpeers"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// fakeTransport retains LightMockPeers, available unless listed as
+// unavailable, and keeps track of the peers retained by the list.
+type fakeTransport struct {
+	peers       map[string]*LightMockPeer
+	unavailable map[string]bool
+	retained    map[string]struct{}
+}
+
+func newFakeTransport(unavailable ...string) *fakeTransport {
+	t := &fakeTransport{
+		peers:       make(map[string]*LightMockPeer),
+		unavailable: make(map[string]bool),
+		retained:    make(map[string]struct{}),
+	}
+	for _, id := range unavailable {
+		t.unavailable[id] = true
+	}
+	return t
+}
+
+func (t *fakeTransport) RetainPeer(pid peer.Identifier, _ peer.Subscriber) (peer.Peer, error) {
+	p, ok := t.peers[pid.Identifier()]
+	if !ok {
+		status := peer.Available
+		if t.unavailable[pid.Identifier()] {
+			status = peer.Unavailable
+		}
+		p = NewLightMockPeer(MockPeerIdentifier(pid.Identifier()), status)
+		t.peers[pid.Identifier()] = p
+	}
+	t.retained[pid.Identifier()] = struct{}{}
+	return p, nil
+}
+
+func (t *fakeTransport) ReleasePeer(pid peer.Identifier, _ peer.Subscriber) error {
+	delete(t.retained, pid.Identifier())
+	return nil
+}
+
+// firstImplementation chooses the available peer with the lowest
+// identifier.
+type firstImplementation struct {
+	peers map[string]peer.Peer
+}
+
+func newFirstImplementation() *firstImplementation {
+	return &firstImplementation{peers: make(map[string]peer.Peer)}
+}
+
+func (f *firstImplementation) Add(p peer.Peer)    { f.peers[p.Identifier()] = p }
+func (f *firstImplementation) Remove(p peer.Peer) { delete(f.peers, p.Identifier()) }
+
+func (f *firstImplementation) Choose(context.Context, *transport.Request) peer.Peer {
+	ids := f.IDs()
+	if len(ids) == 0 {
+		return nil
+	}
+	return f.peers[ids[0]]
+}
+
+func (f *firstImplementation) IDs() []string {
+	ids := make([]string, 0, len(f.peers))
+	for id := range f.peers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestListLifecycle(t *testing.T) {
+	trans := newFakeTransport("2")
+	impl := newFirstImplementation()
+	pl := New("first", trans, impl, 10)
+
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: CreatePeerIDs([]string{"1", "2", "3"})}))
+	require.NoError(t, pl.Update(peer.ListUpdates{Removals: CreatePeerIDs([]string{"3"})}))
+	assert.Error(t, pl.Update(peer.ListUpdates{Removals: CreatePeerIDs([]string{"3"})}),
+		"removing missing peers must fail")
+	assert.Empty(t, trans.retained, "peers must not be retained before starting")
+
+	require.NoError(t, pl.Start())
+	assert.True(t, pl.IsRunning())
+	assert.Len(t, trans.retained, 2)
+	assert.Equal(t, []string{"1"}, impl.IDs(), "only available peers must be added")
+
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: CreatePeerIDs([]string{"0"})}))
+	assert.Equal(t, []string{"0", "1"}, impl.IDs())
+
+	err := pl.Update(peer.ListUpdates{
+		Additions: CreatePeerIDs([]string{"1", "2"}),
+		Removals:  CreatePeerIDs([]string{"3"}),
+	})
+	assert.Len(t, multierr.Errors(err), 3, "duplicate and missing peers must fail")
+
+	require.NoError(t, pl.Stop())
+	assert.False(t, pl.IsRunning())
+	assert.Empty(t, trans.retained, "peers must be released when stopping")
+	assert.Empty(t, impl.IDs())
+	assert.Len(t, pl.uninitializedPeers, 3)
+}
+
+func TestListStatusChanges(t *testing.T) {
+	trans := newFakeTransport("2")
+	impl := newFirstImplementation()
+	pl := New("first", trans, impl, 10)
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: CreatePeerIDs([]string{"1", "2"})}))
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	trans.peers["1"].PeerStatus.ConnectionStatus = peer.Unavailable
+	pl.NotifyStatusChanged(MockPeerIdentifier("1"))
+	trans.peers["2"].PeerStatus.ConnectionStatus = peer.Available
+	pl.NotifyStatusChanged(MockPeerIdentifier("2"))
+	pl.NotifyStatusChanged(MockPeerIdentifier("2"))
+	pl.NotifyStatusChanged(MockPeerIdentifier("missing"))
+	assert.Equal(t, []string{"2"}, impl.IDs())
+
+	require.NoError(t, pl.Update(peer.ListUpdates{Removals: CreatePeerIDs([]string{"1", "2"})}))
+	assert.Empty(t, impl.IDs())
+	assert.Empty(t, trans.retained)
+}
+
+func TestListChoose(t *testing.T) {
+	trans := newFakeTransport()
+	pl := New("first", trans, newFirstImplementation(), 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err := pl.Choose(ctx, &transport.Request{})
+	assert.Equal(t, yarpcerrors.CodeFailedPrecondition, yarpcerrors.FromError(err).Code())
+	assert.Contains(t, err.Error(), "first peer list is not running")
+
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	_, _, err = pl.Choose(context.Background(), &transport.Request{})
+	assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err = pl.Choose(ctx, &transport.Request{})
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+
+	chosen := make(chan peer.Peer)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		p, onFinish, _ := pl.Choose(ctx, &transport.Request{})
+		if onFinish != nil {
+			onFinish(nil)
+		}
+		chosen <- p
+	}()
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: CreatePeerIDs([]string{"1"})}))
+	p := <-chosen
+	require.NotNil(t, p, "must choose the added peer")
+	assert.Equal(t, "1", p.Identifier())
+
+	p, onFinish, err := pl.Choose(ctx, &transport.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.Status().PendingRequestCount)
+	onFinish(nil)
+	assert.Equal(t, 0, p.Status().PendingRequestCount)
+}
+
+func TestListIntrospect(t *testing.T) {
+	trans := newFakeTransport("2")
+	pl := New("first", trans, newFirstImplementation(), 10)
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: CreatePeerIDs([]string{"2", "1"})}))
+	assert.Equal(t, "Stopped (0/0 available)", pl.Introspect().State)
+
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+	assert.Equal(t, introspection.ChooserStatus{
+		Name:  "first",
+		State: "Running (1/2 available)",
+		Peers: []introspection.PeerStatus{
+			{Identifier: "1", State: "Available, 0 pending request(s)"},
+			{Identifier: "2", State: "Unavailable, 0 pending request(s)"},
+		},
+	}, pl.Introspect())
+}
+
+func TestListChooseAvoidsTriedPeers(t *testing.T) {
+	trans := newFakeTransport()
+	pl := New("first", trans, newFirstImplementation(), 10)
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: CreatePeerIDs([]string{"1", "2"})}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx = triedpeers.NewContext(ctx, &triedpeers.Set{})
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		p, onFinish, err := pl.Choose(ctx, &transport.Request{})
+		require.NoError(t, err)
+		onFinish(nil)
+		ids = append(ids, p.Identifier())
+	}
+	assert.Equal(t, []string{"1", "2", "1"}, ids,
+		"must choose untried peers first, then fall back to the implementation")
+}
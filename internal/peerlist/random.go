@@ -0,0 +1,73 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peerlist
+
+import (
+	"math/rand"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// Random is a source of pseudo-random numbers for Implementations which
+// choose peers at random. Unlike a rand.Rand, it is safe for concurrent use
+// without a mutex, so concurrent calls to Choose don't contend on a lock.
+//
+// Numbers are generated with an xorshift* generator whose state is advanced
+// with compare-and-swap. They are not suitable for cryptographic use.
+type Random struct {
+	state atomic.Uint64
+}
+
+// NewRandom returns a Random seeded from the given source, which makes
+// choices deterministic in tests. The seed is the current time if source is
+// nil.
+func NewRandom(source rand.Source) *Random {
+	seed := uint64(time.Now().UnixNano())
+	if source != nil {
+		seed = uint64(source.Int63())
+	}
+	if seed == 0 {
+		// The state of an xorshift generator must not be zero.
+		seed = 1
+	}
+	r := &Random{}
+	r.state.Store(seed)
+	return r
+}
+
+// Intn returns a pseudo-random number in [0, n). It panics if n <= 0.
+func (r *Random) Intn(n int) int {
+	if n <= 0 {
+		panic("peerlist: invalid argument to Intn")
+	}
+	for {
+		old := r.state.Load()
+		next := old
+		next ^= next >> 12
+		next ^= next << 25
+		next ^= next >> 27
+		if r.state.CAS(old, next) {
+			// The high bits of xorshift* are the most random.
+			return int(((next * 2685821657736338717) >> 1) % uint64(n))
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peerlist
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomIntn(t *testing.T) {
+	r := NewRandom(nil)
+	counts := make([]int, 4)
+	for i := 0; i < 4000; i++ {
+		n := r.Intn(len(counts))
+		assert.True(t, n >= 0 && n < len(counts), "out of range: %d", n)
+		counts[n]++
+	}
+	for i, c := range counts {
+		assert.InDelta(t, 1000, c, 200, "value %d is not uniform", i)
+	}
+
+	assert.Panics(t, func() { r.Intn(0) })
+}
+
+func TestRandomDeterministic(t *testing.T) {
+	first := NewRandom(rand.NewSource(42))
+	second := NewRandom(rand.NewSource(42))
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, first.Intn(1000), second.Intn(1000))
+	}
+}
+
+func TestRandomConcurrent(t *testing.T) {
+	r := NewRandom(nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				r.Intn(10)
+			}
+		}()
+	}
+	wg.Wait()
+}
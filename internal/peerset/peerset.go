@@ -0,0 +1,123 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package peerset tracks the peers a peer list updater added to a peer list.
+package peerset
+
+import (
+	"sort"
+	"sync"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/peer/hostport"
+)
+
+// Set tracks the peers added to a peer list, and applies changes to them as
+// incremental peer.ListUpdates.
+type Set struct {
+	lock  sync.Mutex
+	list  peer.List
+	peers map[string]int // weight by address
+}
+
+// New returns an empty Set for the given peer list.
+func New(list peer.List) *Set {
+	return &Set{list: list, peers: make(map[string]int)}
+}
+
+// Replace updates the peer list so that it holds exactly the given host:port
+// addresses, with the given weights. Addresses with a weight of 0 have the
+// default weight. Only the difference with the peers currently in the set is
+// applied, including the weights of peers whose weight changed, and the list
+// is not updated if nothing changed.
+//
+// The set only records the new peers if the list was updated successfully,
+// so that replacing the peers again after a failure retries the update.
+func (s *Set) Replace(addrs map[string]int) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	peers := make(map[string]int, len(addrs))
+	additions := make(map[string]struct{})
+	weights := make(map[string]int)
+	for addr, weight := range addrs {
+		if weight == 0 {
+			weight = peer.DefaultWeight
+		}
+		peers[addr] = weight
+
+		oldWeight, ok := s.peers[addr]
+		switch {
+		case !ok:
+			additions[addr] = struct{}{}
+			if weight != peer.DefaultWeight {
+				weights[addr] = weight
+			}
+		case oldWeight != weight:
+			weights[addr] = weight
+		}
+	}
+	removals := make(map[string]struct{})
+	for addr := range s.peers {
+		if _, ok := addrs[addr]; !ok {
+			removals[addr] = struct{}{}
+		}
+	}
+	if len(additions) == 0 && len(removals) == 0 && len(weights) == 0 {
+		return nil
+	}
+	updates := peer.ListUpdates{
+		Additions: Identifiers(additions),
+		Removals:  Identifiers(removals),
+	}
+	if len(weights) > 0 {
+		updates.Weights = weights
+	}
+	if err := s.list.Update(updates); err != nil {
+		return err
+	}
+	s.peers = peers
+	return nil
+}
+
+// RemoveAll removes all peers in the set from the peer list.
+func (s *Set) RemoveAll() error {
+	return s.Replace(nil)
+}
+
+// Identifiers returns the given host:port addresses as sorted peer
+// identifiers, or nil if there are none.
+func Identifiers(addrs map[string]struct{}) []peer.Identifier {
+	if len(addrs) == 0 {
+		return nil
+	}
+
+	sorted := make([]string, 0, len(addrs))
+	for addr := range addrs {
+		sorted = append(sorted, addr)
+	}
+	sort.Strings(sorted)
+
+	ids := make([]peer.Identifier, len(sorted))
+	for i, addr := range sorted {
+		ids[i] = hostport.PeerIdentifier(addr)
+	}
+	return ids
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peerset
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+)
+
+type recordingList struct {
+	updates []peer.ListUpdates
+	err     error
+}
+
+func (l *recordingList) Update(updates peer.ListUpdates) error {
+	l.updates = append(l.updates, updates)
+	return l.err
+}
+
+func addrs(addrs ...string) map[string]struct{} {
+	m := make(map[string]struct{}, len(addrs))
+	for _, addr := range addrs {
+		m[addr] = struct{}{}
+	}
+	return m
+}
+
+// weights returns the given addresses with the default weight.
+func weights(addrs ...string) map[string]int {
+	m := make(map[string]int, len(addrs))
+	for _, addr := range addrs {
+		m[addr] = 0
+	}
+	return m
+}
+
+func TestSet(t *testing.T) {
+	list := &recordingList{}
+	set := New(list)
+
+	require.NoError(t, set.Replace(weights("127.0.0.1:2", "127.0.0.1:1")))
+	require.NoError(t, set.Replace(weights("127.0.0.1:2", "127.0.0.1:1")))
+	require.NoError(t, set.Replace(weights("127.0.0.1:2", "127.0.0.1:3")))
+	require.NoError(t, set.RemoveAll())
+	require.NoError(t, set.RemoveAll())
+
+	assert.Equal(t, []peer.ListUpdates{
+		{Additions: Identifiers(addrs("127.0.0.1:1", "127.0.0.1:2"))},
+		{
+			Additions: Identifiers(addrs("127.0.0.1:3")),
+			Removals:  Identifiers(addrs("127.0.0.1:1")),
+		},
+		{Removals: Identifiers(addrs("127.0.0.1:2", "127.0.0.1:3"))},
+	}, list.updates, "only changes must be applied")
+}
+
+func TestSetWeights(t *testing.T) {
+	list := &recordingList{}
+	set := New(list)
+
+	require.NoError(t, set.Replace(map[string]int{"127.0.0.1:1": 2, "127.0.0.1:2": 0}))
+	require.NoError(t, set.Replace(map[string]int{"127.0.0.1:1": 2, "127.0.0.1:2": 1}))
+	require.NoError(t, set.Replace(map[string]int{"127.0.0.1:1": 0, "127.0.0.1:2": 3}))
+
+	assert.Equal(t, []peer.ListUpdates{
+		{
+			Additions: Identifiers(addrs("127.0.0.1:1", "127.0.0.1:2")),
+			Weights:   map[string]int{"127.0.0.1:1": 2},
+		},
+		{Weights: map[string]int{"127.0.0.1:1": 1, "127.0.0.1:2": 3}},
+	}, list.updates, "only changed weights must be applied")
+}
+
+func TestSetUpdateError(t *testing.T) {
+	list := &recordingList{err: errors.New("great sadness")}
+	set := New(list)
+	assert.EqualError(t, set.Replace(map[string]int{"127.0.0.1:1": 0}), "great sadness")
+}
+
+func TestSetRetriesFailedUpdate(t *testing.T) {
+	list := &recordingList{err: errors.New("great sadness")}
+	set := New(list)
+	require.Error(t, set.Replace(weights("127.0.0.1:1")))
+
+	list.err = nil
+	require.NoError(t, set.Replace(weights("127.0.0.1:1")))
+	require.NoError(t, set.Replace(weights("127.0.0.1:1")))
+
+	add := peer.ListUpdates{Additions: Identifiers(addrs("127.0.0.1:1"))}
+	assert.Equal(t, []peer.ListUpdates{add, add}, list.updates,
+		"failed updates must be retried, and only once they succeed are they skipped")
+}
+
+func TestIdentifiers(t *testing.T) {
+	assert.Nil(t, Identifiers(nil))
+
+	ids := Identifiers(addrs("127.0.0.1:2", "127.0.0.1:1"))
+	require.Len(t, ids, 2)
+	assert.Equal(t, "127.0.0.1:1", ids[0].Identifier())
+	assert.Equal(t, "127.0.0.1:2", ids[1].Identifier())
+}
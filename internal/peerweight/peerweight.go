@@ -0,0 +1,50 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package peerweight computes the effective weight of peers in weighted peer
+// lists, which ramp new peers up to their weight over a slow start window.
+package peerweight
+
+import "time"
+
+// MinSlowStartFactor is the fraction of its weight a peer starts with when
+// its slow start begins, so that new peers still receive some traffic.
+const MinSlowStartFactor = 0.1
+
+// Effective returns the weight of a peer which became available at the
+// given time, ramping linearly from MinSlowStartFactor of its weight to its
+// full weight over the slow start window. A window of 0 disables slow start.
+func Effective(weight int, since, now time.Time, slowStart time.Duration) float64 {
+	w := float64(weight)
+	if slowStart <= 0 {
+		return w
+	}
+
+	elapsed := now.Sub(since)
+	if elapsed >= slowStart {
+		return w
+	}
+
+	factor := float64(elapsed) / float64(slowStart)
+	if factor < MinSlowStartFactor {
+		factor = MinSlowStartFactor
+	}
+	return w * factor
+}
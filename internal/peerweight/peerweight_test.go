@@ -0,0 +1,54 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peerweight
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEffective(t *testing.T) {
+	since := time.Unix(1000, 0)
+
+	tests := []struct {
+		desc      string
+		weight    int
+		elapsed   time.Duration
+		slowStart time.Duration
+		want      float64
+	}{
+		{desc: "no slow start", weight: 4, want: 4},
+		{desc: "start of window", weight: 4, slowStart: time.Minute, want: 0.4},
+		{desc: "early in window", weight: 4, elapsed: time.Second, slowStart: time.Minute, want: 0.4},
+		{desc: "middle of window", weight: 4, elapsed: 30 * time.Second, slowStart: time.Minute, want: 2},
+		{desc: "end of window", weight: 4, elapsed: time.Minute, slowStart: time.Minute, want: 4},
+		{desc: "after window", weight: 4, elapsed: time.Hour, slowStart: time.Minute, want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := Effective(tt.weight, since, since.Add(tt.elapsed), tt.slowStart)
+			assert.InDelta(t, tt.want, got, 1e-9)
+		})
+	}
+}
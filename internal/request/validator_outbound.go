@@ -33,6 +33,9 @@ type UnaryValidatorOutbound struct{ transport.UnaryOutbound }
 // OnewayValidatorOutbound wraps an Outbound to validate all outgoing oneway requests.
 type OnewayValidatorOutbound struct{ transport.OnewayOutbound }
 
+// StreamValidatorOutbound wraps an Outbound to validate all outgoing stream requests.
+type StreamValidatorOutbound struct{ transport.StreamOutbound }
+
 // Call performs the given request, failing early if the request is invalid.
 func (o UnaryValidatorOutbound) Call(ctx context.Context, request *transport.Request) (*transport.Response, error) {
 	if err := transport.ValidateRequest(request); err != nil {
@@ -70,3 +73,20 @@ func (o OnewayValidatorOutbound) Introspect() introspection.OutboundStatus {
 	}
 	return introspection.OutboundStatusNotSupported
 }
+
+// CallStream performs the given request, failing early if the request is invalid.
+func (o StreamValidatorOutbound) CallStream(ctx context.Context, request *transport.StreamRequest) (*transport.ClientStream, error) {
+	if err := transport.ValidateRequest(request.Meta.ToRequest()); err != nil {
+		return nil, err
+	}
+
+	return o.StreamOutbound.CallStream(ctx, request)
+}
+
+// Introspect returns the introspection status of the underlying outbound.
+func (o StreamValidatorOutbound) Introspect() introspection.OutboundStatus {
+	if o, ok := o.StreamOutbound.(introspection.IntrospectableOutbound); ok {
+		return o.Introspect()
+	}
+	return introspection.OutboundStatusNotSupported
+}
@@ -0,0 +1,479 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package sharedmetrics tracks metrics of components which are built
+// independently of the Dispatcher that uses them, such as middleware,
+// outbounds and transports, so that Dispatchers can report them.
+//
+// Each component tracks its metrics in its own Set, which it exposes by
+// implementing Reporter. Dispatchers report the Sets of the components they
+// are configured with, so components shared between Dispatchers are reported
+// by each of them, and the metrics of components no longer in use go away
+// with them.
+//
+// Subscribers are never called while a lock is held.
+package sharedmetrics
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/yarpc/internal/pally"
+	"go.uber.org/zap"
+)
+
+// Reporter is implemented by components whose metrics are reported by the
+// Dispatchers using them. Components wrapping others, such as middleware
+// chains and composite outbounds, also return the metrics of the components
+// they wrap.
+type Reporter interface {
+	Metrics() []*Set
+}
+
+// Collect returns the metrics of the given components which implement
+// Reporter. Other components are ignored.
+func Collect(components ...interface{}) []*Set {
+	var sets []*Set
+	for _, c := range components {
+		if r, ok := c.(Reporter); ok {
+			sets = append(sets, r.Metrics()...)
+		}
+	}
+	return sets
+}
+
+// Set is a collection of vectors which are reported together.
+type Set struct {
+	mu      sync.Mutex
+	vectors []vector
+}
+
+type vector interface {
+	name() string
+
+	// report registers the vector with the registry and reports it, along
+	// with the vectors of other Sets with the same name.
+	report(reg *pally.Registry, logger *zap.Logger, group []vector) (stop func(), err error)
+}
+
+// NewSet builds a new, empty Set.
+func NewSet() *Set {
+	return &Set{}
+}
+
+func (s *Set) add(v vector) {
+	s.mu.Lock()
+	s.vectors = append(s.vectors, v)
+	s.mu.Unlock()
+}
+
+// NewCounterVector adds a vector of counters to the Set. Vectors must be
+// added before the Set is reported, so they are usually added when the
+// component owning the Set is built.
+func (s *Set) NewCounterVector(opts pally.Opts) *CounterVector {
+	v := &CounterVector{opts: opts, totals: make(map[string]*labeledValue)}
+	s.add(v)
+	return v
+}
+
+// NewGaugeVector adds a vector of gauges to the Set. Vectors must be added
+// before the Set is reported, so they are usually added when the component
+// owning the Set is built.
+func (s *Set) NewGaugeVector(opts pally.Opts) *GaugeVector {
+	v := &GaugeVector{opts: opts, values: make(map[string]*labeledValue)}
+	s.add(v)
+	return v
+}
+
+// NewLatenciesVector adds a vector of latency distributions to the Set.
+// Vectors must be added before the Set is reported, so they are usually
+// added when the component owning the Set is built.
+func (s *Set) NewLatenciesVector(opts pally.LatencyOpts) *LatenciesVector {
+	v := &LatenciesVector{opts: opts}
+	s.add(v)
+	return v
+}
+
+// Report reports the vectors of the given Sets to the registry until the
+// returned function is called. Vectors with the same name are reported as a
+// single metric: counters and latencies of all Sets are combined, and gauges
+// with the same labels are summed. Sets given more than once are reported
+// once. Vectors which cannot be registered are logged and skipped.
+func Report(reg *pally.Registry, logger *zap.Logger, sets ...*Set) (stop func()) {
+	var (
+		seen   = make(map[*Set]struct{}, len(sets))
+		groups = make(map[string][]vector)
+		names  []string
+	)
+	for _, s := range sets {
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+
+		s.mu.Lock()
+		vectors := s.vectors
+		s.mu.Unlock()
+		for _, v := range vectors {
+			if _, ok := groups[v.name()]; !ok {
+				names = append(names, v.name())
+			}
+			groups[v.name()] = append(groups[v.name()], v)
+		}
+	}
+
+	stops := make([]func(), 0, len(names))
+	for _, name := range names {
+		group := groups[name]
+		stop, err := group[0].report(reg, logger, group)
+		if err != nil {
+			logger.Error("Failed to register metric.", zap.String("metric", name), zap.Error(err))
+			continue
+		}
+		stops = append(stops, stop)
+	}
+	return func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}
+}
+
+// Report reports every vector of the Set to the given registry until the
+// returned function is called.
+func (s *Set) Report(reg *pally.Registry, logger *zap.Logger) (stop func()) {
+	return Report(reg, logger, s)
+}
+
+// unsubscribeAll returns a function which calls every given function.
+func unsubscribeAll(unsubscribes []func()) func() {
+	return func() {
+		for _, unsubscribe := range unsubscribes {
+			unsubscribe()
+		}
+	}
+}
+
+// conflictError is logged for vectors which have the same name as vectors of
+// another kind.
+func conflictError(v, other vector) error {
+	return fmt.Errorf("%T conflicts with %T of the same name", other, v)
+}
+
+// Subscriber is notified with the labels of a metric and a value whose
+// meaning depends on the vector.
+type Subscriber func(labels []string, value int64)
+
+// subscribers is a copy-on-write list of subscribers which may be read
+// without locking.
+type subscribers struct {
+	mu   sync.Mutex
+	list atomic.Value // []*Subscriber
+}
+
+func (s *subscribers) load() []*Subscriber {
+	list, _ := s.list.Load().([]*Subscriber)
+	return list
+}
+
+func (s *subscribers) add(f Subscriber) (remove func()) {
+	sub := &f
+
+	s.mu.Lock()
+	old := s.load()
+	s.list.Store(append(old[:len(old):len(old)], sub))
+	s.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			old := s.load()
+			list := make([]*Subscriber, 0, len(old))
+			for _, o := range old {
+				if o != sub {
+					list = append(list, o)
+				}
+			}
+			s.list.Store(list)
+		})
+	}
+}
+
+func notify(subs []*Subscriber, labels []string, value int64) {
+	for _, f := range subs {
+		(*f)(labels, value)
+	}
+}
+
+type labeledValue struct {
+	labels []string
+	value  int64
+}
+
+func labelsKey(labels []string) string {
+	return strings.Join(labels, "\x00")
+}
+
+// CounterVector tracks the totals of counters identified by their labels.
+type CounterVector struct {
+	opts pally.Opts
+
+	// mu guards totals, and orders increments with new subscribers so that
+	// every subscriber sees every increment exactly once.
+	mu          sync.Mutex
+	totals      map[string]*labeledValue
+	subscribers subscribers
+}
+
+// Inc increments the counter with the given labels.
+func (v *CounterVector) Inc(labels ...string) {
+	key := labelsKey(labels)
+
+	v.mu.Lock()
+	total, ok := v.totals[key]
+	if !ok {
+		total = &labeledValue{labels: labels}
+		v.totals[key] = total
+	}
+	total.value++
+	subs := v.subscribers.load()
+	v.mu.Unlock()
+
+	notify(subs, labels, 1)
+}
+
+// Subscribe calls f with the total of every counter so far, and again with
+// every increment until the returned function is called.
+func (v *CounterVector) Subscribe(f Subscriber) (unsubscribe func()) {
+	v.mu.Lock()
+	unsubscribe = v.subscribers.add(f)
+	totals := snapshot(v.totals)
+	v.mu.Unlock()
+
+	for _, total := range totals {
+		f(total.labels, total.value)
+	}
+	return unsubscribe
+}
+
+func (v *CounterVector) name() string { return v.opts.Name }
+
+func (v *CounterVector) report(reg *pally.Registry, logger *zap.Logger, group []vector) (func(), error) {
+	counters, err := reg.NewCounterVector(v.opts)
+	if err != nil {
+		return nil, err
+	}
+	unsubscribes := make([]func(), 0, len(group))
+	for _, member := range group {
+		c, ok := member.(*CounterVector)
+		if !ok {
+			logger.Error("Failed to register metric.", zap.String("metric", v.opts.Name), zap.Error(conflictError(v, member)))
+			continue
+		}
+		unsubscribes = append(unsubscribes, c.Subscribe(func(labels []string, n int64) {
+			counter, err := counters.Get(labels...)
+			if err != nil {
+				logger.Error("Failed to get counter.",
+					zap.String("metric", v.opts.Name), zap.Strings("labels", labels), zap.Error(err))
+				return
+			}
+			counter.Add(n)
+		}))
+	}
+	return unsubscribeAll(unsubscribes), nil
+}
+
+// GaugeVector tracks the latest values of gauges identified by their labels.
+type GaugeVector struct {
+	opts pally.Opts
+
+	mu          sync.Mutex
+	values      map[string]*labeledValue
+	subscribers subscribers
+}
+
+// Store sets the gauge with the given labels to the given value.
+// Subscribers are only notified if the value changed.
+func (v *GaugeVector) Store(value int64, labels ...string) {
+	key := labelsKey(labels)
+
+	v.mu.Lock()
+	gauge, ok := v.values[key]
+	if ok && gauge.value == value {
+		v.mu.Unlock()
+		return
+	}
+	if !ok {
+		gauge = &labeledValue{labels: labels}
+		v.values[key] = gauge
+	}
+	gauge.value = value
+	subs := v.subscribers.load()
+	v.mu.Unlock()
+
+	notify(subs, labels, value)
+}
+
+// Delete removes the gauge with the given labels. Subscribers are notified
+// that its value is now 0, and new subscribers will not see it.
+func (v *GaugeVector) Delete(labels ...string) {
+	key := labelsKey(labels)
+
+	v.mu.Lock()
+	if _, ok := v.values[key]; !ok {
+		v.mu.Unlock()
+		return
+	}
+	delete(v.values, key)
+	subs := v.subscribers.load()
+	v.mu.Unlock()
+
+	notify(subs, labels, 0)
+}
+
+// load returns the value of the gauge with the given labels, or 0 if there
+// is none.
+func (v *GaugeVector) load(labels []string) int64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if gauge, ok := v.values[labelsKey(labels)]; ok {
+		return gauge.value
+	}
+	return 0
+}
+
+// Subscribe calls f with the value of every gauge so far, and again with
+// every change until the returned function is called.
+//
+// Subscribers are notified outside of the lock, so concurrent changes may be
+// notified out of order.
+func (v *GaugeVector) Subscribe(f Subscriber) (unsubscribe func()) {
+	v.mu.Lock()
+	unsubscribe = v.subscribers.add(f)
+	values := snapshot(v.values)
+	v.mu.Unlock()
+
+	for _, gauge := range values {
+		f(gauge.labels, gauge.value)
+	}
+	return unsubscribe
+}
+
+func (v *GaugeVector) name() string { return v.opts.Name }
+
+func (v *GaugeVector) report(reg *pally.Registry, logger *zap.Logger, group []vector) (func(), error) {
+	gauges, err := reg.NewGaugeVector(v.opts)
+	if err != nil {
+		return nil, err
+	}
+	unsubscribes := make([]func(), 0, len(group))
+	for _, member := range group {
+		g, ok := member.(*GaugeVector)
+		if !ok {
+			logger.Error("Failed to register metric.", zap.String("metric", v.opts.Name), zap.Error(conflictError(v, member)))
+			continue
+		}
+
+		// The reported gauges are the sums of the gauges of every member, so
+		// we track what each member contributes to them.
+		var (
+			mu       sync.Mutex
+			reported = make(map[string]int64)
+		)
+		unsubscribes = append(unsubscribes, g.Subscribe(func(labels []string, _ int64) {
+			gauge, err := gauges.Get(labels...)
+			if err != nil {
+				logger.Error("Failed to get gauge.",
+					zap.String("metric", v.opts.Name), zap.Strings("labels", labels), zap.Error(err))
+				return
+			}
+
+			key := labelsKey(labels)
+			mu.Lock()
+			defer mu.Unlock()
+			// Changes may be notified out of order, so we report the current
+			// value rather than the notified one.
+			value := g.load(labels)
+			gauge.Add(value - reported[key])
+			reported[key] = value
+		}))
+	}
+	return unsubscribeAll(unsubscribes), nil
+}
+
+// LatenciesVector forwards latencies to its subscribers, which receive them
+// as nanoseconds. Unlike counters and gauges, latencies observed before
+// subscribing are not reported, and observing a latency does not lock.
+type LatenciesVector struct {
+	opts        pally.LatencyOpts
+	subscribers subscribers
+}
+
+// Observe records a latency for the distribution with the given labels.
+func (v *LatenciesVector) Observe(d time.Duration, labels ...string) {
+	notify(v.subscribers.load(), labels, int64(d))
+}
+
+// Subscribe calls f with every latency observed until the returned function
+// is called.
+func (v *LatenciesVector) Subscribe(f Subscriber) (unsubscribe func()) {
+	return v.subscribers.add(f)
+}
+
+func (v *LatenciesVector) name() string { return v.opts.Name }
+
+func (v *LatenciesVector) report(reg *pally.Registry, logger *zap.Logger, group []vector) (func(), error) {
+	latencies, err := reg.NewLatenciesVector(v.opts)
+	if err != nil {
+		return nil, err
+	}
+	unsubscribes := make([]func(), 0, len(group))
+	for _, member := range group {
+		l, ok := member.(*LatenciesVector)
+		if !ok {
+			logger.Error("Failed to register metric.", zap.String("metric", v.opts.Name), zap.Error(conflictError(v, member)))
+			continue
+		}
+		unsubscribes = append(unsubscribes, l.Subscribe(func(labels []string, d int64) {
+			distribution, err := latencies.Get(labels...)
+			if err != nil {
+				logger.Error("Failed to get latencies.",
+					zap.String("metric", v.opts.Name), zap.Strings("labels", labels), zap.Error(err))
+				return
+			}
+			distribution.Observe(time.Duration(d))
+		}))
+	}
+	return unsubscribeAll(unsubscribes), nil
+}
+
+func snapshot(values map[string]*labeledValue) []labeledValue {
+	s := make([]labeledValue, 0, len(values))
+	for _, v := range values {
+		s = append(s, *v)
+	}
+	return s
+}
@@ -0,0 +1,279 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sharedmetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/internal/pally"
+	"go.uber.org/yarpc/internal/pally/pallytest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type observation struct {
+	Labels []string
+	Value  int64
+}
+
+type recorder struct{ observations []observation }
+
+func (r *recorder) record(labels []string, value int64) {
+	r.observations = append(r.observations, observation{labels, value})
+}
+
+func TestCounterVector(t *testing.T) {
+	v := NewSet().NewCounterVector(pally.Opts{Name: "calls", Help: "Calls."})
+	v.Inc("foo")
+	v.Inc("foo")
+
+	var r recorder
+	unsubscribe := v.Subscribe(r.record)
+	assert.Equal(t, []observation{{[]string{"foo"}, 2}}, r.observations, "totals must be reported")
+
+	v.Inc("bar")
+	assert.Equal(t, observation{[]string{"bar"}, 1}, r.observations[1])
+
+	unsubscribe()
+	unsubscribe()
+	v.Inc("baz")
+	assert.Len(t, r.observations, 2, "increments must not be reported after unsubscribing")
+}
+
+func TestGaugeVector(t *testing.T) {
+	v := NewSet().NewGaugeVector(pally.Opts{Name: "depth", Help: "Depth."})
+	v.Store(1, "foo")
+	v.Store(2, "foo")
+
+	var r recorder
+	unsubscribe := v.Subscribe(r.record)
+	assert.Equal(t, []observation{{[]string{"foo"}, 2}}, r.observations, "latest values must be reported")
+
+	v.Store(2, "foo")
+	assert.Len(t, r.observations, 1, "unchanged values must not be reported")
+
+	v.Store(3, "foo")
+	assert.Equal(t, observation{[]string{"foo"}, 3}, r.observations[1])
+
+	v.Delete("foo")
+	assert.Equal(t, observation{[]string{"foo"}, 0}, r.observations[2], "deleted gauges must be reported as 0")
+	v.Delete("foo")
+	assert.Len(t, r.observations, 3, "deleting missing gauges must not be reported")
+
+	unsubscribe()
+	v.Store(4, "foo")
+	assert.Len(t, r.observations, 3, "changes must not be reported after unsubscribing")
+
+	r = recorder{}
+	v.Delete("foo")
+	v.Subscribe(r.record)()
+	assert.Empty(t, r.observations, "deleted gauges must not be reported to new subscribers")
+}
+
+func TestLatenciesVector(t *testing.T) {
+	v := NewSet().NewLatenciesVector(pally.LatencyOpts{
+		Opts:    pally.Opts{Name: "wait_ms", Help: "Wait."},
+		Unit:    time.Millisecond,
+		Buckets: []time.Duration{time.Millisecond},
+	})
+	v.Observe(time.Second, "foo")
+
+	var r recorder
+	unsubscribe := v.Subscribe(r.record)
+	assert.Empty(t, r.observations, "latencies observed before subscribing must not be reported")
+
+	v.Observe(time.Millisecond, "foo")
+	assert.Equal(t, []observation{{[]string{"foo"}, int64(time.Millisecond)}}, r.observations)
+
+	unsubscribe()
+	v.Observe(time.Millisecond, "foo")
+	assert.Len(t, r.observations, 1, "latencies must not be reported after unsubscribing")
+}
+
+func TestSubscribersRunOutsideLock(t *testing.T) {
+	set := NewSet()
+	counters := set.NewCounterVector(pally.Opts{Name: "calls", Help: "Calls."})
+	gauges := set.NewGaugeVector(pally.Opts{Name: "depth", Help: "Depth."})
+
+	// Subscribers which observe the same vector again must not deadlock.
+	var calls int
+	defer counters.Subscribe(func(labels []string, _ int64) {
+		calls++
+		if labels[0] == "outer" {
+			counters.Inc("inner")
+		}
+	})()
+	defer gauges.Subscribe(func(labels []string, value int64) {
+		gauges.Store(value, "inner")
+	})()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		counters.Inc("outer")
+		gauges.Store(1, "outer")
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("subscribers must not be called with a lock held")
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestReport(t *testing.T) {
+	set := NewSet()
+	counters := set.NewCounterVector(pally.Opts{
+		Name:           "calls",
+		Help:           "Calls.",
+		VariableLabels: []string{"dest"},
+	})
+	gauges := set.NewGaugeVector(pally.Opts{
+		Name:           "depth",
+		Help:           "Depth.",
+		VariableLabels: []string{"queue"},
+	})
+	counters.Inc("before")
+
+	reg := pally.NewRegistry()
+	stop := set.Report(reg, zap.NewNop())
+	counters.Inc("after")
+	gauges.Store(3, "q")
+
+	_, body := pallytest.Scrape(t, reg)
+	assert.Contains(t, body, `calls{dest="before"} 1`)
+	assert.Contains(t, body, `calls{dest="after"} 1`)
+	assert.Contains(t, body, `depth{queue="q"} 3`)
+
+	stop()
+	counters.Inc("after")
+	gauges.Store(4, "q")
+	_, body = pallytest.Scrape(t, reg)
+	assert.Contains(t, body, `calls{dest="after"} 1`, "increments must not be reported once stopped")
+	assert.Contains(t, body, `depth{queue="q"} 3`, "changes must not be reported once stopped")
+}
+
+func TestReportRegistrationError(t *testing.T) {
+	set := NewSet()
+	conflicting := set.NewCounterVector(pally.Opts{
+		Name:           "calls",
+		Help:           "Calls.",
+		VariableLabels: []string{"dest"},
+	})
+	gauges := set.NewGaugeVector(pally.Opts{
+		Name:           "depth",
+		Help:           "Depth.",
+		VariableLabels: []string{"queue"},
+	})
+
+	reg := pally.NewRegistry()
+	_, err := reg.NewGauge(pally.Opts{Name: "calls", Help: "Conflicting metric."})
+	require.NoError(t, err)
+
+	core, logs := observer.New(zapcore.ErrorLevel)
+	stop := set.Report(reg, zap.New(core))
+
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1, "expected the registration error to be logged")
+	assert.Equal(t, "Failed to register metric.", entries[0].Message)
+	require.Len(t, entries[0].Context, 2)
+	assert.Equal(t, "calls", entries[0].Context[0].String)
+
+	conflicting.Inc("foo")
+	gauges.Store(1, "q")
+	_, body := pallytest.Scrape(t, reg)
+	assert.Contains(t, body, `depth{queue="q"} 1`, "other vectors must still be reported")
+	assert.Equal(t, 0, logs.Len(), "vectors which failed to register must not be reported")
+
+	stop()
+	stop()
+}
+
+func TestReportSets(t *testing.T) {
+	newSet := func() (*Set, *CounterVector, *GaugeVector) {
+		set := NewSet()
+		counters := set.NewCounterVector(pally.Opts{
+			Name:           "calls",
+			Help:           "Calls.",
+			VariableLabels: []string{"dest"},
+		})
+		gauges := set.NewGaugeVector(pally.Opts{
+			Name:           "depth",
+			Help:           "Depth.",
+			VariableLabels: []string{"queue"},
+		})
+		return set, counters, gauges
+	}
+	first, firstCounters, firstGauges := newSet()
+	second, secondCounters, secondGauges := newSet()
+	_, unreportedCounters, _ := newSet()
+
+	firstCounters.Inc("foo")
+	firstGauges.Store(2, "q")
+
+	reg := pally.NewRegistry()
+	stop := Report(reg, zap.NewNop(), first, second, first)
+	defer stop()
+
+	secondCounters.Inc("foo")
+	secondGauges.Store(3, "q")
+	unreportedCounters.Inc("foo")
+
+	_, body := pallytest.Scrape(t, reg)
+	assert.Contains(t, body, `calls{dest="foo"} 2`, "counters of all Sets must be combined once")
+	assert.Contains(t, body, `depth{queue="q"} 5`, "gauges of all Sets must be summed")
+
+	firstGauges.Store(1, "q")
+	secondGauges.Delete("q")
+	_, body = pallytest.Scrape(t, reg)
+	assert.Contains(t, body, `depth{queue="q"} 1`, "gauges must reflect the changes of each Set")
+}
+
+func TestReportConflictingSets(t *testing.T) {
+	first := NewSet()
+	counters := first.NewCounterVector(pally.Opts{
+		Name:           "calls",
+		Help:           "Calls.",
+		VariableLabels: []string{"dest"},
+	})
+	second := NewSet()
+	second.NewGaugeVector(pally.Opts{
+		Name:           "calls",
+		Help:           "Calls.",
+		VariableLabels: []string{"dest"},
+	})
+
+	core, logs := observer.New(zapcore.ErrorLevel)
+	reg := pally.NewRegistry()
+	stop := Report(reg, zap.New(core), first, second)
+	defer stop()
+
+	assert.Equal(t, 1, logs.FilterMessage("Failed to register metric.").Len(),
+		"vectors conflicting with vectors of the same name must be logged")
+
+	counters.Inc("foo")
+	_, body := pallytest.Scrape(t, reg)
+	assert.Contains(t, body, `calls{dest="foo"} 1`, "the first vector of a name must still be reported")
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package triedpeers tracks the peers chosen for the attempts of a request,
+// allowing peer lists to send later attempts, like hedges, to other peers.
+package triedpeers
+
+import (
+	"context"
+	"sync"
+)
+
+type setKey struct{}
+
+// Set is the set of peers chosen for the attempts of a request. A nil Set is
+// empty and ignores additions.
+type Set struct {
+	mu  sync.Mutex
+	ids map[string]struct{}
+}
+
+// NewContext returns a context that records the peers chosen for calls made
+// with it, or with contexts derived from it, in the given Set.
+func NewContext(ctx context.Context, s *Set) context.Context {
+	return context.WithValue(ctx, setKey{}, s)
+}
+
+// FromContext returns the Set of the context, or nil if it has none.
+func FromContext(ctx context.Context) *Set {
+	s, _ := ctx.Value(setKey{}).(*Set)
+	return s
+}
+
+// Add records that the peer with the given identifier was chosen.
+func (s *Set) Add(id string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ids == nil {
+		s.ids = make(map[string]struct{})
+	}
+	s.ids[id] = struct{}{}
+	s.mu.Unlock()
+}
+
+// Contains returns whether the peer with the given identifier was chosen.
+func (s *Set) Contains(id string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	_, ok := s.ids[id]
+	s.mu.Unlock()
+	return ok
+}
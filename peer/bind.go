@@ -128,20 +128,31 @@ func (c *BoundChooser) Introspect() introspection.ChooserStatus {
 // binds a peer list to a static list of peers for the duration of its
 // lifecycle.
 func BindPeers(ids []peer.Identifier) peer.Binder {
+	return BindWeightedPeers(ids, nil)
+}
+
+// BindWeightedPeers returns a binder (suitable as an argument to peer.Bind)
+// that binds a peer list to a static list of peers with the given weights,
+// keyed by peer identifier, for the duration of its lifecycle.
+//
+// Peers without a weight have the default weight.
+func BindWeightedPeers(ids []peer.Identifier, weights map[string]int) peer.Binder {
 	return func(pl peer.List) transport.Lifecycle {
 		return &PeersUpdater{
-			once: lifecycle.NewOnce(),
-			pl:   pl,
-			ids:  ids,
+			once:    lifecycle.NewOnce(),
+			pl:      pl,
+			ids:     ids,
+			weights: weights,
 		}
 	}
 }
 
 // PeersUpdater binds a fixed list of peers to a peer list.
 type PeersUpdater struct {
-	once *lifecycle.Once
-	pl   peer.List
-	ids  []peer.Identifier
+	once    *lifecycle.Once
+	pl      peer.List
+	ids     []peer.Identifier
+	weights map[string]int
 }
 
 // Start adds a list of fixed peers to a peer list.
@@ -152,6 +163,7 @@ func (s *PeersUpdater) Start() error {
 func (s *PeersUpdater) start() error {
 	return s.pl.Update(peer.ListUpdates{
 		Additions: s.ids,
+		Weights:   s.weights,
 	})
 }
 
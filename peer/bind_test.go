@@ -90,3 +90,29 @@ func TestBindRealList(t *testing.T) {
 	assert.NoError(t, chooser.Stop(), "start without error")
 	assert.False(t, chooser.IsRunning(), "chooser should not be running")
 }
+
+func TestBindWeightedPeers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	list := peertest.NewMockChooserList(mockCtrl)
+
+	ids := []peer.Identifier{
+		hostport.PeerIdentifier("x"),
+		hostport.PeerIdentifier("y"),
+	}
+	chooser := Bind(list, BindWeightedPeers(ids, map[string]int{"x": 3}))
+
+	list.EXPECT().Start().Return(nil)
+	list.EXPECT().Update(peer.ListUpdates{
+		Additions: ids,
+		Weights:   map[string]int{"x": 3},
+	})
+	assert.NoError(t, chooser.Start(), "start without error")
+
+	list.EXPECT().Stop().Return(nil)
+	list.EXPECT().Update(peer.ListUpdates{
+		Removals: ids,
+	})
+	assert.NoError(t, chooser.Stop(), "stop without error")
+}
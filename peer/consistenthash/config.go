@@ -0,0 +1,97 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consistenthash
+
+import (
+	"fmt"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/yarpcconfig"
+)
+
+type listSpecConfig struct {
+	// Replicas is the number of points each peer occupies on the ring.
+	Replicas int `config:"replicas"`
+
+	// LoadFactor bounds the load of each peer relative to the average.
+	// Disabled by default.
+	LoadFactor float64 `config:"loadFactor"`
+
+	// KeyHeader is the header holding the key of requests without a shard
+	// key.
+	KeyHeader string `config:"keyHeader"`
+}
+
+// Spec returns a configuration specification for the consistent hash peer
+// list implementation, making it possible to send requests with the same
+// shard key to the same peer with transports that use outbound peer list
+// configuration (like HTTP).
+//
+//  cfg := yarpcconfig.New()
+//  cfg.MustRegisterPeerList(consistenthash.Spec())
+//
+// This enables the consistent-hash peer list:
+//
+//  outbounds:
+//    otherservice:
+//      unary:
+//        http:
+//          url: https://host:port/rpc
+//          consistent-hash:
+//            peers:
+//              - 127.0.0.1:8080
+//              - 127.0.0.1:8081
+//
+// The number of points of each peer on the ring, the bound on the load of
+// each peer, and a header keying requests without a shard key may be
+// specified. The load is not bounded by default; see LoadFactor.
+//
+//          consistent-hash:
+//            peers:
+//              - 127.0.0.1:8080
+//              - 127.0.0.1:8081
+//            replicas: 200
+//            loadFactor: 1.5
+//            keyHeader: x-user-id
+func Spec() yarpcconfig.PeerListSpec {
+	return yarpcconfig.PeerListSpec{
+		Name: "consistent-hash",
+		BuildPeerList: func(c listSpecConfig, t peer.Transport, k *yarpcconfig.Kit) (peer.ChooserList, error) {
+			var opts []ListOption
+			if c.Replicas < 0 {
+				return nil, fmt.Errorf("replicas must be positive, got %d", c.Replicas)
+			}
+			if c.Replicas > 0 {
+				opts = append(opts, Replicas(c.Replicas))
+			}
+			if c.LoadFactor != 0 {
+				if c.LoadFactor < 1 {
+					return nil, fmt.Errorf("loadFactor must be 0 or at least 1, got %v", c.LoadFactor)
+				}
+				opts = append(opts, LoadFactor(c.LoadFactor))
+			}
+			if c.KeyHeader != "" {
+				opts = append(opts, KeyHeader(c.KeyHeader))
+			}
+			return New(t, opts...), nil
+		},
+	}
+}
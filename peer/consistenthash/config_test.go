@@ -0,0 +1,75 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consistenthash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/yarpcconfig"
+)
+
+func TestSpec(t *testing.T) {
+	spec := Spec()
+	assert.Equal(t, "consistent-hash", spec.Name)
+	build, ok := spec.BuildPeerList.(func(listSpecConfig, peer.Transport, *yarpcconfig.Kit) (peer.ChooserList, error))
+	require.True(t, ok, "unexpected builder type %T", spec.BuildPeerList)
+	tests := []struct {
+		desc    string
+		cfg     listSpecConfig
+		want    listConfig
+		wantErr string
+	}{
+		{
+			desc: "defaults",
+			want: defaultListConfig,
+		},
+		{
+			desc: "options",
+			cfg:  listSpecConfig{Replicas: 200, LoadFactor: 1.5, KeyHeader: "x-user-id"},
+			want: listConfig{capacity: 10, replicas: 200, loadFactor: 1.5, keyHeader: "x-user-id"},
+		},
+		{
+			desc:    "invalid replicas",
+			cfg:     listSpecConfig{Replicas: -1},
+			wantErr: "replicas must be positive, got -1",
+		},
+		{
+			desc:    "invalid load factor",
+			cfg:     listSpecConfig{LoadFactor: 0.5},
+			wantErr: "loadFactor must be 0 or at least 1, got 0.5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			list, err := build(tt.cfg, newFakeTransport(), nil)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, list.(*List).ring.cfg)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package consistenthash provides a peer list which sends requests with the
+// same key to the same peer.
+//
+// Peers are placed at several points of a hash ring, and a request goes to
+// the peer owning the hash of its shard key. Adding or removing a peer only
+// moves the keys of that peer, so most keys keep their peer as the list
+// changes.
+//
+// Requests skip to the next peer on the ring when their peer is unavailable.
+// With a load factor, they also skip peers which already have too many
+// pending requests relative to the other peers. This bounds the load of
+// peers owning popular keys, at the cost of sending their keys elsewhere.
+//
+// 	list := consistenthash.New(transport, consistenthash.KeyHeader("x-user-id"))
+//
+// See Spec to use the list with yarpcconfig.
+package consistenthash
@@ -0,0 +1,262 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consistenthash
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/internal/peerlist"
+	"go.uber.org/yarpc/internal/triedpeers"
+)
+
+type listConfig struct {
+	capacity   int
+	replicas   int
+	loadFactor float64
+	keyHeader  string
+}
+
+var defaultListConfig = listConfig{
+	capacity: 10,
+	replicas: 100,
+}
+
+// ListOption customizes the behavior of a consistent hash list.
+type ListOption func(*listConfig)
+
+// Capacity specifies the default capacity of the underlying
+// data structures for this list
+//
+// Defaults to 10.
+func Capacity(capacity int) ListOption {
+	return func(c *listConfig) {
+		c.capacity = capacity
+	}
+}
+
+// Replicas specifies the number of points each peer occupies on the hash
+// ring. More points spread keys more evenly between peers, at the cost of
+// memory and of slower updates. Values below 1 use the default.
+//
+// Defaults to 100.
+func Replicas(replicas int) ListOption {
+	return func(c *listConfig) {
+		c.replicas = replicas
+	}
+}
+
+// LoadFactor bounds the load of peers relative to the average load of the
+// available peers. A request whose peer already has more pending requests
+// than the bound goes to the next peer on the ring which doesn't. The load
+// of a peer counts its pending requests from all the lists sharing it.
+//
+// For example, with a load factor of 1.25 no peer has more than 25% more
+// pending requests than the average. The bound moves requests off the peer
+// owning their key as soon as a few of them are in flight, so it only suits
+// keys used to spread load rather than to find the peer holding their state.
+//
+// Defaults to 0, which disables the bound: requests always go to the first
+// available peer on the ring.
+func LoadFactor(loadFactor float64) ListOption {
+	return func(c *listConfig) {
+		c.loadFactor = loadFactor
+	}
+}
+
+// KeyHeader specifies a request header holding the hash key of requests
+// without a shard key. It takes precedence over the routing key.
+func KeyHeader(name string) ListOption {
+	return func(c *listConfig) {
+		c.keyHeader = name
+	}
+}
+
+// New creates a new consistent hash peer list.
+//
+// Requests are sent to the peer owning the hash of their key on a ring of
+// the available peers. The key of a request is its shard key, falling back
+// to the header specified with KeyHeader, and then to its routing key.
+// Requests without any key are spread around the ring.
+//
+// Requests go to the next peer on the ring if their peer is unavailable, or
+// if it has too many pending requests when the load is bounded; see
+// LoadFactor.
+func New(transport peer.Transport, opts ...ListOption) *List {
+	cfg := defaultListConfig
+	for _, o := range opts {
+		o(&cfg)
+	}
+	if cfg.replicas <= 0 {
+		cfg.replicas = defaultListConfig.replicas
+	}
+
+	ring := &hashList{
+		cfg:   cfg,
+		peers: make(map[string]peer.Peer, cfg.capacity),
+		ring:  newHashRing(cfg.replicas),
+	}
+	return &List{
+		List: peerlist.New("consistent-hash", transport, ring, cfg.capacity),
+		ring: ring,
+	}
+}
+
+// List is a PeerList which sends requests with the same key to the same
+// peer, using consistent hashing.
+type List struct {
+	*peerlist.List
+
+	ring *hashList
+}
+
+// Introspect returns a ChooserStatus with a summary of the Peers, including
+// the share of the ring each peer owns.
+func (pl *List) Introspect() introspection.ChooserStatus {
+	status := pl.List.Introspect()
+	ownership := pl.ring.ownership()
+	for i, ps := range status.Peers {
+		status.Peers[i].State = fmt.Sprintf("%s, owns %.1f%% of the ring",
+			ps.State, ownership[ps.Identifier]*100)
+	}
+	return status
+}
+
+// hashList places the available peers of a List on a hash ring. Peers
+// leave the ring while unavailable, so their keys go to the next peer on
+// the ring, and come back to them once the peer is available again.
+type hashList struct {
+	cfg listConfig
+
+	// The peer list serializes Add and Remove with Choose; the lock guards
+	// the ring against introspection.
+	lock  sync.RWMutex
+	peers map[string]peer.Peer
+	ring  *hashRing
+
+	// Spreads requests without a key around the ring.
+	unkeyed atomic.Uint64
+}
+
+func (h *hashList) Add(p peer.Peer) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.peers[p.Identifier()] = p
+	h.ring.Add(p.Identifier())
+}
+
+func (h *hashList) Remove(p peer.Peer) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if _, ok := h.peers[p.Identifier()]; !ok {
+		return
+	}
+	delete(h.peers, p.Identifier())
+	h.ring.Remove(p.Identifier())
+}
+
+// Choose walks the ring from the point owning the key of the request to the
+// first peer under the load bound. Requests without a key start at a
+// rotating point. Peers already tried for the request are skipped, so that
+// hedges go to the next peer on the ring.
+//
+// Returns nil if no peer is available.
+func (h *hashList) Choose(ctx context.Context, req *transport.Request) peer.Peer {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	if h.ring.Len() == 0 {
+		return nil
+	}
+
+	var start int
+	if key, ok := h.key(req); ok {
+		start = h.ring.Search(hashKey(key))
+	} else {
+		start = int(h.unkeyed.Inc() % uint64(h.ring.Len()))
+	}
+
+	// Consistent hashing with bounded loads: no peer takes more than
+	// ceil(loadFactor * average load), counting this request.
+	limit := math.MaxInt64
+	if h.cfg.loadFactor > 0 {
+		pending := 0
+		for _, p := range h.peers {
+			pending += p.Status().PendingRequestCount
+		}
+		limit = int(math.Ceil(h.cfg.loadFactor * float64(pending+1) / float64(len(h.peers))))
+	}
+
+	tried := triedpeers.FromContext(ctx)
+	var chosen, fallback peer.Peer
+	h.ring.Walk(start, func(id string) bool {
+		p := h.peers[id]
+		if fallback == nil {
+			fallback = p
+		}
+		if tried.Contains(id) {
+			return true
+		}
+		if p.Status().PendingRequestCount < limit {
+			chosen = p
+			return false
+		}
+		return true
+	})
+	if chosen == nil {
+		// The bound always leaves room on some peer, but don't rely on it,
+		// and every peer may have been tried.
+		chosen = fallback
+	}
+	return chosen
+}
+
+// key returns the hash key of the request, if it has one.
+func (h *hashList) key(req *transport.Request) (string, bool) {
+	if req.ShardKey != "" {
+		return req.ShardKey, true
+	}
+	if h.cfg.keyHeader != "" {
+		if key, ok := req.Headers.Get(h.cfg.keyHeader); ok && key != "" {
+			return key, true
+		}
+	}
+	if req.RoutingKey != "" {
+		return req.RoutingKey, true
+	}
+	return "", false
+}
+
+// ownership returns the share of the ring owned by each available peer.
+func (h *hashList) ownership() map[string]float64 {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return h.ring.Ownership()
+}
@@ -0,0 +1,408 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consistenthash
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/peer"
+	. "go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/triedpeers"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// fakeTransport retains available LightMockPeers, and keeps track of the
+// peers retained by the list.
+type fakeTransport struct {
+	peers    map[string]*LightMockPeer
+	retained map[string]struct{}
+}
+
+func newFakeTransport() *fakeTransport {
+	return &fakeTransport{
+		peers:    make(map[string]*LightMockPeer),
+		retained: make(map[string]struct{}),
+	}
+}
+
+func (t *fakeTransport) RetainPeer(pid peer.Identifier, _ peer.Subscriber) (peer.Peer, error) {
+	p, ok := t.peers[pid.Identifier()]
+	if !ok {
+		p = NewLightMockPeer(MockPeerIdentifier(pid.Identifier()), peer.Available)
+		t.peers[pid.Identifier()] = p
+	}
+	t.retained[pid.Identifier()] = struct{}{}
+	return p, nil
+}
+
+func (t *fakeTransport) ReleasePeer(pid peer.Identifier, _ peer.Subscriber) error {
+	delete(t.retained, pid.Identifier())
+	return nil
+}
+
+func peerIDs(n int) []peer.Identifier {
+	ids := make([]peer.Identifier, n)
+	for i := range ids {
+		ids[i] = MockPeerIdentifier(fmt.Sprintf("127.0.0.1:%d", 8000+i))
+	}
+	return ids
+}
+
+func newStartedList(t *testing.T, n int, opts ...ListOption) (*List, *fakeTransport) {
+	trans := newFakeTransport()
+	pl := New(trans, opts...)
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: peerIDs(n)}))
+	require.NoError(t, pl.Start())
+	return pl, trans
+}
+
+func choose(t *testing.T, pl *List, req *transport.Request) (peer.Peer, func(error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	p, onFinish, err := pl.Choose(ctx, req)
+	require.NoError(t, err)
+	return p, onFinish
+}
+
+// chooseID chooses a peer for the request and finishes the request.
+func chooseID(t *testing.T, pl *List, req *transport.Request) string {
+	p, onFinish := choose(t, pl, req)
+	onFinish(nil)
+	return p.Identifier()
+}
+
+func TestSameKeySamePeer(t *testing.T) {
+	pl, _ := newStartedList(t, 5)
+	defer pl.Stop()
+
+	chosen := make(map[string]struct{})
+	for i := 0; i < 100; i++ {
+		req := &transport.Request{ShardKey: fmt.Sprint(i)}
+		id := chooseID(t, pl, req)
+		assert.Equal(t, id, chooseID(t, pl, req), "key %d must map to the same peer", i)
+		chosen[id] = struct{}{}
+	}
+	assert.Len(t, chosen, 5, "keys must be spread between peers")
+}
+
+func TestKeyPrecedence(t *testing.T) {
+	pl, _ := newStartedList(t, 20, KeyHeader("x-key"))
+	defer pl.Stop()
+
+	ownerOf := func(key string) string {
+		return owner(pl.ring.ring, key)
+	}
+	// Find keys owned by different peers so that precedence is observable.
+	keys := []string{"shard"}
+	for i := 0; len(keys) < 3; i++ {
+		key := fmt.Sprint(i)
+		distinct := true
+		for _, k := range keys {
+			distinct = distinct && ownerOf(k) != ownerOf(key)
+		}
+		if distinct {
+			keys = append(keys, key)
+		}
+	}
+	shardKey, headerKey, routingKey := keys[0], keys[1], keys[2]
+
+	tests := []struct {
+		desc string
+		req  *transport.Request
+		want string
+	}{
+		{
+			desc: "shard key",
+			req: &transport.Request{
+				ShardKey:   shardKey,
+				RoutingKey: routingKey,
+				Headers:    transport.NewHeaders().With("x-key", headerKey),
+			},
+			want: ownerOf(shardKey),
+		},
+		{
+			desc: "header",
+			req: &transport.Request{
+				RoutingKey: routingKey,
+				Headers:    transport.NewHeaders().With("x-key", headerKey),
+			},
+			want: ownerOf(headerKey),
+		},
+		{
+			desc: "routing key",
+			req:  &transport.Request{RoutingKey: routingKey},
+			want: ownerOf(routingKey),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.want, chooseID(t, pl, tt.req))
+		})
+	}
+}
+
+func TestUnkeyedRequestsSpread(t *testing.T) {
+	pl, _ := newStartedList(t, 4)
+	defer pl.Stop()
+
+	chosen := make(map[string]struct{})
+	for i := 0; i < 200; i++ {
+		chosen[chooseID(t, pl, &transport.Request{})] = struct{}{}
+	}
+	assert.Len(t, chosen, 4)
+}
+
+func TestUnavailableOwner(t *testing.T) {
+	pl, trans := newStartedList(t, 5)
+	defer pl.Stop()
+
+	req := &transport.Request{ShardKey: "foo"}
+	ownerID := chooseID(t, pl, req)
+
+	trans.peers[ownerID].PeerStatus.ConnectionStatus = peer.Unavailable
+	pl.NotifyStatusChanged(MockPeerIdentifier(ownerID))
+	fallbackID := chooseID(t, pl, req)
+	assert.NotEqual(t, ownerID, fallbackID, "unavailable owners must be skipped")
+	assert.Equal(t, fallbackID, chooseID(t, pl, req), "fallback must be stable")
+
+	trans.peers[ownerID].PeerStatus.ConnectionStatus = peer.Available
+	pl.NotifyStatusChanged(MockPeerIdentifier(ownerID))
+	assert.Equal(t, ownerID, chooseID(t, pl, req), "requests must return to the owner")
+}
+
+func TestConcurrentRequestsStayOnOwner(t *testing.T) {
+	pl, trans := newStartedList(t, 4)
+	defer pl.Stop()
+
+	req := &transport.Request{ShardKey: "foo"}
+	ownerID := chooseID(t, pl, req)
+
+	first, finishFirst := choose(t, pl, req)
+	second, finishSecond := choose(t, pl, req)
+	assert.Equal(t, ownerID, first.Identifier())
+	assert.Equal(t, ownerID, second.Identifier(), "concurrent requests must stay on the owner")
+	assert.Equal(t, 2, trans.peers[ownerID].Status().PendingRequestCount)
+
+	finishFirst(nil)
+	finishSecond(nil)
+}
+
+func TestBoundedLoad(t *testing.T) {
+	tests := []struct {
+		desc       string
+		loadFactor float64
+		wantMax    int
+	}{
+		{desc: "bounded", loadFactor: 1.25, wantMax: 7}, // ceil(1.25 * 20 / 4)
+		{desc: "unbounded", loadFactor: 0, wantMax: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			pl, trans := newStartedList(t, 4, LoadFactor(tt.loadFactor))
+			defer pl.Stop()
+
+			req := &transport.Request{ShardKey: "hot"}
+			ownerID := chooseID(t, pl, req)
+
+			var finishes []func(error)
+			for i := 0; i < 20; i++ {
+				_, onFinish := choose(t, pl, req)
+				finishes = append(finishes, onFinish)
+			}
+
+			assert.Equal(t, tt.wantMax, trans.peers[ownerID].Status().PendingRequestCount,
+				"owner must take requests up to the bound")
+			for id, p := range trans.peers {
+				assert.True(t, p.Status().PendingRequestCount <= tt.wantMax,
+					"peer %q exceeds the bound", id)
+			}
+
+			for _, onFinish := range finishes {
+				onFinish(nil)
+			}
+			for id, p := range trans.peers {
+				assert.Equal(t, 0, p.Status().PendingRequestCount, "peer %q has pending requests", id)
+			}
+			assert.Equal(t, ownerID, chooseID(t, pl, req), "requests must return to the owner")
+		})
+	}
+}
+
+func TestMinimalRemapping(t *testing.T) {
+	pl, _ := newStartedList(t, 10)
+	defer pl.Stop()
+
+	const keys = 1000
+	before := make(map[string]string, keys)
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprint(i)
+		before[key] = chooseID(t, pl, &transport.Request{ShardKey: key})
+	}
+
+	added := MockPeerIdentifier("127.0.0.1:9000")
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{added}}))
+	moved := 0
+	for key, id := range before {
+		now := chooseID(t, pl, &transport.Request{ShardKey: key})
+		if now != id {
+			assert.Equal(t, added.Identifier(), now, "keys must only move to the added peer")
+			moved++
+		}
+	}
+	assert.InDelta(t, keys/11, moved, keys/20)
+
+	removed := peerIDs(1)
+	require.NoError(t, pl.Update(peer.ListUpdates{Removals: append(removed, added)}))
+	for key, id := range before {
+		if id != removed[0].Identifier() {
+			assert.Equal(t, id, chooseID(t, pl, &transport.Request{ShardKey: key}),
+				"only keys of removed peers may move")
+		}
+	}
+}
+
+func TestUpdateErrors(t *testing.T) {
+	pl, _ := newStartedList(t, 2)
+	defer pl.Stop()
+
+	err := pl.Update(peer.ListUpdates{
+		Additions: peerIDs(1),
+		Removals:  []peer.Identifier{MockPeerIdentifier("missing")},
+	})
+	assert.Len(t, multierr.Errors(err), 2)
+	assert.Equal(t, 200, pl.ring.ring.Len(), "failed updates must not change the ring")
+}
+
+func TestUninitializedUpdates(t *testing.T) {
+	trans := newFakeTransport()
+	pl := New(trans)
+
+	ids := peerIDs(3)
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: ids}))
+	require.NoError(t, pl.Update(peer.ListUpdates{Removals: ids[:1]}))
+	assert.Error(t, pl.Update(peer.ListUpdates{Removals: ids[:1]}))
+	assert.Empty(t, trans.retained, "peers must not be retained before starting")
+
+	require.NoError(t, pl.Start())
+	assert.Len(t, trans.retained, 2)
+	assert.Equal(t, 200, pl.ring.ring.Len())
+
+	require.NoError(t, pl.Stop())
+	assert.Empty(t, trans.retained, "peers must be released when stopping")
+	assert.Equal(t, 0, pl.ring.ring.Len())
+}
+
+func TestChooseWaitsForPeer(t *testing.T) {
+	trans := newFakeTransport()
+	pl := New(trans)
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	_, _, err := pl.Choose(context.Background(), &transport.Request{})
+	assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err = pl.Choose(ctx, &transport.Request{})
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+
+	chosen := make(chan peer.Peer)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		p, _, _ := pl.Choose(ctx, &transport.Request{ShardKey: "foo"})
+		chosen <- p
+	}()
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: peerIDs(1)}))
+	p := <-chosen
+	require.NotNil(t, p, "must choose the added peer")
+	assert.Equal(t, peerIDs(1)[0].Identifier(), p.Identifier())
+}
+
+func TestChooseNotRunning(t *testing.T) {
+	pl := New(newFakeTransport())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, _, err := pl.Choose(ctx, &transport.Request{})
+	assert.Equal(t, yarpcerrors.CodeFailedPrecondition, yarpcerrors.FromError(err).Code())
+	assert.False(t, pl.IsRunning())
+}
+
+func TestIntrospect(t *testing.T) {
+	pl, trans := newStartedList(t, 2, Replicas(1))
+	defer pl.Stop()
+
+	ids := peerIDs(2)
+	trans.peers[ids[1].Identifier()].PeerStatus.ConnectionStatus = peer.Unavailable
+	pl.NotifyStatusChanged(ids[1])
+
+	status := pl.Introspect()
+	assert.Equal(t, "consistent-hash", status.Name)
+	assert.Equal(t, "Running (1/2 available)", status.State)
+	require.Len(t, status.Peers, 2)
+
+	ownership := pl.ring.ownership()
+	for i, ps := range status.Peers {
+		id := ids[i].Identifier()
+		assert.Equal(t, id, ps.Identifier)
+		connStatus := "Available"
+		if i == 1 {
+			connStatus = "Unavailable"
+		}
+		assert.Equal(t,
+			fmt.Sprintf("%s, 0 pending request(s), owns %.1f%% of the ring", connStatus, ownership[id]*100),
+			ps.State)
+	}
+}
+
+func TestTriedPeersSkipped(t *testing.T) {
+	pl, _ := newStartedList(t, 3)
+	defer pl.Stop()
+
+	req := &transport.Request{ShardKey: "foo"}
+	ownerID := chooseID(t, pl, req)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx = triedpeers.NewContext(ctx, &triedpeers.Set{})
+
+	var ids []string
+	for i := 0; i < 4; i++ {
+		p, onFinish, err := pl.Choose(ctx, req)
+		require.NoError(t, err)
+		onFinish(nil)
+		ids = append(ids, p.Identifier())
+	}
+	assert.Equal(t, ownerID, ids[0], "first attempt must go to the owner")
+	assert.Len(t, map[string]string{ids[0]: "", ids[1]: "", ids[2]: ""}, 3,
+		"attempts must go to peers not yet tried")
+	assert.Equal(t, ownerID, ids[3], "must fall back to the owner once every peer was tried")
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consistenthash
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// point is a virtual node on the hash ring.
+type point struct {
+	hash uint64
+	id   string
+}
+
+// hashRing places each peer at several points of a ring of 64-bit hashes.
+// A key belongs to the peer of the first point at or after its hash, so
+// adding or removing a peer only moves the keys of the arcs ending at its
+// points.
+//
+// hashRing is NOT thread-safe.
+type hashRing struct {
+	replicas int
+	points   []point // sorted by hash
+}
+
+func newHashRing(replicas int) *hashRing {
+	return &hashRing{replicas: replicas}
+}
+
+// hashKey hashes keys onto the ring. The FNV hashes of similar keys, like
+// the points of a peer, differ little in their high bits, so they are mixed
+// with the 64-bit finalizer of MurmurHash3 to spread them around the ring.
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// Add places the peers with the given identifiers on the ring.
+func (r *hashRing) Add(ids ...string) {
+	if len(ids) == 0 {
+		return
+	}
+	for _, id := range ids {
+		for i := 0; i < r.replicas; i++ {
+			r.points = append(r.points, point{
+				hash: hashKey(id + "#" + strconv.Itoa(i)),
+				id:   id,
+			})
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool {
+		if r.points[i].hash != r.points[j].hash {
+			return r.points[i].hash < r.points[j].hash
+		}
+		// Break ties deterministically should two points collide.
+		return r.points[i].id < r.points[j].id
+	})
+}
+
+// Remove removes the peers with the given identifiers from the ring.
+func (r *hashRing) Remove(ids ...string) {
+	if len(ids) == 0 {
+		return
+	}
+	removed := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		removed[id] = struct{}{}
+	}
+
+	points := r.points[:0]
+	for _, p := range r.points {
+		if _, ok := removed[p.id]; !ok {
+			points = append(points, p)
+		}
+	}
+	r.points = points
+}
+
+// Len returns the number of points on the ring.
+func (r *hashRing) Len() int {
+	return len(r.points)
+}
+
+// Search returns the index of the point owning the given hash. The ring must
+// not be empty.
+func (r *hashRing) Search(hash uint64) int {
+	i := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i].hash >= hash
+	})
+	if i == len(r.points) {
+		i = 0
+	}
+	return i
+}
+
+// Walk calls f with the identifiers of the points on the ring, starting at
+// the given index and going around the ring once, until f returns false.
+// Peers are visited once, at their first point.
+func (r *hashRing) Walk(start int, f func(id string) bool) {
+	if len(r.points) == 0 {
+		return
+	}
+
+	// Most walks stop at the owner, so avoid tracking visited peers until
+	// they don't.
+	first := r.points[start].id
+	if !f(first) {
+		return
+	}
+	seen := map[string]struct{}{first: {}}
+	for i := 1; i < len(r.points); i++ {
+		id := r.points[(start+i)%len(r.points)].id
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		if !f(id) {
+			return
+		}
+	}
+}
+
+// Ownership returns the fraction of the ring owned by each peer.
+func (r *hashRing) Ownership() map[string]float64 {
+	ownership := make(map[string]float64)
+	if len(r.points) == 0 {
+		return ownership
+	}
+
+	for i, p := range r.points {
+		// The arc ending at point i starts after the previous point, wrapping
+		// around the ring for the first point. Unsigned subtraction wraps
+		// too, and a lone point owns the whole ring.
+		prev := r.points[(i+len(r.points)-1)%len(r.points)].hash
+		arc := float64(p.hash - prev)
+		if len(r.points) == 1 {
+			arc = math.MaxUint64
+		}
+		ownership[p.id] += arc / math.MaxUint64
+	}
+	return ownership
+}
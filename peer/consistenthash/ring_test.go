@@ -0,0 +1,117 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consistenthash
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func owner(r *hashRing, key string) string {
+	var id string
+	r.Walk(r.Search(hashKey(key)), func(owner string) bool {
+		id = owner
+		return false
+	})
+	return id
+}
+
+func TestHashRingMinimalRemapping(t *testing.T) {
+	r := newHashRing(100)
+	r.Add("a", "b", "c", "d")
+
+	const keys = 10000
+	before := make(map[string]string, keys)
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprint(i)
+		before[key] = owner(r, key)
+	}
+
+	r.Add("e")
+	moved := 0
+	for key, id := range before {
+		now := owner(r, key)
+		if now != id {
+			assert.Equal(t, "e", now, "keys must only move to the added peer")
+			moved++
+		}
+	}
+	// The new peer should take about a fifth of the keys.
+	assert.InDelta(t, keys/5, moved, keys/10)
+
+	r.Remove("e")
+	for key, id := range before {
+		assert.Equal(t, id, owner(r, key), "keys must return to their peer")
+	}
+
+	r.Remove("a")
+	for key, id := range before {
+		if id != "a" {
+			assert.Equal(t, id, owner(r, key), "only keys of the removed peer may move")
+		}
+	}
+}
+
+func TestHashRingWalk(t *testing.T) {
+	r := newHashRing(10)
+	r.Add("a", "b", "c")
+	require.Equal(t, 30, r.Len())
+
+	var visited []string
+	r.Walk(0, func(id string) bool {
+		visited = append(visited, id)
+		return true
+	})
+	assert.Len(t, visited, 3, "each peer must be visited once")
+	assert.Contains(t, visited, "a")
+	assert.Contains(t, visited, "b")
+	assert.Contains(t, visited, "c")
+
+	r.Remove("a", "b", "c")
+	assert.Equal(t, 0, r.Len())
+	r.Walk(0, func(string) bool {
+		t.Fatal("empty rings must not be walked")
+		return false
+	})
+}
+
+func TestHashRingOwnership(t *testing.T) {
+	r := newHashRing(100)
+	assert.Empty(t, r.Ownership())
+
+	r.Add("a")
+	assert.InDelta(t, 1, r.Ownership()["a"], 1e-9)
+
+	r.Add("b", "c", "d")
+	total := 0.0
+	for id, share := range r.Ownership() {
+		assert.InDelta(t, 0.25, share, 0.1, "peer %q owns too much of the ring", id)
+		total += share
+	}
+	assert.InDelta(t, 1, total, 1e-9)
+
+	single := newHashRing(1)
+	single.Add("a")
+	assert.InDelta(t, 1, single.Ownership()["a"], 1e-9)
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dns
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/yarpcconfig"
+)
+
+// Config is the configuration of the DNS peer list updater.
+type Config struct {
+	// Name is the DNS name to resolve. Required.
+	Name string `config:"name,interpolate"`
+
+	// Record is the type of records to resolve: A, AAAA, or SRV. Defaults to
+	// A.
+	Record string `config:"record"`
+
+	// Port is the port of peers resolved from A and AAAA records. Required
+	// for these records, and forbidden for SRV records.
+	Port uint16 `config:"port"`
+
+	// Interval is how often records are resolved. Defaults to 30s.
+	Interval time.Duration `config:"interval"`
+
+	// Backoff is the backoff strategy for retrying failed resolutions.
+	Backoff yarpcconfig.Backoff `config:"backoff"`
+}
+
+// Spec returns a configuration specification for the DNS peer list updater,
+// making it possible to discover peers with DNS for transports that use
+// outbound peer list configuration (like HTTP).
+//
+//  cfg := yarpcconfig.New()
+//  cfg.MustRegisterPeerListUpdater(dns.Spec())
+//
+// This enables the dns peer list updater under any peer list:
+//
+//  outbounds:
+//    otherservice:
+//      unary:
+//        http:
+//          url: https://host:port/rpc
+//          round-robin:
+//            dns:
+//              name: otherservice.example.com
+//              record: A
+//              port: 8080
+//
+// SRV records carry the port of each peer:
+//
+//          round-robin:
+//            dns:
+//              name: _otherservice._tcp.example.com
+//              record: SRV
+//              interval: 10s
+//              backoff:
+//                exponential:
+//                  first: 100ms
+//                  max: 10s
+func Spec() yarpcconfig.PeerListUpdaterSpec {
+	return yarpcconfig.PeerListUpdaterSpec{
+		Name: "dns",
+		BuildPeerListUpdater: func(c Config, _ *yarpcconfig.Kit) (peer.Binder, error) {
+			opts, err := c.options()
+			if err != nil {
+				return nil, err
+			}
+			return Bind(c.Name, opts...), nil
+		},
+	}
+}
+
+func (c Config) options() ([]Option, error) {
+	if c.Name == "" {
+		return nil, errors.New("a DNS name is required")
+	}
+
+	var opts []Option
+	if c.Record != "" {
+		opts = append(opts, Type(RecordType(strings.ToUpper(c.Record))))
+	}
+	if c.Port != 0 {
+		opts = append(opts, Port(c.Port))
+	}
+	if c.Interval != 0 {
+		opts = append(opts, Interval(c.Interval))
+	}
+
+	strategy, err := c.Backoff.Strategy()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, BackoffStrategy(strategy))
+
+	// Catch misconfiguration when building rather than starting.
+	if err := newOptions(opts).validate(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
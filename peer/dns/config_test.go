@@ -0,0 +1,109 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dns
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/yarpcconfig"
+)
+
+func TestSpec(t *testing.T) {
+	spec := Spec()
+	assert.Equal(t, "dns", spec.Name)
+	build, ok := spec.BuildPeerListUpdater.(func(Config, *yarpcconfig.Kit) (peer.Binder, error))
+	require.True(t, ok, "unexpected builder type %T", spec.BuildPeerListUpdater)
+
+	tests := []struct {
+		desc    string
+		cfg     Config
+		wantErr string
+	}{
+		{
+			desc: "A records",
+			cfg:  Config{Name: "myservice.example.com", Port: 8080},
+		},
+		{
+			desc: "SRV records",
+			cfg: Config{
+				Name:     "_myservice._tcp.example.com",
+				Record:   "srv",
+				Interval: 10 * time.Second,
+				Backoff: yarpcconfig.Backoff{
+					Exponential: yarpcconfig.ExponentialBackoff{
+						First: 10 * time.Millisecond,
+						Max:   time.Second,
+					},
+				},
+			},
+		},
+		{
+			desc:    "missing name",
+			cfg:     Config{Port: 8080},
+			wantErr: "a DNS name is required",
+		},
+		{
+			desc:    "missing port",
+			cfg:     Config{Name: "myservice.example.com", Record: "AAAA"},
+			wantErr: "a port is required for AAAA records",
+		},
+		{
+			desc:    "unsupported record",
+			cfg:     Config{Name: "myservice.example.com", Record: "MX"},
+			wantErr: `unsupported DNS record type "MX", expected A, AAAA, or SRV`,
+		},
+		{
+			desc:    "invalid interval",
+			cfg:     Config{Name: "myservice.example.com", Port: 8080, Interval: -time.Second},
+			wantErr: "interval must be positive, got -1s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			binder, err := build(tt.cfg, nil)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, binder)
+		})
+	}
+}
+
+func TestConfigOptions(t *testing.T) {
+	opts, err := Config{
+		Name:     "_myservice._tcp.example.com",
+		Record:   "srv",
+		Interval: time.Minute,
+	}.options()
+	require.NoError(t, err)
+
+	o := newOptions(opts)
+	assert.Equal(t, SRV, o.recordType)
+	assert.Equal(t, uint16(0), o.port)
+	assert.Equal(t, time.Minute, o.interval)
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package dns provides a peer list updater which discovers peers by
+// resolving DNS records.
+//
+// The Updater resolves A, AAAA, or SRV records for a name at a regular
+// interval and adds and removes peers from its peer list as the records
+// change. A and AAAA records only carry addresses, so peers resolved from
+// them listen on a fixed port. SRV records carry the port of each peer, and
+// their weight, which is the weight of the peer for peer lists supporting
+// weights.
+//
+// 	list := roundrobin.New(transport)
+// 	chooser := peer.Bind(list, dns.Bind("myservice.example.com", dns.Port(8080)))
+//
+// Records are resolved again before their TTL expires, if the resolver knows
+// it; the default resolver, SystemResolver, reports the TTL of the records it
+// gets from the name servers of the system. Resolution failures, including
+// names resolving to no records, leave the peer list unchanged; resolution is
+// retried with backoff.
+//
+// To use the updater with yarpcconfig, register its spec.
+//
+// 	cfg := yarpcconfig.New()
+// 	cfg.MustRegisterPeerListUpdater(dns.Spec())
+//
+// See Spec for the configuration it accepts.
+package dns
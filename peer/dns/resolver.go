@@ -0,0 +1,119 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// RecordType is the type of DNS records an Updater resolves.
+type RecordType string
+
+const (
+	// A records resolve to IPv4 addresses. Peers listen on the port given to
+	// the Updater.
+	A RecordType = "A"
+
+	// AAAA records resolve to IPv6 addresses. Peers listen on the port given
+	// to the Updater.
+	AAAA RecordType = "AAAA"
+
+	// SRV records resolve to host names and the ports peers listen on.
+	SRV RecordType = "SRV"
+)
+
+// Record is a resolved DNS record.
+type Record struct {
+	// Host is the IP address of A and AAAA records, or the target host name
+	// of SRV records.
+	Host string
+
+	// Port is the port of SRV records, and 0 for other records.
+	Port uint16
+
+	// Weight is the weight of SRV records, and 0 for other records. Peers
+	// resolved from records with a weight of 0 have the default weight.
+	Weight uint16
+
+	// TTL is the time to live of the record, or 0 if the resolver does not
+	// know it.
+	TTL time.Duration
+}
+
+// Resolver resolves DNS records.
+type Resolver interface {
+	// Resolve returns the records of the given type for the given name.
+	Resolve(ctx context.Context, name string, recordType RecordType) ([]Record, error)
+}
+
+// NetResolver returns a Resolver backed by the given net.Resolver, or by
+// net.DefaultResolver if nil.
+//
+// The net package does not report the TTL of records, so records resolved
+// with this resolver are refreshed at the interval of the Updater.
+func NetResolver(r *net.Resolver) Resolver {
+	if r == nil {
+		r = net.DefaultResolver
+	}
+	return netResolver{r: r}
+}
+
+type netResolver struct {
+	r *net.Resolver
+}
+
+func (n netResolver) Resolve(ctx context.Context, name string, recordType RecordType) ([]Record, error) {
+	switch recordType {
+	case A, AAAA:
+		addrs, err := n.r.LookupIPAddr(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		var records []Record
+		for _, addr := range addrs {
+			if isIPv4 := addr.IP.To4() != nil; isIPv4 != (recordType == A) {
+				continue
+			}
+			records = append(records, Record{Host: addr.IP.String()})
+		}
+		return records, nil
+	case SRV:
+		_, srvs, err := n.r.LookupSRV(ctx, "", "", name)
+		if err != nil {
+			return nil, err
+		}
+		records := make([]Record, len(srvs))
+		for i, srv := range srvs {
+			records[i] = Record{
+				Host:   strings.TrimSuffix(srv.Target, "."),
+				Port:   srv.Port,
+				Weight: srv.Weight,
+			}
+		}
+		return records, nil
+	default:
+		return nil, fmt.Errorf("unsupported DNS record type %q", recordType)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dns
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetResolver(t *testing.T) {
+	tests := []struct {
+		desc       string
+		name       string
+		recordType RecordType
+		want       []Record
+	}{
+		{
+			desc:       "A",
+			name:       "127.0.0.1",
+			recordType: A,
+			want:       []Record{{Host: "127.0.0.1"}},
+		},
+		{
+			desc:       "A skips IPv6 addresses",
+			name:       "::1",
+			recordType: A,
+		},
+		{
+			desc:       "AAAA",
+			name:       "::1",
+			recordType: AAAA,
+			want:       []Record{{Host: "::1"}},
+		},
+		{
+			desc:       "AAAA skips IPv4 addresses",
+			name:       "127.0.0.1",
+			recordType: AAAA,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			records, err := NetResolver(nil).Resolve(context.Background(), tt.name, tt.recordType)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, records)
+		})
+	}
+}
+
+func TestNetResolverUnsupportedType(t *testing.T) {
+	_, err := NetResolver(nil).Resolve(context.Background(), "myservice.example.com", "MX")
+	assert.EqualError(t, err, `unsupported DNS record type "MX"`)
+}
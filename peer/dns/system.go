@@ -0,0 +1,266 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dns
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	_resolvConf = "/etc/resolv.conf"
+
+	// How long to wait for a name server before trying the next one.
+	_queryTimeout = 5 * time.Second
+
+	// Records with a TTL of 0 must not be cached; they are resolved again
+	// after this delay.
+	_minTTL = time.Second
+)
+
+var _questionTypes = map[RecordType]dnsmessage.Type{
+	A:    dnsmessage.TypeA,
+	AAAA: dnsmessage.TypeAAAA,
+	SRV:  dnsmessage.TypeSRV,
+}
+
+// SystemResolver returns a Resolver which queries the name servers listed in
+// /etc/resolv.conf, and reports the TTL of the records so that they are
+// resolved again before they expire. It is the default resolver.
+//
+// Names the name servers don't resolve, such as names listed in /etc/hosts
+// or relying on search domains, and all names if no name server is
+// configured, are resolved with NetResolver(nil) instead, without TTLs.
+func SystemResolver() Resolver {
+	return systemResolver{
+		servers:  func() []string { return nameServers(_resolvConf) },
+		fallback: NetResolver(nil),
+	}
+}
+
+type systemResolver struct {
+	// Returns the host:port addresses of the name servers to query.
+	servers  func() []string
+	fallback Resolver
+}
+
+func (s systemResolver) Resolve(ctx context.Context, name string, recordType RecordType) ([]Record, error) {
+	qtype, ok := _questionTypes[recordType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported DNS record type %q", recordType)
+	}
+	if net.ParseIP(name) == nil {
+		for _, server := range s.servers() {
+			records, err := query(ctx, server, name, qtype)
+			if err == nil && len(records) > 0 {
+				return records, nil
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+		}
+	}
+	return s.fallback.Resolve(ctx, name, recordType)
+}
+
+// nameServers returns the host:port addresses of the name servers listed in
+// the given resolv.conf file, or nil if it can't be read.
+func nameServers(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var servers []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+		if ip := net.ParseIP(fields[1]); ip != nil {
+			servers = append(servers, net.JoinHostPort(ip.String(), "53"))
+		}
+	}
+	return servers
+}
+
+// query asks the name server for the records of the given type for the
+// name, over UDP, and over TCP if the answer doesn't fit in a datagram.
+func query(ctx context.Context, server, name string, qtype dnsmessage.Type) ([]Record, error) {
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	id := uint16(rand.Uint32())
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{
+			{Name: qname, Type: qtype, Class: dnsmessage.ClassINET},
+		},
+	}
+	req, err := msg.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := exchange(ctx, "udp", server, req)
+	if err == nil && resp.Truncated {
+		resp, err = exchange(ctx, "tcp", server, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.ID != id {
+		return nil, errors.New("DNS response does not match the query")
+	}
+	if resp.RCode != dnsmessage.RCodeSuccess {
+		return nil, fmt.Errorf("DNS query for %q failed: %v", name, resp.RCode)
+	}
+	return answerRecords(resp.Answers, qtype), nil
+}
+
+// exchange sends the query to the name server and reads its response.
+func exchange(ctx context.Context, network, server string, req []byte) (*dnsmessage.Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, _queryTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// Unblock reads and writes once the context finishes.
+	go func() {
+		<-ctx.Done()
+		_ = conn.SetDeadline(time.Unix(1, 0))
+	}()
+
+	var buf []byte
+	if network == "tcp" {
+		// Messages over TCP are prefixed with their length.
+		prefixed := make([]byte, 2+len(req))
+		binary.BigEndian.PutUint16(prefixed, uint16(len(req)))
+		copy(prefixed[2:], req)
+		if _, err := conn.Write(prefixed); err != nil {
+			return nil, err
+		}
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return nil, err
+		}
+		buf = make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+		buf = make([]byte, 65535)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		buf = buf[:n]
+	}
+
+	var resp dnsmessage.Message
+	if err := resp.Unpack(buf); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// answerRecords returns the records of the given type among the answers.
+// The TTL of records reached through CNAME records is bounded by the TTL of
+// these.
+func answerRecords(answers []dnsmessage.Resource, qtype dnsmessage.Type) []Record {
+	var (
+		records  []Record
+		cnameTTL time.Duration
+	)
+	for _, answer := range answers {
+		ttl := time.Duration(answer.Header.TTL) * time.Second
+		if ttl < _minTTL {
+			ttl = _minTTL
+		}
+
+		var record Record
+		switch body := answer.Body.(type) {
+		case *dnsmessage.CNAMEResource:
+			if cnameTTL == 0 || ttl < cnameTTL {
+				cnameTTL = ttl
+			}
+			continue
+		case *dnsmessage.AResource:
+			if qtype != dnsmessage.TypeA {
+				continue
+			}
+			record = Record{Host: net.IP(body.A[:]).String()}
+		case *dnsmessage.AAAAResource:
+			if qtype != dnsmessage.TypeAAAA {
+				continue
+			}
+			record = Record{Host: net.IP(body.AAAA[:]).String()}
+		case *dnsmessage.SRVResource:
+			if qtype != dnsmessage.TypeSRV {
+				continue
+			}
+			record = Record{
+				Host:   strings.TrimSuffix(body.Target.String(), "."),
+				Port:   body.Port,
+				Weight: body.Weight,
+			}
+		default:
+			continue
+		}
+		record.TTL = ttl
+		records = append(records, record)
+	}
+
+	if cnameTTL > 0 {
+		for i := range records {
+			if cnameTTL < records[i].TTL {
+				records[i].TTL = cnameTTL
+			}
+		}
+	}
+	return records
+}
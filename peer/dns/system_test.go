@@ -0,0 +1,258 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fakeNameServer answers DNS queries over UDP and TCP on the same port.
+type fakeNameServer struct {
+	// answer returns the response to the query, and whether it is
+	// truncated over UDP.
+	answer func(q dnsmessage.Question) (answers []dnsmessage.Resource, rcode dnsmessage.RCode, truncated bool)
+
+	udp net.PacketConn
+	tcp net.Listener
+}
+
+func newFakeNameServer(t *testing.T, answer func(dnsmessage.Question) ([]dnsmessage.Resource, dnsmessage.RCode, bool)) *fakeNameServer {
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	require.NoError(t, err)
+
+	s := &fakeNameServer{answer: answer, udp: udp, tcp: tcp}
+	go s.serveUDP()
+	go s.serveTCP()
+	return s
+}
+
+func (s *fakeNameServer) Addr() string { return s.udp.LocalAddr().String() }
+
+func (s *fakeNameServer) Close() {
+	s.udp.Close()
+	s.tcp.Close()
+}
+
+func (s *fakeNameServer) serveUDP() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if resp := s.respond(buf[:n], true); resp != nil {
+			s.udp.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (s *fakeNameServer) serveTCP() {
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			return
+		}
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err == nil {
+			req := make([]byte, binary.BigEndian.Uint16(length[:]))
+			if _, err := io.ReadFull(conn, req); err == nil {
+				if resp := s.respond(req, false); resp != nil {
+					binary.BigEndian.PutUint16(length[:], uint16(len(resp)))
+					conn.Write(append(length[:], resp...))
+				}
+			}
+		}
+		conn.Close()
+	}
+}
+
+func (s *fakeNameServer) respond(req []byte, udp bool) []byte {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(req); err != nil || len(msg.Questions) != 1 {
+		return nil
+	}
+	answers, rcode, truncated := s.answer(msg.Questions[0])
+	msg.Response = true
+	msg.RCode = rcode
+	if udp && truncated {
+		msg.Truncated = true
+		answers = nil
+	}
+	msg.Answers = answers
+	resp, err := msg.Pack()
+	if err != nil {
+		return nil
+	}
+	return resp
+}
+
+func mustName(name string) dnsmessage.Name {
+	n, err := dnsmessage.NewName(name)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func resource(name string, ttl uint32, body dnsmessage.ResourceBody) dnsmessage.Resource {
+	header := dnsmessage.ResourceHeader{
+		Name:  mustName(name),
+		Class: dnsmessage.ClassINET,
+		TTL:   ttl,
+	}
+	switch body.(type) {
+	case *dnsmessage.AResource:
+		header.Type = dnsmessage.TypeA
+	case *dnsmessage.SRVResource:
+		header.Type = dnsmessage.TypeSRV
+	case *dnsmessage.CNAMEResource:
+		header.Type = dnsmessage.TypeCNAME
+	}
+	return dnsmessage.Resource{Header: header, Body: body}
+}
+
+func TestSystemResolver(t *testing.T) {
+	tests := []struct {
+		desc       string
+		name       string
+		recordType RecordType
+		answers    []dnsmessage.Resource
+		rcode      dnsmessage.RCode
+		truncated  bool
+		want       []Record
+	}{
+		{
+			desc:       "A",
+			name:       "myservice.example.com",
+			recordType: A,
+			answers: []dnsmessage.Resource{
+				resource("myservice.example.com.", 30, &dnsmessage.AResource{A: [4]byte{10, 0, 0, 1}}),
+				resource("myservice.example.com.", 60, &dnsmessage.AResource{A: [4]byte{10, 0, 0, 2}}),
+			},
+			want: []Record{
+				{Host: "10.0.0.1", TTL: 30 * time.Second},
+				{Host: "10.0.0.2", TTL: time.Minute},
+			},
+		},
+		{
+			desc:       "SRV",
+			name:       "_myservice._tcp.example.com",
+			recordType: SRV,
+			answers: []dnsmessage.Resource{
+				resource("_myservice._tcp.example.com.", 0, &dnsmessage.SRVResource{
+					Target: mustName("host1.example.com."),
+					Port:   8080,
+					Weight: 5,
+				}),
+			},
+			want: []Record{{Host: "host1.example.com", Port: 8080, Weight: 5, TTL: time.Second}},
+		},
+		{
+			desc:       "CNAME bounds TTL",
+			name:       "myservice.example.com",
+			recordType: A,
+			answers: []dnsmessage.Resource{
+				resource("myservice.example.com.", 5, &dnsmessage.CNAMEResource{CNAME: mustName("host1.example.com.")}),
+				resource("host1.example.com.", 60, &dnsmessage.AResource{A: [4]byte{10, 0, 0, 1}}),
+			},
+			want: []Record{{Host: "10.0.0.1", TTL: 5 * time.Second}},
+		},
+		{
+			desc:       "truncated responses are retried over TCP",
+			name:       "myservice.example.com",
+			recordType: A,
+			answers: []dnsmessage.Resource{
+				resource("myservice.example.com.", 30, &dnsmessage.AResource{A: [4]byte{10, 0, 0, 1}}),
+			},
+			truncated: true,
+			want:      []Record{{Host: "10.0.0.1", TTL: 30 * time.Second}},
+		},
+		{
+			desc:       "unknown names fall back",
+			name:       "myservice",
+			recordType: A,
+			rcode:      dnsmessage.RCodeNameError,
+			want:       []Record{{Host: "10.0.0.9"}},
+		},
+		{
+			desc:       "IP addresses fall back",
+			name:       "10.0.0.9",
+			recordType: A,
+			want:       []Record{{Host: "10.0.0.9"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var queries int32
+			server := newFakeNameServer(t, func(q dnsmessage.Question) ([]dnsmessage.Resource, dnsmessage.RCode, bool) {
+				atomic.AddInt32(&queries, 1)
+				assert.Equal(t, tt.name+".", q.Name.String(), "unexpected name")
+				assert.Equal(t, _questionTypes[tt.recordType], q.Type, "unexpected type")
+				return tt.answers, tt.rcode, tt.truncated
+			})
+			defer server.Close()
+
+			resolver := systemResolver{
+				servers:  func() []string { return []string{server.Addr()} },
+				fallback: &fakeResolver{records: []Record{{Host: "10.0.0.9"}}},
+			}
+			records, err := resolver.Resolve(context.Background(), tt.name, tt.recordType)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, records)
+			if net.ParseIP(tt.name) != nil {
+				assert.Equal(t, int32(0), atomic.LoadInt32(&queries), "IP addresses must not be queried")
+			}
+		})
+	}
+}
+
+func TestSystemResolverUnsupportedType(t *testing.T) {
+	_, err := SystemResolver().Resolve(context.Background(), "myservice.example.com", "MX")
+	assert.EqualError(t, err, `unsupported DNS record type "MX"`)
+}
+
+func TestNameServers(t *testing.T) {
+	f, err := ioutil.TempFile("", "resolv.conf")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString("# comment\nsearch example.com\nnameserver 10.0.0.1\nnameserver ::1\nnameserver bogus\noptions ndots:2\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, []string{"10.0.0.1:53", "[::1]:53"}, nameServers(f.Name()))
+	assert.Empty(t, nameServers(f.Name()+".missing"))
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"go.uber.org/yarpc/api/backoff"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	intbackoff "go.uber.org/yarpc/internal/backoff"
+	"go.uber.org/yarpc/internal/peerset"
+	"go.uber.org/yarpc/pkg/lifecycle"
+)
+
+const _defaultInterval = 30 * time.Second
+
+var errNoRecords = errors.New("no records found")
+
+// Option customizes an Updater.
+type Option func(*options)
+
+type options struct {
+	recordType RecordType
+	port       uint16
+	interval   time.Duration
+	resolver   Resolver
+	backoff    backoff.Strategy
+}
+
+// Type specifies the type of records the Updater resolves.
+//
+// Defaults to A.
+func Type(recordType RecordType) Option {
+	return func(o *options) {
+		o.recordType = recordType
+	}
+}
+
+// Port specifies the port peers resolved from A and AAAA records listen on.
+// It is required for these records, and must not be set for SRV records.
+func Port(port uint16) Option {
+	return func(o *options) {
+		o.port = port
+	}
+}
+
+// Interval specifies how often records are resolved. Records are resolved
+// sooner if their TTL is shorter.
+//
+// Defaults to 30 seconds.
+func Interval(interval time.Duration) Option {
+	return func(o *options) {
+		o.interval = interval
+	}
+}
+
+// WithResolver specifies the resolver used to resolve records.
+//
+// Defaults to SystemResolver().
+func WithResolver(r Resolver) Option {
+	return func(o *options) {
+		o.resolver = r
+	}
+}
+
+// BackoffStrategy specifies the backoff strategy for retrying failed
+// resolutions. Retries are never delayed beyond the interval.
+//
+// The default is exponential backoff starting with 10ms fully jittered,
+// doubling each attempt, with a maximum interval of 30s.
+func BackoffStrategy(strategy backoff.Strategy) Option {
+	return func(o *options) {
+		o.backoff = strategy
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		recordType: A,
+		interval:   _defaultInterval,
+		resolver:   SystemResolver(),
+		backoff:    intbackoff.DefaultExponential,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o options) validate() error {
+	switch o.recordType {
+	case A, AAAA:
+		if o.port == 0 {
+			return fmt.Errorf("a port is required for %s records", o.recordType)
+		}
+	case SRV:
+		if o.port != 0 {
+			return errors.New("SRV records carry their own port, a port cannot be set")
+		}
+	default:
+		return fmt.Errorf("unsupported DNS record type %q, expected A, AAAA, or SRV", o.recordType)
+	}
+	if o.interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %v", o.interval)
+	}
+	return nil
+}
+
+// Bind returns a peer.Binder which binds peer lists to an Updater resolving
+// the given name. It is suitable for passing to peer.Bind.
+func Bind(name string, opts ...Option) peer.Binder {
+	return func(list peer.List) transport.Lifecycle {
+		return NewUpdater(list, name, opts...)
+	}
+}
+
+// Updater keeps a peer list up to date with the records of a DNS name.
+type Updater struct {
+	once *lifecycle.Once
+	name string
+	opts options
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped chan struct{}
+	backoff backoff.Backoff
+
+	peers *peerset.Set
+}
+
+// NewUpdater returns an Updater which adds the peers resolved from the
+// records of the given name to the peer list.
+func NewUpdater(list peer.List, name string, opts ...Option) *Updater {
+	return &Updater{
+		once:    lifecycle.NewOnce(),
+		name:    name,
+		opts:    newOptions(opts),
+		stopped: make(chan struct{}),
+		peers:   peerset.New(list),
+	}
+}
+
+// Start resolves the records of the name a first time, and keeps resolving
+// them in the background until the Updater is stopped.
+//
+// Start fails only if the Updater is misconfigured or the peer list rejects
+// the resolved peers. If the first resolution fails, the peer list starts
+// empty and resolution is retried in the background.
+func (u *Updater) Start() error {
+	return u.once.Start(u.start)
+}
+
+func (u *Updater) start() error {
+	if u.name == "" {
+		return errors.New("a DNS name is required")
+	}
+	if err := u.opts.validate(); err != nil {
+		return err
+	}
+
+	u.ctx, u.cancel = context.WithCancel(context.Background())
+	u.backoff = u.opts.backoff.Backoff()
+	next, _, err := u.refresh(0)
+	if err != nil {
+		u.cancel()
+		return err
+	}
+	go u.run(next)
+	return nil
+}
+
+// Stop stops resolving records and removes all resolved peers from the peer
+// list.
+func (u *Updater) Stop() error {
+	return u.once.Stop(u.stop)
+}
+
+func (u *Updater) stop() error {
+	u.cancel()
+	<-u.stopped
+	return u.peers.RemoveAll()
+}
+
+// IsRunning returns whether the Updater is running.
+func (u *Updater) IsRunning() bool {
+	return u.once.IsRunning()
+}
+
+func (u *Updater) run(next time.Duration) {
+	defer close(u.stopped)
+
+	var failures uint
+	timer := time.NewTimer(next)
+	defer timer.Stop()
+	for {
+		select {
+		case <-u.ctx.Done():
+			return
+		case <-timer.C:
+		}
+		// Errors updating the peer list are not fatal once started; the
+		// list is brought up to date at the next refresh.
+		next, failures, _ = u.refresh(failures)
+		timer.Reset(next)
+	}
+}
+
+// refresh resolves the records of the name and updates the peer list with
+// them. It returns the delay until the next refresh, and the updated number
+// of consecutive resolution failures.
+//
+// Resolution failures are not returned as errors; they leave the peer list
+// unchanged.
+func (u *Updater) refresh(failures uint) (time.Duration, uint, error) {
+	records, err := u.opts.resolver.Resolve(u.ctx, u.name, u.opts.recordType)
+	if err == nil && len(records) == 0 {
+		err = errNoRecords
+	}
+	if err != nil {
+		failures++
+		return u.retryDelay(failures), failures, nil
+	}
+
+	peers := make(map[string]int, len(records))
+	next := u.opts.interval
+	for _, record := range records {
+		port := u.opts.port
+		if u.opts.recordType == SRV {
+			port = record.Port
+		}
+		peers[net.JoinHostPort(record.Host, strconv.Itoa(int(port)))] = int(record.Weight)
+		if record.TTL > 0 && record.TTL < next {
+			next = record.TTL
+		}
+	}
+	return next, 0, u.peers.Replace(peers)
+}
+
+func (u *Updater) retryDelay(failures uint) time.Duration {
+	delay := u.backoff.Duration(failures)
+	if delay > u.opts.interval {
+		delay = u.opts.interval
+	}
+	return delay
+}
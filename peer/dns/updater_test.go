@@ -0,0 +1,391 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	backoffapi "go.uber.org/yarpc/api/backoff"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/internal/peerset"
+	"go.uber.org/yarpc/peer/hostport"
+)
+
+type fakeResolver struct {
+	sync.Mutex
+
+	records []Record
+	err     error
+	calls   int
+}
+
+func (r *fakeResolver) set(records []Record, err error) {
+	r.Lock()
+	defer r.Unlock()
+	r.records = records
+	r.err = err
+}
+
+func (r *fakeResolver) Calls() int {
+	r.Lock()
+	defer r.Unlock()
+	return r.calls
+}
+
+func (r *fakeResolver) Resolve(ctx context.Context, name string, recordType RecordType) ([]Record, error) {
+	r.Lock()
+	defer r.Unlock()
+	r.calls++
+	return r.records, r.err
+}
+
+// fakeList records the peers added to and removed from it.
+type fakeList struct {
+	sync.Mutex
+
+	peers   map[string]struct{}
+	updates []peer.ListUpdates
+	err     error
+}
+
+func newFakeList() *fakeList {
+	return &fakeList{peers: make(map[string]struct{})}
+}
+
+func (l *fakeList) Update(updates peer.ListUpdates) error {
+	l.Lock()
+	defer l.Unlock()
+	l.updates = append(l.updates, updates)
+	if l.err != nil {
+		return l.err
+	}
+	for _, id := range updates.Additions {
+		l.peers[id.Identifier()] = struct{}{}
+	}
+	for _, id := range updates.Removals {
+		delete(l.peers, id.Identifier())
+	}
+	return nil
+}
+
+func (l *fakeList) Peers() []string {
+	l.Lock()
+	defer l.Unlock()
+	return sortedKeys(l.peers)
+}
+
+func (l *fakeList) Updates() []peer.ListUpdates {
+	l.Lock()
+	defer l.Unlock()
+	return append([]peer.ListUpdates(nil), l.updates...)
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	ids := peerset.Identifiers(m)
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = id.Identifier()
+	}
+	return keys
+}
+
+// waitFor polls the condition until it holds or a second has passed.
+func waitFor(t *testing.T, msg string, cond func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting: %v", msg)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func ids(addrs ...string) []peer.Identifier {
+	if len(addrs) == 0 {
+		return nil
+	}
+	ids := make([]peer.Identifier, len(addrs))
+	for i, addr := range addrs {
+		ids[i] = hostport.PeerIdentifier(addr)
+	}
+	return ids
+}
+
+type noBackoff struct{}
+
+func (noBackoff) Backoff() backoffapi.Backoff { return noBackoff{} }
+func (noBackoff) Duration(uint) time.Duration { return time.Millisecond }
+
+func TestUpdaterA(t *testing.T) {
+	resolver := &fakeResolver{records: []Record{{Host: "10.0.0.2"}, {Host: "10.0.0.1"}}}
+	list := newFakeList()
+	updater := NewUpdater(list, "myservice", Port(8080), Interval(5*time.Millisecond), WithResolver(resolver))
+
+	require.NoError(t, updater.Start())
+	assert.True(t, updater.IsRunning())
+	assert.Equal(t, []string{"10.0.0.1:8080", "10.0.0.2:8080"}, list.Peers(),
+		"peers must be added when starting")
+
+	resolver.set([]Record{{Host: "10.0.0.2"}, {Host: "10.0.0.3"}}, nil)
+	waitFor(t, "peers to change", func() bool {
+		return assert.ObjectsAreEqual([]string{"10.0.0.2:8080", "10.0.0.3:8080"}, list.Peers())
+	})
+
+	require.NoError(t, updater.Stop())
+	assert.False(t, updater.IsRunning())
+	assert.Empty(t, list.Peers(), "peers must be removed when stopping")
+
+	updates := list.Updates()
+	require.Len(t, updates, 3)
+	assert.Equal(t, peer.ListUpdates{
+		Additions: ids("10.0.0.1:8080", "10.0.0.2:8080"),
+		Removals:  ids(),
+	}, updates[0])
+	assert.Equal(t, peer.ListUpdates{
+		Additions: ids("10.0.0.3:8080"),
+		Removals:  ids("10.0.0.1:8080"),
+	}, updates[1], "only changes must be applied")
+	assert.Equal(t, peer.ListUpdates{
+		Removals: ids("10.0.0.2:8080", "10.0.0.3:8080"),
+	}, updates[2])
+}
+
+func TestUpdaterAAAA(t *testing.T) {
+	resolver := &fakeResolver{records: []Record{{Host: "::1"}}}
+	list := newFakeList()
+	updater := NewUpdater(list, "myservice", Type(AAAA), Port(8080), WithResolver(resolver))
+
+	require.NoError(t, updater.Start())
+	defer updater.Stop()
+	assert.Equal(t, []string{"[::1]:8080"}, list.Peers())
+}
+
+func TestUpdaterSRV(t *testing.T) {
+	resolver := &fakeResolver{records: []Record{
+		{Host: "a.example.com", Port: 8080},
+		{Host: "b.example.com", Port: 8081},
+	}}
+	list := newFakeList()
+	updater := NewUpdater(list, "_myservice._tcp.example.com", Type(SRV), WithResolver(resolver))
+
+	require.NoError(t, updater.Start())
+	defer updater.Stop()
+	assert.Equal(t, []string{"a.example.com:8080", "b.example.com:8081"}, list.Peers())
+}
+
+func TestUpdaterSRVWeights(t *testing.T) {
+	resolver := &fakeResolver{records: []Record{
+		{Host: "a.example.com", Port: 8080, Weight: 3},
+		{Host: "b.example.com", Port: 8081, Weight: 0},
+	}}
+	list := newFakeList()
+	updater := NewUpdater(list, "_myservice._tcp.example.com", Type(SRV), WithResolver(resolver), Interval(time.Millisecond))
+
+	require.NoError(t, updater.Start())
+	defer updater.Stop()
+
+	updates := list.Updates()
+	require.Len(t, updates, 1)
+	assert.Equal(t, map[string]int{"a.example.com:8080": 3}, updates[0].Weights)
+
+	resolver.set([]Record{
+		{Host: "a.example.com", Port: 8080, Weight: 3},
+		{Host: "b.example.com", Port: 8081, Weight: 2},
+	}, nil)
+	waitFor(t, "weight change to be applied", func() bool {
+		return len(list.Updates()) > 1
+	})
+	updates = list.Updates()
+	assert.Equal(t, peer.ListUpdates{
+		Weights: map[string]int{"b.example.com:8081": 2},
+	}, updates[1], "only the changed weight must be applied")
+}
+
+func TestUpdaterHonorsTTL(t *testing.T) {
+	resolver := &fakeResolver{records: []Record{
+		{Host: "10.0.0.1", TTL: time.Hour},
+		{Host: "10.0.0.2", TTL: time.Millisecond},
+	}}
+	list := newFakeList()
+	updater := NewUpdater(list, "myservice", Port(8080), Interval(time.Hour), WithResolver(resolver))
+
+	require.NoError(t, updater.Start())
+	defer updater.Stop()
+
+	waitFor(t, "records to be resolved before the interval", func() bool {
+		return resolver.Calls() > 2
+	})
+}
+
+func TestUpdaterToleratesFailures(t *testing.T) {
+	tests := []struct {
+		desc    string
+		records []Record
+		err     error
+	}{
+		{desc: "resolution error", err: errors.New("great sadness")},
+		{desc: "no records"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			// A short TTL refreshes records quickly despite the long interval.
+			resolver := &fakeResolver{records: []Record{{Host: "10.0.0.1", TTL: time.Millisecond}}}
+			list := newFakeList()
+			updater := NewUpdater(list, "myservice",
+				Port(8080),
+				Interval(time.Hour),
+				WithResolver(resolver),
+				BackoffStrategy(noBackoff{}),
+			)
+
+			require.NoError(t, updater.Start())
+			defer updater.Stop()
+			assert.Equal(t, []string{"10.0.0.1:8080"}, list.Peers())
+
+			resolver.set(tt.records, tt.err)
+			calls := resolver.Calls()
+			waitFor(t, "resolution to be retried", func() bool {
+				return resolver.Calls() > calls+3
+			})
+			assert.Equal(t, []string{"10.0.0.1:8080"}, list.Peers(),
+				"peers must be kept while resolution fails")
+
+			resolver.set([]Record{{Host: "10.0.0.2", TTL: time.Millisecond}}, nil)
+			waitFor(t, "peers to change after recovering", func() bool {
+				return assert.ObjectsAreEqual([]string{"10.0.0.2:8080"}, list.Peers())
+			})
+		})
+	}
+}
+
+func TestUpdaterStartsDespiteFailure(t *testing.T) {
+	resolver := &fakeResolver{err: errors.New("great sadness")}
+	list := newFakeList()
+	updater := NewUpdater(list, "myservice",
+		Port(8080),
+		Interval(time.Hour),
+		WithResolver(resolver),
+		BackoffStrategy(noBackoff{}),
+	)
+
+	require.NoError(t, updater.Start(), "start must tolerate resolution failures")
+	assert.Empty(t, list.Peers())
+
+	resolver.set([]Record{{Host: "10.0.0.1"}}, nil)
+	waitFor(t, "peers to be added", func() bool {
+		return assert.ObjectsAreEqual([]string{"10.0.0.1:8080"}, list.Peers())
+	})
+
+	require.NoError(t, updater.Stop())
+	assert.Empty(t, list.Peers())
+}
+
+func TestUpdaterStopWithoutPeers(t *testing.T) {
+	resolver := &fakeResolver{}
+	list := newFakeList()
+	updater := NewUpdater(list, "myservice", Port(8080), WithResolver(resolver))
+
+	require.NoError(t, updater.Start())
+	require.NoError(t, updater.Stop())
+	assert.Empty(t, list.Updates(), "the list must not be updated without peers")
+}
+
+func TestUpdaterStartListError(t *testing.T) {
+	list := newFakeList()
+	list.err = errors.New("great sadness")
+	updater := NewUpdater(list, "myservice", Port(8080),
+		WithResolver(&fakeResolver{records: []Record{{Host: "10.0.0.1"}}}))
+
+	assert.EqualError(t, updater.Start(), "great sadness")
+	assert.False(t, updater.IsRunning())
+}
+
+func TestUpdaterInvalidOptions(t *testing.T) {
+	tests := []struct {
+		desc    string
+		name    string
+		opts    []Option
+		wantErr string
+	}{
+		{
+			desc:    "missing name",
+			opts:    []Option{Port(8080)},
+			wantErr: "a DNS name is required",
+		},
+		{
+			desc:    "missing port for A",
+			name:    "myservice",
+			wantErr: "a port is required for A records",
+		},
+		{
+			desc:    "missing port for AAAA",
+			name:    "myservice",
+			opts:    []Option{Type(AAAA)},
+			wantErr: "a port is required for AAAA records",
+		},
+		{
+			desc:    "port for SRV",
+			name:    "myservice",
+			opts:    []Option{Type(SRV), Port(8080)},
+			wantErr: "SRV records carry their own port, a port cannot be set",
+		},
+		{
+			desc:    "unsupported record type",
+			name:    "myservice",
+			opts:    []Option{Type("MX")},
+			wantErr: `unsupported DNS record type "MX", expected A, AAAA, or SRV`,
+		},
+		{
+			desc:    "invalid interval",
+			name:    "myservice",
+			opts:    []Option{Port(8080), Interval(-time.Second)},
+			wantErr: "interval must be positive, got -1s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			resolver := &fakeResolver{records: []Record{{Host: "10.0.0.1"}}}
+			opts := append([]Option{WithResolver(resolver)}, tt.opts...)
+			updater := NewUpdater(newFakeList(), tt.name, opts...)
+			assert.EqualError(t, updater.Start(), tt.wantErr)
+			assert.Equal(t, 0, resolver.Calls(), "must not resolve records")
+		})
+	}
+}
+
+func TestBind(t *testing.T) {
+	list := newFakeList()
+	lc := Bind("myservice", Port(8080),
+		WithResolver(&fakeResolver{records: []Record{{Host: "10.0.0.1"}}}))(list)
+
+	require.NoError(t, lc.Start())
+	assert.Equal(t, []string{"10.0.0.1:8080"}, list.Peers())
+	require.NoError(t, lc.Stop())
+	assert.Empty(t, list.Peers())
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package file
+
+import (
+	"errors"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/yarpcconfig"
+)
+
+// Config is the configuration of the file peer list updater.
+type Config struct {
+	// File is the path to the file listing peers. Required.
+	File string `config:"file,interpolate"`
+
+	// Interval is how often the file is checked for changes. Defaults to 5s.
+	Interval time.Duration `config:"interval"`
+}
+
+// Spec returns a configuration specification for the file peer list
+// updater, making it possible to read peers from a file for transports that
+// use outbound peer list configuration (like HTTP).
+//
+// The given options apply to all updaters built from configuration, which
+// makes it possible to provide them a Logger.
+//
+//  cfg := yarpcconfig.New()
+//  cfg.MustRegisterPeerListUpdater(file.Spec(file.Logger(logger)))
+//
+// This enables the peers-json peer list updater under any peer list:
+//
+//  outbounds:
+//    otherservice:
+//      unary:
+//        http:
+//          url: https://host:port/rpc
+//          round-robin:
+//            peers-json:
+//              file: /var/run/otherservice/peers.json
+//              interval: 1s
+func Spec(opts ...Option) yarpcconfig.PeerListUpdaterSpec {
+	return yarpcconfig.PeerListUpdaterSpec{
+		Name: "peers-json",
+		BuildPeerListUpdater: func(c Config, _ *yarpcconfig.Kit) (peer.Binder, error) {
+			if c.File == "" {
+				return nil, errors.New("a file path is required")
+			}
+
+			opts := append([]Option(nil), opts...)
+			if c.Interval != 0 {
+				opts = append(opts, Interval(c.Interval))
+			}
+			if err := newOptions(opts).validate(); err != nil {
+				return nil, err
+			}
+			return Bind(c.File, opts...), nil
+		},
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package file provides a peer list updater which reads peers from a file.
+//
+// The Updater watches a file listing host:port addresses, in JSON or YAML,
+// and adds and removes peers from its peer list as the file changes. This
+// suits deployments where a sidecar writes the hosts of a service to disk.
+//
+// 	["10.0.0.1:8080", "10.0.0.2:8080"]
+//
+// Peers may have a weight, for peer lists supporting weights. Peers without
+// a weight have the default weight.
+//
+// 	["10.0.0.1:8080", {"address": "10.0.0.2:8080", "weight": 3}]
+//
+// The file is checked for changes at an interval. Files that cannot be read,
+// that are invalid, or that list no peers are logged and leave the peer list
+// unchanged, so a sidecar rewriting the file never wipes the peer list.
+//
+// 	list := roundrobin.New(transport)
+// 	chooser := peer.Bind(list, file.Bind("/var/run/myservice/peers.json"))
+//
+// To use the updater with yarpcconfig, register its spec.
+//
+// 	cfg := yarpcconfig.New()
+// 	cfg.MustRegisterPeerListUpdater(file.Spec())
+//
+// See Spec for the configuration it accepts.
+package file
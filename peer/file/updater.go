@@ -0,0 +1,277 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package file
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/peerset"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+)
+
+const _defaultInterval = 5 * time.Second
+
+var errNoPeers = errors.New("no peers found")
+
+// Option customizes an Updater.
+type Option func(*options)
+
+type options struct {
+	interval time.Duration
+	logger   *zap.Logger
+}
+
+// Interval specifies how often the file is checked for changes.
+//
+// Defaults to 5 seconds.
+func Interval(interval time.Duration) Option {
+	return func(o *options) {
+		o.interval = interval
+	}
+}
+
+// Logger specifies the logger to which invalid files are reported.
+//
+// Defaults to a no-op logger.
+func Logger(logger *zap.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		interval: _defaultInterval,
+		logger:   zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o options) validate() error {
+	if o.interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %v", o.interval)
+	}
+	return nil
+}
+
+// Bind returns a peer.Binder which binds peer lists to an Updater watching
+// the file at the given path. It is suitable for passing to peer.Bind.
+func Bind(path string, opts ...Option) peer.Binder {
+	return func(list peer.List) transport.Lifecycle {
+		return NewUpdater(list, path, opts...)
+	}
+}
+
+// Updater keeps a peer list up to date with the peers listed in a file.
+type Updater struct {
+	once *lifecycle.Once
+	path string
+	opts options
+
+	stop    chan struct{}
+	stopped chan struct{}
+
+	// Contents of the file and error reported at the last check, used to
+	// skip unchanged files and avoid logging the same error repeatedly.
+	// Owned by the goroutine watching the file while the Updater is running.
+	lastData []byte
+	lastErr  string
+
+	peers *peerset.Set
+}
+
+// NewUpdater returns an Updater which adds the peers listed in the file at
+// the given path to the peer list.
+func NewUpdater(list peer.List, path string, opts ...Option) *Updater {
+	return &Updater{
+		once:    lifecycle.NewOnce(),
+		path:    path,
+		opts:    newOptions(opts),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		peers:   peerset.New(list),
+	}
+}
+
+// Start reads the file a first time, and keeps checking it for changes in
+// the background until the Updater is stopped.
+//
+// Start fails only if the Updater is misconfigured or the peer list rejects
+// the peers in the file. If the file is missing or invalid, the peer list
+// starts empty and the file is checked again at the next interval.
+func (u *Updater) Start() error {
+	return u.once.Start(u.start)
+}
+
+func (u *Updater) start() error {
+	if u.path == "" {
+		return errors.New("a file path is required")
+	}
+	if err := u.opts.validate(); err != nil {
+		return err
+	}
+
+	if err := u.check(); err != nil {
+		return err
+	}
+	go u.watch()
+	return nil
+}
+
+// Stop stops watching the file and removes all its peers from the peer
+// list.
+func (u *Updater) Stop() error {
+	return u.once.Stop(u.stopUpdater)
+}
+
+func (u *Updater) stopUpdater() error {
+	close(u.stop)
+	<-u.stopped
+	return u.peers.RemoveAll()
+}
+
+// IsRunning returns whether the Updater is running.
+func (u *Updater) IsRunning() bool {
+	return u.once.IsRunning()
+}
+
+func (u *Updater) watch() {
+	defer close(u.stopped)
+
+	ticker := time.NewTicker(u.opts.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-u.stop:
+			return
+		case <-ticker.C:
+		}
+		if err := u.check(); err != nil {
+			u.opts.logger.Warn("failed to update peer list from file",
+				zap.String("path", u.path), zap.Error(err))
+		}
+	}
+}
+
+// check reads the file and updates the peer list with its peers if it
+// changed since the last check.
+//
+// Files that cannot be read or are invalid are logged rather than returned
+// as errors; they leave the peer list unchanged.
+func (u *Updater) check() error {
+	data, err := ioutil.ReadFile(u.path)
+	if err != nil {
+		u.invalid(err)
+		return nil
+	}
+	if u.lastData != nil && bytes.Equal(data, u.lastData) {
+		return nil
+	}
+
+	addrs, err := parse(data)
+	if err != nil {
+		u.invalid(err)
+		return nil
+	}
+	if err := u.peers.Replace(addrs); err != nil {
+		// Check the file again at the next interval.
+		u.lastData = nil
+		return err
+	}
+	u.lastData = data
+	u.lastErr = ""
+	return nil
+}
+
+func (u *Updater) invalid(err error) {
+	u.lastData = nil
+	if msg := err.Error(); msg != u.lastErr {
+		u.lastErr = msg
+		u.opts.logger.Warn("ignoring invalid peers file, keeping current peers",
+			zap.String("path", u.path), zap.Error(err))
+	}
+}
+
+// parse parses a JSON or YAML list of host:port addresses, returning the
+// weight of each address, or 0 if it has none.
+func parse(data []byte) (map[string]int, error) {
+	var peers []peerEntry
+	if err := yaml.Unmarshal(data, &peers); err != nil {
+		return nil, fmt.Errorf("failed to parse peers: %v", err)
+	}
+	if len(peers) == 0 {
+		return nil, errNoPeers
+	}
+
+	addrs := make(map[string]int, len(peers))
+	for _, p := range peers {
+		host, port, err := net.SplitHostPort(p.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer %q: %v", p.Address, err)
+		}
+		if host == "" || port == "" {
+			return nil, fmt.Errorf("invalid peer %q: expected host:port", p.Address)
+		}
+		addrs[p.Address] = p.Weight
+	}
+	return addrs, nil
+}
+
+// peerEntry is an entry of a peers file, which is either a host:port address
+// or an object with an "address" and a "weight".
+type peerEntry struct {
+	Address string
+	Weight  int
+}
+
+func (e *peerEntry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&e.Address); err == nil {
+		return nil
+	}
+
+	var attrs struct {
+		Address string `yaml:"address"`
+		Weight  *int   `yaml:"weight"`
+	}
+	if err := unmarshal(&attrs); err != nil {
+		return err
+	}
+	e.Address = attrs.Address
+	if attrs.Weight != nil {
+		if *attrs.Weight <= 0 {
+			return fmt.Errorf("invalid weight %d for peer %q: weights must be positive", *attrs.Weight, attrs.Address)
+		}
+		e.Weight = *attrs.Weight
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package file
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fakeList records the peers added to and removed from it, with their
+// weights.
+type fakeList struct {
+	sync.Mutex
+
+	peers map[string]int
+	err   error
+}
+
+func newFakeList() *fakeList {
+	return &fakeList{peers: make(map[string]int)}
+}
+
+func (l *fakeList) Update(updates peer.ListUpdates) error {
+	l.Lock()
+	defer l.Unlock()
+	if l.err != nil {
+		return l.err
+	}
+	for _, id := range updates.Removals {
+		delete(l.peers, id.Identifier())
+	}
+	for _, id := range updates.Additions {
+		l.peers[id.Identifier()] = updates.Weight(id.Identifier())
+	}
+	for id, weight := range updates.Weights {
+		if _, ok := l.peers[id]; ok {
+			l.peers[id] = weight
+		}
+	}
+	return nil
+}
+
+func (l *fakeList) Weights() map[string]int {
+	l.Lock()
+	defer l.Unlock()
+	weights := make(map[string]int, len(l.peers))
+	for id, weight := range l.peers {
+		weights[id] = weight
+	}
+	return weights
+}
+
+func (l *fakeList) Peers() []string {
+	l.Lock()
+	defer l.Unlock()
+	peers := make([]string, 0, len(l.peers))
+	for id := range l.peers {
+		peers = append(peers, id)
+	}
+	sort.Strings(peers)
+	return peers
+}
+
+// waitFor polls the condition until it holds or a second has passed.
+func waitFor(t *testing.T, msg string, cond func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting: %v", msg)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func tempFile(t *testing.T) (path string, cleanup func()) {
+	dir, err := ioutil.TempDir("", "yarpc-peers")
+	require.NoError(t, err)
+	return filepath.Join(dir, "peers.json"), func() { os.RemoveAll(dir) }
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644))
+}
+
+func TestUpdater(t *testing.T) {
+	path, cleanup := tempFile(t)
+	defer cleanup()
+	writeFile(t, path, `["127.0.0.1:2", "127.0.0.1:1"]`)
+
+	list := newFakeList()
+	updater := NewUpdater(list, path, Interval(time.Millisecond))
+
+	require.NoError(t, updater.Start())
+	assert.True(t, updater.IsRunning())
+	assert.Equal(t, []string{"127.0.0.1:1", "127.0.0.1:2"}, list.Peers(),
+		"peers must be added when starting")
+
+	writeFile(t, path, "- 127.0.0.1:2\n- 127.0.0.1:3\n")
+	waitFor(t, "peers to change", func() bool {
+		return assert.ObjectsAreEqual([]string{"127.0.0.1:2", "127.0.0.1:3"}, list.Peers())
+	})
+
+	require.NoError(t, updater.Stop())
+	assert.False(t, updater.IsRunning())
+	assert.Empty(t, list.Peers(), "peers must be removed when stopping")
+}
+
+func TestUpdaterWeights(t *testing.T) {
+	path, cleanup := tempFile(t)
+	defer cleanup()
+	writeFile(t, path, `["127.0.0.1:1", {"address": "127.0.0.1:2", "weight": 3}]`)
+
+	list := newFakeList()
+	updater := NewUpdater(list, path, Interval(time.Millisecond))
+
+	require.NoError(t, updater.Start())
+	defer updater.Stop()
+	assert.Equal(t, map[string]int{"127.0.0.1:1": 1, "127.0.0.1:2": 3}, list.Weights())
+
+	writeFile(t, path, `[{"address": "127.0.0.1:1", "weight": 2}, "127.0.0.1:2"]`)
+	waitFor(t, "weights to change", func() bool {
+		return assert.ObjectsAreEqual(map[string]int{"127.0.0.1:1": 2, "127.0.0.1:2": 1}, list.Weights())
+	})
+}
+
+func TestUpdaterKeepsPeers(t *testing.T) {
+	tests := []struct {
+		desc    string
+		give    *string // nil removes the file
+		wantLog string
+	}{
+		{
+			desc:    "missing file",
+			wantLog: "no such file or directory",
+		},
+		{
+			desc:    "invalid file",
+			give:    stringPtr(`["127.0.0.1:1"`),
+			wantLog: "failed to parse peers",
+		},
+		{
+			desc:    "no peers",
+			give:    stringPtr(`[]`),
+			wantLog: "no peers found",
+		},
+		{
+			desc:    "invalid peer",
+			give:    stringPtr(`["127.0.0.1:1", "127.0.0.1"]`),
+			wantLog: `invalid peer "127.0.0.1"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			path, cleanup := tempFile(t)
+			defer cleanup()
+			writeFile(t, path, `["127.0.0.1:1"]`)
+
+			core, logs := observer.New(zapcore.WarnLevel)
+			list := newFakeList()
+			updater := NewUpdater(list, path, Interval(time.Millisecond), Logger(zap.New(core)))
+			require.NoError(t, updater.Start())
+			defer updater.Stop()
+
+			if tt.give == nil {
+				require.NoError(t, os.Remove(path))
+			} else {
+				writeFile(t, path, *tt.give)
+			}
+			waitFor(t, "the file to be reported", func() bool {
+				return logs.Len() > 0
+			})
+			// Give the updater a few more checks to report the file again.
+			time.Sleep(20 * time.Millisecond)
+
+			entries := logs.TakeAll()
+			require.Len(t, entries, 1, "the same error must only be logged once")
+			require.Len(t, entries[0].Context, 2)
+			assert.Contains(t, entries[0].Context[1].Interface.(error).Error(), tt.wantLog)
+			assert.Equal(t, []string{"127.0.0.1:1"}, list.Peers(),
+				"peers must be kept while the file is invalid")
+
+			writeFile(t, path, `["127.0.0.1:2"]`)
+			waitFor(t, "peers to change after recovering", func() bool {
+				return assert.ObjectsAreEqual([]string{"127.0.0.1:2"}, list.Peers())
+			})
+		})
+	}
+}
+
+func TestUpdaterStartsWithoutFile(t *testing.T) {
+	path, cleanup := tempFile(t)
+	defer cleanup()
+
+	list := newFakeList()
+	updater := NewUpdater(list, path, Interval(time.Millisecond))
+	require.NoError(t, updater.Start(), "start must tolerate missing files")
+	assert.Empty(t, list.Peers())
+
+	writeFile(t, path, `["127.0.0.1:1"]`)
+	waitFor(t, "peers to be added", func() bool {
+		return assert.ObjectsAreEqual([]string{"127.0.0.1:1"}, list.Peers())
+	})
+	require.NoError(t, updater.Stop())
+	assert.Empty(t, list.Peers())
+}
+
+func TestUpdaterStartListError(t *testing.T) {
+	path, cleanup := tempFile(t)
+	defer cleanup()
+	writeFile(t, path, `["127.0.0.1:1"]`)
+
+	list := newFakeList()
+	list.err = errors.New("great sadness")
+	updater := NewUpdater(list, path)
+	assert.EqualError(t, updater.Start(), "great sadness")
+	assert.False(t, updater.IsRunning())
+}
+
+func TestUpdaterInvalidOptions(t *testing.T) {
+	assert.EqualError(t, NewUpdater(newFakeList(), "").Start(), "a file path is required")
+	assert.EqualError(t, NewUpdater(newFakeList(), "peers.json", Interval(0)).Start(),
+		"interval must be positive, got 0s")
+}
+
+func TestBind(t *testing.T) {
+	path, cleanup := tempFile(t)
+	defer cleanup()
+	writeFile(t, path, `["127.0.0.1:1"]`)
+
+	list := newFakeList()
+	lc := Bind(path)(list)
+	require.NoError(t, lc.Start())
+	assert.Equal(t, []string{"127.0.0.1:1"}, list.Peers())
+	require.NoError(t, lc.Stop())
+	assert.Empty(t, list.Peers())
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		desc    string
+		give    string
+		want    map[string]int
+		wantErr string
+	}{
+		{
+			desc: "JSON",
+			give: `["127.0.0.1:1", "[::1]:2", "example.com:3"]`,
+			want: map[string]int{"127.0.0.1:1": 0, "[::1]:2": 0, "example.com:3": 0},
+		},
+		{
+			desc: "YAML",
+			give: "- 127.0.0.1:1\n- 127.0.0.1:2\n",
+			want: map[string]int{"127.0.0.1:1": 0, "127.0.0.1:2": 0},
+		},
+		{
+			desc: "duplicates",
+			give: `["127.0.0.1:1", "127.0.0.1:1"]`,
+			want: map[string]int{"127.0.0.1:1": 0},
+		},
+		{
+			desc: "weights",
+			give: `["127.0.0.1:1", {"address": "127.0.0.1:2", "weight": 3}]`,
+			want: map[string]int{"127.0.0.1:1": 0, "127.0.0.1:2": 3},
+		},
+		{
+			desc: "YAML weights",
+			give: "- address: 127.0.0.1:1\n  weight: 2\n",
+			want: map[string]int{"127.0.0.1:1": 2},
+		},
+		{
+			desc:    "not a list",
+			give:    `{"peers": ["127.0.0.1:1"]}`,
+			wantErr: "failed to parse peers",
+		},
+		{
+			desc:    "empty",
+			give:    "",
+			wantErr: "no peers found",
+		},
+		{
+			desc:    "missing port",
+			give:    `["127.0.0.1:"]`,
+			wantErr: `invalid peer "127.0.0.1:": expected host:port`,
+		},
+		{
+			desc:    "missing host",
+			give:    `[":1"]`,
+			wantErr: `invalid peer ":1": expected host:port`,
+		},
+		{
+			desc:    "missing address",
+			give:    `[{"weight": 3}]`,
+			wantErr: `invalid peer ""`,
+		},
+		{
+			desc:    "invalid weight",
+			give:    `[{"address": "127.0.0.1:1", "weight": 0}]`,
+			wantErr: `invalid weight 0 for peer "127.0.0.1:1": weights must be positive`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			addrs, err := parse([]byte(tt.give))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, addrs)
+		})
+	}
+}
+
+func stringPtr(s string) *string { return &s }
@@ -0,0 +1,82 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package outlier
+
+import (
+	"fmt"
+	"time"
+)
+
+// Config configures outlier detection for a peer list.
+//
+//  outlierDetection:
+//    consecutiveFailures: 5
+//    failureRate: 0.5
+//    failureRateWindow: 100
+//    cooldown: 30s
+type Config struct {
+	// ConsecutiveFailures is the number of consecutive failures after which
+	// a peer is ejected. Defaults to 5.
+	ConsecutiveFailures int `config:"consecutiveFailures"`
+	// FailureRate is the fraction of failed requests among the last
+	// FailureRateWindow requests at which a peer is ejected.
+	FailureRate float64 `config:"failureRate"`
+	// FailureRateWindow is the number of recent requests considered for the
+	// failure rate. Defaults to 100 if FailureRate is set.
+	FailureRateWindow int `config:"failureRateWindow"`
+	// Cooldown is the duration for which a peer stays ejected before it is
+	// probed again. Defaults to 30s.
+	Cooldown time.Duration `config:"cooldown"`
+}
+
+// Options returns the Detector options for this configuration, or an error
+// if the configuration is invalid.
+func (c Config) Options() ([]Option, error) {
+	var opts []Option
+	if c.ConsecutiveFailures < 0 {
+		return nil, fmt.Errorf("outlier detection consecutiveFailures must not be negative, got %d", c.ConsecutiveFailures)
+	}
+	if c.ConsecutiveFailures > 0 {
+		opts = append(opts, ConsecutiveFailures(c.ConsecutiveFailures))
+	}
+
+	if c.FailureRate < 0 || c.FailureRate > 1 {
+		return nil, fmt.Errorf("outlier detection failureRate must be between 0 and 1, got %v", c.FailureRate)
+	}
+	if c.FailureRateWindow < 0 {
+		return nil, fmt.Errorf("outlier detection failureRateWindow must not be negative, got %d", c.FailureRateWindow)
+	}
+	if c.FailureRate > 0 {
+		window := c.FailureRateWindow
+		if window == 0 {
+			window = 100
+		}
+		opts = append(opts, FailureRate(c.FailureRate, window))
+	}
+
+	if c.Cooldown < 0 {
+		return nil, fmt.Errorf("outlier detection cooldown must not be negative, got %v", c.Cooldown)
+	}
+	if c.Cooldown > 0 {
+		opts = append(opts, Cooldown(c.Cooldown))
+	}
+	return opts, nil
+}
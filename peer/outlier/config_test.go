@@ -0,0 +1,103 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package outlier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/internal/config"
+	"go.uber.org/yarpc/internal/whitespace"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestConfig(t *testing.T) {
+	given := whitespace.Expand(`
+		consecutiveFailures: 3
+		failureRate: 0.5
+		cooldown: 10s
+	`)
+	var unstructured config.AttributeMap
+	err := yaml.Unmarshal([]byte(given), &unstructured)
+	require.NoError(t, err)
+
+	var cfg Config
+	err = unstructured.Decode(&cfg)
+	require.NoError(t, err)
+
+	opts, err := cfg.Options()
+	require.NoError(t, err)
+
+	d := New(nil, opts...)
+	assert.Equal(t, 3, d.opts.consecutiveFailures)
+	assert.Equal(t, 0.5, d.opts.failureRate)
+	assert.Equal(t, 100, d.opts.failureRateWindow)
+	assert.Equal(t, 10*time.Second, d.opts.cooldown)
+}
+
+func TestConfigDefaults(t *testing.T) {
+	opts, err := Config{}.Options()
+	require.NoError(t, err)
+
+	d := New(nil, opts...)
+	assert.Equal(t, _defaultConsecutiveFailures, d.opts.consecutiveFailures)
+	assert.Equal(t, 0.0, d.opts.failureRate)
+	assert.Equal(t, _defaultCooldown, d.opts.cooldown)
+}
+
+func TestConfigErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		give    Config
+		wantErr string
+	}{
+		{
+			desc:    "negative consecutive failures",
+			give:    Config{ConsecutiveFailures: -1},
+			wantErr: "consecutiveFailures must not be negative",
+		},
+		{
+			desc:    "failure rate too high",
+			give:    Config{FailureRate: 1.5},
+			wantErr: "failureRate must be between 0 and 1",
+		},
+		{
+			desc:    "negative window",
+			give:    Config{FailureRate: 0.5, FailureRateWindow: -1},
+			wantErr: "failureRateWindow must not be negative",
+		},
+		{
+			desc:    "negative cooldown",
+			give:    Config{Cooldown: -time.Second},
+			wantErr: "cooldown must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := tt.give.Options()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,363 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package outlier detects peers that fail requests and temporarily ejects
+// them from peer lists.
+//
+// Peer lists only know whether a peer accepts connections. A peer that
+// accepts connections but fails every request would otherwise keep receiving
+// its share of traffic. Peer lists which support outlier detection, like
+// roundrobin and peerheap, report the outcome of every request to a
+// Detector through the onFinish callback returned by Choose.
+//
+// A peer is ejected after a number of consecutive failures, or if the
+// fraction of failed requests among its recent requests crosses a threshold.
+// Ejected peers are not chosen unless every peer is ejected. After a
+// cooldown, an ejected peer is half-open: Admit lets a single probe request
+// through, and the outcome of that request either restores the peer or ejects
+// it for another cooldown. Until then, the peer gets no other requests.
+//
+// Requests count as failures if they fail with an error that is not a YARPC
+// error, or with one of the Unknown, Internal, Unavailable,
+// DeadlineExceeded or DataLoss codes. Other errors, like InvalidArgument, are
+// caused by the caller and do not reflect on the health of the peer.
+package outlier
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	_defaultConsecutiveFailures = 5
+	_defaultCooldown            = 30 * time.Second
+)
+
+type options struct {
+	consecutiveFailures int
+	failureRate         float64
+	failureRateWindow   int
+	cooldown            time.Duration
+	clock               clock.Clock
+}
+
+// Option customizes the behavior of a Detector.
+type Option func(*options)
+
+// ConsecutiveFailures ejects peers after the given number of consecutive
+// failed requests. Zero disables ejection based on consecutive failures.
+//
+// Defaults to 5.
+func ConsecutiveFailures(n int) Option {
+	return func(o *options) {
+		o.consecutiveFailures = n
+	}
+}
+
+// FailureRate ejects peers if at least the given fraction, between 0 and 1,
+// of their last window requests failed.
+//
+// Ejection based on the failure rate is disabled by default.
+func FailureRate(rate float64, window int) Option {
+	return func(o *options) {
+		o.failureRate = rate
+		o.failureRateWindow = window
+	}
+}
+
+// Cooldown is the duration for which a peer stays ejected before it is
+// probed again.
+//
+// Defaults to 30 seconds.
+func Cooldown(d time.Duration) Option {
+	return func(o *options) {
+		o.cooldown = d
+	}
+}
+
+// Detector tracks the outcome of requests to the peers of a peer list and
+// decides which peers are ejected.
+//
+// Detector is safe for concurrent use.
+type Detector struct {
+	opts     options
+	onChange func(id string)
+
+	mu    sync.Mutex
+	peers map[string]*peerState
+}
+
+// state is the circuit state of a peer.
+type state int
+
+const (
+	// closed peers are healthy.
+	closed state = iota
+	// open peers are ejected.
+	open
+	// halfOpen peers take a single probe request after a cooldown, until
+	// the outcome of the probe is known.
+	halfOpen
+)
+
+type peerState struct {
+	state state
+	timer clock.Timer
+
+	// probing is whether the probe request of a half-open peer is in flight.
+	probing bool
+
+	consecutiveFailures int
+
+	// outcomes is a ring of the outcomes of the last requests, true for
+	// failures.
+	outcomes []bool
+	next     int
+	count    int
+	failures int
+}
+
+// New builds a Detector. The onChange function, which may be nil, is called
+// whenever a peer is ejected, becomes half-open, or is restored by a probe.
+// It is called without holding any locks of the Detector.
+func New(onChange func(id string), opts ...Option) *Detector {
+	o := options{
+		consecutiveFailures: _defaultConsecutiveFailures,
+		cooldown:            _defaultCooldown,
+		clock:               clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if onChange == nil {
+		onChange = func(string) {}
+	}
+	return &Detector{
+		opts:     o,
+		onChange: onChange,
+		peers:    make(map[string]*peerState),
+	}
+}
+
+// Add starts tracking the peer with the given identifier.
+func (d *Detector) Add(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.peers[id]; ok {
+		return
+	}
+	ps := &peerState{}
+	if d.opts.failureRate > 0 && d.opts.failureRateWindow > 0 {
+		ps.outcomes = make([]bool, d.opts.failureRateWindow)
+	}
+	d.peers[id] = ps
+}
+
+// Remove stops tracking the peer with the given identifier.
+func (d *Detector) Remove(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if ps, ok := d.peers[id]; ok {
+		if ps.timer != nil {
+			ps.timer.Stop()
+		}
+		delete(d.peers, id)
+	}
+}
+
+// Ejected reports whether the peer with the given identifier is ejected, or
+// half-open with its probe request in flight.
+func (d *Detector) Ejected(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ps, ok := d.peers[id]
+	return ok && (ps.state == open || ps.probing)
+}
+
+// Admit reports whether a request may be sent to the peer with the given
+// identifier. Healthy peers admit every request and ejected peers none.
+// Half-open peers admit a single probe request: Admit returns true once, and
+// Ejected returns true, until the outcome of the probe is reported.
+//
+// Peer lists call Admit on the peer they are about to choose.
+func (d *Detector) Admit(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ps, ok := d.peers[id]
+	if !ok {
+		return true
+	}
+	switch ps.state {
+	case open:
+		return false
+	case halfOpen:
+		if ps.probing {
+			return false
+		}
+		ps.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Status describes the outlier state of the peer with the given identifier
+// for introspection: "ejected", "probing", or an empty string for healthy
+// peers.
+func (d *Detector) Status(id string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ps, ok := d.peers[id]
+	if !ok {
+		return ""
+	}
+	switch ps.state {
+	case open:
+		return "ejected"
+	case halfOpen:
+		return "probing"
+	default:
+		return ""
+	}
+}
+
+// Report records the outcome of a request to the peer with the given
+// identifier.
+func (d *Detector) Report(id string, err error) {
+	failed := IsFailure(err)
+
+	d.mu.Lock()
+	ps, ok := d.peers[id]
+	if !ok || ps.state == open {
+		// Requests started before the peer was ejected do not count.
+		d.mu.Unlock()
+		return
+	}
+
+	eject, restore := false, false
+	switch ps.state {
+	case halfOpen:
+		if !ps.probing {
+			// Requests started before the peer became half-open do not
+			// count; only the probe decides.
+			d.mu.Unlock()
+			return
+		}
+		ps.probing = false
+		if failed {
+			eject = true
+		} else {
+			ps.state = closed
+			restore = true
+		}
+	default:
+		eject = d.record(ps, failed)
+	}
+	if eject {
+		d.eject(id, ps)
+	}
+	d.mu.Unlock()
+
+	if eject || restore {
+		d.onChange(id)
+	}
+}
+
+// record adds an outcome to the statistics of a healthy peer, reporting
+// whether it should be ejected.
+//
+// Must be called with the lock held.
+func (d *Detector) record(ps *peerState, failed bool) bool {
+	if failed {
+		ps.consecutiveFailures++
+	} else {
+		ps.consecutiveFailures = 0
+	}
+	if d.opts.consecutiveFailures > 0 && ps.consecutiveFailures >= d.opts.consecutiveFailures {
+		return true
+	}
+
+	if ps.outcomes == nil {
+		return false
+	}
+	if ps.count == len(ps.outcomes) {
+		if ps.outcomes[ps.next] {
+			ps.failures--
+		}
+	} else {
+		ps.count++
+	}
+	ps.outcomes[ps.next] = failed
+	if failed {
+		ps.failures++
+	}
+	ps.next = (ps.next + 1) % len(ps.outcomes)
+
+	return ps.count == len(ps.outcomes) &&
+		float64(ps.failures) >= d.opts.failureRate*float64(ps.count)
+}
+
+// eject opens the circuit of the peer for a cooldown.
+//
+// Must be called with the lock held.
+func (d *Detector) eject(id string, ps *peerState) {
+	ps.state = open
+	ps.probing = false
+	ps.consecutiveFailures = 0
+	ps.next, ps.count, ps.failures = 0, 0, 0
+	ps.timer = d.opts.clock.AfterFunc(d.opts.cooldown, func() {
+		d.probe(id, ps)
+	})
+}
+
+// probe makes an ejected peer half-open after its cooldown.
+func (d *Detector) probe(id string, ps *peerState) {
+	d.mu.Lock()
+	if d.peers[id] != ps || ps.state != open {
+		d.mu.Unlock()
+		return
+	}
+	ps.state = halfOpen
+	ps.timer = nil
+	d.mu.Unlock()
+
+	d.onChange(id)
+}
+
+// IsFailure reports whether a request that finished with the given error
+// reflects a failure of the peer.
+func IsFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if !yarpcerrors.IsStatus(err) {
+		return true
+	}
+	switch yarpcerrors.FromError(err).Code() {
+	case yarpcerrors.CodeUnknown, yarpcerrors.CodeInternal, yarpcerrors.CodeUnavailable,
+		yarpcerrors.CodeDeadlineExceeded, yarpcerrors.CodeDataLoss:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package outlier
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var errInternal = yarpcerrors.InternalErrorf("internal")
+
+func withClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// newTestDetector builds a detector which reports changes on the returned
+// channel.
+func newTestDetector(opts ...Option) (*Detector, <-chan string) {
+	changes := make(chan string, 10)
+	d := New(func(id string) { changes <- id }, opts...)
+	return d, changes
+}
+
+func waitForChange(t *testing.T, changes <-chan string, want string) {
+	select {
+	case id := <-changes:
+		assert.Equal(t, want, id, "unexpected peer changed")
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for peer %q to change", want)
+	}
+}
+
+func TestConsecutiveFailures(t *testing.T) {
+	d, changes := newTestDetector(ConsecutiveFailures(3))
+	d.Add("foo")
+
+	d.Report("foo", errInternal)
+	d.Report("foo", errInternal)
+	d.Report("foo", nil)
+	d.Report("foo", errInternal)
+	d.Report("foo", errInternal)
+	assert.False(t, d.Ejected("foo"), "successes must reset consecutive failures")
+
+	d.Report("foo", errInternal)
+	assert.True(t, d.Ejected("foo"))
+	assert.Equal(t, "ejected", d.Status("foo"))
+	waitForChange(t, changes, "foo")
+}
+
+func TestFailureRate(t *testing.T) {
+	d, changes := newTestDetector(ConsecutiveFailures(0), FailureRate(0.5, 4))
+	d.Add("foo")
+
+	d.Report("foo", errInternal)
+	d.Report("foo", errInternal)
+	d.Report("foo", nil)
+	assert.False(t, d.Ejected("foo"), "must not eject before the window is full")
+
+	d.Report("foo", nil)
+	assert.True(t, d.Ejected("foo"), "2 of 4 failed")
+	waitForChange(t, changes, "foo")
+
+	d.Add("bar")
+	for _, err := range []error{errInternal, nil, nil, nil, errInternal} {
+		d.Report("bar", err)
+	}
+	assert.False(t, d.Ejected("bar"), "1 of the last 4 failed")
+
+	d.Report("bar", errInternal)
+	assert.True(t, d.Ejected("bar"), "2 of the last 4 failed")
+	waitForChange(t, changes, "bar")
+}
+
+func TestCallerErrorsDoNotEject(t *testing.T) {
+	d := New(nil, ConsecutiveFailures(1))
+	d.Add("foo")
+
+	d.Report("foo", yarpcerrors.InvalidArgumentErrorf("bad request"))
+	d.Report("foo", yarpcerrors.NotFoundErrorf("not found"))
+	assert.False(t, d.Ejected("foo"))
+}
+
+func TestCooldown(t *testing.T) {
+	fake := clock.NewFake()
+	d, changes := newTestDetector(ConsecutiveFailures(1), Cooldown(time.Minute), withClock(fake))
+	d.Add("foo")
+
+	d.Report("foo", errInternal)
+	require.True(t, d.Ejected("foo"))
+	waitForChange(t, changes, "foo")
+
+	d.Report("foo", nil)
+	assert.True(t, d.Ejected("foo"), "requests started before ejection must not count")
+
+	fake.Add(time.Minute)
+	waitForChange(t, changes, "foo")
+	assert.False(t, d.Ejected("foo"))
+	assert.Equal(t, "probing", d.Status("foo"))
+
+	// A failed probe ejects the peer again.
+	require.True(t, d.Admit("foo"), "half-open peers must admit a probe")
+	d.Report("foo", errInternal)
+	assert.True(t, d.Ejected("foo"))
+	waitForChange(t, changes, "foo")
+
+	fake.Add(time.Minute)
+	waitForChange(t, changes, "foo")
+
+	// A successful probe restores the peer.
+	require.True(t, d.Admit("foo"), "half-open peers must admit a probe")
+	d.Report("foo", nil)
+	waitForChange(t, changes, "foo")
+	assert.False(t, d.Ejected("foo"))
+	assert.Equal(t, "", d.Status("foo"))
+}
+
+func TestSingleProbe(t *testing.T) {
+	fake := clock.NewFake()
+	d, changes := newTestDetector(ConsecutiveFailures(1), Cooldown(time.Minute), withClock(fake))
+	d.Add("foo")
+
+	assert.True(t, d.Admit("foo"), "healthy peers must admit requests")
+	d.Report("foo", errInternal)
+	waitForChange(t, changes, "foo")
+	assert.False(t, d.Admit("foo"), "ejected peers must not admit requests")
+
+	fake.Add(time.Minute)
+	waitForChange(t, changes, "foo")
+
+	d.Report("foo", nil)
+	assert.Equal(t, "probing", d.Status("foo"), "requests other than the probe must not count")
+
+	require.True(t, d.Admit("foo"), "half-open peers must admit a probe")
+	assert.True(t, d.Ejected("foo"), "peers must be ejected while their probe is in flight")
+	assert.False(t, d.Admit("foo"), "half-open peers must admit a single probe")
+
+	d.Report("foo", nil)
+	waitForChange(t, changes, "foo")
+	assert.False(t, d.Ejected("foo"))
+	assert.True(t, d.Admit("foo"), "restored peers must admit requests")
+	assert.True(t, d.Admit("foo"), "restored peers must admit requests")
+}
+
+func TestRemove(t *testing.T) {
+	fake := clock.NewFake()
+	d, changes := newTestDetector(ConsecutiveFailures(1), Cooldown(time.Minute), withClock(fake))
+	d.Add("foo")
+
+	d.Report("foo", errInternal)
+	waitForChange(t, changes, "foo")
+	d.Remove("foo")
+	assert.False(t, d.Ejected("foo"))
+	assert.Equal(t, "", d.Status("foo"))
+
+	fake.Add(time.Minute)
+	select {
+	case id := <-changes:
+		t.Fatalf("unexpected change for removed peer %q", id)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	d.Report("foo", errInternal)
+	assert.False(t, d.Ejected("foo"), "reports for unknown peers must be ignored")
+}
+
+func TestIsFailure(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: nil, want: false},
+		{err: errors.New("connection refused"), want: true},
+		{err: yarpcerrors.UnknownErrorf("unknown"), want: true},
+		{err: yarpcerrors.InternalErrorf("internal"), want: true},
+		{err: yarpcerrors.UnavailableErrorf("unavailable"), want: true},
+		{err: yarpcerrors.DeadlineExceededErrorf("deadline exceeded"), want: true},
+		{err: yarpcerrors.DataLossErrorf("data loss"), want: true},
+		{err: yarpcerrors.InvalidArgumentErrorf("invalid argument"), want: false},
+		{err: yarpcerrors.ResourceExhaustedErrorf("resource exhausted"), want: false},
+		{err: yarpcerrors.CancelledErrorf("cancelled"), want: false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, IsFailure(tt.err), "IsFailure(%v)", tt.err)
+	}
+}
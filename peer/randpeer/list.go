@@ -0,0 +1,121 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package randpeer
+
+import (
+	"context"
+	"math/rand"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/peerlist"
+)
+
+type listConfig struct {
+	capacity int
+	source   rand.Source
+}
+
+var defaultListConfig = listConfig{
+	capacity: 10,
+}
+
+// ListOption customizes the behavior of a random list.
+type ListOption func(*listConfig)
+
+// Capacity specifies the default capacity of the underlying
+// data structures for this list
+//
+// Defaults to 10.
+func Capacity(capacity int) ListOption {
+	return func(c *listConfig) {
+		c.capacity = capacity
+	}
+}
+
+// Source specifies the source of randomness used to choose peers, which
+// makes choices deterministic in tests. The source only seeds the generator
+// of the list, which is safe for concurrent use without locking.
+//
+// Defaults to a seed from the current time.
+func Source(source rand.Source) ListOption {
+	return func(c *listConfig) {
+		c.source = source
+	}
+}
+
+// New creates a new random peer list.
+func New(transport peer.Transport, opts ...ListOption) *List {
+	cfg := defaultListConfig
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	return &List{
+		List: peerlist.New("random", transport, &randomList{
+			peers:  make([]peer.Peer, 0, cfg.capacity),
+			index:  make(map[string]int, cfg.capacity),
+			random: peerlist.NewRandom(cfg.source),
+		}, cfg.capacity),
+	}
+}
+
+// List is a PeerList which chooses an available peer uniformly at random for
+// each request.
+type List struct {
+	*peerlist.List
+}
+
+// randomList holds the available peers of a List.
+type randomList struct {
+	peers []peer.Peer
+	index map[string]int
+
+	random *peerlist.Random
+}
+
+func (r *randomList) Add(p peer.Peer) {
+	r.index[p.Identifier()] = len(r.peers)
+	r.peers = append(r.peers, p)
+}
+
+func (r *randomList) Remove(p peer.Peer) {
+	i, ok := r.index[p.Identifier()]
+	if !ok {
+		return
+	}
+
+	// Move the last peer into the hole.
+	last := len(r.peers) - 1
+	r.peers[i] = r.peers[last]
+	r.index[r.peers[i].Identifier()] = i
+	r.peers[last] = nil
+	r.peers = r.peers[:last]
+	delete(r.index, p.Identifier())
+}
+
+func (r *randomList) Choose(context.Context, *transport.Request) peer.Peer {
+	if len(r.peers) == 0 {
+		return nil
+	}
+
+	return r.peers[r.random.Intn(len(r.peers))]
+}
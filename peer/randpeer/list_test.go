@@ -0,0 +1,137 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package randpeer
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	. "go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcconfig"
+)
+
+type fakeTransport map[string]*LightMockPeer
+
+func (t fakeTransport) RetainPeer(pid peer.Identifier, _ peer.Subscriber) (peer.Peer, error) {
+	p := NewLightMockPeer(MockPeerIdentifier(pid.Identifier()), peer.Available)
+	t[pid.Identifier()] = p
+	return p, nil
+}
+
+func (t fakeTransport) ReleasePeer(peer.Identifier, peer.Subscriber) error {
+	return nil
+}
+
+func chooseIDs(t *testing.T, pl *List, n int) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ids := make([]string, n)
+	for i := range ids {
+		p, onFinish, err := pl.Choose(ctx, &transport.Request{})
+		require.NoError(t, err)
+		onFinish(nil)
+		ids[i] = p.Identifier()
+	}
+	return ids
+}
+
+func newStartedList(t *testing.T, ids []string, opts ...ListOption) (*List, fakeTransport) {
+	trans := make(fakeTransport)
+	pl := New(trans, opts...)
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: CreatePeerIDs(ids)}))
+	require.NoError(t, pl.Start())
+	return pl, trans
+}
+
+func TestRandom(t *testing.T) {
+	ids := []string{"1", "2", "3", "4"}
+	pl, _ := newStartedList(t, ids, Source(rand.NewSource(1)))
+	defer pl.Stop()
+
+	counts := make(map[string]int)
+	for _, id := range chooseIDs(t, pl, 400) {
+		counts[id]++
+	}
+	require.Len(t, counts, 4, "every peer must be chosen")
+	for id, count := range counts {
+		assert.InDelta(t, 100, count, 40, "peer %q chosen too unevenly", id)
+	}
+}
+
+func TestRandomDeterministicSource(t *testing.T) {
+	ids := []string{"1", "2", "3", "4"}
+	first, _ := newStartedList(t, ids, Source(rand.NewSource(42)))
+	defer first.Stop()
+	second, _ := newStartedList(t, ids, Source(rand.NewSource(42)))
+	defer second.Stop()
+
+	assert.Equal(t, chooseIDs(t, first, 20), chooseIDs(t, second, 20))
+}
+
+func TestRandomSkipsUnavailablePeers(t *testing.T) {
+	pl, trans := newStartedList(t, []string{"1", "2", "3"}, Source(rand.NewSource(1)))
+	defer pl.Stop()
+
+	trans["2"].PeerStatus.ConnectionStatus = peer.Unavailable
+	pl.NotifyStatusChanged(MockPeerIdentifier("2"))
+	require.NoError(t, pl.Update(peer.ListUpdates{Removals: CreatePeerIDs([]string{"1"})}))
+
+	for _, id := range chooseIDs(t, pl, 50) {
+		assert.Equal(t, "3", id)
+	}
+}
+
+func TestRandomListRemove(t *testing.T) {
+	l := &randomList{index: make(map[string]int)}
+	peers := make(map[string]peer.Peer)
+	for _, id := range []string{"1", "2", "3", "4"} {
+		peers[id] = NewLightMockPeer(MockPeerIdentifier(id), peer.Available)
+		l.Add(peers[id])
+	}
+
+	l.Remove(peers["2"])
+	l.Remove(peers["4"])
+	l.Remove(NewLightMockPeer(MockPeerIdentifier("missing"), peer.Available))
+	require.Len(t, l.peers, 2)
+	for id, i := range l.index {
+		assert.Equal(t, id, l.peers[i].Identifier(), "index of %q is stale", id)
+	}
+	assert.Contains(t, l.index, "1")
+	assert.Contains(t, l.index, "3")
+}
+
+func TestSpec(t *testing.T) {
+	spec := Spec()
+	assert.Equal(t, "random", spec.Name)
+	build, ok := spec.BuildPeerList.(func(struct{}, peer.Transport, *yarpcconfig.Kit) (peer.ChooserList, error))
+	require.True(t, ok, "unexpected builder type %T", spec.BuildPeerList)
+
+	list, err := build(struct{}{}, make(fakeTransport), nil)
+	require.NoError(t, err)
+	assert.IsType(t, &List{}, list)
+}
@@ -21,10 +21,22 @@
 package roundrobin
 
 import (
+	"errors"
+	"time"
+
 	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/peer/outlier"
 	"go.uber.org/yarpc/yarpcconfig"
 )
 
+type listSpecConfig struct {
+	// OutlierDetection enables outlier detection if present.
+	OutlierDetection *outlier.Config `config:"outlierDetection"`
+
+	// SlowStart ramps peers up to their weight over this duration.
+	SlowStart time.Duration `config:"slowStart"`
+}
+
 // Spec returns a configuration specification for the round-robin peer list
 // implementation, making it possible to select the least recently chosen peer
 // with transports that use outbound peer list configuration (like HTTP).
@@ -43,11 +55,46 @@ import (
 //            peers:
 //              - 127.0.0.1:8080
 //              - 127.0.0.1:8081
+//
+// Peers which fail too many requests can be ejected from the list with the
+// outlierDetection option. See the outlier package for details.
+//
+//          round-robin:
+//            peers:
+//              - 127.0.0.1:8080
+//              - 127.0.0.1:8081
+//            outlierDetection:
+//              consecutiveFailures: 5
+//              cooldown: 30s
+//
+// Peers may have weights, and new peers can be ramped up to their weight
+// with the slowStart option.
+//
+//          round-robin:
+//            peers:
+//              - 127.0.0.1:8080
+//              - address: 127.0.0.1:8081
+//                weight: 3
+//            slowStart: 1m
 func Spec() yarpcconfig.PeerListSpec {
 	return yarpcconfig.PeerListSpec{
 		Name: "round-robin",
-		BuildPeerList: func(c struct{}, t peer.Transport, k *yarpcconfig.Kit) (peer.ChooserList, error) {
-			return New(t), nil
+		BuildPeerList: func(c listSpecConfig, t peer.Transport, k *yarpcconfig.Kit) (peer.ChooserList, error) {
+			var opts []ListOption
+			if c.OutlierDetection != nil {
+				outlierOpts, err := c.OutlierDetection.Options()
+				if err != nil {
+					return nil, err
+				}
+				opts = append(opts, OutlierDetection(outlierOpts...))
+			}
+			if c.SlowStart < 0 {
+				return nil, errors.New("slowStart must not be negative")
+			}
+			if c.SlowStart > 0 {
+				opts = append(opts, SlowStart(c.SlowStart))
+			}
+			return New(t, opts...), nil
 		},
 	}
 }
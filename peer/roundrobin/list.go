@@ -24,12 +24,17 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"go.uber.org/atomic"
 	"go.uber.org/multierr"
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
 	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/internal/triedpeers"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/outlier"
 	"go.uber.org/yarpc/pkg/lifecycle"
 	"go.uber.org/yarpc/yarpcerrors"
 )
@@ -40,10 +45,17 @@ var (
 
 type listConfig struct {
 	capacity int
+
+	outlierDetection bool
+	outlierOptions   []outlier.Option
+
+	slowStart time.Duration
+	clock     clock.Clock
 }
 
 var defaultListConfig = listConfig{
 	capacity: 10,
+	clock:    clock.NewReal(),
 }
 
 // ListOption customizes the behavior of a roundrobin list.
@@ -59,6 +71,32 @@ func Capacity(capacity int) ListOption {
 	}
 }
 
+// OutlierDetection enables outlier detection for the list. Peers which fail
+// too many requests are ejected and skipped by Choose until they recover,
+// unless every available peer is ejected.
+//
+// See the outlier package for details.
+func OutlierDetection(opts ...outlier.Option) ListOption {
+	return func(c *listConfig) {
+		c.outlierDetection = true
+		c.outlierOptions = opts
+	}
+}
+
+// SlowStart ramps peers which become available up to their weight over the
+// given duration, starting at a tenth of their weight, so that new or
+// reconnected hosts are not flooded with requests.
+//
+// Peers are chosen with smooth weighted round-robin when slow start is
+// enabled or peers have weights other than peer.DefaultWeight.
+//
+// Defaults to 0, which disables slow start.
+func SlowStart(d time.Duration) ListOption {
+	return func(c *listConfig) {
+		c.slowStart = d
+	}
+}
+
 // New creates a new round robin PeerList
 func New(transport peer.Transport, opts ...ListOption) *List {
 	cfg := defaultListConfig
@@ -66,14 +104,22 @@ func New(transport peer.Transport, opts ...ListOption) *List {
 		o(&cfg)
 	}
 
-	return &List{
+	pl := &List{
 		once:               lifecycle.NewOnce(),
 		uninitializedPeers: make(map[string]peer.Identifier, cfg.capacity),
 		unavailablePeers:   make(map[string]peer.Peer, cfg.capacity),
-		availablePeerRing:  newPeerRing(cfg.capacity),
+		availablePeerRing:  newPeerRing(cfg.capacity, cfg.slowStart),
+		weights:            make(map[string]int),
 		transport:          transport,
 		peerAvailableEvent: make(chan struct{}, 1),
+		clock:              cfg.clock,
 	}
+	if cfg.outlierDetection {
+		// Ejections are checked when choosing peers, so the list does not
+		// need to be notified of changes.
+		pl.outliers = outlier.New(nil, cfg.outlierOptions...)
+	}
+	return pl
 }
 
 // List is a PeerList which rotates which peers are to be selected in a circle
@@ -88,24 +134,94 @@ type List struct {
 	peerAvailableEvent chan struct{}
 	transport          peer.Transport
 
+	// weights holds the weights of peers in the list which don't have the
+	// default weight.
+	weights map[string]int
+	clock   clock.Clock
+
+	// outliers is nil unless outlier detection is enabled.
+	outliers *outlier.Detector
+
 	once *lifecycle.Once
 }
 
 // Update applies the additions and removals of peer Identifiers to the list
 // it returns a multi-error result of every failure that happened without
 // circuit breaking due to failures.
+//
+// Peers with invalid weights are added with the default weight.
 func (pl *List) Update(updates peer.ListUpdates) error {
-	if len(updates.Additions) == 0 && len(updates.Removals) == 0 {
+	if len(updates.Additions) == 0 && len(updates.Removals) == 0 && len(updates.Weights) == 0 {
 		return nil
 	}
 
 	pl.lock.Lock()
 	defer pl.lock.Unlock()
 
+	errs := pl.updateWeights(updates)
 	if pl.shouldRetainPeers.Load() {
-		return pl.updateInitialized(updates)
+		return multierr.Append(errs, pl.updateInitialized(updates))
+	}
+	return multierr.Append(errs, pl.updateUninitialized(updates))
+}
+
+// updateWeights forgets the weights of removed peers, records the weights of
+// added peers and changes the weights of peers already in the list.
+//
+// Must be run inside a mutex.Lock()
+func (pl *List) updateWeights(updates peer.ListUpdates) error {
+	for _, pid := range updates.Removals {
+		delete(pl.weights, pid.Identifier())
+	}
+
+	added := make(map[string]struct{}, len(updates.Additions))
+	for _, pid := range updates.Additions {
+		added[pid.Identifier()] = struct{}{}
+	}
+
+	var errs error
+	for id, weight := range updates.Weights {
+		if weight <= 0 {
+			errs = multierr.Append(errs, peer.ErrInvalidPeerWeight{PeerIdentifier: id, Weight: weight})
+			weight = peer.DefaultWeight
+		}
+
+		if pl.contains(id) {
+			pl.availablePeerRing.SetWeight(hostport.PeerIdentifier(id), weight)
+		} else if _, ok := added[id]; !ok {
+			continue
+		}
+
+		if weight == peer.DefaultWeight {
+			delete(pl.weights, id)
+		} else {
+			pl.weights[id] = weight
+		}
 	}
-	return pl.updateUninitialized(updates)
+	return errs
+}
+
+// contains returns whether the peer with the given identifier is in the list.
+//
+// Must be run inside a mutex.Lock()
+func (pl *List) contains(id string) bool {
+	if _, ok := pl.uninitializedPeers[id]; ok {
+		return true
+	}
+	if _, ok := pl.unavailablePeers[id]; ok {
+		return true
+	}
+	return pl.availablePeerRing.GetPeer(hostport.PeerIdentifier(id)) != nil
+}
+
+// weight returns the weight of the peer with the given identifier.
+//
+// Must be run inside a mutex.Lock()
+func (pl *List) weight(id string) int {
+	if w, ok := pl.weights[id]; ok {
+		return w
+	}
+	return peer.DefaultWeight
 }
 
 // updateInitialized applies peer list updates when the peer list
@@ -150,9 +266,13 @@ func (pl *List) updateUninitialized(updates peer.ListUpdates) error {
 func (pl *List) addPeerIdentifier(pid peer.Identifier) error {
 	p, err := pl.transport.RetainPeer(pid, pl)
 	if err != nil {
+		delete(pl.weights, pid.Identifier())
 		return err
 	}
 
+	if pl.outliers != nil {
+		pl.outliers.Add(p.Identifier())
+	}
 	return pl.addPeer(p)
 }
 
@@ -173,7 +293,7 @@ func (pl *List) addToUnavailablePeers(p peer.Peer) error {
 
 // Must be run in a mutex.Lock()
 func (pl *List) addToAvailablePeers(p peer.Peer) error {
-	if err := pl.availablePeerRing.Add(p); err != nil {
+	if err := pl.availablePeerRing.Add(p, pl.weight(p.Identifier()), pl.clock.Now()); err != nil {
 		return err
 	}
 
@@ -249,6 +369,9 @@ func (pl *List) removeAllUnavailable() []peer.Peer {
 func (pl *List) releaseAll(peers []peer.Peer) []error {
 	var errs []error
 	for _, p := range peers {
+		if pl.outliers != nil {
+			pl.outliers.Remove(p.Identifier())
+		}
 		if err := pl.transport.ReleasePeer(p, pl); err != nil {
 			errs = append(errs, err)
 		}
@@ -265,6 +388,9 @@ func (pl *List) removePeerIdentifier(pid peer.Identifier) error {
 		return err
 	}
 
+	if pl.outliers != nil {
+		pl.outliers.Remove(pid.Identifier())
+	}
 	return pl.transport.ReleasePeer(pid, pl)
 }
 
@@ -297,9 +423,11 @@ func (pl *List) Choose(ctx context.Context, req *transport.Request) (peer.Peer,
 		return nil, nil, newNotRunningError(err)
 	}
 
+	tried := triedpeers.FromContext(ctx)
 	for {
-		if nextPeer := pl.nextPeer(); nextPeer != nil {
+		if nextPeer := pl.nextPeer(tried); nextPeer != nil {
 			pl.notifyPeerAvailable()
+			tried.Add(nextPeer.Identifier())
 			nextPeer.StartRequest()
 			return nextPeer, pl.getOnFinishFunc(nextPeer), nil
 		}
@@ -321,11 +449,30 @@ func (pl *List) IsRunning() bool {
 
 // nextPeer grabs the next available peer from the PeerRing and returns it,
 // if there are no available peers it returns nil
-func (pl *List) nextPeer() peer.Peer {
+//
+// Ejected peers, half-open peers with a probe in flight, and peers already
+// tried for the request are skipped, unless all available peers are.
+func (pl *List) nextPeer(tried *triedpeers.Set) peer.Peer {
 	pl.lock.Lock()
-	p := pl.availablePeerRing.Next()
-	pl.lock.Unlock()
-	return p
+	defer pl.lock.Unlock()
+
+	now := pl.clock.Now()
+	if pl.outliers == nil && tried == nil {
+		return pl.availablePeerRing.Next(now)
+	}
+
+	for i := pl.availablePeerRing.Len(); i > 0; i-- {
+		p := pl.availablePeerRing.Next(now)
+		if tried.Contains(p.Identifier()) {
+			continue
+		}
+		if pl.outliers == nil || pl.outliers.Admit(p.Identifier()) {
+			return p
+		}
+	}
+	// Every available peer is ejected or tried and the ring is back where it
+	// started, so fall back to plain round-robin.
+	return pl.availablePeerRing.Next(now)
 }
 
 // notifyPeerAvailable writes to a channel indicating that a Peer is currently
@@ -339,8 +486,14 @@ func (pl *List) notifyPeerAvailable() {
 
 // getOnFinishFunc creates a closure that will be run at the end of the request
 func (pl *List) getOnFinishFunc(p peer.Peer) func(error) {
-	return func(_ error) {
+	if pl.outliers == nil {
+		return func(_ error) {
+			p.EndRequest()
+		}
+	}
+	return func(err error) {
 		p.EndRequest()
+		pl.outliers.Report(p.Identifier(), err)
 	}
 }
 
@@ -427,6 +580,10 @@ func (pl *List) Introspect() introspection.ChooserStatus {
 	for _, peer := range pl.unavailablePeers {
 		unavailables = append(unavailables, peer)
 	}
+	weights := make(map[string]int, len(pl.weights))
+	for id, w := range pl.weights {
+		weights[id] = w
+	}
 	pl.lock.Unlock()
 
 	peersStatus := make([]introspection.PeerStatus, 0,
@@ -434,26 +591,45 @@ func (pl *List) Introspect() introspection.ChooserStatus {
 
 	buildPeerStatus := func(peer peer.Peer) introspection.PeerStatus {
 		ps := peer.Status()
+		state := fmt.Sprintf("%s, %d pending request(s)",
+			ps.ConnectionStatus.String(),
+			ps.PendingRequestCount)
+		if w, ok := weights[peer.Identifier()]; ok {
+			state += fmt.Sprintf(", weight %d", w)
+		}
+		if pl.outliers != nil {
+			if status := pl.outliers.Status(peer.Identifier()); status != "" {
+				state += ", " + status
+			}
+		}
 		return introspection.PeerStatus{
 			Identifier: peer.Identifier(),
-			State: fmt.Sprintf("%s, %d pending request(s)",
-				ps.ConnectionStatus.String(),
-				ps.PendingRequestCount),
+			State:      state,
 		}
 	}
 
+	ejected := 0
 	for _, peer := range availables {
 		peersStatus = append(peersStatus, buildPeerStatus(peer))
+		if pl.outliers != nil && pl.outliers.Ejected(peer.Identifier()) {
+			ejected++
+		}
 	}
 
 	for _, peer := range unavailables {
 		peersStatus = append(peersStatus, buildPeerStatus(peer))
 	}
 
+	state = fmt.Sprintf("%s (%d/%d available", state, len(availables),
+		len(availables)+len(unavailables))
+	if pl.outliers != nil {
+		state += fmt.Sprintf(", %d ejected", ejected)
+	}
+	state += ")"
+
 	return introspection.ChooserStatus{
-		Name: "Single",
-		State: fmt.Sprintf("%s (%d/%d available)", state, len(availables),
-			len(availables)+len(unavailables)),
+		Name:  "Single",
+		State: state,
 		Peers: peersStatus,
 	}
 }
@@ -23,6 +23,7 @@ package roundrobin
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -31,7 +32,11 @@ import (
 	"go.uber.org/multierr"
 	"go.uber.org/yarpc/api/peer"
 	. "go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/internal/clock"
 	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/internal/triedpeers"
+	"go.uber.org/yarpc/peer/outlier"
+	"go.uber.org/yarpc/yarpcerrors"
 )
 
 func TestRoundRobinList(t *testing.T) {
@@ -913,6 +918,277 @@ func TestIntrospect(t *testing.T) {
 	checkPeerStatus(t, peerIdentifierToPeerStatus, "baz", "Available, 2 pending request(s)")
 }
 
+func TestOutlierDetection(t *testing.T) {
+	pl := New(testTransport{}, OutlierDetection(outlier.ConsecutiveFailures(2), outlier.Cooldown(time.Hour)))
+	assert.NoError(t, pl.Start())
+	assert.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{
+			newTestPeer("foo", 0, peer.Available),
+			newTestPeer("bar", 0, peer.Available),
+		},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	choose := func() (string, func(error)) {
+		p, onFinish, err := pl.Choose(ctx, nil)
+		assert.NoError(t, err)
+		return p.Identifier(), onFinish
+	}
+
+	// Fail every request to foo until it is ejected.
+	for i := 0; i < 4; i++ {
+		id, onFinish := choose()
+		if id == "foo" {
+			onFinish(yarpcerrors.InternalErrorf("internal"))
+		} else {
+			onFinish(nil)
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		id, onFinish := choose()
+		assert.Equal(t, "bar", id, "ejected peer must not be chosen")
+		onFinish(nil)
+	}
+
+	chooserStatus := pl.Introspect()
+	assert.Equal(t, "Running (2/2 available, 1 ejected)", chooserStatus.State)
+	peerIdentifierToPeerStatus := make(map[string]introspection.PeerStatus, len(chooserStatus.Peers))
+	for _, peerStatus := range chooserStatus.Peers {
+		peerIdentifierToPeerStatus[peerStatus.Identifier] = peerStatus
+	}
+	checkPeerStatus(t, peerIdentifierToPeerStatus, "foo", "Available, 0 pending request(s), ejected")
+	checkPeerStatus(t, peerIdentifierToPeerStatus, "bar", "Available, 0 pending request(s)")
+
+	// Once every peer is ejected, peers are chosen regardless.
+	for i := 0; i < 2; i++ {
+		_, onFinish := choose()
+		onFinish(yarpcerrors.UnavailableErrorf("unavailable"))
+	}
+	assert.Equal(t, "Running (2/2 available, 2 ejected)", pl.Introspect().State)
+
+	chosen := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		id, onFinish := choose()
+		chosen[id] = true
+		onFinish(nil)
+	}
+	assert.Equal(t, map[string]bool{"foo": true, "bar": true}, chosen)
+}
+
+func TestOutlierSingleProbe(t *testing.T) {
+	pl := New(testTransport{}, OutlierDetection(outlier.ConsecutiveFailures(1), outlier.Cooldown(10*time.Millisecond)))
+	assert.NoError(t, pl.Start())
+	assert.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{
+			newTestPeer("foo", 0, peer.Available),
+			newTestPeer("bar", 0, peer.Available),
+		},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Eject foo and wait for it to become half-open.
+	for {
+		p, onFinish, err := pl.Choose(ctx, nil)
+		assert.NoError(t, err)
+		if p.Identifier() == "foo" {
+			onFinish(yarpcerrors.InternalErrorf("internal"))
+			break
+		}
+		onFinish(nil)
+	}
+	for !strings.Contains(introspectPeers(pl)["foo"].State, "probing") {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Only one request goes to foo until its probe finishes.
+	var probeFinish func(error)
+	for i := 0; i < 6; i++ {
+		p, onFinish, err := pl.Choose(ctx, nil)
+		assert.NoError(t, err)
+		if p.Identifier() != "foo" {
+			onFinish(nil)
+			continue
+		}
+		if assert.Nil(t, probeFinish, "half-open peer must take a single probe") {
+			probeFinish = onFinish
+		}
+	}
+	if assert.NotNil(t, probeFinish, "half-open peer must be probed") {
+		probeFinish(nil)
+	}
+
+	chosen := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		p, onFinish, err := pl.Choose(ctx, nil)
+		assert.NoError(t, err)
+		chosen[p.Identifier()]++
+		onFinish(nil)
+	}
+	assert.Equal(t, map[string]int{"foo": 2, "bar": 2}, chosen, "successful probe must restore the peer")
+}
+
+func withClock(c clock.Clock) ListOption {
+	return func(cfg *listConfig) {
+		cfg.clock = c
+	}
+}
+
+func TestWeightedRoundRobin(t *testing.T) {
+	pl := New(testTransport{})
+	assert.NoError(t, pl.Start())
+	assert.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{
+			newTestPeer("a", 0, peer.Available),
+			newTestPeer("b", 0, peer.Available),
+		},
+		Weights: map[string]int{"a": 3},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	choose := func(n int) []string {
+		ids := make([]string, 0, n)
+		for i := 0; i < n; i++ {
+			p, onFinish, err := pl.Choose(ctx, nil)
+			if !assert.NoError(t, err) {
+				return ids
+			}
+			ids = append(ids, p.Identifier())
+			onFinish(nil)
+		}
+		return ids
+	}
+
+	// The heavier peer is interleaved with the lighter one.
+	assert.Equal(t, []string{"a", "b", "a", "a", "a", "b", "a", "a"}, choose(8))
+	checkPeerStatus(t, introspectPeers(pl), "a", "Available, 0 pending request(s), weight 3")
+	checkPeerStatus(t, introspectPeers(pl), "b", "Available, 0 pending request(s)")
+
+	// Weights of peers in the list can change without adding them again.
+	assert.NoError(t, pl.Update(peer.ListUpdates{
+		Weights: map[string]int{"a": 1, "b": 2},
+	}))
+	assert.Equal(t, map[string]int{"a": 10, "b": 20}, countChoices(choose(30)))
+
+	// Weights of peers not in the list are ignored.
+	assert.NoError(t, pl.Update(peer.ListUpdates{
+		Weights: map[string]int{"c": 5},
+	}))
+	assert.Equal(t, map[string]int{"a": 10, "b": 20}, countChoices(choose(30)))
+
+	// Removed peers forget their weight.
+	assert.NoError(t, pl.Update(peer.ListUpdates{
+		Removals: []peer.Identifier{newTestPeer("b", 0, peer.Available)},
+	}))
+	assert.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{newTestPeer("b", 0, peer.Available)},
+	}))
+	assert.Equal(t, map[string]int{"a": 15, "b": 15}, countChoices(choose(30)))
+}
+
+func TestWeightsBeforeStart(t *testing.T) {
+	pl := New(testTransport{})
+	assert.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{
+			newTestPeer("a", 0, peer.Available),
+			newTestPeer("b", 0, peer.Available),
+		},
+		Weights: map[string]int{"a": 2},
+	}))
+	assert.NoError(t, pl.Update(peer.ListUpdates{
+		Weights: map[string]int{"b": 3},
+	}))
+	assert.NoError(t, pl.Start())
+
+	checkPeerStatus(t, introspectPeers(pl), "a", "Available, 0 pending request(s), weight 2")
+	checkPeerStatus(t, introspectPeers(pl), "b", "Available, 0 pending request(s), weight 3")
+}
+
+func TestInvalidWeight(t *testing.T) {
+	pl := New(testTransport{})
+	assert.NoError(t, pl.Start())
+
+	err := pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{newTestPeer("a", 0, peer.Available)},
+		Weights:   map[string]int{"a": -1},
+	})
+	assert.Equal(t, peer.ErrInvalidPeerWeight{PeerIdentifier: "a", Weight: -1}, err)
+
+	// The peer is added with the default weight.
+	checkPeerStatus(t, introspectPeers(pl), "a", "Available, 0 pending request(s)")
+}
+
+func TestSlowStart(t *testing.T) {
+	fake := clock.NewFake()
+	pl := New(testTransport{}, SlowStart(10*time.Second), withClock(fake))
+	assert.NoError(t, pl.Start())
+	assert.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{newTestPeer("a", 0, peer.Available)},
+	}))
+	fake.Add(10 * time.Second)
+
+	b := newTestPeer("b", 0, peer.Unavailable)
+	assert.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{b},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	chooseCounts := func(n int) map[string]int {
+		ids := make([]string, 0, n)
+		for i := 0; i < n; i++ {
+			p, onFinish, err := pl.Choose(ctx, nil)
+			if !assert.NoError(t, err) {
+				break
+			}
+			ids = append(ids, p.Identifier())
+			onFinish(nil)
+		}
+		return countChoices(ids)
+	}
+
+	// The slow start begins when the peer becomes available, with a tenth
+	// of its weight.
+	fake.Add(time.Minute)
+	b.connectionStatus = peer.Available
+	pl.NotifyStatusChanged(b)
+	counts := chooseCounts(110)
+	assert.InDelta(t, 10, counts["b"], 1, "new peer must receive a tenth of the traffic")
+
+	// Halfway through, the peer has half its weight.
+	fake.Add(5 * time.Second)
+	counts = chooseCounts(90)
+	assert.InDelta(t, 30, counts["b"], 1, "new peer must receive a third of the traffic")
+
+	// Afterwards, the peer has its full weight.
+	fake.Add(5 * time.Second)
+	assert.Equal(t, map[string]int{"a": 20, "b": 20}, chooseCounts(40))
+}
+
+func introspectPeers(pl *List) map[string]introspection.PeerStatus {
+	peers := pl.Introspect().Peers
+	statuses := make(map[string]introspection.PeerStatus, len(peers))
+	for _, peerStatus := range peers {
+		statuses[peerStatus.Identifier] = peerStatus
+	}
+	return statuses
+}
+
+func countChoices(ids []string) map[string]int {
+	counts := make(map[string]int)
+	for _, id := range ids {
+		counts[id]++
+	}
+	return counts
+}
+
 func checkPeerStatus(
 	t *testing.T,
 	peerIdentifierToPeerStatus map[string]introspection.PeerStatus,
@@ -966,3 +1242,32 @@ func (p *testPeer) Status() peer.Status {
 func (*testPeer) StartRequest() {}
 
 func (*testPeer) EndRequest() {}
+
+func TestTriedPeersSkipped(t *testing.T) {
+	pl := New(testTransport{})
+	assert.NoError(t, pl.Start())
+	assert.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{
+			newTestPeer("a", 0, peer.Available),
+			newTestPeer("b", 0, peer.Available),
+		},
+		Weights: map[string]int{"a": 3},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	tried := &triedpeers.Set{}
+	tried.Add("a")
+	ctx = triedpeers.NewContext(ctx, tried)
+
+	p, onFinish, err := pl.Choose(ctx, nil)
+	assert.NoError(t, err)
+	onFinish(nil)
+	assert.Equal(t, "b", p.Identifier(), "tried peers must be skipped")
+	assert.True(t, tried.Contains("b"), "chosen peers must be recorded as tried")
+
+	p, onFinish, err = pl.Choose(ctx, nil)
+	assert.NoError(t, err)
+	onFinish(nil)
+	assert.Equal(t, "a", p.Identifier(), "must fall back to round-robin once every peer was tried")
+}
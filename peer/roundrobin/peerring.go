@@ -22,14 +22,18 @@ package roundrobin
 
 import (
 	"container/ring"
+	"time"
 
 	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/internal/peerweight"
 )
 
-// newPeerRing creates a new peerRing with an initial capacity
-func newPeerRing(capacity int) *peerRing {
+// newPeerRing creates a new peerRing with an initial capacity, ramping new
+// peers up to their weight over the given slow start window.
+func newPeerRing(capacity int, slowStart time.Duration) *peerRing {
 	return &peerRing{
 		peerToNode: make(map[string]*ring.Ring, capacity),
+		slowStart:  slowStart,
 	}
 }
 
@@ -39,6 +43,22 @@ func newPeerRing(capacity int) *peerRing {
 type peerRing struct {
 	peerToNode map[string]*ring.Ring
 	nextNode   *ring.Ring
+
+	// Peers are chosen in turn unless some peer has a weight other than the
+	// default, or new peers are slowly started, in which case they are chosen
+	// with smooth weighted round-robin.
+	slowStart time.Duration
+	weighted  int // number of peers without the default weight
+}
+
+// ringEntry is the value of ring nodes.
+type ringEntry struct {
+	peer   peer.Peer
+	weight int
+	added  time.Time
+
+	// current is the smooth weighted round-robin counter of the peer.
+	current float64
 }
 
 // GetPeer returns the Peer from the Ring or Nil
@@ -53,14 +73,17 @@ func (pr *peerRing) GetPeer(pid peer.Identifier) peer.Peer {
 
 // Add a peer.Peer to the end of the peerRing, if the ring is empty
 // it initializes the nextNode marker
-func (pr *peerRing) Add(p peer.Peer) error {
+func (pr *peerRing) Add(p peer.Peer, weight int, now time.Time) error {
 	if _, ok := pr.peerToNode[p.Identifier()]; ok {
 		// Peer Already in ring, ignore the add
 		return peer.ErrPeerAddAlreadyInList(p.Identifier())
 	}
 
-	newNode := newPeerRingNode(p)
+	newNode := newPeerRingNode(p, weight, now)
 	pr.peerToNode[p.Identifier()] = newNode
+	if weight != peer.DefaultWeight {
+		pr.weighted++
+	}
 
 	if pr.nextNode == nil {
 		// Empty ring, add the first node
@@ -72,12 +95,29 @@ func (pr *peerRing) Add(p peer.Peer) error {
 	return nil
 }
 
-func newPeerRingNode(p peer.Peer) *ring.Ring {
+func newPeerRingNode(p peer.Peer, weight int, now time.Time) *ring.Ring {
 	newNode := ring.New(1)
-	newNode.Value = p
+	newNode.Value = &ringEntry{peer: p, weight: weight, added: now}
 	return newNode
 }
 
+// SetWeight changes the weight of a peer in the ring, if present.
+func (pr *peerRing) SetWeight(pid peer.Identifier, weight int) {
+	node, ok := pr.peerToNode[pid.Identifier()]
+	if !ok {
+		return
+	}
+
+	entry := getEntryForRingNode(node)
+	if entry.weight != peer.DefaultWeight {
+		pr.weighted--
+	}
+	entry.weight = weight
+	if weight != peer.DefaultWeight {
+		pr.weighted++
+	}
+}
+
 // Remove a peer Peer from the peerRing, if the PeerID is not
 // in the ring return an error
 func (pr *peerRing) Remove(p peer.Peer) error {
@@ -101,6 +141,11 @@ func (pr *peerRing) RemoveAll() []peer.Peer {
 	return peers
 }
 
+// Len returns the number of peers in the ring.
+func (pr *peerRing) Len() int {
+	return len(pr.peerToNode)
+}
+
 // All returns a snapshot of all the peers from the ring as a list.
 func (pr *peerRing) All() []peer.Peer {
 	peers := make([]peer.Peer, 0, len(pr.peerToNode))
@@ -112,6 +157,9 @@ func (pr *peerRing) All() []peer.Peer {
 
 func (pr *peerRing) popNode(node *ring.Ring) peer.Peer {
 	p := getPeerForRingNode(node)
+	if getEntryForRingNode(node).weight != peer.DefaultWeight {
+		pr.weighted--
+	}
 
 	if isLastRingNode(node) {
 		pr.nextNode = nil
@@ -134,11 +182,15 @@ func (pr *peerRing) isNextNode(node *ring.Ring) bool {
 
 // Next returns the next peer in the ring, or nil if there is no peer in the ring
 // after it has the next peer, it increments the nextPeer marker in the ring
-func (pr *peerRing) Next() peer.Peer {
+func (pr *peerRing) Next(now time.Time) peer.Peer {
 	if pr.nextNode == nil {
 		return nil
 	}
 
+	if pr.weighted > 0 || pr.slowStart > 0 {
+		return pr.nextWeighted(now)
+	}
+
 	p := getPeerForRingNode(pr.nextNode)
 
 	pr.nextNode = pr.nextNode.Next()
@@ -146,8 +198,38 @@ func (pr *peerRing) Next() peer.Peer {
 	return p
 }
 
+// nextWeighted chooses the next peer with smooth weighted round-robin: every
+// peer earns its effective weight, and the richest peer is chosen and pays
+// the total. Peers with equal weights are chosen in turn, and heavier peers
+// are chosen proportionally more often, interleaved with the others.
+func (pr *peerRing) nextWeighted(now time.Time) peer.Peer {
+	var (
+		chosen *ring.Ring
+		total  float64
+	)
+	node := pr.nextNode
+	for i := 0; i < len(pr.peerToNode); i++ {
+		entry := getEntryForRingNode(node)
+		weight := peerweight.Effective(entry.weight, entry.added, now, pr.slowStart)
+		entry.current += weight
+		total += weight
+		if chosen == nil || entry.current > getEntryForRingNode(chosen).current {
+			chosen = node
+		}
+		node = node.Next()
+	}
+
+	getEntryForRingNode(chosen).current -= total
+	pr.nextNode = chosen.Next()
+	return getPeerForRingNode(chosen)
+}
+
 func getPeerForRingNode(rNode *ring.Ring) peer.Peer {
-	return rNode.Value.(peer.Peer)
+	return getEntryForRingNode(rNode).peer
+}
+
+func getEntryForRingNode(rNode *ring.Ring) *ringEntry {
+	return rNode.Value.(*ringEntry)
 }
 
 func isLastRingNode(rNode *ring.Ring) bool {
@@ -0,0 +1,136 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tworandomchoices
+
+import (
+	"context"
+	"math/rand"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/peerlist"
+)
+
+type listConfig struct {
+	capacity int
+	source   rand.Source
+}
+
+var defaultListConfig = listConfig{
+	capacity: 10,
+}
+
+// ListOption customizes the behavior of a two random choices list.
+type ListOption func(*listConfig)
+
+// Capacity specifies the default capacity of the underlying
+// data structures for this list
+//
+// Defaults to 10.
+func Capacity(capacity int) ListOption {
+	return func(c *listConfig) {
+		c.capacity = capacity
+	}
+}
+
+// Source specifies the source of randomness used to sample peers, which
+// makes choices deterministic in tests. The source only seeds the generator
+// of the list, which is safe for concurrent use without locking.
+//
+// Defaults to a seed from the current time.
+func Source(source rand.Source) ListOption {
+	return func(c *listConfig) {
+		c.source = source
+	}
+}
+
+// New creates a new two random choices peer list.
+func New(transport peer.Transport, opts ...ListOption) *List {
+	cfg := defaultListConfig
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	return &List{
+		List: peerlist.New("two-random-choices", transport, &twoRandomChoicesList{
+			peers:  make([]peer.Peer, 0, cfg.capacity),
+			index:  make(map[string]int, cfg.capacity),
+			random: peerlist.NewRandom(cfg.source),
+		}, cfg.capacity),
+	}
+}
+
+// List is a PeerList which samples two available peers at random for each
+// request, and chooses the one with fewer pending requests.
+type List struct {
+	*peerlist.List
+}
+
+// twoRandomChoicesList holds the available peers of a List.
+type twoRandomChoicesList struct {
+	peers []peer.Peer
+	index map[string]int
+
+	random *peerlist.Random
+}
+
+func (l *twoRandomChoicesList) Add(p peer.Peer) {
+	l.index[p.Identifier()] = len(l.peers)
+	l.peers = append(l.peers, p)
+}
+
+func (l *twoRandomChoicesList) Remove(p peer.Peer) {
+	i, ok := l.index[p.Identifier()]
+	if !ok {
+		return
+	}
+
+	// Move the last peer into the hole.
+	last := len(l.peers) - 1
+	l.peers[i] = l.peers[last]
+	l.index[l.peers[i].Identifier()] = i
+	l.peers[last] = nil
+	l.peers = l.peers[:last]
+	delete(l.index, p.Identifier())
+}
+
+func (l *twoRandomChoicesList) Choose(context.Context, *transport.Request) peer.Peer {
+	n := len(l.peers)
+	switch n {
+	case 0:
+		return nil
+	case 1:
+		return l.peers[0]
+	}
+
+	// Sample two distinct peers.
+	i := l.random.Intn(n)
+	j := l.random.Intn(n - 1)
+	if j >= i {
+		j++
+	}
+
+	first, second := l.peers[i], l.peers[j]
+	if second.Status().PendingRequestCount < first.Status().PendingRequestCount {
+		return second
+	}
+	return first
+}
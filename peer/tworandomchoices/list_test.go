@@ -0,0 +1,144 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tworandomchoices
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	. "go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/peerlist"
+	"go.uber.org/yarpc/yarpcconfig"
+)
+
+type fakeTransport map[string]*LightMockPeer
+
+func (t fakeTransport) RetainPeer(pid peer.Identifier, _ peer.Subscriber) (peer.Peer, error) {
+	p := NewLightMockPeer(MockPeerIdentifier(pid.Identifier()), peer.Available)
+	t[pid.Identifier()] = p
+	return p, nil
+}
+
+func (t fakeTransport) ReleasePeer(peer.Identifier, peer.Subscriber) error {
+	return nil
+}
+
+func newStartedList(t *testing.T, ids []string, opts ...ListOption) (*List, fakeTransport) {
+	trans := make(fakeTransport)
+	pl := New(trans, opts...)
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: CreatePeerIDs(ids)}))
+	require.NoError(t, pl.Start())
+	return pl, trans
+}
+
+func choose(t *testing.T, pl *List) (peer.Peer, func(error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	p, onFinish, err := pl.Choose(ctx, &transport.Request{})
+	require.NoError(t, err)
+	return p, onFinish
+}
+
+func TestTwoRandomChoicesPrefersLessPending(t *testing.T) {
+	pl, trans := newStartedList(t, []string{"1", "2"}, Source(rand.NewSource(1)))
+	defer pl.Stop()
+
+	for i := 0; i < 5; i++ {
+		trans["1"].StartRequest()
+	}
+	for i := 0; i < 20; i++ {
+		p, onFinish := choose(t, pl)
+		assert.Equal(t, "2", p.Identifier(), "the less pending peer must be chosen")
+		onFinish(nil)
+	}
+}
+
+func TestTwoRandomChoicesBalancesLoad(t *testing.T) {
+	pl, trans := newStartedList(t, []string{"1", "2", "3", "4"}, Source(rand.NewSource(1)))
+	defer pl.Stop()
+
+	// Requests which never finish must pile up evenly.
+	for i := 0; i < 100; i++ {
+		choose(t, pl)
+	}
+	for id, p := range trans {
+		assert.InDelta(t, 25, p.Status().PendingRequestCount, 3, "peer %q is unbalanced", id)
+	}
+}
+
+func TestTwoRandomChoicesSinglePeer(t *testing.T) {
+	pl, _ := newStartedList(t, []string{"1"}, Source(rand.NewSource(1)))
+	defer pl.Stop()
+
+	p, onFinish := choose(t, pl)
+	assert.Equal(t, "1", p.Identifier())
+	onFinish(nil)
+}
+
+func TestTwoRandomChoicesDeterministicSource(t *testing.T) {
+	ids := []string{"1", "2", "3", "4"}
+	first, _ := newStartedList(t, ids, Source(rand.NewSource(42)))
+	defer first.Stop()
+	second, _ := newStartedList(t, ids, Source(rand.NewSource(42)))
+	defer second.Stop()
+
+	for i := 0; i < 20; i++ {
+		p1, _ := choose(t, first)
+		p2, _ := choose(t, second)
+		assert.Equal(t, p1.Identifier(), p2.Identifier())
+	}
+}
+
+func TestTwoRandomChoicesSamplesDistinctPeers(t *testing.T) {
+	l := &twoRandomChoicesList{index: make(map[string]int), random: peerlist.NewRandom(rand.NewSource(1))}
+	busy := NewLightMockPeer(MockPeerIdentifier("busy"), peer.Available)
+	idle := NewLightMockPeer(MockPeerIdentifier("idle"), peer.Available)
+	busy.StartRequest()
+	l.Add(busy)
+	l.Add(idle)
+
+	// Sampling the busy peer twice would choose it.
+	for i := 0; i < 50; i++ {
+		assert.Equal(t, "idle", l.Choose(context.Background(), nil).Identifier())
+	}
+
+	l.Remove(idle)
+	assert.Equal(t, "busy", l.Choose(context.Background(), nil).Identifier())
+	l.Remove(busy)
+	assert.Nil(t, l.Choose(context.Background(), nil))
+}
+
+func TestSpec(t *testing.T) {
+	spec := Spec()
+	assert.Equal(t, "two-random-choices", spec.Name)
+	build, ok := spec.BuildPeerList.(func(struct{}, peer.Transport, *yarpcconfig.Kit) (peer.ChooserList, error))
+	require.True(t, ok, "unexpected builder type %T", spec.BuildPeerList)
+
+	list, err := build(struct{}{}, make(fakeTransport), nil)
+	require.NoError(t, err)
+	assert.IsType(t, &List{}, list)
+}
@@ -104,6 +104,12 @@ func (ph *peerHeap) pushPeer(ps *peerScore) {
 	heap.Push(ph, ps)
 }
 
+// pushBack returns a popped peer to the heap, keeping its place among peers
+// with the same score.
+func (ph *peerHeap) pushBack(ps *peerScore) {
+	heap.Push(ph, ps)
+}
+
 func (ph *peerHeap) peekPeer() (*peerScore, bool) {
 	if ph.Len() == 0 {
 		return nil, false
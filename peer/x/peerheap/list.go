@@ -22,13 +22,18 @@ package peerheap
 
 import (
 	"context"
-	"math"
+	"fmt"
 	"sync"
 	"time"
 
 	"go.uber.org/multierr"
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/internal/peerweight"
+	"go.uber.org/yarpc/internal/triedpeers"
+	"go.uber.org/yarpc/peer/outlier"
 	"go.uber.org/yarpc/pkg/lifecycle"
 	"go.uber.org/yarpc/yarpcerrors"
 )
@@ -37,14 +42,30 @@ var (
 	_noContextDeadlineError = yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument, "can't wait for peer without a context deadline for peerheap")
 )
 
-const unavailablePenalty = math.MaxInt32
+const (
+	// scoreScale scales the number of pending requests of peers before
+	// dividing it by their weight, so that scores remain integers.
+	scoreScale = 1 << 16
+
+	unavailablePenalty = 1 << 62
+	// Ejected peers are still preferred to unavailable peers, so that
+	// requests are not blocked if every available peer is ejected.
+	ejectedPenalty = 1 << 61
+)
 
 type heapConfig struct {
 	startupWait time.Duration
+
+	outlierDetection bool
+	outlierOptions   []outlier.Option
+
+	slowStart time.Duration
+	clock     clock.Clock
 }
 
 var defaultHeapConfig = heapConfig{
 	startupWait: 5 * time.Second,
+	clock:       clock.NewReal(),
 }
 
 // HeapOption customizes the behavior of a peer heap.
@@ -60,9 +81,36 @@ func StartupWait(t time.Duration) HeapOption {
 	}
 }
 
+// OutlierDetection enables outlier detection for the heap. Peers which fail
+// too many requests are ejected and are not chosen until they recover,
+// unless every available peer is ejected.
+//
+// See the outlier package for details.
+func OutlierDetection(opts ...outlier.Option) HeapOption {
+	return func(c *heapConfig) {
+		c.outlierDetection = true
+		c.outlierOptions = opts
+	}
+}
+
+// SlowStart ramps peers which become available up to their weight over the
+// given duration, starting at a tenth of their weight, so that new or
+// reconnected hosts are not flooded with requests.
+//
+// Defaults to 0, which disables slow start.
+func SlowStart(d time.Duration) HeapOption {
+	return func(c *heapConfig) {
+		c.slowStart = d
+	}
+}
+
 // List is a peer list and peer chooser that favors the peer with the least
 // pending requests, and then favors the least recently used or most recently
 // introduced peer.
+//
+// Peers with weights are favored in proportion to their weight, such that a
+// peer with twice the weight of another is chosen as if it had half as many
+// pending requests.
 type List struct {
 	mu   sync.Mutex
 	once *lifecycle.Once
@@ -75,6 +123,15 @@ type List struct {
 	peerAvailableEvent chan struct{}
 
 	startupWait time.Duration
+
+	// outliers is nil unless outlier detection is enabled.
+	outliers *outlier.Detector
+
+	// slowStarting holds the available peers which are still ramping up to
+	// their weight, whose scores change over time.
+	slowStart    time.Duration
+	slowStarting map[*peerScore]struct{}
+	clock        clock.Clock
 }
 
 // IsRunning returns whether the peer list is running.
@@ -99,17 +156,26 @@ func New(transport peer.Transport, opts ...HeapOption) *List {
 		o(&cfg)
 	}
 
-	return &List{
+	pl := &List{
 		once:               lifecycle.NewOnce(),
 		transport:          transport,
 		byIdentifier:       make(map[string]*peerScore),
 		peerAvailableEvent: make(chan struct{}, 1),
 		startupWait:        cfg.startupWait,
+		slowStart:          cfg.slowStart,
+		slowStarting:       make(map[*peerScore]struct{}),
+		clock:              cfg.clock,
+	}
+	if cfg.outlierDetection {
+		pl.outliers = outlier.New(pl.outlierChanged, cfg.outlierOptions...)
 	}
+	return pl
 }
 
 // Update satisfies the peer.List interface, so a peer list updater can manage
 // the retained peers.
+//
+// Peers with invalid weights are added with the default weight.
 func (pl *List) Update(updates peer.ListUpdates) error {
 	ctx, cancel := context.WithTimeout(context.Background(), pl.startupWait)
 	defer cancel()
@@ -126,27 +192,55 @@ func (pl *List) Update(updates peer.ListUpdates) error {
 		errs = multierr.Append(errs, pl.releasePeer(pid))
 	}
 
+	weights := make(map[string]int, len(updates.Weights))
+	for id, weight := range updates.Weights {
+		if weight <= 0 {
+			errs = multierr.Append(errs, peer.ErrInvalidPeerWeight{PeerIdentifier: id, Weight: weight})
+			weight = peer.DefaultWeight
+		}
+		weights[id] = weight
+	}
+
 	for _, pid := range updates.Additions {
-		errs = multierr.Append(errs, pl.retainPeer(pid))
+		weight, ok := weights[pid.Identifier()]
+		if !ok {
+			weight = peer.DefaultWeight
+		}
+		if err := pl.retainPeer(pid, weight); err != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
+		delete(weights, pid.Identifier())
+	}
+
+	// The remaining weights change the weight of peers already in the list.
+	for id, weight := range weights {
+		if ps, ok := pl.byIdentifier[id]; ok && ps.weight != weight {
+			ps.weight = weight
+			pl.rescorePeer(ps, pl.clock.Now())
+		}
 	}
 
 	return errs
 }
 
 // retainPeer must be called with the mutex locked.
-func (pl *List) retainPeer(pid peer.Identifier) error {
+func (pl *List) retainPeer(pid peer.Identifier, weight int) error {
 	if _, ok := pl.byIdentifier[pid.Identifier()]; ok {
 		return peer.ErrPeerAddAlreadyInList(pid.Identifier())
 	}
 
-	ps := &peerScore{id: pid, list: pl}
+	ps := &peerScore{id: pid, list: pl, outliers: pl.outliers, weight: weight}
 	p, err := pl.transport.RetainPeer(pid, ps)
 	if err != nil {
 		return err
 	}
 
+	if pl.outliers != nil {
+		pl.outliers.Add(pid.Identifier())
+	}
 	ps.peer = p
-	ps.score = scorePeer(p)
+	ps.score = scorePeer(p, float64(weight))
 	ps.boundFinish = ps.finish
 	pl.byIdentifier[pid.Identifier()] = ps
 	pl.byScore.pushPeer(ps)
@@ -166,7 +260,11 @@ func (pl *List) releasePeer(pid peer.Identifier) error {
 	}
 
 	err := pl.transport.ReleasePeer(pid, ps)
+	if pl.outliers != nil {
+		pl.outliers.Remove(pid.Identifier())
+	}
 	delete(pl.byIdentifier, pid.Identifier())
+	delete(pl.slowStarting, ps)
 	pl.byScore.delete(ps.idx)
 	ps.list = nil
 	return err
@@ -202,9 +300,11 @@ func (pl *List) Choose(ctx context.Context, _ *transport.Request) (peer.Peer, fu
 		return nil, nil, newNotRunningError(err)
 	}
 
+	tried := triedpeers.FromContext(ctx)
 	for {
-		if ps, ok := pl.get(); ok {
+		if ps, ok := pl.get(tried); ok {
 			pl.notifyPeerAvailable()
+			tried.Add(ps.id.Identifier())
 			ps.peer.StartRequest()
 			return ps.peer, ps.boundFinish, nil
 		}
@@ -219,22 +319,72 @@ func newNotRunningError(err error) error {
 	return yarpcerrors.Newf(yarpcerrors.CodeFailedPrecondition, "peer heap is not running: %s", err.Error())
 }
 
-func (pl *List) get() (*peerScore, bool) {
+// get returns the peer with the best score, skipping peers already tried for
+// the request unless no other available peer is left.
+func (pl *List) get(tried *triedpeers.Set) (*peerScore, bool) {
 	pl.mu.Lock()
 	defer pl.mu.Unlock()
 
+	if len(pl.slowStarting) > 0 {
+		now := pl.clock.Now()
+		for ps := range pl.slowStarting {
+			pl.rescorePeer(ps, now)
+		}
+	}
+
 	ps, ok := pl.byScore.popPeer()
 	if !ok {
 		return nil, false
 	}
 
+	if tried.Contains(ps.id.Identifier()) {
+		ps = pl.skipTried(ps, tried)
+	}
+
 	// Note: We push the peer back to reset the "next" counter.
 	// This gives us round-robin behavior.
 	pl.byScore.pushPeer(ps)
 
+	// Claiming the probe of a half-open peer keeps other requests away from
+	// it until the probe finishes.
+	if id := ps.id.Identifier(); pl.outliers != nil && pl.outliers.Admit(id) && pl.outliers.Ejected(id) {
+		pl.rescorePeer(ps, pl.clock.Now())
+	}
+
 	return ps, ps.status.ConnectionStatus == peer.Available
 }
 
+// skipTried pops peers off the heap until it finds an available peer not yet
+// tried for the request, and returns it, or the given best peer if there is
+// none. The skipped peers go back on the heap in their previous order.
+// Must be run in a mutex.Lock()
+func (pl *List) skipTried(best *peerScore, tried *triedpeers.Set) *peerScore {
+	skipped := []*peerScore{best}
+	chosen := best
+	for {
+		ps, ok := pl.byScore.popPeer()
+		if !ok {
+			break
+		}
+		if ps.status.ConnectionStatus != peer.Available {
+			// Peers are ordered by score, so the rest are unavailable too.
+			skipped = append(skipped, ps)
+			break
+		}
+		if !tried.Contains(ps.id.Identifier()) {
+			chosen = ps
+			break
+		}
+		skipped = append(skipped, ps)
+	}
+	for _, ps := range skipped {
+		if ps != chosen {
+			pl.byScore.pushBack(ps)
+		}
+	}
+	return chosen
+}
+
 // waitForPeerAvailableEvent waits until a peer is added to the peer list or the
 // given context finishes.
 // Must NOT be run in a mutex.Lock()
@@ -277,7 +427,7 @@ func (pl *List) NotifyStatusChanged(pid peer.Identifier) {
 
 func (pl *List) peerScoreChanged(ps *peerScore) {
 	pl.mu.Lock()
-	pl.rescorePeer(ps)
+	pl.rescorePeer(ps, pl.clock.Now())
 	pl.mu.Unlock()
 
 	if ps.peer.Status().ConnectionStatus == peer.Available {
@@ -286,25 +436,115 @@ func (pl *List) peerScoreChanged(ps *peerScore) {
 }
 
 func (pl *List) internalNotifyStatusChanged(ps *peerScore) {
-	pl.rescorePeer(ps)
+	pl.rescorePeer(ps, pl.clock.Now())
 
 	if ps.peer.Status().ConnectionStatus == peer.Available {
 		pl.notifyPeerAvailable()
 	}
 }
 
-func (pl *List) rescorePeer(ps *peerScore) {
+func (pl *List) rescorePeer(ps *peerScore, now time.Time) {
 	p := ps.peer
 	ps.status = p.Status()
-	ps.score = scorePeer(p)
+
+	available := ps.status.ConnectionStatus == peer.Available
+	if available && !ps.available {
+		ps.availableSince = now
+	}
+	ps.available = available
+
+	weight := peerweight.Effective(ps.weight, ps.availableSince, now, pl.slowStart)
+	if available && weight < float64(ps.weight) {
+		pl.slowStarting[ps] = struct{}{}
+	} else {
+		delete(pl.slowStarting, ps)
+	}
+
+	ps.score = scorePeer(p, weight)
+	if pl.outliers != nil && pl.outliers.Ejected(ps.id.Identifier()) {
+		ps.score += ejectedPenalty
+	}
 	pl.byScore.update(ps.idx)
 }
 
-func scorePeer(p peer.Peer) int64 {
+// outlierChanged rescores a peer when it is ejected, probed again, or
+// restored.
+func (pl *List) outlierChanged(id string) {
+	pl.mu.Lock()
+	ps, ok := pl.byIdentifier[id]
+	if ok {
+		pl.rescorePeer(ps, pl.clock.Now())
+	}
+	pl.mu.Unlock()
+
+	if ok && ps.peer.Status().ConnectionStatus == peer.Available {
+		pl.notifyPeerAvailable()
+	}
+}
+
+// scorePeer scores a peer by its number of pending requests, including the
+// request it would receive, divided by its effective weight.
+func scorePeer(p peer.Peer, weight float64) int64 {
 	status := p.Status()
-	score := int64(status.PendingRequestCount)
+	score := int64(float64(status.PendingRequestCount+1) * scoreScale / weight)
 	if status.ConnectionStatus != peer.Available {
 		score += int64(unavailablePenalty)
 	}
 	return score
 }
+
+// Introspect returns a ChooserStatus with a summary of the Peers.
+func (pl *List) Introspect() introspection.ChooserStatus {
+	state := "Stopped"
+	if pl.IsRunning() {
+		state = "Running"
+	}
+
+	pl.mu.Lock()
+	scores := make([]*peerScore, 0, len(pl.byIdentifier))
+	weights := make(map[*peerScore]int, len(pl.byIdentifier))
+	for _, ps := range pl.byIdentifier {
+		scores = append(scores, ps)
+		weights[ps] = ps.weight
+	}
+	pl.mu.Unlock()
+
+	available, ejected := 0, 0
+	peersStatus := make([]introspection.PeerStatus, 0, len(scores))
+	for _, ps := range scores {
+		status := ps.peer.Status()
+		peerState := fmt.Sprintf("%s, %d pending request(s)",
+			status.ConnectionStatus.String(),
+			status.PendingRequestCount)
+		if weight := weights[ps]; weight != peer.DefaultWeight {
+			peerState += fmt.Sprintf(", weight %d", weight)
+		}
+		if status.ConnectionStatus == peer.Available {
+			available++
+		}
+		if pl.outliers != nil {
+			if outlierStatus := pl.outliers.Status(ps.id.Identifier()); outlierStatus != "" {
+				peerState += ", " + outlierStatus
+			}
+			if status.ConnectionStatus == peer.Available && pl.outliers.Ejected(ps.id.Identifier()) {
+				ejected++
+			}
+		}
+		peersStatus = append(peersStatus, introspection.PeerStatus{
+			Identifier: ps.id.Identifier(),
+			State:      peerState,
+		})
+	}
+
+	state = fmt.Sprintf("%s (%d/%d available", state, available, len(scores))
+	if pl.outliers != nil {
+		state += fmt.Sprintf(", %d ejected", ejected)
+	}
+	state += ")"
+
+	return introspection.ChooserStatus{
+		Name:  "PeerHeap",
+		State: state,
+		Peers: peersStatus,
+	}
+}
@@ -28,9 +28,14 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/multierr"
 	"go.uber.org/yarpc/api/peer"
 	. "go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/triedpeers"
+	"go.uber.org/yarpc/peer/outlier"
+	"go.uber.org/yarpc/yarpcerrors"
 )
 
 func TestPeerHeapList(t *testing.T) {
@@ -750,3 +755,262 @@ func TestPeerHeapList(t *testing.T) {
 		})
 	}
 }
+
+func TestOutlierDetection(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	transport := NewMockTransport(mockCtrl)
+	ExpectPeerRetains(transport, []string{"foo", "bar"}, nil)
+	ExpectPeerReleases(transport, []string{"foo", "bar"}, nil)
+
+	pl := New(transport, OutlierDetection(outlier.ConsecutiveFailures(1), outlier.Cooldown(time.Hour)))
+	require.NoError(t, pl.Start())
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: CreatePeerIDs([]string{"foo", "bar"}),
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	p, onFinish, err := pl.Choose(ctx, nil)
+	require.NoError(t, err)
+	ejected := p.Identifier()
+	onFinish(yarpcerrors.InternalErrorf("internal"))
+
+	for i := 0; i < 3; i++ {
+		p, onFinish, err := pl.Choose(ctx, nil)
+		require.NoError(t, err)
+		assert.NotEqual(t, ejected, p.Identifier(), "ejected peer must not be chosen")
+		onFinish(nil)
+	}
+
+	chooserStatus := pl.Introspect()
+	assert.Equal(t, "PeerHeap", chooserStatus.Name)
+	assert.Equal(t, "Running (2/2 available, 1 ejected)", chooserStatus.State)
+	for _, peerStatus := range chooserStatus.Peers {
+		if peerStatus.Identifier == ejected {
+			assert.Equal(t, "Available, 0 pending request(s), ejected", peerStatus.State)
+		} else {
+			assert.Equal(t, "Available, 0 pending request(s)", peerStatus.State)
+		}
+	}
+
+	require.NoError(t, pl.Stop())
+}
+
+func TestOutlierSingleProbe(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	transport := NewMockTransport(mockCtrl)
+	ExpectPeerRetains(transport, []string{"foo", "bar"}, nil)
+	ExpectPeerReleases(transport, []string{"foo", "bar"}, nil)
+
+	pl := New(transport, OutlierDetection(outlier.ConsecutiveFailures(1), outlier.Cooldown(10*time.Millisecond)))
+	require.NoError(t, pl.Start())
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: CreatePeerIDs([]string{"foo", "bar"}),
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	p, onFinish, err := pl.Choose(ctx, nil)
+	require.NoError(t, err)
+	ejected := p.Identifier()
+	onFinish(yarpcerrors.InternalErrorf("internal"))
+
+	// Wait for the detector to make the peer half-open and the list to
+	// rescore it. Light mock peers are not safe for concurrent use.
+	select {
+	case <-pl.peerAvailableEvent:
+	default:
+	}
+	select {
+	case <-pl.peerAvailableEvent:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the peer to become half-open")
+	}
+	for _, peerStatus := range pl.Introspect().Peers {
+		if peerStatus.Identifier == ejected {
+			assert.Equal(t, "Available, 0 pending request(s), probing", peerStatus.State)
+		}
+	}
+
+	// Only one request goes to the half-open peer until its probe finishes.
+	var probeFinish func(error)
+	for i := 0; i < 6; i++ {
+		p, onFinish, err := pl.Choose(ctx, nil)
+		require.NoError(t, err)
+		if p.Identifier() != ejected {
+			onFinish(nil)
+			continue
+		}
+		if assert.Nil(t, probeFinish, "half-open peer must take a single probe") {
+			probeFinish = onFinish
+		}
+	}
+	require.NotNil(t, probeFinish, "half-open peer must be probed")
+
+	probeFinish(nil)
+	chosen := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		p, onFinish, err := pl.Choose(ctx, nil)
+		require.NoError(t, err)
+		chosen[p.Identifier()]++
+		onFinish(nil)
+	}
+	assert.Equal(t, map[string]int{"foo": 2, "bar": 2}, chosen, "successful probe must restore the peer")
+
+	require.NoError(t, pl.Stop())
+}
+
+func withClock(c clock.Clock) HeapOption {
+	return func(cfg *heapConfig) {
+		cfg.clock = c
+	}
+}
+
+// chooseAndHold chooses n peers without finishing their requests, and
+// returns how many times each peer was chosen.
+func chooseAndHold(t *testing.T, pl *List, n int) map[string]int {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		p, _, err := pl.Choose(ctx, nil)
+		require.NoError(t, err)
+		// Light mock peers don't notify their subscribers of new requests.
+		pl.NotifyStatusChanged(p)
+		counts[p.Identifier()]++
+	}
+	return counts
+}
+
+func TestWeightedPeers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	transport := NewMockTransport(mockCtrl)
+	ExpectPeerRetains(transport, []string{"a", "b"}, nil)
+	ExpectPeerReleases(transport, []string{"a", "b"}, nil)
+
+	pl := New(transport)
+	require.NoError(t, pl.Start())
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: CreatePeerIDs([]string{"a", "b"}),
+		Weights:   map[string]int{"a": 3},
+	}))
+
+	// The heavier peer takes three times the pending requests.
+	assert.Equal(t, map[string]int{"a": 6, "b": 2}, chooseAndHold(t, pl, 8))
+
+	// Weights of peers in the list can change without adding them again.
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Weights: map[string]int{"a": 1, "b": 4},
+	}))
+	assert.Equal(t, map[string]int{"b": 13}, chooseAndHold(t, pl, 13))
+
+	for _, peerStatus := range pl.Introspect().Peers {
+		switch peerStatus.Identifier {
+		case "a":
+			assert.Equal(t, "Available, 6 pending request(s)", peerStatus.State)
+		case "b":
+			assert.Equal(t, "Available, 15 pending request(s), weight 4", peerStatus.State)
+		}
+	}
+
+	require.NoError(t, pl.Stop())
+}
+
+func TestInvalidWeight(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	transport := NewMockTransport(mockCtrl)
+	ExpectPeerRetains(transport, []string{"a"}, nil)
+	ExpectPeerReleases(transport, []string{"a"}, nil)
+
+	pl := New(transport)
+	require.NoError(t, pl.Start())
+	err := pl.Update(peer.ListUpdates{
+		Additions: CreatePeerIDs([]string{"a"}),
+		Weights:   map[string]int{"a": 0},
+	})
+	assert.Equal(t, peer.ErrInvalidPeerWeight{PeerIdentifier: "a", Weight: 0}, err)
+
+	// The peer is added with the default weight.
+	peers := pl.Introspect().Peers
+	require.Len(t, peers, 1)
+	assert.Equal(t, "Available, 0 pending request(s)", peers[0].State)
+
+	require.NoError(t, pl.Stop())
+}
+
+func TestSlowStart(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	transport := NewMockTransport(mockCtrl)
+	ExpectPeerRetains(transport, []string{"a", "b"}, nil)
+	ExpectPeerReleases(transport, []string{"a", "b"}, nil)
+
+	fake := clock.NewFake()
+	pl := New(transport, SlowStart(10*time.Second), withClock(fake))
+	require.NoError(t, pl.Start())
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: CreatePeerIDs([]string{"a"}),
+	}))
+	fake.Add(10 * time.Second)
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: CreatePeerIDs([]string{"b"}),
+	}))
+
+	// The new peer starts with a tenth of its weight, so it is chosen as if
+	// it had ten times as many pending requests.
+	assert.Equal(t, map[string]int{"a": 10, "b": 1}, chooseAndHold(t, pl, 11))
+
+	// Halfway through, the peer has half its weight.
+	fake.Add(5 * time.Second)
+	assert.Equal(t, map[string]int{"a": 1, "b": 4}, chooseAndHold(t, pl, 5))
+
+	// Afterwards, the peer has its full weight.
+	fake.Add(5 * time.Second)
+	assert.Equal(t, map[string]int{"b": 6}, chooseAndHold(t, pl, 6))
+
+	require.NoError(t, pl.Stop())
+}
+
+func TestTriedPeersSkipped(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	transport := NewMockTransport(mockCtrl)
+	ExpectPeerRetains(transport, []string{"a", "b", "c"}, nil)
+	ExpectPeerReleases(transport, []string{"a", "b", "c"}, nil)
+
+	pl := New(transport)
+	require.NoError(t, pl.Start())
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: CreatePeerIDs([]string{"a", "b", "c"}),
+		Weights:   map[string]int{"a": 10, "b": 5},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx = triedpeers.NewContext(ctx, &triedpeers.Set{})
+
+	// Peers keep no pending requests, so "a" has the best score throughout.
+	var ids []string
+	for i := 0; i < 4; i++ {
+		p, onFinish, err := pl.Choose(ctx, nil)
+		require.NoError(t, err)
+		onFinish(nil)
+		ids = append(ids, p.Identifier())
+	}
+	assert.Equal(t, []string{"a", "b", "c", "a"}, ids)
+
+	require.NoError(t, pl.Stop())
+}
@@ -20,7 +20,12 @@
 
 package peerheap
 
-import "go.uber.org/yarpc/api/peer"
+import (
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/peer/outlier"
+)
 
 // peerScore is a book-keeping object for each retained peer and
 // gets
@@ -29,12 +34,20 @@ type peerScore struct {
 	peer        peer.Peer
 	id          peer.Identifier
 	list        *List
+	outliers    *outlier.Detector
 	boundFinish func(error)
 
 	status peer.Status
 	score  int64
-	idx    int // index in the peer list.
-	last   int // snapshot of the heap's incrementing counter.
+	weight int
+
+	// available is whether the peer was available when it was last scored,
+	// and availableSince when it last became available, for slow start.
+	available      bool
+	availableSince time.Time
+
+	idx  int // index in the peer list.
+	last int // snapshot of the heap's incrementing counter.
 }
 
 func (ps *peerScore) NotifyStatusChanged(_ peer.Identifier) {
@@ -51,6 +64,9 @@ func (ps *peerScore) NotifyStatusChanged(_ peer.Identifier) {
 	ps.list.peerScoreChanged(ps)
 }
 
-func (ps *peerScore) finish(error) {
+func (ps *peerScore) finish(err error) {
 	ps.peer.EndRequest()
+	if ps.outliers != nil {
+		ps.outliers.Report(ps.id.Identifier(), err)
+	}
 }
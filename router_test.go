@@ -101,6 +101,33 @@ func TestMapRouter(t *testing.T) {
 	}
 }
 
+func TestMapRouterStreamProcedure(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	m := NewMapRouter("myservice")
+
+	stream := transporttest.NewMockStreamHandler(mockCtrl)
+	m.Register([]transport.Procedure{
+		{
+			Name:        "stream",
+			Encoding:    "proto",
+			HandlerSpec: transport.NewStreamHandlerSpec(stream),
+		},
+	})
+
+	meta := &transport.RequestMeta{
+		Procedure: "stream",
+		Encoding:  "proto",
+	}
+	got, err := m.Choose(context.Background(), meta.ToRequest())
+	if assert.NoError(t, err) {
+		assert.Equal(t, transport.Streaming, got.Type())
+		assert.True(t, stream == got.Stream(), "stream handler did not match")
+		assert.Nil(t, got.Unary())
+	}
+}
+
 func TestMapRouter_Procedures(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -0,0 +1,148 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package serialize
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/yarpc/api/transport"
+)
+
+const (
+	// Version0 serializes the request with Thrift and the span context with
+	// the tracer's binary format. It does not record the deadline, type or
+	// metadata of a Message. ToBytes and FromBytes use this version.
+	Version0 = byte(0)
+
+	// Version1 serializes everything except the body as a length-prefixed
+	// protobuf header, followed by the body in length-prefixed chunks. It
+	// records the deadline, type and metadata of a Message and can encode
+	// and decode bodies without holding them in memory.
+	Version1 = byte(1)
+)
+
+// Message is a request along with everything needed to execute it later.
+type Message struct {
+	SpanContext opentracing.SpanContext
+	Request     *transport.Request
+
+	// Deadline is the time by which the request must be executed. The zero
+	// value indicates that the request has no deadline.
+	Deadline time.Time
+
+	// Type is the RPC type of the request.
+	Type transport.Type
+
+	// Metadata holds arbitrary application-defined data about the request.
+	Metadata map[string]string
+}
+
+// Format is a serialization format for Messages.
+//
+// Formats are registered with RegisterFormat under a version number, which
+// Encode writes before the encoded Message so that Decode knows which Format
+// to use to read it back.
+type Format interface {
+	// Encode writes the Message to the Writer, consuming the request body.
+	Encode(w io.Writer, tracer opentracing.Tracer, m *Message) error
+
+	// Decode reads a Message from the Reader. The request body of the
+	// returned Message may read lazily from the Reader.
+	Decode(r io.Reader, tracer opentracing.Tracer) (*Message, error)
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[byte]Format{
+		Version0: version0Format{},
+		Version1: version1Format{},
+	}
+)
+
+// RegisterFormat registers a Format under the given version number.
+//
+// RegisterFormat panics if a Format was already registered under the same
+// version. It is intended to be called from init functions.
+func RegisterFormat(version byte, f Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	if _, ok := formats[version]; ok {
+		panic(fmt.Sprintf("a serialization format is already registered for version %v", version))
+	}
+	formats[version] = f
+}
+
+func lookupFormat(version byte) (Format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	f, ok := formats[version]
+	return f, ok
+}
+
+// Encode writes the Message to the Writer using the Format registered under
+// the given version. The request body is consumed.
+func Encode(w io.Writer, version byte, tracer opentracing.Tracer, m *Message) error {
+	f, ok := lookupFormat(version)
+	if !ok {
+		return fmt.Errorf("unsupported YARPC serialization version '%v'", version)
+	}
+	if m == nil || m.Request == nil {
+		return errors.New("cannot serialize a Message without a Request")
+	}
+
+	// use the first byte to version the serialization
+	if _, err := w.Write([]byte{version}); err != nil {
+		return err
+	}
+	return f.Encode(w, tracer, m)
+}
+
+// Decode reads a Message written by Encode with any registered version.
+//
+// The request body of the returned Message may read lazily from the Reader,
+// in which case it must be read to completion before the Reader is used for
+// anything else.
+func Decode(r io.Reader, tracer opentracing.Tracer) (*Message, error) {
+	var version [1]byte
+	if _, err := io.ReadFull(r, version[:]); err != nil {
+		if err == io.EOF {
+			return nil, errors.New("cannot deserialize empty request")
+		}
+		return nil, err
+	}
+
+	f, ok := lookupFormat(version[0])
+	if !ok {
+		return nil, unsupportedVersionError(version[0])
+	}
+	return f.Decode(r, tracer)
+}
+
+func unsupportedVersionError(version byte) error {
+	return fmt.Errorf("unsupported YARPC serialization version '%v' found during deserialization", version)
+}
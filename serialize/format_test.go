@@ -0,0 +1,327 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package serialize
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+)
+
+func newTestRequest(body []byte) *transport.Request {
+	return &transport.Request{
+		Caller:          "Caller",
+		Service:         "ServiceName",
+		Encoding:        "Encoding",
+		Procedure:       "Procedure",
+		Headers:         transport.HeadersFromMap(map[string]string{"hello": "world", "foo": "bar"}),
+		ShardKey:        "ShardKey",
+		RoutingKey:      "RoutingKey",
+		RoutingDelegate: "RoutingDelegate",
+		Body:            bytes.NewReader(body),
+	}
+}
+
+func assertRequestEqual(t *testing.T, want *transport.Request, wantBody []byte, got *transport.Request) {
+	gotBody, err := ioutil.ReadAll(got.Body)
+	require.NoError(t, err, "failed to read body")
+	assert.Equal(t, string(wantBody), string(gotBody), "body mismatch")
+
+	got.Body = nil
+	wantCopy := *want
+	wantCopy.Body = nil
+	assert.Equal(t, &wantCopy, got)
+}
+
+func TestVersion1RoundTrip(t *testing.T) {
+	tracer := opentracing.NoopTracer{}
+	spanContext := tracer.StartSpan("test-span").Context()
+	deadline := time.Unix(1500000000, 123456789)
+
+	tests := []struct {
+		desc string
+		msg  Message
+		body []byte
+	}{
+		{
+			desc: "empty body",
+			msg:  Message{Type: transport.Unary},
+		},
+		{
+			desc: "everything",
+			msg: Message{
+				Deadline: deadline,
+				Type:     transport.Oneway,
+				Metadata: map[string]string{"attempt": "3", "queue": "retries"},
+			},
+			body: []byte("My mind is tellin' me no... but my body... my body's tellin' me yes"),
+		},
+		{
+			desc: "large body",
+			msg:  Message{Deadline: deadline, Type: transport.Oneway},
+			body: bytes.Repeat([]byte("0123456789abcdef"), 10*_maxChunkSize),
+		},
+		{
+			desc: "body of exactly one chunk",
+			msg:  Message{Type: transport.Unary},
+			body: bytes.Repeat([]byte{'x'}, _maxChunkSize),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := newTestRequest(tt.body)
+			want := *req
+			msg := tt.msg
+			msg.SpanContext = spanContext
+			msg.Request = req
+
+			var buf bytes.Buffer
+			require.NoError(t, Encode(&buf, Version1, tracer, &msg), "failed to encode")
+			assert.Equal(t, Version1, buf.Bytes()[0], "serialization byte invalid")
+
+			got, err := Decode(&buf, tracer)
+			require.NoError(t, err, "failed to decode")
+
+			assert.Nil(t, got.SpanContext)
+			assert.Equal(t, msg.Type, got.Type)
+			assert.Equal(t, msg.Metadata, got.Metadata)
+			if msg.Deadline.IsZero() {
+				assert.True(t, got.Deadline.IsZero(), "expected no deadline")
+			} else {
+				assert.True(t, msg.Deadline.Equal(got.Deadline), "deadline mismatch: %v != %v", msg.Deadline, got.Deadline)
+			}
+			assertRequestEqual(t, &want, tt.body, got.Request)
+			assert.Equal(t, 0, buf.Len(), "expected the whole message to be consumed")
+		})
+	}
+}
+
+func TestVersion1Streaming(t *testing.T) {
+	tracer := opentracing.NoopTracer{}
+	body := bytes.Repeat([]byte("streaming body "), 20000)
+
+	var buf bytes.Buffer
+	msg := &Message{
+		Request: newTestRequest(nil),
+		Type:    transport.Oneway,
+	}
+	// Feed the body one byte at a time to exercise short reads.
+	msg.Request.Body = iotest.OneByteReader(bytes.NewReader(body))
+	require.NoError(t, Encode(&buf, Version1, tracer, msg))
+
+	// Follow the message with unrelated data to make sure that decoding
+	// stops at the end of the message.
+	buf.WriteString("trailer")
+
+	// Readers that do not implement io.ByteReader must not be read past the
+	// end of the message either.
+	r := iotest.HalfReader(&buf)
+	got, err := Decode(r, tracer)
+	require.NoError(t, err)
+
+	gotBody, err := ioutil.ReadAll(got.Request.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, gotBody)
+
+	trailer, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "trailer", string(trailer), "decoding must not consume data after the message")
+}
+
+func TestVersion1Truncated(t *testing.T) {
+	tracer := opentracing.NoopTracer{}
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, Version1, tracer, &Message{
+		Request: newTestRequest([]byte("hello world")),
+	}))
+	encoded := buf.Bytes()
+
+	// Drop the terminating chunk.
+	got, err := Decode(bytes.NewReader(encoded[:len(encoded)-1]), tracer)
+	require.NoError(t, err, "header should decode")
+	_, err = ioutil.ReadAll(got.Request.Body)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	// Cut into the header.
+	_, err = Decode(bytes.NewReader(encoded[:5]), tracer)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestVersion1HeaderTooLarge(t *testing.T) {
+	// version byte followed by a uvarint header length over the limit
+	_, err := Decode(bytes.NewReader([]byte{Version1, 0xff, 0xff, 0xff, 0xff, 0x0f}), opentracing.NoopTracer{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "serialized request header too large")
+}
+
+func TestVersion0Compatibility(t *testing.T) {
+	tracer := opentracing.NoopTracer{}
+	spanContext := tracer.StartSpan("test-span").Context()
+	body := []byte("someBODY")
+
+	t.Run("ToBytes to Decode", func(t *testing.T) {
+		req := newTestRequest(body)
+		want := *req
+
+		b, err := ToBytes(tracer, spanContext, req)
+		require.NoError(t, err)
+
+		got, err := Decode(bytes.NewReader(b), tracer)
+		require.NoError(t, err)
+		assertRequestEqual(t, &want, body, got.Request)
+		assert.True(t, got.Deadline.IsZero(), "version 0 has no deadline")
+		assert.Equal(t, transport.Type(0), got.Type, "version 0 has no type")
+		assert.Nil(t, got.Metadata, "version 0 has no metadata")
+	})
+
+	t.Run("Encode to FromBytes", func(t *testing.T) {
+		req := newTestRequest(body)
+		want := *req
+
+		var buf bytes.Buffer
+		require.NoError(t, Encode(&buf, Version0, tracer, &Message{
+			SpanContext: spanContext,
+			Request:     req,
+			Deadline:    time.Now(),
+			Type:        transport.Oneway,
+			Metadata:    map[string]string{"dropped": "true"},
+		}))
+
+		_, got, err := FromBytes(tracer, buf.Bytes())
+		require.NoError(t, err)
+		assertRequestEqual(t, &want, body, got)
+	})
+
+	t.Run("Encode matches ToBytes", func(t *testing.T) {
+		want, err := ToBytes(tracer, spanContext, newTestRequest(body))
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, Encode(&buf, Version0, tracer, &Message{
+			SpanContext: spanContext,
+			Request:     newTestRequest(body),
+		}))
+		assert.Equal(t, want, buf.Bytes())
+	})
+
+	t.Run("FromBytes rejects version 1", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, Encode(&buf, Version1, tracer, &Message{Request: newTestRequest(body)}))
+
+		_, _, err := FromBytes(tracer, buf.Bytes())
+		assert.Error(t, err)
+	})
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tracer := opentracing.NoopTracer{}
+
+	_, err := Decode(bytes.NewReader(nil), tracer)
+	assert.EqualError(t, err, "cannot deserialize empty request")
+
+	_, err = Decode(bytes.NewReader([]byte{42, 1, 2, 3}), tracer)
+	assert.EqualError(t, err, "unsupported YARPC serialization version '42' found during deserialization")
+}
+
+func TestEncodeErrors(t *testing.T) {
+	tracer := opentracing.NoopTracer{}
+
+	err := Encode(ioutil.Discard, 42, tracer, &Message{Request: newTestRequest(nil)})
+	assert.EqualError(t, err, "unsupported YARPC serialization version '42'")
+
+	err = Encode(ioutil.Discard, Version1, tracer, &Message{})
+	assert.EqualError(t, err, "cannot serialize a Message without a Request")
+}
+
+type customFormat struct{ version1Format }
+
+func TestRegisterFormat(t *testing.T) {
+	const version = byte(200)
+	defer func() {
+		formatsMu.Lock()
+		delete(formats, version)
+		formatsMu.Unlock()
+	}()
+
+	RegisterFormat(version, customFormat{})
+	assert.Panics(t, func() { RegisterFormat(version, customFormat{}) })
+	assert.Panics(t, func() { RegisterFormat(Version1, customFormat{}) })
+
+	tracer := opentracing.NoopTracer{}
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, version, tracer, &Message{Request: newTestRequest([]byte("body"))}))
+	assert.Equal(t, version, buf.Bytes()[0])
+
+	got, err := Decode(&buf, tracer)
+	require.NoError(t, err)
+	assertRequestEqual(t, newTestRequest(nil), []byte("body"), got.Request)
+}
+
+func TestNoSpanContext(t *testing.T) {
+	tracer, closer := initTracer()
+	defer closer()
+
+	for _, version := range []byte{Version0, Version1} {
+		var buf bytes.Buffer
+		require.NoError(t, Encode(&buf, version, tracer, &Message{Request: newTestRequest([]byte("body"))}),
+			"version %v: failed to encode", version)
+
+		got, err := Decode(&buf, tracer)
+		require.NoError(t, err, "version %v: failed to decode", version)
+		assert.Nil(t, got.SpanContext, "version %v: unexpected span context", version)
+	}
+}
+
+func TestVersion1SpanContext(t *testing.T) {
+	tracer, closer := initTracer()
+	defer closer()
+
+	span := tracer.StartSpan("test-span")
+	span.SetBaggageItem("hello", "world")
+	defer span.Finish()
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, Version1, tracer, &Message{
+		SpanContext: span.Context(),
+		Request:     newTestRequest(nil),
+	}))
+
+	got, err := Decode(&buf, tracer)
+	require.NoError(t, err)
+	require.NotNil(t, got.SpanContext)
+
+	baggage := make(map[string]string)
+	got.SpanContext.ForeachBaggageItem(func(k, v string) bool {
+		baggage[k] = v
+		return true
+	})
+	assert.Equal(t, map[string]string{"hello": "world"}, baggage)
+}
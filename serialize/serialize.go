@@ -23,7 +23,7 @@ package serialize
 import (
 	"bytes"
 	"errors"
-	"fmt"
+	"io"
 	"io/ioutil"
 
 	"github.com/opentracing/opentracing-go"
@@ -33,21 +33,53 @@ import (
 	"go.uber.org/yarpc/serialize/internal"
 )
 
-// version indicates which underlying serialization method will be used
-// '0' indicates:
-// 		thrift serialization (request) + jaeger.binary format (ctx/tracing)
-const version = byte(0)
-
 // ToBytes encodes an opentracing.SpanContext and transport.Request into bytes
+// using serialization Version0.
 func ToBytes(tracer opentracing.Tracer, spanContext opentracing.SpanContext, req *transport.Request) ([]byte, error) {
-	spanBytes, err := spanContextToBytes(tracer, spanContext)
-	if err != nil {
+	var writer bytes.Buffer
+	if err := Encode(&writer, Version0, tracer, &Message{SpanContext: spanContext, Request: req}); err != nil {
 		return nil, err
 	}
+	return writer.Bytes(), nil
+}
+
+// FromBytes decodes bytes produced by ToBytes into a opentracing.SpanContext
+// and transport.Request. Use Decode to read other serialization versions.
+func FromBytes(tracer opentracing.Tracer, request []byte) (opentracing.SpanContext, *transport.Request, error) {
+	if len(request) <= 1 {
+		return nil, nil, errors.New("cannot deserialize empty request")
+	}
+
+	// check valid thrift serialization byte
+	if request[0] != Version0 {
+		return nil, nil, unsupportedVersionError(request[0])
+	}
 
-	body, err := ioutil.ReadAll(req.Body)
+	m, err := version0Format{}.Decode(bytes.NewReader(request[1:]), tracer)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	return m.SpanContext, m.Request, nil
+}
+
+// version0Format is thrift serialization (request) + jaeger.binary format
+// (ctx/tracing). It does not record the deadline, type or metadata of a
+// Message.
+type version0Format struct{}
+
+func (version0Format) Encode(w io.Writer, tracer opentracing.Tracer, m *Message) error {
+	req := m.Request
+	spanBytes, err := spanContextToBytes(tracer, m.SpanContext)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	if req.Body != nil {
+		body, err = ioutil.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
 	}
 
 	rpc := internal.RPC{
@@ -66,41 +98,26 @@ func ToBytes(tracer opentracing.Tracer, spanContext opentracing.SpanContext, req
 
 	wireValue, err := rpc.ToWire()
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	var writer bytes.Buffer
-	// use the first byte to version the serialization
-	if err := writer.WriteByte(version); err != nil {
-		return nil, err
-	}
-	err = protocol.Binary.Encode(wireValue, &writer)
-	return writer.Bytes(), err
+	return protocol.Binary.Encode(wireValue, w)
 }
 
-// FromBytes decodes bytes into a opentracing.SpanContext and transport.Request
-func FromBytes(tracer opentracing.Tracer, request []byte) (opentracing.SpanContext, *transport.Request, error) {
-	if len(request) <= 1 {
-		return nil, nil, errors.New("cannot deserialize empty request")
-	}
-
-	// check valid thrift serialization byte
-	if request[0] != 0 {
-		return nil, nil,
-			fmt.Errorf(
-				"unsupported YARPC serialization version '%v' found during deserialization",
-				request[0])
+func (version0Format) Decode(r io.Reader, tracer opentracing.Tracer) (*Message, error) {
+	// The thrift decoder needs random access to the whole struct.
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
 	}
 
-	reader := bytes.NewReader(request[1:])
-	wireValue, err := protocol.Binary.Decode(reader, wire.TStruct)
+	wireValue, err := protocol.Binary.Decode(bytes.NewReader(buf), wire.TStruct)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	var rpc internal.RPC
 	if err = rpc.FromWire(wireValue); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	req := transport.Request{
@@ -124,19 +141,26 @@ func FromBytes(tracer opentracing.Tracer, request []byte) (opentracing.SpanConte
 
 	spanContext, err := spanContextFromBytes(tracer, rpc.SpanContext)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	return spanContext, &req, nil
+	return &Message{SpanContext: spanContext, Request: &req}, nil
 }
 
 func spanContextToBytes(tracer opentracing.Tracer, spanContext opentracing.SpanContext) ([]byte, error) {
+	// Requests made outside of a span have no SpanContext to inject.
+	if spanContext == nil {
+		return []byte{}, nil
+	}
 	carrier := bytes.NewBuffer([]byte{})
 	err := tracer.Inject(spanContext, opentracing.Binary, carrier)
 	return carrier.Bytes(), err
 }
 
 func spanContextFromBytes(tracer opentracing.Tracer, spanContextBytes []byte) (opentracing.SpanContext, error) {
+	if len(spanContextBytes) == 0 {
+		return nil, nil
+	}
 	carrier := bytes.NewBuffer(spanContextBytes)
 	spanContext, err := tracer.Extract(opentracing.Binary, carrier)
 	// If no SpanContext was given, we return nil instead of erroring
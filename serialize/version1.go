@@ -0,0 +1,257 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package serialize
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/serialize/internal"
+)
+
+const (
+	// _maxChunkSize is the largest body chunk written by version1Format.
+	_maxChunkSize = 32 * 1024
+
+	// _maxHeaderSize bounds the header length accepted by version1Format so
+	// that corrupt input does not cause huge allocations.
+	_maxHeaderSize = 16 * 1024 * 1024
+)
+
+// version1Format is laid out as,
+//
+//	header_length:uvarint header:RequestHeader
+//	(chunk_length:uvarint chunk:bytes)* 0:uvarint
+//
+// where RequestHeader is the protobuf message defined in
+// serialize/internal/internal.proto and every chunk is non-empty.
+type version1Format struct{}
+
+func (version1Format) Encode(w io.Writer, tracer opentracing.Tracer, m *Message) error {
+	req := m.Request
+	spanBytes, err := spanContextToBytes(tracer, m.SpanContext)
+	if err != nil {
+		return err
+	}
+
+	header := internal.RequestHeader{
+		SpanContext:     spanBytes,
+		Caller:          req.Caller,
+		Service:         req.Service,
+		Encoding:        string(req.Encoding),
+		Procedure:       req.Procedure,
+		Headers:         req.Headers.Items(),
+		ShardKey:        req.ShardKey,
+		RoutingKey:      req.RoutingKey,
+		RoutingDelegate: req.RoutingDelegate,
+		RpcType:         rpcTypeToProto(m.Type),
+		Metadata:        m.Metadata,
+	}
+	if !m.Deadline.IsZero() {
+		header.Deadline = m.Deadline.UnixNano()
+	}
+
+	headerBytes, err := header.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := writeChunk(w, headerBytes); err != nil {
+		return err
+	}
+
+	if req.Body != nil {
+		buf := make([]byte, _maxChunkSize)
+		for {
+			n, err := req.Body.Read(buf)
+			if n > 0 {
+				if werr := writeChunk(w, buf[:n]); werr != nil {
+					return werr
+				}
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// A zero-length chunk terminates the body.
+	return writeChunk(w, nil)
+}
+
+func (version1Format) Decode(r io.Reader, tracer opentracing.Tracer) (*Message, error) {
+	br, ok := r.(byteReader)
+	if !ok {
+		br = &oneByteReader{Reader: r}
+	}
+
+	headerLen, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if headerLen > _maxHeaderSize {
+		return nil, fmt.Errorf("serialized request header too large: %v bytes", headerLen)
+	}
+
+	headerBytes := make([]byte, headerLen)
+	if _, err := io.ReadFull(br, headerBytes); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	var header internal.RequestHeader
+	if err := header.Unmarshal(headerBytes); err != nil {
+		return nil, err
+	}
+
+	spanContext, err := spanContextFromBytes(tracer, header.SpanContext)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Message{
+		SpanContext: spanContext,
+		Request: &transport.Request{
+			Caller:          header.Caller,
+			Service:         header.Service,
+			Encoding:        transport.Encoding(header.Encoding),
+			Procedure:       header.Procedure,
+			Headers:         transport.HeadersFromMap(header.Headers),
+			ShardKey:        header.ShardKey,
+			RoutingKey:      header.RoutingKey,
+			RoutingDelegate: header.RoutingDelegate,
+			Body:            &chunkReader{r: br},
+		},
+		Type:     rpcTypeFromProto(header.RpcType),
+		Metadata: header.Metadata,
+	}
+	if header.Deadline != 0 {
+		m.Deadline = time.Unix(0, header.Deadline)
+	}
+	return m, nil
+}
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// oneByteReader adds io.ByteReader to a Reader by reading a single byte at a
+// time. Unlike a bufio.Reader, it never reads past the end of the message, so
+// the Reader may be used for other data afterwards.
+type oneByteReader struct {
+	io.Reader
+
+	buf [1]byte
+}
+
+func (r *oneByteReader) ReadByte() (byte, error) {
+	if _, err := io.ReadFull(r.Reader, r.buf[:]); err != nil {
+		return 0, err
+	}
+	return r.buf[0], nil
+}
+
+func writeChunk(w io.Writer, b []byte) error {
+	var lenBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(lenBuf[:], uint64(len(b)))
+	if _, err := w.Write(lenBuf[:n]); err != nil {
+		return err
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// chunkReader reads a body written by version1Format, stopping after the
+// terminating zero-length chunk.
+type chunkReader struct {
+	r         byteReader
+	remaining uint64
+	done      bool
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for c.remaining == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		n, err := binary.ReadUvarint(c.r)
+		if err != nil {
+			return 0, unexpectedEOF(err)
+		}
+		if n == 0 {
+			c.done = true
+		}
+		c.remaining = n
+	}
+
+	if uint64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.r.Read(p)
+	c.remaining -= uint64(n)
+	if err == io.EOF {
+		// The terminating chunk has not been read yet.
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func rpcTypeToProto(t transport.Type) internal.RPCType {
+	switch t {
+	case transport.Unary:
+		return internal.UNARY
+	case transport.Oneway:
+		return internal.ONEWAY
+	default:
+		return internal.RPC_TYPE_UNSPECIFIED
+	}
+}
+
+func rpcTypeFromProto(t internal.RPCType) transport.Type {
+	switch t {
+	case internal.UNARY:
+		return transport.Unary
+	case internal.ONEWAY:
+		return transport.Oneway
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"io"
+	"sync"
+
+	"go.uber.org/yarpc/compressor"
+	"google.golang.org/grpc/encoding"
+)
+
+var _ encoding.Compressor = grpcCompressor{}
+
+var _registerCompressorsOnce sync.Once
+
+// registerCompressors makes all compressors registered with the compressor
+// package available to gRPC, unless gRPC already has a compressor with the
+// same name.
+//
+// gRPC looks compressors up by name in a registry of its own: clients to
+// compress requests and decompress responses, and servers to decompress
+// requests compressed with any of them and to compress the responses to
+// these requests. The registry is not safe for concurrent use, so this is
+// done once, when the first Transport is built.
+func registerCompressors() {
+	_registerCompressorsOnce.Do(func() {
+		for _, name := range compressor.Names() {
+			if encoding.GetCompressor(name) != nil {
+				continue
+			}
+			if c, ok := compressor.Get(name); ok {
+				encoding.RegisterCompressor(grpcCompressor{c})
+			}
+		}
+	})
+}
+
+// grpcCompressor adapts a compressor.Compressor into an encoding.Compressor.
+type grpcCompressor struct {
+	c compressor.Compressor
+}
+
+func (g grpcCompressor) Name() string {
+	return g.c.Name()
+}
+
+func (g grpcCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return g.c.Compress(w)
+}
+
+func (g grpcCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	cr, err := g.c.Decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	return &closeOnEOFReader{r: cr}, nil
+}
+
+// closeOnEOFReader closes the reader it wraps once it was read to the end.
+// gRPC never closes the readers returned by Decompress, while compressors
+// may need them closed, for example to reuse them.
+type closeOnEOFReader struct {
+	r io.ReadCloser
+}
+
+func (r *closeOnEOFReader) Read(p []byte) (int, error) {
+	if r.r == nil {
+		return 0, io.EOF
+	}
+	n, err := r.r.Read(p)
+	if err == io.EOF {
+		if closeErr := r.r.Close(); closeErr != nil {
+			err = closeErr
+		}
+		r.r = nil
+	}
+	return n, err
+}
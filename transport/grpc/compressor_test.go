@@ -0,0 +1,153 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/compressor"
+)
+
+// Compressors are made available to gRPC when the first Transport is built,
+// so the compressors used by the tests are registered up front.
+var (
+	_countingCompressor        = &countingCompressor{name: "counting"}
+	_countingMinSizeCompressor = &countingCompressor{name: "counting-min-size"}
+)
+
+func init() {
+	compressor.Register(_countingCompressor)
+	compressor.Register(_countingMinSizeCompressor)
+}
+
+// countingCompressor is gzip under another name which counts the bodies it
+// compresses and decompresses.
+type countingCompressor struct {
+	name         string
+	compressed   int32
+	decompressed int32
+}
+
+func (c *countingCompressor) Name() string { return c.name }
+
+func (c *countingCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	atomic.AddInt32(&c.compressed, 1)
+	return compressor.Gzip.Compress(w)
+}
+
+func (c *countingCompressor) Decompress(r io.Reader) (io.ReadCloser, error) {
+	atomic.AddInt32(&c.decompressed, 1)
+	return compressor.Gzip.Decompress(r)
+}
+
+func TestYARPCCompression(t *testing.T) {
+	t.Parallel()
+	value := strings.Repeat("a", 1024)
+
+	tests := []struct {
+		desc            string
+		compressor      *countingCompressor
+		minSize         int
+		wantCompression bool
+	}{
+		{
+			desc:            "compressed",
+			compressor:      _countingCompressor,
+			wantCompression: true,
+		},
+		{
+			desc:       "below min size",
+			compressor: _countingMinSizeCompressor,
+			minSize:    1 << 20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			c := tt.compressor
+			atomic.StoreInt32(&c.compressed, 0)
+			atomic.StoreInt32(&c.decompressed, 0)
+			// The inbound has no compression options: it accepts requests
+			// compressed with any registered compressor.
+			doWithTestEnv(t, nil, nil,
+				[]OutboundOption{OutboundCompressor(c), OutboundCompressionMinSize(tt.minSize)},
+				func(t *testing.T, e *testEnv) {
+					require.NoError(t, e.SetValueYARPC(context.Background(), "foo", value))
+					getValue, err := e.GetValueYARPC(context.Background(), "foo")
+					require.NoError(t, err)
+					assert.Equal(t, value, getValue)
+
+					if tt.wantCompression {
+						// Both requests and both responses.
+						assert.Equal(t, int32(4), atomic.LoadInt32(&c.compressed), "requests and responses should be compressed")
+						assert.Equal(t, int32(4), atomic.LoadInt32(&c.decompressed), "requests and responses should be decompressed")
+					} else {
+						assert.Equal(t, int32(0), atomic.LoadInt32(&c.compressed), "requests and responses should not be compressed")
+					}
+					assert.Equal(t, c.Name(), e.Outbound.Introspect().Compressor)
+				})
+		})
+	}
+}
+
+func TestYARPCUnregisteredCompression(t *testing.T) {
+	t.Parallel()
+	c := &countingCompressor{name: "unregistered"}
+	doWithTestEnv(t, nil, nil, []OutboundOption{OutboundCompressor(c)}, func(t *testing.T, e *testEnv) {
+		err := e.SetValueYARPC(context.Background(), "foo", "bar")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"unregistered"`)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&c.compressed))
+	})
+}
+
+func TestGRPCCompressorRoundTrip(t *testing.T) {
+	g := grpcCompressor{compressor.Gzip}
+	assert.Equal(t, "gzip", g.Name())
+
+	var buf bytes.Buffer
+	w, err := g.Compress(&buf)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r, err := g.Decompress(&buf)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+
+	n, err := r.Read(make([]byte, 1))
+	assert.Equal(t, 0, n)
+	assert.Equal(t, io.EOF, err, "reads after the end should keep returning EOF")
+
+	_, err = g.Decompress(strings.NewReader("not gzip"))
+	assert.Error(t, err)
+}
@@ -23,8 +23,10 @@ package grpc
 import (
 	"fmt"
 	"net"
+	"strings"
 
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/compressor"
 	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/yarpcconfig"
 )
@@ -46,10 +48,12 @@ func TransportSpec(opts ...Option) yarpcconfig.TransportSpec {
 		panic(err.Error())
 	}
 	return yarpcconfig.TransportSpec{
-		Name:               transportName,
-		BuildTransport:     transportSpec.buildTransport,
-		BuildInbound:       transportSpec.buildInbound,
-		BuildUnaryOutbound: transportSpec.buildUnaryOutbound,
+		Name:                transportName,
+		BuildTransport:      transportSpec.buildTransport,
+		BuildInbound:        transportSpec.buildInbound,
+		BuildUnaryOutbound:  transportSpec.buildUnaryOutbound,
+		BuildOnewayOutbound: transportSpec.buildOnewayOutbound,
+		BuildStreamOutbound: transportSpec.buildStreamOutbound,
 	}
 }
 
@@ -65,12 +69,23 @@ func TransportSpec(opts ...Option) yarpcconfig.TransportSpec {
 //
 // All parameters of TransportConfig are optional. This section
 // may be omitted in the transports section.
+//
+// The tls section configures outbounds to connect to peers over TLS. See
+// yarpcconfig.TLS for details.
+//
+//  transports:
+//    grpc:
+//      tls:
+//        caFile: /etc/certs/ca.pem
+//        certFile: /etc/certs/myservice.pem
+//        keyFile: /etc/certs/myservice-key.pem
 type TransportConfig struct {
 	ServerMaxRecvMsgSize int                 `config:"serverMaxRecvMsgSize"`
 	ServerMaxSendMsgSize int                 `config:"serverMaxSendMsgSize"`
 	ClientMaxRecvMsgSize int                 `config:"clientMaxRecvMsgSize"`
 	ClientMaxSendMsgSize int                 `config:"clientMaxSendMsgSize"`
 	Backoff              yarpcconfig.Backoff `config:"backoff"`
+	TLS                  yarpcconfig.TLS     `config:"tls"`
 }
 
 // InboundConfig configures a gRPC Inbound.
@@ -78,9 +93,36 @@ type TransportConfig struct {
 // inbounds:
 //   grpc:
 //     address: ":80"
+//
+// The inbound accepts connections over TLS if the tls section is present.
+// See yarpcconfig.TLS for details.
+//
+// inbounds:
+//   grpc:
+//     address: ":443"
+//     tls:
+//       certFile: /etc/certs/myservice.pem
+//       keyFile: /etc/certs/myservice-key.pem
+//       caFile: /etc/certs/ca.pem
+//       clientAuth: require-and-verify
+//
+// Oneway requests are acknowledged before their handler runs unless
+// onewayAckAfterHandler is set, in which case they are acknowledged once the
+// handler has returned and handler errors are reported to the caller.
+//
+// inbounds:
+//   grpc:
+//     address: ":80"
+//     onewayAckAfterHandler: true
 type InboundConfig struct {
 	// Address to listen on. This field is required.
 	Address string `config:"address,interpolate"`
+	// TLS configuration for the inbound. This field is optional.
+	TLS yarpcconfig.TLS `config:"tls"`
+	// Whether oneway requests are acknowledged only once their handler has
+	// returned. Defaults to false, acknowledging them before the handler
+	// runs.
+	OnewayAckAfterHandler bool `config:"onewayAckAfterHandler"`
 }
 
 // OutboundConfig configures a gRPC Outbound.
@@ -99,11 +141,32 @@ type InboundConfig struct {
 //          peers:
 //            - 127.0.0.1:8080
 //            - 127.0.0.1:8081
+//
+// Unary, oneway, and stream calls to the outbound share a single Outbound
+// and thus a single peer list.
+//
+// Requests may be compressed with one of the compressors registered with the
+// compressor package. Requests smaller than compressionMinSize bytes are sent
+// uncompressed. Responses to compressed requests are compressed with the same
+// compressor.
+//
+//  outbounds:
+//    myservice:
+//      grpc:
+//        address: ":80"
+//        compressor: gzip
+//        compressionMinSize: 1024
 type OutboundConfig struct {
 	yarpcconfig.PeerChooser
 
 	// Address to connect to if no peer options set.
 	Address string `config:"address,interpolate"`
+	// Name of the compressor used for requests. Requests are not compressed
+	// by default.
+	Compressor string `config:"compressor"`
+	// Size in bytes below which requests are not compressed. Defaults to 0,
+	// compressing all requests.
+	CompressionMinSize int `config:"compressionMinSize"`
 }
 
 type transportSpec struct {
@@ -148,6 +211,13 @@ func (t *transportSpec) buildTransport(transportConfig *TransportConfig, _ *yarp
 		return nil, err
 	}
 	options = append(options, BackoffStrategy(backoffStrategy))
+	tlsConfig, err := transportConfig.TLS.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		options = append(options, ClientTLSConfig(tlsConfig))
+	}
 	return newTransport(newTransportOptions(options)), nil
 }
 
@@ -159,29 +229,85 @@ func (t *transportSpec) buildInbound(inboundConfig *InboundConfig, tr transport.
 	if inboundConfig.Address == "" {
 		return nil, newRequiredFieldMissingError("address")
 	}
+	inboundOptions := t.InboundOptions
+	tlsConfig, err := inboundConfig.TLS.ServerConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		inboundOptions = append(inboundOptions, InboundTLSConfig(tlsConfig))
+	}
+	if inboundConfig.OnewayAckAfterHandler {
+		inboundOptions = append(inboundOptions, InboundOnewayAckAfterHandler(true))
+	}
 	listener, err := net.Listen("tcp", inboundConfig.Address)
 	if err != nil {
 		return nil, err
 	}
-	return trans.NewInbound(listener, t.InboundOptions...), nil
+	return trans.NewInbound(listener, inboundOptions...), nil
 }
 
 func (t *transportSpec) buildUnaryOutbound(outboundConfig *OutboundConfig, tr transport.Transport, kit *yarpcconfig.Kit) (transport.UnaryOutbound, error) {
+	return t.buildOutbound(outboundConfig, tr, kit)
+}
+
+func (t *transportSpec) buildOnewayOutbound(outboundConfig *OutboundConfig, tr transport.Transport, kit *yarpcconfig.Kit) (transport.OnewayOutbound, error) {
+	return t.buildOutbound(outboundConfig, tr, kit)
+}
+
+func (t *transportSpec) buildStreamOutbound(outboundConfig *OutboundConfig, tr transport.Transport, kit *yarpcconfig.Kit) (transport.StreamOutbound, error) {
+	return t.buildOutbound(outboundConfig, tr, kit)
+}
+
+// buildOutbound returns the Outbound for the outbound being built, building
+// it only once per Transport so that all RPC types of an outbound share a
+// single peer chooser and its connections.
+func (t *transportSpec) buildOutbound(outboundConfig *OutboundConfig, tr transport.Transport, kit *yarpcconfig.Kit) (*Outbound, error) {
 	trans, ok := tr.(*Transport)
 	if !ok {
 		return nil, newTransportCastError(tr)
 	}
+	var name string
+	if kit != nil {
+		name = kit.OutboundName()
+	}
+	return trans.configuredOutbound(name, func() (*Outbound, error) {
+		return t.newOutbound(outboundConfig, trans, kit)
+	})
+}
+
+func (t *transportSpec) newOutbound(outboundConfig *OutboundConfig, trans *Transport, kit *yarpcconfig.Kit) (*Outbound, error) {
+	outboundOptions := t.OutboundOptions
+	if outboundConfig.Compressor != "" {
+		c, err := getCompressor(outboundConfig.Compressor)
+		if err != nil {
+			return nil, err
+		}
+		outboundOptions = append(
+			outboundOptions,
+			OutboundCompressor(c),
+			OutboundCompressionMinSize(outboundConfig.CompressionMinSize),
+		)
+	}
 	if outboundConfig.Empty() {
 		if outboundConfig.Address == "" {
 			return nil, newRequiredFieldMissingError("address")
 		}
-		return trans.NewSingleOutbound(outboundConfig.Address, t.OutboundOptions...), nil
+		return trans.NewSingleOutbound(outboundConfig.Address, outboundOptions...), nil
 	}
 	chooser, err := outboundConfig.BuildPeerChooser(trans, hostport.Identify, kit)
 	if err != nil {
 		return nil, err
 	}
-	return trans.NewOutbound(chooser, t.OutboundOptions...), nil
+	return trans.NewOutbound(chooser, outboundOptions...), nil
+}
+
+func getCompressor(name string) (compressor.Compressor, error) {
+	c, ok := compressor.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown compressor %q, available compressors: %v", name, strings.Join(compressor.Names(), ", "))
+	}
+	return c, nil
 }
 
 func newTransportCastError(tr transport.Transport) error {
@@ -66,6 +66,18 @@ func TestConfigBuildUnaryOutboundRequiredAddress(t *testing.T) {
 	require.Equal(t, newRequiredFieldMissingError("address"), err)
 }
 
+func TestConfigBuildOnewayOutboundOtherTransport(t *testing.T) {
+	transportSpec := &transportSpec{}
+	_, err := transportSpec.buildOnewayOutbound(&OutboundConfig{}, testTransport{}, nil)
+	require.Equal(t, newTransportCastError(testTransport{}), err)
+}
+
+func TestConfigBuildOnewayOutboundRequiredAddress(t *testing.T) {
+	transportSpec := &transportSpec{}
+	_, err := transportSpec.buildOnewayOutbound(&OutboundConfig{}, NewTransport(), nil)
+	require.Equal(t, newRequiredFieldMissingError("address"), err)
+}
+
 func TestTransportSpecUnknownOption(t *testing.T) {
 	assert.Panics(t, func() { TransportSpec(testOption{}) })
 }
@@ -74,15 +86,18 @@ func TestTransportSpec(t *testing.T) {
 	type attrs map[string]interface{}
 
 	type wantInbound struct {
-		Address              string
-		ServerMaxRecvMsgSize int
-		ServerMaxSendMsgSize int
-		ClientMaxRecvMsgSize int
-		ClientMaxSendMsgSize int
+		Address               string
+		ServerMaxRecvMsgSize  int
+		ServerMaxSendMsgSize  int
+		ClientMaxRecvMsgSize  int
+		ClientMaxSendMsgSize  int
+		OnewayAckAfterHandler bool
 	}
 
 	type wantOutbound struct {
-		Address string
+		Address            string
+		Compressor         string
+		CompressionMinSize int
 	}
 
 	type test struct {
@@ -197,6 +212,59 @@ func TestTransportSpec(t *testing.T) {
 				ClientMaxSendMsgSize: 8192,
 			},
 		},
+		{
+			desc:         "transport TLS config error",
+			transportCfg: attrs{"tls": attrs{"caFile": "/does/not/exist.pem"}},
+			inboundCfg:   attrs{"address": ":54572"},
+			wantErrors:   []string{"failed to read CA bundle"},
+		},
+		{
+			desc:        "inbound oneway ack after handler",
+			inboundCfg:  attrs{"address": ":54576", "onewayAckAfterHandler": true},
+			wantInbound: &wantInbound{Address: ":54576", OnewayAckAfterHandler: true},
+		},
+		{
+			desc: "outbound compressor",
+			outboundCfg: attrs{
+				"myservice": attrs{
+					transportName: attrs{
+						"address":            "localhost:54569",
+						"compressor":         "gzip",
+						"compressionMinSize": 1024,
+					},
+				},
+			},
+			wantOutbounds: map[string]wantOutbound{
+				"myservice": {
+					Address:            "localhost:54569",
+					Compressor:         "gzip",
+					CompressionMinSize: 1024,
+				},
+			},
+		},
+		{
+			desc: "outbound unknown compressor",
+			outboundCfg: attrs{
+				"myservice": attrs{
+					transportName: attrs{
+						"address":    "localhost:54569",
+						"compressor": "lzma",
+					},
+				},
+			},
+			wantErrors: []string{
+				`failed to configure unary outbound for "myservice"`,
+				`unknown compressor "lzma"`,
+			},
+		},
+		{
+			desc: "inbound TLS without certificate",
+			inboundCfg: attrs{
+				"address": ":54573",
+				"tls":     attrs{"caFile": "/etc/certs/ca.pem"},
+			},
+			wantErrors: []string{"a certificate and private key are required for a TLS server"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -256,6 +324,7 @@ func TestTransportSpec(t *testing.T) {
 				} else {
 					assert.Equal(t, defaultClientMaxSendMsgSize, inbound.t.options.clientMaxSendMsgSize)
 				}
+				assert.Equal(t, tt.wantInbound.OnewayAckAfterHandler, inbound.options.onewayAckAfterHandler)
 			} else {
 				assert.Len(t, cfg.Inbounds, 0)
 			}
@@ -264,6 +333,14 @@ func TestTransportSpec(t *testing.T) {
 				require.True(t, ok, "no outbounds for %s", svc)
 				outbound, ok := ob.Unary.(*Outbound)
 				require.True(t, ok, "expected *Outbound, got %T", ob)
+				oneway, ok := ob.Oneway.(*Outbound)
+				require.True(t, ok, "expected oneway *Outbound, got %T", ob.Oneway)
+				stream, ok := ob.Stream.(*Outbound)
+				require.True(t, ok, "expected stream *Outbound, got %T", ob.Stream)
+				assert.True(t, outbound == oneway && outbound == stream,
+					"expected unary, oneway, and stream calls to share an outbound")
+				assert.Equal(t, wantOutbound.Compressor, outbound.Introspect().Compressor)
+				assert.Equal(t, wantOutbound.CompressionMinSize, outbound.options.compressionMinSize)
 				if wantOutbound.Address != "" {
 					single, ok := outbound.peerChooser.(*peer.Single)
 					if !ok {
@@ -281,6 +358,26 @@ func TestTransportSpec(t *testing.T) {
 	}
 }
 
+func TestTransportSpecOutboundsWithSameConfig(t *testing.T) {
+	type attrs map[string]interface{}
+
+	configurator := yarpcconfig.New()
+	require.NoError(t, configurator.RegisterTransport(TransportSpec()))
+
+	cfg, err := configurator.LoadConfig("foo", attrs{
+		"outbounds": attrs{
+			"bar": attrs{transportName: attrs{"address": "localhost:8080"}},
+			"baz": attrs{transportName: attrs{"address": "localhost:8080"}},
+		},
+	})
+	require.NoError(t, err)
+
+	bar := cfg.Outbounds["bar"].Unary.(*Outbound)
+	baz := cfg.Outbounds["baz"].Unary.(*Outbound)
+	assert.True(t, bar == cfg.Outbounds["bar"].Stream.(*Outbound), "expected RPC types of an outbound to share an Outbound")
+	assert.False(t, bar == baz, "expected outbounds with the same config not to share an Outbound")
+}
+
 func mapResolver(m map[string]string) func(string) (string, bool) {
 	return func(k string) (v string, ok bool) {
 		if m != nil {
@@ -19,7 +19,7 @@
 // THE SOFTWARE.
 
 // Package grpc implements a YARPC transport based on the gRPC protocol.
-// The gRPC transport provides support for Unary RPCs only.
+// The gRPC transport provides support for Unary, Oneway and Streaming RPCs.
 //
 // Usage
 //
@@ -47,7 +47,7 @@
 //   dispatcher := yarpc.NewDispatcher(yarpc.Config{
 //     Name: "myclient",
 //     Outbounds: yarpc.Outbounds{
-//       "myservice": {Unary: myserviceOutbound},
+//       "myservice": {Unary: myserviceOutbound, Oneway: myserviceOutbound},
 //     },
 //   })
 //
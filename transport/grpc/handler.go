@@ -21,7 +21,8 @@
 package grpc
 
 import (
-	"io"
+	"bytes"
+	"crypto/x509"
 	"strings"
 	"time"
 
@@ -29,10 +30,13 @@ import (
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/bufferpool"
 	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/yarpc/yarpctls"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	gtransport "google.golang.org/grpc/transport"
 )
@@ -60,6 +64,43 @@ func (h *handler) handle(srv interface{}, serverStream grpc.ServerStream) error
 		return errInvalidGRPCStream
 	}
 
+	transportRequest, err := h.getBasicTransportRequest(ctx, stream.Method())
+	if err != nil {
+		return handlerErrorToGRPCError(err, nil)
+	}
+	ctx = transport.WithPeerCertificate(ctx, peerCertificateFromContext(ctx))
+
+	// Handlers take ownership of the span and finish it.
+	ctx, span := h.extractSpan(ctx, transportRequest, start)
+	handlerSpec, err := h.i.router.Choose(ctx, transportRequest)
+	if err != nil {
+		return finishSpanWithErr(span, err)
+	}
+
+	switch handlerSpec.Type() {
+	case transport.Unary:
+		return h.handleUnary(ctx, transportRequest, serverStream, handlerSpec.Unary(), span)
+	case transport.Oneway:
+		return h.handleOneway(ctx, transportRequest, serverStream, handlerSpec.Oneway(), span)
+	case transport.Streaming:
+		return h.handleStream(ctx, transportRequest, serverStream, handlerSpec.Stream(), span)
+	default:
+		return finishSpanWithErr(
+			span,
+			yarpcerrors.Newf(yarpcerrors.CodeUnimplemented, "transport grpc does not handle %s handlers", handlerSpec.Type().String()),
+		)
+	}
+}
+
+func (h *handler) handleUnary(
+	ctx context.Context,
+	transportRequest *transport.Request,
+	serverStream grpc.ServerStream,
+	unaryHandler transport.UnaryHandler,
+	span opentracing.Span,
+) error {
+	defer span.Finish()
+
 	var requestData []byte
 	if err := serverStream.RecvMsg(&requestData); err != nil {
 		return err
@@ -68,12 +109,13 @@ func (h *handler) handle(srv interface{}, serverStream grpc.ServerStream) error
 	defer bufferpool.Put(requestBuffer)
 	// Buffers are documented to always return a nil error.
 	_, _ = requestBuffer.Write(requestData)
+	transportRequest.Body = requestBuffer
 
 	responseWriter := newResponseWriter()
 	defer responseWriter.Close()
 
-	err := h.handleBeforeErrorConversion(ctx, stream.Method(), requestBuffer, responseWriter, start)
-	err = handlerErrorToGRPCError(err, responseWriter)
+	err := h.callUnary(ctx, transportRequest, unaryHandler, responseWriter)
+	err = handlerErrorToGRPCError(transport.UpdateSpanWithErr(span, err), responseWriter)
 
 	// Send the response attributes back and end the stream.
 	if sendErr := serverStream.SendMsg(responseWriter.Bytes()); sendErr != nil {
@@ -86,18 +128,81 @@ func (h *handler) handle(srv interface{}, serverStream grpc.ServerStream) error
 	return err
 }
 
-func (h *handler) handleBeforeErrorConversion(
+func (h *handler) handleOneway(
 	ctx context.Context,
-	streamMethod string,
-	requestReader io.Reader,
-	responseWriter *responseWriter,
-	start time.Time,
+	transportRequest *transport.Request,
+	serverStream grpc.ServerStream,
+	onewayHandler transport.OnewayHandler,
+	span opentracing.Span,
 ) error {
-	transportRequest, err := h.getTransportRequest(ctx, streamMethod, requestReader)
-	if err != nil {
+	var requestData []byte
+	if err := serverStream.RecvMsg(&requestData); err != nil {
+		span.Finish()
 		return err
 	}
+	// The request buffer must outlive this call since the handler may run in
+	// the background, so it is not taken from the buffer pool.
+	transportRequest.Body = bytes.NewReader(requestData)
+
+	if h.i.options.onewayAckAfterHandler {
+		defer span.Finish()
+		err := transport.DispatchOnewayHandler(ctx, onewayHandler, transportRequest)
+		if err := transport.UpdateSpanWithErr(span, err); err != nil {
+			return handlerErrorToGRPCError(err, nil)
+		}
+		return serverStream.SendMsg([]byte{})
+	}
+
+	// Acknowledge the request before the handler runs; the stream and its
+	// context are finished once we return.
+	if err := serverStream.SendMsg([]byte{}); err != nil {
+		span.Finish()
+		return err
+	}
+
+	ctx = transport.WithPeerCertificate(context.Background(), transport.PeerCertificateFromContext(ctx))
+	ctx = opentracing.ContextWithSpan(ctx, span)
+	go func() {
+		// ensure the span lasts for length of the handler in case of errors
+		defer span.Finish()
+		transport.UpdateSpanWithErr(span, transport.DispatchOnewayHandler(ctx, onewayHandler, transportRequest))
+	}()
+	return nil
+}
+
+func (h *handler) handleStream(
+	ctx context.Context,
+	transportRequest *transport.Request,
+	serverStream grpc.ServerStream,
+	streamHandler transport.StreamHandler,
+	span opentracing.Span,
+) error {
+	defer span.Finish()
+
+	stream, err := transport.NewServerStream(newServerStream(ctx, &transport.StreamRequest{Meta: transportRequest.ToRequestMeta()}, serverStream))
+	if err != nil {
+		return handlerErrorToGRPCError(transport.UpdateSpanWithErr(span, err), nil)
+	}
+
+	responseWriter := newResponseWriter()
+	defer responseWriter.Close()
 
+	err = transport.UpdateSpanWithErr(span, transport.DispatchStreamHandler(streamHandler, stream))
+	err = handlerErrorToGRPCError(err, responseWriter)
+	if responseWriter.md != nil {
+		serverStream.SetTrailer(responseWriter.md)
+	}
+	return err
+}
+
+// finishSpanWithErr records the error on the span, finishes it, and returns
+// the error converted for gRPC.
+func finishSpanWithErr(span opentracing.Span, err error) error {
+	defer span.Finish()
+	return handlerErrorToGRPCError(transport.UpdateSpanWithErr(span, err), nil)
+}
+
+func (h *handler) extractSpan(ctx context.Context, transportRequest *transport.Request, start time.Time) (context.Context, opentracing.Span) {
 	tracer := h.i.t.options.tracer
 	if tracer == nil {
 		tracer = opentracing.GlobalTracer()
@@ -113,14 +218,26 @@ func (h *handler) handleBeforeErrorConversion(
 		TransportName:     transportName,
 		StartTime:         start,
 	}
-	ctx, span := extractOpenTracingSpan.Do(ctx, transportRequest)
-	defer span.Finish()
+	return extractOpenTracingSpan.Do(ctx, transportRequest)
+}
 
-	err = h.call(ctx, transportRequest, responseWriter)
-	return transport.UpdateSpanWithErr(span, err)
+// peerCertificateFromContext returns the verified certificate of the client
+// if the request was received over TLS.
+func peerCertificateFromContext(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil
+	}
+	return yarpctls.VerifiedPeerCertificate(&tlsInfo.State)
 }
 
-func (h *handler) getTransportRequest(ctx context.Context, streamMethod string, requestReader io.Reader) (*transport.Request, error) {
+// getBasicTransportRequest builds a transport.Request from the metadata of
+// the incoming stream. The body of the request is left unset.
+func (h *handler) getBasicTransportRequest(ctx context.Context, streamMethod string) (*transport.Request, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if md == nil || !ok {
 		return nil, yarpcerrors.Newf(yarpcerrors.CodeInternal, "cannot get metadata from ctx: %v", ctx)
@@ -129,7 +246,6 @@ func (h *handler) getTransportRequest(ctx context.Context, streamMethod string,
 	if err != nil {
 		return nil, err
 	}
-	transportRequest.Body = requestReader
 	procedure, err := procedureFromStreamMethod(streamMethod)
 	if err != nil {
 		return nil, err
@@ -158,19 +274,6 @@ func procedureFromStreamMethod(streamMethod string) (string, error) {
 	return procedureToName(service, method)
 }
 
-func (h *handler) call(ctx context.Context, transportRequest *transport.Request, responseWriter *responseWriter) error {
-	handlerSpec, err := h.i.router.Choose(ctx, transportRequest)
-	if err != nil {
-		return err
-	}
-	switch handlerSpec.Type() {
-	case transport.Unary:
-		return h.callUnary(ctx, transportRequest, handlerSpec.Unary(), responseWriter)
-	default:
-		return yarpcerrors.Newf(yarpcerrors.CodeUnimplemented, "transport grpc does not handle %s handlers", handlerSpec.Type().String())
-	}
-}
-
 func (h *handler) callUnary(ctx context.Context, transportRequest *transport.Request, unaryHandler transport.UnaryHandler, responseWriter *responseWriter) error {
 	if err := transport.ValidateUnaryContext(ctx); err != nil {
 		return err
@@ -197,7 +300,9 @@ func handlerErrorToGRPCError(err error, responseWriter *responseWriter) error {
 	message := yarpcStatus.Message()
 	// if the yarpc error has a name, set the header
 	if name != "" {
-		responseWriter.AddSystemHeader(ErrorNameHeader, name)
+		if responseWriter != nil {
+			responseWriter.AddSystemHeader(ErrorNameHeader, name)
+		}
 		if message == "" {
 			// if the message is empty, set the message to the name for grpc compatibility
 			message = name
@@ -0,0 +1,138 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+func doWithOnewayTestEnv(t *testing.T, handler transport.OnewayHandler, inboundOptions []InboundOption, f func(*testing.T, *Outbound)) {
+	trans := NewTransport()
+	require.NoError(t, trans.Start())
+	defer func() { assert.NoError(t, trans.Stop()) }()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	inbound := trans.NewInbound(listener, inboundOptions...)
+	inbound.SetRouter(newTestRouter([]transport.Procedure{{
+		Name:        "test::oneway",
+		HandlerSpec: transport.NewOnewayHandlerSpec(handler),
+	}}))
+	require.NoError(t, inbound.Start())
+	defer func() { assert.NoError(t, inbound.Stop()) }()
+
+	outbound := trans.NewSingleOutbound(listener.Addr().String())
+	require.NoError(t, outbound.Start())
+	defer func() { assert.NoError(t, outbound.Stop()) }()
+
+	f(t, outbound)
+}
+
+func callOneway(ctx context.Context, o *Outbound) (transport.Ack, error) {
+	return o.CallOneway(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  transport.Encoding("raw"),
+		Procedure: "test::oneway",
+		Body:      bytes.NewBufferString("hello"),
+	})
+}
+
+func TestOnewayAckBeforeHandler(t *testing.T) {
+	release := make(chan struct{})
+	handled := make(chan struct{})
+	handler := onewayHandlerFunc(func(context.Context, *transport.Request) error {
+		defer close(handled)
+		<-release
+		return yarpcerrors.Newf(yarpcerrors.CodeInternal, "handler failed")
+	})
+
+	doWithOnewayTestEnv(t, handler, nil, func(t *testing.T, o *Outbound) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		ack, err := callOneway(ctx, o)
+		require.NoError(t, err, "handler errors must not be reported")
+		assert.NotNil(t, ack)
+
+		// The request was acknowledged while the handler is still blocked.
+		close(release)
+		select {
+		case <-handled:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the handler")
+		}
+	})
+}
+
+func TestOnewayAckAfterHandler(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		handled := false
+		handler := onewayHandlerFunc(func(ctx context.Context, req *transport.Request) error {
+			_, ok := ctx.Deadline()
+			assert.True(t, ok, "handler must run with the deadline of the request")
+			handled = true
+			return nil
+		})
+
+		doWithOnewayTestEnv(t, handler, []InboundOption{InboundOnewayAckAfterHandler(true)}, func(t *testing.T, o *Outbound) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			ack, err := callOneway(ctx, o)
+			require.NoError(t, err)
+			assert.NotNil(t, ack)
+			assert.True(t, handled, "request must be acknowledged after the handler returned")
+		})
+	})
+
+	t.Run("error", func(t *testing.T) {
+		handler := onewayHandlerFunc(func(context.Context, *transport.Request) error {
+			return yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted, "queue full")
+		})
+
+		doWithOnewayTestEnv(t, handler, []InboundOption{InboundOnewayAckAfterHandler(true)}, func(t *testing.T, o *Outbound) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			_, err := callOneway(ctx, o)
+			require.Error(t, err)
+			assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code())
+			assert.Equal(t, "queue full", yarpcerrors.FromError(err).Message())
+		})
+	})
+}
@@ -25,9 +25,12 @@ import (
 	"sync"
 
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/certmetrics"
+	"go.uber.org/yarpc/internal/sharedmetrics"
 	"go.uber.org/yarpc/pkg/lifecycle"
 	"go.uber.org/yarpc/yarpcerrors"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 var (
@@ -45,15 +48,20 @@ type Inbound struct {
 	options  *inboundOptions
 	router   transport.Router
 	server   *grpc.Server
+	certs    *certmetrics.Observer
 }
 
 // newInbound returns a new Inbound for the given listener.
 func newInbound(t *Transport, listener net.Listener, options ...InboundOption) *Inbound {
+	inboundOptions := newInboundOptions(options)
+	certs := certmetrics.NewObserver()
+	inboundOptions.tlsConfig = certs.Observe(inboundOptions.tlsConfig)
 	return &Inbound{
 		once:     lifecycle.NewOnce(),
 		t:        t,
 		listener: listener,
-		options:  newInboundOptions(options),
+		options:  inboundOptions,
+		certs:    certs,
 	}
 }
 
@@ -79,6 +87,12 @@ func (i *Inbound) SetRouter(router transport.Router) {
 	i.router = router
 }
 
+// Metrics returns the metrics of the inbound, which Dispatchers using it
+// report.
+func (i *Inbound) Metrics() []*sharedmetrics.Set {
+	return i.certs.Metrics()
+}
+
 // Transports implements transport.Inbound#Transports.
 func (i *Inbound) Transports() []transport.Transport {
 	return []transport.Transport{i.t}
@@ -93,12 +107,16 @@ func (i *Inbound) start() error {
 
 	handler := newHandler(i)
 
-	server := grpc.NewServer(
+	serverOptions := []grpc.ServerOption{
 		grpc.CustomCodec(customCodec{}),
 		grpc.UnknownServiceHandler(handler.handle),
 		grpc.MaxRecvMsgSize(i.t.options.serverMaxRecvMsgSize),
 		grpc.MaxSendMsgSize(i.t.options.serverMaxSendMsgSize),
-	)
+	}
+	if i.options.tlsConfig != nil {
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(i.options.tlsConfig)))
+	}
+	server := grpc.NewServer(serverOptions...)
 
 	go func() {
 		// TODO there should be some mechanism to block here
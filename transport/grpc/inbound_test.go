@@ -21,11 +21,24 @@
 package grpc
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
 	"net"
+	"os"
 	"testing"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/internal/tlstest"
+	"go.uber.org/yarpc/yarpctls"
 )
 
 func TestInboundMechanics(t *testing.T) {
@@ -42,3 +55,70 @@ func TestInboundMechanics(t *testing.T) {
 	assert.True(t, inbound.IsRunning())
 	assert.NoError(t, inbound.Stop())
 }
+
+func TestInboundPeerCertificate(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	dir, err := ioutil.TempDir("", "yarpcgrpc")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := tlstest.WriteFiles(t, dir)
+	serverConfig, err := yarpctls.ServerConfig(
+		yarpctls.CertificateFiles(files.ServerCertFile, files.ServerKeyFile),
+		yarpctls.CAFile(files.CAFile),
+		yarpctls.ClientAuth(tls.RequireAndVerifyClientCert),
+	)
+	require.NoError(t, err)
+	clientConfig, err := yarpctls.ClientConfig(
+		yarpctls.CertificateFiles(files.ClientCertFile, files.ClientKeyFile),
+		yarpctls.CAFile(files.CAFile),
+	)
+	require.NoError(t, err)
+
+	trans := NewTransport(ClientTLSConfig(clientConfig))
+	require.NoError(t, trans.Start())
+	defer trans.Stop()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	var peerCert *x509.Certificate
+	handler := transporttest.NewMockUnaryHandler(mockCtrl)
+	handler.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).
+		Do(func(ctx context.Context, _ *transport.Request, _ transport.ResponseWriter) {
+			peerCert = transport.PeerCertificateFromContext(ctx)
+		}).
+		Return(nil)
+
+	inbound := trans.NewInbound(listener, InboundTLSConfig(serverConfig))
+	inbound.SetRouter(newTestRouter([]transport.Procedure{
+		{
+			Name:        "hello",
+			Service:     "bar",
+			HandlerSpec: transport.NewUnaryHandlerSpec(handler),
+		},
+	}))
+	require.NoError(t, inbound.Start())
+	defer inbound.Stop()
+
+	outbound := trans.NewSingleOutbound(listener.Addr().String())
+	require.NoError(t, outbound.Start())
+	defer outbound.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+	defer cancel()
+	res, err := outbound.Call(ctx, &transport.Request{
+		Caller:    "foo",
+		Service:   "bar",
+		Procedure: "hello",
+		Encoding:  raw.Encoding,
+		Body:      bytes.NewReader([]byte("derp")),
+	})
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	require.NotNil(t, peerCert, "peer certificate must be available to the handler")
+	assert.Equal(t, tlstest.ClientCommonName, peerCert.Subject.CommonName)
+}
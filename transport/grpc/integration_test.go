@@ -23,9 +23,12 @@ package grpc
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
+	"io/ioutil"
 	"math"
 	"net"
+	"os"
 	"strings"
 	"testing"
 
@@ -40,8 +43,10 @@ import (
 	"go.uber.org/yarpc/internal/examples/protobuf/examplepb"
 	"go.uber.org/yarpc/internal/grpcctx"
 	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/internal/tlstest"
 	"go.uber.org/yarpc/pkg/procedure"
 	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/yarpc/yarpctls"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -179,6 +184,37 @@ func TestApplicationErrorPropagation(t *testing.T) {
 	})
 }
 
+func TestYARPCMutualTLS(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "yarpcgrpc")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := tlstest.WriteFiles(t, dir)
+	serverConfig, err := yarpctls.ServerConfig(
+		yarpctls.CertificateFiles(files.ServerCertFile, files.ServerKeyFile),
+		yarpctls.CAFile(files.CAFile),
+		yarpctls.ClientAuth(tls.RequireAndVerifyClientCert),
+	)
+	require.NoError(t, err)
+	clientConfig, err := yarpctls.ClientConfig(
+		yarpctls.CertificateFiles(files.ClientCertFile, files.ClientKeyFile),
+		yarpctls.CAFile(files.CAFile),
+	)
+	require.NoError(t, err)
+
+	doWithTestEnv(t, []TransportOption{
+		ClientTLSConfig(clientConfig),
+	}, []InboundOption{
+		InboundTLSConfig(serverConfig),
+	}, nil, func(t *testing.T, e *testEnv) {
+		assert.NoError(t, e.SetValueYARPC(context.Background(), "foo", "bar"))
+		value, err := e.GetValueYARPC(context.Background(), "foo")
+		assert.NoError(t, err)
+		assert.Equal(t, "bar", value)
+	})
+}
+
 func doWithTestEnv(t *testing.T, transportOptions []TransportOption, inboundOptions []InboundOption, outboundOptions []OutboundOption, f func(*testing.T, *testEnv)) {
 	testEnv, err := newTestEnv(transportOptions, inboundOptions, outboundOptions)
 	require.NoError(t, err)
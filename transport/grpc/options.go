@@ -21,10 +21,12 @@
 package grpc
 
 import (
+	"crypto/tls"
 	"math"
 
 	"github.com/opentracing/opentracing-go"
 	"go.uber.org/yarpc/api/backoff"
+	"go.uber.org/yarpc/compressor"
 	intbackoff "go.uber.org/yarpc/internal/backoff"
 )
 
@@ -109,16 +111,75 @@ func ClientMaxSendMsgSize(clientMaxSendMsgSize int) TransportOption {
 	}
 }
 
+// ClientTLSConfig specifies the TLS configuration used to connect to peers.
+// By default, connections to peers are not encrypted.
+//
+// Use the yarpctls package to build the configuration.
+func ClientTLSConfig(config *tls.Config) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.clientTLSConfig = config
+	}
+}
+
 // InboundOption is an option for an inbound.
 type InboundOption func(*inboundOptions)
 
 func (InboundOption) grpcOption() {}
 
+// InboundTLSConfig specifies that the inbound should only accept
+// connections over TLS, using the given configuration.
+//
+// Use the yarpctls package to build the configuration. If the configuration
+// verifies client certificates, the verified certificate of the caller is
+// available to handlers with yarpc.CallFromContext.
+func InboundTLSConfig(config *tls.Config) InboundOption {
+	return func(inboundOptions *inboundOptions) {
+		inboundOptions.tlsConfig = config
+	}
+}
+
+// InboundOnewayAckAfterHandler specifies whether oneway requests are
+// acknowledged only once their handler has returned. The handler then runs
+// with the context of the request, including its deadline, and errors
+// returned by the handler are reported to the caller.
+//
+// By default, oneway requests are acknowledged as soon as the inbound has
+// read them, before the handler runs.
+func InboundOnewayAckAfterHandler(ackAfterHandler bool) InboundOption {
+	return func(inboundOptions *inboundOptions) {
+		inboundOptions.onewayAckAfterHandler = ackAfterHandler
+	}
+}
+
 // OutboundOption is an option for an outbound.
 type OutboundOption func(*outboundOptions)
 
 func (OutboundOption) grpcOption() {}
 
+// OutboundCompressor specifies that requests made through the outbound are
+// compressed with the given compressor. Responses are compressed by the
+// server with the same compressor.
+//
+// gRPC looks compressors up by name, so the compressor must be registered
+// with the compressor package; calls fail otherwise. Servers accept requests
+// compressed with any registered compressor.
+func OutboundCompressor(c compressor.Compressor) OutboundOption {
+	return func(outboundOptions *outboundOptions) {
+		outboundOptions.compressor = c
+	}
+}
+
+// OutboundCompressionMinSize specifies the size in bytes below which
+// requests are sent uncompressed, even if the outbound has a compressor.
+// Streaming requests are always compressed.
+//
+// The default is 0, compressing all requests.
+func OutboundCompressionMinSize(size int) OutboundOption {
+	return func(outboundOptions *outboundOptions) {
+		outboundOptions.compressionMinSize = size
+	}
+}
+
 type transportOptions struct {
 	backoffStrategy      backoff.Strategy
 	tracer               opentracing.Tracer
@@ -126,6 +187,7 @@ type transportOptions struct {
 	serverMaxSendMsgSize int
 	clientMaxRecvMsgSize int
 	clientMaxSendMsgSize int
+	clientTLSConfig      *tls.Config
 }
 
 func newTransportOptions(options []TransportOption) *transportOptions {
@@ -142,7 +204,10 @@ func newTransportOptions(options []TransportOption) *transportOptions {
 	return transportOptions
 }
 
-type inboundOptions struct{}
+type inboundOptions struct {
+	tlsConfig             *tls.Config
+	onewayAckAfterHandler bool
+}
 
 func newInboundOptions(options []InboundOption) *inboundOptions {
 	inboundOptions := &inboundOptions{}
@@ -152,7 +217,10 @@ func newInboundOptions(options []InboundOption) *inboundOptions {
 	return inboundOptions
 }
 
-type outboundOptions struct{}
+type outboundOptions struct {
+	compressor         compressor.Compressor
+	compressionMinSize int
+}
 
 func newOutboundOptions(options []OutboundOption) *outboundOptions {
 	outboundOptions := &outboundOptions{}
@@ -32,7 +32,9 @@ import (
 	"go.uber.org/yarpc"
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/compressor"
 	"go.uber.org/yarpc/internal/bufferpool"
+	"go.uber.org/yarpc/internal/introspection"
 	peerchooser "go.uber.org/yarpc/peer"
 	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/pkg/lifecycle"
@@ -46,9 +48,18 @@ import (
 // http://www.grpc.io/docs/guides/wire.html#user-agents
 const UserAgent = "yarpc-go/" + yarpc.Version
 
-var _ transport.UnaryOutbound = (*Outbound)(nil)
+var (
+	_ transport.UnaryOutbound  = (*Outbound)(nil)
+	_ transport.OnewayOutbound = (*Outbound)(nil)
+	_ transport.StreamOutbound = (*Outbound)(nil)
 
-// Outbound is a transport.UnaryOutbound.
+	_ introspection.IntrospectableOutbound = (*Outbound)(nil)
+
+	_bidirectionalStreamDesc = &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}
+)
+
+// Outbound is a transport.UnaryOutbound, transport.OnewayOutbound and
+// transport.StreamOutbound.
 type Outbound struct {
 	once        *lifecycle.Once
 	lock        sync.Mutex
@@ -116,6 +127,27 @@ func (o *Outbound) Call(ctx context.Context, request *transport.Request) (*trans
 	}, invokeErrorToYARPCError(invokeErr, responseMD)
 }
 
+// CallOneway implements transport.OnewayOutbound#CallOneway.
+//
+// The request is sent as a regular gRPC unary call with an empty response.
+// The returned Ack indicates that the server received the request; it does
+// not imply that the handler has finished executing unless the inbound
+// acknowledges oneway requests after their handler. See
+// InboundOnewayAckAfterHandler.
+func (o *Outbound) CallOneway(ctx context.Context, request *transport.Request) (transport.Ack, error) {
+	if err := o.once.WaitUntilRunning(ctx); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+
+	var responseBody []byte
+	var responseMD metadata.MD
+	if err := o.invoke(ctx, request, &responseBody, &responseMD, start); err != nil {
+		return nil, invokeErrorToYARPCError(err, responseMD)
+	}
+	return time.Now(), nil
+}
+
 func (o *Outbound) invoke(
 	ctx context.Context,
 	request *transport.Request,
@@ -141,6 +173,9 @@ func (o *Outbound) invoke(
 	if responseMD != nil {
 		callOptions = []grpc.CallOption{grpc.Trailer(responseMD)}
 	}
+	if c := o.compressorFor(requestBuffer.Len()); c != nil {
+		callOptions = append(callOptions, grpc.UseCompressor(c.Name()))
+	}
 	apiPeer, onFinish, err := o.peerChooser.Choose(ctx, request)
 	defer func() {
 		if onFinish != nil {
@@ -187,6 +222,121 @@ func (o *Outbound) invoke(
 	)
 }
 
+// CallStream implements transport.StreamOutbound#CallStream.
+//
+// The stream is bound to the given context: the stream is terminated and
+// released when the context is canceled or its deadline expires, even if it
+// was not read to the end.
+func (o *Outbound) CallStream(ctx context.Context, request *transport.StreamRequest) (*transport.ClientStream, error) {
+	if err := o.once.WaitUntilRunning(ctx); err != nil {
+		return nil, err
+	}
+	return o.stream(ctx, request, time.Now())
+}
+
+func (o *Outbound) stream(
+	ctx context.Context,
+	request *transport.StreamRequest,
+	start time.Time,
+) (_ *transport.ClientStream, retErr error) {
+	treq := request.Meta.ToRequest()
+	md, err := transportRequestToMetadata(treq)
+	if err != nil {
+		return nil, err
+	}
+	fullMethod, err := procedureNameToFullMethod(treq.Procedure)
+	if err != nil {
+		return nil, err
+	}
+
+	apiPeer, onFinish, err := o.peerChooser.Choose(ctx, treq)
+	if err != nil {
+		if onFinish != nil {
+			onFinish(err)
+		}
+		return nil, err
+	}
+	grpcPeer, ok := apiPeer.(*grpcPeer)
+	if !ok {
+		err := peer.ErrInvalidPeerConversion{
+			Peer:         apiPeer,
+			ExpectedType: "*grpcPeer",
+		}
+		onFinish(err)
+		return nil, err
+	}
+
+	tracer := o.t.options.tracer
+	if tracer == nil {
+		tracer = opentracing.GlobalTracer()
+	}
+	createOpenTracingSpan := &transport.CreateOpenTracingSpan{
+		Tracer:        tracer,
+		TransportName: transportName,
+		StartTime:     start,
+	}
+	_, span := createOpenTracingSpan.Do(ctx, treq)
+	if err := tracer.Inject(span.Context(), opentracing.HTTPHeaders, mdReadWriter(md)); err != nil {
+		span.Finish()
+		onFinish(err)
+		return nil, err
+	}
+
+	// The stream gets a context of its own which is canceled once the stream
+	// terminates, releasing it even if the caller's context lives on.
+	streamCtx, cancel := context.WithCancel(opentracing.ContextWithSpan(ctx, span))
+	streamCtx = metadata.NewOutgoingContext(streamCtx, md)
+	var callOptions []grpc.CallOption
+	if o.options.compressor != nil {
+		callOptions = append(callOptions, grpc.UseCompressor(o.options.compressor.Name()))
+	}
+	grpcStream, err := grpc.NewClientStream(streamCtx, _bidirectionalStreamDesc, grpcPeer.clientConn, fullMethod, callOptions...)
+	if err != nil {
+		err = invokeErrorToYARPCError(err, nil)
+		_ = transport.UpdateSpanWithErr(span, err)
+		span.Finish()
+		onFinish(err)
+		cancel()
+		return nil, err
+	}
+	return transport.NewClientStream(newClientStream(streamCtx, cancel, request, grpcStream, span, onFinish))
+}
+
+// compressorFor returns the compressor for a request body of the given size,
+// or nil if it should not be compressed.
+func (o *Outbound) compressorFor(size int) compressor.Compressor {
+	if size < o.options.compressionMinSize {
+		return nil
+	}
+	return o.options.compressor
+}
+
+// Introspect returns basic status about this outbound.
+func (o *Outbound) Introspect() introspection.OutboundStatus {
+	state := "Stopped"
+	if o.IsRunning() {
+		state = "Running"
+	}
+	var chooser introspection.ChooserStatus
+	if i, ok := o.peerChooser.(introspection.IntrospectableChooser); ok {
+		chooser = i.Introspect()
+	} else {
+		chooser = introspection.ChooserStatus{
+			Name: "Introspection not available",
+		}
+	}
+	var compressorName string
+	if o.options.compressor != nil {
+		compressorName = o.options.compressor.Name()
+	}
+	return introspection.OutboundStatus{
+		Transport:  transportName,
+		State:      state,
+		Chooser:    chooser,
+		Compressor: compressorName,
+	}
+}
+
 func metadataToIsApplicationError(responseMD metadata.MD) bool {
 	if responseMD == nil {
 		return false
@@ -29,6 +29,7 @@ import (
 	"go.uber.org/yarpc/yarpcerrors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
 )
 
 type grpcPeer struct {
@@ -44,9 +45,13 @@ type grpcPeer struct {
 }
 
 func newPeer(address string, t *Transport) (*grpcPeer, error) {
+	securityOption := grpc.WithInsecure()
+	if t.options.clientTLSConfig != nil {
+		securityOption = grpc.WithTransportCredentials(credentials.NewTLS(t.options.clientTLSConfig))
+	}
 	clientConn, err := grpc.Dial(
 		address,
-		grpc.WithInsecure(),
+		securityOption,
 		grpc.WithCodec(customCodec{}),
 		grpc.WithUserAgent(UserAgent),
 		grpc.WithDefaultCallOptions(
@@ -0,0 +1,193 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/yarpc/api/transport"
+	"google.golang.org/grpc"
+)
+
+var (
+	_ transport.Stream       = (*serverStream)(nil)
+	_ transport.StreamCloser = (*clientStream)(nil)
+)
+
+// serverStream adapts a grpc.ServerStream into a transport.Stream.
+type serverStream struct {
+	ctx    context.Context
+	req    *transport.StreamRequest
+	stream grpc.ServerStream
+}
+
+func newServerStream(ctx context.Context, req *transport.StreamRequest, stream grpc.ServerStream) *serverStream {
+	return &serverStream{
+		ctx:    ctx,
+		req:    req,
+		stream: stream,
+	}
+}
+
+func (ss *serverStream) Context() context.Context {
+	return ss.ctx
+}
+
+func (ss *serverStream) Request() *transport.StreamRequest {
+	return ss.req
+}
+
+func (ss *serverStream) SendMessage(_ context.Context, m *transport.StreamMessage) error {
+	msg, err := readStreamMessage(m)
+	if err != nil {
+		return err
+	}
+	return ss.stream.SendMsg(msg)
+}
+
+func (ss *serverStream) ReceiveMessage(_ context.Context) (*transport.StreamMessage, error) {
+	var msg []byte
+	if err := ss.stream.RecvMsg(&msg); err != nil {
+		return nil, err
+	}
+	return newStreamMessage(msg), nil
+}
+
+// clientStream adapts a grpc.ClientStream into a transport.StreamCloser.
+//
+// The span and the peer's onFinish callback are completed once the stream
+// terminates, that is, once ReceiveMessage returns an error (including
+// io.EOF), or once the context of the stream is done.
+type clientStream struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	req      *transport.StreamRequest
+	stream   grpc.ClientStream
+	span     opentracing.Span
+	onFinish func(error)
+
+	finishOnce sync.Once
+}
+
+func newClientStream(
+	ctx context.Context,
+	cancel context.CancelFunc,
+	req *transport.StreamRequest,
+	stream grpc.ClientStream,
+	span opentracing.Span,
+	onFinish func(error),
+) *clientStream {
+	cs := &clientStream{
+		ctx:      ctx,
+		cancel:   cancel,
+		req:      req,
+		stream:   stream,
+		span:     span,
+		onFinish: onFinish,
+	}
+	go cs.watch()
+	return cs
+}
+
+// watch finishes the stream once its context is done, so that streams
+// abandoned before they are read to the end are released when the caller
+// cancels their context.
+func (cs *clientStream) watch() {
+	<-cs.ctx.Done()
+	cs.finish(cs.ctx.Err())
+}
+
+func (cs *clientStream) Context() context.Context {
+	return cs.ctx
+}
+
+func (cs *clientStream) Request() *transport.StreamRequest {
+	return cs.req
+}
+
+func (cs *clientStream) SendMessage(_ context.Context, m *transport.StreamMessage) error {
+	msg, err := readStreamMessage(m)
+	if err != nil {
+		return err
+	}
+	if err := cs.stream.SendMsg(msg); err != nil {
+		if err == io.EOF {
+			// The stream was terminated by the server; the actual status is
+			// reported through ReceiveMessage.
+			return err
+		}
+		err = invokeErrorToYARPCError(err, cs.stream.Trailer())
+		cs.finish(err)
+		return err
+	}
+	return nil
+}
+
+func (cs *clientStream) ReceiveMessage(_ context.Context) (*transport.StreamMessage, error) {
+	var msg []byte
+	if err := cs.stream.RecvMsg(&msg); err != nil {
+		if err == io.EOF {
+			cs.finish(nil)
+			return nil, err
+		}
+		err = invokeErrorToYARPCError(err, cs.stream.Trailer())
+		cs.finish(err)
+		return nil, err
+	}
+	return newStreamMessage(msg), nil
+}
+
+// Close closes the sending side of the stream. Messages sent by the server
+// may still be read with ReceiveMessage until it returns io.EOF.
+//
+// The stream is released once ReceiveMessage returns an error. Callers which
+// stop reading before that must cancel the context of the stream instead.
+func (cs *clientStream) Close(_ context.Context) error {
+	return cs.stream.CloseSend()
+}
+
+func (cs *clientStream) finish(err error) {
+	cs.finishOnce.Do(func() {
+		_ = transport.UpdateSpanWithErr(cs.span, err)
+		cs.span.Finish()
+		if cs.onFinish != nil {
+			cs.onFinish(err)
+		}
+		cs.cancel()
+	})
+}
+
+func readStreamMessage(m *transport.StreamMessage) ([]byte, error) {
+	if m == nil || m.Body == nil {
+		return nil, nil
+	}
+	defer m.Body.Close()
+	return ioutil.ReadAll(m.Body)
+}
+
+func newStreamMessage(msg []byte) *transport.StreamMessage {
+	return &transport.StreamMessage{Body: ioutil.NopCloser(bytes.NewReader(msg))}
+}
@@ -0,0 +1,271 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	peerchooser "go.uber.org/yarpc/peer"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type streamHandlerFunc func(*transport.ServerStream) error
+
+func (f streamHandlerFunc) HandleStream(s *transport.ServerStream) error { return f(s) }
+
+// finishChooser wraps a peer.Chooser and reports the error passed to the
+// onFinish callback of every chosen peer.
+type finishChooser struct {
+	peer.Chooser
+
+	finished chan error
+}
+
+func (c *finishChooser) Choose(ctx context.Context, req *transport.Request) (peer.Peer, func(error), error) {
+	p, onFinish, err := c.Chooser.Choose(ctx, req)
+	if err != nil {
+		return p, onFinish, err
+	}
+	return p, func(err error) {
+		onFinish(err)
+		c.finished <- err
+	}, nil
+}
+
+type streamTestEnv struct {
+	Outbound *Outbound
+	Tracer   *mocktracer.MockTracer
+	Finished chan error
+}
+
+func doWithStreamTestEnv(t *testing.T, handler transport.StreamHandler, f func(*testing.T, *streamTestEnv)) {
+	tracer := mocktracer.New()
+	trans := NewTransport(Tracer(tracer))
+	require.NoError(t, trans.Start())
+	defer func() { assert.NoError(t, trans.Stop()) }()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	inbound := trans.NewInbound(listener)
+	inbound.SetRouter(newTestRouter([]transport.Procedure{{
+		Name:        "test::stream",
+		HandlerSpec: transport.NewStreamHandlerSpec(handler),
+	}}))
+	require.NoError(t, inbound.Start())
+	defer func() { assert.NoError(t, inbound.Stop()) }()
+
+	chooser := &finishChooser{
+		Chooser:  peerchooser.NewSingle(hostport.PeerIdentifier(listener.Addr().String()), trans),
+		finished: make(chan error, 1),
+	}
+	outbound := trans.NewOutbound(chooser)
+	require.NoError(t, outbound.Start())
+	defer func() { assert.NoError(t, outbound.Stop()) }()
+
+	f(t, &streamTestEnv{Outbound: outbound, Tracer: tracer, Finished: chooser.finished})
+}
+
+func (e *streamTestEnv) CallStream(ctx context.Context) (*transport.ClientStream, error) {
+	return e.Outbound.CallStream(ctx, &transport.StreamRequest{
+		Meta: &transport.RequestMeta{
+			Caller:    "caller",
+			Service:   "service",
+			Encoding:  transport.Encoding("raw"),
+			Procedure: "test::stream",
+		},
+	})
+}
+
+// WaitFinished waits until the stream has been released and returns the
+// error it finished with.
+func (e *streamTestEnv) WaitFinished(t *testing.T) error {
+	select {
+	case err := <-e.Finished:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the stream to finish")
+		return nil
+	}
+}
+
+func streamMessage(s string) *transport.StreamMessage {
+	return &transport.StreamMessage{Body: ioutil.NopCloser(bytes.NewBufferString(s))}
+}
+
+func readStreamMessageString(t *testing.T, m *transport.StreamMessage) string {
+	body, err := ioutil.ReadAll(m.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	echo := streamHandlerFunc(func(s *transport.ServerStream) error {
+		assert.Equal(t, "caller", s.Request().Meta.Caller)
+		for {
+			msg, err := s.ReceiveMessage(s.Context())
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := s.SendMessage(s.Context(), msg); err != nil {
+				return err
+			}
+		}
+	})
+
+	doWithStreamTestEnv(t, echo, func(t *testing.T, e *streamTestEnv) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		stream, err := e.CallStream(ctx)
+		require.NoError(t, err)
+
+		for _, s := range []string{"hello", "world"} {
+			require.NoError(t, stream.SendMessage(ctx, streamMessage(s)))
+			msg, err := stream.ReceiveMessage(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, s, readStreamMessageString(t, msg))
+		}
+
+		require.NoError(t, stream.Close(ctx))
+		_, err = stream.ReceiveMessage(ctx)
+		assert.Equal(t, io.EOF, err)
+
+		assert.NoError(t, e.WaitFinished(t))
+		spans := e.Tracer.FinishedSpans()
+		require.Len(t, spans, 2, "expected client and server spans")
+		for _, span := range spans {
+			assert.Nil(t, span.Tag("error"), "span must not be marked as failed")
+		}
+	})
+}
+
+func TestStreamErrorStatus(t *testing.T) {
+	failing := streamHandlerFunc(func(s *transport.ServerStream) error {
+		return yarpcerrors.Newf(yarpcerrors.CodeNotFound, "no such stream")
+	})
+
+	doWithStreamTestEnv(t, failing, func(t *testing.T, e *streamTestEnv) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		stream, err := e.CallStream(ctx)
+		require.NoError(t, err)
+
+		_, err = stream.ReceiveMessage(ctx)
+		require.Error(t, err)
+		assert.Equal(t, yarpcerrors.CodeNotFound, yarpcerrors.FromError(err).Code())
+		assert.Equal(t, "no such stream", yarpcerrors.FromError(err).Message())
+
+		assert.Equal(t, err, e.WaitFinished(t), "peer must be released with the stream error")
+	})
+}
+
+func TestStreamUnknownProcedure(t *testing.T) {
+	doWithStreamTestEnv(t, streamHandlerFunc(func(*transport.ServerStream) error { return nil }), func(t *testing.T, e *streamTestEnv) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		stream, err := e.Outbound.CallStream(ctx, &transport.StreamRequest{
+			Meta: &transport.RequestMeta{
+				Caller:    "caller",
+				Service:   "service",
+				Encoding:  transport.Encoding("raw"),
+				Procedure: "test::unknown",
+			},
+		})
+		require.NoError(t, err)
+
+		_, err = stream.ReceiveMessage(ctx)
+		require.Error(t, err)
+		assert.Equal(t, err, e.WaitFinished(t), "peer must be released with the routing error")
+
+		var serverSpans int
+		for _, span := range e.Tracer.FinishedSpans() {
+			if span.Tag(string(ext.SpanKind)) == ext.SpanKindRPCServerEnum {
+				serverSpans++
+				assert.Equal(t, true, span.Tag("error"), "server span must record the routing error")
+			}
+		}
+		assert.Equal(t, 1, serverSpans, "server span must be finished")
+	})
+}
+
+func TestStreamCloseWithoutDraining(t *testing.T) {
+	handlerDone := make(chan error, 1)
+	chatty := streamHandlerFunc(func(s *transport.ServerStream) error {
+		for {
+			if err := s.SendMessage(s.Context(), streamMessage("more")); err != nil {
+				handlerDone <- err
+				return err
+			}
+		}
+	})
+
+	doWithStreamTestEnv(t, chatty, func(t *testing.T, e *streamTestEnv) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		stream, err := e.CallStream(ctx)
+		require.NoError(t, err)
+
+		msg, err := stream.ReceiveMessage(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "more", readStreamMessageString(t, msg))
+
+		// Abandon the stream without reading it to the end.
+		require.NoError(t, stream.Close(ctx))
+		cancel()
+
+		assert.Equal(t, context.Canceled, e.WaitFinished(t), "peer must be released")
+		select {
+		case err := <-handlerDone:
+			assert.Error(t, err, "server must see the stream terminate")
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the handler to return")
+		}
+
+		var clientSpans int
+		for _, span := range e.Tracer.FinishedSpans() {
+			if span.Tag(string(ext.SpanKind)) == ext.SpanKindRPCClientEnum {
+				clientSpans++
+				assert.Equal(t, true, span.Tag("error"), "client span must be marked as failed")
+			}
+		}
+		assert.Equal(t, 1, clientSpans, "client span must be finished")
+	})
+}
@@ -26,6 +26,8 @@ import (
 
 	"go.uber.org/multierr"
 	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/internal/certmetrics"
+	"go.uber.org/yarpc/internal/sharedmetrics"
 	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/pkg/lifecycle"
 )
@@ -39,6 +41,11 @@ type Transport struct {
 	once          *lifecycle.Once
 	options       *transportOptions
 	addressToPeer map[string]*grpcPeer
+	certs         *certmetrics.Observer
+
+	// Outbounds built from configuration, by outbound name.
+	outboundsLock sync.Mutex
+	outbounds     map[string]*Outbound
 }
 
 // NewTransport returns a new Transport.
@@ -47,13 +54,24 @@ func NewTransport(options ...TransportOption) *Transport {
 }
 
 func newTransport(transportOptions *transportOptions) *Transport {
+	registerCompressors()
+	certs := certmetrics.NewObserver()
+	transportOptions.clientTLSConfig = certs.Observe(transportOptions.clientTLSConfig)
 	return &Transport{
 		once:          lifecycle.NewOnce(),
 		options:       transportOptions,
 		addressToPeer: make(map[string]*grpcPeer),
+		certs:         certs,
+		outbounds:     make(map[string]*Outbound),
 	}
 }
 
+// Metrics returns the metrics of the transport, which Dispatchers using it
+// report.
+func (t *Transport) Metrics() []*sharedmetrics.Set {
+	return t.certs.Metrics()
+}
+
 // Start implements transport.Lifecycle#Start.
 func (t *Transport) Start() error {
 	return t.once.Start(nil)
@@ -95,6 +113,27 @@ func (t *Transport) NewOutbound(peerChooser peer.Chooser, options ...OutboundOpt
 	return newOutbound(t, peerChooser, options...)
 }
 
+// configuredOutbound returns the Outbound built for the named outbound,
+// building it with the given function the first time. Outbounds without a
+// name are never shared.
+func (t *Transport) configuredOutbound(name string, build func() (*Outbound, error)) (*Outbound, error) {
+	if name == "" {
+		return build()
+	}
+
+	t.outboundsLock.Lock()
+	defer t.outboundsLock.Unlock()
+	if o, ok := t.outbounds[name]; ok {
+		return o, nil
+	}
+	o, err := build()
+	if err != nil {
+		return nil, err
+	}
+	t.outbounds[name] = o
+	return o, nil
+}
+
 // RetainPeer retains the peer.
 func (t *Transport) RetainPeer(peerIdentifier peer.Identifier, peerSubscriber peer.Subscriber) (peer.Peer, error) {
 	t.lock.Lock()
@@ -0,0 +1,115 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package http
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/compressor"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	contentEncodingHeader = "Content-Encoding"
+	acceptEncodingHeader  = "Accept-Encoding"
+
+	// Responses smaller than this are not worth compressing.
+	_minResponseCompressionSize = 1024
+)
+
+// compress writes p into buf compressed with the given compressor.
+func compress(c compressor.Compressor, buf *bytes.Buffer, p []byte) error {
+	w, err := c.Compress(buf)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(p); err != nil {
+		return multierr.Append(err, w.Close())
+	}
+	return w.Close()
+}
+
+// decompressBody wraps the body of a request or response with the
+// compressor named by its Content-Encoding header. The body is returned as-is
+// if it is not compressed.
+//
+// The returned ReadCloser closes both the decompressor and body.
+func decompressBody(header http.Header, body io.ReadCloser) (io.ReadCloser, error) {
+	encoding := header.Get(contentEncodingHeader)
+	if encoding == "" || encoding == "identity" {
+		return body, nil
+	}
+
+	c, ok := compressor.Get(encoding)
+	if !ok {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument, "unsupported content encoding %q", encoding)
+	}
+	r, err := c.Decompress(body)
+	if err != nil {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument, "failed to decompress %q body: %v", encoding, err)
+	}
+	return decompressedBody{Reader: r, decompressor: r, body: body}, nil
+}
+
+type decompressedBody struct {
+	io.Reader
+
+	decompressor io.Closer
+	body         io.Closer
+}
+
+func (b decompressedBody) Close() error {
+	return multierr.Append(b.decompressor.Close(), b.body.Close())
+}
+
+// negotiateCompressor returns the first registered compressor listed in the
+// given Accept-Encoding header, or nil if the caller accepts none of them.
+func negotiateCompressor(acceptEncoding string) compressor.Compressor {
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(coding, ";")
+		name := strings.TrimSpace(params[0])
+		if isRefused(params[1:]) {
+			continue
+		}
+		if c, ok := compressor.Get(name); ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// isRefused returns true if the parameters of an Accept-Encoding entry have
+// a quality value of zero, which means the coding is not acceptable.
+func isRefused(params []string) bool {
+	for _, param := range params {
+		param = strings.Replace(param, " ", "", -1)
+		if !strings.HasPrefix(param, "q=") {
+			continue
+		}
+		q := strings.TrimRight(strings.TrimPrefix(param, "q="), "0")
+		return q == "" || q == "." || q == "0."
+	}
+	return false
+}
@@ -0,0 +1,321 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/compressor"
+	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func gzipped(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, compress(compressor.Gzip, &buf, []byte(s)))
+	return buf.Bytes()
+}
+
+func gunzipped(t *testing.T, b []byte) string {
+	r, err := compressor.Gzip.Decompress(bytes.NewReader(b))
+	require.NoError(t, err)
+	defer r.Close()
+	s, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	return string(s)
+}
+
+func TestNegotiateCompressor(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           compressor.Compressor
+	}{
+		{"", nil},
+		{"gzip", compressor.Gzip},
+		{"br, gzip", compressor.Gzip},
+		{"gzip;q=0.5", compressor.Gzip},
+		{"gzip;q=1.0, identity", compressor.Gzip},
+		{"gzip;q=0", nil},
+		{"gzip; q=0.000", nil},
+		{"identity", nil},
+		{"deflate, br", nil},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, negotiateCompressor(tt.acceptEncoding), "Accept-Encoding: %q", tt.acceptEncoding)
+	}
+}
+
+func TestOutboundCompression(t *testing.T) {
+	tests := []struct {
+		desc         string
+		opts         []OutboundOption
+		wantEncoding string
+		wantAccept   string
+	}{
+		{
+			desc: "no compressor",
+		},
+		{
+			desc:         "compressor",
+			opts:         []OutboundOption{Compressor(compressor.Gzip)},
+			wantEncoding: "gzip",
+			wantAccept:   "gzip",
+		},
+		{
+			desc:       "body below min size",
+			opts:       []OutboundOption{Compressor(compressor.Gzip), CompressionMinSize(6)},
+			wantAccept: "gzip",
+		},
+		{
+			desc:         "body at min size",
+			opts:         []OutboundOption{Compressor(compressor.Gzip), CompressionMinSize(5)},
+			wantEncoding: "gzip",
+			wantAccept:   "gzip",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, req *http.Request) {
+					defer req.Body.Close()
+
+					body, err := ioutil.ReadAll(req.Body)
+					require.NoError(t, err)
+					assert.Equal(t, tt.wantEncoding, req.Header.Get("Content-Encoding"))
+					if tt.wantEncoding != "" {
+						assert.Equal(t, "world", gunzipped(t, body))
+					} else {
+						assert.Equal(t, "world", string(body))
+					}
+
+					if tt.wantAccept != "" {
+						assert.Equal(t, tt.wantAccept, req.Header.Get("Accept-Encoding"))
+					}
+					w.Header().Set("Content-Encoding", "gzip")
+					_, err = w.Write(gzipped(t, "great success"))
+					assert.NoError(t, err)
+				},
+			))
+			defer server.Close()
+
+			out := NewTransport().NewSingleOutbound(server.URL, tt.opts...)
+			require.NoError(t, out.Start(), "failed to start outbound")
+			defer out.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+			defer cancel()
+			res, err := out.Call(ctx, &transport.Request{
+				Caller:    "caller",
+				Service:   "service",
+				Encoding:  raw.Encoding,
+				Procedure: "hello",
+				Body:      bytes.NewReader([]byte("world")),
+			})
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			body, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "great success", string(body))
+			assert.Equal(t, tt.wantAccept, out.Introspect().Compressor)
+		})
+	}
+}
+
+func TestOutboundUnknownResponseEncoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Encoding", "lzma")
+			_, _ = w.Write([]byte("great success"))
+		},
+	))
+	defer server.Close()
+
+	out := NewTransport().NewSingleOutbound(server.URL, Compressor(compressor.Gzip))
+	require.NoError(t, out.Start(), "failed to start outbound")
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+	defer cancel()
+	_, err := out.Call(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  raw.Encoding,
+		Procedure: "hello",
+		Body:      bytes.NewReader([]byte("world")),
+	})
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeInternal, yarpcerrors.FromError(err).Code())
+	assert.Contains(t, err.Error(), `unsupported content encoding "lzma"`)
+}
+
+func TestHandlerCompression(t *testing.T) {
+	largeBody := strings.Repeat("Nyuck ", _minResponseCompressionSize)
+
+	tests := []struct {
+		desc            string
+		contentEncoding string
+		requestBody     []byte
+		acceptEncoding  string
+		responseBody    string
+
+		wantEncoding string
+	}{
+		{
+			desc:         "uncompressed",
+			requestBody:  []byte("Nyuck Nyuck"),
+			responseBody: largeBody,
+		},
+		{
+			desc:            "compressed request",
+			contentEncoding: "gzip",
+			requestBody:     gzipped(t, "Nyuck Nyuck"),
+			responseBody:    largeBody,
+		},
+		{
+			desc:           "compressed response",
+			requestBody:    []byte("Nyuck Nyuck"),
+			acceptEncoding: "br, gzip",
+			responseBody:   largeBody,
+			wantEncoding:   "gzip",
+		},
+		{
+			desc:            "small response",
+			contentEncoding: "gzip",
+			requestBody:     gzipped(t, "Nyuck Nyuck"),
+			acceptEncoding:  "gzip",
+			responseBody:    "Nyuck",
+		},
+		{
+			desc:           "unknown accepted encoding",
+			requestBody:    []byte("Nyuck Nyuck"),
+			acceptEncoding: "br",
+			responseBody:   largeBody,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			router := transporttest.NewMockRouter(mockCtrl)
+			rpcHandler := transporttest.NewMockUnaryHandler(mockCtrl)
+			router.EXPECT().Choose(gomock.Any(), gomock.Any()).
+				Return(transport.NewUnaryHandlerSpec(rpcHandler), nil)
+			rpcHandler.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).
+				Do(func(_ context.Context, req *transport.Request, rw transport.ResponseWriter) {
+					body, err := ioutil.ReadAll(req.Body)
+					require.NoError(t, err)
+					assert.Equal(t, "Nyuck Nyuck", string(body))
+					_, err = rw.Write([]byte(tt.responseBody))
+					require.NoError(t, err)
+				}).Return(nil)
+
+			headers := make(http.Header)
+			headers.Set(CallerHeader, "moe")
+			headers.Set(EncodingHeader, "raw")
+			headers.Set(TTLMSHeader, "1000")
+			headers.Set(ProcedureHeader, "nyuck")
+			headers.Set(ServiceHeader, "curly")
+			if tt.contentEncoding != "" {
+				headers.Set("Content-Encoding", tt.contentEncoding)
+			}
+			if tt.acceptEncoding != "" {
+				headers.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+
+			httpHandler := handler{router: router, tracer: &opentracing.NoopTracer{}}
+			rw := httptest.NewRecorder()
+			httpHandler.ServeHTTP(rw, &http.Request{
+				Method: "POST",
+				Header: headers,
+				Body:   ioutil.NopCloser(bytes.NewReader(tt.requestBody)),
+			})
+			require.Equal(t, 200, rw.Code, "expected 200 code")
+
+			assert.Equal(t, tt.wantEncoding, rw.HeaderMap.Get("Content-Encoding"))
+			if tt.wantEncoding != "" {
+				assert.Equal(t, tt.responseBody, gunzipped(t, rw.Body.Bytes()))
+			} else {
+				assert.Equal(t, tt.responseBody, rw.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandlerInvalidContentEncoding(t *testing.T) {
+	tests := []struct {
+		desc            string
+		contentEncoding string
+		wantMessage     string
+	}{
+		{
+			desc:            "unknown encoding",
+			contentEncoding: "lzma",
+			wantMessage:     `unsupported content encoding "lzma"`,
+		},
+		{
+			desc:            "corrupt body",
+			contentEncoding: "gzip",
+			wantMessage:     `failed to decompress "gzip" body`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			headers := make(http.Header)
+			headers.Set(CallerHeader, "moe")
+			headers.Set(EncodingHeader, "raw")
+			headers.Set(TTLMSHeader, "1000")
+			headers.Set(ProcedureHeader, "nyuck")
+			headers.Set(ServiceHeader, "curly")
+			headers.Set("Content-Encoding", tt.contentEncoding)
+
+			httpHandler := handler{tracer: &opentracing.NoopTracer{}}
+			rw := httptest.NewRecorder()
+			httpHandler.ServeHTTP(rw, &http.Request{
+				Method: "POST",
+				Header: headers,
+				Body:   ioutil.NopCloser(bytes.NewReader([]byte("Nyuck Nyuck"))),
+			})
+
+			assert.Equal(t, http.StatusBadRequest, rw.Code)
+			assert.Equal(t, "invalid-argument", rw.HeaderMap.Get(ErrorCodeHeader))
+			assert.Contains(t, rw.Body.String(), tt.wantMessage)
+		})
+	}
+}
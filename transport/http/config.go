@@ -22,9 +22,11 @@ package http
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/compressor"
 	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/yarpcconfig"
 )
@@ -90,6 +92,17 @@ func (ts *transportSpec) Spec() yarpcconfig.TransportSpec {
 //
 // All parameters of TransportConfig are optional. This section may be omitted
 // in the transports section.
+//
+// The tls section configures how outbounds verify servers when they make
+// requests to "https" URLs, and the client certificate they present for
+// mutual TLS. See yarpcconfig.TLS for details.
+//
+//  transports:
+//    http:
+//      tls:
+//        caFile: /etc/certs/ca.pem
+//        certFile: /etc/certs/myservice.pem
+//        keyFile: /etc/certs/myservice-key.pem
 type TransportConfig struct {
 	// Specifies the keep-alive period for all HTTP clients. This field is
 	// optional.
@@ -97,6 +110,7 @@ type TransportConfig struct {
 	MaxIdleConnsPerHost int                 `config:"maxIdleConnsPerHost"`
 	ConnTimeout         time.Duration       `config:"connTimeout"`
 	ConnBackoff         yarpcconfig.Backoff `config:"connBackoff"`
+	TLS                 yarpcconfig.TLS     `config:"tls"`
 }
 
 func (ts *transportSpec) buildTransport(tc *TransportConfig, k *yarpcconfig.Kit) (transport.Transport, error) {
@@ -122,6 +136,14 @@ func (ts *transportSpec) buildTransport(tc *TransportConfig, k *yarpcconfig.Kit)
 	}
 	options.connBackoffStrategy = strategy
 
+	tlsConfig, err := tc.TLS.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		options.tlsConfig = tlsConfig
+	}
+
 	return options.newTransport(), nil
 }
 
@@ -133,12 +155,26 @@ func (ts *transportSpec) buildTransport(tc *TransportConfig, k *yarpcconfig.Kit)
 //      grabHeaders:
 //        - x-foo
 //        - x-bar
+//
+// The inbound accepts requests over TLS if the tls section is present. See
+// yarpcconfig.TLS for details.
+//
+//  inbounds:
+//    http:
+//      address: ":443"
+//      tls:
+//        certFile: /etc/certs/myservice.pem
+//        keyFile: /etc/certs/myservice-key.pem
+//        caFile: /etc/certs/ca.pem
+//        clientAuth: require-and-verify
 type InboundConfig struct {
 	// Address to listen on. This field is required.
 	Address string `config:"address,interpolate"`
 	// The additional headers, starting with x, that should be
 	// propagated to handlers. This field is optional.
 	GrabHeaders []string `config:"grabHeaders"`
+	// TLS configuration for the inbound. This field is optional.
+	TLS yarpcconfig.TLS `config:"tls"`
 }
 
 func (ts *transportSpec) buildInbound(ic *InboundConfig, t transport.Transport, k *yarpcconfig.Kit) (transport.Inbound, error) {
@@ -149,6 +185,13 @@ func (ts *transportSpec) buildInbound(ic *InboundConfig, t transport.Transport,
 	if len(ic.GrabHeaders) > 0 {
 		inboundOptions = append(inboundOptions, GrabHeaders(ic.GrabHeaders...))
 	}
+	tlsConfig, err := ic.TLS.ServerConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		inboundOptions = append(inboundOptions, InboundTLSConfig(tlsConfig))
+	}
 	return t.(*Transport).NewInbound(ic.Address, inboundOptions...), nil
 }
 
@@ -183,6 +226,17 @@ func (ts *transportSpec) buildInbound(ic *InboundConfig, t transport.Transport,
 //            peers:
 //              - 127.0.0.1:8080
 //              - 127.0.0.1:8081
+//
+// Request bodies may be compressed with one of the compressors registered
+// with the compressor package. Bodies smaller than compressionMinSize bytes
+// are sent uncompressed.
+//
+//  outbounds:
+//    keyvalueservice:
+//      http:
+//        url: "http://127.0.0.1:80/"
+//        compressor: gzip
+//        compressionMinSize: 1024
 type OutboundConfig struct {
 	yarpcconfig.PeerChooser
 
@@ -199,6 +253,14 @@ type OutboundConfig struct {
 	//      X-Caller: myserice
 	//      X-Token: foo
 	AddHeaders map[string]string `config:"addHeaders"`
+
+	// Name of the compressor used for request bodies. Requests are not
+	// compressed by default.
+	Compressor string `config:"compressor"`
+
+	// Size in bytes below which request bodies are not compressed. Defaults
+	// to 0, compressing all requests.
+	CompressionMinSize int `config:"compressionMinSize"`
 }
 
 func (ts *transportSpec) buildOutbound(oc *OutboundConfig, t transport.Transport, k *yarpcconfig.Kit) (*Outbound, error) {
@@ -210,6 +272,14 @@ func (ts *transportSpec) buildOutbound(oc *OutboundConfig, t transport.Transport
 			opts = append(opts, AddHeader(k, v))
 		}
 	}
+	if oc.Compressor != "" {
+		c, ok := compressor.Get(oc.Compressor)
+		if !ok {
+			return nil, fmt.Errorf("unknown compressor %q for HTTP outbound, available compressors: %v",
+				oc.Compressor, strings.Join(compressor.Names(), ", "))
+		}
+		opts = append(opts, Compressor(c), CompressionMinSize(oc.CompressionMinSize))
+	}
 
 	// Special case where the URL implies the single peer.
 	if oc.Empty() {
@@ -75,8 +75,10 @@ func TestTransportSpec(t *testing.T) {
 	}
 
 	type wantOutbound struct {
-		URLTemplate string
-		Headers     http.Header
+		URLTemplate        string
+		Headers            http.Header
+		Compressor         string
+		CompressionMinSize int
 	}
 
 	type outboundTest struct {
@@ -157,6 +159,22 @@ func TestTransportSpec(t *testing.T) {
 				MuxPattern: "/yarpc",
 			},
 		},
+		{
+			desc: "inbound TLS without certificate",
+			cfg: attrs{
+				"address": ":8080",
+				"tls":     attrs{"caFile": "/etc/certs/ca.pem"},
+			},
+			wantErrors: []string{"a certificate and private key are required for a TLS server"},
+		},
+		{
+			desc: "inbound TLS unknown client auth",
+			cfg: attrs{
+				"address": ":8080",
+				"tls":     attrs{"clientAuth": "always"},
+			},
+			wantErrors: []string{`unknown TLS client auth type "always"`},
+		},
 	}
 
 	outboundTests := []outboundTest{
@@ -291,6 +309,40 @@ func TestTransportSpec(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "outbound compressor config",
+			cfg: attrs{
+				"myservice": attrs{
+					"http": attrs{
+						"url":                "http://localhost/yarpc",
+						"compressor":         "gzip",
+						"compressionMinSize": 1024,
+					},
+				},
+			},
+			wantOutbounds: map[string]wantOutbound{
+				"myservice": {
+					URLTemplate:        "http://localhost/yarpc",
+					Compressor:         "gzip",
+					CompressionMinSize: 1024,
+				},
+			},
+		},
+		{
+			desc: "outbound unknown compressor",
+			cfg: attrs{
+				"myservice": attrs{
+					"http": attrs{
+						"url":        "http://localhost/yarpc",
+						"compressor": "lzma",
+					},
+				},
+			},
+			wantErrors: []string{
+				`unknown compressor "lzma" for HTTP outbound`,
+				"available compressors: gzip",
+			},
+		},
 		{
 			desc: "outbound peer build error",
 			cfg: attrs{
@@ -401,6 +453,8 @@ func TestTransportSpec(t *testing.T) {
 
 				assert.Equal(t, want.URLTemplate, ob.urlTemplate.String(), "outbound URLTemplate should match")
 				assert.Equal(t, want.Headers, ob.headers, "outbound headers should match")
+				assert.Equal(t, want.Compressor, ob.Introspect().Compressor, "outbound compressor should match")
+				assert.Equal(t, want.CompressionMinSize, ob.compressionMinSize, "outbound compression min size should match")
 			}
 
 		}
@@ -30,10 +30,12 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/compressor"
 	"go.uber.org/yarpc/internal/bufferpool"
 	"go.uber.org/yarpc/internal/iopool"
 	"go.uber.org/yarpc/pkg/errors"
 	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/yarpc/yarpctls"
 )
 
 func popHeader(h http.Header, n string) string {
@@ -51,6 +53,7 @@ type handler struct {
 
 func (h handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	responseWriter := newResponseWriter(w)
+	responseWriter.compressor = negotiateCompressor(popHeader(req.Header, acceptEncodingHeader))
 	service := popHeader(req.Header, ServiceHeader)
 	procedure := popHeader(req.Header, ProcedureHeader)
 	status := yarpcerrors.FromError(errors.WrapHandlerError(h.callHandler(responseWriter, req, service, procedure), service, procedure))
@@ -84,6 +87,13 @@ func (h handler) callHandler(responseWriter *responseWriter, req *http.Request,
 	if req.Method != http.MethodPost {
 		return yarpcerrors.Newf(yarpcerrors.CodeNotFound, "request method was %s but only %s is allowed", req.Method, http.MethodPost)
 	}
+	body, err := decompressBody(req.Header, req.Body)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	req.Header.Del(contentEncodingHeader)
+
 	treq := &transport.Request{
 		Caller:          popHeader(req.Header, CallerHeader),
 		Service:         service,
@@ -93,7 +103,7 @@ func (h handler) callHandler(responseWriter *responseWriter, req *http.Request,
 		RoutingKey:      popHeader(req.Header, RoutingKeyHeader),
 		RoutingDelegate: popHeader(req.Header, RoutingDelegateHeader),
 		Headers:         applicationHeaders.FromHTTPHeaders(req.Header, transport.Headers{}),
-		Body:            req.Body,
+		Body:            body,
 	}
 	for header := range h.grabHeaders {
 		if value := req.Header.Get(header); value != "" {
@@ -111,7 +121,7 @@ func (h handler) callHandler(responseWriter *responseWriter, req *http.Request,
 		}
 	}()
 
-	ctx := req.Context()
+	ctx := transport.WithPeerCertificate(req.Context(), yarpctls.VerifiedPeerCertificate(req.TLS))
 	ctx, cancel, parseTTLErr := parseTTL(ctx, treq, popHeader(req.Header, TTLMSHeader))
 	// parseTTLErr != nil is a problem only if the request is unary.
 	defer cancel()
@@ -136,7 +146,7 @@ func (h handler) callHandler(responseWriter *responseWriter, req *http.Request,
 		err = transport.DispatchUnaryHandler(ctx, spec.Unary(), start, treq, responseWriter)
 
 	case transport.Oneway:
-		err = handleOnewayRequest(span, treq, spec.Oneway())
+		err = handleOnewayRequest(ctx, span, treq, spec.Oneway())
 
 	default:
 		err = yarpcerrors.Newf(yarpcerrors.CodeUnimplemented, "transport http does not handle %s handlers", spec.Type().String())
@@ -147,6 +157,7 @@ func (h handler) callHandler(responseWriter *responseWriter, req *http.Request,
 }
 
 func handleOnewayRequest(
+	ctx context.Context,
 	span opentracing.Span,
 	treq *transport.Request,
 	onewayHandler transport.OnewayHandler,
@@ -161,7 +172,8 @@ func handleOnewayRequest(
 
 	// create a new context for oneway requests since the HTTP handler cancels
 	// http.Request's context when ServeHTTP returns
-	ctx := opentracing.ContextWithSpan(context.Background(), span)
+	ctx = transport.WithPeerCertificate(context.Background(), transport.PeerCertificateFromContext(ctx))
+	ctx = opentracing.ContextWithSpan(ctx, span)
 
 	go func() {
 		// ensure the span lasts for length of the handler in case of errors
@@ -205,9 +217,13 @@ func (h handler) createSpan(ctx context.Context, req *http.Request, treq *transp
 }
 
 // responseWriter adapts a http.ResponseWriter into a transport.ResponseWriter.
+//
+// Successful responses of at least _minResponseCompressionSize bytes are
+// compressed if the caller accepts one of the registered compressors.
 type responseWriter struct {
-	w      http.ResponseWriter
-	buffer *bytes.Buffer
+	w          http.ResponseWriter
+	buffer     *bytes.Buffer
+	compressor compressor.Compressor
 }
 
 func newResponseWriter(w http.ResponseWriter) *responseWriter {
@@ -235,6 +251,16 @@ func (rw *responseWriter) AddSystemHeader(key string, value string) {
 }
 
 func (rw *responseWriter) Close(httpStatusCode int) {
+	if rw.buffer != nil && rw.compressor != nil && httpStatusCode == http.StatusOK &&
+		rw.buffer.Len() >= _minResponseCompressionSize {
+		compressed := bufferpool.Get()
+		if err := compress(rw.compressor, compressed, rw.buffer.Bytes()); err == nil {
+			rw.w.Header().Set(contentEncodingHeader, rw.compressor.Name())
+			rw.buffer, compressed = compressed, rw.buffer
+		}
+		bufferpool.Put(compressed)
+	}
+
 	rw.w.WriteHeader(httpStatusCode)
 	if rw.buffer != nil {
 		// TODO: what to do with error?
@@ -21,14 +21,17 @@
 package http
 
 import (
+	"crypto/tls"
 	"net"
 	"net/http"
 	"strings"
 
 	"github.com/opentracing/opentracing-go"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/certmetrics"
 	"go.uber.org/yarpc/internal/introspection"
 	intnet "go.uber.org/yarpc/internal/net"
+	"go.uber.org/yarpc/internal/sharedmetrics"
 	"go.uber.org/yarpc/pkg/lifecycle"
 	"go.uber.org/yarpc/yarpcerrors"
 )
@@ -67,6 +70,18 @@ func GrabHeaders(headers ...string) InboundOption {
 	}
 }
 
+// InboundTLSConfig specifies that the inbound should only accept requests
+// over TLS, using the given configuration.
+//
+// Use the yarpctls package to build the configuration. If the configuration
+// verifies client certificates, the verified certificate of the caller is
+// available to handlers with yarpc.CallFromContext.
+func InboundTLSConfig(config *tls.Config) InboundOption {
+	return func(i *Inbound) {
+		i.tlsConfig = config
+	}
+}
+
 // NewInbound builds a new HTTP inbound that listens on the given address and
 // sharing this transport.
 func (t *Transport) NewInbound(addr string, opts ...InboundOption) *Inbound {
@@ -76,10 +91,12 @@ func (t *Transport) NewInbound(addr string, opts ...InboundOption) *Inbound {
 		tracer:      t.tracer,
 		transport:   t,
 		grabHeaders: make(map[string]struct{}),
+		certs:       certmetrics.NewObserver(),
 	}
 	for _, opt := range opts {
 		opt(i)
 	}
+	i.tlsConfig = i.certs.Observe(i.tlsConfig)
 	return i
 }
 
@@ -94,6 +111,8 @@ type Inbound struct {
 	tracer      opentracing.Tracer
 	transport   *Transport
 	grabHeaders map[string]struct{}
+	tlsConfig   *tls.Config
+	certs       *certmetrics.Observer
 
 	once *lifecycle.Once
 }
@@ -111,6 +130,12 @@ func (i *Inbound) SetRouter(router transport.Router) {
 	i.router = router
 }
 
+// Metrics returns the metrics of the inbound, which Dispatchers using it
+// report.
+func (i *Inbound) Metrics() []*sharedmetrics.Set {
+	return i.certs.Metrics()
+}
+
 // Transports returns the inbound's HTTP transport.
 func (i *Inbound) Transports() []transport.Transport {
 	return []transport.Transport{i.transport}
@@ -143,8 +168,9 @@ func (i *Inbound) start() error {
 	}
 
 	i.server = intnet.NewHTTPServer(&http.Server{
-		Addr:    i.addr,
-		Handler: httpHandler,
+		Addr:      i.addr,
+		Handler:   httpHandler,
+		TLSConfig: i.tlsConfig,
 	})
 	if err := i.server.ListenAndServe(); err != nil {
 		return err
@@ -23,13 +23,18 @@ package http
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sync"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -39,8 +44,10 @@ import (
 	"go.uber.org/yarpc/encoding/raw"
 	"go.uber.org/yarpc/internal/routertest"
 	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/internal/tlstest"
 	"go.uber.org/yarpc/internal/yarpctest"
 	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/yarpc/yarpctls"
 )
 
 func TestStartAddrInUse(t *testing.T) {
@@ -190,3 +197,199 @@ func TestInboundMux(t *testing.T) {
 		}
 	}
 }
+
+func TestInboundMutualTLS(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	dir, err := ioutil.TempDir("", "yarpchttp")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := tlstest.WriteFiles(t, dir)
+
+	serverConfig, err := yarpctls.ServerConfig(
+		yarpctls.CertificateFiles(files.ServerCertFile, files.ServerKeyFile),
+		yarpctls.CAFile(files.CAFile),
+		yarpctls.ClientAuth(tls.RequireAndVerifyClientCert),
+	)
+	require.NoError(t, err)
+
+	serverTransport := NewTransport()
+	i := serverTransport.NewInbound("127.0.0.1:0", InboundTLSConfig(serverConfig))
+	h := transporttest.NewMockUnaryHandler(mockCtrl)
+	reg := transporttest.NewMockRouter(mockCtrl)
+	i.SetRouter(reg)
+	require.NoError(t, serverTransport.Start())
+	defer serverTransport.Stop()
+	require.NoError(t, i.Start())
+	defer i.Stop()
+
+	url := fmt.Sprintf("https://%v/", i.Addr())
+	newRequest := func() *transport.Request {
+		return &transport.Request{
+			Caller:    "foo",
+			Service:   "bar",
+			Procedure: "hello",
+			Encoding:  raw.Encoding,
+			Body:      bytes.NewReader([]byte("derp")),
+		}
+	}
+
+	t.Run("with client certificate", func(t *testing.T) {
+		clientConfig, err := yarpctls.ClientConfig(
+			yarpctls.CertificateFiles(files.ClientCertFile, files.ClientKeyFile),
+			yarpctls.CAFile(files.CAFile),
+		)
+		require.NoError(t, err)
+
+		clientTransport := NewTransport(ClientTLSConfig(clientConfig))
+		o := clientTransport.NewSingleOutbound(url)
+		require.NoError(t, clientTransport.Start())
+		defer clientTransport.Stop()
+		require.NoError(t, o.Start())
+		defer o.Stop()
+
+		reg.EXPECT().Choose(gomock.Any(), gomock.Any()).
+			Return(transport.NewUnaryHandlerSpec(h), nil)
+
+		var peerCert *x509.Certificate
+		h.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).
+			Do(func(ctx context.Context, _ *transport.Request, _ transport.ResponseWriter) {
+				peerCert = transport.PeerCertificateFromContext(ctx)
+			}).
+			Return(nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+		defer cancel()
+		res, err := o.Call(ctx, newRequest())
+		require.NoError(t, err, "expected rpc request to succeed")
+		require.NoError(t, res.Body.Close())
+
+		require.NotNil(t, peerCert, "peer certificate must be available to the handler")
+		assert.Equal(t, tlstest.ClientCommonName, peerCert.Subject.CommonName)
+	})
+
+	t.Run("without client certificate", func(t *testing.T) {
+		clientConfig, err := yarpctls.ClientConfig(yarpctls.CAFile(files.CAFile))
+		require.NoError(t, err)
+
+		clientTransport := NewTransport(ClientTLSConfig(clientConfig))
+		o := clientTransport.NewSingleOutbound(url)
+		require.NoError(t, clientTransport.Start())
+		defer clientTransport.Stop()
+		require.NoError(t, o.Start())
+		defer o.Stop()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+		defer cancel()
+		_, err = o.Call(ctx, newRequest())
+		assert.Error(t, err, "expected rpc request without a client certificate to fail")
+	})
+}
+
+func TestInboundCertificateRotation(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	dir, err := ioutil.TempDir("", "yarpchttp")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	firstDir := filepath.Join(dir, "first")
+	secondDir := filepath.Join(dir, "second")
+	require.NoError(t, os.Mkdir(firstDir, 0700))
+	require.NoError(t, os.Mkdir(secondDir, 0700))
+
+	firstExpiry := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	secondExpiry := firstExpiry.Add(time.Hour)
+	first := tlstest.WriteFilesWithExpiry(t, firstDir, firstExpiry)
+	second := tlstest.WriteFilesWithExpiry(t, secondDir, secondExpiry)
+
+	// Clients trust both CAs.
+	caBundle := filepath.Join(dir, "ca.pem")
+	var caPEM []byte
+	for _, f := range []string{first.CAFile, second.CAFile} {
+		b, err := ioutil.ReadFile(f)
+		require.NoError(t, err)
+		caPEM = append(caPEM, b...)
+	}
+	require.NoError(t, ioutil.WriteFile(caBundle, caPEM, 0600))
+
+	var (
+		mu    sync.Mutex
+		certs *yarpctls.Certificates
+	)
+	setCertificates := func(files tlstest.Files) {
+		cert, err := tls.LoadX509KeyPair(files.ServerCertFile, files.ServerKeyFile)
+		require.NoError(t, err)
+		mu.Lock()
+		certs = &yarpctls.Certificates{Certificate: &cert}
+		mu.Unlock()
+	}
+	setCertificates(first)
+
+	serverConfig, err := yarpctls.ServerConfig(yarpctls.CertificateSource(
+		yarpctls.SourceFunc(func() (*yarpctls.Certificates, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return certs, nil
+		}),
+	))
+	require.NoError(t, err)
+	clientConfig, err := yarpctls.ClientConfig(yarpctls.CAFile(caBundle))
+	require.NoError(t, err)
+
+	serverTransport := NewTransport()
+	i := serverTransport.NewInbound("127.0.0.1:0", InboundTLSConfig(serverConfig))
+	h := transporttest.NewMockUnaryHandler(mockCtrl)
+	reg := transporttest.NewMockRouter(mockCtrl)
+	i.SetRouter(reg)
+	require.NoError(t, serverTransport.Start())
+	defer serverTransport.Stop()
+	require.NoError(t, i.Start())
+	defer i.Stop()
+
+	clientTransport := NewTransport(ClientTLSConfig(clientConfig))
+	o := clientTransport.NewSingleOutbound(fmt.Sprintf("https://%v/", i.Addr()))
+	require.NoError(t, clientTransport.Start())
+	defer clientTransport.Stop()
+	require.NoError(t, o.Start())
+	defer o.Stop()
+
+	reg.EXPECT().Choose(gomock.Any(), gomock.Any()).
+		Return(transport.NewUnaryHandlerSpec(h), nil).Times(2)
+	h.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	call := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+		defer cancel()
+		res, err := o.Call(ctx, &transport.Request{
+			Caller:    "foo",
+			Service:   "bar",
+			Procedure: "hello",
+			Encoding:  raw.Encoding,
+			Body:      bytes.NewReader([]byte("derp")),
+		})
+		require.NoError(t, err, "expected rpc request to succeed")
+		_, err = ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+	}
+	serverExpiry := func() time.Time {
+		conn, err := tls.Dial("tcp", i.Addr().String(), clientConfig)
+		require.NoError(t, err)
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].NotAfter
+	}
+
+	call()
+	assert.Equal(t, firstExpiry, serverExpiry())
+
+	setCertificates(second)
+
+	// Requests continue to succeed and new connections use the new
+	// certificate.
+	call()
+	assert.Equal(t, secondExpiry, serverExpiry())
+}
@@ -21,6 +21,7 @@
 package http
 
 import (
+	"crypto/tls"
 	"net"
 	"net/http"
 	"sync"
@@ -31,11 +32,14 @@ import (
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/backoff"
+	"go.uber.org/yarpc/internal/certmetrics"
+	"go.uber.org/yarpc/internal/sharedmetrics"
 	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/pkg/lifecycle"
 )
 
 type transportOptions struct {
+	tlsConfig           *tls.Config
 	keepAlive           time.Duration
 	maxIdleConnsPerHost int
 	connTimeout         time.Duration
@@ -115,6 +119,17 @@ func Tracer(tracer opentracing.Tracer) TransportOption {
 	}
 }
 
+// ClientTLSConfig specifies the TLS configuration used by outbounds of this
+// transport to make requests to "https" URLs.
+//
+// Use the yarpctls package to build the configuration. By default, server
+// certificates are verified against the host's root CAs.
+func ClientTLSConfig(config *tls.Config) TransportOption {
+	return func(options *transportOptions) {
+		options.tlsConfig = config
+	}
+}
+
 // Hidden option to override the buildHTTPClient function. This is used only
 // for testing.
 func buildClient(f func(*transportOptions) *http.Client) TransportOption {
@@ -133,9 +148,12 @@ func NewTransport(opts ...TransportOption) *Transport {
 }
 
 func (o *transportOptions) newTransport() *Transport {
+	certs := certmetrics.NewObserver()
+	o.tlsConfig = certs.Observe(o.tlsConfig)
 	return &Transport{
 		once:                lifecycle.NewOnce(),
 		client:              o.buildClient(o),
+		certs:               certs,
 		connTimeout:         o.connTimeout,
 		connBackoffStrategy: o.connBackoffStrategy,
 		peers:               make(map[string]*httpPeer),
@@ -152,6 +170,7 @@ func buildHTTPClient(options *transportOptions) *http.Client {
 				Timeout:   30 * time.Second,
 				KeepAlive: options.keepAlive,
 			}).Dial,
+			TLSClientConfig:       options.tlsConfig,
 			TLSHandshakeTimeout:   10 * time.Second,
 			ExpectContinueTimeout: 1 * time.Second,
 			MaxIdleConnsPerHost:   options.maxIdleConnsPerHost,
@@ -174,10 +193,17 @@ type Transport struct {
 	connectorsGroup     sync.WaitGroup
 
 	tracer opentracing.Tracer
+	certs  *certmetrics.Observer
 }
 
 var _ transport.Transport = (*Transport)(nil)
 
+// Metrics returns the metrics of the transport, which Dispatchers using it
+// report.
+func (a *Transport) Metrics() []*sharedmetrics.Set {
+	return a.certs.Metrics()
+}
+
 // Start starts the HTTP transport.
 func (a *Transport) Start() error {
 	return a.once.Start(func() error {
@@ -159,7 +159,9 @@ func (tt tchannelTransport) WithRouter(r transport.Router, f func(transport.Unar
 }
 
 func (tt tchannelTransport) WithRouterOneway(r transport.Router, f func(transport.OnewayOutbound)) {
-	panic("tchannel does not support oneway calls")
+	tt.WithRouter(r, func(o transport.UnaryOutbound) {
+		f(o.(transport.OnewayOutbound))
+	})
 }
 
 // grpcTransport implements a roundTripTransport for gRPC.
@@ -184,7 +186,21 @@ func (gt grpcTransport) WithRouter(r transport.Router, f func(transport.UnaryOut
 }
 
 func (gt grpcTransport) WithRouterOneway(r transport.Router, f func(transport.OnewayOutbound)) {
-	panic("grpc does not support oneway calls")
+	grpcTransport := grpc.NewTransport()
+	require.NoError(gt.t, grpcTransport.Start(), "failed to start transport")
+	defer grpcTransport.Stop()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(gt.t, err)
+	i := grpcTransport.NewInbound(listener)
+	i.SetRouter(r)
+	require.NoError(gt.t, i.Start(), "failed to start inbound")
+	defer i.Stop()
+
+	o := grpcTransport.NewSingleOutbound(listener.Addr().String())
+	require.NoError(gt.t, o.Start(), "failed to start outbound")
+	defer o.Stop()
+	f(o)
 }
 
 func TestSimpleRoundTrip(t *testing.T) {
@@ -307,7 +323,11 @@ func TestSimpleRoundTrip(t *testing.T) {
 }
 
 func TestSimpleRoundTripOneway(t *testing.T) {
-	trans := httpTransport{t}
+	transports := []roundTripTransport{
+		httpTransport{t},
+		tchannelTransport{t},
+		grpcTransport{t},
+	}
 
 	tests := []struct {
 		name           string
@@ -330,56 +350,63 @@ func TestSimpleRoundTripOneway(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			for _, trans := range transports {
+				requestMatcher := transporttest.NewRequestMatcher(t, &transport.Request{
+					Caller:    testCaller,
+					Service:   testService,
+					Procedure: testProcedureOneway,
+					Encoding:  raw.Encoding,
+					Headers:   tt.requestHeaders,
+					Body:      bytes.NewReader([]byte(tt.requestBody)),
+				})
 
-			requestMatcher := transporttest.NewRequestMatcher(t, &transport.Request{
-				Caller:    testCaller,
-				Service:   testService,
-				Procedure: testProcedureOneway,
-				Encoding:  raw.Encoding,
-				Headers:   tt.requestHeaders,
-				Body:      bytes.NewReader([]byte(tt.requestBody)),
-			})
+				handlerDone := make(chan struct{})
 
-			handlerDone := make(chan struct{})
+				onewayHandler := onewayHandlerFunc(func(_ context.Context, r *transport.Request) error {
+					r.Headers.Del("user-agent") // for gRPC
+					r.Headers.Del(":authority") // for gRPC
+					assert.True(t, requestMatcher.Matches(r), "request mismatch: received %v", r)
 
-			onewayHandler := onewayHandlerFunc(func(_ context.Context, r *transport.Request) error {
-				assert.True(t, requestMatcher.Matches(r), "request mismatch: received %v", r)
+					// Pretend to work: this delay should not slow down tests since it is a
+					// server-side operation
+					testtime.Sleep(5 * time.Second)
 
-				// Pretend to work: this delay should not slow down tests since it is a
-				// server-side operation
-				testtime.Sleep(5 * time.Second)
+					// close the channel, telling the client (which should not be waiting for
+					// a response) that the handler finished executing
+					close(handlerDone)
 
-				// close the channel, telling the client (which should not be waiting for
-				// a response) that the handler finished executing
-				close(handlerDone)
+					return nil
+				})
 
-				return nil
-			})
+				router := staticRouter{OnewayHandler: onewayHandler}
 
-			router := staticRouter{OnewayHandler: onewayHandler}
+				// TChannel requires a deadline, even for oneway requests.
+				ctx, cancel := context.WithTimeout(rootCtx, 200*testtime.Millisecond)
+				defer cancel()
 
-			trans.WithRouterOneway(router, func(o transport.OnewayOutbound) {
-				ack, err := o.CallOneway(rootCtx, &transport.Request{
-					Caller:    testCaller,
-					Service:   testService,
-					Procedure: testProcedureOneway,
-					Encoding:  raw.Encoding,
-					Headers:   tt.requestHeaders,
-					Body:      bytes.NewReader([]byte(tt.requestBody)),
-				})
+				trans.WithRouterOneway(router, func(o transport.OnewayOutbound) {
+					ack, err := o.CallOneway(ctx, &transport.Request{
+						Caller:    testCaller,
+						Service:   testService,
+						Procedure: testProcedureOneway,
+						Encoding:  raw.Encoding,
+						Headers:   tt.requestHeaders,
+						Body:      bytes.NewReader([]byte(tt.requestBody)),
+					})
 
-				select {
-				case <-handlerDone:
-					// if the server filled the channel, it means we waited for the server
-					// to complete the request
-					assert.Fail(t, "client waited for server handler to finish executing")
-				default:
-				}
+					select {
+					case <-handlerDone:
+						// if the server filled the channel, it means we waited for the server
+						// to complete the request
+						assert.Fail(t, "client waited for server handler to finish executing")
+					default:
+					}
 
-				if assert.NoError(t, err, "%T: oneway call failed for test '%v'", trans, tt.name) {
-					assert.NotNil(t, ack)
-				}
-			})
+					if assert.NoError(t, err, "%T: oneway call failed for test '%v'", trans, tt.name) {
+						assert.NotNil(t, ack)
+					}
+				})
+			}
 		})
 	}
 }
@@ -23,6 +23,7 @@ package tchannel
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/uber/tchannel-go"
 	"go.uber.org/yarpc/api/transport"
@@ -35,6 +36,7 @@ import (
 
 var (
 	_ transport.UnaryOutbound              = (*ChannelOutbound)(nil)
+	_ transport.OnewayOutbound             = (*ChannelOutbound)(nil)
 	_ introspection.IntrospectableOutbound = (*ChannelOutbound)(nil)
 )
 
@@ -191,6 +193,23 @@ func (o *ChannelOutbound) Call(ctx context.Context, req *transport.Request) (*tr
 	}, getResponseErrorAndDeleteHeaderKeys(headers)
 }
 
+// CallOneway sends a oneway RPC over this TChannel outbound.
+//
+// TChannel requires a response for every call, so the inbound replies with
+// an empty response as soon as it has read the request, before the handler
+// runs. CallOneway returns once that acknowledgement has been received. As
+// with Call, the context must have a deadline.
+func (o *ChannelOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	res, err := o.Call(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Body.Close(); err != nil {
+		return nil, err
+	}
+	return time.Now(), nil
+}
+
 // Introspect returns basic status about this outbound.
 func (o *ChannelOutbound) Introspect() introspection.OutboundStatus {
 	state := "Stopped"
@@ -226,6 +226,55 @@ func TestChannelCallSuccess(t *testing.T) {
 	}
 }
 
+func TestChannelCallOnewaySuccess(t *testing.T) {
+	server := testutils.NewServer(t, nil)
+	defer server.Close()
+	serverHostPort := server.PeerInfo().HostPort
+
+	server.GetSubChannel("service").SetHandler(tchannel.HandlerFunc(
+		func(ctx context.Context, call *tchannel.InboundCall) {
+			assert.Equal(t, "hello", call.MethodString())
+
+			_, body, err := readArgs(call)
+			if assert.NoError(t, err, "failed to read request") {
+				assert.Equal(t, []byte("world"), body)
+			}
+
+			err = writeArgs(call.Response(), []byte{0x00, 0x00}, nil)
+			assert.NoError(t, err, "failed to write response")
+		}))
+
+	for _, constructor := range constructors {
+		t.Run(constructor.desc, func(t *testing.T) {
+			out, err := constructor.new(testutils.NewClient(t, &testutils.ChannelOpts{
+				ServiceName: "caller",
+			}), serverHostPort)
+			require.NoError(t, err)
+			require.NoError(t, out.Start(), "failed to start outbound")
+			defer out.Stop()
+
+			onewayOut, ok := out.(transport.OnewayOutbound)
+			require.True(t, ok, "expected a transport.OnewayOutbound, got %T", out)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*testtime.Millisecond)
+			defer cancel()
+			ack, err := onewayOut.CallOneway(
+				ctx,
+				&transport.Request{
+					Caller:    "caller",
+					Service:   "service",
+					Encoding:  raw.Encoding,
+					Procedure: "hello",
+					Body:      bytes.NewReader([]byte("world")),
+				},
+			)
+			if assert.NoError(t, err, "failed to make oneway call") {
+				assert.NotNil(t, ack)
+			}
+		})
+	}
+}
+
 func TestChannelCallFailures(t *testing.T) {
 	server := testutils.NewServer(t, nil)
 	defer server.Close()
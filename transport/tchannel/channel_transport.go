@@ -27,6 +27,7 @@ import (
 	"github.com/uber/tchannel-go"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/zap"
 )
 
 var errChannelOrServiceNameIsRequired = errors.New(
@@ -48,6 +49,7 @@ var errChannelOrServiceNameIsRequired = errors.New(
 func NewChannelTransport(opts ...TransportOption) (*ChannelTransport, error) {
 	var options transportOptions
 	options.tracer = opentracing.GlobalTracer()
+	options.logger = zap.NewNop()
 	for _, opt := range opts {
 		opt(&options)
 	}
@@ -81,6 +83,7 @@ func (options transportOptions) newChannelTransport() *ChannelTransport {
 		ch:     options.ch,
 		addr:   options.addr,
 		tracer: options.tracer,
+		logger: options.logger,
 	}
 }
 
@@ -93,6 +96,7 @@ type ChannelTransport struct {
 	name   string
 	addr   string
 	tracer opentracing.Tracer
+	logger *zap.Logger
 	router transport.Router
 
 	once *lifecycle.Once
@@ -130,7 +134,7 @@ func (t *ChannelTransport) start() error {
 		for s := range services {
 			sc := t.ch.GetSubChannel(s)
 			existing := sc.GetHandlers()
-			sc.SetHandler(handler{existing: existing, router: t.router, tracer: t.tracer})
+			sc.SetHandler(handler{existing: existing, router: t.router, tracer: t.tracer, logger: t.logger})
 		}
 	}
 
@@ -39,9 +39,22 @@ import (
 //        exponential:
 //          first: 10ms
 //          max: 30s
+//
+// TLS may be enabled for all connections of the transport. If a certificate
+// is provided, the transport accepts only TLS connections and presents the
+// certificate to peers it connects to.
+//
+//  transports:
+//    tchannel:
+//      tls:
+//        certFile: /etc/certs/myservice.pem
+//        keyFile: /etc/certs/myservice-key.pem
+//        caFile: /etc/certs/ca.pem
+//        clientAuth: require-and-verify
 type TransportConfig struct {
 	ConnTimeout time.Duration       `config:"connTimeout"`
 	ConnBackoff yarpcconfig.Backoff `config:"connBackoff"`
+	TLS         yarpcconfig.TLS     `config:"tls"`
 }
 
 // InboundConfig configures a TChannel inbound.
@@ -67,7 +80,7 @@ type OutboundConfig struct {
 	yarpcconfig.PeerChooser
 }
 
-// TransportSpec returns a TransportSpec for the TChannel unary transport.
+// TransportSpec returns a TransportSpec for the TChannel transport.
 func TransportSpec(opts ...Option) yarpcconfig.TransportSpec {
 	var ts transportSpec
 	for _, o := range opts {
@@ -91,10 +104,11 @@ type transportSpec struct {
 
 func (ts *transportSpec) Spec() yarpcconfig.TransportSpec {
 	return yarpcconfig.TransportSpec{
-		Name:               transportName,
-		BuildTransport:     ts.buildTransport,
-		BuildInbound:       ts.buildInbound,
-		BuildUnaryOutbound: ts.buildUnaryOutbound,
+		Name:                transportName,
+		BuildTransport:      ts.buildTransport,
+		BuildInbound:        ts.buildInbound,
+		BuildUnaryOutbound:  ts.buildUnaryOutbound,
+		BuildOnewayOutbound: ts.buildOnewayOutbound,
 	}
 }
 
@@ -115,6 +129,22 @@ func (ts *transportSpec) buildTransport(tc *TransportConfig, k *yarpcconfig.Kit)
 	}
 	options.connBackoffStrategy = strategy
 
+	if !tc.TLS.Empty() {
+		clientConfig, err := tc.TLS.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		options.clientTLSConfig = clientConfig
+
+		if tc.TLS.CertFile != "" {
+			serverConfig, err := tc.TLS.ServerConfig()
+			if err != nil {
+				return nil, err
+			}
+			options.serverTLSConfig = serverConfig
+		}
+	}
+
 	if options.name != "" {
 		return nil, fmt.Errorf("TChannel TransportSpec does not accept ServiceName")
 	}
@@ -149,6 +179,14 @@ func (ts *transportSpec) buildInbound(c *InboundConfig, t transport.Transport, k
 }
 
 func (ts *transportSpec) buildUnaryOutbound(oc *OutboundConfig, t transport.Transport, k *yarpcconfig.Kit) (transport.UnaryOutbound, error) {
+	return ts.buildOutbound(oc, t, k)
+}
+
+func (ts *transportSpec) buildOnewayOutbound(oc *OutboundConfig, t transport.Transport, k *yarpcconfig.Kit) (transport.OnewayOutbound, error) {
+	return ts.buildOutbound(oc, t, k)
+}
+
+func (ts *transportSpec) buildOutbound(oc *OutboundConfig, t transport.Transport, k *yarpcconfig.Kit) (*Outbound, error) {
 	x := t.(*Transport)
 	chooser, err := oc.BuildPeerChooser(x, hostport.Identify, k)
 	if err != nil {
@@ -222,6 +222,8 @@ func TestTransportSpec(t *testing.T) {
 		for _, svc := range outbound.wantOutbounds {
 			_, ok := cfg.Outbounds[svc].Unary.(*Outbound)
 			assert.True(t, ok, "expected *Outbound for %q, got %T", svc, cfg.Outbounds[svc].Unary)
+			_, ok = cfg.Outbounds[svc].Oneway.(*Outbound)
+			assert.True(t, ok, "expected oneway *Outbound for %q, got %T", svc, cfg.Outbounds[svc].Oneway)
 		}
 
 		d := yarpc.NewDispatcher(cfg)
@@ -19,7 +19,7 @@
 // THE SOFTWARE.
 
 // Package tchannel implements a YARPC transport based on the TChannel
-// protocol. The TChannel transport provides support for Unary RPCs only.
+// protocol. The TChannel transport provides support for Unary and Oneway RPCs.
 //
 // Usage
 //
@@ -31,8 +31,10 @@ import (
 	"go.uber.org/multierr"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/bufferpool"
+	"go.uber.org/yarpc/internal/iopool"
 	"go.uber.org/yarpc/pkg/errors"
 	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
 	ncontext "golang.org/x/net/context"
 )
 
@@ -79,11 +81,13 @@ func (c tchannelCall) Response() inboundCallResponse {
 	return c.InboundCall.Response()
 }
 
-// handler wraps a transport.UnaryHandler into a TChannel Handler.
+// handler wraps a transport.UnaryHandler or transport.OnewayHandler into a
+// TChannel Handler.
 type handler struct {
 	existing map[string]tchannel.Handler
 	router   transport.Router
 	tracer   opentracing.Tracer
+	logger   *zap.Logger
 }
 
 func (h handler) Handle(ctx ncontext.Context, call *tchannel.InboundCall) {
@@ -181,11 +185,41 @@ func (h handler) callHandler(ctx context.Context, call inboundCall, responseWrit
 		}
 		return transport.DispatchUnaryHandler(ctx, spec.Unary(), start, treq, responseWriter)
 
+	case transport.Oneway:
+		return h.handleOnewayRequest(ctx, treq, spec.Oneway())
+
 	default:
 		return yarpcerrors.Newf(yarpcerrors.CodeUnimplemented, "transport tchannel does not handle %s handlers", spec.Type().String())
 	}
 }
 
+// handleOnewayRequest reads the request body and runs the oneway handler in
+// the background. The empty response written by the caller acknowledges the
+// request.
+func (h handler) handleOnewayRequest(ctx context.Context, treq *transport.Request, onewayHandler transport.OnewayHandler) error {
+	// we will lose access to the body unless we read all the bytes before
+	// returning from the request
+	var buff bytes.Buffer
+	if _, err := iopool.Copy(&buff, treq.Body); err != nil {
+		return err
+	}
+	treq.Body = &buff
+
+	// create a new context for oneway requests since TChannel cancels the
+	// call's context once the response has been sent
+	ctx = opentracing.ContextWithSpan(context.Background(), opentracing.SpanFromContext(ctx))
+
+	go func() {
+		if err := transport.DispatchOnewayHandler(ctx, onewayHandler, treq); err != nil {
+			h.logger.Error("oneway handler failed",
+				zap.String("service", treq.Service),
+				zap.String("procedure", treq.Procedure),
+				zap.Error(err))
+		}
+	}()
+	return nil
+}
+
 type responseWriter struct {
 	failedWith         error
 	format             tchannel.Format
@@ -26,6 +26,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -38,6 +39,9 @@ import (
 	"go.uber.org/yarpc/internal/routertest"
 	"go.uber.org/yarpc/internal/testtime"
 	pkgerrors "go.uber.org/yarpc/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestHandlerErrors(t *testing.T) {
@@ -116,6 +120,106 @@ func TestHandlerErrors(t *testing.T) {
 	}
 }
 
+func TestHandlerOneway(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	onewayHandler := transporttest.NewMockOnewayHandler(mockCtrl)
+	router := transporttest.NewMockRouter(mockCtrl)
+
+	spec := transport.NewOnewayHandlerSpec(onewayHandler)
+	tchHandler := handler{router: router}
+
+	router.EXPECT().Choose(gomock.Any(), routertest.NewMatcher().
+		WithService("service").
+		WithProcedure("hello"),
+	).Return(spec, nil)
+
+	called := make(chan struct{})
+	onewayHandler.EXPECT().HandleOneway(
+		gomock.Any(),
+		transporttest.NewRequestMatcher(t,
+			&transport.Request{
+				Caller:    "caller",
+				Service:   "service",
+				Headers:   transport.NewHeaders().With("foo", "bar"),
+				Encoding:  raw.Encoding,
+				Procedure: "hello",
+				Body:      bytes.NewReader([]byte("world")),
+			}),
+	).Do(func(context.Context, *transport.Request) { close(called) }).Return(nil)
+
+	respRecorder := newResponseRecorder()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+	defer cancel()
+	tchHandler.handle(ctx, &fakeInboundCall{
+		service: "service",
+		caller:  "caller",
+		format:  tchannel.Raw,
+		method:  "hello",
+		arg2: []byte{
+			0x00, 0x01,
+			0x00, 0x03, 'f', 'o', 'o',
+			0x00, 0x03, 'b', 'a', 'r',
+		},
+		arg3: []byte("world"),
+		resp: respRecorder,
+	})
+
+	assert.NoError(t, respRecorder.systemErr, "did not expect an error")
+	assert.Empty(t, respRecorder.arg3.Bytes(), "expected an empty response body")
+
+	select {
+	case <-called:
+	case <-time.After(testtime.Second):
+		t.Fatal("oneway handler was not called")
+	}
+}
+
+func TestHandlerOnewayError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	onewayHandler := transporttest.NewMockOnewayHandler(mockCtrl)
+	router := transporttest.NewMockRouter(mockCtrl)
+
+	core, logs := observer.New(zapcore.ErrorLevel)
+	spec := transport.NewOnewayHandlerSpec(onewayHandler)
+	tchHandler := handler{router: router, logger: zap.New(core)}
+
+	router.EXPECT().Choose(gomock.Any(), gomock.Any()).Return(spec, nil)
+	onewayHandler.EXPECT().HandleOneway(gomock.Any(), gomock.Any()).
+		Return(errors.New("great sadness"))
+
+	respRecorder := newResponseRecorder()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+	defer cancel()
+	tchHandler.handle(ctx, &fakeInboundCall{
+		service: "service",
+		caller:  "caller",
+		format:  tchannel.Raw,
+		method:  "hello",
+		arg2:    []byte{0x00, 0x00},
+		arg3:    []byte("world"),
+		resp:    respRecorder,
+	})
+	assert.NoError(t, respRecorder.systemErr, "handler errors must not be returned to the caller")
+
+	deadline := time.Now().Add(testtime.Second)
+	for logs.Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1, "expected the handler error to be logged")
+	assert.Equal(t, "oneway handler failed", entries[0].Message)
+	require.Len(t, entries[0].Context, 3)
+	assert.Equal(t, "service", entries[0].Context[0].String)
+	assert.Equal(t, "hello", entries[0].Context[1].String)
+	assert.EqualError(t, entries[0].Context[2].Interface.(error), "great sadness")
+}
+
 func TestHandlerFailures(t *testing.T) {
 	tests := []struct {
 		desc string
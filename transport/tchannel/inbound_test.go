@@ -23,7 +23,9 @@ package tchannel
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -33,6 +35,8 @@ import (
 	"go.uber.org/yarpc/api/transport/transporttest"
 	"go.uber.org/yarpc/encoding/raw"
 	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/internal/tlstest"
+	"go.uber.org/yarpc/yarpctls"
 )
 
 func TestInboundStartNew(t *testing.T) {
@@ -193,3 +197,70 @@ func TestArbitraryInboundServiceOutboundCallerName(t *testing.T) {
 	require.NoError(t, i.Stop())
 	require.NoError(t, o.Stop())
 }
+
+func TestInboundTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpctchannel")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := tlstest.WriteFiles(t, dir)
+	serverConfig, err := yarpctls.ServerConfig(
+		yarpctls.CertificateFiles(files.ServerCertFile, files.ServerKeyFile),
+		yarpctls.CAFile(files.CAFile),
+		yarpctls.ClientAuth(tls.RequireAndVerifyClientCert),
+	)
+	require.NoError(t, err)
+	clientConfig, err := yarpctls.ClientConfig(
+		yarpctls.CertificateFiles(files.ClientCertFile, files.ClientKeyFile),
+		yarpctls.CAFile(files.CAFile),
+	)
+	require.NoError(t, err)
+
+	it, err := NewTransport(
+		ServiceName("service"),
+		ListenAddr("127.0.0.1:0"),
+		ServerTLSConfig(serverConfig),
+	)
+	require.NoError(t, err)
+	i := it.NewInbound()
+	i.SetRouter(transporttest.EchoRouter{})
+	require.NoError(t, i.Start(), "failed to start inbound")
+	require.NoError(t, it.Start(), "failed to start inbound transport")
+	defer it.Stop()
+	defer i.Stop()
+
+	call := func(t *testing.T, opts ...TransportOption) error {
+		ot, err := NewTransport(append(opts, ServiceName("caller"))...)
+		require.NoError(t, err)
+		require.NoError(t, ot.Start(), "failed to start outbound transport")
+		defer ot.Stop()
+		o := ot.NewSingleOutbound(it.ListenAddr())
+		require.NoError(t, o.Start(), "failed to start outbound")
+		defer o.Stop()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*testtime.Millisecond)
+		defer cancel()
+		res, err := o.Call(ctx, &transport.Request{
+			Caller:    "caller",
+			Service:   "service",
+			Procedure: "hello",
+			Encoding:  raw.Encoding,
+			Body:      bytes.NewReader([]byte("hello")),
+		})
+		if err != nil {
+			return err
+		}
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(body))
+		return res.Body.Close()
+	}
+
+	t.Run("TLS client", func(t *testing.T) {
+		assert.NoError(t, call(t, ClientTLSConfig(clientConfig)))
+	})
+
+	t.Run("plaintext client", func(t *testing.T) {
+		assert.Error(t, call(t))
+	})
+}
@@ -21,11 +21,13 @@
 package tchannel
 
 import (
+	"crypto/tls"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
 	backoffapi "go.uber.org/yarpc/api/backoff"
 	"go.uber.org/yarpc/internal/backoff"
+	"go.uber.org/zap"
 )
 
 // Option allows customizing the YARPC TChannel transport.
@@ -48,16 +50,20 @@ var _ Option = (TransportOption)(nil)
 type transportOptions struct {
 	ch                  Channel
 	tracer              opentracing.Tracer
+	logger              *zap.Logger
 	addr                string
 	name                string
 	connTimeout         time.Duration
 	connBackoffStrategy backoffapi.Strategy
+	serverTLSConfig     *tls.Config
+	clientTLSConfig     *tls.Config
 }
 
 // newTransportOptions constructs the default transport options struct
 func newTransportOptions() transportOptions {
 	return transportOptions{
 		tracer:              opentracing.GlobalTracer(),
+		logger:              zap.NewNop(),
 		connTimeout:         defaultConnTimeout,
 		connBackoffStrategy: backoff.DefaultExponential,
 	}
@@ -78,6 +84,16 @@ func Tracer(tracer opentracing.Tracer) TransportOption {
 	}
 }
 
+// Logger specifies the logger to which the transport reports errors which
+// cannot be returned to the caller, such as errors from oneway handlers.
+//
+// Defaults to a no-op logger.
+func Logger(logger *zap.Logger) TransportOption {
+	return func(t *transportOptions) {
+		t.logger = logger
+	}
+}
+
 // WithChannel specifies the TChannel Channel to use to send and receive YARPC
 // requests. The instance may already have handlers registered against it;
 // these will be left unchanged.
@@ -152,3 +168,33 @@ func ConnBackoff(s backoffapi.Strategy) TransportOption {
 		options.connBackoffStrategy = s
 	}
 }
+
+// ServerTLSConfig specifies that the transport should only accept
+// connections over TLS, using the given configuration.
+//
+// Use the yarpctls package to build the configuration.
+//
+// This option is only supported by NewTransport and transports constructed
+// with the YARPC configuration system. TChannel does not expose connections
+// to handlers, so the certificates of callers are not available through
+// yarpc.CallFromContext.
+func ServerTLSConfig(config *tls.Config) TransportOption {
+	return func(options *transportOptions) {
+		options.serverTLSConfig = config
+	}
+}
+
+// ClientTLSConfig specifies that the transport should connect to peers over
+// TLS, using the given configuration.
+//
+// Use the yarpctls package to build the configuration. Unless the
+// configuration specifies a ServerName, the host of each peer is used to
+// verify its certificate.
+//
+// This option is only supported by NewTransport and transports constructed
+// with the YARPC configuration system.
+func ClientTLSConfig(config *tls.Config) TransportOption {
+	return func(options *transportOptions) {
+		options.clientTLSConfig = config
+	}
+}
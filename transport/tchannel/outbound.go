@@ -22,6 +22,7 @@ package tchannel
 
 import (
 	"context"
+	"time"
 
 	"github.com/uber/tchannel-go"
 	"go.uber.org/yarpc/api/peer"
@@ -38,6 +39,7 @@ var (
 	errDoNotUseContextWithHeaders = yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument, "tchannel.ContextWithHeaders is not compatible with YARPC, use yarpc.CallOption instead")
 
 	_ transport.UnaryOutbound              = (*Outbound)(nil)
+	_ transport.OnewayOutbound             = (*Outbound)(nil)
 	_ introspection.IntrospectableOutbound = (*Outbound)(nil)
 )
 
@@ -91,6 +93,23 @@ func (o *Outbound) Call(ctx context.Context, req *transport.Request) (*transport
 	return res, err
 }
 
+// CallOneway sends a oneway RPC over this TChannel outbound.
+//
+// TChannel requires a response for every call, so the inbound replies with
+// an empty response as soon as it has read the request, before the handler
+// runs. CallOneway returns once that acknowledgement has been received. As
+// with Call, the context must have a deadline.
+func (o *Outbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	res, err := o.Call(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Body.Close(); err != nil {
+		return nil, err
+	}
+	return time.Now(), nil
+}
+
 // callWithPeer sends a request with the chosen peer.
 func (o *Outbound) callWithPeer(ctx context.Context, req *transport.Request, peer *tchannel.Peer) (*transport.Response, error) {
 	// NB(abg): Under the current API, the local service's name is required
@@ -187,6 +187,72 @@ func TestCallSuccess(t *testing.T) {
 	assert.NoError(t, res.Body.Close(), "failed to close response body")
 }
 
+func TestCallOneway(t *testing.T) {
+	server := testutils.NewServer(t, nil)
+	defer server.Close()
+	serverHostPort := server.PeerInfo().HostPort
+
+	server.GetSubChannel("service").SetHandler(tchannel.HandlerFunc(
+		func(ctx context.Context, call *tchannel.InboundCall) {
+			if call.MethodString() != "hello" {
+				call.Response().SendSystemError(tchannel.NewSystemError(
+					tchannel.ErrCodeBadRequest, "unknown method"))
+				return
+			}
+
+			_, body, err := readArgs(call)
+			if assert.NoError(t, err, "failed to read request") {
+				assert.Equal(t, []byte("world"), body)
+			}
+
+			err = writeArgs(call.Response(), []byte{0x00, 0x00}, nil)
+			assert.NoError(t, err, "failed to write response")
+		}))
+
+	x, err := NewTransport(ServiceName("caller"))
+	require.NoError(t, err)
+	require.NoError(t, x.Start(), "failed to start transport")
+	defer x.Stop()
+
+	out := x.NewSingleOutbound(serverHostPort)
+	require.NoError(t, out.Start(), "failed to start outbound")
+	defer out.Stop()
+
+	tests := []struct {
+		procedure string
+		wantError string
+	}{
+		{procedure: "hello"},
+		{procedure: "unknown", wantError: "unknown method"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.procedure, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 200*testtime.Millisecond)
+			defer cancel()
+			ack, err := out.CallOneway(
+				ctx,
+				&transport.Request{
+					Caller:    "caller",
+					Service:   "service",
+					Encoding:  raw.Encoding,
+					Procedure: tt.procedure,
+					Body:      bytes.NewReader([]byte("world")),
+				},
+			)
+			if tt.wantError != "" {
+				if assert.Error(t, err, "expected failure") {
+					assert.Contains(t, err.Error(), tt.wantError)
+				}
+				return
+			}
+			if assert.NoError(t, err, "failed to make oneway call") {
+				assert.NotNil(t, ack)
+			}
+		})
+	}
+}
+
 func TestCallFailures(t *testing.T) {
 	server := testutils.NewServer(t, nil)
 	defer server.Close()
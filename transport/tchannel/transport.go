@@ -21,7 +21,9 @@
 package tchannel
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net"
 	"sync"
 	"time"
 
@@ -30,8 +32,11 @@ import (
 	backoffapi "go.uber.org/yarpc/api/backoff"
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/certmetrics"
+	"go.uber.org/yarpc/internal/sharedmetrics"
 	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/zap"
 )
 
 // Transport is a TChannel transport suitable for use with YARPC's peer
@@ -47,6 +52,7 @@ type Transport struct {
 	ch     Channel
 	router transport.Router
 	tracer opentracing.Tracer
+	logger *zap.Logger
 	name   string
 	addr   string
 
@@ -55,6 +61,9 @@ type Transport struct {
 	connRetryBackoffFactor int
 	connectorsGroup        sync.WaitGroup
 	connBackoffStrategy    backoffapi.Strategy
+	serverTLSConfig        *tls.Config
+	clientTLSConfig        *tls.Config
+	certs                  *certmetrics.Observer
 
 	peers map[string]*tchannelPeer
 }
@@ -81,17 +90,28 @@ func NewTransport(opts ...TransportOption) (*Transport, error) {
 }
 
 func (o transportOptions) newTransport() *Transport {
+	certs := certmetrics.NewObserver()
 	return &Transport{
 		once:                lifecycle.NewOnce(),
 		name:                o.name,
 		addr:                o.addr,
 		connTimeout:         o.connTimeout,
 		connBackoffStrategy: o.connBackoffStrategy,
+		serverTLSConfig:     certs.Observe(o.serverTLSConfig),
+		clientTLSConfig:     certs.Observe(o.clientTLSConfig),
+		certs:               certs,
 		peers:               make(map[string]*tchannelPeer),
 		tracer:              o.tracer,
+		logger:              o.logger,
 	}
 }
 
+// Metrics returns the metrics of the transport, which Dispatchers using it
+// report.
+func (t *Transport) Metrics() []*sharedmetrics.Set {
+	return t.certs.Metrics()
+}
+
 // ListenAddr exposes the listen address of the transport.
 func (t *Transport) ListenAddr() string {
 	return t.addr
@@ -185,9 +205,13 @@ func (t *Transport) start() error {
 		Handler: handler{
 			router: t.router,
 			tracer: t.tracer,
+			logger: t.logger,
 		},
 		OnPeerStatusChanged: t.onPeerStatusChanged,
 	}
+	if t.clientTLSConfig != nil {
+		chopts.Dialer = newTLSDialer(t.clientTLSConfig)
+	}
 	ch, err := tchannel.NewChannel(t.name, &chopts)
 	if err != nil {
 		return err
@@ -209,8 +233,18 @@ func (t *Transport) start() error {
 	// TODO(abg): If addr was just the port (":4040"), we want to use
 	// ListenIP() + ":4040" rather than just ":4040".
 
-	if err := t.ch.ListenAndServe(addr); err != nil {
-		return err
+	if t.serverTLSConfig == nil {
+		if err := t.ch.ListenAndServe(addr); err != nil {
+			return err
+		}
+	} else {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+		if err := ch.Serve(tls.NewListener(listener, t.serverTLSConfig)); err != nil {
+			return err
+		}
 	}
 
 	t.addr = t.ch.PeerInfo().HostPort
@@ -155,7 +155,22 @@ var (
 			<td>{{.Service}}</td>
 			<td>{{.Transport}}</td>
 			<td>{{.RPCType}}</td>
-			<td>{{.Endpoint}}</td>
+			<td>
+				{{.Endpoint}}
+				{{if .Outbounds}}
+				<ul>
+				{{range .Outbounds}}
+					<li>{{.Name}} (weight {{.Weight}}): {{.Outbound.Transport}} {{.Outbound.Endpoint}}</li>
+				{{end}}
+				</ul>
+				{{end}}
+				{{with .Queue}}
+				<br>queue {{.Name}}: {{.Depth}} pending in {{.Directory}}
+				{{end}}
+				{{with .Compressor}}
+				<br>compressed with {{.}}
+				{{end}}
+			</td>
 			<td>{{.State}}</td>
 			<td>{{.Chooser.Name}}</td>
 			<td>{{.Chooser.State}}</td>
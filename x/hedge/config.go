@@ -0,0 +1,122 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hedge
+
+import (
+	"fmt"
+	"time"
+)
+
+// Config describes how to configure and construct a hedging middleware.
+//
+// Policies are declared by name and selected for services or procedures by
+// overrides. Requests to other procedures are not hedged.
+//
+//  budget: 0.05
+//  policies:
+//    fast:
+//      delay: 20ms
+//  overrides:
+//    - service: users
+//      procedure: getUser
+//      with: fast
+type Config struct {
+	// Budget caps the number of hedges to a fraction of the requests made to
+	// hedged procedures. Defaults to 0.1.
+	Budget float64 `config:"budget"`
+	// Policies declares hedging policies by name.
+	Policies map[string]PolicyConfig `config:"policies"`
+	// Overrides select policies for specific services or procedures.
+	Overrides []OverrideConfig `config:"overrides"`
+}
+
+// PolicyConfig describes a hedging policy.
+type PolicyConfig struct {
+	// Delay is the static delay after which requests are hedged.
+	Delay time.Duration `config:"delay"`
+	// LatencyQuantile derives the delay from the observed latency of the
+	// procedure, falling back to Delay until latencies are known.
+	LatencyQuantile float64 `config:"latencyQuantile"`
+	// MaxHedges is the maximum number of hedges sent for each request.
+	// Defaults to 1.
+	MaxHedges uint `config:"maxHedges"`
+}
+
+// OverrideConfig selects a policy for all procedures of a service, or for a
+// single procedure if Procedure is set.
+type OverrideConfig struct {
+	Service   string `config:"service"`
+	Procedure string `config:"procedure"`
+	// With is the name of the policy to use.
+	With string `config:"with"`
+}
+
+// Build creates a hedging middleware, or returns an error if the
+// configuration is invalid.
+func (c Config) Build() (*OutboundMiddleware, error) {
+	if c.Budget < 0 {
+		return nil, fmt.Errorf("hedge budget must not be negative, got %v", c.Budget)
+	}
+
+	policies := make(map[string]*Policy, len(c.Policies))
+	for name, pc := range c.Policies {
+		if pc.LatencyQuantile < 0 || pc.LatencyQuantile >= 1 {
+			return nil, fmt.Errorf("hedge policy %q: latencyQuantile must be between 0 and 1, got %v", name, pc.LatencyQuantile)
+		}
+		if pc.Delay <= 0 && pc.LatencyQuantile == 0 {
+			return nil, fmt.Errorf("hedge policy %q must specify a delay or latencyQuantile", name)
+		}
+		policies[name] = pc.policy()
+	}
+
+	provider := NewProcedurePolicyProvider()
+	for _, o := range c.Overrides {
+		if o.Service == "" {
+			return nil, fmt.Errorf("hedge policy override for %q must specify a service", o.With)
+		}
+		policy, ok := policies[o.With]
+		if !ok {
+			return nil, fmt.Errorf("hedge policy %q for service %q is not defined", o.With, o.Service)
+		}
+		if o.Procedure == "" {
+			provider.RegisterService(o.Service, policy)
+		} else {
+			provider.RegisterServiceProcedure(o.Service, o.Procedure, policy)
+		}
+	}
+
+	opts := []MiddlewareOption{WithPolicyProvider(provider)}
+	if c.Budget > 0 {
+		opts = append(opts, Budget(c.Budget))
+	}
+	return NewUnaryMiddleware(opts...), nil
+}
+
+func (c PolicyConfig) policy() *Policy {
+	opts := []PolicyOption{
+		Delay(c.Delay),
+		LatencyQuantile(c.LatencyQuantile),
+	}
+	if c.MaxHedges > 0 {
+		opts = append(opts, MaxHedges(c.MaxHedges))
+	}
+	return NewPolicy(opts...)
+}
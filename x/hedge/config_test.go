@@ -0,0 +1,136 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hedge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/config"
+	"go.uber.org/yarpc/internal/whitespace"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestConfig(t *testing.T) {
+	given := whitespace.Expand(`
+		budget: 0.05
+		policies:
+		  fast:
+		    latencyQuantile: 0.95
+		    delay: 20ms
+		    maxHedges: 2
+		  slow:
+		    delay: 1s
+		overrides:
+		  - service: users
+		    procedure: getUser
+		    with: fast
+		  - service: orders
+		    with: slow
+	`)
+	var unstructured config.AttributeMap
+	err := yaml.Unmarshal([]byte(given), &unstructured)
+	require.NoError(t, err)
+
+	var cfg Config
+	err = unstructured.Decode(&cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0.05, cfg.Budget)
+	assert.Equal(t, 0.95, cfg.Policies["fast"].LatencyQuantile)
+	assert.Equal(t, 20*time.Millisecond, cfg.Policies["fast"].Delay)
+	assert.Equal(t, uint(2), cfg.Policies["fast"].MaxHedges)
+	require.Len(t, cfg.Overrides, 2)
+
+	mw, err := cfg.Build()
+	require.NoError(t, err)
+	assert.Equal(t, 0.05, mw.budget.ratio)
+
+	policy := func(service, procedure string) *Policy {
+		return mw.provider.Policy(context.Background(), &transport.Request{Service: service, Procedure: procedure})
+	}
+
+	fast := policy("users", "getUser")
+	require.NotNil(t, fast)
+	assert.Equal(t, 0.95, fast.quantile)
+	assert.Equal(t, uint(2), fast.maxHedges)
+	assert.Nil(t, policy("users", "createUser"))
+
+	slow := policy("orders", "getOrder")
+	require.NotNil(t, slow)
+	assert.Equal(t, time.Second, slow.delay)
+	assert.Equal(t, uint(1), slow.maxHedges)
+}
+
+func TestConfigErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		give    Config
+		wantErr string
+	}{
+		{
+			desc:    "negative budget",
+			give:    Config{Budget: -1},
+			wantErr: "hedge budget must not be negative",
+		},
+		{
+			desc: "no delay",
+			give: Config{
+				Policies: map[string]PolicyConfig{"p": {}},
+			},
+			wantErr: `hedge policy "p" must specify a delay or latencyQuantile`,
+		},
+		{
+			desc: "invalid quantile",
+			give: Config{
+				Policies: map[string]PolicyConfig{"p": {LatencyQuantile: 1.5}},
+			},
+			wantErr: `hedge policy "p": latencyQuantile must be between 0 and 1`,
+		},
+		{
+			desc: "unknown policy",
+			give: Config{
+				Overrides: []OverrideConfig{{Service: "foo", With: "missing"}},
+			},
+			wantErr: `hedge policy "missing" for service "foo" is not defined`,
+		},
+		{
+			desc: "override without service",
+			give: Config{
+				Policies:  map[string]PolicyConfig{"p": {Delay: time.Second}},
+				Overrides: []OverrideConfig{{Procedure: "bar", With: "p"}},
+			},
+			wantErr: `hedge policy override for "p" must specify a service`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := tt.give.Build()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package hedge provides an outbound middleware that reduces tail latency by
+// hedging slow unary requests.
+//
+// When a request to a hedged procedure has not completed after a delay, the
+// middleware sends a duplicate of the request through the same outbound. The
+// first successful response is returned and the other attempts are
+// cancelled. The peer lists of YARPC send each hedge to a peer that has not
+// been tried for the request yet, when there is one.
+//
+// Hedging is opt-in: only procedures with a registered Policy are hedged.
+// Since hedged requests may be handled more than once, hedge only idempotent
+// procedures. The extra load is capped by a budget shared by all procedures,
+// and hedges are counted by the "hedges" and "hedges_won" metrics of the
+// outbound observability edge.
+//
+// Policies may be configured from YAML using Config:
+//
+//  budget: 0.05
+//  policies:
+//    fast:
+//      latencyQuantile: 0.95
+//      delay: 20ms
+//  overrides:
+//    - service: users
+//      procedure: getUser
+//      with: fast
+package hedge
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/observability"
+	"go.uber.org/yarpc/internal/triedpeers"
+)
+
+const (
+	_defaultBudget = 0.1
+
+	// _budgetBurst is the number of hedges the budget allows in a burst,
+	// after a period with enough requests.
+	_budgetBurst = 10
+)
+
+type middlewareOptions struct {
+	provider PolicyProvider
+	budget   float64
+}
+
+// MiddlewareOption customizes the behavior of the hedging middleware.
+type MiddlewareOption func(*middlewareOptions)
+
+// WithPolicyProvider sets the PolicyProvider used to select the hedging
+// policy of each request.
+//
+// By default, no requests are hedged.
+func WithPolicyProvider(provider PolicyProvider) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.provider = provider
+	}
+}
+
+// Budget caps the number of hedges to the given fraction of the requests
+// made to hedged procedures. For example, Budget(0.05) adds at most 5% load.
+//
+// Defaults to 0.1.
+func Budget(ratio float64) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.budget = ratio
+	}
+}
+
+// NewUnaryMiddleware builds an outbound middleware that hedges slow unary
+// requests.
+func NewUnaryMiddleware(opts ...MiddlewareOption) *OutboundMiddleware {
+	options := middlewareOptions{budget: _defaultBudget}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.provider == nil {
+		options.provider = NewProcedurePolicyProvider()
+	}
+	return &OutboundMiddleware{
+		provider: options.provider,
+		budget:   newBudget(options.budget, _budgetBurst),
+		refs:     make(map[serviceProcedure]*observability.EdgeRef),
+	}
+}
+
+// OutboundMiddleware is a unary outbound middleware that hedges requests
+// which take longer than the delay of their policy.
+type OutboundMiddleware struct {
+	provider PolicyProvider
+	budget   *budget
+
+	refsMu sync.RWMutex
+	refs   map[serviceProcedure]*observability.EdgeRef
+}
+
+var _ middleware.UnaryOutbound = (*OutboundMiddleware)(nil)
+
+type attemptResult struct {
+	index int
+	res   *transport.Response
+	err   error
+}
+
+// Call sends the request, hedging it according to the policy selected for
+// the request, and returns the first successful response.
+func (m *OutboundMiddleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	policy := m.provider.Policy(ctx, req)
+	if policy == nil {
+		return out.Call(ctx, req)
+	}
+
+	ref := m.edgeRef(req)
+	ctx = observability.WithEdgeRef(ctx, ref)
+	ctx = triedpeers.NewContext(ctx, &triedpeers.Set{})
+	delay, ok := policy.hedgeDelay(ref)
+	if !ok {
+		return out.Call(ctx, req)
+	}
+	m.budget.deposit()
+
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(chan attemptResult, policy.maxHedges+1)
+	var cancels []context.CancelFunc
+	cancelAll := func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}
+	start := func(ctx context.Context) {
+		index := len(cancels)
+		ctx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+
+		attemptReq := *req
+		attemptReq.Body = bytes.NewReader(body)
+		go func() {
+			res, err := out.Call(ctx, &attemptReq)
+			results <- attemptResult{index: index, res: res, err: err}
+		}()
+	}
+
+	start(ctx)
+	inflight := 1
+	hedges := uint(0)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case r := <-results:
+			inflight--
+			if r.err != nil {
+				if inflight > 0 {
+					closeBody(r.res)
+					continue
+				}
+				cancelAll()
+				return r.res, r.err
+			}
+
+			if r.index > 0 {
+				ref.HedgeWon()
+			}
+			for i, cancel := range cancels {
+				if i != r.index {
+					cancel()
+				}
+			}
+			go drain(results, inflight)
+			return withCancel(r.res, cancels[r.index]), nil
+
+		case <-timer.C:
+			if hedges >= policy.maxHedges || ctx.Err() != nil || !m.budget.withdraw() {
+				continue
+			}
+			hedges++
+			inflight++
+			start(observability.WithHedgeAttempt(ctx))
+			if hedges < policy.maxHedges {
+				timer.Reset(delay)
+			}
+		}
+	}
+}
+
+func (m *OutboundMiddleware) edgeRef(req *transport.Request) *observability.EdgeRef {
+	key := serviceProcedure{service: req.Service, procedure: req.Procedure}
+
+	m.refsMu.RLock()
+	ref, ok := m.refs[key]
+	m.refsMu.RUnlock()
+	if ok {
+		return ref
+	}
+
+	m.refsMu.Lock()
+	defer m.refsMu.Unlock()
+	if ref, ok := m.refs[key]; ok {
+		return ref
+	}
+	ref = &observability.EdgeRef{}
+	m.refs[key] = ref
+	return ref
+}
+
+// drain releases the responses of attempts that lost the race.
+func drain(results <-chan attemptResult, n int) {
+	for i := 0; i < n; i++ {
+		closeBody((<-results).res)
+	}
+}
+
+func closeBody(res *transport.Response) {
+	if res != nil && res.Body != nil {
+		res.Body.Close()
+	}
+}
+
+func readBody(req *transport.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	return ioutil.ReadAll(req.Body)
+}
+
+// withCancel ties the lifetime of the attempt context to the response body,
+// which may still be streaming from the attempt.
+func withCancel(res *transport.Response, cancel context.CancelFunc) *transport.Response {
+	if res == nil || res.Body == nil {
+		cancel()
+		return res
+	}
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	return res
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
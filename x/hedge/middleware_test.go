@@ -0,0 +1,350 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hedge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/triedpeers"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type attemptFunc func(ctx context.Context) (*transport.Response, error)
+
+// fakeOutbound runs the given attempt functions in the order in which the
+// attempts are made.
+type fakeOutbound struct {
+	transport.UnaryOutbound
+
+	t        *testing.T
+	mu       sync.Mutex
+	attempts []attemptFunc
+	calls    int
+}
+
+func (o *fakeOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	require.NoError(o.t, err)
+	assert.Equal(o.t, "body", string(body), "request body must be intact for every attempt")
+
+	o.mu.Lock()
+	i := o.calls
+	o.calls++
+	o.mu.Unlock()
+
+	require.True(o.t, i < len(o.attempts), "unexpected attempt %d", i)
+	return o.attempts[i](ctx)
+}
+
+func (o *fakeOutbound) Calls() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.calls
+}
+
+func newRequest() *transport.Request {
+	return &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: "procedure",
+		Body:      strings.NewReader("body"),
+	}
+}
+
+func newResponse(body string) *transport.Response {
+	return &transport.Response{Body: ioutil.NopCloser(bytes.NewReader([]byte(body)))}
+}
+
+// closeRecorder is a response body which records whether it was closed.
+type closeRecorder struct {
+	*bytes.Reader
+
+	closed chan struct{}
+}
+
+func newCloseRecorder(body string) *closeRecorder {
+	return &closeRecorder{Reader: bytes.NewReader([]byte(body)), closed: make(chan struct{})}
+}
+
+func (r *closeRecorder) Close() error {
+	close(r.closed)
+	return nil
+}
+
+func (r *closeRecorder) assertClosed(t *testing.T, msg string) {
+	select {
+	case <-r.closed:
+	case <-time.After(time.Second):
+		t.Fatal(msg)
+	}
+}
+
+func newMiddleware(policy *Policy, opts ...MiddlewareOption) *OutboundMiddleware {
+	provider := NewProcedurePolicyProvider()
+	provider.RegisterService("service", policy)
+	return NewUnaryMiddleware(append([]MiddlewareOption{WithPolicyProvider(provider)}, opts...)...)
+}
+
+func readResponse(t *testing.T, res *transport.Response) string {
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	return string(body)
+}
+
+func TestMiddlewareNoPolicy(t *testing.T) {
+	out := &fakeOutbound{t: t, attempts: []attemptFunc{
+		func(context.Context) (*transport.Response, error) {
+			return newResponse("primary"), nil
+		},
+	}}
+
+	res, err := NewUnaryMiddleware().Call(context.Background(), newRequest(), out)
+	require.NoError(t, err)
+	assert.Equal(t, "primary", readResponse(t, res))
+	assert.Equal(t, 1, out.Calls())
+}
+
+func TestMiddlewareFastResponse(t *testing.T) {
+	out := &fakeOutbound{t: t, attempts: []attemptFunc{
+		func(context.Context) (*transport.Response, error) {
+			return newResponse("primary"), nil
+		},
+	}}
+
+	mw := newMiddleware(NewPolicy(Delay(time.Minute)), Budget(1))
+	res, err := mw.Call(context.Background(), newRequest(), out)
+	require.NoError(t, err)
+	assert.Equal(t, "primary", readResponse(t, res))
+	assert.Equal(t, 1, out.Calls())
+}
+
+func TestMiddlewareHedgeWins(t *testing.T) {
+	primaryCancelled := make(chan struct{})
+	var hedgeCtx context.Context
+	out := &fakeOutbound{t: t, attempts: []attemptFunc{
+		func(ctx context.Context) (*transport.Response, error) {
+			<-ctx.Done()
+			close(primaryCancelled)
+			return nil, yarpcerrors.CancelledErrorf("cancelled")
+		},
+		func(ctx context.Context) (*transport.Response, error) {
+			hedgeCtx = ctx
+			return newResponse("hedge"), nil
+		},
+	}}
+
+	mw := newMiddleware(NewPolicy(Delay(time.Millisecond)), Budget(1))
+	res, err := mw.Call(context.Background(), newRequest(), out)
+	require.NoError(t, err)
+
+	select {
+	case <-primaryCancelled:
+	case <-time.After(time.Second):
+		t.Fatal("primary attempt was not cancelled")
+	}
+
+	assert.NoError(t, hedgeCtx.Err(), "winning attempt must live until its response is closed")
+	assert.Equal(t, "hedge", readResponse(t, res))
+	assert.Error(t, hedgeCtx.Err(), "closing the response must release the winning attempt")
+}
+
+func TestMiddlewareHedgeAvoidsTriedPeers(t *testing.T) {
+	primaryChosen := make(chan struct{})
+	out := &fakeOutbound{t: t, attempts: []attemptFunc{
+		func(ctx context.Context) (*transport.Response, error) {
+			// Stands in for the peer list of the outbound.
+			triedpeers.FromContext(ctx).Add("primary-peer")
+			close(primaryChosen)
+			<-ctx.Done()
+			return nil, yarpcerrors.CancelledErrorf("cancelled")
+		},
+		func(ctx context.Context) (*transport.Response, error) {
+			<-primaryChosen
+			assert.True(t, triedpeers.FromContext(ctx).Contains("primary-peer"),
+				"hedge must know the peer of the primary attempt")
+			return newResponse("hedge"), nil
+		},
+	}}
+
+	mw := newMiddleware(NewPolicy(Delay(time.Millisecond)), Budget(1))
+	res, err := mw.Call(context.Background(), newRequest(), out)
+	require.NoError(t, err)
+	assert.Equal(t, "hedge", readResponse(t, res))
+}
+
+func TestMiddlewarePrimaryFailsDuringHedge(t *testing.T) {
+	hedgeStarted := make(chan struct{})
+	out := &fakeOutbound{t: t, attempts: []attemptFunc{
+		func(ctx context.Context) (*transport.Response, error) {
+			<-hedgeStarted
+			return nil, yarpcerrors.UnavailableErrorf("unavailable")
+		},
+		func(ctx context.Context) (*transport.Response, error) {
+			close(hedgeStarted)
+			return newResponse("hedge"), nil
+		},
+	}}
+
+	mw := newMiddleware(NewPolicy(Delay(time.Millisecond)), Budget(1))
+	res, err := mw.Call(context.Background(), newRequest(), out)
+	require.NoError(t, err)
+	assert.Equal(t, "hedge", readResponse(t, res))
+}
+
+func TestMiddlewareClosesFailedResponse(t *testing.T) {
+	hedgeStarted := make(chan struct{})
+	failed := newCloseRecorder("failed")
+	out := &fakeOutbound{t: t, attempts: []attemptFunc{
+		func(ctx context.Context) (*transport.Response, error) {
+			<-hedgeStarted
+			return &transport.Response{Body: failed}, yarpcerrors.InternalErrorf("internal")
+		},
+		func(ctx context.Context) (*transport.Response, error) {
+			close(hedgeStarted)
+			<-failed.closed
+			return newResponse("hedge"), nil
+		},
+	}}
+
+	mw := newMiddleware(NewPolicy(Delay(time.Millisecond)), Budget(1))
+	res, err := mw.Call(context.Background(), newRequest(), out)
+	require.NoError(t, err)
+	assert.Equal(t, "hedge", readResponse(t, res))
+	failed.assertClosed(t, "response of the failed attempt was not closed")
+}
+
+func TestMiddlewareClosesLosingResponse(t *testing.T) {
+	hedgeStarted := make(chan struct{})
+	primaryWon := make(chan struct{})
+	losing := newCloseRecorder("hedge")
+	out := &fakeOutbound{t: t, attempts: []attemptFunc{
+		func(ctx context.Context) (*transport.Response, error) {
+			<-hedgeStarted
+			defer close(primaryWon)
+			return newResponse("primary"), nil
+		},
+		func(ctx context.Context) (*transport.Response, error) {
+			close(hedgeStarted)
+			<-primaryWon
+			return &transport.Response{Body: losing}, nil
+		},
+	}}
+
+	mw := newMiddleware(NewPolicy(Delay(time.Millisecond)), Budget(1))
+	res, err := mw.Call(context.Background(), newRequest(), out)
+	require.NoError(t, err)
+	assert.Equal(t, "primary", readResponse(t, res))
+	losing.assertClosed(t, "response of the losing attempt was not closed")
+}
+
+func TestMiddlewareAllAttemptsFail(t *testing.T) {
+	primaryErr := errors.New("primary failed")
+	hedgeErr := errors.New("hedge failed")
+	hedgeFailed := make(chan struct{})
+	out := &fakeOutbound{t: t, attempts: []attemptFunc{
+		func(ctx context.Context) (*transport.Response, error) {
+			<-hedgeFailed
+			return nil, primaryErr
+		},
+		func(ctx context.Context) (*transport.Response, error) {
+			defer close(hedgeFailed)
+			return nil, hedgeErr
+		},
+	}}
+
+	mw := newMiddleware(NewPolicy(Delay(time.Millisecond)), Budget(1))
+	_, err := mw.Call(context.Background(), newRequest(), out)
+	require.Error(t, err)
+	assert.Contains(t, []error{primaryErr, hedgeErr}, err)
+	assert.Equal(t, 2, out.Calls())
+}
+
+func TestMiddlewareBudgetExhausted(t *testing.T) {
+	out := &fakeOutbound{t: t, attempts: []attemptFunc{
+		func(ctx context.Context) (*transport.Response, error) {
+			time.Sleep(20 * time.Millisecond)
+			return newResponse("primary"), nil
+		},
+	}}
+
+	mw := newMiddleware(NewPolicy(Delay(time.Millisecond)), Budget(0))
+	res, err := mw.Call(context.Background(), newRequest(), out)
+	require.NoError(t, err)
+	assert.Equal(t, "primary", readResponse(t, res))
+	assert.Equal(t, 1, out.Calls())
+}
+
+func TestMiddlewareUnknownLatency(t *testing.T) {
+	out := &fakeOutbound{t: t, attempts: []attemptFunc{
+		func(ctx context.Context) (*transport.Response, error) {
+			time.Sleep(20 * time.Millisecond)
+			return newResponse("primary"), nil
+		},
+	}}
+
+	// Without a static delay, requests are not hedged until the latency of
+	// the procedure has been observed.
+	mw := newMiddleware(NewPolicy(LatencyQuantile(0.9)), Budget(1))
+	res, err := mw.Call(context.Background(), newRequest(), out)
+	require.NoError(t, err)
+	assert.Equal(t, "primary", readResponse(t, res))
+	assert.Equal(t, 1, out.Calls())
+}
+
+func TestProcedurePolicyProvider(t *testing.T) {
+	var (
+		servicePolicy   = NewPolicy(Delay(time.Second))
+		procedurePolicy = NewPolicy(Delay(time.Millisecond))
+	)
+
+	provider := NewProcedurePolicyProvider()
+	provider.RegisterService("foo", servicePolicy)
+	provider.RegisterServiceProcedure("foo", "bar", procedurePolicy)
+
+	tests := []struct {
+		service   string
+		procedure string
+		want      *Policy
+	}{
+		{service: "foo", procedure: "bar", want: procedurePolicy},
+		{service: "foo", procedure: "baz", want: servicePolicy},
+		{service: "qux", procedure: "bar", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.service+"::"+tt.procedure, func(t *testing.T) {
+			req := &transport.Request{Service: tt.service, Procedure: tt.procedure}
+			assert.True(t, tt.want == provider.Policy(context.Background(), req))
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hedge
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/internal/observability"
+)
+
+// Policy describes when a request should be hedged.
+//
+// Policies are immutable and may be shared between procedures.
+type Policy struct {
+	// delay is the static delay before sending a hedge.
+	delay time.Duration
+	// quantile, if non-zero, derives the delay from the observed latency of
+	// the procedure.
+	quantile float64
+	// maxHedges is the maximum number of hedges sent for each request.
+	maxHedges uint
+}
+
+// PolicyOption customizes a Policy.
+type PolicyOption func(*Policy)
+
+// Delay sets a static delay after which a request that has not completed is
+// hedged.
+//
+// If LatencyQuantile is also used, this delay applies only until enough
+// latencies have been observed.
+func Delay(delay time.Duration) PolicyOption {
+	return func(p *Policy) {
+		p.delay = delay
+	}
+}
+
+// LatencyQuantile hedges requests that take longer than the given quantile,
+// between 0 and 1, of the latencies observed for successful calls to the same
+// procedure. For example, LatencyQuantile(0.95) hedges the slowest 5% of
+// requests.
+//
+// Latencies are read from the metrics of the outbound observability edge, and
+// are rounded up to the bucket of the latency histogram.
+func LatencyQuantile(q float64) PolicyOption {
+	return func(p *Policy) {
+		p.quantile = q
+	}
+}
+
+// MaxHedges sets the maximum number of duplicate requests sent for each
+// request, each after a further delay.
+//
+// Defaults to 1.
+func MaxHedges(n uint) PolicyOption {
+	return func(p *Policy) {
+		p.maxHedges = n
+	}
+}
+
+// NewPolicy builds a hedging Policy.
+//
+// A Policy without a delay or latency quantile never hedges requests.
+func NewPolicy(opts ...PolicyOption) *Policy {
+	p := &Policy{maxHedges: 1}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// hedgeDelay returns the delay after which requests along the given edge
+// should be hedged, or false if they should not be hedged.
+func (p *Policy) hedgeDelay(ref *observability.EdgeRef) (time.Duration, bool) {
+	if p.maxHedges == 0 {
+		return 0, false
+	}
+	if p.quantile > 0 {
+		if d, ok := ref.LatencyQuantile(p.quantile); ok {
+			return d, true
+		}
+	}
+	return p.delay, p.delay > 0
+}
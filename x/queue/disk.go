@@ -0,0 +1,499 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package queue
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	_segmentSuffix    = ".log"
+	_cursorFile       = "cursor"
+	_recordHeaderSize = 8
+)
+
+var (
+	// errCorruptRecord is returned for records whose payload doesn't match
+	// its checksum. The records after it can still be read.
+	errCorruptRecord = errors.New("corrupt queue record")
+
+	// errTruncatedRecord is returned for records which extend past the end
+	// of their segment. The records after it, if any, can't be found.
+	errTruncatedRecord = errors.New("truncated queue record")
+)
+
+// position identifies a record in the queue by the segment it is stored in
+// and its offset in that segment.
+type position struct {
+	segment uint64
+	offset  int64
+}
+
+// diskQueue is an append-only queue of records stored in a directory.
+//
+// Records are appended to numbered segment files, starting a new segment
+// once the current one grows past segmentSize. Each record is framed as,
+//
+//	length:uint32 crc32:uint32 payload:[length]byte
+//
+// in big endian, where crc32 is the IEEE checksum of the payload. The
+// position of the first record that has not been consumed is kept in the
+// cursor file, and segments are deleted once every record in them has been
+// consumed.
+//
+// Records are synced to disk before append returns. A record that was only
+// partially written when the process died is discarded when the queue is
+// next opened. Records that were damaged on disk are skipped when they are
+// consumed, and reported to the onCorrupt function of the queue.
+type diskQueue struct {
+	dir         string
+	segmentSize int64
+	onCorrupt   func()
+
+	// ready receives a value whenever a record is appended.
+	ready chan struct{}
+
+	mu     sync.Mutex
+	head   position // next record to consume
+	tail   position // where the next record is appended
+	depth  int64
+	reader *os.File // segment of head, opened lazily
+	writer *os.File // segment of tail
+}
+
+// openDiskQueue opens the queue stored in the given directory, creating it
+// if needed. The onCorrupt function, which may be nil, is called whenever
+// damaged records are skipped.
+func openDiskQueue(dir string, segmentSize int64, onCorrupt func()) (*diskQueue, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	head, ok, err := readCursor(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		head = position{segment: 1}
+		if len(segments) > 0 {
+			head.segment = segments[0]
+		}
+	}
+
+	if onCorrupt == nil {
+		onCorrupt = func() {}
+	}
+	q := &diskQueue{
+		dir:         dir,
+		segmentSize: segmentSize,
+		onCorrupt:   onCorrupt,
+		ready:       make(chan struct{}, 1),
+		head:        head,
+	}
+
+	// Segments before the cursor were consumed before they could be deleted.
+	var remaining []uint64
+	for _, s := range segments {
+		if s >= head.segment {
+			remaining = append(remaining, s)
+			continue
+		}
+		if err := os.Remove(q.segmentPath(s)); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	if len(remaining) == 0 {
+		q.head.offset = 0
+		remaining = []uint64{head.segment}
+	}
+
+	// Count the records left in every segment, and find where the last
+	// complete record of the last segment ends. Corrupt records are counted
+	// until they are skipped.
+	var end int64
+	for _, s := range remaining {
+		var start int64
+		if s == q.head.segment {
+			start = q.head.offset
+		}
+		var n int64
+		n, end, err = scanSegment(q.segmentPath(s), start)
+		if err != nil {
+			return nil, err
+		}
+		q.depth += n
+	}
+
+	last := remaining[len(remaining)-1]
+	w, err := os.OpenFile(q.segmentPath(last), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	// Discard a record that was only partially written.
+	if err := w.Truncate(end); err != nil {
+		w.Close()
+		return nil, err
+	}
+	q.writer = w
+	q.tail = position{segment: last, offset: end}
+	if q.head.segment == last && q.head.offset > end {
+		q.head.offset = end
+	}
+	return q, nil
+}
+
+func (q *diskQueue) segmentPath(segment uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", segment, _segmentSuffix))
+}
+
+// Depth returns the number of records that have not been consumed.
+func (q *diskQueue) Depth() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.depth
+}
+
+// append durably adds a record to the end of the queue.
+func (q *diskQueue) append(payload []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.tail.offset >= q.segmentSize {
+		if err := q.rotate(); err != nil {
+			return err
+		}
+	}
+
+	record := make([]byte, _recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	copy(record[_recordHeaderSize:], payload)
+
+	if _, err := q.writer.Write(record); err != nil {
+		// Don't leave a partial record behind for the next append.
+		q.writer.Truncate(q.tail.offset)
+		return err
+	}
+	if err := q.writer.Sync(); err != nil {
+		q.writer.Truncate(q.tail.offset)
+		return err
+	}
+	q.tail.offset += int64(len(record))
+	q.depth++
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// rotate starts a new segment for appended records.
+func (q *diskQueue) rotate() error {
+	next := q.tail.segment + 1
+	w, err := os.OpenFile(q.segmentPath(next), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if err := q.writer.Close(); err != nil {
+		w.Close()
+		return err
+	}
+	q.writer = w
+	q.tail = position{segment: next}
+	return nil
+}
+
+// peek returns the first record that has not been consumed along with the
+// position of the record after it, or false if the queue is empty. Damaged
+// records are consumed and skipped.
+func (q *diskQueue) peek() (payload []byte, next position, ok bool, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		if q.head.segment == q.tail.segment && q.head.offset >= q.tail.offset {
+			return nil, position{}, false, nil
+		}
+
+		limit := q.tail.offset
+		if q.head.segment != q.tail.segment {
+			limit, err = q.headSegmentSize()
+			if err != nil {
+				return nil, position{}, false, err
+			}
+		}
+
+		var end int64
+		payload, end, err = q.readHead(limit)
+		switch {
+		case err == nil:
+			return payload, position{segment: q.head.segment, offset: end}, true, nil
+
+		case err == errCorruptRecord:
+			// Retrying can't repair the record, so skip it.
+			if err = q.moveHead(position{segment: q.head.segment, offset: end}); err != nil {
+				return nil, position{}, false, err
+			}
+			q.depth--
+			q.onCorrupt()
+
+		case err == errTruncatedRecord && q.head.segment == q.tail.segment:
+			// The records up to the tail can't be found, so skip them all.
+			if err = q.moveHead(q.tail); err != nil {
+				return nil, position{}, false, err
+			}
+			q.depth = 0
+			q.onCorrupt()
+
+		case err == io.EOF || err == errTruncatedRecord:
+			// The rest of an older segment is either consumed or unreadable.
+			if err == errTruncatedRecord {
+				q.onCorrupt()
+			}
+			if err = q.moveHead(position{segment: q.head.segment + 1}); err != nil {
+				return nil, position{}, false, err
+			}
+
+		default:
+			return nil, position{}, false, err
+		}
+	}
+}
+
+// commit marks every record before the given position as consumed.
+func (q *diskQueue) commit(next position) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.moveHead(next); err != nil {
+		return err
+	}
+	q.depth--
+	return nil
+}
+
+func (q *diskQueue) headSegmentSize() (int64, error) {
+	if err := q.openReader(); err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	info, err := q.reader.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (q *diskQueue) readHead(limit int64) ([]byte, int64, error) {
+	if q.head.offset >= limit {
+		return nil, q.head.offset, io.EOF
+	}
+	if err := q.openReader(); err != nil {
+		return nil, q.head.offset, err
+	}
+	return readRecord(q.reader, q.head.offset, limit)
+}
+
+func (q *diskQueue) openReader() error {
+	if q.reader != nil {
+		return nil
+	}
+	f, err := os.Open(q.segmentPath(q.head.segment))
+	if err != nil {
+		return err
+	}
+	q.reader = f
+	return nil
+}
+
+// moveHead persists the new head position and deletes segments before it.
+func (q *diskQueue) moveHead(head position) error {
+	if err := writeCursor(q.dir, head); err != nil {
+		return err
+	}
+	for s := q.head.segment; s < head.segment; s++ {
+		if s == q.head.segment && q.reader != nil {
+			q.reader.Close()
+			q.reader = nil
+		}
+		if err := os.Remove(q.segmentPath(s)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	q.head = head
+	return nil
+}
+
+func (q *diskQueue) close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var err error
+	if q.reader != nil {
+		err = q.reader.Close()
+		q.reader = nil
+	}
+	if cerr := q.writer.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// readRecord reads the record at the given offset of a segment, returning
+// its payload and the offset of the following record. Records may not extend
+// past limit.
+//
+// Corrupt records are reported with errCorruptRecord along with the offset
+// of the following record, and records extending past limit with
+// errTruncatedRecord.
+func readRecord(f *os.File, offset, limit int64) ([]byte, int64, error) {
+	if offset >= limit {
+		return nil, offset, io.EOF
+	}
+	if limit-offset < _recordHeaderSize {
+		return nil, offset, errTruncatedRecord
+	}
+
+	var header [_recordHeaderSize]byte
+	if _, err := f.ReadAt(header[:], offset); err != nil {
+		return nil, offset, err
+	}
+	length := int64(binary.BigEndian.Uint32(header[0:4]))
+	if length > limit-offset-_recordHeaderSize {
+		return nil, offset, errTruncatedRecord
+	}
+
+	payload := make([]byte, length)
+	if _, err := f.ReadAt(payload, offset+_recordHeaderSize); err != nil {
+		return nil, offset, err
+	}
+	next := offset + _recordHeaderSize + length
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, next, errCorruptRecord
+	}
+	return payload, next, nil
+}
+
+// scanSegment counts the complete records of a segment from the given
+// offset, including corrupt ones, and returns the offset at which they end.
+func scanSegment(path string, offset int64) (n int64, end int64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, 0, nil
+		}
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	end = offset
+	if end > info.Size() {
+		end = info.Size()
+	}
+	for {
+		_, next, err := readRecord(f, end, info.Size())
+		switch err {
+		case nil, errCorruptRecord:
+			n++
+			end = next
+		case io.EOF, errTruncatedRecord:
+			return n, end, nil
+		default:
+			return 0, 0, err
+		}
+	}
+}
+
+// listSegments returns the numbers of the segments in a directory in
+// ascending order.
+func listSegments(dir string) ([]uint64, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*"+_segmentSuffix))
+	if err != nil {
+		return nil, err
+	}
+	var segments []uint64
+	for _, name := range names {
+		s, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(name), _segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, s)
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i] < segments[j] })
+	return segments, nil
+}
+
+func readCursor(dir string) (pos position, ok bool, err error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, _cursorFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return position{}, false, nil
+		}
+		return position{}, false, err
+	}
+	if _, err := fmt.Sscanf(string(b), "%d %d", &pos.segment, &pos.offset); err != nil {
+		return position{}, false, fmt.Errorf("invalid queue cursor %q: %v", b, err)
+	}
+	return pos, true, nil
+}
+
+// writeCursor atomically replaces the cursor file.
+func writeCursor(dir string, pos position) error {
+	tmp := filepath.Join(dir, _cursorFile+".tmp")
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(f, "%d %d\n", pos.segment, pos.offset); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, _cursorFile))
+}
@@ -0,0 +1,275 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package queue
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "yarpc-queue")
+	require.NoError(t, err)
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func appendAll(t *testing.T, q *diskQueue, records ...string) {
+	for _, r := range records {
+		require.NoError(t, q.append([]byte(r)), "failed to append %q", r)
+	}
+}
+
+// consumeAll reads and commits every record in the queue.
+func consumeAll(t *testing.T, q *diskQueue) []string {
+	var records []string
+	for {
+		payload, next, ok, err := q.peek()
+		require.NoError(t, err)
+		if !ok {
+			return records
+		}
+		records = append(records, string(payload))
+		require.NoError(t, q.commit(next))
+	}
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	names, err := filepath.Glob(filepath.Join(dir, "*"+_segmentSuffix))
+	require.NoError(t, err)
+	for i, name := range names {
+		names[i] = filepath.Base(name)
+	}
+	return names
+}
+
+func TestDiskQueue(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	q, err := openDiskQueue(dir, 1024, nil)
+	require.NoError(t, err)
+	defer q.close()
+
+	_, _, ok, err := q.peek()
+	require.NoError(t, err)
+	assert.False(t, ok, "new queue must be empty")
+
+	appendAll(t, q, "foo", "bar", "")
+	assert.Equal(t, int64(3), q.Depth())
+
+	select {
+	case <-q.ready:
+	default:
+		t.Fatal("appending must signal readiness")
+	}
+
+	// Peeking without committing returns the same record.
+	payload, _, ok, err := q.peek()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "foo", string(payload))
+
+	assert.Equal(t, []string{"foo", "bar", ""}, consumeAll(t, q))
+	assert.Equal(t, int64(0), q.Depth())
+
+	appendAll(t, q, "baz")
+	assert.Equal(t, []string{"baz"}, consumeAll(t, q))
+}
+
+func TestDiskQueueSegments(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	// Every record fills a segment.
+	q, err := openDiskQueue(dir, 1, nil)
+	require.NoError(t, err)
+	defer q.close()
+
+	appendAll(t, q, "a", "b", "c")
+	assert.Len(t, segmentFiles(t, dir), 3)
+
+	payload, next, ok, err := q.peek()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "a", string(payload))
+	require.NoError(t, q.commit(next))
+	assert.Len(t, segmentFiles(t, dir), 3, "a segment is deleted once the record after it is read")
+
+	assert.Equal(t, []string{"b", "c"}, consumeAll(t, q))
+	assert.Equal(t, []string{fmt.Sprintf("%020d%s", 3, _segmentSuffix)}, segmentFiles(t, dir),
+		"consumed segments must be deleted")
+}
+
+func TestDiskQueueReopen(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	q, err := openDiskQueue(dir, 16, nil)
+	require.NoError(t, err)
+	appendAll(t, q, "first", "second", "third", "fourth")
+
+	payload, next, ok, err := q.peek()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "first", string(payload))
+	require.NoError(t, q.commit(next))
+
+	// Peeked but not committed.
+	_, _, ok, err = q.peek()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, q.close())
+
+	q, err = openDiskQueue(dir, 16, nil)
+	require.NoError(t, err)
+	defer q.close()
+
+	assert.Equal(t, int64(3), q.Depth())
+	appendAll(t, q, "fifth")
+	assert.Equal(t, []string{"second", "third", "fourth", "fifth"}, consumeAll(t, q))
+}
+
+func TestDiskQueueTornWrite(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	q, err := openDiskQueue(dir, 1024, nil)
+	require.NoError(t, err)
+	appendAll(t, q, "foo", "bar")
+	require.NoError(t, q.close())
+
+	// Simulate a process dying half way through an append.
+	segments := segmentFiles(t, dir)
+	require.Len(t, segments, 1)
+	f, err := os.OpenFile(filepath.Join(dir, segments[0]), os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0, 0, 0, 42, 1, 2})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	q, err = openDiskQueue(dir, 1024, nil)
+	require.NoError(t, err)
+	defer q.close()
+
+	assert.Equal(t, int64(2), q.Depth())
+	appendAll(t, q, "baz")
+	assert.Equal(t, []string{"foo", "bar", "baz"}, consumeAll(t, q))
+}
+
+func TestDiskQueueCorruptSegment(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	q, err := openDiskQueue(dir, 20, nil)
+	require.NoError(t, err)
+	// "first" and "second" share a segment, "third" starts the next one.
+	appendAll(t, q, "first", "second", "third")
+	require.NoError(t, q.close())
+
+	segments := segmentFiles(t, dir)
+	require.Len(t, segments, 2)
+	path := filepath.Join(dir, segments[0])
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	b[len(b)-1] ^= 0xff // corrupt "second"
+	require.NoError(t, ioutil.WriteFile(path, b, 0644))
+
+	var corrupt int
+	q, err = openDiskQueue(dir, 20, func() { corrupt++ })
+	require.NoError(t, err)
+	defer q.close()
+
+	assert.Equal(t, int64(3), q.Depth(), "corrupt records must count until they are skipped")
+	assert.Equal(t, []string{"first", "third"}, consumeAll(t, q))
+	assert.Equal(t, int64(0), q.Depth())
+	assert.Equal(t, 1, corrupt, "skipped records must be reported")
+}
+
+// corruptRecord flips the last byte of the given record in the only segment
+// of a queue.
+func corruptRecord(t *testing.T, dir string, record string) {
+	segments := segmentFiles(t, dir)
+	require.Len(t, segments, 1)
+	path := filepath.Join(dir, segments[0])
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	i := bytes.Index(b, []byte(record))
+	require.True(t, i >= 0, "record %q not found", record)
+	b[i+len(record)-1] ^= 0xff
+	require.NoError(t, ioutil.WriteFile(path, b, 0644))
+}
+
+func TestDiskQueueCorruptRecordKeepsFollowingRecords(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	q, err := openDiskQueue(dir, 1024, nil)
+	require.NoError(t, err)
+	appendAll(t, q, "first", "second", "third")
+	require.NoError(t, q.close())
+	corruptRecord(t, dir, "second")
+
+	var corrupt int
+	q, err = openDiskQueue(dir, 1024, func() { corrupt++ })
+	require.NoError(t, err)
+	defer q.close()
+
+	appendAll(t, q, "fourth")
+	assert.Equal(t, []string{"first", "third", "fourth"}, consumeAll(t, q),
+		"records after a corrupt record must not be discarded")
+	assert.Equal(t, 1, corrupt)
+}
+
+func TestDiskQueueCorruptTail(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	var corrupt int
+	q, err := openDiskQueue(dir, 1024, func() { corrupt++ })
+	require.NoError(t, err)
+	defer q.close()
+
+	appendAll(t, q, "first", "second")
+	corruptRecord(t, dir, "second")
+	assert.Equal(t, []string{"first"}, consumeAll(t, q), "corrupt tail record must be skipped")
+	assert.Equal(t, 1, corrupt)
+
+	appendAll(t, q, "third")
+	assert.Equal(t, []string{"third"}, consumeAll(t, q), "records after a corrupt tail must be delivered")
+	assert.Equal(t, int64(0), q.Depth())
+}
+
+func TestDiskQueueInvalidCursor(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, _cursorFile), []byte("garbage"), 0644))
+	_, err := openDiskQueue(dir, 1024, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid queue cursor")
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package queue
+
+import (
+	"go.uber.org/yarpc/internal/pally"
+	"go.uber.org/yarpc/internal/sharedmetrics"
+)
+
+// newDepthsVector adds the number of requests waiting in queues to the given
+// Set.
+func newDepthsVector(metrics *sharedmetrics.Set) *sharedmetrics.GaugeVector {
+	return metrics.NewGaugeVector(pally.Opts{
+		Name:           "outbound_queue_depth",
+		Help:           "Number of requests waiting in durable outbound queues.",
+		VariableLabels: []string{"queue"},
+	})
+}
+
+// newDropsVector adds the requests dropped by queues to the given Set.
+func newDropsVector(metrics *sharedmetrics.Set) *sharedmetrics.CounterVector {
+	return metrics.NewCounterVector(pally.Opts{
+		Name:           "outbound_queue_drops",
+		Help:           "Number of requests dropped by durable outbound queues without being delivered.",
+		VariableLabels: []string{"queue"},
+	})
+}
@@ -0,0 +1,438 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package queue provides a oneway outbound which durably queues requests on
+// local disk and delivers them in the background, so that oneway requests
+// are not lost while the destination is unavailable.
+//
+// Requests are serialized with the serialize package and appended to an
+// append-only queue in a directory before they are acknowledged. A
+// background goroutine delivers queued requests in order through the wrapped
+// outbound, retrying failed requests with backoff. Requests that are still
+// queued when the outbound stops, or when the process dies, are delivered
+// once an outbound using the same directory starts again.
+//
+// Requests are delivered at least once: a request may be delivered again
+// if the process dies after delivering it but before recording that it was
+// delivered. Requests are dropped if they fail with an error that retrying
+// cannot fix, such as InvalidArgument or Unimplemented, if they are still
+// queued after their time to live, if they exhaust their attempts, or if
+// they were damaged on disk.
+//
+// The number of queued requests is reported in the introspection status of
+// the outbound, and by the Dispatchers using it as the "outbound_queue_depth"
+// metric. Dropped requests are counted by the "outbound_queue_drops" metric.
+//
+//  outbound := queue.NewOnewayOutbound(
+//    httpTransport.NewSingleOutbound("http://127.0.0.1:8080"),
+//    "/var/lib/myservice/queue",
+//    queue.TTL(time.Hour),
+//  )
+//
+// A directory must not be used by more than one outbound at a time.
+package queue
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/backoff"
+	"go.uber.org/yarpc/api/transport"
+	internalbackoff "go.uber.org/yarpc/internal/backoff"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/internal/sharedmetrics"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/serialize"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	_defaultTTL            = 24 * time.Hour
+	_defaultAttemptTimeout = 10 * time.Second
+	_defaultSegmentSize    = 64 * 1024 * 1024
+)
+
+type options struct {
+	name           string
+	tracer         opentracing.Tracer
+	backoff        backoff.Strategy
+	ttl            time.Duration
+	attemptTimeout time.Duration
+	maxAttempts    int
+	segmentSize    int64
+	clock          clock.Clock
+}
+
+// Option customizes the behavior of a queue outbound.
+type Option func(*options)
+
+// Name specifies the name of the queue in metrics and introspection.
+//
+// Defaults to the base name of the queue directory.
+func Name(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// Tracer specifies the tracer used to serialize the span context of queued
+// requests, and to trace their delivery.
+//
+// Defaults to the global opentracing.Tracer.
+func Tracer(tracer opentracing.Tracer) Option {
+	return func(o *options) {
+		o.tracer = tracer
+	}
+}
+
+// Backoff specifies the strategy used to wait between attempts to deliver a
+// request.
+//
+// Defaults to exponential backoff with full jitter, starting at 10ms and
+// capped at a minute.
+func Backoff(strategy backoff.Strategy) Option {
+	return func(o *options) {
+		o.backoff = strategy
+	}
+}
+
+// TTL specifies how long requests may wait in the queue. Requests that have
+// not been delivered by then are dropped. A TTL of zero lets requests wait
+// indefinitely.
+//
+// Defaults to 24 hours.
+func TTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// AttemptTimeout specifies how long each attempt to deliver a request may
+// take.
+//
+// Defaults to 10 seconds.
+func AttemptTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.attemptTimeout = timeout
+	}
+}
+
+// MaxAttempts specifies how many times delivering a request may be attempted
+// before it is dropped. Zero allows unlimited attempts.
+//
+// Defaults to unlimited attempts.
+func MaxAttempts(n int) Option {
+	return func(o *options) {
+		o.maxAttempts = n
+	}
+}
+
+// SegmentSize specifies the size, in bytes, after which the queue starts a
+// new file. Files are deleted once every request in them was delivered.
+//
+// Defaults to 64 MiB.
+func SegmentSize(size int64) Option {
+	return func(o *options) {
+		o.segmentSize = size
+	}
+}
+
+func withClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// OnewayOutbound is a transport.OnewayOutbound which queues requests on disk
+// and delivers them through another outbound in the background.
+type OnewayOutbound struct {
+	once     *lifecycle.Once
+	outbound transport.OnewayOutbound
+	dir      string
+	opts     options
+	metrics  *sharedmetrics.Set
+	depths   *sharedmetrics.GaugeVector
+	drops    *sharedmetrics.CounterVector
+
+	queue  *diskQueue
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	_ transport.OnewayOutbound             = (*OnewayOutbound)(nil)
+	_ introspection.IntrospectableOutbound = (*OnewayOutbound)(nil)
+)
+
+// NewOnewayOutbound builds an outbound which queues requests in the given
+// directory and delivers them through the given outbound.
+func NewOnewayOutbound(outbound transport.OnewayOutbound, dir string, opts ...Option) *OnewayOutbound {
+	o := options{
+		name:           filepath.Base(dir),
+		backoff:        internalbackoff.DefaultExponential,
+		ttl:            _defaultTTL,
+		attemptTimeout: _defaultAttemptTimeout,
+		segmentSize:    _defaultSegmentSize,
+		clock:          clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	metrics := sharedmetrics.NewSet()
+	return &OnewayOutbound{
+		once:     lifecycle.NewOnce(),
+		outbound: outbound,
+		dir:      dir,
+		opts:     o,
+		metrics:  metrics,
+		depths:   newDepthsVector(metrics),
+		drops:    newDropsVector(metrics),
+	}
+}
+
+// Transports returns the transports used by the wrapped outbound.
+func (o *OnewayOutbound) Transports() []transport.Transport {
+	return o.outbound.Transports()
+}
+
+// Metrics returns the metrics of the queue along with those of the wrapped
+// outbound.
+func (o *OnewayOutbound) Metrics() []*sharedmetrics.Set {
+	return append([]*sharedmetrics.Set{o.metrics}, sharedmetrics.Collect(o.outbound)...)
+}
+
+// Start opens the queue, starts the wrapped outbound, and starts delivering
+// requests left in the queue by earlier runs.
+func (o *OnewayOutbound) Start() error {
+	return o.once.Start(func() error {
+		q, err := openDiskQueue(o.dir, o.opts.segmentSize, o.drop)
+		if err != nil {
+			return err
+		}
+		if err := o.outbound.Start(); err != nil {
+			return multierr.Append(err, q.close())
+		}
+
+		o.queue = q
+		o.ctx, o.cancel = context.WithCancel(context.Background())
+		o.done = make(chan struct{})
+		o.observeDepth()
+		go o.deliverAll()
+		return nil
+	})
+}
+
+// Stop stops delivering requests and stops the wrapped outbound. Requests
+// that were not delivered yet stay in the queue.
+func (o *OnewayOutbound) Stop() error {
+	return o.once.Stop(func() error {
+		o.cancel()
+		<-o.done
+		o.depths.Delete(o.opts.name)
+		return multierr.Combine(o.outbound.Stop(), o.queue.close())
+	})
+}
+
+// IsRunning returns whether the outbound is running.
+func (o *OnewayOutbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// Depth returns the number of requests waiting to be delivered.
+func (o *OnewayOutbound) Depth() int64 {
+	if !o.once.IsRunning() {
+		return 0
+	}
+	return o.queue.Depth()
+}
+
+// Introspect returns the status of the wrapped outbound along with the
+// status of the queue.
+func (o *OnewayOutbound) Introspect() introspection.OutboundStatus {
+	status := introspection.OutboundStatusNotSupported
+	if i, ok := o.outbound.(introspection.IntrospectableOutbound); ok {
+		status = i.Introspect()
+	}
+	status.Queue = &introspection.QueueStatus{
+		Name:      o.opts.name,
+		Directory: o.dir,
+		Depth:     o.Depth(),
+	}
+	return status
+}
+
+// CallOneway queues the request and acknowledges it as soon as it is
+// durably stored.
+func (o *OnewayOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	if err := o.once.WaitUntilRunning(ctx); err != nil {
+		return nil, err
+	}
+
+	m := &serialize.Message{Request: req, Type: transport.Oneway}
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		m.SpanContext = span.Context()
+	}
+	if o.opts.ttl > 0 {
+		m.Deadline = o.opts.clock.Now().Add(o.opts.ttl)
+	}
+
+	var buf bytes.Buffer
+	if err := serialize.Encode(&buf, serialize.Version1, o.tracer(), m); err != nil {
+		return nil, err
+	}
+	if err := o.queue.append(buf.Bytes()); err != nil {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeInternal, "failed to queue request: %v", err)
+	}
+	o.observeDepth()
+	return o.opts.clock.Now(), nil
+}
+
+// deliverAll delivers queued requests in order until the outbound stops.
+func (o *OnewayOutbound) deliverAll() {
+	defer close(o.done)
+
+	boff := o.opts.backoff.Backoff()
+	var attempts uint
+	for {
+		payload, next, ok, err := o.queue.peek()
+		if err == nil && !ok {
+			select {
+			case <-o.queue.ready:
+				continue
+			case <-o.ctx.Done():
+				return
+			}
+		}
+
+		if err == nil && o.deliver(payload, attempts) {
+			// Failing to record the delivery retries it, delivering the
+			// request again.
+			if err = o.queue.commit(next); err == nil {
+				attempts = 0
+				o.observeDepth()
+				continue
+			}
+		}
+		if o.ctx.Err() != nil {
+			return
+		}
+
+		attempts++
+		select {
+		case <-o.opts.clock.After(boff.Duration(attempts)):
+		case <-o.ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver attempts to deliver a queued request, returning true if it was
+// either delivered or dropped, and false if it should be retried.
+func (o *OnewayOutbound) deliver(payload []byte, attempts uint) bool {
+	tracer := o.tracer()
+	m, err := serialize.Decode(bytes.NewReader(payload), tracer)
+	if err != nil {
+		// Retrying cannot fix a request that cannot be read.
+		o.drop()
+		return true
+	}
+	if !m.Deadline.IsZero() && !o.opts.clock.Now().Before(m.Deadline) {
+		o.drop()
+		return true
+	}
+	if o.opts.maxAttempts > 0 && attempts >= uint(o.opts.maxAttempts) {
+		o.drop()
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(o.ctx, o.opts.attemptTimeout)
+	defer cancel()
+	if !m.Deadline.IsZero() {
+		ctx, cancel = context.WithDeadline(ctx, m.Deadline)
+		defer cancel()
+	}
+
+	req := m.Request
+	span := tracer.StartSpan(
+		req.Procedure,
+		opentracing.FollowsFrom(m.SpanContext),
+		opentracing.Tags{
+			"rpc.caller":    req.Caller,
+			"rpc.service":   req.Service,
+			"rpc.encoding":  req.Encoding,
+			"rpc.transport": "queue",
+			"queue.attempt": attempts + 1,
+		},
+	)
+	_, err = o.outbound.CallOneway(opentracing.ContextWithSpan(ctx, span), req)
+	transport.UpdateSpanWithErr(span, err)
+	span.Finish()
+
+	if err == nil {
+		return true
+	}
+	if isPermanent(err) {
+		o.drop()
+		return true
+	}
+	return false
+}
+
+// isPermanent returns whether retrying a request which failed with the given
+// error cannot succeed.
+func isPermanent(err error) bool {
+	if !yarpcerrors.IsStatus(err) {
+		return false
+	}
+	switch yarpcerrors.FromError(err).Code() {
+	case yarpcerrors.CodeInvalidArgument,
+		yarpcerrors.CodeNotFound,
+		yarpcerrors.CodeAlreadyExists,
+		yarpcerrors.CodePermissionDenied,
+		yarpcerrors.CodeFailedPrecondition,
+		yarpcerrors.CodeOutOfRange,
+		yarpcerrors.CodeUnimplemented,
+		yarpcerrors.CodeUnauthenticated:
+		return true
+	default:
+		return false
+	}
+}
+
+func (o *OnewayOutbound) tracer() opentracing.Tracer {
+	if o.opts.tracer != nil {
+		return o.opts.tracer
+	}
+	return opentracing.GlobalTracer()
+}
+
+func (o *OnewayOutbound) observeDepth() {
+	o.depths.Store(o.queue.Depth(), o.opts.name)
+}
+
+func (o *OnewayOutbound) drop() {
+	o.drops.Inc(o.opts.name)
+}
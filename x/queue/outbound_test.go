@@ -0,0 +1,422 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/backoff"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/sharedmetrics"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type delivery struct {
+	ctx       context.Context
+	procedure string
+	body      string
+	err       error
+}
+
+// fakeOutbound records the requests it receives, failing them with the
+// errors returned by respond.
+type fakeOutbound struct {
+	transport.Outbound
+
+	respond    func(attempt int) error
+	deliveries chan delivery
+
+	mu       sync.Mutex
+	attempts int
+}
+
+func newFakeOutbound(respond func(attempt int) error) *fakeOutbound {
+	if respond == nil {
+		respond = func(int) error { return nil }
+	}
+	return &fakeOutbound{respond: respond, deliveries: make(chan delivery, 100)}
+}
+
+func (o *fakeOutbound) Start() error                      { return nil }
+func (o *fakeOutbound) Stop() error                       { return nil }
+func (o *fakeOutbound) Transports() []transport.Transport { return nil }
+
+func (o *fakeOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	o.mu.Lock()
+	o.attempts++
+	attempt := o.attempts
+	o.mu.Unlock()
+
+	err = o.respond(attempt)
+	o.deliveries <- delivery{ctx: ctx, procedure: req.Procedure, body: string(body), err: err}
+	if err != nil {
+		return nil, err
+	}
+	return time.Now(), nil
+}
+
+func (o *fakeOutbound) next(t *testing.T) delivery {
+	select {
+	case d := <-o.deliveries:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a delivery")
+		return delivery{}
+	}
+}
+
+func (o *fakeOutbound) expectNoDelivery(t *testing.T) {
+	select {
+	case d := <-o.deliveries:
+		t.Fatalf("unexpected delivery of %q", d.procedure)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+type constantBackoff time.Duration
+
+func (b constantBackoff) Backoff() backoff.Backoff    { return b }
+func (b constantBackoff) Duration(uint) time.Duration { return time.Duration(b) }
+
+func newRequest(procedure string) *transport.Request {
+	return &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: procedure,
+		Body:      strings.NewReader("body of " + procedure),
+	}
+}
+
+func callOneway(t *testing.T, o *OnewayOutbound, procedures ...string) {
+	for _, p := range procedures {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		ack, err := o.CallOneway(ctx, newRequest(p))
+		cancel()
+		require.NoError(t, err, "failed to queue %q", p)
+		assert.NotNil(t, ack)
+	}
+}
+
+// drops returns the number of requests dropped by the given outbound.
+func drops(o *OnewayOutbound) int64 {
+	var n int64
+	o.drops.Subscribe(func(_ []string, count int64) {
+		n += count
+	})()
+	return n
+}
+
+func TestOnewayOutboundDelivers(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	// Block the first delivery to show that requests are acknowledged
+	// before they are delivered.
+	unblock := make(chan struct{})
+	fake := newFakeOutbound(func(attempt int) error {
+		if attempt == 1 {
+			<-unblock
+		}
+		return nil
+	})
+	o := NewOnewayOutbound(fake, dir)
+	require.NoError(t, o.Start())
+	defer o.Stop()
+
+	callOneway(t, o, "foo", "bar", "baz")
+	assert.True(t, o.Depth() > 0, "requests must be queued")
+	close(unblock)
+
+	for _, p := range []string{"foo", "bar", "baz"} {
+		d := fake.next(t)
+		assert.Equal(t, p, d.procedure)
+		assert.Equal(t, "body of "+p, d.body)
+		deadline, ok := d.ctx.Deadline()
+		assert.True(t, ok, "deliveries must have a deadline")
+		assert.True(t, time.Until(deadline) <= _defaultAttemptTimeout, "deliveries must time out")
+	}
+}
+
+func TestOnewayOutboundRetries(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	fake := newFakeOutbound(func(attempt int) error {
+		switch attempt {
+		case 1:
+			return yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "peer is down")
+		case 2:
+			return errors.New("connection refused")
+		default:
+			return nil
+		}
+	})
+	o := NewOnewayOutbound(fake, dir, Backoff(constantBackoff(0)))
+	require.NoError(t, o.Start())
+	defer o.Stop()
+
+	callOneway(t, o, "foo", "bar")
+
+	for _, want := range []struct {
+		procedure string
+		failed    bool
+	}{
+		{"foo", true},
+		{"foo", true},
+		{"foo", false},
+		{"bar", false},
+	} {
+		d := fake.next(t)
+		assert.Equal(t, want.procedure, d.procedure)
+		assert.Equal(t, "body of "+want.procedure, d.body, "retries must send the whole body")
+		assert.Equal(t, want.failed, d.err != nil)
+	}
+}
+
+func TestOnewayOutboundDrops(t *testing.T) {
+	tests := []struct {
+		desc    string
+		opts    []Option
+		respond func(attempt int) error
+		// attempts made to deliver the first request before it is dropped
+		attempts int
+	}{
+		{
+			desc: "permanent error",
+			respond: func(attempt int) error {
+				if attempt == 1 {
+					return yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument, "bad request")
+				}
+				return nil
+			},
+			attempts: 1,
+		},
+		{
+			desc: "max attempts",
+			opts: []Option{MaxAttempts(3)},
+			respond: func(attempt int) error {
+				if attempt <= 3 {
+					return yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "peer is down")
+				}
+				return nil
+			},
+			attempts: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			dir, cleanup := tempDir(t)
+			defer cleanup()
+
+			name := "drops " + tt.desc
+			fake := newFakeOutbound(tt.respond)
+			opts := append([]Option{Name(name), Backoff(constantBackoff(0))}, tt.opts...)
+			o := NewOnewayOutbound(fake, dir, opts...)
+			require.NoError(t, o.Start())
+			defer o.Stop()
+
+			callOneway(t, o, "dropped", "delivered")
+			for i := 0; i < tt.attempts; i++ {
+				assert.Equal(t, "dropped", fake.next(t).procedure)
+			}
+			d := fake.next(t)
+			assert.Equal(t, "delivered", d.procedure)
+			assert.NoError(t, d.err)
+			assert.Equal(t, int64(1), drops(o))
+		})
+	}
+}
+
+func TestOnewayOutboundTTL(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	fakeClock := clock.NewFake()
+	fake := newFakeOutbound(func(attempt int) error {
+		if attempt == 1 {
+			fakeClock.Add(time.Hour)
+			return yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "peer is down")
+		}
+		return nil
+	})
+	o := NewOnewayOutbound(fake, dir,
+		Name("ttl"),
+		TTL(time.Minute),
+		Backoff(constantBackoff(0)),
+		withClock(fakeClock),
+	)
+	require.NoError(t, o.Start())
+	defer o.Stop()
+
+	callOneway(t, o, "expires")
+	assert.Error(t, fake.next(t).err)
+	fake.expectNoDelivery(t)
+	assert.Equal(t, int64(1), drops(o))
+	assert.Equal(t, int64(0), o.Depth())
+}
+
+func TestOnewayOutboundSurvivesRestart(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	down := newFakeOutbound(func(int) error {
+		return yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "peer is down")
+	})
+	o := NewOnewayOutbound(down, dir, Backoff(constantBackoff(time.Hour)))
+	require.NoError(t, o.Start())
+	callOneway(t, o, "foo", "bar", "baz")
+	assert.Equal(t, "foo", down.next(t).procedure)
+	require.NoError(t, o.Stop())
+
+	up := newFakeOutbound(nil)
+	o = NewOnewayOutbound(up, dir)
+	require.NoError(t, o.Start())
+	defer o.Stop()
+
+	for _, p := range []string{"foo", "bar", "baz"} {
+		d := up.next(t)
+		assert.Equal(t, p, d.procedure)
+		assert.Equal(t, "body of "+p, d.body)
+	}
+}
+
+func TestOnewayOutboundIntrospect(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	fake := newFakeOutbound(func(int) error {
+		return yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "peer is down")
+	})
+	o := NewOnewayOutbound(fake, dir, Name("introspect"), Backoff(constantBackoff(time.Hour)))
+
+	depths := make(chan int64, 10)
+	unsubscribe := o.depths.Subscribe(func(labels []string, depth int64) {
+		depths <- depth
+	})
+	defer unsubscribe()
+
+	status := o.Introspect()
+	require.NotNil(t, status.Queue)
+	assert.Equal(t, "introspect", status.Queue.Name)
+	assert.Equal(t, dir, status.Queue.Directory)
+	assert.Equal(t, int64(0), status.Queue.Depth)
+	assert.Equal(t, []*sharedmetrics.Set{o.metrics}, o.Metrics())
+
+	require.NoError(t, o.Start())
+	assert.Equal(t, int64(0), <-depths)
+
+	callOneway(t, o, "foo", "bar")
+	assert.Equal(t, int64(1), <-depths)
+	assert.Equal(t, int64(2), <-depths)
+	assert.Equal(t, int64(2), o.Introspect().Queue.Depth)
+
+	require.NoError(t, o.Stop())
+	assert.Equal(t, int64(0), <-depths, "stopped queues must not report their depth")
+}
+
+func TestOnewayOutboundNotRunning(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	o := NewOnewayOutbound(newFakeOutbound(nil), dir)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := o.CallOneway(ctx, newRequest("foo"))
+	assert.Error(t, err)
+}
+
+type failingOutbound struct{ *fakeOutbound }
+
+func (failingOutbound) Start() error { return errors.New("great sadness") }
+
+func TestOnewayOutboundStartFailure(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	o := NewOnewayOutbound(failingOutbound{newFakeOutbound(nil)}, dir)
+	assert.EqualError(t, o.Start(), "great sadness")
+	assert.False(t, o.IsRunning())
+}
+
+// binaryPropagator lets mocktracer serialize span contexts.
+type binaryPropagator struct{}
+
+func (binaryPropagator) Inject(sc mocktracer.MockSpanContext, carrier interface{}) error {
+	_, err := fmt.Fprintf(carrier.(io.Writer), "%d %d", sc.TraceID, sc.SpanID)
+	return err
+}
+
+func (binaryPropagator) Extract(carrier interface{}) (mocktracer.MockSpanContext, error) {
+	var sc mocktracer.MockSpanContext
+	_, err := fmt.Fscanf(carrier.(io.Reader), "%d %d", &sc.TraceID, &sc.SpanID)
+	sc.Sampled = true
+	return sc, err
+}
+
+func TestOnewayOutboundTracing(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	tracer := mocktracer.New()
+	tracer.RegisterInjector(opentracing.Binary, binaryPropagator{})
+	tracer.RegisterExtractor(opentracing.Binary, binaryPropagator{})
+
+	fake := newFakeOutbound(nil)
+	o := NewOnewayOutbound(fake, dir, Tracer(tracer))
+	require.NoError(t, o.Start())
+	defer o.Stop()
+
+	parent := tracer.StartSpan("parent")
+	ctx, cancel := context.WithTimeout(opentracing.ContextWithSpan(context.Background(), parent), time.Second)
+	defer cancel()
+	_, err := o.CallOneway(ctx, newRequest("traced"))
+	require.NoError(t, err)
+	parent.Finish()
+
+	d := fake.next(t)
+	span, ok := opentracing.SpanFromContext(d.ctx).(*mocktracer.MockSpan)
+	require.True(t, ok, "deliveries must be traced")
+	parentContext := parent.Context().(mocktracer.MockSpanContext)
+	assert.Equal(t, parentContext.TraceID, span.SpanContext.TraceID)
+	assert.Equal(t, parentContext.SpanID, span.ParentID)
+	assert.Equal(t, "traced", span.OperationName)
+	assert.Equal(t, "queue", span.Tag("rpc.transport"))
+}
@@ -0,0 +1,339 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/sharedmetrics"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	defaultInitialLimit = 20
+	defaultMinLimit     = 1
+	defaultMaxLimit     = 1000
+	defaultBackoffRatio = 0.9
+)
+
+type adaptiveOptions struct {
+	initialLimit     int
+	minLimit         int
+	maxLimit         int
+	backoffRatio     float64
+	latencyThreshold time.Duration
+	clock            Clock
+}
+
+// AdaptiveOption customizes the behavior of an adaptive concurrency limit
+// middleware.
+type AdaptiveOption func(*adaptiveOptions)
+
+// WithInitialLimit specifies the number of concurrent requests allowed to
+// each service before any response has been observed.
+//
+// Defaults to 20.
+func WithInitialLimit(limit int) AdaptiveOption {
+	return func(options *adaptiveOptions) {
+		options.initialLimit = limit
+	}
+}
+
+// WithMinLimit specifies the number of concurrent requests that are always
+// allowed to each service, no matter how degraded it is.
+//
+// Defaults to 1.
+func WithMinLimit(limit int) AdaptiveOption {
+	return func(options *adaptiveOptions) {
+		options.minLimit = limit
+	}
+}
+
+// WithMaxLimit specifies the maximum number of concurrent requests the
+// limit may grow to for each service.
+//
+// Defaults to 1000.
+func WithMaxLimit(limit int) AdaptiveOption {
+	return func(options *adaptiveOptions) {
+		options.maxLimit = limit
+	}
+}
+
+// WithBackoffRatio specifies the factor by which the limit is multiplied
+// when a request indicates that the service is overloaded. It must be
+// between 0 and 1, exclusive.
+//
+// Defaults to 0.9.
+func WithBackoffRatio(ratio float64) AdaptiveOption {
+	return func(options *adaptiveOptions) {
+		options.backoffRatio = ratio
+	}
+}
+
+// WithLatencyThreshold specifies a latency above which successful requests
+// are treated as a sign of overload, in addition to errors.
+//
+// By default, only errors decrease the limit.
+func WithLatencyThreshold(threshold time.Duration) AdaptiveOption {
+	return func(options *adaptiveOptions) {
+		options.latencyThreshold = threshold
+	}
+}
+
+func withAdaptiveClock(clock Clock) AdaptiveOption {
+	return func(options *adaptiveOptions) {
+		options.clock = clock
+	}
+}
+
+// NewAdaptiveOutboundMiddleware creates an outbound middleware that limits
+// the number of concurrent requests to each service, adapting the limit to
+// the latency and errors of its responses.
+//
+// The limit is adjusted with additive increase, multiplicative decrease
+// (AIMD): every successful request made while the limit was in use grows
+// it by one, and every request that failed with Unavailable,
+// ResourceExhausted or DeadlineExceeded, or that took longer than the
+// latency threshold, shrinks it by the backoff ratio. Requests above the
+// limit fail immediately with a ResourceExhaustedError instead of waiting
+// for the service.
+//
+// The current limit of each service is reported by the Dispatchers using the
+// middleware as the outbound_concurrency_limit gauge.
+func NewAdaptiveOutboundMiddleware(opts ...AdaptiveOption) (*AdaptiveOutboundMiddleware, error) {
+	options := adaptiveOptions{
+		initialLimit: defaultInitialLimit,
+		minLimit:     defaultMinLimit,
+		maxLimit:     defaultMaxLimit,
+		backoffRatio: defaultBackoffRatio,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.clock == nil {
+		options.clock = clock.NewReal()
+	}
+
+	if options.minLimit <= 0 {
+		return nil, fmt.Errorf("adaptive concurrency limit minimum must be more than zero")
+	}
+	if options.minLimit > options.maxLimit {
+		return nil, fmt.Errorf("adaptive concurrency limit minimum (%d) must not exceed the maximum (%d)", options.minLimit, options.maxLimit)
+	}
+	if options.initialLimit < options.minLimit || options.initialLimit > options.maxLimit {
+		return nil, fmt.Errorf("adaptive concurrency initial limit (%d) must be between the minimum (%d) and maximum (%d)", options.initialLimit, options.minLimit, options.maxLimit)
+	}
+	if options.backoffRatio <= 0 || options.backoffRatio >= 1 {
+		return nil, fmt.Errorf("adaptive concurrency limit backoff ratio must be between 0 and 1, got %v", options.backoffRatio)
+	}
+
+	metrics := sharedmetrics.NewSet()
+	return &AdaptiveOutboundMiddleware{
+		options:  options,
+		metrics:  metrics,
+		limits:   newLimitsVector(metrics),
+		limiters: make(map[string]*adaptiveLimiter),
+	}, nil
+}
+
+// AdaptiveOutboundMiddleware is a unary and oneway outbound middleware that
+// sheds outbound requests above an adaptive concurrency limit.
+type AdaptiveOutboundMiddleware struct {
+	options adaptiveOptions
+	metrics *sharedmetrics.Set
+	limits  *sharedmetrics.GaugeVector
+
+	mu       sync.Mutex
+	limiters map[string]*adaptiveLimiter
+}
+
+var (
+	_ middleware.UnaryOutbound  = (*AdaptiveOutboundMiddleware)(nil)
+	_ middleware.OnewayOutbound = (*AdaptiveOutboundMiddleware)(nil)
+)
+
+// Call sends the request to the next outbound if the concurrency limit of
+// its service has not been reached, or fails with a ResourceExhaustedError
+// otherwise.
+func (m *AdaptiveOutboundMiddleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	limiter := m.limiter(req.Service)
+	inflight, ok := limiter.acquire()
+	if !ok {
+		return nil, limiter.exceeded()
+	}
+
+	start := m.options.clock.Now()
+	res, err := out.Call(ctx, req)
+	limiter.release(inflight, m.outcome(err, start))
+	return res, err
+}
+
+// CallOneway sends the request to the next outbound if the concurrency
+// limit of its service has not been reached, or fails with a
+// ResourceExhaustedError otherwise.
+func (m *AdaptiveOutboundMiddleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	limiter := m.limiter(req.Service)
+	inflight, ok := limiter.acquire()
+	if !ok {
+		return nil, limiter.exceeded()
+	}
+
+	start := m.options.clock.Now()
+	ack, err := out.CallOneway(ctx, req)
+	limiter.release(inflight, m.outcome(err, start))
+	return ack, err
+}
+
+// Metrics returns the metrics of the middleware, which Dispatchers using it
+// report.
+func (m *AdaptiveOutboundMiddleware) Metrics() []*sharedmetrics.Set {
+	return []*sharedmetrics.Set{m.metrics}
+}
+
+// Limit returns the current concurrency limit for requests to the given
+// service.
+func (m *AdaptiveOutboundMiddleware) Limit(service string) int {
+	return m.limiter(service).currentLimit()
+}
+
+func (m *AdaptiveOutboundMiddleware) limiter(service string) *adaptiveLimiter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l, ok := m.limiters[service]
+	if !ok {
+		l = newAdaptiveLimiter(service, m.options, m.limits)
+		m.limiters[service] = l
+	}
+	return l
+}
+
+// outcome classifies the result of a request as a signal for the limit of its
+// service.
+func (m *AdaptiveOutboundMiddleware) outcome(err error, start time.Time) adaptiveOutcome {
+	if err != nil {
+		switch yarpcerrors.ErrorCode(err) {
+		case yarpcerrors.CodeUnavailable, yarpcerrors.CodeResourceExhausted, yarpcerrors.CodeDeadlineExceeded:
+			return outcomeOverloaded
+		}
+		if err == context.DeadlineExceeded {
+			return outcomeOverloaded
+		}
+		// Other errors say nothing about the capacity of the service.
+		return outcomeIgnored
+	}
+	if threshold := m.options.latencyThreshold; threshold > 0 && m.options.clock.Now().Sub(start) > threshold {
+		return outcomeOverloaded
+	}
+	return outcomeSucceeded
+}
+
+type adaptiveOutcome int
+
+const (
+	outcomeIgnored adaptiveOutcome = iota
+	outcomeSucceeded
+	outcomeOverloaded
+)
+
+// adaptiveLimiter tracks the concurrency limit and in-flight requests to a
+// single service.
+type adaptiveLimiter struct {
+	service      string
+	limits       *sharedmetrics.GaugeVector
+	minLimit     float64
+	maxLimit     float64
+	backoffRatio float64
+
+	mu       sync.Mutex
+	limit    float64
+	inflight int
+}
+
+func newAdaptiveLimiter(service string, options adaptiveOptions, limits *sharedmetrics.GaugeVector) *adaptiveLimiter {
+	l := &adaptiveLimiter{
+		service:      service,
+		limits:       limits,
+		minLimit:     float64(options.minLimit),
+		maxLimit:     float64(options.maxLimit),
+		backoffRatio: options.backoffRatio,
+		limit:        float64(options.initialLimit),
+	}
+	limits.Store(int64(l.limit), service)
+	return l
+}
+
+// acquire reserves a slot for a request, returning the number of requests
+// that were already in flight, or false if the limit has been reached.
+func (l *adaptiveLimiter) acquire() (int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	inflight := l.inflight
+	if inflight >= int(l.limit) {
+		return inflight, false
+	}
+	l.inflight++
+	return inflight, true
+}
+
+// release frees the slot of a finished request and adjusts the limit.
+//
+// The limit only grows if the request was made while at least half of it
+// was in use, so that it doesn't grow unbounded while traffic is low.
+func (l *adaptiveLimiter) release(inflight int, outcome adaptiveOutcome) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inflight--
+	old := int64(l.limit)
+	switch {
+	case outcome == outcomeOverloaded:
+		l.limit *= l.backoffRatio
+		if l.limit < l.minLimit {
+			l.limit = l.minLimit
+		}
+	case outcome == outcomeSucceeded && float64(inflight*2) >= l.limit:
+		l.limit++
+		if l.limit > l.maxLimit {
+			l.limit = l.maxLimit
+		}
+	}
+	if limit := int64(l.limit); limit != old {
+		l.limits.Store(limit, l.service)
+	}
+}
+
+func (l *adaptiveLimiter) currentLimit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+func (l *adaptiveLimiter) exceeded() error {
+	return yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted, "concurrency limit exceeded for service %q", l.service)
+}
@@ -0,0 +1,253 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// fakeOutbound calls a function for every request.
+type fakeOutbound struct {
+	transport.Outbound
+
+	call func(*transport.Request) error
+}
+
+func (o fakeOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	if err := o.call(req); err != nil {
+		return nil, err
+	}
+	return &transport.Response{}, nil
+}
+
+func (o fakeOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	return nil, o.call(req)
+}
+
+func newAdaptiveMiddleware(t *testing.T, opts ...AdaptiveOption) *AdaptiveOutboundMiddleware {
+	mw, err := NewAdaptiveOutboundMiddleware(opts...)
+	require.NoError(t, err)
+	return mw
+}
+
+func TestAdaptiveOutboundMiddlewareSheds(t *testing.T) {
+	mw := newAdaptiveMiddleware(t, WithInitialLimit(2), WithMinLimit(1), WithMaxLimit(2))
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	out := fakeOutbound{call: func(*transport.Request) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := mw.Call(context.Background(), &transport.Request{Service: "shed"}, out)
+			assert.NoError(t, err)
+		}()
+		<-started
+	}
+
+	_, err := mw.Call(context.Background(), &transport.Request{Service: "shed"}, out)
+	require.Error(t, err)
+	assert.True(t, yarpcerrors.IsResourceExhausted(err), "requests above the limit must fail fast")
+
+	_, err = mw.CallOneway(context.Background(), &transport.Request{Service: "shed"}, out)
+	require.Error(t, err)
+	assert.True(t, yarpcerrors.IsResourceExhausted(err), "oneway requests above the limit must fail fast")
+
+	close(release)
+	wg.Wait()
+
+	go func() { <-started }()
+	_, err = mw.Call(context.Background(), &transport.Request{Service: "shed"}, out)
+	assert.NoError(t, err, "requests must be allowed once in-flight requests finish")
+}
+
+func TestAdaptiveOutboundMiddlewareLimitsPerService(t *testing.T) {
+	mw := newAdaptiveMiddleware(t, WithInitialLimit(1))
+	out := fakeOutbound{call: func(*transport.Request) error {
+		return yarpcerrors.UnavailableErrorf("unavailable")
+	}}
+
+	for i := 0; i < 3; i++ {
+		_, err := mw.Call(context.Background(), &transport.Request{Service: "degraded"}, out)
+		assert.True(t, yarpcerrors.IsUnavailable(err))
+	}
+	assert.Equal(t, 1, mw.Limit("degraded"), "limit must not drop below the minimum")
+	assert.Equal(t, 1, mw.Limit("healthy"))
+}
+
+func TestAdaptiveOutboundMiddlewareMetrics(t *testing.T) {
+	degraded := newAdaptiveMiddleware(t, WithInitialLimit(4), WithBackoffRatio(0.5))
+	healthy := newAdaptiveMiddleware(t, WithInitialLimit(4))
+	out := fakeOutbound{call: func(*transport.Request) error {
+		return yarpcerrors.UnavailableErrorf("unavailable")
+	}}
+	_, err := degraded.Call(context.Background(), &transport.Request{Service: "myservice"}, out)
+	assert.True(t, yarpcerrors.IsUnavailable(err))
+	assert.Equal(t, 4, healthy.Limit("myservice"))
+
+	limits := func(mw *AdaptiveOutboundMiddleware) map[string]int64 {
+		limits := make(map[string]int64)
+		mw.limits.Subscribe(func(labels []string, limit int64) {
+			limits[labels[0]] = limit
+		})()
+		return limits
+	}
+	assert.Equal(t, map[string]int64{"myservice": 2}, limits(degraded))
+	assert.Equal(t, map[string]int64{"myservice": 4}, limits(healthy),
+		"middleware must report their own limits")
+}
+
+func TestAdaptiveOutboundMiddlewareAdjustsLimit(t *testing.T) {
+	tests := []struct {
+		desc      string
+		err       error
+		latency   time.Duration
+		inflight  int
+		wantLimit int
+	}{
+		{
+			desc:      "success while limit in use",
+			inflight:  5,
+			wantLimit: 11,
+		},
+		{
+			desc:      "success while limit mostly unused",
+			inflight:  1,
+			wantLimit: 10,
+		},
+		{
+			desc:      "unavailable",
+			err:       yarpcerrors.UnavailableErrorf("unavailable"),
+			wantLimit: 5,
+		},
+		{
+			desc:      "resource exhausted",
+			err:       yarpcerrors.ResourceExhaustedErrorf("resource exhausted"),
+			wantLimit: 5,
+		},
+		{
+			desc:      "deadline exceeded",
+			err:       yarpcerrors.DeadlineExceededErrorf("deadline exceeded"),
+			wantLimit: 5,
+		},
+		{
+			desc:      "context deadline exceeded",
+			err:       context.DeadlineExceeded,
+			wantLimit: 5,
+		},
+		{
+			desc:      "slow success",
+			latency:   time.Second,
+			inflight:  5,
+			wantLimit: 5,
+		},
+		{
+			desc:      "invalid argument",
+			err:       yarpcerrors.InvalidArgumentErrorf("invalid argument"),
+			inflight:  5,
+			wantLimit: 10,
+		},
+		{
+			desc:      "unknown error",
+			err:       errors.New("great sadness"),
+			inflight:  5,
+			wantLimit: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			clock := clock.NewFake()
+			mw := newAdaptiveMiddleware(t,
+				WithInitialLimit(10),
+				WithBackoffRatio(0.5),
+				WithLatencyThreshold(100*time.Millisecond),
+				withAdaptiveClock(clock),
+			)
+
+			limiter := mw.limiter("adjust")
+			for i := 0; i < tt.inflight; i++ {
+				_, ok := limiter.acquire()
+				require.True(t, ok)
+			}
+
+			out := fakeOutbound{call: func(*transport.Request) error {
+				clock.Add(tt.latency)
+				return tt.err
+			}}
+			_, err := mw.Call(context.Background(), &transport.Request{Service: "adjust"}, out)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.wantLimit, mw.Limit("adjust"))
+		})
+	}
+}
+
+func TestAdaptiveOutboundMiddlewareMaxLimit(t *testing.T) {
+	mw := newAdaptiveMiddleware(t, WithInitialLimit(2), WithMaxLimit(3))
+	out := fakeOutbound{call: func(*transport.Request) error { return nil }}
+
+	limiter := mw.limiter("max")
+	_, ok := limiter.acquire()
+	require.True(t, ok)
+
+	for i := 0; i < 3; i++ {
+		_, err := mw.Call(context.Background(), &transport.Request{Service: "max"}, out)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, mw.Limit("max"), "limit must not grow above the maximum")
+}
+
+func TestAdaptiveOutboundMiddlewareInvalidOptions(t *testing.T) {
+	tests := []struct {
+		desc string
+		opts []AdaptiveOption
+	}{
+		{desc: "zero minimum", opts: []AdaptiveOption{WithMinLimit(0)}},
+		{desc: "minimum above maximum", opts: []AdaptiveOption{WithMinLimit(10), WithMaxLimit(5), WithInitialLimit(5)}},
+		{desc: "initial limit above maximum", opts: []AdaptiveOption{WithInitialLimit(10), WithMaxLimit(5)}},
+		{desc: "zero backoff ratio", opts: []AdaptiveOption{WithBackoffRatio(0)}},
+		{desc: "backoff ratio of one", opts: []AdaptiveOption{WithBackoffRatio(1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := NewAdaptiveOutboundMiddleware(tt.opts...)
+			assert.Error(t, err)
+		})
+	}
+}
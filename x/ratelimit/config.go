@@ -20,7 +20,11 @@
 
 package ratelimit
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // UnaryInboundMiddlewareConfig describes how to configure and construct a
 // unary inbound rate limiter.
@@ -28,6 +32,9 @@ type UnaryInboundMiddlewareConfig struct {
 	// RPS is the maximum requests per second, after which the inbound will
 	// throttle inbound requests with a ResourceExhaustedError of "rate limit
 	// exceeded".
+	// If KeyBy is set, this limit applies separately to each key without an
+	// override, and an RPS of 0 means that only keys with overrides are
+	// limited.
 	RPS int `config:"rps"`
 	// BurstLimit determines how much slack the rate limiter will tolerate for
 	// a burst of requests from an idle state before throttling.
@@ -37,14 +44,33 @@ type UnaryInboundMiddlewareConfig struct {
 	// NoSlack configures the rate limiter without any slack, even after idling
 	// indefinitely.
 	NoSlack bool `config:"noSlack"`
+	// KeyBy configures a separate rate limit for each key of inbound
+	// requests. It may be "caller", "procedure", or "header:" followed by the
+	// name of a request header. By default, all requests share one limit.
+	KeyBy string `config:"keyBy"`
+	// Overrides configures the rate limits of individual keys.
+	Overrides map[string]RateLimitConfig `config:"overrides"`
+	// MaxKeys is the maximum number of keys without overrides whose rate
+	// limits are tracked at a time. The default is 1000.
+	MaxKeys int `config:"maxKeys"`
 }
 
-// Build creates a unary inbound rate limit middleware, or returns an error if
-// the configuration is invalid.
-func (c UnaryInboundMiddlewareConfig) Build() (*UnaryInboundMiddleware, error) {
+// RateLimitConfig describes the rate limit of a single key.
+type RateLimitConfig struct {
+	// RPS is the maximum requests per second for the key.
+	RPS int `config:"rps"`
+	// BurstLimit determines how much slack the rate limiter will tolerate for
+	// a burst of requests from an idle state before throttling.
+	// The default is 10.
+	BurstLimit int `config:"burstLimit"`
+	// NoSlack configures the rate limiter without any slack.
+	NoSlack bool `config:"noSlack"`
+}
+
+func (c RateLimitConfig) options() ([]Option, error) {
 	var opts []Option
 	if c.NoSlack && c.BurstLimit > 0 {
-		return nil, fmt.Errorf("unary inbound rate limit middleware configured with contradictory noSlack and non-zero BurstLimit (%d)", c.BurstLimit)
+		return nil, fmt.Errorf("rate limit configured with contradictory noSlack and non-zero BurstLimit (%d)", c.BurstLimit)
 	}
 	if c.NoSlack {
 		opts = append(opts, WithoutSlack)
@@ -52,5 +78,249 @@ func (c UnaryInboundMiddlewareConfig) Build() (*UnaryInboundMiddleware, error) {
 	if c.BurstLimit > 0 {
 		opts = append(opts, WithBurstLimit(c.BurstLimit))
 	}
-	return NewUnaryInboundMiddleware(c.RPS, opts...)
+	return opts, nil
+}
+
+// Build creates a unary inbound rate limit middleware, or returns an error if
+// the configuration is invalid.
+func (c UnaryInboundMiddlewareConfig) Build() (*UnaryInboundMiddleware, error) {
+	opts, err := c.defaultLimit().options()
+	if err != nil {
+		return nil, fmt.Errorf("unary inbound rate limit middleware: %v", err)
+	}
+	if c.KeyBy == "" {
+		if len(c.Overrides) > 0 {
+			return nil, fmt.Errorf("unary inbound rate limit middleware configured with overrides but no keyBy")
+		}
+		return NewUnaryInboundMiddleware(c.RPS, opts...)
+	}
+
+	key, keyedOpts, err := c.keyed(opts)
+	if err != nil {
+		return nil, fmt.Errorf("unary inbound rate limit middleware: %v", err)
+	}
+	return NewKeyedUnaryInboundMiddleware(key, keyedOpts...)
+}
+
+func (c UnaryInboundMiddlewareConfig) defaultLimit() RateLimitConfig {
+	return RateLimitConfig{RPS: c.RPS, BurstLimit: c.BurstLimit, NoSlack: c.NoSlack}
+}
+
+// keyed returns the KeyFunc and options of a keyed rate limiter, given the
+// options of the default limit.
+func (c UnaryInboundMiddlewareConfig) keyed(defaultOpts []Option) (KeyFunc, []KeyedOption, error) {
+	key, err := parseKeyBy(c.KeyBy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var opts []KeyedOption
+	if c.RPS != 0 {
+		opts = append(opts, WithDefaultLimit(c.RPS, defaultOpts...))
+	}
+	for k, override := range c.Overrides {
+		overrideOpts, err := override.options()
+		if err != nil {
+			return nil, nil, fmt.Errorf("override for %q: %v", k, err)
+		}
+		opts = append(opts, WithKeyLimit(k, override.RPS, overrideOpts...))
+	}
+	if c.MaxKeys > 0 {
+		opts = append(opts, WithMaxKeys(c.MaxKeys))
+	}
+	return key, opts, nil
+}
+
+func parseKeyBy(keyBy string) (KeyFunc, error) {
+	switch {
+	case keyBy == "caller":
+		return ByCaller, nil
+	case keyBy == "procedure":
+		return ByProcedure, nil
+	case strings.HasPrefix(keyBy, "header:") && len(keyBy) > len("header:"):
+		return ByHeader(strings.TrimPrefix(keyBy, "header:")), nil
+	default:
+		return nil, fmt.Errorf(`unknown keyBy %q: expected "caller", "procedure" or "header:<name>"`, keyBy)
+	}
+}
+
+// OnewayInboundMiddlewareConfig describes how to configure and construct a
+// oneway inbound rate limiter. It supports the same options as
+// UnaryInboundMiddlewareConfig.
+type OnewayInboundMiddlewareConfig UnaryInboundMiddlewareConfig
+
+// Build creates a oneway inbound rate limit middleware, or returns an error if
+// the configuration is invalid.
+func (c OnewayInboundMiddlewareConfig) Build() (*OnewayInboundMiddleware, error) {
+	uc := UnaryInboundMiddlewareConfig(c)
+	opts, err := uc.defaultLimit().options()
+	if err != nil {
+		return nil, fmt.Errorf("oneway inbound rate limit middleware: %v", err)
+	}
+	if c.KeyBy == "" {
+		if len(c.Overrides) > 0 {
+			return nil, fmt.Errorf("oneway inbound rate limit middleware configured with overrides but no keyBy")
+		}
+		return NewOnewayInboundMiddleware(c.RPS, opts...)
+	}
+
+	key, keyedOpts, err := uc.keyed(opts)
+	if err != nil {
+		return nil, fmt.Errorf("oneway inbound rate limit middleware: %v", err)
+	}
+	return NewKeyedOnewayInboundMiddleware(key, keyedOpts...)
+}
+
+// OutboundMiddlewareConfig describes how to configure and construct an
+// outbound rate limit middleware.
+//
+// 	failFast: false
+// 	services:
+// 	  fragile:
+// 	    rps: 100
+// 	    procedures:
+// 	      Fragile::expensive:
+// 	        rps: 5
+// 	        noSlack: true
+type OutboundMiddlewareConfig struct {
+	// FailFast fails requests above the rate limit immediately instead of
+	// waiting until they are allowed, up to their deadline.
+	FailFast bool `config:"failFast"`
+	// Services configures the rate limits of requests to each service.
+	Services map[string]ServiceRateLimitConfig `config:"services"`
+}
+
+// ServiceRateLimitConfig describes the rate limit of requests to a service
+// and to its procedures. An RPS of 0 means that only the listed procedures
+// are limited.
+type ServiceRateLimitConfig struct {
+	RateLimitConfig `config:",squash"`
+
+	// Procedures configures the rate limits of individual procedures of the
+	// service, which replace the limit of the service for those procedures.
+	Procedures map[string]RateLimitConfig `config:"procedures"`
+}
+
+// Build creates an outbound rate limit middleware, or returns an error if the
+// configuration is invalid.
+func (c OutboundMiddlewareConfig) Build() (*OutboundMiddleware, error) {
+	var opts []OutboundOption
+	if c.FailFast {
+		opts = append(opts, FailFast())
+	}
+	for service, sc := range c.Services {
+		if sc.RPS != 0 || sc.BurstLimit != 0 || sc.NoSlack {
+			throttleOpts, err := sc.options()
+			if err != nil {
+				return nil, fmt.Errorf("outbound rate limit middleware: service %q: %v", service, err)
+			}
+			opts = append(opts, WithServiceLimit(service, sc.RPS, throttleOpts...))
+		}
+		for procedure, pc := range sc.Procedures {
+			throttleOpts, err := pc.options()
+			if err != nil {
+				return nil, fmt.Errorf("outbound rate limit middleware: procedure %q of service %q: %v", procedure, service, err)
+			}
+			opts = append(opts, WithProcedureLimit(service, procedure, pc.RPS, throttleOpts...))
+		}
+	}
+	return NewOutboundMiddleware(opts...)
+}
+
+// AdaptiveOutboundMiddlewareConfig describes how to configure and construct
+// an adaptive concurrency limit outbound middleware.
+type AdaptiveOutboundMiddlewareConfig struct {
+	// InitialLimit is the number of concurrent requests allowed to each
+	// service before any response has been observed. The default is 20.
+	InitialLimit int `config:"initialLimit"`
+	// MinLimit is the number of concurrent requests always allowed to each
+	// service. The default is 1.
+	MinLimit int `config:"minLimit"`
+	// MaxLimit is the maximum number of concurrent requests the limit may
+	// grow to. The default is 1000.
+	MaxLimit int `config:"maxLimit"`
+	// BackoffRatio is the factor by which the limit shrinks when a service
+	// appears overloaded. The default is 0.9.
+	BackoffRatio float64 `config:"backoffRatio"`
+	// LatencyThreshold is the latency above which successful requests are
+	// treated as a sign of overload. Latency is ignored by default.
+	LatencyThreshold time.Duration `config:"latencyThreshold"`
+}
+
+// Build creates an adaptive concurrency limit outbound middleware, or returns
+// an error if the configuration is invalid.
+func (c AdaptiveOutboundMiddlewareConfig) Build() (*AdaptiveOutboundMiddleware, error) {
+	var opts []AdaptiveOption
+	if c.InitialLimit > 0 {
+		opts = append(opts, WithInitialLimit(c.InitialLimit))
+	}
+	if c.MinLimit > 0 {
+		opts = append(opts, WithMinLimit(c.MinLimit))
+	}
+	if c.MaxLimit > 0 {
+		opts = append(opts, WithMaxLimit(c.MaxLimit))
+	}
+	if c.BackoffRatio > 0 {
+		opts = append(opts, WithBackoffRatio(c.BackoffRatio))
+	}
+	if c.LatencyThreshold > 0 {
+		opts = append(opts, WithLatencyThreshold(c.LatencyThreshold))
+	}
+	return NewAdaptiveOutboundMiddleware(opts...)
+}
+
+// LoadSheddingInboundMiddlewareConfig describes how to configure and
+// construct a load shedding inbound middleware.
+type LoadSheddingInboundMiddlewareConfig struct {
+	// MaxConcurrency is the maximum number of requests handled concurrently
+	// across all procedures. Zero means no global limit.
+	MaxConcurrency int `config:"maxConcurrency"`
+	// ProcedureMaxConcurrency is the maximum number of requests handled
+	// concurrently by each procedure not listed in Procedures. Zero means no
+	// limit.
+	ProcedureMaxConcurrency int `config:"procedureMaxConcurrency"`
+	// Procedures maps procedure names to their own maximum number of
+	// concurrent requests.
+	Procedures map[string]int `config:"procedures"`
+	// PriorityCallers lists callers whose requests may use the capacity
+	// reserved for priority requests.
+	PriorityCallers []string `config:"priorityCallers"`
+	// PriorityHeader is a request header which marks requests as priority
+	// requests if it has one of the PriorityHeaderValues, or any value if no
+	// values are listed.
+	PriorityHeader       string   `config:"priorityHeader"`
+	PriorityHeaderValues []string `config:"priorityHeaderValues"`
+	// ReservedCapacity is the fraction of every limit reserved for priority
+	// requests. The default is 0.2.
+	ReservedCapacity float64 `config:"reservedCapacity"`
+	// MinRemainingTimeout is the minimum time that must remain before the
+	// deadline of a request for it to be handled.
+	MinRemainingTimeout time.Duration `config:"minRemainingTimeout"`
+}
+
+// Build creates a load shedding inbound middleware, or returns an error if
+// the configuration is invalid.
+func (c LoadSheddingInboundMiddlewareConfig) Build() (*LoadSheddingInboundMiddleware, error) {
+	if len(c.PriorityHeaderValues) > 0 && c.PriorityHeader == "" {
+		return nil, fmt.Errorf("load shedding inbound middleware configured with priorityHeaderValues but no priorityHeader")
+	}
+
+	opts := []LoadSheddingOption{
+		WithMaxConcurrency(c.MaxConcurrency),
+		WithDefaultProcedureMaxConcurrency(c.ProcedureMaxConcurrency),
+		WithMinRemainingTimeout(c.MinRemainingTimeout),
+	}
+	for procedure, limit := range c.Procedures {
+		opts = append(opts, WithProcedureMaxConcurrency(procedure, limit))
+	}
+	if len(c.PriorityCallers) > 0 {
+		opts = append(opts, WithPriorityCallers(c.PriorityCallers...))
+	}
+	if c.PriorityHeader != "" {
+		opts = append(opts, WithPriorityHeader(c.PriorityHeader, c.PriorityHeaderValues...))
+	}
+	if c.ReservedCapacity != 0 {
+		opts = append(opts, WithReservedCapacity(c.ReservedCapacity))
+	}
+	return NewLoadSheddingInboundMiddleware(opts...)
 }
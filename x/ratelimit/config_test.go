@@ -22,9 +22,11 @@ package ratelimit
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/config"
 	"go.uber.org/yarpc/internal/whitespace"
 	yaml "gopkg.in/yaml.v2"
@@ -79,3 +81,339 @@ func TestUnaryInboundMiddlewareConfigContradiction(t *testing.T) {
 	}.Build()
 	require.Error(t, err)
 }
+
+func TestAdaptiveOutboundMiddlewareConfig(t *testing.T) {
+	given := whitespace.Expand(`
+		initialLimit: 10
+		minLimit: 2
+		maxLimit: 50
+		backoffRatio: 0.5
+		latencyThreshold: 200ms
+	`)
+	var unstructured config.AttributeMap
+	err := yaml.Unmarshal([]byte(given), &unstructured)
+	require.NoError(t, err)
+
+	var config AdaptiveOutboundMiddlewareConfig
+	err = unstructured.Decode(&config)
+	require.NoError(t, err)
+
+	assert.Equal(t, AdaptiveOutboundMiddlewareConfig{
+		InitialLimit:     10,
+		MinLimit:         2,
+		MaxLimit:         50,
+		BackoffRatio:     0.5,
+		LatencyThreshold: 200 * time.Millisecond,
+	}, config)
+
+	mw, err := config.Build()
+	require.NoError(t, err)
+	assert.Equal(t, 10, mw.Limit("service"))
+}
+
+func TestAdaptiveOutboundMiddlewareConfigDefaults(t *testing.T) {
+	mw, err := AdaptiveOutboundMiddlewareConfig{}.Build()
+	require.NoError(t, err)
+	assert.Equal(t, defaultInitialLimit, mw.Limit("service"))
+}
+
+func TestAdaptiveOutboundMiddlewareConfigInvalid(t *testing.T) {
+	tests := []struct {
+		desc string
+		give AdaptiveOutboundMiddlewareConfig
+	}{
+		{
+			desc: "initial limit above maximum",
+			give: AdaptiveOutboundMiddlewareConfig{InitialLimit: 20, MaxLimit: 10},
+		},
+		{
+			desc: "initial limit below minimum",
+			give: AdaptiveOutboundMiddlewareConfig{InitialLimit: 5, MinLimit: 10},
+		},
+		{
+			desc: "minimum above maximum",
+			give: AdaptiveOutboundMiddlewareConfig{MinLimit: 100, MaxLimit: 10},
+		},
+		{
+			desc: "backoff ratio too large",
+			give: AdaptiveOutboundMiddlewareConfig{BackoffRatio: 1.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := tt.give.Build()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadSheddingInboundMiddlewareConfig(t *testing.T) {
+	given := whitespace.Expand(`
+		maxConcurrency: 100
+		procedureMaxConcurrency: 10
+		procedures:
+			KeyValue::getValue: 50
+		priorityCallers: [web]
+		priorityHeader: x-priority
+		priorityHeaderValues: [high]
+		reservedCapacity: 0.3
+		minRemainingTimeout: 20ms
+	`)
+	var unstructured config.AttributeMap
+	err := yaml.Unmarshal([]byte(given), &unstructured)
+	require.NoError(t, err)
+
+	var config LoadSheddingInboundMiddlewareConfig
+	err = unstructured.Decode(&config)
+	require.NoError(t, err)
+
+	assert.Equal(t, LoadSheddingInboundMiddlewareConfig{
+		MaxConcurrency:          100,
+		ProcedureMaxConcurrency: 10,
+		Procedures:              map[string]int{"KeyValue::getValue": 50},
+		PriorityCallers:         []string{"web"},
+		PriorityHeader:          "x-priority",
+		PriorityHeaderValues:    []string{"high"},
+		ReservedCapacity:        0.3,
+		MinRemainingTimeout:     20 * time.Millisecond,
+	}, config)
+
+	mw, err := config.Build()
+	require.NoError(t, err)
+	assert.Equal(t, 100, mw.options.maxConcurrency)
+	assert.Equal(t, 10, mw.options.defaultProcedureMaxConcurrency)
+	assert.Equal(t, map[string]int{"KeyValue::getValue": 50}, mw.options.procedures)
+	assert.Equal(t, 0.3, mw.options.reservedCapacity)
+}
+
+func TestLoadSheddingInboundMiddlewareConfigDefaults(t *testing.T) {
+	mw, err := LoadSheddingInboundMiddlewareConfig{}.Build()
+	require.NoError(t, err)
+	assert.Equal(t, defaultReservedCapacity, mw.options.reservedCapacity)
+}
+
+func TestLoadSheddingInboundMiddlewareConfigInvalid(t *testing.T) {
+	tests := []struct {
+		desc string
+		give LoadSheddingInboundMiddlewareConfig
+	}{
+		{
+			desc: "header values without header",
+			give: LoadSheddingInboundMiddlewareConfig{PriorityHeaderValues: []string{"high"}},
+		},
+		{
+			desc: "negative procedure limit",
+			give: LoadSheddingInboundMiddlewareConfig{Procedures: map[string]int{"foo": -1}},
+		},
+		{
+			desc: "reserved capacity too large",
+			give: LoadSheddingInboundMiddlewareConfig{ReservedCapacity: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := tt.give.Build()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestUnaryInboundMiddlewareConfigKeyed(t *testing.T) {
+	given := whitespace.Expand(`
+		rps: 10
+		keyBy: caller
+		overrides:
+			batch:
+				rps: 1
+				noSlack: true
+			web:
+				rps: 100
+				burstLimit: 50
+		maxKeys: 20
+	`)
+	var unstructured config.AttributeMap
+	err := yaml.Unmarshal([]byte(given), &unstructured)
+	require.NoError(t, err)
+
+	var config UnaryInboundMiddlewareConfig
+	err = unstructured.Decode(&config)
+	require.NoError(t, err)
+
+	assert.Equal(t, UnaryInboundMiddlewareConfig{
+		RPS:   10,
+		KeyBy: "caller",
+		Overrides: map[string]RateLimitConfig{
+			"batch": {RPS: 1, NoSlack: true},
+			"web":   {RPS: 100, BurstLimit: 50},
+		},
+		MaxKeys: 20,
+	}, config)
+
+	mw, err := config.Build()
+	require.NoError(t, err)
+	assert.NotNil(t, mw.limiter.key)
+	assert.Len(t, mw.limiter.overrides, 2)
+	assert.Equal(t, 20, mw.limiter.maxKeys)
+}
+
+func TestInboundMiddlewareConfigKeyBy(t *testing.T) {
+	req := &transport.Request{
+		Caller:    "caller",
+		Procedure: "procedure",
+		Headers:   transport.NewHeaders().With("x-tenant", "tenant"),
+	}
+
+	tests := []struct {
+		keyBy string
+		want  string
+	}{
+		{keyBy: "caller", want: "caller"},
+		{keyBy: "procedure", want: "procedure"},
+		{keyBy: "header:x-tenant", want: "tenant"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.keyBy, func(t *testing.T) {
+			mw, err := OnewayInboundMiddlewareConfig{RPS: 10, KeyBy: tt.keyBy}.Build()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, mw.limiter.key(req))
+		})
+	}
+}
+
+func TestInboundMiddlewareConfigInvalid(t *testing.T) {
+	tests := []struct {
+		desc    string
+		give    UnaryInboundMiddlewareConfig
+		wantErr string
+	}{
+		{
+			desc:    "unknown keyBy",
+			give:    UnaryInboundMiddlewareConfig{RPS: 10, KeyBy: "service"},
+			wantErr: `unknown keyBy "service"`,
+		},
+		{
+			desc:    "header without name",
+			give:    UnaryInboundMiddlewareConfig{RPS: 10, KeyBy: "header:"},
+			wantErr: `unknown keyBy "header:"`,
+		},
+		{
+			desc:    "overrides without keyBy",
+			give:    UnaryInboundMiddlewareConfig{RPS: 10, Overrides: map[string]RateLimitConfig{"foo": {RPS: 1}}},
+			wantErr: "configured with overrides but no keyBy",
+		},
+		{
+			desc: "contradictory override",
+			give: UnaryInboundMiddlewareConfig{
+				KeyBy:     "caller",
+				Overrides: map[string]RateLimitConfig{"foo": {RPS: 1, NoSlack: true, BurstLimit: 2}},
+			},
+			wantErr: `override for "foo": rate limit configured with contradictory noSlack`,
+		},
+		{
+			desc:    "keyed without limits",
+			give:    UnaryInboundMiddlewareConfig{KeyBy: "caller"},
+			wantErr: "keyed rate limiter requires a default limit or at least one key limit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := tt.give.Build()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+
+			_, err = OnewayInboundMiddlewareConfig(tt.give).Build()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestOutboundMiddlewareConfig(t *testing.T) {
+	given := whitespace.Expand(`
+		failFast: true
+		services:
+			fragile:
+				rps: 100
+				burstLimit: 20
+				procedures:
+					Fragile::expensive:
+						rps: 5
+						noSlack: true
+			other:
+				procedures:
+					Other::expensive:
+						rps: 1
+	`)
+	var unstructured config.AttributeMap
+	err := yaml.Unmarshal([]byte(given), &unstructured)
+	require.NoError(t, err)
+
+	var config OutboundMiddlewareConfig
+	err = unstructured.Decode(&config)
+	require.NoError(t, err)
+
+	assert.Equal(t, OutboundMiddlewareConfig{
+		FailFast: true,
+		Services: map[string]ServiceRateLimitConfig{
+			"fragile": {
+				RateLimitConfig: RateLimitConfig{RPS: 100, BurstLimit: 20},
+				Procedures: map[string]RateLimitConfig{
+					"Fragile::expensive": {RPS: 5, NoSlack: true},
+				},
+			},
+			"other": {
+				Procedures: map[string]RateLimitConfig{
+					"Other::expensive": {RPS: 1},
+				},
+			},
+		},
+	}, config)
+
+	mw, err := config.Build()
+	require.NoError(t, err)
+	assert.True(t, mw.failFast)
+	assert.Len(t, mw.services, 1, "services without rps must not be limited")
+	assert.Len(t, mw.procedures, 2)
+}
+
+func TestOutboundMiddlewareConfigInvalid(t *testing.T) {
+	tests := []struct {
+		desc    string
+		give    OutboundMiddlewareConfig
+		wantErr string
+	}{
+		{
+			desc: "contradictory service limit",
+			give: OutboundMiddlewareConfig{Services: map[string]ServiceRateLimitConfig{
+				"foo": {RateLimitConfig: RateLimitConfig{RPS: 1, BurstLimit: 2, NoSlack: true}},
+			}},
+			wantErr: `service "foo": rate limit configured with contradictory noSlack`,
+		},
+		{
+			desc: "burst limit without rps",
+			give: OutboundMiddlewareConfig{Services: map[string]ServiceRateLimitConfig{
+				"foo": {RateLimitConfig: RateLimitConfig{BurstLimit: 2}},
+			}},
+			wantErr: `invalid rate limit for service "foo"`,
+		},
+		{
+			desc: "invalid procedure limit",
+			give: OutboundMiddlewareConfig{Services: map[string]ServiceRateLimitConfig{
+				"foo": {Procedures: map[string]RateLimitConfig{"bar": {}}},
+			}},
+			wantErr: `invalid rate limit for procedure "bar" of service "foo"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := tt.give.Build()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
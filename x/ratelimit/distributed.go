@@ -0,0 +1,306 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	defaultBatchSize      = 10
+	defaultLeaseTTL       = time.Second
+	defaultAcquireTimeout = 100 * time.Millisecond
+	defaultRetryInterval  = 100 * time.Millisecond
+)
+
+// Throttler decides whether requests must be throttled. Throttle and
+// DistributedThrottle are Throttlers.
+type Throttler interface {
+	// Throttle returns true if a request must be dropped.
+	Throttle() bool
+}
+
+var (
+	_ Throttler = (*Throttle)(nil)
+	_ Throttler = (*DistributedThrottle)(nil)
+)
+
+// TokenStore grants tokens from rate limit quotas shared by many processes.
+type TokenStore interface {
+	// Acquire requests up to n tokens from the quota with the given key and
+	// returns the number of tokens granted, which may be zero if the quota
+	// is exhausted. It returns an error if the store could not grant tokens,
+	// for example because it could not be reached.
+	Acquire(ctx context.Context, key string, n int) (int, error)
+}
+
+// MemoryTokenStore is a TokenStore which keeps quotas in memory. It may be
+// shared by throttles in the same process, or served to other processes
+// with a QuotaServer.
+type MemoryTokenStore struct {
+	mu     sync.RWMutex
+	quotas map[string]*Throttle
+}
+
+var _ TokenStore = (*MemoryTokenStore)(nil)
+
+// NewMemoryTokenStore creates a TokenStore without any quotas.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{quotas: make(map[string]*Throttle)}
+}
+
+// SetQuota sets the rate limit shared by all requests for tokens with the
+// given key, replacing its previous quota, if any.
+func (s *MemoryTokenStore) SetQuota(key string, rps int, opts ...Option) error {
+	throttle, err := NewThrottle(rps, opts...)
+	if err != nil {
+		return fmt.Errorf("invalid quota for %q: %v", key, err)
+	}
+
+	s.mu.Lock()
+	s.quotas[key] = throttle
+	s.mu.Unlock()
+	return nil
+}
+
+// Acquire grants up to n tokens from the quota with the given key.
+func (s *MemoryTokenStore) Acquire(ctx context.Context, key string, n int) (int, error) {
+	s.mu.RLock()
+	quota, ok := s.quotas[key]
+	s.mu.RUnlock()
+	if !ok {
+		return 0, yarpcerrors.Newf(yarpcerrors.CodeNotFound, "no rate limit quota for %q", key)
+	}
+
+	granted := 0
+	for granted < n && !quota.Throttle() {
+		granted++
+	}
+	return granted, nil
+}
+
+type distributedOptions struct {
+	batchSize      int
+	leaseTTL       time.Duration
+	acquireTimeout time.Duration
+	retryInterval  time.Duration
+	fallback       *keyLimit
+	clock          clock.Clock
+}
+
+// DistributedOption customizes the behavior of a DistributedThrottle.
+type DistributedOption func(*distributedOptions)
+
+// WithBatchSize specifies the number of tokens a DistributedThrottle leases
+// from its store at a time. Larger batches mean fewer requests to the store,
+// but let a process hold on to tokens other processes could have used.
+//
+// Defaults to 10.
+func WithBatchSize(n int) DistributedOption {
+	return func(options *distributedOptions) {
+		options.batchSize = n
+	}
+}
+
+// WithLeaseTTL specifies how long leased tokens may be used. Tokens which
+// weren't used in time are discarded, so that idle processes don't save up
+// tokens beyond the shared rate.
+//
+// Defaults to one second.
+func WithLeaseTTL(ttl time.Duration) DistributedOption {
+	return func(options *distributedOptions) {
+		options.leaseTTL = ttl
+	}
+}
+
+// WithAcquireTimeout specifies how long a DistributedThrottle waits for its
+// store to grant tokens before treating it as unreachable.
+//
+// Defaults to 100 milliseconds.
+func WithAcquireTimeout(timeout time.Duration) DistributedOption {
+	return func(options *distributedOptions) {
+		options.acquireTimeout = timeout
+	}
+}
+
+// WithRetryInterval specifies how long a DistributedThrottle waits before
+// asking its store for tokens again after the store denied tokens or could
+// not be reached.
+//
+// Defaults to 100 milliseconds.
+func WithRetryInterval(interval time.Duration) DistributedOption {
+	return func(options *distributedOptions) {
+		options.retryInterval = interval
+	}
+}
+
+// WithFallbackLimit specifies a local rate limit used while the store can't
+// be reached.
+//
+// By default, requests are not throttled while the store can't be reached.
+func WithFallbackLimit(rps int, opts ...Option) DistributedOption {
+	return func(options *distributedOptions) {
+		options.fallback = &keyLimit{rps: rps, opts: opts}
+	}
+}
+
+func withDistributedClock(clock clock.Clock) DistributedOption {
+	return func(options *distributedOptions) {
+		options.clock = clock
+	}
+}
+
+// DistributedThrottle is a rate limiter which enforces a rate limit shared
+// by many processes. It leases batches of tokens from a TokenStore and
+// allows one request for every token.
+type DistributedThrottle struct {
+	store    TokenStore
+	key      string
+	options  distributedOptions
+	fallback *Throttle
+
+	mu          sync.Mutex
+	tokens      int
+	expires     time.Time
+	retryAt     time.Time
+	unreachable bool
+	// acquiring is whether a request is leasing tokens from the store.
+	acquiring bool
+}
+
+// NewDistributedThrottle returns a DistributedThrottle that leases tokens
+// from the quota with the given key in the store.
+func NewDistributedThrottle(store TokenStore, key string, opts ...DistributedOption) (*DistributedThrottle, error) {
+	options := distributedOptions{
+		batchSize:      defaultBatchSize,
+		leaseTTL:       defaultLeaseTTL,
+		acquireTimeout: defaultAcquireTimeout,
+		retryInterval:  defaultRetryInterval,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.clock == nil {
+		options.clock = clock.NewReal()
+	}
+
+	if store == nil {
+		return nil, fmt.Errorf("distributed rate limiter requires a token store")
+	}
+	if options.batchSize <= 0 {
+		return nil, fmt.Errorf("distributed rate limiter batch size must be more than zero, got %d", options.batchSize)
+	}
+	if options.leaseTTL <= 0 {
+		return nil, fmt.Errorf("distributed rate limiter lease TTL must be more than zero, got %v", options.leaseTTL)
+	}
+	if options.acquireTimeout <= 0 {
+		return nil, fmt.Errorf("distributed rate limiter acquire timeout must be more than zero, got %v", options.acquireTimeout)
+	}
+	if options.retryInterval < 0 {
+		return nil, fmt.Errorf("distributed rate limiter retry interval must not be negative, got %v", options.retryInterval)
+	}
+
+	t := &DistributedThrottle{
+		store:   store,
+		key:     key,
+		options: options,
+	}
+	if l := options.fallback; l != nil {
+		fallback, err := NewThrottle(l.rps, append([]Option{WithClock(options.clock)}, l.opts...)...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fallback rate limit: %v", err)
+		}
+		t.fallback = fallback
+	}
+	return t, nil
+}
+
+// Throttle returns whether a request should be dropped. It uses a leased
+// token if one is available, and otherwise leases a new batch of tokens from
+// the store, blocking for up to the acquire timeout. Only one request at a
+// time leases tokens; concurrent requests don't wait for the store, and
+// apply the fallback rate limit meanwhile.
+//
+// While the store can't be reached, Throttle applies the fallback rate
+// limit instead.
+func (t *DistributedThrottle) Throttle() bool {
+	t.mu.Lock()
+	now := t.options.clock.Now()
+	if t.tokens > 0 && now.Before(t.expires) {
+		t.tokens--
+		t.mu.Unlock()
+		return false
+	}
+	t.tokens = 0
+
+	if t.acquiring {
+		throttle := t.throttleFallback()
+		t.mu.Unlock()
+		return throttle
+	}
+	if now.Before(t.retryAt) {
+		// The store recently denied us tokens or could not be reached;
+		// don't ask again yet.
+		throttle := !t.unreachable || t.throttleFallback()
+		t.mu.Unlock()
+		return throttle
+	}
+	t.acquiring = true
+	t.mu.Unlock()
+
+	// Don't hold the lock while waiting for the store, so that requests
+	// with leased tokens aren't blocked.
+	ctx, cancel := context.WithTimeout(context.Background(), t.options.acquireTimeout)
+	granted, err := t.store.Acquire(ctx, t.key, t.options.batchSize)
+	cancel()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.acquiring = false
+
+	now = t.options.clock.Now()
+	if err != nil {
+		t.unreachable = true
+		t.retryAt = now.Add(t.options.retryInterval)
+		return t.throttleFallback()
+	}
+	t.unreachable = false
+	if granted <= 0 {
+		t.retryAt = now.Add(t.options.retryInterval)
+		return true
+	}
+	t.tokens = granted - 1
+	t.expires = now.Add(t.options.leaseTTL)
+	return false
+}
+
+func (t *DistributedThrottle) throttleFallback() bool {
+	if t.fallback == nil {
+		return false
+	}
+	return t.fallback.Throttle()
+}
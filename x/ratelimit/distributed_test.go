@@ -0,0 +1,294 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// fakeTokenStore grants tokens with a function and counts requests.
+type fakeTokenStore struct {
+	acquire func(n int) (int, error)
+	calls   int
+}
+
+func (s *fakeTokenStore) Acquire(ctx context.Context, key string, n int) (int, error) {
+	s.calls++
+	return s.acquire(n)
+}
+
+type tokenStoreFunc func(ctx context.Context, key string, n int) (int, error)
+
+func (f tokenStoreFunc) Acquire(ctx context.Context, key string, n int) (int, error) {
+	return f(ctx, key, n)
+}
+
+func newDistributedThrottle(t *testing.T, store TokenStore, opts ...DistributedOption) *DistributedThrottle {
+	throttle, err := NewDistributedThrottle(store, "quota", opts...)
+	require.NoError(t, err)
+	return throttle
+}
+
+func TestMemoryTokenStore(t *testing.T) {
+	fakeClock := clock.NewFake()
+	store := NewMemoryTokenStore()
+	require.NoError(t, store.SetQuota("quota", 10, WithBurstLimit(10), WithClock(fakeClock)))
+
+	granted, err := store.Acquire(context.Background(), "quota", 4)
+	require.NoError(t, err)
+	assert.Equal(t, 4, granted)
+
+	granted, err = store.Acquire(context.Background(), "quota", 20)
+	require.NoError(t, err)
+	assert.Equal(t, 6, granted, "the burst must be exhausted")
+
+	granted, err = store.Acquire(context.Background(), "quota", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, granted)
+
+	fakeClock.Add(time.Second)
+	granted, err = store.Acquire(context.Background(), "quota", 20)
+	require.NoError(t, err)
+	assert.Equal(t, 10, granted, "tokens must be replenished")
+
+	_, err = store.Acquire(context.Background(), "unknown", 1)
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeNotFound, yarpcerrors.ErrorCode(err))
+
+	assert.Error(t, store.SetQuota("invalid", 0))
+}
+
+func TestDistributedThrottleSharesQuota(t *testing.T) {
+	fakeClock := clock.NewFake()
+	store := NewMemoryTokenStore()
+	require.NoError(t, store.SetQuota("quota", 10, WithBurstLimit(10), WithClock(fakeClock)))
+
+	a := newDistributedThrottle(t, store, WithBatchSize(4), withDistributedClock(fakeClock))
+	b := newDistributedThrottle(t, store, WithBatchSize(4), withDistributedClock(fakeClock))
+
+	allowed := 0
+	for i := 0; i < 20; i++ {
+		if !a.Throttle() {
+			allowed++
+		}
+		if !b.Throttle() {
+			allowed++
+		}
+	}
+	assert.Equal(t, 10, allowed, "throttles must share the quota")
+}
+
+func TestDistributedThrottleLeaseTTL(t *testing.T) {
+	fakeClock := clock.NewFake()
+	store := &fakeTokenStore{acquire: func(n int) (int, error) { return n, nil }}
+	throttle := newDistributedThrottle(t, store,
+		WithBatchSize(5),
+		WithLeaseTTL(time.Second),
+		withDistributedClock(fakeClock),
+	)
+
+	assert.False(t, throttle.Throttle())
+	assert.False(t, throttle.Throttle())
+	assert.Equal(t, 1, store.calls, "leased tokens must be used before asking for more")
+
+	fakeClock.Add(time.Second)
+	assert.False(t, throttle.Throttle())
+	assert.Equal(t, 2, store.calls, "expired tokens must be discarded")
+}
+
+func TestDistributedThrottleDenied(t *testing.T) {
+	fakeClock := clock.NewFake()
+	store := &fakeTokenStore{acquire: func(int) (int, error) { return 0, nil }}
+	throttle := newDistributedThrottle(t, store,
+		WithRetryInterval(time.Second),
+		WithFallbackLimit(100, WithClock(fakeClock)),
+		withDistributedClock(fakeClock),
+	)
+
+	assert.True(t, throttle.Throttle())
+	assert.True(t, throttle.Throttle(), "the fallback must not be used when the store denies tokens")
+	assert.Equal(t, 1, store.calls, "the store must not be asked again before the retry interval")
+
+	fakeClock.Add(time.Second)
+	store.acquire = func(n int) (int, error) { return 1, nil }
+	assert.False(t, throttle.Throttle())
+	assert.Equal(t, 2, store.calls)
+}
+
+func TestDistributedThrottleFallback(t *testing.T) {
+	fakeClock := clock.NewFake()
+	store := &fakeTokenStore{acquire: func(int) (int, error) { return 0, errors.New("great sadness") }}
+	throttle := newDistributedThrottle(t, store,
+		WithRetryInterval(time.Second),
+		WithFallbackLimit(1, WithBurstLimit(2)),
+		withDistributedClock(fakeClock),
+	)
+
+	assert.False(t, throttle.Throttle())
+	assert.False(t, throttle.Throttle())
+	assert.True(t, throttle.Throttle(), "the fallback limit must apply while the store is unreachable")
+	assert.Equal(t, 1, store.calls)
+
+	fakeClock.Add(time.Second)
+	store.acquire = func(n int) (int, error) { return n, nil }
+	assert.False(t, throttle.Throttle())
+	assert.Equal(t, 2, store.calls, "the store must be retried after the retry interval")
+}
+
+func TestDistributedThrottleWithoutFallback(t *testing.T) {
+	store := &fakeTokenStore{acquire: func(int) (int, error) { return 0, errors.New("great sadness") }}
+	throttle := newDistributedThrottle(t, store)
+
+	for i := 0; i < 10; i++ {
+		assert.False(t, throttle.Throttle(), "requests must be allowed while the store is unreachable")
+	}
+}
+
+func TestDistributedThrottleAcquireTimeout(t *testing.T) {
+	var deadline time.Time
+	store := tokenStoreFunc(func(ctx context.Context, key string, n int) (int, error) {
+		deadline, _ = ctx.Deadline()
+		return n, nil
+	})
+	throttle := newDistributedThrottle(t, store, WithAcquireTimeout(time.Minute))
+
+	start := time.Now()
+	assert.False(t, throttle.Throttle())
+	assert.WithinDuration(t, start.Add(time.Minute), deadline, 10*time.Second)
+}
+
+func TestDistributedThrottleSlowStore(t *testing.T) {
+	acquiring := make(chan struct{})
+	release := make(chan struct{})
+	store := tokenStoreFunc(func(ctx context.Context, key string, n int) (int, error) {
+		close(acquiring)
+		<-release
+		return n, nil
+	})
+	throttle := newDistributedThrottle(t, store,
+		WithBatchSize(3),
+		WithAcquireTimeout(time.Minute),
+		WithFallbackLimit(1, WithBurstLimit(1)),
+		withDistributedClock(clock.NewFake()),
+	)
+
+	leased := make(chan bool)
+	go func() { leased <- throttle.Throttle() }()
+	<-acquiring
+
+	// Concurrent requests must not wait for the store, and use the fallback
+	// rate limit meanwhile.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.False(t, throttle.Throttle(), "fallback limit must allow the first request")
+		assert.True(t, throttle.Throttle(), "fallback limit must apply while leasing tokens")
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("requests must not block while tokens are leased")
+	}
+
+	close(release)
+	assert.False(t, <-leased, "the leasing request must use a leased token")
+	assert.False(t, throttle.Throttle(), "leased tokens must be used")
+	assert.False(t, throttle.Throttle(), "leased tokens must be used")
+}
+
+func TestNewDistributedThrottleErrors(t *testing.T) {
+	store := NewMemoryTokenStore()
+	tests := []struct {
+		desc    string
+		store   TokenStore
+		opts    []DistributedOption
+		wantErr string
+	}{
+		{
+			desc:    "no store",
+			wantErr: "distributed rate limiter requires a token store",
+		},
+		{
+			desc:    "invalid batch size",
+			store:   store,
+			opts:    []DistributedOption{WithBatchSize(0)},
+			wantErr: "distributed rate limiter batch size must be more than zero",
+		},
+		{
+			desc:    "invalid lease TTL",
+			store:   store,
+			opts:    []DistributedOption{WithLeaseTTL(0)},
+			wantErr: "distributed rate limiter lease TTL must be more than zero",
+		},
+		{
+			desc:    "invalid acquire timeout",
+			store:   store,
+			opts:    []DistributedOption{WithAcquireTimeout(-time.Second)},
+			wantErr: "distributed rate limiter acquire timeout must be more than zero",
+		},
+		{
+			desc:    "invalid retry interval",
+			store:   store,
+			opts:    []DistributedOption{WithRetryInterval(-time.Second)},
+			wantErr: "distributed rate limiter retry interval must not be negative",
+		},
+		{
+			desc:    "invalid fallback",
+			store:   store,
+			opts:    []DistributedOption{WithFallbackLimit(0)},
+			wantErr: "invalid fallback rate limit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := NewDistributedThrottle(tt.store, "quota", tt.opts...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestInboundMiddlewareWithThrottler(t *testing.T) {
+	store := &fakeTokenStore{acquire: func(int) (int, error) { return 1, nil }}
+	throttle := newDistributedThrottle(t, store, WithRetryInterval(time.Hour))
+
+	unary := NewUnaryInboundMiddlewareWithThrottler(throttle)
+	assert.NoError(t, unary.Handle(context.Background(), &transport.Request{}, nil, nopUnaryHandler))
+
+	store.acquire = func(int) (int, error) { return 0, nil }
+	err := unary.Handle(context.Background(), &transport.Request{}, nil, nopUnaryHandler)
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.ErrorCode(err))
+
+	oneway := NewOnewayInboundMiddlewareWithThrottler(throttle)
+	h := onewayHandlerFunc(func(context.Context, *transport.Request) error { return nil })
+	assert.Error(t, oneway.HandleOneway(context.Background(), &transport.Request{}, h))
+}
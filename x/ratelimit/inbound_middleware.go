@@ -25,6 +25,7 @@ import (
 
 	"go.uber.org/yarpc/api/middleware"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/sharedmetrics"
 	"go.uber.org/yarpc/yarpcerrors"
 )
 
@@ -34,28 +35,103 @@ var errRateLimitExceeded = yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted, "
 // introduces a throttle, shedding inbound requests if they arrive more often
 // than the configured rate limit.
 func NewUnaryInboundMiddleware(rps int, opts ...Option) (*UnaryInboundMiddleware, error) {
-	throttle, err := NewThrottle(rps, opts...)
+	limiter, err := newLimiter("unary", rps, opts)
 	if err != nil {
 		return nil, err
 	}
-	return &UnaryInboundMiddleware{
-		throttle: throttle,
-	}, nil
+	return &UnaryInboundMiddleware{limiter: limiter}, nil
+}
+
+// NewKeyedUnaryInboundMiddleware creates a unary inbound middleware that
+// applies a separate rate limit to the requests of every key, such as every
+// caller or procedure, shedding inbound requests of a key if they arrive more
+// often than its rate limit.
+func NewKeyedUnaryInboundMiddleware(key KeyFunc, opts ...KeyedOption) (*UnaryInboundMiddleware, error) {
+	limiter, err := newKeyedLimiter("unary", key, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &UnaryInboundMiddleware{limiter: limiter}, nil
+}
+
+// NewUnaryInboundMiddlewareWithThrottler creates a unary inbound middleware
+// that sheds inbound requests whenever the given Throttler throttles them,
+// such as a DistributedThrottle.
+func NewUnaryInboundMiddlewareWithThrottler(throttler Throttler) *UnaryInboundMiddleware {
+	return &UnaryInboundMiddleware{limiter: newThrottlerLimiter("unary", throttler)}
 }
 
 // UnaryInboundMiddleware is a unary inbound middleware that sheds inbound
 // requests above a rate limit, with some slack for bursts.
 type UnaryInboundMiddleware struct {
-	throttle *Throttle
+	limiter *limiter
 }
 
 var _ middleware.UnaryInbound = (*UnaryInboundMiddleware)(nil)
 
+// Metrics returns the metrics of the middleware, which Dispatchers using it
+// report.
+func (m *UnaryInboundMiddleware) Metrics() []*sharedmetrics.Set {
+	return []*sharedmetrics.Set{m.limiter.metrics}
+}
+
 // Handle drops inbound requests with a ResourceExhaustedError if the arrive
 // more frequently than the configured rate limit.
 func (m *UnaryInboundMiddleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, next transport.UnaryHandler) error {
-	if m.throttle.Throttle() {
-		return errRateLimitExceeded
+	if err := m.limiter.allow(req); err != nil {
+		return err
 	}
 	return next.Handle(ctx, req, resw)
 }
+
+// NewOnewayInboundMiddleware creates a oneway inbound middleware that
+// introduces a throttle, shedding inbound requests if they arrive more often
+// than the configured rate limit.
+func NewOnewayInboundMiddleware(rps int, opts ...Option) (*OnewayInboundMiddleware, error) {
+	limiter, err := newLimiter("oneway", rps, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &OnewayInboundMiddleware{limiter: limiter}, nil
+}
+
+// NewKeyedOnewayInboundMiddleware creates a oneway inbound middleware that
+// applies a separate rate limit to the requests of every key, shedding
+// inbound requests of a key if they arrive more often than its rate limit.
+func NewKeyedOnewayInboundMiddleware(key KeyFunc, opts ...KeyedOption) (*OnewayInboundMiddleware, error) {
+	limiter, err := newKeyedLimiter("oneway", key, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &OnewayInboundMiddleware{limiter: limiter}, nil
+}
+
+// NewOnewayInboundMiddlewareWithThrottler creates a oneway inbound middleware
+// that sheds inbound requests whenever the given Throttler throttles them,
+// such as a DistributedThrottle.
+func NewOnewayInboundMiddlewareWithThrottler(throttler Throttler) *OnewayInboundMiddleware {
+	return &OnewayInboundMiddleware{limiter: newThrottlerLimiter("oneway", throttler)}
+}
+
+// OnewayInboundMiddleware is a oneway inbound middleware that sheds inbound
+// requests above a rate limit, with some slack for bursts.
+type OnewayInboundMiddleware struct {
+	limiter *limiter
+}
+
+var _ middleware.OnewayInbound = (*OnewayInboundMiddleware)(nil)
+
+// Metrics returns the metrics of the middleware, which Dispatchers using it
+// report.
+func (m *OnewayInboundMiddleware) Metrics() []*sharedmetrics.Set {
+	return []*sharedmetrics.Set{m.limiter.metrics}
+}
+
+// HandleOneway drops inbound requests with a ResourceExhaustedError if they
+// arrive more frequently than the configured rate limit.
+func (m *OnewayInboundMiddleware) HandleOneway(ctx context.Context, req *transport.Request, next transport.OnewayHandler) error {
+	if err := m.limiter.allow(req); err != nil {
+		return err
+	}
+	return next.HandleOneway(ctx, req)
+}
@@ -0,0 +1,268 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/sharedmetrics"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	defaultMaxKeys = 1000
+
+	// defaultKeyLabel labels the throttled requests of keys without their own
+	// limit, so that clients can't grow the number of metrics by sending
+	// arbitrary keys.
+	defaultKeyLabel = "default"
+)
+
+// KeyFunc returns the key of an inbound request. Requests with the same key
+// share a rate limit.
+type KeyFunc func(*transport.Request) string
+
+// ByCaller is a KeyFunc which applies a separate rate limit to each caller.
+func ByCaller(req *transport.Request) string {
+	return req.Caller
+}
+
+// ByProcedure is a KeyFunc which applies a separate rate limit to each
+// procedure.
+func ByProcedure(req *transport.Request) string {
+	return req.Procedure
+}
+
+// ByHeader returns a KeyFunc which applies a separate rate limit to each
+// value of the given request header. Requests without the header share the
+// rate limit for the empty key.
+func ByHeader(name string) KeyFunc {
+	return func(req *transport.Request) string {
+		v, _ := req.Headers.Get(name)
+		return v
+	}
+}
+
+type keyLimit struct {
+	rps  int
+	opts []Option
+}
+
+type keyedOptions struct {
+	defaultLimit *keyLimit
+	overrides    map[string]keyLimit
+	maxKeys      int
+}
+
+// KeyedOption customizes the rate limits of a keyed inbound rate limit
+// middleware.
+type KeyedOption func(*keyedOptions)
+
+// WithDefaultLimit specifies the rate limit applied separately to each key
+// that doesn't have its own limit.
+//
+// By default, only keys with their own limit are limited.
+func WithDefaultLimit(rps int, opts ...Option) KeyedOption {
+	return func(options *keyedOptions) {
+		options.defaultLimit = &keyLimit{rps: rps, opts: opts}
+	}
+}
+
+// WithKeyLimit specifies the rate limit for requests with the given key,
+// overriding the default limit for that key. Throttled requests are counted
+// under the key in metrics, while those of other keys share the "default"
+// label.
+//
+// This option may be provided multiple times.
+func WithKeyLimit(key string, rps int, opts ...Option) KeyedOption {
+	return func(options *keyedOptions) {
+		if options.overrides == nil {
+			options.overrides = make(map[string]keyLimit)
+		}
+		options.overrides[key] = keyLimit{rps: rps, opts: opts}
+	}
+}
+
+// WithMaxKeys specifies the maximum number of keys without their own limit
+// whose throttles are kept at a time. When the maximum is reached, the
+// throttle of the least recently seen key is discarded, so that key starts
+// again with a full burst allowance.
+//
+// Defaults to 1000.
+func WithMaxKeys(n int) KeyedOption {
+	return func(options *keyedOptions) {
+		options.maxKeys = n
+	}
+}
+
+// limiter decides which inbound requests of an RPC type are throttled,
+// either with a single throttle or with a throttle for each key.
+type limiter struct {
+	rpcType   string
+	metrics   *sharedmetrics.Set
+	throttled *sharedmetrics.CounterVector
+
+	// throttle is used for every request if key is nil.
+	throttle Throttler
+
+	key          KeyFunc
+	overrides    map[string]*Throttle
+	defaultLimit *keyLimit
+	maxKeys      int
+
+	mu        sync.Mutex
+	lru       *list.List // of *keyedThrottle, most recently used first
+	throttles map[string]*list.Element
+}
+
+type keyedThrottle struct {
+	key      string
+	throttle *Throttle
+}
+
+func newLimiter(rpcType string, rps int, opts []Option) (*limiter, error) {
+	throttle, err := NewThrottle(rps, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return newThrottlerLimiter(rpcType, throttle), nil
+}
+
+func newThrottlerLimiter(rpcType string, throttle Throttler) *limiter {
+	metrics := sharedmetrics.NewSet()
+	return &limiter{
+		rpcType:   rpcType,
+		metrics:   metrics,
+		throttled: newThrottledVector(metrics),
+		throttle:  throttle,
+	}
+}
+
+func newKeyedLimiter(rpcType string, key KeyFunc, opts []KeyedOption) (*limiter, error) {
+	options := keyedOptions{maxKeys: defaultMaxKeys}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if key == nil {
+		return nil, fmt.Errorf("keyed rate limiter requires a KeyFunc")
+	}
+	if options.defaultLimit == nil && len(options.overrides) == 0 {
+		return nil, fmt.Errorf("keyed rate limiter requires a default limit or at least one key limit")
+	}
+	if options.maxKeys <= 0 {
+		return nil, fmt.Errorf("keyed rate limiter maximum number of keys must be more than zero, got %d", options.maxKeys)
+	}
+	if l := options.defaultLimit; l != nil {
+		// Validate the default limit before any request needs it.
+		if _, err := NewThrottle(l.rps, l.opts...); err != nil {
+			return nil, fmt.Errorf("invalid default rate limit: %v", err)
+		}
+	}
+
+	overrides := make(map[string]*Throttle, len(options.overrides))
+	for k, l := range options.overrides {
+		throttle, err := NewThrottle(l.rps, l.opts...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit for key %q: %v", k, err)
+		}
+		overrides[k] = throttle
+	}
+
+	metrics := sharedmetrics.NewSet()
+	return &limiter{
+		rpcType:      rpcType,
+		metrics:      metrics,
+		throttled:    newThrottledVector(metrics),
+		key:          key,
+		overrides:    overrides,
+		defaultLimit: options.defaultLimit,
+		maxKeys:      options.maxKeys,
+		lru:          list.New(),
+		throttles:    make(map[string]*list.Element),
+	}, nil
+}
+
+// keyLabel returns the metric label of the given key: the key itself if it
+// has its own limit, or defaultKeyLabel.
+func (l *limiter) keyLabel(key string) string {
+	if _, ok := l.overrides[key]; ok {
+		return key
+	}
+	return defaultKeyLabel
+}
+
+// allow returns an error if the request must be throttled.
+func (l *limiter) allow(req *transport.Request) error {
+	if l.key == nil {
+		if !l.throttle.Throttle() {
+			return nil
+		}
+		l.throttled.Inc(l.rpcType, "")
+		return errRateLimitExceeded
+	}
+
+	key := l.key(req)
+	throttle := l.throttleFor(key)
+	if throttle == nil || !throttle.Throttle() {
+		return nil
+	}
+	l.throttled.Inc(l.rpcType, l.keyLabel(key))
+	return yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted, "rate limit exceeded for %q", key)
+}
+
+// throttleFor returns the throttle for the given key, or nil if requests
+// with that key are not limited.
+func (l *limiter) throttleFor(key string) *Throttle {
+	if throttle, ok := l.overrides[key]; ok {
+		return throttle
+	}
+	if l.defaultLimit == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.throttles[key]; ok {
+		l.lru.MoveToFront(e)
+		return e.Value.(*keyedThrottle).throttle
+	}
+
+	// The default limit was validated when the limiter was built.
+	throttle, _ := NewThrottle(l.defaultLimit.rps, l.defaultLimit.opts...)
+	l.throttles[key] = l.lru.PushFront(&keyedThrottle{key: key, throttle: throttle})
+	if l.lru.Len() > l.maxKeys {
+		oldest := l.lru.Remove(l.lru.Back()).(*keyedThrottle)
+		delete(l.throttles, oldest.key)
+	}
+	return throttle
+}
+
+// numKeys returns the number of keys whose default throttles are kept.
+func (l *limiter) numKeys() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.throttles)
+}
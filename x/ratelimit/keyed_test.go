@@ -0,0 +1,238 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/sharedmetrics"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+var nopUnaryHandler = unaryHandlerFunc(func(context.Context, *transport.Request, transport.ResponseWriter) error {
+	return nil
+})
+
+// throttledCounts returns the number of requests throttled by the given
+// limiter for each key.
+func throttledCounts(l *limiter) map[string]int64 {
+	counts := make(map[string]int64)
+	l.throttled.Subscribe(func(labels []string, n int64) {
+		counts[labels[0]+":"+labels[1]] += n
+	})()
+	return counts
+}
+
+func TestKeyedUnaryInboundMiddleware(t *testing.T) {
+	fakeClock := clock.NewFake()
+	mw, err := NewKeyedUnaryInboundMiddleware(ByCaller,
+		WithDefaultLimit(1, WithBurstLimit(1), WithClock(fakeClock)),
+		WithKeyLimit("keyed-test-vip", 1, WithBurstLimit(3), WithClock(fakeClock)),
+	)
+	require.NoError(t, err)
+
+	handle := func(caller string) error {
+		return mw.Handle(context.Background(), &transport.Request{Caller: caller}, nil, nopUnaryHandler)
+	}
+
+	assert.NoError(t, handle("keyed-test-foo"))
+	assert.NoError(t, handle("keyed-test-bar"), "callers must have separate limits")
+	err = handle("keyed-test-foo")
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.ErrorCode(err))
+	assert.Contains(t, err.Error(), `rate limit exceeded for "keyed-test-foo"`)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, handle("keyed-test-vip"), "request %d of overridden caller", i)
+	}
+	assert.Error(t, handle("keyed-test-vip"))
+
+	assert.NoError(t, handle("keyed-test-baz"))
+	assert.Error(t, handle("keyed-test-baz"))
+
+	assert.Equal(t, map[string]int64{
+		"unary:default":        2,
+		"unary:keyed-test-vip": 1,
+	}, throttledCounts(mw.limiter), "keys without their own limit must share a label")
+}
+
+func TestKeyedInboundMiddlewareWithoutDefault(t *testing.T) {
+	fakeClock := clock.NewFake()
+	mw, err := NewKeyedUnaryInboundMiddleware(ByProcedure,
+		WithKeyLimit("expensive", 1, WithBurstLimit(1), WithClock(fakeClock)),
+	)
+	require.NoError(t, err)
+
+	handle := func(procedure string) error {
+		return mw.Handle(context.Background(), &transport.Request{Procedure: procedure}, nil, nopUnaryHandler)
+	}
+
+	assert.NoError(t, handle("expensive"))
+	assert.Error(t, handle("expensive"))
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, handle("cheap"), "procedures without limits must not be throttled")
+	}
+	assert.Equal(t, 0, mw.limiter.numKeys(), "unlimited keys must not be tracked")
+}
+
+func TestKeyedInboundMiddlewareByHeader(t *testing.T) {
+	fakeClock := clock.NewFake()
+	mw, err := NewKeyedUnaryInboundMiddleware(ByHeader("tenant"),
+		WithDefaultLimit(1, WithBurstLimit(1), WithClock(fakeClock)),
+	)
+	require.NoError(t, err)
+
+	handle := func(headers transport.Headers) error {
+		return mw.Handle(context.Background(), &transport.Request{Headers: headers}, nil, nopUnaryHandler)
+	}
+
+	assert.NoError(t, handle(transport.NewHeaders().With("Tenant", "a")))
+	assert.NoError(t, handle(transport.NewHeaders().With("tenant", "b")))
+	assert.Error(t, handle(transport.NewHeaders().With("tenant", "a")))
+	assert.NoError(t, handle(transport.NewHeaders()))
+	assert.Error(t, handle(transport.NewHeaders()), "requests without the header must share a limit")
+}
+
+func TestKeyedInboundMiddlewareMaxKeys(t *testing.T) {
+	fakeClock := clock.NewFake()
+	mw, err := NewKeyedUnaryInboundMiddleware(ByCaller,
+		WithDefaultLimit(1, WithBurstLimit(1), WithClock(fakeClock)),
+		WithMaxKeys(2),
+	)
+	require.NoError(t, err)
+
+	handle := func(caller string) error {
+		return mw.Handle(context.Background(), &transport.Request{Caller: caller}, nil, nopUnaryHandler)
+	}
+
+	assert.NoError(t, handle("a"))
+	assert.NoError(t, handle("b"))
+	assert.Error(t, handle("a"), "a is limited and becomes the most recently used key")
+	assert.NoError(t, handle("c"), "c evicts b")
+	assert.Equal(t, 2, mw.limiter.numKeys())
+
+	assert.Error(t, handle("a"), "a must still be tracked")
+	assert.NoError(t, handle("b"), "b must start over after being evicted")
+}
+
+func TestOnewayInboundMiddleware(t *testing.T) {
+	fakeClock := clock.NewFake()
+	mw, err := NewOnewayInboundMiddleware(1, WithBurstLimit(1), WithClock(fakeClock))
+	require.NoError(t, err)
+
+	var handled int
+	h := onewayHandlerFunc(func(context.Context, *transport.Request) error {
+		handled++
+		return nil
+	})
+
+	assert.NoError(t, mw.HandleOneway(context.Background(), &transport.Request{}, h))
+	err = mw.HandleOneway(context.Background(), &transport.Request{}, h)
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.ErrorCode(err))
+	assert.Equal(t, "rate limit exceeded", yarpcerrors.ErrorMessage(err))
+	assert.Equal(t, 1, handled)
+	assert.Equal(t, int64(1), throttledCounts(mw.limiter)["oneway:"])
+
+	other, err := NewOnewayInboundMiddleware(1, WithBurstLimit(1), WithClock(fakeClock))
+	require.NoError(t, err)
+	assert.Empty(t, throttledCounts(other.limiter), "middleware must count their own throttled requests")
+	assert.Equal(t, []*sharedmetrics.Set{mw.limiter.metrics}, mw.Metrics())
+}
+
+func TestKeyedOnewayInboundMiddleware(t *testing.T) {
+	fakeClock := clock.NewFake()
+	mw, err := NewKeyedOnewayInboundMiddleware(ByCaller,
+		WithDefaultLimit(1, WithBurstLimit(1), WithClock(fakeClock)),
+	)
+	require.NoError(t, err)
+
+	h := onewayHandlerFunc(func(context.Context, *transport.Request) error { return nil })
+	assert.NoError(t, mw.HandleOneway(context.Background(), &transport.Request{Caller: "a"}, h))
+	assert.NoError(t, mw.HandleOneway(context.Background(), &transport.Request{Caller: "b"}, h))
+	assert.Error(t, mw.HandleOneway(context.Background(), &transport.Request{Caller: "a"}, h))
+}
+
+func TestNewKeyedInboundMiddlewareErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		key     KeyFunc
+		opts    []KeyedOption
+		wantErr string
+	}{
+		{
+			desc:    "no key",
+			opts:    []KeyedOption{WithDefaultLimit(10)},
+			wantErr: "keyed rate limiter requires a KeyFunc",
+		},
+		{
+			desc:    "no limits",
+			key:     ByCaller,
+			wantErr: "keyed rate limiter requires a default limit or at least one key limit",
+		},
+		{
+			desc:    "invalid max keys",
+			key:     ByCaller,
+			opts:    []KeyedOption{WithDefaultLimit(10), WithMaxKeys(0)},
+			wantErr: "keyed rate limiter maximum number of keys must be more than zero",
+		},
+		{
+			desc:    "invalid default limit",
+			key:     ByCaller,
+			opts:    []KeyedOption{WithDefaultLimit(-1)},
+			wantErr: "invalid default rate limit",
+		},
+		{
+			desc:    "invalid key limit",
+			key:     ByCaller,
+			opts:    []KeyedOption{WithKeyLimit("foo", 0)},
+			wantErr: `invalid rate limit for key "foo"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := NewKeyedUnaryInboundMiddleware(tt.key, tt.opts...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+
+			_, err = NewKeyedOnewayInboundMiddleware(tt.key, tt.opts...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestInvalidOnewayInboundMiddleware(t *testing.T) {
+	_, err := NewOnewayInboundMiddleware(0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,298 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const defaultReservedCapacity = 0.2
+
+var errConcurrencyLimitExceeded = yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted, "concurrency limit exceeded")
+
+type loadSheddingOptions struct {
+	maxConcurrency                 int
+	defaultProcedureMaxConcurrency int
+	procedures                     map[string]int
+	priorityCallers                map[string]struct{}
+	priorityHeader                 string
+	priorityHeaderValues           map[string]struct{}
+	reservedCapacity               float64
+	minRemainingTimeout            time.Duration
+	clock                          Clock
+}
+
+// LoadSheddingOption customizes the behavior of a load shedding inbound
+// middleware.
+type LoadSheddingOption func(*loadSheddingOptions)
+
+// WithMaxConcurrency specifies the maximum number of requests that may be
+// handled concurrently across all procedures.
+//
+// By default, the number of concurrent requests is not limited globally.
+func WithMaxConcurrency(limit int) LoadSheddingOption {
+	return func(options *loadSheddingOptions) {
+		options.maxConcurrency = limit
+	}
+}
+
+// WithDefaultProcedureMaxConcurrency specifies the maximum number of requests
+// that may be handled concurrently by each procedure that does not have its
+// own limit.
+//
+// By default, procedures without their own limit are only subject to the
+// global limit.
+func WithDefaultProcedureMaxConcurrency(limit int) LoadSheddingOption {
+	return func(options *loadSheddingOptions) {
+		options.defaultProcedureMaxConcurrency = limit
+	}
+}
+
+// WithProcedureMaxConcurrency specifies the maximum number of requests that
+// may be handled concurrently by the given procedure, overriding
+// WithDefaultProcedureMaxConcurrency for that procedure.
+//
+// This option may be provided multiple times.
+func WithProcedureMaxConcurrency(procedure string, limit int) LoadSheddingOption {
+	return func(options *loadSheddingOptions) {
+		if options.procedures == nil {
+			options.procedures = make(map[string]int)
+		}
+		options.procedures[procedure] = limit
+	}
+}
+
+// WithPriorityCallers specifies callers whose requests have priority over
+// other requests. See WithReservedCapacity.
+//
+// This option may be provided multiple times.
+func WithPriorityCallers(callers ...string) LoadSheddingOption {
+	return func(options *loadSheddingOptions) {
+		if options.priorityCallers == nil {
+			options.priorityCallers = make(map[string]struct{})
+		}
+		for _, caller := range callers {
+			options.priorityCallers[caller] = struct{}{}
+		}
+	}
+}
+
+// WithPriorityHeader specifies a request header which gives requests
+// priority over other requests if it has one of the given values, or any
+// non-empty value if no values are given. See WithReservedCapacity.
+func WithPriorityHeader(key string, values ...string) LoadSheddingOption {
+	return func(options *loadSheddingOptions) {
+		options.priorityHeader = key
+		options.priorityHeaderValues = nil
+		if len(values) > 0 {
+			options.priorityHeaderValues = make(map[string]struct{}, len(values))
+			for _, v := range values {
+				options.priorityHeaderValues[v] = struct{}{}
+			}
+		}
+	}
+}
+
+// WithReservedCapacity specifies the fraction of every concurrency limit
+// that is reserved for priority requests, if priority callers or a priority
+// header were specified. Other requests are shed once the remainder is in
+// use. It must be at least 0 and less than 1.
+//
+// Defaults to 0.2.
+func WithReservedCapacity(ratio float64) LoadSheddingOption {
+	return func(options *loadSheddingOptions) {
+		options.reservedCapacity = ratio
+	}
+}
+
+// WithMinRemainingTimeout specifies the minimum time that must remain before
+// the deadline of a request for it to be handled. Requests with less time
+// remaining are unlikely to complete before the caller gives up, so they
+// are shed with a DeadlineExceededError instead.
+//
+// By default, requests are handled regardless of their deadline.
+func WithMinRemainingTimeout(timeout time.Duration) LoadSheddingOption {
+	return func(options *loadSheddingOptions) {
+		options.minRemainingTimeout = timeout
+	}
+}
+
+func withLoadSheddingClock(clock Clock) LoadSheddingOption {
+	return func(options *loadSheddingOptions) {
+		options.clock = clock
+	}
+}
+
+// NewLoadSheddingInboundMiddleware creates a unary inbound middleware that
+// sheds inbound requests when too many are already being handled, either
+// globally or by their procedure, failing them with a
+// ResourceExhaustedError.
+//
+// Unlike a rate limit, concurrency limits protect a service whose handlers
+// slow down: requests pile up while handlers are slow, and excess requests
+// are rejected right away instead of waiting behind them.
+func NewLoadSheddingInboundMiddleware(opts ...LoadSheddingOption) (*LoadSheddingInboundMiddleware, error) {
+	options := loadSheddingOptions{reservedCapacity: defaultReservedCapacity}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.clock == nil {
+		options.clock = clock.NewReal()
+	}
+
+	if options.maxConcurrency < 0 {
+		return nil, fmt.Errorf("load shedding max concurrency must not be negative, got %d", options.maxConcurrency)
+	}
+	if options.defaultProcedureMaxConcurrency < 0 {
+		return nil, fmt.Errorf("load shedding default procedure max concurrency must not be negative, got %d", options.defaultProcedureMaxConcurrency)
+	}
+	for procedure, limit := range options.procedures {
+		if limit <= 0 {
+			return nil, fmt.Errorf("load shedding concurrency limit for procedure %q must be more than zero, got %d", procedure, limit)
+		}
+	}
+	if options.reservedCapacity < 0 || options.reservedCapacity >= 1 {
+		return nil, fmt.Errorf("load shedding reserved capacity must be at least 0 and less than 1, got %v", options.reservedCapacity)
+	}
+	if options.minRemainingTimeout < 0 {
+		return nil, fmt.Errorf("load shedding minimum remaining timeout must not be negative, got %v", options.minRemainingTimeout)
+	}
+
+	return &LoadSheddingInboundMiddleware{
+		options:    options,
+		procedures: make(map[string]int),
+	}, nil
+}
+
+// LoadSheddingInboundMiddleware is a unary inbound middleware that sheds
+// inbound requests above concurrency limits, or with too little time left
+// before their deadline.
+type LoadSheddingInboundMiddleware struct {
+	options loadSheddingOptions
+
+	mu         sync.Mutex
+	inflight   int
+	procedures map[string]int // in-flight requests by procedure
+}
+
+var _ middleware.UnaryInbound = (*LoadSheddingInboundMiddleware)(nil)
+
+// Handle passes the request to the next handler if its deadline is far
+// enough away and no concurrency limit has been reached, or fails with an
+// error otherwise.
+func (m *LoadSheddingInboundMiddleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	if min := m.options.minRemainingTimeout; min > 0 {
+		if deadline, ok := ctx.Deadline(); ok {
+			if remaining := deadline.Sub(m.options.clock.Now()); remaining < min {
+				return yarpcerrors.Newf(yarpcerrors.CodeDeadlineExceeded,
+					"shed request to procedure %q with %v remaining before its deadline, need at least %v",
+					req.Procedure, remaining, min)
+			}
+		}
+	}
+
+	if err := m.acquire(req.Procedure, m.hasPriority(req)); err != nil {
+		return err
+	}
+	defer m.release(req.Procedure)
+	return h.Handle(ctx, req, resw)
+}
+
+// Inflight returns the number of requests currently being handled.
+func (m *LoadSheddingInboundMiddleware) Inflight() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.inflight
+}
+
+func (m *LoadSheddingInboundMiddleware) hasPriority(req *transport.Request) bool {
+	if len(m.options.priorityCallers) == 0 && m.options.priorityHeader == "" {
+		// Without priorities, every request may use the whole capacity.
+		return true
+	}
+	if _, ok := m.options.priorityCallers[req.Caller]; ok {
+		return true
+	}
+	if m.options.priorityHeader == "" {
+		return false
+	}
+	v, ok := req.Headers.Get(m.options.priorityHeader)
+	if !ok || v == "" {
+		return false
+	}
+	if m.options.priorityHeaderValues == nil {
+		return true
+	}
+	_, ok = m.options.priorityHeaderValues[v]
+	return ok
+}
+
+func (m *LoadSheddingInboundMiddleware) acquire(procedure string, priority bool) error {
+	procedureLimit, ok := m.options.procedures[procedure]
+	if !ok {
+		procedureLimit = m.options.defaultProcedureMaxConcurrency
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.exceeds(m.inflight, m.options.maxConcurrency, priority) {
+		return errConcurrencyLimitExceeded
+	}
+	if m.exceeds(m.procedures[procedure], procedureLimit, priority) {
+		return yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted, "concurrency limit exceeded for procedure %q", procedure)
+	}
+	m.inflight++
+	m.procedures[procedure]++
+	return nil
+}
+
+// exceeds returns true if another request can't be handled with the given
+// number of requests in flight. A limit of zero means no limit.
+func (m *LoadSheddingInboundMiddleware) exceeds(inflight, limit int, priority bool) bool {
+	if limit == 0 {
+		return false
+	}
+	if !priority {
+		limit -= int(float64(limit) * m.options.reservedCapacity)
+	}
+	return inflight >= limit
+}
+
+func (m *LoadSheddingInboundMiddleware) release(procedure string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.inflight--
+	if n := m.procedures[procedure] - 1; n > 0 {
+		m.procedures[procedure] = n
+	} else {
+		delete(m.procedures, procedure)
+	}
+}
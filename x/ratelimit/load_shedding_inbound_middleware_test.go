@@ -0,0 +1,283 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return f(ctx, req, resw)
+}
+
+// handleNested handles the given requests such that every request which is
+// accepted is still in flight while the following requests are handled. It
+// returns the error for each request.
+func handleNested(ctx context.Context, mw *LoadSheddingInboundMiddleware, reqs ...*transport.Request) []error {
+	errs := make([]error, len(reqs))
+	var handle func(int)
+	handle = func(i int) {
+		if i == len(reqs) {
+			return
+		}
+		called := false
+		errs[i] = mw.Handle(ctx, reqs[i], nil, unaryHandlerFunc(func(context.Context, *transport.Request, transport.ResponseWriter) error {
+			called = true
+			handle(i + 1)
+			return nil
+		}))
+		if !called {
+			handle(i + 1)
+		}
+	}
+	handle(0)
+	return errs
+}
+
+func newLoadSheddingMiddleware(t *testing.T, opts ...LoadSheddingOption) *LoadSheddingInboundMiddleware {
+	mw, err := NewLoadSheddingInboundMiddleware(opts...)
+	require.NoError(t, err)
+	return mw
+}
+
+func assertShed(t *testing.T, errs []error, want []bool) {
+	require.Len(t, errs, len(want))
+	for i, err := range errs {
+		if want[i] {
+			if assert.Error(t, err, "request %d should be shed", i) {
+				assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.ErrorCode(err), "request %d", i)
+			}
+		} else {
+			assert.NoError(t, err, "request %d should be handled", i)
+		}
+	}
+}
+
+func TestLoadSheddingMaxConcurrency(t *testing.T) {
+	mw := newLoadSheddingMiddleware(t, WithMaxConcurrency(2))
+
+	errs := handleNested(context.Background(), mw,
+		&transport.Request{Procedure: "a"},
+		&transport.Request{Procedure: "b"},
+		&transport.Request{Procedure: "c"},
+	)
+	assertShed(t, errs, []bool{false, false, true})
+	assert.Contains(t, errs[2].Error(), "concurrency limit exceeded")
+	assert.Equal(t, 0, mw.Inflight(), "all requests must be released")
+
+	// Capacity is available again once requests finish.
+	assertShed(t, handleNested(context.Background(), mw, &transport.Request{Procedure: "c"}), []bool{false})
+}
+
+func TestLoadSheddingProcedureMaxConcurrency(t *testing.T) {
+	mw := newLoadSheddingMiddleware(t,
+		WithDefaultProcedureMaxConcurrency(1),
+		WithProcedureMaxConcurrency("b", 2),
+	)
+
+	errs := handleNested(context.Background(), mw,
+		&transport.Request{Procedure: "a"},
+		&transport.Request{Procedure: "a"},
+		&transport.Request{Procedure: "b"},
+		&transport.Request{Procedure: "b"},
+		&transport.Request{Procedure: "b"},
+		&transport.Request{Procedure: "c"},
+	)
+	assertShed(t, errs, []bool{false, true, false, false, true, false})
+	assert.Contains(t, errs[1].Error(), `concurrency limit exceeded for procedure "a"`)
+	assert.Contains(t, errs[4].Error(), `concurrency limit exceeded for procedure "b"`)
+	assert.Empty(t, mw.procedures, "in-flight counts must be cleaned up")
+}
+
+func TestLoadSheddingGlobalAndProcedureLimits(t *testing.T) {
+	mw := newLoadSheddingMiddleware(t,
+		WithMaxConcurrency(3),
+		WithProcedureMaxConcurrency("a", 2),
+	)
+
+	errs := handleNested(context.Background(), mw,
+		&transport.Request{Procedure: "a"},
+		&transport.Request{Procedure: "a"},
+		&transport.Request{Procedure: "a"},
+		&transport.Request{Procedure: "b"},
+		&transport.Request{Procedure: "b"},
+	)
+	assertShed(t, errs, []bool{false, false, true, false, true})
+}
+
+func TestLoadSheddingPriority(t *testing.T) {
+	normal := &transport.Request{Caller: "batch", Procedure: "a"}
+
+	tests := []struct {
+		desc     string
+		opts     []LoadSheddingOption
+		priority *transport.Request
+	}{
+		{
+			desc:     "caller",
+			opts:     []LoadSheddingOption{WithPriorityCallers("web", "mobile")},
+			priority: &transport.Request{Caller: "mobile", Procedure: "a"},
+		},
+		{
+			desc: "header value",
+			opts: []LoadSheddingOption{WithPriorityHeader("priority", "high", "critical")},
+			priority: &transport.Request{
+				Caller:    "batch",
+				Procedure: "a",
+				Headers:   transport.NewHeaders().With("Priority", "critical"),
+			},
+		},
+		{
+			desc: "any header value",
+			opts: []LoadSheddingOption{WithPriorityHeader("priority")},
+			priority: &transport.Request{
+				Caller:    "batch",
+				Procedure: "a",
+				Headers:   transport.NewHeaders().With("priority", "yes"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			opts := append([]LoadSheddingOption{WithMaxConcurrency(5), WithReservedCapacity(0.4)}, tt.opts...)
+			mw := newLoadSheddingMiddleware(t, opts...)
+
+			// Three of the five slots are available to normal requests, all
+			// five to priority requests.
+			errs := handleNested(context.Background(), mw,
+				normal, normal, normal, normal, tt.priority, tt.priority, tt.priority,
+			)
+			assertShed(t, errs, []bool{false, false, false, true, false, false, true})
+		})
+	}
+}
+
+func TestLoadSheddingPriorityHeaderMismatch(t *testing.T) {
+	mw := newLoadSheddingMiddleware(t,
+		WithMaxConcurrency(2),
+		WithReservedCapacity(0.5),
+		WithPriorityHeader("priority", "high"),
+	)
+
+	low := &transport.Request{Procedure: "a", Headers: transport.NewHeaders().With("priority", "low")}
+	errs := handleNested(context.Background(), mw, low, low)
+	assertShed(t, errs, []bool{false, true})
+}
+
+func TestLoadSheddingWithoutPriorityUsesFullCapacity(t *testing.T) {
+	mw := newLoadSheddingMiddleware(t, WithMaxConcurrency(2), WithReservedCapacity(0.5))
+
+	req := &transport.Request{Procedure: "a"}
+	assertShed(t, handleNested(context.Background(), mw, req, req, req), []bool{false, false, true})
+}
+
+func TestLoadSheddingMinRemainingTimeout(t *testing.T) {
+	fakeClock := clock.NewFake()
+	mw := newLoadSheddingMiddleware(t,
+		WithMinRemainingTimeout(100*time.Millisecond),
+		withLoadSheddingClock(fakeClock),
+	)
+	req := &transport.Request{Procedure: "a"}
+
+	t.Run("no deadline", func(t *testing.T) {
+		assert.NoError(t, handleNested(context.Background(), mw, req)[0])
+	})
+
+	t.Run("enough time", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(context.Background(), fakeClock.Now().Add(time.Second))
+		defer cancel()
+		assert.NoError(t, handleNested(ctx, mw, req)[0])
+	})
+
+	t.Run("too little time", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(context.Background(), fakeClock.Now().Add(50*time.Millisecond))
+		defer cancel()
+		err := handleNested(ctx, mw, req)[0]
+		require.Error(t, err)
+		assert.Equal(t, yarpcerrors.CodeDeadlineExceeded, yarpcerrors.ErrorCode(err))
+		assert.Contains(t, err.Error(), `shed request to procedure "a" with 50ms remaining`)
+	})
+}
+
+func TestLoadSheddingHandlerError(t *testing.T) {
+	mw := newLoadSheddingMiddleware(t, WithMaxConcurrency(1))
+	wantErr := yarpcerrors.Newf(yarpcerrors.CodeInternal, "great sadness")
+
+	err := mw.Handle(context.Background(), &transport.Request{Procedure: "a"}, nil,
+		unaryHandlerFunc(func(context.Context, *transport.Request, transport.ResponseWriter) error {
+			return wantErr
+		}))
+	assert.Equal(t, wantErr, err)
+	assert.Equal(t, 0, mw.Inflight())
+}
+
+func TestNewLoadSheddingInboundMiddlewareErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		opts    []LoadSheddingOption
+		wantErr string
+	}{
+		{
+			desc:    "negative max concurrency",
+			opts:    []LoadSheddingOption{WithMaxConcurrency(-1)},
+			wantErr: "load shedding max concurrency must not be negative",
+		},
+		{
+			desc:    "negative default procedure max concurrency",
+			opts:    []LoadSheddingOption{WithDefaultProcedureMaxConcurrency(-1)},
+			wantErr: "load shedding default procedure max concurrency must not be negative",
+		},
+		{
+			desc:    "zero procedure max concurrency",
+			opts:    []LoadSheddingOption{WithProcedureMaxConcurrency("a", 0)},
+			wantErr: `load shedding concurrency limit for procedure "a" must be more than zero`,
+		},
+		{
+			desc:    "reserved capacity too large",
+			opts:    []LoadSheddingOption{WithReservedCapacity(1)},
+			wantErr: "load shedding reserved capacity must be at least 0 and less than 1",
+		},
+		{
+			desc:    "negative minimum remaining timeout",
+			opts:    []LoadSheddingOption{WithMinRemainingTimeout(-time.Second)},
+			wantErr: "load shedding minimum remaining timeout must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := NewLoadSheddingInboundMiddleware(tt.opts...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/internal/pally"
+	"go.uber.org/yarpc/internal/sharedmetrics"
+)
+
+// newWaitsVector adds the time outbound requests waited for rate limits to
+// the given Set.
+func newWaitsVector(metrics *sharedmetrics.Set) *sharedmetrics.LatenciesVector {
+	return metrics.NewLatenciesVector(pally.LatencyOpts{
+		Opts: pally.Opts{
+			Name:           "outbound_rate_limit_wait_ms",
+			Help:           "Time outbound requests waited for rate limits.",
+			VariableLabels: []string{"dest", "procedure"},
+		},
+		Unit: time.Millisecond,
+		Buckets: []time.Duration{
+			1 * time.Millisecond,
+			2 * time.Millisecond,
+			5 * time.Millisecond,
+			10 * time.Millisecond,
+			20 * time.Millisecond,
+			50 * time.Millisecond,
+			100 * time.Millisecond,
+			200 * time.Millisecond,
+			500 * time.Millisecond,
+			1000 * time.Millisecond,
+			2000 * time.Millisecond,
+			5000 * time.Millisecond,
+		},
+	})
+}
+
+// newLimitsVector adds the current limits of adaptive outbound middleware to
+// the given Set.
+func newLimitsVector(metrics *sharedmetrics.Set) *sharedmetrics.GaugeVector {
+	return metrics.NewGaugeVector(pally.Opts{
+		Name:           "outbound_concurrency_limit",
+		Help:           "Current adaptive limit of concurrent outbound requests.",
+		VariableLabels: []string{"dest"},
+	})
+}
+
+// newThrottledVector adds the requests throttled by inbound middleware to the
+// given Set.
+func newThrottledVector(metrics *sharedmetrics.Set) *sharedmetrics.CounterVector {
+	return metrics.NewCounterVector(pally.Opts{
+		Name:           "inbound_throttled_calls",
+		Help:           "Number of inbound requests rejected by rate limits.",
+		VariableLabels: []string{"rpc_type", "key"},
+	})
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/sharedmetrics"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type procedureKey struct {
+	service   string
+	procedure string
+}
+
+type outboundOptions struct {
+	services   map[string]keyLimit
+	procedures map[procedureKey]keyLimit
+	failFast   bool
+	clock      clock.Clock
+}
+
+// OutboundOption customizes the behavior of an outbound rate limit
+// middleware.
+type OutboundOption func(*outboundOptions)
+
+// WithServiceLimit specifies the rate limit for requests to the given
+// service, shared by all of its procedures that don't have their own limit.
+//
+// This option may be provided multiple times.
+func WithServiceLimit(service string, rps int, opts ...Option) OutboundOption {
+	return func(options *outboundOptions) {
+		if options.services == nil {
+			options.services = make(map[string]keyLimit)
+		}
+		options.services[service] = keyLimit{rps: rps, opts: opts}
+	}
+}
+
+// WithProcedureLimit specifies the rate limit for requests to a procedure of
+// the given service. Requests to the procedure are not subject to the limit
+// of the service.
+//
+// This option may be provided multiple times.
+func WithProcedureLimit(service, procedure string, rps int, opts ...Option) OutboundOption {
+	return func(options *outboundOptions) {
+		if options.procedures == nil {
+			options.procedures = make(map[procedureKey]keyLimit)
+		}
+		options.procedures[procedureKey{service: service, procedure: procedure}] = keyLimit{rps: rps, opts: opts}
+	}
+}
+
+// FailFast fails requests above the rate limit immediately with a
+// ResourceExhaustedError.
+//
+// By default, requests above the rate limit wait until they are allowed, and
+// only fail if that would take longer than their deadline.
+func FailFast() OutboundOption {
+	return func(options *outboundOptions) {
+		options.failFast = true
+	}
+}
+
+func withOutboundClock(clock clock.Clock) OutboundOption {
+	return func(options *outboundOptions) {
+		options.clock = clock
+	}
+}
+
+// NewOutboundMiddleware creates a unary and oneway outbound middleware that
+// limits the rate of requests to services or to their procedures, to avoid
+// overwhelming fragile dependencies.
+//
+// Requests to services and procedures without a rate limit are sent right
+// away. The time requests waited for a rate limit is reported by the
+// Dispatchers using the middleware as the outbound_rate_limit_wait_ms
+// histogram.
+func NewOutboundMiddleware(opts ...OutboundOption) (*OutboundMiddleware, error) {
+	var options outboundOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.clock == nil {
+		options.clock = clock.NewReal()
+	}
+
+	metrics := sharedmetrics.NewSet()
+	m := &OutboundMiddleware{
+		failFast:   options.failFast,
+		clock:      options.clock,
+		metrics:    metrics,
+		waits:      newWaitsVector(metrics),
+		services:   make(map[string]*Throttle, len(options.services)),
+		procedures: make(map[procedureKey]*Throttle, len(options.procedures)),
+	}
+	for service, l := range options.services {
+		throttle, err := NewThrottle(l.rps, append([]Option{WithClock(options.clock)}, l.opts...)...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit for service %q: %v", service, err)
+		}
+		m.services[service] = throttle
+	}
+	for k, l := range options.procedures {
+		throttle, err := NewThrottle(l.rps, append([]Option{WithClock(options.clock)}, l.opts...)...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit for procedure %q of service %q: %v", k.procedure, k.service, err)
+		}
+		m.procedures[k] = throttle
+	}
+	return m, nil
+}
+
+// OutboundMiddleware is a unary and oneway outbound middleware that delays
+// or fails outbound requests above per-service and per-procedure rate
+// limits.
+type OutboundMiddleware struct {
+	failFast   bool
+	clock      clock.Clock
+	metrics    *sharedmetrics.Set
+	waits      *sharedmetrics.LatenciesVector
+	services   map[string]*Throttle
+	procedures map[procedureKey]*Throttle
+}
+
+var (
+	_ middleware.UnaryOutbound  = (*OutboundMiddleware)(nil)
+	_ middleware.OnewayOutbound = (*OutboundMiddleware)(nil)
+)
+
+// Metrics returns the metrics of the middleware, which Dispatchers using it
+// report.
+func (m *OutboundMiddleware) Metrics() []*sharedmetrics.Set {
+	return []*sharedmetrics.Set{m.metrics}
+}
+
+// Call sends the request to the next outbound once its rate limit allows
+// it.
+func (m *OutboundMiddleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	if err := m.wait(ctx, req); err != nil {
+		return nil, err
+	}
+	return out.Call(ctx, req)
+}
+
+// CallOneway sends the request to the next outbound once its rate limit
+// allows it.
+func (m *OutboundMiddleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	if err := m.wait(ctx, req); err != nil {
+		return nil, err
+	}
+	return out.CallOneway(ctx, req)
+}
+
+// wait blocks until the rate limit of the request allows it to be sent, or
+// returns an error if it can't be sent in time.
+func (m *OutboundMiddleware) wait(ctx context.Context, req *transport.Request) error {
+	throttle := m.throttleFor(req)
+	if throttle == nil {
+		return nil
+	}
+
+	if m.failFast {
+		if throttle.Throttle() {
+			return yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted,
+				"outbound rate limit exceeded for procedure %q of service %q", req.Procedure, req.Service)
+		}
+		return nil
+	}
+
+	maxWait := time.Duration(math.MaxInt64)
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = deadline.Sub(m.clock.Now())
+	}
+	delay, ok := throttle.reserve(maxWait)
+	if !ok {
+		return yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted,
+			"outbound rate limit for procedure %q of service %q would delay request by %v, past its deadline",
+			req.Procedure, req.Service, delay)
+	}
+	// Observing is lock-free, so requests that don't wait are recorded too.
+	m.waits.Observe(delay, req.Service, req.Procedure)
+	if delay <= 0 {
+		return nil
+	}
+
+	select {
+	case <-m.clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		// The request won't use its slot, so let the next request have it.
+		throttle.release()
+		if ctx.Err() == context.DeadlineExceeded {
+			return yarpcerrors.Newf(yarpcerrors.CodeDeadlineExceeded,
+				"deadline exceeded while waiting for outbound rate limit of service %q", req.Service)
+		}
+		return yarpcerrors.Newf(yarpcerrors.CodeCancelled,
+			"cancelled while waiting for outbound rate limit of service %q", req.Service)
+	}
+}
+
+func (m *OutboundMiddleware) throttleFor(req *transport.Request) *Throttle {
+	if throttle, ok := m.procedures[procedureKey{service: req.Service, procedure: req.Procedure}]; ok {
+		return throttle
+	}
+	return m.services[req.Service]
+}
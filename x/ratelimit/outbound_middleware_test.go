@@ -0,0 +1,232 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func newOutboundMiddleware(t *testing.T, opts ...OutboundOption) *OutboundMiddleware {
+	mw, err := NewOutboundMiddleware(opts...)
+	require.NoError(t, err)
+	return mw
+}
+
+func callWith(ctx context.Context, mw *OutboundMiddleware, service, procedure string) error {
+	out := fakeOutbound{call: func(*transport.Request) error { return nil }}
+	_, err := mw.Call(ctx, &transport.Request{Service: service, Procedure: procedure}, out)
+	return err
+}
+
+func TestThrottleReserve(t *testing.T) {
+	fakeClock := clock.NewFake()
+	throttle, err := NewThrottle(10, WithoutSlack, WithClock(fakeClock))
+	require.NoError(t, err)
+
+	wait, ok := throttle.reserve(time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), wait)
+
+	wait, ok = throttle.reserve(time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 100*time.Millisecond, wait)
+
+	wait, ok = throttle.reserve(150 * time.Millisecond)
+	assert.False(t, ok, "must not reserve a slot beyond the maximum wait")
+	assert.Equal(t, 200*time.Millisecond, wait)
+
+	wait, ok = throttle.reserve(time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 200*time.Millisecond, wait, "failed reservations must not claim a slot")
+
+	throttle.release()
+	wait, ok = throttle.reserve(time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 200*time.Millisecond, wait, "released slots must be claimed again")
+
+	fakeClock.Add(time.Second)
+	assert.False(t, throttle.Throttle(), "reservations must be shared with Throttle")
+}
+
+func TestOutboundMiddlewareFailFast(t *testing.T) {
+	fakeClock := clock.NewFake()
+	mw := newOutboundMiddleware(t,
+		FailFast(),
+		WithServiceLimit("fragile", 1, WithBurstLimit(1)),
+		WithProcedureLimit("fragile", "expensive", 1, WithBurstLimit(2)),
+		withOutboundClock(fakeClock),
+	)
+	ctx := context.Background()
+
+	assert.NoError(t, callWith(ctx, mw, "fragile", "cheap"))
+	err := callWith(ctx, mw, "fragile", "other")
+	require.Error(t, err, "procedures without limits must share the limit of the service")
+	assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.ErrorCode(err))
+	assert.Contains(t, err.Error(), `outbound rate limit exceeded for procedure "other" of service "fragile"`)
+
+	assert.NoError(t, callWith(ctx, mw, "fragile", "expensive"))
+	assert.NoError(t, callWith(ctx, mw, "fragile", "expensive"))
+	assert.Error(t, callWith(ctx, mw, "fragile", "expensive"))
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, callWith(ctx, mw, "sturdy", "cheap"), "services without limits must not be limited")
+	}
+}
+
+func TestOutboundMiddlewareWaits(t *testing.T) {
+	mw := newOutboundMiddleware(t, WithServiceLimit("waits-test", 50, WithoutSlack))
+
+	var (
+		mu    sync.Mutex
+		waits []time.Duration
+	)
+	stop := mw.waits.Subscribe(func(labels []string, wait int64) {
+		mu.Lock()
+		waits = append(waits, time.Duration(wait))
+		mu.Unlock()
+	})
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, callWith(ctx, mw, "waits-test", "procedure"))
+	}
+	assert.True(t, time.Since(start) >= 35*time.Millisecond, "requests must be spread out by the rate limit")
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, waits, 3)
+	assert.True(t, waits[1] > 0, "the second request must wait")
+}
+
+func TestOutboundMiddlewareWaitErrors(t *testing.T) {
+	fakeClock := clock.NewFake()
+	mw := newOutboundMiddleware(t,
+		WithServiceLimit("fragile", 1, WithoutSlack),
+		withOutboundClock(fakeClock),
+	)
+	require.NoError(t, callWith(context.Background(), mw, "fragile", "procedure"))
+
+	t.Run("wait past deadline", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(context.Background(), fakeClock.Now().Add(500*time.Millisecond))
+		defer cancel()
+
+		err := callWith(ctx, mw, "fragile", "procedure")
+		require.Error(t, err)
+		assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.ErrorCode(err))
+		assert.Contains(t, err.Error(), "would delay request by 1s, past its deadline")
+	})
+
+	t.Run("deadline exceeded while waiting", func(t *testing.T) {
+		// The context expired in real time, but not according to the fake
+		// clock.
+		ctx, cancel := context.WithDeadline(context.Background(), fakeClock.Now().Add(time.Minute))
+		defer cancel()
+
+		err := callWith(ctx, mw, "fragile", "procedure")
+		require.Error(t, err)
+		assert.Equal(t, yarpcerrors.CodeDeadlineExceeded, yarpcerrors.ErrorCode(err))
+	})
+
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := callWith(ctx, mw, "fragile", "procedure")
+		require.Error(t, err)
+		assert.Equal(t, yarpcerrors.CodeCancelled, yarpcerrors.ErrorCode(err))
+	})
+
+	t.Run("abandoned slots are given back", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			assert.Error(t, callWith(ctx, mw, "fragile", "procedure"))
+		}
+
+		ctx, cancel := context.WithDeadline(context.Background(), fakeClock.Now().Add(500*time.Millisecond))
+		defer cancel()
+		err := callWith(ctx, mw, "fragile", "procedure")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "would delay request by 1s, past its deadline",
+			"abandoned requests must not delay later requests")
+	})
+}
+
+func TestOutboundMiddlewareOneway(t *testing.T) {
+	fakeClock := clock.NewFake()
+	mw := newOutboundMiddleware(t,
+		FailFast(),
+		WithServiceLimit("fragile", 1, WithBurstLimit(1)),
+		withOutboundClock(fakeClock),
+	)
+
+	var calls int
+	out := fakeOutbound{call: func(*transport.Request) error {
+		calls++
+		return nil
+	}}
+	req := &transport.Request{Service: "fragile", Procedure: "procedure"}
+
+	_, err := mw.CallOneway(context.Background(), req, out)
+	assert.NoError(t, err)
+	_, err = mw.CallOneway(context.Background(), req, out)
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestNewOutboundMiddlewareErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		opts    []OutboundOption
+		wantErr string
+	}{
+		{
+			desc:    "invalid service limit",
+			opts:    []OutboundOption{WithServiceLimit("foo", 0)},
+			wantErr: `invalid rate limit for service "foo"`,
+		},
+		{
+			desc:    "invalid procedure limit",
+			opts:    []OutboundOption{WithProcedureLimit("foo", "bar", -1)},
+			wantErr: `invalid rate limit for procedure "bar" of service "foo"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := NewOutboundMiddleware(tt.opts...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/encoding/json"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// _acquireProcedure is the procedure through which QuotaServers grant tokens.
+const _acquireProcedure = "ratelimit::acquire"
+
+type acquireRequest struct {
+	Key    string `json:"key"`
+	Tokens int    `json:"tokens"`
+}
+
+type acquireResponse struct {
+	Granted int `json:"granted"`
+}
+
+// QuotaServer serves the quotas of a TokenStore to other processes over
+// YARPC, so that their DistributedThrottles can share rate limits through a
+// RemoteTokenStore.
+//
+// 	store := ratelimit.NewMemoryTokenStore()
+// 	store.SetQuota("myservice", 1000)
+// 	dispatcher.Register(ratelimit.NewQuotaServer(store).Procedures())
+type QuotaServer struct {
+	store TokenStore
+}
+
+// NewQuotaServer creates a QuotaServer which grants tokens from the given
+// store.
+func NewQuotaServer(store TokenStore) *QuotaServer {
+	return &QuotaServer{store: store}
+}
+
+// Procedures returns the procedures to register on a Dispatcher.
+func (s *QuotaServer) Procedures() []transport.Procedure {
+	p := json.Procedure(_acquireProcedure, s.acquire)[0]
+	p.Signature = `acquire({"key": "...", "tokens": 10}) {"granted": 10}`
+	return []transport.Procedure{p}
+}
+
+func (s *QuotaServer) acquire(ctx context.Context, req *acquireRequest) (*acquireResponse, error) {
+	if req.Tokens <= 0 {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument, "number of tokens must be more than zero, got %d", req.Tokens)
+	}
+	granted, err := s.store.Acquire(ctx, req.Key, req.Tokens)
+	if err != nil {
+		return nil, err
+	}
+	return &acquireResponse{Granted: granted}, nil
+}
+
+// RemoteTokenStore is a TokenStore which leases tokens from a QuotaServer.
+type RemoteTokenStore struct {
+	client json.Client
+}
+
+var _ TokenStore = (*RemoteTokenStore)(nil)
+
+// NewRemoteTokenStore creates a TokenStore which leases tokens from the
+// QuotaServer reachable with the given client configuration.
+//
+// 	store := ratelimit.NewRemoteTokenStore(dispatcher.ClientConfig("quota"))
+func NewRemoteTokenStore(cc transport.ClientConfig) *RemoteTokenStore {
+	return &RemoteTokenStore{client: json.New(cc)}
+}
+
+// Acquire requests up to n tokens from the quota with the given key.
+func (s *RemoteTokenStore) Acquire(ctx context.Context, key string, n int) (int, error) {
+	var res acquireResponse
+	if err := s.client.Call(ctx, _acquireProcedure, &acquireRequest{Key: key, Tokens: n}, &res); err != nil {
+		return 0, err
+	}
+	return res.Granted, nil
+}
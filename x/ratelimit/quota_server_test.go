@@ -0,0 +1,83 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/transport/http"
+	"go.uber.org/yarpc/x/ratelimit"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func TestQuotaServer(t *testing.T) {
+	store := ratelimit.NewMemoryTokenStore()
+	require.NoError(t, store.SetQuota("shared", 1, ratelimit.WithBurstLimit(5)))
+
+	serverTransport := http.NewTransport()
+	serverInbound := serverTransport.NewInbound("127.0.0.1:0")
+	serverDispatcher := yarpc.NewDispatcher(yarpc.Config{
+		Name:     "quota",
+		Inbounds: yarpc.Inbounds{serverInbound},
+	})
+	serverDispatcher.Register(ratelimit.NewQuotaServer(store).Procedures())
+	require.NoError(t, serverDispatcher.Start())
+	defer serverDispatcher.Stop()
+
+	clientTransport := http.NewTransport()
+	clientDispatcher := yarpc.NewDispatcher(yarpc.Config{
+		Name: "client",
+		Outbounds: yarpc.Outbounds{
+			"quota": transport.Outbounds{
+				Unary: clientTransport.NewSingleOutbound(fmt.Sprintf("http://%s/", serverInbound.Addr())),
+			},
+		},
+	})
+	require.NoError(t, clientDispatcher.Start())
+	defer clientDispatcher.Stop()
+
+	remote := ratelimit.NewRemoteTokenStore(clientDispatcher.ClientConfig("quota"))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	granted, err := remote.Acquire(ctx, "shared", 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, granted)
+
+	throttle, err := ratelimit.NewDistributedThrottle(remote, "shared", ratelimit.WithBatchSize(10))
+	require.NoError(t, err)
+	assert.False(t, throttle.Throttle(), "remaining tokens must be leased")
+
+	_, err = remote.Acquire(ctx, "unknown", 1)
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeNotFound, yarpcerrors.ErrorCode(err))
+
+	_, err = remote.Acquire(ctx, "shared", 0)
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.ErrorCode(err))
+}
@@ -156,6 +156,43 @@ func (t *Throttle) Throttle() bool {
 	}
 }
 
+// reserve claims the next request slot and returns how long the caller must
+// wait before using it, or false without claiming a slot if the wait would
+// exceed maxWait.
+func (t *Throttle) reserve(maxWait time.Duration) (time.Duration, bool) {
+	now := t.clock.Now().UnixNano()
+	for {
+		minAllowableTime := t.minAllowableTime.Load()
+
+		var wait time.Duration
+		if now < minAllowableTime {
+			wait = time.Duration(minAllowableTime - now)
+		}
+		if wait > maxWait {
+			return wait, false
+		}
+
+		// Advance the time as Throttle does, but regardless of whether the
+		// slot is in the future.
+		nextMinAllowableTime := minAllowableTime
+		clampedMinAllowableTime := now - t.maxSlack
+		if nextMinAllowableTime < clampedMinAllowableTime {
+			nextMinAllowableTime = clampedMinAllowableTime
+		}
+		nextMinAllowableTime = nextMinAllowableTime + t.requestInterval
+
+		if t.minAllowableTime.CAS(minAllowableTime, nextMinAllowableTime) {
+			return wait, true
+		}
+	}
+}
+
+// release gives back a slot claimed by reserve for a request which gave up
+// waiting, moving the following slots earlier by one request interval.
+func (t *Throttle) release() {
+	t.minAllowableTime.Sub(t.requestInterval)
+}
+
 // OpenThrottle is a singleton open throttle. An open throttle provides no rate
 // limit.
 var OpenThrottle = &openThrottle{}
@@ -0,0 +1,140 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package retry
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/yarpc/yarpcconfig"
+)
+
+// Config describes how to configure and construct a retry middleware.
+//
+// Policies are declared by name and referenced by the default and by
+// overrides for specific services and procedures.
+//
+//  policies:
+//    fast:
+//      retries: 2
+//      attemptTimeout: 100ms
+//  default: fast
+//  overrides:
+//    - service: users
+//      with: fast
+//
+// Requests that match neither the default nor an override are not retried.
+type Config struct {
+	// Policies declares retry policies by name.
+	Policies map[string]PolicyConfig `config:"policies"`
+	// Default is the name of the policy used for requests without an
+	// override. If empty, requests without an override are not retried.
+	Default string `config:"default"`
+	// Overrides select policies for specific services or procedures.
+	Overrides []OverrideConfig `config:"overrides"`
+}
+
+// PolicyConfig describes a retry policy.
+type PolicyConfig struct {
+	// Retries is the number of times a failed request may be retried, not
+	// counting the first attempt. Defaults to 1; set it to 0 to disable
+	// retries.
+	Retries *uint `config:"retries"`
+	// AttemptTimeout bounds the duration of each attempt.
+	AttemptTimeout time.Duration `config:"attemptTimeout"`
+	// Idempotent allows retries on errors where the server may have
+	// processed the request.
+	Idempotent bool `config:"idempotent"`
+	// Backoff configures the delay between attempts.
+	Backoff yarpcconfig.Backoff `config:"backoff"`
+}
+
+// OverrideConfig selects a policy for all procedures of a service, or for a
+// single procedure if Procedure is set.
+type OverrideConfig struct {
+	Service   string `config:"service"`
+	Procedure string `config:"procedure"`
+	// With is the name of the policy to use.
+	With string `config:"with"`
+}
+
+// Build creates a retry middleware, or returns an error if the configuration
+// is invalid.
+func (c Config) Build() (*OutboundMiddleware, error) {
+	// Build policies in a stable order so that errors are deterministic.
+	names := make([]string, 0, len(c.Policies))
+	for name := range c.Policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	policies := make(map[string]*Policy, len(c.Policies))
+	for _, name := range names {
+		policy, err := c.Policies[name].policy()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build retry policy %q: %v", name, err)
+		}
+		policies[name] = policy
+	}
+
+	provider := NewProcedurePolicyProvider()
+	if c.Default != "" {
+		policy, ok := policies[c.Default]
+		if !ok {
+			return nil, fmt.Errorf("default retry policy %q is not defined", c.Default)
+		}
+		provider.SetDefault(policy)
+	}
+
+	for _, o := range c.Overrides {
+		if o.Service == "" {
+			return nil, fmt.Errorf("retry policy override for %q must specify a service", o.With)
+		}
+		policy, ok := policies[o.With]
+		if !ok {
+			return nil, fmt.Errorf("retry policy %q for service %q is not defined", o.With, o.Service)
+		}
+		if o.Procedure == "" {
+			provider.RegisterService(o.Service, policy)
+		} else {
+			provider.RegisterServiceProcedure(o.Service, o.Procedure, policy)
+		}
+	}
+
+	return NewUnaryMiddleware(WithPolicyProvider(provider)), nil
+}
+
+func (c PolicyConfig) policy() (*Policy, error) {
+	strategy, err := c.Backoff.Strategy()
+	if err != nil {
+		return nil, err
+	}
+	opts := []PolicyOption{
+		AttemptTimeout(c.AttemptTimeout),
+		Idempotent(c.Idempotent),
+		BackoffStrategy(strategy),
+	}
+	if c.Retries != nil {
+		opts = append(opts, Retries(*c.Retries))
+	}
+	return NewPolicy(opts...), nil
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/config"
+	"go.uber.org/yarpc/internal/whitespace"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestConfig(t *testing.T) {
+	given := whitespace.Expand(`
+		policies:
+		  default:
+		    retries: 2
+		    attemptTimeout: 500ms
+		    backoff:
+		      exponential:
+		        first: 10ms
+		        max: 1s
+		  idempotent:
+		    retries: 3
+		    idempotent: true
+		default: default
+		overrides:
+		  - service: users
+		    with: idempotent
+		  - service: orders
+		    procedure: getOrder
+		    with: idempotent
+	`)
+	var unstructured config.AttributeMap
+	err := yaml.Unmarshal([]byte(given), &unstructured)
+	require.NoError(t, err)
+
+	var cfg Config
+	err = unstructured.Decode(&cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, "default", cfg.Default)
+	require.NotNil(t, cfg.Policies["default"].Retries)
+	assert.Equal(t, uint(2), *cfg.Policies["default"].Retries)
+	assert.Equal(t, 500*time.Millisecond, cfg.Policies["default"].AttemptTimeout)
+	assert.Equal(t, 10*time.Millisecond, cfg.Policies["default"].Backoff.Exponential.First)
+	assert.True(t, cfg.Policies["idempotent"].Idempotent)
+	require.Len(t, cfg.Overrides, 2)
+
+	mw, err := cfg.Build()
+	require.NoError(t, err)
+
+	policy := func(service, procedure string) *Policy {
+		return mw.provider.Policy(context.Background(), &transport.Request{Service: service, Procedure: procedure})
+	}
+
+	assert.Equal(t, uint(2), policy("foo", "bar").retries)
+	assert.Equal(t, 500*time.Millisecond, policy("foo", "bar").attemptTimeout)
+	assert.False(t, policy("foo", "bar").idempotent)
+	assert.Equal(t, uint(3), policy("users", "getUser").retries)
+	assert.True(t, policy("users", "getUser").idempotent)
+	assert.True(t, policy("orders", "getOrder").idempotent)
+	assert.False(t, policy("orders", "createOrder").idempotent)
+}
+
+func TestConfigDisableRetries(t *testing.T) {
+	var unstructured config.AttributeMap
+	err := yaml.Unmarshal([]byte(whitespace.Expand(`
+		policies:
+		  never:
+		    retries: 0
+		default: never
+	`)), &unstructured)
+	require.NoError(t, err)
+
+	var cfg Config
+	require.NoError(t, unstructured.Decode(&cfg))
+
+	mw, err := cfg.Build()
+	require.NoError(t, err)
+	policy := mw.provider.Policy(context.Background(), &transport.Request{Service: "foo"})
+	assert.Equal(t, uint(0), policy.retries, "retries must be disabled when set to 0")
+}
+
+func TestConfigWithoutDefault(t *testing.T) {
+	mw, err := Config{
+		Policies:  map[string]PolicyConfig{"p": {}},
+		Overrides: []OverrideConfig{{Service: "foo", With: "p"}},
+	}.Build()
+	require.NoError(t, err)
+
+	assert.Nil(t, mw.provider.Policy(context.Background(), &transport.Request{Service: "bar"}))
+	assert.Equal(t, uint(1), mw.provider.Policy(context.Background(), &transport.Request{Service: "foo"}).retries)
+}
+
+func TestConfigErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		give    Config
+		wantErr string
+	}{
+		{
+			desc:    "unknown default",
+			give:    Config{Default: "missing"},
+			wantErr: `default retry policy "missing" is not defined`,
+		},
+		{
+			desc: "unknown override",
+			give: Config{
+				Overrides: []OverrideConfig{{Service: "foo", With: "missing"}},
+			},
+			wantErr: `retry policy "missing" for service "foo" is not defined`,
+		},
+		{
+			desc: "override without service",
+			give: Config{
+				Policies:  map[string]PolicyConfig{"p": {}},
+				Overrides: []OverrideConfig{{Procedure: "bar", With: "p"}},
+			},
+			wantErr: `retry policy override for "p" must specify a service`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := tt.give.Build()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
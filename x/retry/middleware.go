@@ -0,0 +1,214 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package retry provides an outbound middleware that retries failed unary
+// requests.
+//
+// Retry policies are selected per request by a PolicyProvider, typically a
+// ProcedurePolicyProvider keyed by service and procedure, and may be
+// configured from YAML using Config:
+//
+//  policies:
+//    default:
+//      retries: 2
+//      attemptTimeout: 500ms
+//      backoff:
+//        exponential:
+//          first: 10ms
+//          max: 1s
+//    idempotent:
+//      retries: 3
+//      idempotent: true
+//  default: default
+//  overrides:
+//    - service: users
+//      procedure: getUser
+//      with: idempotent
+//
+// The middleware buffers request bodies in memory so that they may be sent
+// again. Application errors are never retried.
+//
+// Every attempt after the first is counted in the "retries" metric of the
+// outbound observability edge for the request.
+package retry
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/observability"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type middlewareOptions struct {
+	provider PolicyProvider
+}
+
+// MiddlewareOption customizes the behavior of the retry middleware.
+type MiddlewareOption func(*middlewareOptions)
+
+// WithPolicyProvider sets the PolicyProvider used to select the retry policy
+// of each request.
+//
+// By default, all requests are retried using the policy built by NewPolicy
+// without any options.
+func WithPolicyProvider(provider PolicyProvider) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.provider = provider
+	}
+}
+
+// NewUnaryMiddleware builds an outbound middleware that retries failed unary
+// requests.
+func NewUnaryMiddleware(opts ...MiddlewareOption) *OutboundMiddleware {
+	var options middlewareOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.provider == nil {
+		provider := NewProcedurePolicyProvider()
+		provider.SetDefault(NewPolicy())
+		options.provider = provider
+	}
+	return &OutboundMiddleware{provider: options.provider}
+}
+
+// OutboundMiddleware is a unary outbound middleware that retries requests
+// which failed with a retryable error.
+type OutboundMiddleware struct {
+	provider PolicyProvider
+}
+
+var _ middleware.UnaryOutbound = (*OutboundMiddleware)(nil)
+
+// Call sends the request, retrying it according to the policy selected for
+// the request for as long as the context deadline allows.
+func (m *OutboundMiddleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	policy := m.provider.Policy(ctx, req)
+	if policy == nil {
+		return out.Call(ctx, req)
+	}
+	if policy.retries == 0 {
+		return callAttempt(ctx, req, out, policy)
+	}
+
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	boff := policy.backoff.Backoff()
+	for attempt := uint(0); ; attempt++ {
+		attemptCtx := ctx
+		if attempt > 0 {
+			attemptCtx = observability.WithRetryAttempt(ctx)
+		}
+
+		attemptReq := *req
+		attemptReq.Body = bytes.NewReader(body)
+
+		res, err := callAttempt(attemptCtx, &attemptReq, out, policy)
+		if err == nil || attempt >= policy.retries || ctx.Err() != nil || !policy.retryable(err) {
+			return res, err
+		}
+		if !wait(ctx, boff.Duration(attempt)) {
+			return res, err
+		}
+	}
+}
+
+// callAttempt makes a single attempt, bounded by the attempt timeout of the
+// policy.
+func callAttempt(ctx context.Context, req *transport.Request, out transport.UnaryOutbound, policy *Policy) (*transport.Response, error) {
+	if policy.attemptTimeout <= 0 {
+		return out.Call(ctx, req)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, policy.attemptTimeout)
+	res, err := out.Call(ctx, req)
+	if err != nil || res == nil || res.Body == nil {
+		cancel()
+		return res, err
+	}
+	// The response body may still be streaming from the attempt, so the
+	// attempt context lives until the caller closes it.
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// retryable reports whether a request that failed with the given error may
+// be attempted again under this policy.
+func (p *Policy) retryable(err error) bool {
+	if !yarpcerrors.IsStatus(err) {
+		return false
+	}
+	switch yarpcerrors.FromError(err).Code() {
+	case yarpcerrors.CodeUnavailable:
+		return true
+	case yarpcerrors.CodeResourceExhausted, yarpcerrors.CodeDeadlineExceeded, yarpcerrors.CodeAborted:
+		return p.idempotent
+	default:
+		return false
+	}
+}
+
+// wait blocks for the given backoff duration, returning false if the context
+// would expire or is cancelled before another attempt could be made.
+func wait(ctx context.Context, d time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= d {
+		return false
+	}
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func readBody(req *transport.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	return ioutil.ReadAll(req.Body)
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
@@ -0,0 +1,263 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package retry
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	backoffapi "go.uber.org/yarpc/api/backoff"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type fixedBackoff time.Duration
+
+func (b fixedBackoff) Backoff() backoffapi.Backoff { return b }
+func (b fixedBackoff) Duration(uint) time.Duration { return time.Duration(b) }
+
+func newRequest() *transport.Request {
+	return &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: "procedure",
+		Body:      strings.NewReader("body"),
+	}
+}
+
+func newMiddleware(policy *Policy) *OutboundMiddleware {
+	provider := NewProcedurePolicyProvider()
+	provider.SetDefault(policy)
+	return NewUnaryMiddleware(WithPolicyProvider(provider))
+}
+
+// expectCalls sets up the outbound to fail with the given errors in order,
+// succeeding afterwards, and verifies that the request body is intact on
+// every attempt.
+func expectCalls(t *testing.T, out *transporttest.MockUnaryOutbound, errs ...error) {
+	var calls []*gomock.Call
+	for _, err := range append(errs, nil) {
+		var res *transport.Response
+		if err == nil {
+			res = &transport.Response{Body: ioutil.NopCloser(bytes.NewReader([]byte("response")))}
+		}
+		calls = append(calls, out.EXPECT().Call(gomock.Any(), gomock.Any()).Do(
+			func(ctx context.Context, req *transport.Request) {
+				body, err := ioutil.ReadAll(req.Body)
+				require.NoError(t, err)
+				assert.Equal(t, "body", string(body), "request body must be rewound for every attempt")
+			}).Return(res, err))
+	}
+	gomock.InOrder(calls...)
+}
+
+func TestMiddlewareRetryableErrors(t *testing.T) {
+	tests := []struct {
+		desc       string
+		err        error
+		idempotent bool
+		wantCalls  int
+	}{
+		{
+			desc:      "unavailable",
+			err:       yarpcerrors.UnavailableErrorf("unavailable"),
+			wantCalls: 3,
+		},
+		{
+			desc:      "resource exhausted",
+			err:       yarpcerrors.ResourceExhaustedErrorf("resource exhausted"),
+			wantCalls: 1,
+		},
+		{
+			desc:       "resource exhausted idempotent",
+			err:        yarpcerrors.ResourceExhaustedErrorf("resource exhausted"),
+			idempotent: true,
+			wantCalls:  3,
+		},
+		{
+			desc:       "deadline exceeded idempotent",
+			err:        yarpcerrors.DeadlineExceededErrorf("deadline exceeded"),
+			idempotent: true,
+			wantCalls:  3,
+		},
+		{
+			desc:       "aborted idempotent",
+			err:        yarpcerrors.AbortedErrorf("aborted"),
+			idempotent: true,
+			wantCalls:  3,
+		},
+		{
+			desc:       "invalid argument",
+			err:        yarpcerrors.InvalidArgumentErrorf("invalid argument"),
+			idempotent: true,
+			wantCalls:  1,
+		},
+		{
+			desc:       "internal",
+			err:        yarpcerrors.InternalErrorf("internal"),
+			idempotent: true,
+			wantCalls:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			out := transporttest.NewMockUnaryOutbound(mockCtrl)
+			out.EXPECT().Call(gomock.Any(), gomock.Any()).Return(nil, tt.err).Times(tt.wantCalls)
+
+			mw := newMiddleware(NewPolicy(
+				Retries(2),
+				Idempotent(tt.idempotent),
+				BackoffStrategy(backoffapi.None),
+			))
+			_, err := mw.Call(context.Background(), newRequest(), out)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestMiddlewareRetrySucceeds(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	out := transporttest.NewMockUnaryOutbound(mockCtrl)
+	expectCalls(t, out,
+		yarpcerrors.UnavailableErrorf("unavailable"),
+		yarpcerrors.UnavailableErrorf("unavailable"),
+	)
+
+	mw := newMiddleware(NewPolicy(Retries(2), BackoffStrategy(fixedBackoff(time.Millisecond))))
+	res, err := mw.Call(context.Background(), newRequest(), out)
+	require.NoError(t, err)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "response", string(body))
+	assert.NoError(t, res.Body.Close())
+}
+
+func TestMiddlewareApplicationError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	res := &transport.Response{ApplicationError: true}
+	out := transporttest.NewMockUnaryOutbound(mockCtrl)
+	out.EXPECT().Call(gomock.Any(), gomock.Any()).Return(res, nil)
+
+	mw := newMiddleware(NewPolicy(Retries(2), BackoffStrategy(backoffapi.None)))
+	got, err := mw.Call(context.Background(), newRequest(), out)
+	require.NoError(t, err)
+	assert.Equal(t, res, got)
+}
+
+func TestMiddlewareNoPolicy(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	req := newRequest()
+	out := transporttest.NewMockUnaryOutbound(mockCtrl)
+	out.EXPECT().Call(gomock.Any(), req).Return(nil, yarpcerrors.UnavailableErrorf("unavailable"))
+
+	mw := NewUnaryMiddleware(WithPolicyProvider(NewProcedurePolicyProvider()))
+	_, err := mw.Call(context.Background(), req, out)
+	assert.Error(t, err)
+}
+
+func TestMiddlewareDefaultPolicy(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	out := transporttest.NewMockUnaryOutbound(mockCtrl)
+	expectCalls(t, out, yarpcerrors.UnavailableErrorf("unavailable"))
+
+	_, err := NewUnaryMiddleware().Call(context.Background(), newRequest(), out)
+	assert.NoError(t, err)
+}
+
+func TestMiddlewareBackoffExceedsDeadline(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	unavailable := yarpcerrors.UnavailableErrorf("unavailable")
+	out := transporttest.NewMockUnaryOutbound(mockCtrl)
+	out.EXPECT().Call(gomock.Any(), gomock.Any()).Return(nil, unavailable)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	mw := newMiddleware(NewPolicy(Retries(2), BackoffStrategy(fixedBackoff(time.Second))))
+	start := time.Now()
+	_, err := mw.Call(ctx, newRequest(), out)
+	assert.Equal(t, unavailable, err)
+	assert.True(t, time.Since(start) < time.Second, "must not wait for a backoff beyond the deadline")
+}
+
+func TestMiddlewareContextCancelled(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unavailable := yarpcerrors.UnavailableErrorf("unavailable")
+	out := transporttest.NewMockUnaryOutbound(mockCtrl)
+	out.EXPECT().Call(gomock.Any(), gomock.Any()).Do(
+		func(context.Context, *transport.Request) { cancel() },
+	).Return(nil, unavailable)
+
+	mw := newMiddleware(NewPolicy(Retries(2), BackoffStrategy(backoffapi.None)))
+	_, err := mw.Call(ctx, newRequest(), out)
+	assert.Equal(t, unavailable, err)
+}
+
+func TestMiddlewareAttemptTimeout(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var attemptCtx context.Context
+	out := transporttest.NewMockUnaryOutbound(mockCtrl)
+	out.EXPECT().Call(gomock.Any(), gomock.Any()).Do(
+		func(ctx context.Context, req *transport.Request) {
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok, "attempt must have a deadline")
+			assert.True(t, time.Until(deadline) <= time.Second, "attempt deadline must respect the attempt timeout")
+			attemptCtx = ctx
+		},
+	).Return(&transport.Response{Body: ioutil.NopCloser(bytes.NewReader(nil))}, nil)
+
+	mw := newMiddleware(NewPolicy(AttemptTimeout(time.Second)))
+	res, err := mw.Call(context.Background(), newRequest(), out)
+	require.NoError(t, err)
+
+	assert.NoError(t, attemptCtx.Err(), "attempt context must outlive the call until the body is closed")
+	require.NoError(t, res.Body.Close())
+	assert.Error(t, attemptCtx.Err(), "closing the body must release the attempt context")
+}
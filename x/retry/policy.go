@@ -0,0 +1,108 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package retry
+
+import (
+	"time"
+
+	backoffapi "go.uber.org/yarpc/api/backoff"
+	"go.uber.org/yarpc/internal/backoff"
+)
+
+// Policy describes how and when a request should be retried.
+//
+// Policies are immutable and may be shared between procedures.
+type Policy struct {
+	// retries is the number of attempts to make after the first attempt
+	// fails.
+	retries uint
+	// attemptTimeout bounds the duration of each individual attempt. A zero
+	// timeout means each attempt may use the whole context deadline.
+	attemptTimeout time.Duration
+	// backoff produces the delay between consecutive attempts.
+	backoff backoffapi.Strategy
+	// idempotent indicates that procedures using this policy may safely
+	// receive the same request more than once, allowing retries on errors
+	// where the server may have processed the request.
+	idempotent bool
+}
+
+// PolicyOption customizes a Policy.
+type PolicyOption func(*Policy)
+
+// Retries sets the number of times a failed request may be retried, not
+// counting the first attempt.
+//
+// Defaults to 1.
+func Retries(retries uint) PolicyOption {
+	return func(p *Policy) {
+		p.retries = retries
+	}
+}
+
+// AttemptTimeout bounds the duration of each attempt. Attempts never outlive
+// the deadline of the original context.
+//
+// By default, each attempt may use the remainder of the context deadline.
+func AttemptTimeout(timeout time.Duration) PolicyOption {
+	return func(p *Policy) {
+		p.attemptTimeout = timeout
+	}
+}
+
+// BackoffStrategy sets the backoff strategy used to wait between attempts.
+//
+// Defaults to an exponential backoff with full jitter.
+func BackoffStrategy(strategy backoffapi.Strategy) PolicyOption {
+	return func(p *Policy) {
+		p.backoff = strategy
+	}
+}
+
+// Idempotent marks procedures using this policy as idempotent.
+//
+// Requests failing with an Unavailable error are always retried. This error
+// usually means that no peer could serve the request, but transports also
+// report it when a connection fails after the request was sent, and handlers
+// may return it themselves, so a request failing with it may still have been
+// processed. Procedures which must not receive a request twice should not use
+// a retry policy at all.
+//
+// Idempotent requests are additionally retried if they fail with
+// ResourceExhausted, DeadlineExceeded or Aborted errors, since the server may
+// have started processing the request before failing.
+func Idempotent(idempotent bool) PolicyOption {
+	return func(p *Policy) {
+		p.idempotent = idempotent
+	}
+}
+
+// NewPolicy builds a retry Policy.
+func NewPolicy(opts ...PolicyOption) *Policy {
+	p := &Policy{
+		retries: 1,
+		backoff: backoff.DefaultExponential,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package retry
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// PolicyProvider selects the retry Policy for an outbound request.
+//
+// A nil Policy indicates that the request must not be retried.
+type PolicyProvider interface {
+	Policy(ctx context.Context, req *transport.Request) *Policy
+}
+
+type serviceProcedure struct {
+	service   string
+	procedure string
+}
+
+// ProcedurePolicyProvider is a PolicyProvider which selects policies based on
+// the service and procedure of the request.
+//
+// Policies are selected in the following order of precedence:
+//
+//  1. The policy registered for the request's service and procedure.
+//  2. The policy registered for the request's service.
+//  3. The default policy.
+//
+// ProcedurePolicyProvider must be fully configured before it is used; it is
+// not safe to register policies concurrently with calls to Policy.
+type ProcedurePolicyProvider struct {
+	defaultPolicy     *Policy
+	servicePolicies   map[string]*Policy
+	procedurePolicies map[serviceProcedure]*Policy
+}
+
+var _ PolicyProvider = (*ProcedurePolicyProvider)(nil)
+
+// NewProcedurePolicyProvider builds an empty ProcedurePolicyProvider. Without
+// any registered policies, requests are not retried.
+func NewProcedurePolicyProvider() *ProcedurePolicyProvider {
+	return &ProcedurePolicyProvider{
+		servicePolicies:   make(map[string]*Policy),
+		procedurePolicies: make(map[serviceProcedure]*Policy),
+	}
+}
+
+// SetDefault sets the policy used for requests without a more specific
+// policy.
+func (p *ProcedurePolicyProvider) SetDefault(pol *Policy) {
+	p.defaultPolicy = pol
+}
+
+// RegisterService sets the policy for all procedures of the given service.
+func (p *ProcedurePolicyProvider) RegisterService(service string, pol *Policy) {
+	p.servicePolicies[service] = pol
+}
+
+// RegisterServiceProcedure sets the policy for a single procedure of the
+// given service.
+func (p *ProcedurePolicyProvider) RegisterServiceProcedure(service, procedure string, pol *Policy) {
+	p.procedurePolicies[serviceProcedure{service: service, procedure: procedure}] = pol
+}
+
+// Policy returns the most specific policy registered for the request.
+func (p *ProcedurePolicyProvider) Policy(ctx context.Context, req *transport.Request) *Policy {
+	if pol, ok := p.procedurePolicies[serviceProcedure{service: req.Service, procedure: req.Procedure}]; ok {
+		return pol
+	}
+	if pol, ok := p.servicePolicies[req.Service]; ok {
+		return pol
+	}
+	return p.defaultPolicy
+}
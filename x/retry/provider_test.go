@@ -0,0 +1,61 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package retry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/yarpc/api/transport"
+)
+
+func TestProcedurePolicyProvider(t *testing.T) {
+	var (
+		defaultPolicy   = NewPolicy()
+		servicePolicy   = NewPolicy(Retries(2))
+		procedurePolicy = NewPolicy(Retries(3))
+	)
+
+	provider := NewProcedurePolicyProvider()
+	assert.Nil(t, provider.Policy(context.Background(), &transport.Request{Service: "foo"}))
+
+	provider.SetDefault(defaultPolicy)
+	provider.RegisterService("foo", servicePolicy)
+	provider.RegisterServiceProcedure("foo", "bar", procedurePolicy)
+
+	tests := []struct {
+		service   string
+		procedure string
+		want      *Policy
+	}{
+		{service: "foo", procedure: "bar", want: procedurePolicy},
+		{service: "foo", procedure: "baz", want: servicePolicy},
+		{service: "qux", procedure: "bar", want: defaultPolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.service+"::"+tt.procedure, func(t *testing.T) {
+			req := &transport.Request{Service: tt.service, Procedure: tt.procedure}
+			assert.True(t, tt.want == provider.Policy(context.Background(), req))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package shadow
+
+import (
+	"go.uber.org/yarpc/internal/pally"
+	"go.uber.org/yarpc/internal/sharedmetrics"
+)
+
+// counters count the shadow requests of an outbound.
+type counters struct {
+	sent       *sharedmetrics.CounterVector
+	dropped    *sharedmetrics.CounterVector
+	failed     *sharedmetrics.CounterVector
+	mismatched *sharedmetrics.CounterVector
+}
+
+func newCounters(metrics *sharedmetrics.Set) counters {
+	return counters{
+		sent:       newShadowCounter(metrics, "shadow_calls", "Number of requests mirrored to shadow outbounds."),
+		dropped:    newShadowCounter(metrics, "shadow_drops", "Number of sampled requests not mirrored because too many shadow requests were pending."),
+		failed:     newShadowCounter(metrics, "shadow_failures", "Number of shadow requests that failed."),
+		mismatched: newShadowCounter(metrics, "shadow_mismatches", "Number of shadow requests whose response differed from the primary response."),
+	}
+}
+
+func newShadowCounter(metrics *sharedmetrics.Set, name, help string) *sharedmetrics.CounterVector {
+	return metrics.NewCounterVector(pally.Opts{
+		Name:           name,
+		Help:           help,
+		VariableLabels: []string{"dest", "procedure"},
+	})
+}
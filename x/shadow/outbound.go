@@ -0,0 +1,425 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package shadow provides composite outbounds which mirror requests to a
+// shadow outbound, typically while migrating a service to a new transport
+// protocol or cluster.
+//
+// Every request, or a sampled percentage of requests, is sent to both the
+// primary and the shadow outbound. Callers only ever see the response of the
+// primary outbound: shadow requests are sent asynchronously and their
+// responses and errors are discarded.
+//
+// Shadow requests are counted by the "shadow_calls", "shadow_drops" and
+// "shadow_failures" metrics. If responses are compared, the
+// "shadow_mismatches" metric counts shadow responses that differ from the
+// primary response.
+//
+// Shadow outbounds may be configured from YAML with the "shadow" outbound
+// type of the yarpcconfig package.
+//
+//  outbounds:
+//    keyvalue:
+//      shadow:
+//        primary:
+//          tchannel:
+//            peer: 127.0.0.1:4040
+//        shadow:
+//          grpc:
+//            address: 127.0.0.1:5050
+//        percentage: 10
+//        compare: true
+package shadow
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math/rand"
+	"sync"
+
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/atomic"
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/internal/sharedmetrics"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const defaultMaxPending = 100
+
+type options struct {
+	percentage float64
+	compare    bool
+	maxPending int
+	sample     func(percentage float64) bool
+}
+
+// Option customizes the behavior of a shadow outbound.
+type Option func(*options)
+
+// Percentage specifies the percentage of requests, between 0 and 100, that
+// are mirrored to the shadow outbound.
+//
+// Defaults to 100.
+func Percentage(p float64) Option {
+	return func(o *options) {
+		o.percentage = p
+	}
+}
+
+// CompareResponses enables comparing the responses of the primary and shadow
+// outbounds. Responses differ if only one of them failed, if they failed
+// with different error codes, or if their bodies or application error
+// status differ.
+//
+// Comparing responses requires reading the primary response body entirely
+// before it is returned to the caller.
+func CompareResponses() Option {
+	return func(o *options) {
+		o.compare = true
+	}
+}
+
+// MaxPending specifies the maximum number of shadow requests which may be in
+// flight at once. Sampled requests are not mirrored while this many shadow
+// requests are pending, so that a slow shadow outbound cannot pile up
+// goroutines.
+//
+// Defaults to 100.
+func MaxPending(n int) Option {
+	return func(o *options) {
+		o.maxPending = n
+	}
+}
+
+func randomSample(percentage float64) bool {
+	return rand.Float64()*100 < percentage
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		percentage: 100,
+		maxPending: defaultMaxPending,
+		sample:     randomSample,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// mirror holds the state shared by unary and oneway shadow outbounds.
+type mirror struct {
+	opts    options
+	once    *lifecycle.Once
+	pending atomic.Int32
+	metrics *sharedmetrics.Set
+	events  counters
+
+	// mu orders new shadow requests with Stop, so that Stop waits for every
+	// shadow request started before it and no shadow request starts after.
+	mu       sync.Mutex
+	stopping bool
+	wg       sync.WaitGroup
+
+	primaryOutbound transport.Outbound
+	shadowOutbound  transport.Outbound
+}
+
+func newMirror(primary, shadow transport.Outbound, opts []Option) *mirror {
+	metrics := sharedmetrics.NewSet()
+	return &mirror{
+		opts:            newOptions(opts),
+		once:            lifecycle.NewOnce(),
+		metrics:         metrics,
+		events:          newCounters(metrics),
+		primaryOutbound: primary,
+		shadowOutbound:  shadow,
+	}
+}
+
+// Transports returns the transports used by both the primary and the shadow
+// outbounds.
+func (m *mirror) Transports() []transport.Transport {
+	transports := m.primaryOutbound.Transports()
+	return append(transports[:len(transports):len(transports)], m.shadowOutbound.Transports()...)
+}
+
+// Start starts the primary and the shadow outbounds. The primary outbound is
+// stopped if the shadow outbound fails to start.
+func (m *mirror) Start() error {
+	return m.once.Start(func() error {
+		if err := m.primaryOutbound.Start(); err != nil {
+			return err
+		}
+		if err := m.shadowOutbound.Start(); err != nil {
+			return multierr.Append(err, m.primaryOutbound.Stop())
+		}
+		return nil
+	})
+}
+
+// Stop waits for pending shadow requests and stops both outbounds.
+func (m *mirror) Stop() error {
+	return m.once.Stop(func() error {
+		m.mu.Lock()
+		m.stopping = true
+		m.mu.Unlock()
+
+		m.wg.Wait()
+		return multierr.Combine(m.primaryOutbound.Stop(), m.shadowOutbound.Stop())
+	})
+}
+
+// IsRunning returns whether the outbound is running.
+func (m *mirror) IsRunning() bool {
+	return m.once.IsRunning()
+}
+
+// Metrics returns the metrics of the outbound, along with those of the
+// primary and shadow outbounds.
+func (m *mirror) Metrics() []*sharedmetrics.Set {
+	sets := []*sharedmetrics.Set{m.metrics}
+	return append(sets, sharedmetrics.Collect(m.primaryOutbound, m.shadowOutbound)...)
+}
+
+// Introspect returns the status of the primary outbound.
+func (m *mirror) Introspect() introspection.OutboundStatus {
+	if i, ok := m.primaryOutbound.(introspection.IntrospectableOutbound); ok {
+		return i.Introspect()
+	}
+	return introspection.OutboundStatusNotSupported
+}
+
+// acquire decides whether the given request should be mirrored, reserving a
+// slot for its shadow request if so. The request body is buffered so that it
+// can be sent to both outbounds. Requests are not mirrored once the outbound
+// is stopping.
+func (m *mirror) acquire(req *transport.Request) (body []byte, ok bool, err error) {
+	if m.opts.percentage < 100 && !m.opts.sample(m.opts.percentage) {
+		return nil, false, nil
+	}
+
+	m.mu.Lock()
+	if m.stopping {
+		m.mu.Unlock()
+		return nil, false, nil
+	}
+	m.wg.Add(1)
+	m.mu.Unlock()
+
+	if int(m.pending.Inc()) > m.opts.maxPending {
+		m.release()
+		m.events.dropped.Inc(req.Service, req.Procedure)
+		return nil, false, nil
+	}
+	if req.Body != nil {
+		body, err = ioutil.ReadAll(req.Body)
+		if err != nil {
+			m.release()
+			return nil, false, err
+		}
+	}
+	return body, true, nil
+}
+
+func (m *mirror) release() {
+	m.pending.Dec()
+	m.wg.Done()
+}
+
+// shadowContext builds a context for a shadow request that is not cancelled
+// when the primary request returns, but keeps its deadline and its tracing
+// span, along with the span's baggage.
+func shadowContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	shadowCtx := context.Background()
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		shadowCtx = opentracing.ContextWithSpan(shadowCtx, span)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(shadowCtx, deadline)
+	}
+	return context.WithCancel(shadowCtx)
+}
+
+func withBody(req *transport.Request, body []byte) *transport.Request {
+	r := *req
+	r.Body = bytes.NewReader(body)
+	return &r
+}
+
+// result is the outcome of a unary request, as compared between the
+// primary and shadow outbounds.
+type result struct {
+	body     []byte
+	appError bool
+	err      error
+}
+
+// readResult reads and closes the body of a response.
+func readResult(res *transport.Response, err error) result {
+	if err != nil {
+		return result{err: err}
+	}
+	r := result{appError: res.ApplicationError}
+	if res.Body != nil {
+		r.body, r.err = ioutil.ReadAll(res.Body)
+		r.err = multierr.Append(r.err, res.Body.Close())
+	}
+	return r
+}
+
+func (r result) matches(other result) bool {
+	if r.err != nil || other.err != nil {
+		return r.err != nil && other.err != nil &&
+			yarpcerrors.ErrorCode(r.err) == yarpcerrors.ErrorCode(other.err)
+	}
+	return r.appError == other.appError && bytes.Equal(r.body, other.body)
+}
+
+// UnaryOutbound is a composite transport.UnaryOutbound which mirrors
+// requests to a shadow outbound.
+type UnaryOutbound struct {
+	*mirror
+
+	primary transport.UnaryOutbound
+	shadow  transport.UnaryOutbound
+}
+
+var (
+	_ transport.UnaryOutbound              = (*UnaryOutbound)(nil)
+	_ introspection.IntrospectableOutbound = (*UnaryOutbound)(nil)
+)
+
+// NewUnaryOutbound builds an outbound which sends requests to the primary
+// outbound and mirrors them to the shadow outbound.
+func NewUnaryOutbound(primary, shadow transport.UnaryOutbound, opts ...Option) *UnaryOutbound {
+	return &UnaryOutbound{
+		mirror:  newMirror(primary, shadow, opts),
+		primary: primary,
+		shadow:  shadow,
+	}
+}
+
+// Call sends the request to the primary outbound and returns its response,
+// mirroring the request to the shadow outbound in the background.
+func (o *UnaryOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	body, ok, err := o.acquire(req)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return o.primary.Call(ctx, req)
+	}
+
+	var primaryResult chan result
+	if o.opts.compare {
+		primaryResult = make(chan result, 1)
+	}
+	shadowCtx, cancel := shadowContext(ctx)
+	go o.callShadow(shadowCtx, cancel, withBody(req, body), primaryResult)
+
+	res, err := o.primary.Call(ctx, withBody(req, body))
+	if primaryResult == nil {
+		return res, err
+	}
+
+	r := readResult(res, err)
+	primaryResult <- r
+	if err != nil {
+		return res, err
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(r.body))
+	return res, nil
+}
+
+func (o *UnaryOutbound) callShadow(ctx context.Context, cancel context.CancelFunc, req *transport.Request, primaryResult <-chan result) {
+	defer o.release()
+	defer cancel()
+
+	o.events.sent.Inc(req.Service, req.Procedure)
+	r := readResult(o.shadow.Call(ctx, req))
+	if r.err != nil {
+		o.events.failed.Inc(req.Service, req.Procedure)
+	}
+	if primaryResult != nil && !r.matches(<-primaryResult) {
+		o.events.mismatched.Inc(req.Service, req.Procedure)
+	}
+}
+
+// OnewayOutbound is a composite transport.OnewayOutbound which mirrors
+// requests to a shadow outbound.
+type OnewayOutbound struct {
+	*mirror
+
+	primary transport.OnewayOutbound
+	shadow  transport.OnewayOutbound
+}
+
+var (
+	_ transport.OnewayOutbound             = (*OnewayOutbound)(nil)
+	_ introspection.IntrospectableOutbound = (*OnewayOutbound)(nil)
+)
+
+// NewOnewayOutbound builds an outbound which sends requests to the primary
+// outbound and mirrors them to the shadow outbound.
+//
+// Oneway requests have no response to compare, so CompareResponses has no
+// effect.
+func NewOnewayOutbound(primary, shadow transport.OnewayOutbound, opts ...Option) *OnewayOutbound {
+	return &OnewayOutbound{
+		mirror:  newMirror(primary, shadow, opts),
+		primary: primary,
+		shadow:  shadow,
+	}
+}
+
+// CallOneway sends the request to the primary outbound and returns its
+// acknowledgement, mirroring the request to the shadow outbound in the
+// background.
+func (o *OnewayOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	body, ok, err := o.acquire(req)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return o.primary.CallOneway(ctx, req)
+	}
+
+	shadowCtx, cancel := shadowContext(ctx)
+	go o.callShadow(shadowCtx, cancel, withBody(req, body))
+	return o.primary.CallOneway(ctx, withBody(req, body))
+}
+
+func (o *OnewayOutbound) callShadow(ctx context.Context, cancel context.CancelFunc, req *transport.Request) {
+	defer o.release()
+	defer cancel()
+
+	o.events.sent.Inc(req.Service, req.Procedure)
+	if _, err := o.shadow.CallOneway(ctx, req); err != nil {
+		o.events.failed.Inc(req.Service, req.Procedure)
+	}
+}
@@ -0,0 +1,422 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package shadow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/sharedmetrics"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// total returns the sum of the counters of the given vector.
+func total(v *sharedmetrics.CounterVector) int64 {
+	var n int64
+	v.Subscribe(func(_ []string, count int64) {
+		n += count
+	})()
+	return n
+}
+
+func newRequest(procedure string) *transport.Request {
+	return &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: procedure,
+		Body:      strings.NewReader("body"),
+	}
+}
+
+func newResponse(body string) *transport.Response {
+	return &transport.Response{Body: ioutil.NopCloser(bytes.NewReader([]byte(body)))}
+}
+
+// expectBody asserts that every call receives the full request body.
+func expectBody(t *testing.T) func(context.Context, *transport.Request) {
+	return func(_ context.Context, req *transport.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "body", string(body))
+	}
+}
+
+type fakeAck struct{}
+
+func (fakeAck) String() string { return "ack" }
+
+func newUnaryOutbounds(mockCtrl *gomock.Controller) (primary, shadow *transporttest.MockUnaryOutbound) {
+	primary = transporttest.NewMockUnaryOutbound(mockCtrl)
+	shadow = transporttest.NewMockUnaryOutbound(mockCtrl)
+	for _, o := range []*transporttest.MockUnaryOutbound{primary, shadow} {
+		o.EXPECT().Start().Return(nil)
+		o.EXPECT().Stop().Return(nil)
+	}
+	return primary, shadow
+}
+
+func TestUnaryOutboundMirrors(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	primary, shadow := newUnaryOutbounds(mockCtrl)
+	o := NewUnaryOutbound(primary, shadow)
+	require.NoError(t, o.Start())
+
+	span := mocktracer.New().StartSpan("mirrors")
+	span.SetBaggageItem("foo", "bar")
+	ctx, cancel := context.WithTimeout(opentracing.ContextWithSpan(context.Background(), span), time.Second)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	primary.EXPECT().Call(ctx, gomock.Any()).Do(expectBody(t)).Return(newResponse("primary"), nil)
+	shadow.EXPECT().Call(gomock.Any(), gomock.Any()).Do(
+		func(ctx context.Context, req *transport.Request) {
+			expectBody(t)(ctx, req)
+			shadowDeadline, ok := ctx.Deadline()
+			assert.True(t, ok, "shadow requests must have a deadline")
+			assert.Equal(t, deadline, shadowDeadline, "shadow requests must keep the deadline of the request")
+			shadowSpan := opentracing.SpanFromContext(ctx)
+			assert.Equal(t, span, shadowSpan, "shadow requests must keep the span of the request")
+			if shadowSpan != nil {
+				assert.Equal(t, "bar", shadowSpan.BaggageItem("foo"), "shadow requests must keep the baggage of the request")
+			}
+		}).Return(newResponse("shadow"), nil)
+
+	res, err := o.Call(ctx, newRequest("mirrors"))
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "primary", string(body), "only the primary response must be returned")
+
+	require.NoError(t, o.Stop(), "stop must wait for pending shadow requests")
+	assert.Equal(t, int64(1), total(o.events.sent))
+	assert.Equal(t, int64(0), total(o.events.failed))
+	assert.Equal(t, int64(0), total(o.events.mismatched), "responses must not be compared by default")
+}
+
+func TestUnaryOutboundCompare(t *testing.T) {
+	tests := []struct {
+		desc          string
+		primaryBody   string
+		primaryErr    error
+		shadowBody    string
+		shadowErr     error
+		wantFailed    int64
+		wantMismatch  int64
+		wantErrorCode yarpcerrors.Code
+	}{
+		{
+			desc:        "same response",
+			primaryBody: "hello",
+			shadowBody:  "hello",
+		},
+		{
+			desc:         "different response",
+			primaryBody:  "hello",
+			shadowBody:   "world",
+			wantMismatch: 1,
+		},
+		{
+			desc:         "shadow error",
+			primaryBody:  "hello",
+			shadowErr:    yarpcerrors.UnavailableErrorf("unavailable"),
+			wantFailed:   1,
+			wantMismatch: 1,
+		},
+		{
+			desc:          "primary error",
+			primaryErr:    yarpcerrors.InvalidArgumentErrorf("invalid argument"),
+			shadowBody:    "hello",
+			wantMismatch:  1,
+			wantErrorCode: yarpcerrors.CodeInvalidArgument,
+		},
+		{
+			desc:          "same error code",
+			primaryErr:    yarpcerrors.InvalidArgumentErrorf("invalid argument"),
+			shadowErr:     yarpcerrors.InvalidArgumentErrorf("bad argument"),
+			wantFailed:    1,
+			wantErrorCode: yarpcerrors.CodeInvalidArgument,
+		},
+		{
+			desc:          "different error codes",
+			primaryErr:    yarpcerrors.InvalidArgumentErrorf("invalid argument"),
+			shadowErr:     errors.New("great sadness"),
+			wantFailed:    1,
+			wantMismatch:  1,
+			wantErrorCode: yarpcerrors.CodeInvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			procedure := "compare " + tt.desc
+
+			primary, shadow := newUnaryOutbounds(mockCtrl)
+			o := NewUnaryOutbound(primary, shadow, CompareResponses())
+			require.NoError(t, o.Start())
+
+			var primaryRes, shadowRes *transport.Response
+			if tt.primaryErr == nil {
+				primaryRes = newResponse(tt.primaryBody)
+			}
+			if tt.shadowErr == nil {
+				shadowRes = newResponse(tt.shadowBody)
+			}
+			primary.EXPECT().Call(gomock.Any(), gomock.Any()).Return(primaryRes, tt.primaryErr)
+			shadow.EXPECT().Call(gomock.Any(), gomock.Any()).Return(shadowRes, tt.shadowErr)
+
+			res, err := o.Call(context.Background(), newRequest(procedure))
+			if tt.wantErrorCode != yarpcerrors.CodeOK {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErrorCode, yarpcerrors.ErrorCode(err))
+			} else {
+				require.NoError(t, err)
+				body, err := ioutil.ReadAll(res.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tt.primaryBody, string(body), "compared responses must still be returned")
+			}
+
+			require.NoError(t, o.Stop())
+			assert.Equal(t, int64(1), total(o.events.sent))
+			assert.Equal(t, tt.wantFailed, total(o.events.failed))
+			assert.Equal(t, tt.wantMismatch, total(o.events.mismatched))
+		})
+	}
+}
+
+func TestUnaryOutboundPercentage(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	primary, shadow := newUnaryOutbounds(mockCtrl)
+	o := NewUnaryOutbound(primary, shadow, Percentage(0))
+	require.NoError(t, o.Start())
+
+	primary.EXPECT().Call(gomock.Any(), gomock.Any()).Do(expectBody(t)).Return(newResponse("primary"), nil).Times(10)
+	for i := 0; i < 10; i++ {
+		_, err := o.Call(context.Background(), newRequest("percentage"))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, o.Stop())
+	assert.Equal(t, int64(0), total(o.events.sent), "requests that aren't sampled must not be mirrored")
+}
+
+func TestUnaryOutboundSample(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	primary, shadow := newUnaryOutbounds(mockCtrl)
+	o := NewUnaryOutbound(primary, shadow, Percentage(50))
+	var sampled bool
+	o.opts.sample = func(p float64) bool {
+		assert.Equal(t, float64(50), p)
+		sampled = !sampled
+		return sampled
+	}
+	require.NoError(t, o.Start())
+
+	primary.EXPECT().Call(gomock.Any(), gomock.Any()).Return(newResponse("primary"), nil).Times(4)
+	shadow.EXPECT().Call(gomock.Any(), gomock.Any()).Return(newResponse("shadow"), nil)
+	shadow.EXPECT().Call(gomock.Any(), gomock.Any()).Return(newResponse("shadow"), nil)
+	for i := 0; i < 4; i++ {
+		_, err := o.Call(context.Background(), newRequest("sample"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, o.Stop())
+}
+
+func TestUnaryOutboundMaxPending(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	primary, shadow := newUnaryOutbounds(mockCtrl)
+	o := NewUnaryOutbound(primary, shadow, MaxPending(1))
+	require.NoError(t, o.Start())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	primary.EXPECT().Call(gomock.Any(), gomock.Any()).Do(expectBody(t)).Return(newResponse("primary"), nil).Times(2)
+	shadow.EXPECT().Call(gomock.Any(), gomock.Any()).Do(
+		func(context.Context, *transport.Request) {
+			close(started)
+			<-release
+		}).Return(newResponse("shadow"), nil)
+
+	_, err := o.Call(context.Background(), newRequest("max pending"))
+	require.NoError(t, err)
+	<-started
+
+	_, err = o.Call(context.Background(), newRequest("max pending"))
+	require.NoError(t, err, "requests must succeed even if they can't be mirrored")
+
+	close(release)
+	require.NoError(t, o.Stop())
+	assert.Equal(t, int64(1), total(o.events.sent))
+	assert.Equal(t, int64(1), total(o.events.dropped))
+}
+
+func TestUnaryOutboundStopping(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	primary, shadow := newUnaryOutbounds(mockCtrl)
+	o := NewUnaryOutbound(primary, shadow)
+	require.NoError(t, o.Start())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	primary.EXPECT().Call(gomock.Any(), gomock.Any()).Do(expectBody(t)).Return(newResponse("primary"), nil).Times(2)
+	shadow.EXPECT().Call(gomock.Any(), gomock.Any()).Do(
+		func(context.Context, *transport.Request) {
+			close(started)
+			<-release
+		}).Return(newResponse("shadow"), nil)
+
+	_, err := o.Call(context.Background(), newRequest("stopping"))
+	require.NoError(t, err)
+	<-started
+
+	stopped := make(chan error)
+	go func() { stopped <- o.Stop() }()
+	for stopping := false; !stopping; {
+		o.mu.Lock()
+		stopping = o.stopping
+		o.mu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+
+	_, err = o.Call(context.Background(), newRequest("stopping"))
+	require.NoError(t, err, "requests must succeed while the outbound is stopping")
+
+	close(release)
+	require.NoError(t, <-stopped)
+	assert.Equal(t, int64(1), total(o.events.sent), "requests must not be mirrored once stopping")
+	assert.Equal(t, int64(0), total(o.events.dropped))
+}
+
+func TestOnewayOutbound(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	primary := transporttest.NewMockOnewayOutbound(mockCtrl)
+	shadow := transporttest.NewMockOnewayOutbound(mockCtrl)
+	for _, o := range []*transporttest.MockOnewayOutbound{primary, shadow} {
+		o.EXPECT().Start().Return(nil)
+		o.EXPECT().Stop().Return(nil)
+	}
+
+	o := NewOnewayOutbound(primary, shadow, CompareResponses())
+	require.NoError(t, o.Start())
+
+	ack := fakeAck{}
+	primary.EXPECT().CallOneway(gomock.Any(), gomock.Any()).Do(expectBody(t)).Return(ack, nil)
+	shadow.EXPECT().CallOneway(gomock.Any(), gomock.Any()).Do(expectBody(t)).Return(nil, errors.New("great sadness"))
+
+	got, err := o.CallOneway(context.Background(), newRequest("oneway"))
+	require.NoError(t, err)
+	assert.Equal(t, ack, got)
+
+	require.NoError(t, o.Stop())
+	assert.Equal(t, int64(1), total(o.events.sent))
+	assert.Equal(t, int64(1), total(o.events.failed))
+	assert.Equal(t, int64(0), total(o.events.mismatched))
+}
+
+// metricsOutbound is an outbound with its own metrics.
+type metricsOutbound struct {
+	transport.UnaryOutbound
+
+	metrics *sharedmetrics.Set
+}
+
+func (o metricsOutbound) Metrics() []*sharedmetrics.Set {
+	return []*sharedmetrics.Set{o.metrics}
+}
+
+func TestMetrics(t *testing.T) {
+	primary := metricsOutbound{metrics: sharedmetrics.NewSet()}
+	shadow := metricsOutbound{metrics: sharedmetrics.NewSet()}
+	o := NewUnaryOutbound(primary, shadow)
+	other := NewUnaryOutbound(primary, shadow)
+
+	assert.Equal(t, []*sharedmetrics.Set{o.metrics, primary.metrics, shadow.metrics}, o.Metrics(),
+		"metrics of the primary and shadow outbounds must be reported with the shadow outbound")
+	assert.False(t, o.metrics == other.metrics, "shadow outbounds must count their own requests")
+}
+
+func TestLifecycle(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	primaryTransport := transporttest.NewMockTransport(mockCtrl)
+	shadowTransport := transporttest.NewMockTransport(mockCtrl)
+
+	primary := transporttest.NewMockUnaryOutbound(mockCtrl)
+	shadow := transporttest.NewMockUnaryOutbound(mockCtrl)
+	primary.EXPECT().Transports().Return([]transport.Transport{primaryTransport})
+	shadow.EXPECT().Transports().Return([]transport.Transport{shadowTransport})
+
+	o := NewUnaryOutbound(primary, shadow)
+	assert.Equal(t, []transport.Transport{primaryTransport, shadowTransport}, o.Transports())
+
+	primary.EXPECT().Start().Return(nil)
+	shadow.EXPECT().Start().Return(nil)
+	require.NoError(t, o.Start())
+	assert.True(t, o.IsRunning())
+
+	primary.EXPECT().Stop().Return(nil)
+	shadow.EXPECT().Stop().Return(errors.New("great sadness"))
+	assert.Error(t, o.Stop())
+	assert.False(t, o.IsRunning())
+}
+
+func TestStartError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	primary := transporttest.NewMockUnaryOutbound(mockCtrl)
+	shadow := transporttest.NewMockUnaryOutbound(mockCtrl)
+	o := NewUnaryOutbound(primary, shadow)
+
+	primary.EXPECT().Start().Return(nil)
+	shadow.EXPECT().Start().Return(errors.New("great sadness"))
+	primary.EXPECT().Stop().Return(nil)
+	assert.Error(t, o.Start())
+	assert.False(t, o.IsRunning())
+}
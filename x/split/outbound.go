@@ -0,0 +1,433 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package split provides composite outbounds which split requests between
+// several outbounds by weight, typically to shift traffic gradually while
+// migrating a service to a new transport protocol or cluster.
+//
+// Each request is sent to exactly one of the outbounds, chosen at random in
+// proportion to their weights. With the Sticky option, requests with the
+// same shard key, or routing key if the shard key is not set, are sent to the
+// same outbound as long as the weights don't change. Weights may be changed
+// at runtime with SetWeights, and are reported by Dispatcher introspection.
+//
+// Split outbounds may be configured from YAML with the "split" outbound type
+// of the yarpcconfig package.
+//
+//  outbounds:
+//    keyvalue:
+//      split:
+//        sticky: true
+//        outbounds:
+//          tchannel:
+//            weight: 90
+//            tchannel:
+//              peer: 127.0.0.1:4040
+//          grpc:
+//            weight: 10
+//            grpc:
+//              address: 127.0.0.1:5050
+//
+// The outbounds built from configuration may be retrieved from the
+// yarpc.Config returned by the yarpcconfig.Configurator to change their
+// weights at runtime.
+package split
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sync"
+
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/pkg/lifecycle"
+)
+
+type options struct {
+	sticky bool
+	random func() float64
+}
+
+// Option customizes the behavior of a split outbound.
+type Option func(*options)
+
+// Sticky sends requests with the same shard key to the same outbound, as
+// long as the weights don't change. Requests without a shard key are pinned
+// by their routing key instead, and requests with neither are sent to a
+// random outbound.
+//
+// Keys are assigned to outbounds by weighted rendezvous hashing, so when the
+// weights change, keys only move away from outbounds which lost weight, or
+// to outbounds which gained weight.
+func Sticky() Option {
+	return func(o *options) {
+		o.sticky = true
+	}
+}
+
+// target is an outbound of either RPC type with its name and weight.
+type target struct {
+	name     string
+	weight   int
+	outbound transport.Outbound
+}
+
+// splitter holds the state shared by unary and oneway split outbounds.
+type splitter struct {
+	opts options
+	once *lifecycle.Once
+
+	outbounds []transport.Outbound
+
+	mu      sync.RWMutex
+	names   []string
+	weights []int
+	total   int
+}
+
+func newSplitter(targets []target, opts []Option) (*splitter, error) {
+	s := &splitter{
+		opts: options{random: rand.Float64},
+		once: lifecycle.NewOnce(),
+	}
+	for _, opt := range opts {
+		opt(&s.opts)
+	}
+
+	if len(targets) == 0 {
+		return nil, errors.New("split outbounds require at least one outbound")
+	}
+	seen := make(map[string]struct{}, len(targets))
+	weights := make(map[string]int, len(targets))
+	for _, t := range targets {
+		if _, ok := seen[t.name]; ok {
+			return nil, fmt.Errorf("split outbound %q specified more than once", t.name)
+		}
+		seen[t.name] = struct{}{}
+		s.names = append(s.names, t.name)
+		s.outbounds = append(s.outbounds, t.outbound)
+		weights[t.name] = t.weight
+	}
+	s.weights = make([]int, len(targets))
+	if err := s.SetWeights(weights); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetWeights changes the weights of the given outbounds. Outbounds which are
+// not specified keep their current weight.
+//
+// An error is returned and no weight is changed if an outbound is unknown, a
+// weight is negative, or all weights would be zero.
+func (s *splitter) SetWeights(weights map[string]int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	updated := append([]int(nil), s.weights...)
+	for name, w := range weights {
+		i := s.index(name)
+		if i < 0 {
+			return fmt.Errorf("unknown split outbound %q", name)
+		}
+		if w < 0 {
+			return fmt.Errorf("weight of split outbound %q must not be negative, got %d", name, w)
+		}
+		updated[i] = w
+	}
+
+	var total int
+	for _, w := range updated {
+		total += w
+	}
+	if total == 0 {
+		return errors.New("at least one split outbound must have a positive weight")
+	}
+
+	s.weights = updated
+	s.total = total
+	return nil
+}
+
+// Weights returns the current weight of every outbound.
+func (s *splitter) Weights() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	weights := make(map[string]int, len(s.names))
+	for i, name := range s.names {
+		weights[name] = s.weights[i]
+	}
+	return weights
+}
+
+func (s *splitter) index(name string) int {
+	for i, n := range s.names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// choose returns the index of the outbound the request should be sent to.
+func (s *splitter) choose(req *transport.Request) int {
+	if key := s.key(req); key != "" {
+		return s.chooseKey(key)
+	}
+	point := s.opts.random()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n := int(point * float64(s.total))
+	for i, w := range s.weights {
+		if n < w {
+			return i
+		}
+		n -= w
+	}
+
+	// Only reachable through rounding errors at the end of the range.
+	for i := len(s.weights) - 1; i >= 0; i-- {
+		if s.weights[i] > 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+// key returns the key requests are pinned by, if they are sticky.
+func (s *splitter) key(req *transport.Request) string {
+	if !s.opts.sticky {
+		return ""
+	}
+	if req.ShardKey != "" {
+		return req.ShardKey
+	}
+	return req.RoutingKey
+}
+
+// chooseKey returns the index of the outbound with the highest weighted
+// rendezvous score for the key. The score of an outbound only depends on the
+// key, its name and its weight.
+func (s *splitter) chooseKey(key string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	best, bestScore := 0, math.Inf(-1)
+	for i, w := range s.weights {
+		if w == 0 {
+			continue
+		}
+		// -w/ln(u) for u uniform in (0, 1) picks each outbound with a
+		// probability proportional to its weight.
+		score := -float64(w) / math.Log(unitHash(key, s.names[i]))
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// unitHash hashes the key and the name of an outbound to a number in (0, 1).
+func unitHash(key, name string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(name))
+
+	// FNV leaves similar inputs with similar high bits; mix them with the
+	// finalizer of SplitMix64.
+	x := h.Sum64()
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	x ^= x >> 31
+	return (float64(x>>11) + 0.5) / (1 << 53)
+}
+
+// Transports returns the transports used by all outbounds.
+func (s *splitter) Transports() []transport.Transport {
+	var transports []transport.Transport
+	for _, o := range s.outbounds {
+		transports = append(transports, o.Transports()...)
+	}
+	return transports
+}
+
+// Start starts all outbounds. If an outbound fails to start, the outbounds
+// started before it are stopped.
+func (s *splitter) Start() error {
+	return s.once.Start(func() error {
+		for i, o := range s.outbounds {
+			if err := o.Start(); err != nil {
+				for _, started := range s.outbounds[:i] {
+					err = multierr.Append(err, started.Stop())
+				}
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Stop stops all outbounds.
+func (s *splitter) Stop() error {
+	return s.once.Stop(func() error {
+		var err error
+		for _, o := range s.outbounds {
+			err = multierr.Append(err, o.Stop())
+		}
+		return err
+	})
+}
+
+// IsRunning returns whether the outbound is running.
+func (s *splitter) IsRunning() bool {
+	return s.once.IsRunning()
+}
+
+// Introspect returns the status of the outbound, including the weight and
+// status of every outbound it splits requests between.
+func (s *splitter) Introspect() introspection.OutboundStatus {
+	state := "Stopped"
+	if s.IsRunning() {
+		state = "Running"
+	}
+
+	weights := s.Weights()
+	status := introspection.OutboundStatus{
+		Transport: "split",
+		State:     state,
+	}
+	for i, o := range s.outbounds {
+		var child introspection.OutboundStatus
+		if io, ok := o.(introspection.IntrospectableOutbound); ok {
+			child = io.Introspect()
+		} else {
+			child.Transport = "Introspection not supported"
+		}
+		status.Outbounds = append(status.Outbounds, introspection.WeightedOutboundStatus{
+			Name:     s.names[i],
+			Weight:   weights[s.names[i]],
+			Outbound: child,
+		})
+	}
+	return status
+}
+
+// UnaryTarget is an outbound a UnaryOutbound may send requests to.
+type UnaryTarget struct {
+	// Name identifies the outbound when changing weights and in
+	// introspection.
+	Name string
+
+	// Weight of the requests sent to this outbound relative to the other
+	// outbounds.
+	Weight int
+
+	Outbound transport.UnaryOutbound
+}
+
+// UnaryOutbound is a composite transport.UnaryOutbound which splits
+// requests between several outbounds by weight.
+type UnaryOutbound struct {
+	*splitter
+
+	unaryOutbounds []transport.UnaryOutbound
+}
+
+var (
+	_ transport.UnaryOutbound              = (*UnaryOutbound)(nil)
+	_ introspection.IntrospectableOutbound = (*UnaryOutbound)(nil)
+)
+
+// NewUnaryOutbound builds an outbound which sends each request to one of the
+// given outbounds.
+func NewUnaryOutbound(targets []UnaryTarget, opts ...Option) (*UnaryOutbound, error) {
+	ts := make([]target, len(targets))
+	outbounds := make([]transport.UnaryOutbound, len(targets))
+	for i, t := range targets {
+		ts[i] = target{name: t.Name, weight: t.Weight, outbound: t.Outbound}
+		outbounds[i] = t.Outbound
+	}
+	s, err := newSplitter(ts, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &UnaryOutbound{splitter: s, unaryOutbounds: outbounds}, nil
+}
+
+// Call sends the request to one of the outbounds.
+func (o *UnaryOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	return o.unaryOutbounds[o.choose(req)].Call(ctx, req)
+}
+
+// OnewayTarget is an outbound a OnewayOutbound may send requests to.
+type OnewayTarget struct {
+	// Name identifies the outbound when changing weights and in
+	// introspection.
+	Name string
+
+	// Weight of the requests sent to this outbound relative to the other
+	// outbounds.
+	Weight int
+
+	Outbound transport.OnewayOutbound
+}
+
+// OnewayOutbound is a composite transport.OnewayOutbound which splits
+// requests between several outbounds by weight.
+type OnewayOutbound struct {
+	*splitter
+
+	onewayOutbounds []transport.OnewayOutbound
+}
+
+var (
+	_ transport.OnewayOutbound             = (*OnewayOutbound)(nil)
+	_ introspection.IntrospectableOutbound = (*OnewayOutbound)(nil)
+)
+
+// NewOnewayOutbound builds an outbound which sends each request to one of the
+// given outbounds.
+func NewOnewayOutbound(targets []OnewayTarget, opts ...Option) (*OnewayOutbound, error) {
+	ts := make([]target, len(targets))
+	outbounds := make([]transport.OnewayOutbound, len(targets))
+	for i, t := range targets {
+		ts[i] = target{name: t.Name, weight: t.Weight, outbound: t.Outbound}
+		outbounds[i] = t.Outbound
+	}
+	s, err := newSplitter(ts, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &OnewayOutbound{splitter: s, onewayOutbounds: outbounds}, nil
+}
+
+// CallOneway sends the request to one of the outbounds.
+func (o *OnewayOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	return o.onewayOutbounds[o.choose(req)].CallOneway(ctx, req)
+}
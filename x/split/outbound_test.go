@@ -0,0 +1,345 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package split
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/introspection"
+)
+
+// countingOutbound counts the requests it receives.
+type countingOutbound struct {
+	transport.UnaryOutbound
+
+	calls int
+}
+
+func (o *countingOutbound) Call(context.Context, *transport.Request) (*transport.Response, error) {
+	o.calls++
+	return &transport.Response{}, nil
+}
+
+// sequence returns a random function which evenly covers [0, 1) in n calls.
+func sequence(n int) func() float64 {
+	var i int
+	return func() float64 {
+		f := float64(i%n) / float64(n)
+		i++
+		return f
+	}
+}
+
+func TestUnaryOutboundSplitsByWeight(t *testing.T) {
+	foo, bar, baz := &countingOutbound{}, &countingOutbound{}, &countingOutbound{}
+	o, err := NewUnaryOutbound([]UnaryTarget{
+		{Name: "foo", Weight: 70, Outbound: foo},
+		{Name: "bar", Weight: 30, Outbound: bar},
+		{Name: "baz", Weight: 0, Outbound: baz},
+	})
+	require.NoError(t, err)
+	o.opts.random = sequence(100)
+
+	for i := 0; i < 100; i++ {
+		_, err := o.Call(context.Background(), &transport.Request{})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 70, foo.calls)
+	assert.Equal(t, 30, bar.calls)
+	assert.Equal(t, 0, baz.calls, "outbounds without weight must not receive requests")
+
+	require.NoError(t, o.SetWeights(map[string]int{"foo": 0, "baz": 10}))
+	assert.Equal(t, map[string]int{"foo": 0, "bar": 30, "baz": 10}, o.Weights())
+
+	for i := 0; i < 100; i++ {
+		_, err := o.Call(context.Background(), &transport.Request{})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 70, foo.calls, "outbounds without weight must not receive requests")
+	assert.Equal(t, 30+75, bar.calls)
+	assert.Equal(t, 25, baz.calls)
+}
+
+func TestUnaryOutboundSticky(t *testing.T) {
+	foo, bar := &countingOutbound{}, &countingOutbound{}
+	o, err := NewUnaryOutbound([]UnaryTarget{
+		{Name: "foo", Weight: 50, Outbound: foo},
+		{Name: "bar", Weight: 50, Outbound: bar},
+	}, Sticky())
+	require.NoError(t, err)
+
+	chosen := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		chosen[key] = o.choose(&transport.Request{ShardKey: key})
+		for j := 0; j < 3; j++ {
+			assert.Equal(t, chosen[key], o.choose(&transport.Request{ShardKey: key}),
+				"requests with the same shard key must go to the same outbound")
+		}
+		assert.Equal(t, chosen[key], o.choose(&transport.Request{RoutingKey: key}),
+			"routing keys must be used without a shard key")
+	}
+
+	// Shifting weight from foo to bar must only move requests from foo.
+	require.NoError(t, o.SetWeights(map[string]int{"foo": 25, "bar": 75}))
+	var moved int
+	for key, i := range chosen {
+		got := o.choose(&transport.Request{ShardKey: key})
+		if got != i {
+			assert.Equal(t, 0, i, "only requests sent to foo may move")
+			moved++
+		}
+	}
+	assert.True(t, moved > 0, "some requests must move to bar")
+}
+
+func TestUnaryOutboundStickyRebalancing(t *testing.T) {
+	o, err := NewUnaryOutbound([]UnaryTarget{
+		{Name: "foo", Weight: 50, Outbound: &countingOutbound{}},
+		{Name: "bar", Weight: 30, Outbound: &countingOutbound{}},
+		{Name: "baz", Weight: 20, Outbound: &countingOutbound{}},
+	}, Sticky())
+	require.NoError(t, err)
+
+	chooseAll := func() map[string]int {
+		chosen := make(map[string]int, 1000)
+		for i := 0; i < 1000; i++ {
+			key := fmt.Sprintf("key-%d", i)
+			chosen[key] = o.choose(&transport.Request{ShardKey: key})
+		}
+		return chosen
+	}
+	countsOf := func(chosen map[string]int) []int {
+		counts := make([]int, 3)
+		for _, i := range chosen {
+			counts[i]++
+		}
+		return counts
+	}
+
+	before := chooseAll()
+	counts := countsOf(before)
+	assert.InDelta(t, 500, counts[0], 60, "keys must be split by weight")
+	assert.InDelta(t, 300, counts[1], 60, "keys must be split by weight")
+	assert.InDelta(t, 200, counts[2], 60, "keys must be split by weight")
+
+	// Draining baz moves its keys, and only its keys, to both other outbounds.
+	require.NoError(t, o.SetWeights(map[string]int{"baz": 0}))
+	after := chooseAll()
+	movedTo := make([]int, 3)
+	for key, i := range before {
+		if after[key] != i {
+			assert.Equal(t, 2, i, "only keys of the drained outbound may move")
+			movedTo[after[key]]++
+		}
+	}
+	assert.Equal(t, counts[2], movedTo[0]+movedTo[1], "every key of the drained outbound must move")
+	assert.True(t, movedTo[0] > 0 && movedTo[1] > 0, "keys must be spread between the remaining outbounds")
+
+	// Adding weight to bar only moves keys to bar.
+	before = after
+	require.NoError(t, o.SetWeights(map[string]int{"bar": 60}))
+	after = chooseAll()
+	var moved int
+	for key, i := range before {
+		if after[key] != i {
+			assert.Equal(t, 1, after[key], "keys may only move to the outbound which gained weight")
+			moved++
+		}
+	}
+	assert.True(t, moved > 0, "some keys must move to bar")
+}
+
+func TestNewOutboundErrors(t *testing.T) {
+	out := &countingOutbound{}
+	tests := []struct {
+		desc    string
+		targets []UnaryTarget
+		wantErr string
+	}{
+		{
+			desc:    "no targets",
+			wantErr: "at least one outbound",
+		},
+		{
+			desc: "duplicate name",
+			targets: []UnaryTarget{
+				{Name: "foo", Weight: 1, Outbound: out},
+				{Name: "foo", Weight: 1, Outbound: out},
+			},
+			wantErr: `"foo" specified more than once`,
+		},
+		{
+			desc: "negative weight",
+			targets: []UnaryTarget{
+				{Name: "foo", Weight: -1, Outbound: out},
+				{Name: "bar", Weight: 1, Outbound: out},
+			},
+			wantErr: "must not be negative",
+		},
+		{
+			desc: "zero weights",
+			targets: []UnaryTarget{
+				{Name: "foo", Outbound: out},
+				{Name: "bar", Outbound: out},
+			},
+			wantErr: "at least one split outbound must have a positive weight",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := NewUnaryOutbound(tt.targets)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestSetWeightsErrors(t *testing.T) {
+	o, err := NewUnaryOutbound([]UnaryTarget{
+		{Name: "foo", Weight: 1, Outbound: &countingOutbound{}},
+		{Name: "bar", Weight: 1, Outbound: &countingOutbound{}},
+	})
+	require.NoError(t, err)
+
+	assert.Error(t, o.SetWeights(map[string]int{"baz": 1}), "unknown outbound")
+	assert.Error(t, o.SetWeights(map[string]int{"foo": -1}), "negative weight")
+	assert.Error(t, o.SetWeights(map[string]int{"foo": 0, "bar": 0}), "zero weights")
+	assert.Equal(t, map[string]int{"foo": 1, "bar": 1}, o.Weights(), "weights must not change on error")
+}
+
+func TestOnewayOutbound(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	foo := transporttest.NewMockOnewayOutbound(mockCtrl)
+	bar := transporttest.NewMockOnewayOutbound(mockCtrl)
+	o, err := NewOnewayOutbound([]OnewayTarget{
+		{Name: "foo", Weight: 0, Outbound: foo},
+		{Name: "bar", Weight: 1, Outbound: bar},
+	})
+	require.NoError(t, err)
+
+	bar.EXPECT().CallOneway(gomock.Any(), gomock.Any()).Return(nil, nil)
+	_, err = o.CallOneway(context.Background(), &transport.Request{})
+	require.NoError(t, err)
+}
+
+func TestLifecycle(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	fooTransport := transporttest.NewMockTransport(mockCtrl)
+	barTransport := transporttest.NewMockTransport(mockCtrl)
+	foo := transporttest.NewMockUnaryOutbound(mockCtrl)
+	bar := transporttest.NewMockUnaryOutbound(mockCtrl)
+	foo.EXPECT().Transports().Return([]transport.Transport{fooTransport})
+	bar.EXPECT().Transports().Return([]transport.Transport{barTransport})
+
+	o, err := NewUnaryOutbound([]UnaryTarget{
+		{Name: "foo", Weight: 1, Outbound: foo},
+		{Name: "bar", Weight: 1, Outbound: bar},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []transport.Transport{fooTransport, barTransport}, o.Transports())
+
+	foo.EXPECT().Start().Return(nil)
+	bar.EXPECT().Start().Return(nil)
+	require.NoError(t, o.Start())
+	assert.True(t, o.IsRunning())
+
+	foo.EXPECT().Stop().Return(nil)
+	bar.EXPECT().Stop().Return(errors.New("great sadness"))
+	assert.Error(t, o.Stop())
+	assert.False(t, o.IsRunning())
+}
+
+func TestStartError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	foo := transporttest.NewMockUnaryOutbound(mockCtrl)
+	bar := transporttest.NewMockUnaryOutbound(mockCtrl)
+	o, err := NewUnaryOutbound([]UnaryTarget{
+		{Name: "foo", Weight: 1, Outbound: foo},
+		{Name: "bar", Weight: 1, Outbound: bar},
+	})
+	require.NoError(t, err)
+
+	foo.EXPECT().Start().Return(nil)
+	bar.EXPECT().Start().Return(errors.New("great sadness"))
+	foo.EXPECT().Stop().Return(nil)
+	assert.Error(t, o.Start())
+	assert.False(t, o.IsRunning())
+}
+
+// introspectableOutbound is an outbound with a fixed introspection status.
+type introspectableOutbound struct {
+	transport.UnaryOutbound
+
+	status introspection.OutboundStatus
+}
+
+func (o introspectableOutbound) IsRunning() bool { return false }
+
+func (o introspectableOutbound) Introspect() introspection.OutboundStatus { return o.status }
+
+func TestIntrospect(t *testing.T) {
+	o, err := NewUnaryOutbound([]UnaryTarget{
+		{
+			Name:     "tchannel",
+			Weight:   90,
+			Outbound: introspectableOutbound{status: introspection.OutboundStatus{Transport: "tchannel", Endpoint: "127.0.0.1:4040"}},
+		},
+		{
+			Name:     "other",
+			Weight:   10,
+			Outbound: &countingOutbound{},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, introspection.OutboundStatus{
+		Transport: "split",
+		State:     "Stopped",
+		Outbounds: []introspection.WeightedOutboundStatus{
+			{
+				Name:     "tchannel",
+				Weight:   90,
+				Outbound: introspection.OutboundStatus{Transport: "tchannel", Endpoint: "127.0.0.1:4040"},
+			},
+			{
+				Name:     "other",
+				Weight:   10,
+				Outbound: introspection.OutboundStatus{Transport: "Introspection not supported"},
+			},
+		},
+	}, o.Introspect())
+}
@@ -22,6 +22,7 @@ package yarpcconfig
 
 import (
 	"fmt"
+	"strings"
 
 	"go.uber.org/multierr"
 	"go.uber.org/yarpc"
@@ -33,6 +34,7 @@ type buildableOutbounds struct {
 	Service string
 	Unary   *buildableOutbound
 	Oneway  *buildableOutbound
+	Stream  *buildableOutbound
 }
 
 type buildableInbound struct {
@@ -43,6 +45,15 @@ type buildableInbound struct {
 type buildableOutbound struct {
 	TransportSpec *compiledTransportSpec
 	Value         *buildable
+
+	// Composite is set instead of TransportSpec and Value for outbounds
+	// built from other outbounds.
+	Composite *buildableComposite
+}
+
+type buildableComposite struct {
+	Outbound  *compositeOutbound
+	Outbounds []*buildableOutbound
 }
 
 type builder struct {
@@ -106,25 +117,33 @@ func (b *builder) Build() (yarpc.Config, error) {
 	for ccname, c := range b.clients {
 		var err error
 
+		kit := b.kit.withOutboundName(ccname)
 		var ob transport.Outbounds
 		if c.Service != ccname {
 			ob.ServiceName = c.Service
 		}
 
 		if o := c.Unary; o != nil {
-			ob.Unary, err = buildUnaryOutbound(o, transports[o.TransportSpec.Name], b.kit)
+			ob.Unary, err = buildUnaryOutbound(o, transports, kit)
 			if err != nil {
 				errs = multierr.Append(errs, fmt.Errorf(`failed to configure unary outbound for %q: %v`, ccname, err))
 				continue
 			}
 		}
 		if o := c.Oneway; o != nil {
-			ob.Oneway, err = buildOnewayOutbound(o, transports[o.TransportSpec.Name], b.kit)
+			ob.Oneway, err = buildOnewayOutbound(o, transports, kit)
 			if err != nil {
 				errs = multierr.Append(errs, fmt.Errorf(`failed to configure oneway outbound for %q: %v`, ccname, err))
 				continue
 			}
 		}
+		if o := c.Stream; o != nil {
+			ob.Stream, err = buildStreamOutbound(o, transports, kit)
+			if err != nil {
+				errs = multierr.Append(errs, fmt.Errorf(`failed to configure stream outbound for %q: %v`, ccname, err))
+				continue
+			}
+		}
 
 		outbounds[ccname] = ob
 	}
@@ -155,9 +174,28 @@ func buildInbound(cv *buildable, t transport.Transport, k *Kit) (transport.Inbou
 	return result.(transport.Inbound), nil
 }
 
+// childOutboundName returns the name of the i-th outbound composing the
+// outbound being built.
+func childOutboundName(k *Kit, i int) string {
+	return fmt.Sprintf("%s/%d", k.outboundName, i)
+}
+
 // buildUnaryOutbound builds an UnaryOutbound from the given value. This will panic
 // if the output type for this is not transport.UnaryOutbound.
-func buildUnaryOutbound(o *buildableOutbound, t transport.Transport, k *Kit) (transport.UnaryOutbound, error) {
+func buildUnaryOutbound(o *buildableOutbound, transports map[string]transport.Transport, k *Kit) (transport.UnaryOutbound, error) {
+	if c := o.Composite; c != nil {
+		outbounds := make([]transport.UnaryOutbound, len(c.Outbounds))
+		for i, child := range c.Outbounds {
+			var err error
+			outbounds[i], err = buildUnaryOutbound(child, transports, k.withOutboundName(childOutboundName(k, i)))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return c.Outbound.BuildUnary(outbounds)
+	}
+
+	t := transports[o.TransportSpec.Name]
 	result, err := o.Value.Build(t, k.withTransportSpec(o.TransportSpec))
 	if err != nil {
 		return nil, err
@@ -167,7 +205,20 @@ func buildUnaryOutbound(o *buildableOutbound, t transport.Transport, k *Kit) (tr
 
 // buildOnewayOutbound builds an OnewayOutbound from the given value. This will
 // panic if the output type for this is not transport.OnewayOutbound.
-func buildOnewayOutbound(o *buildableOutbound, t transport.Transport, k *Kit) (transport.OnewayOutbound, error) {
+func buildOnewayOutbound(o *buildableOutbound, transports map[string]transport.Transport, k *Kit) (transport.OnewayOutbound, error) {
+	if c := o.Composite; c != nil {
+		outbounds := make([]transport.OnewayOutbound, len(c.Outbounds))
+		for i, child := range c.Outbounds {
+			var err error
+			outbounds[i], err = buildOnewayOutbound(child, transports, k.withOutboundName(childOutboundName(k, i)))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return c.Outbound.BuildOneway(outbounds)
+	}
+
+	t := transports[o.TransportSpec.Name]
 	result, err := o.Value.Build(t, k.withTransportSpec(o.TransportSpec))
 	if err != nil {
 		return nil, err
@@ -175,6 +226,17 @@ func buildOnewayOutbound(o *buildableOutbound, t transport.Transport, k *Kit) (t
 	return result.(transport.OnewayOutbound), nil
 }
 
+// buildStreamOutbound builds an StreamOutbound from the given value. This will
+// panic if the output type for this is not transport.StreamOutbound.
+func buildStreamOutbound(o *buildableOutbound, transports map[string]transport.Transport, k *Kit) (transport.StreamOutbound, error) {
+	t := transports[o.TransportSpec.Name]
+	result, err := o.Value.Build(t, k.withTransportSpec(o.TransportSpec))
+	if err != nil {
+		return nil, err
+	}
+	return result.(transport.StreamOutbound), nil
+}
+
 func (b *builder) AddTransportConfig(spec *compiledTransportSpec, attrs config.AttributeMap) error {
 	cv, err := spec.Transport.Decode(attrs, config.InterpolateWith(b.kit.resolver))
 	if err != nil {
@@ -223,6 +285,13 @@ func (b *builder) AddImplicitOutbound(
 		}
 	}
 
+	if spec.SupportsStreamOutbound() {
+		supportsOutbound = true
+		if err := b.AddStreamOutbound(spec, outboundKey, service, attrs); err != nil {
+			errs = multierr.Append(errs, err)
+		}
+	}
+
 	if !supportsOutbound {
 		return fmt.Errorf("transport %q does not support outbound requests", spec.Name)
 	}
@@ -276,6 +345,88 @@ func (b *builder) AddOnewayOutbound(
 	return nil
 }
 
+func (b *builder) AddStreamOutbound(
+	spec *compiledTransportSpec, outboundKey, service string, attrs config.AttributeMap,
+) error {
+	if spec.StreamOutbound == nil {
+		return fmt.Errorf("transport %q does not support stream outbound requests", spec.Name)
+	}
+
+	b.needTransport(spec)
+	cv, err := spec.StreamOutbound.Decode(attrs, config.InterpolateWith(b.kit.resolver))
+	if err != nil {
+		return fmt.Errorf("failed to decode stream outbound configuration: %v", err)
+	}
+
+	cc, ok := b.clients[outboundKey]
+	if !ok {
+		cc = &buildableOutbounds{Service: service}
+		b.clients[outboundKey] = cc
+	}
+
+	cc.Stream = &buildableOutbound{TransportSpec: spec, Value: cv}
+	return nil
+}
+
 func (b *builder) needTransport(spec *compiledTransportSpec) {
 	b.needTransports[spec.Name] = spec
 }
+
+// AddCompositeOutbound adds an outbound of the given RPC type which is built
+// from the outbounds of the given transports, configured with the given
+// attributes.
+func (b *builder) AddCompositeOutbound(
+	t transport.Type, composite *compositeOutbound, specs []*compiledTransportSpec,
+	outboundKey, service string,
+) error {
+	var (
+		supported bool
+		decode    func(*compiledTransportSpec, config.AttributeMap) (*buildable, error)
+	)
+	switch t {
+	case transport.Unary:
+		supported = composite.BuildUnary != nil
+		decode = func(spec *compiledTransportSpec, attrs config.AttributeMap) (*buildable, error) {
+			if spec.UnaryOutbound == nil {
+				return nil, fmt.Errorf("transport %q does not support unary outbound requests", spec.Name)
+			}
+			return spec.UnaryOutbound.Decode(attrs, config.InterpolateWith(b.kit.resolver))
+		}
+	case transport.Oneway:
+		supported = composite.BuildOneway != nil
+		decode = func(spec *compiledTransportSpec, attrs config.AttributeMap) (*buildable, error) {
+			if spec.OnewayOutbound == nil {
+				return nil, fmt.Errorf("transport %q does not support oneway outbound requests", spec.Name)
+			}
+			return spec.OnewayOutbound.Decode(attrs, config.InterpolateWith(b.kit.resolver))
+		}
+	}
+	if !supported {
+		return fmt.Errorf("%s outbounds do not support %s outbound requests", composite.Name, strings.ToLower(t.String()))
+	}
+
+	c := &buildableComposite{Outbound: composite}
+	for i, spec := range specs {
+		cv, err := decode(spec, composite.Outbounds[i].Attributes)
+		if err != nil {
+			return fmt.Errorf("failed to decode %s outbound configuration: %v", composite.Name, err)
+		}
+		b.needTransport(spec)
+		c.Outbounds = append(c.Outbounds, &buildableOutbound{TransportSpec: spec, Value: cv})
+	}
+
+	cc, ok := b.clients[outboundKey]
+	if !ok {
+		cc = &buildableOutbounds{Service: service}
+		b.clients[outboundKey] = cc
+	}
+
+	o := &buildableOutbound{Composite: c}
+	switch t {
+	case transport.Unary:
+		cc.Unary = o
+	case transport.Oneway:
+		cc.Oneway = o
+	}
+	return nil
+}
@@ -21,10 +21,12 @@
 package yarpcconfig
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 
+	"github.com/uber-go/mapdecode"
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/internal/config"
 	peerbind "go.uber.org/yarpc/peer"
@@ -69,6 +71,15 @@ import (
 // robin peer list. The only remaining key is the name of the peer list
 // updater: `peers` which is just a static list of peers.
 //
+// Peers in the static list may have a weight, for peer lists which support
+// weights. Peers without a weight have the default weight of 1.
+//
+// 	round-robin:
+// 	  peers:
+// 	    - 127.0.0.1:8080
+// 	    - address: 127.0.0.1:8081
+// 	      weight: 3
+//
 // Integration
 //
 // To integrate peer choosers with your transport, embed this struct into your
@@ -243,14 +254,15 @@ func getPeerListInfo(etc config.AttributeMap, kit *Kit) (name string, config con
 //     dns:
 //       name: myservice.example.com
 //       record: A
+//       port: 8080
 func buildPeerListUpdater(c config.AttributeMap, identify func(string) peer.Identifier, kit *Kit) (peer.Binder, error) {
 	// Special case for explicit list of peers.
-	var peers []string
+	var peers []peerConfig
 	if _, err := c.Pop("peers", &peers); err != nil {
 		return nil, err
 	}
 	if len(peers) > 0 {
-		return peerbind.BindPeers(identifyAll(identify, peers)), nil
+		return bindPeers(identify, peers)
 	}
 	// TODO: Make peers a separate peer list updater that is registered by
 	// default instead of special casing here.
@@ -309,12 +321,57 @@ func buildPeerListUpdater(c config.AttributeMap, identify func(string) peer.Iden
 	return result.(peer.Binder), nil
 }
 
-func identifyAll(identify func(string) peer.Identifier, peers []string) []peer.Identifier {
-	pids := make([]peer.Identifier, len(peers))
+// peerConfig is an entry of a static list of peers, which is either an
+// address or an object with an 'address' and a 'weight' attribute.
+type peerConfig struct {
+	Address string
+	Weight  int
+}
+
+func (p *peerConfig) Decode(into mapdecode.Into) error {
+	if err := into(&p.Address); err == nil {
+		return nil
+	}
+
+	var attrs struct {
+		Address string `config:"address"`
+		Weight  *int   `config:"weight"`
+	}
+	if err := into(&attrs); err != nil {
+		return fmt.Errorf("failed to decode peer: expected an address or an object with an address and weight: %v", err)
+	}
+	if attrs.Address == "" {
+		return errors.New(`failed to decode peer: attribute "address" is required`)
+	}
+	p.Address = attrs.Address
+	if attrs.Weight != nil {
+		if *attrs.Weight <= 0 {
+			return fmt.Errorf("invalid weight %d for peer %q: weights must be positive", *attrs.Weight, attrs.Address)
+		}
+		p.Weight = *attrs.Weight
+	}
+	return nil
+}
+
+// bindPeers binds a static list of peers, with their weights if any.
+func bindPeers(identify func(string) peer.Identifier, peers []peerConfig) (peer.Binder, error) {
+	ids := make([]peer.Identifier, len(peers))
+	var weights map[string]int
 	for i, p := range peers {
-		pids[i] = identify(p)
+		ids[i] = identify(p.Address)
+		if p.Weight == 0 {
+			continue
+		}
+		if weights == nil {
+			weights = make(map[string]int)
+		}
+		weights[ids[i].Identifier()] = p.Weight
+	}
+
+	if weights == nil {
+		return peerbind.BindPeers(ids), nil
 	}
-	return pids
+	return peerbind.BindWeightedPeers(ids, weights), nil
 }
 
 func configNames(c config.AttributeMap) (names []string) {
@@ -284,6 +284,43 @@ func TestChooserConfigurator(t *testing.T) {
 				assert.Equal(t, peer.Identifier(), "127.0.0.1:8080", "chooses first peer")
 			},
 		},
+		{
+			desc: "use weighted static peers with round robin",
+			given: whitespace.Expand(`
+				outbounds:
+					their-service:
+						unary:
+							fake-transport:
+								round-robin:
+									peers:
+									- 127.0.0.1:8080
+									- address: 127.0.0.1:8081
+									  weight: 3
+			`),
+			test: func(t *testing.T, c yarpc.Config) {
+				outbound := c.Outbounds["their-service"]
+				unary := outbound.Unary.(*yarpctest.FakeOutbound)
+				chooser := unary.Chooser().(*peer.BoundChooser)
+				list, ok := chooser.ChooserList().(*roundrobin.List)
+				require.True(t, ok, "use round robin")
+
+				dispatcher := yarpc.NewDispatcher(c)
+				require.NoError(t, dispatcher.Start(), "error starting dispatcher")
+				defer func() {
+					require.NoError(t, dispatcher.Stop(), "error stopping dispatcher")
+				}()
+
+				peers := list.Introspect().Peers
+				require.Len(t, peers, 2)
+				for _, p := range peers {
+					if p.Identifier == "127.0.0.1:8081" {
+						assert.Contains(t, p.State, "weight 3")
+					} else {
+						assert.NotContains(t, p.State, "weight")
+					}
+				}
+			},
+		},
 		{
 			desc: "use round-robin chooser",
 			given: whitespace.Expand(`
@@ -630,6 +667,39 @@ func TestChooserConfigurator(t *testing.T) {
 				`failed to read attribute "peers"`,
 			},
 		},
+		{
+			desc: "invalid peer weight",
+			given: whitespace.Expand(`
+				outbounds:
+					their-service:
+						unary:
+							fake-transport:
+								fake-list:
+									peers:
+									- address: 127.0.0.1:8080
+									  weight: 0
+			`),
+			wantErr: []string{
+				`failed to configure unary outbound for "their-service": `,
+				`invalid weight 0 for peer "127.0.0.1:8080": weights must be positive`,
+			},
+		},
+		{
+			desc: "peer without address",
+			given: whitespace.Expand(`
+				outbounds:
+					their-service:
+						unary:
+							fake-transport:
+								fake-list:
+									peers:
+									- weight: 2
+			`),
+			wantErr: []string{
+				`failed to configure unary outbound for "their-service": `,
+				`attribute "address" is required`,
+			},
+		},
 		{
 			desc: "extraneous config in combination with custom updater",
 			given: whitespace.Expand(`
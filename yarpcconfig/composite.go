@@ -0,0 +1,129 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcconfig
+
+import (
+	"fmt"
+
+	"github.com/uber-go/mapdecode"
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/config"
+)
+
+// compositeOutbound is an outbound built from the outbounds of other
+// transports rather than from a transport of its own, like the shadow and
+// split outbounds.
+type compositeOutbound struct {
+	// Name of the outbound type.
+	Name string
+
+	// Outbounds configures the outbounds this outbound is built from.
+	Outbounds []outbound
+
+	// BuildUnary and BuildOneway build the composite outbound from the
+	// outbounds configured in Outbounds, in the same order. Either may be
+	// nil if the RPC type is not supported.
+	BuildUnary  func([]transport.UnaryOutbound) (transport.UnaryOutbound, error)
+	BuildOneway func([]transport.OnewayOutbound) (transport.OnewayOutbound, error)
+}
+
+// compositeOutboundDecoder decodes the configuration of a composite outbound.
+type compositeOutboundDecoder func(attrs config.AttributeMap, opts ...mapdecode.Option) (*compositeOutbound, error)
+
+// _compositeOutbounds lists the composite outbound types by name. These names
+// take precedence over registered transports in outbound configurations.
+var _compositeOutbounds = map[string]compositeOutboundDecoder{
+	shadowOutboundType: decodeShadowOutbound,
+	splitOutboundType:  decodeSplitOutbound,
+}
+
+// supports returns whether the composite outbound and all the transports it
+// is built from support the given RPC type.
+func (c *compositeOutbound) supports(t transport.Type, specs []*compiledTransportSpec) bool {
+	switch t {
+	case transport.Unary:
+		if c.BuildUnary == nil {
+			return false
+		}
+		for _, spec := range specs {
+			if !spec.SupportsUnaryOutbound() {
+				return false
+			}
+		}
+		return true
+	case transport.Oneway:
+		if c.BuildOneway == nil {
+			return false
+		}
+		for _, spec := range specs {
+			if !spec.SupportsOnewayOutbound() {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// loadCompositeOutboundInto adds a composite outbound to the builder. If the
+// RPC type is zero, the outbound is added for every RPC type it supports.
+func (c *Configurator) loadCompositeOutboundInto(
+	b *builder, decode compositeOutboundDecoder, t transport.Type,
+	outboundKey, service string, attrs config.AttributeMap,
+) error {
+	composite, err := decode(attrs, config.InterpolateWith(c.resolver))
+	if err != nil {
+		return err
+	}
+
+	specs := make([]*compiledTransportSpec, len(composite.Outbounds))
+	for i, o := range composite.Outbounds {
+		if _, ok := _compositeOutbounds[o.Type]; ok {
+			return fmt.Errorf("%s outbounds cannot be built from %s outbounds", composite.Name, o.Type)
+		}
+		specs[i], err = c.spec(o.Type)
+		if err != nil {
+			return err
+		}
+	}
+
+	if t != 0 {
+		return b.AddCompositeOutbound(t, composite, specs, outboundKey, service)
+	}
+
+	var (
+		errs      error
+		supported bool
+	)
+	for _, t := range []transport.Type{transport.Unary, transport.Oneway} {
+		if !composite.supports(t, specs) {
+			continue
+		}
+		supported = true
+		errs = multierr.Append(errs, b.AddCompositeOutbound(t, composite, specs, outboundKey, service))
+	}
+	if !supported {
+		return fmt.Errorf("%s outbound does not support any RPC type supported by all of its outbounds", composite.Name)
+	}
+	return errs
+}
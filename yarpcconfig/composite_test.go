@@ -0,0 +1,59 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcconfig
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/golang/mock/gomock"
+)
+
+type compositeTestOutboundConfig struct{ Address string }
+
+// compositeTestSpecs builds an "http" transport supporting unary and oneway
+// outbounds, and a "grpc" transport supporting unary and stream outbounds.
+func compositeTestSpecs(mockCtrl *gomock.Controller) (http, grpc *mockTransportSpec) {
+	http = mockTransportSpecBuilder{
+		Name:                 "http",
+		TransportConfig:      _typeOfEmptyStruct,
+		UnaryOutboundConfig:  reflect.TypeOf(compositeTestOutboundConfig{}),
+		OnewayOutboundConfig: reflect.TypeOf(compositeTestOutboundConfig{}),
+	}.Build(mockCtrl)
+	grpc = mockTransportSpecBuilder{
+		Name:                 "grpc",
+		TransportConfig:      _typeOfEmptyStruct,
+		UnaryOutboundConfig:  reflect.TypeOf(compositeTestOutboundConfig{}),
+		StreamOutboundConfig: reflect.TypeOf(compositeTestOutboundConfig{}),
+	}.Build(mockCtrl)
+	return http, grpc
+}
+
+// indent prefixes every non-empty line of s.
+func indent(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
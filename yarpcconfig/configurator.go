@@ -29,6 +29,7 @@ import (
 
 	"go.uber.org/multierr"
 	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/config"
 	"go.uber.org/yarpc/internal/interpolate"
 	"gopkg.in/yaml.v2"
@@ -258,10 +259,18 @@ func (c *Configurator) loadInboundInto(b *builder, i inbound) error {
 
 func (c *Configurator) loadOutboundInto(b *builder, name string, cfg outbounds) error {
 	// This matches the signature of builder.AddImplicitOutbound,
-	// AddUnaryOutbound and AddOnewayOutbound
+	// AddUnaryOutbound, AddOnewayOutbound and AddStreamOutbound
 	type adder func(*compiledTransportSpec, string, string, config.AttributeMap) error
 
-	loadUsing := func(o *outbound, adder adder) error {
+	// rpcType is zero for implicit outbounds.
+	loadUsing := func(o *outbound, rpcType transport.Type, adder adder) error {
+		if decode, ok := _compositeOutbounds[o.Type]; ok {
+			if err := c.loadCompositeOutboundInto(b, decode, rpcType, name, cfg.Service, o.Attributes); err != nil {
+				return fmt.Errorf("failed to add outbound %q: %v", name, err)
+			}
+			return nil
+		}
+
 		spec, err := c.spec(o.Type)
 		if err != nil {
 			return fmt.Errorf("failed to load configuration for outbound %q: %v", name, err)
@@ -275,17 +284,23 @@ func (c *Configurator) loadOutboundInto(b *builder, name string, cfg outbounds)
 	}
 
 	if implicit := cfg.Implicit; implicit != nil {
-		return loadUsing(implicit, b.AddImplicitOutbound)
+		return loadUsing(implicit, 0, b.AddImplicitOutbound)
 	}
 
 	if unary := cfg.Unary; unary != nil {
-		if err := loadUsing(unary, b.AddUnaryOutbound); err != nil {
+		if err := loadUsing(unary, transport.Unary, b.AddUnaryOutbound); err != nil {
 			return err
 		}
 	}
 
 	if oneway := cfg.Oneway; oneway != nil {
-		if err := loadUsing(oneway, b.AddOnewayOutbound); err != nil {
+		if err := loadUsing(oneway, transport.Oneway, b.AddOnewayOutbound); err != nil {
+			return err
+		}
+	}
+
+	if stream := cfg.Stream; stream != nil {
+		if err := loadUsing(stream, transport.Streaming, b.AddStreamOutbound); err != nil {
 			return err
 		}
 	}
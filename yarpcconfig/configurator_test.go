@@ -763,6 +763,74 @@ func TestConfigurator(t *testing.T) {
 				return
 			},
 		},
+		{
+			desc: "explicit stream outbound",
+			test: func(t *testing.T, mockCtrl *gomock.Controller) (tt testCase) {
+				type outboundConfig struct{ Address string }
+				tt.serviceName = "foo"
+				tt.give = whitespace.Expand(`
+					outbounds:
+						bar:
+							stream:
+								grpc:
+									address: localhost:4040
+				`)
+
+				grpc := mockTransportSpecBuilder{
+					Name:                 "grpc",
+					TransportConfig:      _typeOfEmptyStruct,
+					StreamOutboundConfig: reflect.TypeOf(&outboundConfig{}),
+				}.Build(mockCtrl)
+
+				transport := transporttest.NewMockTransport(mockCtrl)
+				stream := transporttest.NewMockStreamOutbound(mockCtrl)
+
+				grpc.EXPECT().
+					BuildTransport(struct{}{}, kitMatcher{ServiceName: "foo"}).
+					Return(transport, nil)
+				grpc.EXPECT().
+					BuildStreamOutbound(
+						&outboundConfig{Address: "localhost:4040"}, transport,
+						kitMatcher{ServiceName: "foo"}).
+					Return(stream, nil)
+
+				tt.specs = []TransportSpec{grpc.Spec()}
+				tt.wantConfig = yarpc.Config{
+					Name: "foo",
+					Outbounds: yarpc.Outbounds{
+						"bar": {Stream: stream},
+					},
+				}
+
+				return
+			},
+		},
+		{
+			desc: "explicit stream not supported",
+			test: func(t *testing.T, mockCtrl *gomock.Controller) (tt testCase) {
+				type outboundConfig struct{ Address string }
+				tt.give = whitespace.Expand(`
+					outbounds:
+						bar:
+							stream:
+								tchannel:
+									address: localhost:4040
+				`)
+
+				tchan := mockTransportSpecBuilder{
+					Name:                "tchannel",
+					TransportConfig:     _typeOfEmptyStruct,
+					UnaryOutboundConfig: reflect.TypeOf(&outboundConfig{}),
+				}.Build(mockCtrl)
+
+				tt.specs = []TransportSpec{tchan.Spec()}
+				tt.wantErr = []string{
+					`transport "tchannel" does not support stream outbound requests`,
+				}
+
+				return
+			},
+		},
 		{
 			desc: "implicit outbound service name override",
 			test: func(t *testing.T, mockCtrl *gomock.Controller) (tt testCase) {
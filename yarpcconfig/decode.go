@@ -96,11 +96,12 @@ func (cc *clientConfigs) Decode(into mapdecode.Into) error {
 type outbounds struct {
 	Service string
 
-	// Either (Unary and/or Oneway) will be set or Implicit will be set. For
-	// the latter case, we need to only use those configurations that that
-	// transport supports.
+	// Either (Unary, Oneway and/or Stream) will be set or Implicit will be
+	// set. For the latter case, we need to only use those configurations
+	// that that transport supports.
 	Unary    *outbound
 	Oneway   *outbound
+	Stream   *outbound
 	Implicit *outbound
 }
 
@@ -126,7 +127,12 @@ func (o *outbounds) Decode(into mapdecode.Into) error {
 		return fmt.Errorf("failed to oneway outbound configuration: %v", err)
 	}
 
-	if hasUnary || hasOneway {
+	hasStream, err := attrs.Pop("stream", &o.Stream)
+	if err != nil {
+		return fmt.Errorf("failed to stream outbound configuration: %v", err)
+	}
+
+	if hasUnary || hasOneway || hasStream {
 		// No more attributes should be remaining
 		var empty struct{}
 		if err := attrs.Decode(&empty); err != nil {
@@ -139,8 +139,8 @@
 // check the documentation for the corresponding transport package.)
 //
 // The outbound configuration for a service has at least one of the following
-// keys: unary, oneway. These specify the configurations for the corresponding
-// RPC types for that service. For example, the following specifies that we
+// keys: unary, oneway, stream. These specify the configurations for the
+// corresponding RPC types for that service. For example, the following specifies that we
 // make Unary requests to keyvalue service over TChannel and Oneway requests over
 // HTTP.
 //
@@ -153,8 +153,8 @@
 //        url: http://127.0.0.1:8080/
 //
 // For convenience, if there is only one outbound configuration for a service,
-// it may be specified one level higher (without the 'unary' or 'oneway'
-// attributes). In this case, that transport will be used to send requests for
+// it may be specified one level higher (without the 'unary', 'oneway', or
+// 'stream' attributes). In this case, that transport will be used to send requests for
 // all compatible RPC types. For example, the HTTP transport supports both,
 // Unary and Oneway RPC types so the following states that requests for both
 // RPC types must be made over HTTP.
@@ -185,6 +185,58 @@
 // 	  oneway:
 // 	    # ...
 //
+// Shadow Outbounds
+//
+// Requests may be mirrored to a second outbound, typically while migrating a
+// service to a different transport, with the 'shadow' outbound type. Only the
+// responses of the primary outbound are returned; requests to the shadow
+// outbound are made in the background. The 'percentage' key limits mirroring
+// to a sample of requests, 'compare' enables reporting shadow responses which
+// differ from primary responses as metrics, and 'maxPending' caps the number
+// of concurrent shadow requests.
+//
+// 	keyvalue:
+// 	  shadow:
+// 	    primary:
+// 	      tchannel:
+// 	        peer: 127.0.0.1:4040
+// 	    shadow:
+// 	      grpc:
+// 	        address: 127.0.0.1:5050
+// 	    percentage: 10
+// 	    compare: true
+//
+// Shadow outbounds support unary and oneway requests, and may also be used
+// under the 'unary' or 'oneway' keys. When used as above, they are used for
+// the RPC types supported by both transports. See the x/shadow package for
+// details.
+//
+// Split Outbounds
+//
+// Requests may be divided between several outbounds by weight, typically to
+// shift traffic gradually from one transport or cluster to another, with the
+// 'split' outbound type. Each outbound receives a share of requests
+// proportional to its 'weight'. With 'sticky' enabled, requests with the same
+// shard key (or routing key, if no shard key is set) are always sent to the
+// same outbound while the weights are unchanged.
+//
+// 	keyvalue:
+// 	  split:
+// 	    sticky: true
+// 	    outbounds:
+// 	      old:
+// 	        weight: 90
+// 	        tchannel:
+// 	          peer: 127.0.0.1:4040
+// 	      new:
+// 	        weight: 10
+// 	        grpc:
+// 	          address: 127.0.0.1:5050
+//
+// Like shadow outbounds, split outbounds support unary and oneway requests.
+// Weights may be changed at runtime with SetWeights. See the x/split package
+// for details.
+//
 // Peer Configuration
 //
 // Transports that support peer management and selection through YARPC accept
@@ -42,6 +42,10 @@ type Kit struct {
 
 	// TransportSpec currently being used. This may or may not be set.
 	transportSpec *compiledTransportSpec
+
+	// Name of the outbound being built. This is set only inside
+	// build*Outbound.
+	outboundName string
 }
 
 // Returns a shallow copy of this Kit with spec set to the given value.
@@ -51,10 +55,27 @@ func (k *Kit) withTransportSpec(spec *compiledTransportSpec) *Kit {
 	return &newK
 }
 
+// Returns a shallow copy of this Kit with the outbound name set to the given
+// value.
+func (k *Kit) withOutboundName(name string) *Kit {
+	newK := *k
+	newK.outboundName = name
+	return &newK
+}
+
 // ServiceName returns the name of the service for which components are being
 // built.
 func (k *Kit) ServiceName() string { return k.name }
 
+// OutboundName returns the name of the outbound being built, or an empty
+// string outside of outbound build functions.
+//
+// The unary, oneway, and stream outbounds built for the same outbound share
+// its name, so transports may use it to share a single outbound between RPC
+// types. Outbounds composing a shadow or split outbound are named after the
+// outbound and their position in it, for example "myservice/1".
+func (k *Kit) OutboundName() string { return k.outboundName }
+
 var _typeOfKit = reflect.TypeOf((*Kit)(nil))
 
 func (k *Kit) peerListSpec(name string) (*compiledPeerListSpec, error) {
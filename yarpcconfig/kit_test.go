@@ -40,3 +40,14 @@ func TestKitWithTransportSpec(t *testing.T) {
 	assert.Equal(t, "foo", root.ServiceName())
 	assert.Equal(t, "bar", child.ServiceName())
 }
+
+func TestKitWithOutboundName(t *testing.T) {
+	root := &Kit{name: "foo"}
+	assert.Equal(t, "", root.OutboundName())
+
+	child := root.withOutboundName("bar")
+	assert.Equal(t, "", root.OutboundName())
+	assert.Equal(t, "bar", child.OutboundName())
+	assert.Equal(t, "bar/1", childOutboundName(child, 1))
+	assert.Equal(t, "foo", child.ServiceName())
+}
@@ -47,6 +47,7 @@ type mockTransportSpecBuilder struct {
 	InboundConfig        reflect.Type
 	UnaryOutboundConfig  reflect.Type
 	OnewayOutboundConfig reflect.Type
+	StreamOutboundConfig reflect.Type
 }
 
 // build the mockTransportSpec.
@@ -69,6 +70,9 @@ func (b mockTransportSpecBuilder) Build(ctrl *gomock.Controller) *mockTransportS
 	if b.OnewayOutboundConfig != nil {
 		s.BuildOnewayOutbound = builderFunc(ctrl, &m, "BuildOnewayOutbound", []reflect.Type{b.OnewayOutboundConfig, _typeOfTransport, _typeOfKit}, _typeOfOnewayOutbound)
 	}
+	if b.StreamOutboundConfig != nil {
+		s.BuildStreamOutbound = builderFunc(ctrl, &m, "BuildStreamOutbound", []reflect.Type{b.StreamOutboundConfig, _typeOfTransport, _typeOfKit}, _typeOfStreamOutbound)
+	}
 
 	return &m
 }
@@ -99,6 +103,10 @@ func (m *mockTransportSpec) BuildOnewayOutbound(interface{}, transport.Transport
 	panic("This function should never be called")
 }
 
+func (m *mockTransportSpec) BuildStreamOutbound(interface{}, transport.Transport, gomock.Matcher) (transport.StreamOutbound, error) {
+	panic("This function should never be called")
+}
+
 // EXPECT may be used to define expectations on the TransportSpec.
 func (m *mockTransportSpec) EXPECT() *_transportSpecRecorder {
 	return &_transportSpecRecorder{m: m, ctrl: m.ctrl}
@@ -132,6 +140,10 @@ func (r *_transportSpecRecorder) BuildOnewayOutbound(cfg interface{}, t transpor
 	return r.ctrl.RecordCall(r.m, "BuildOnewayOutbound", cfg, t, kit)
 }
 
+func (r *_transportSpecRecorder) BuildStreamOutbound(cfg interface{}, t transport.Transport, kit gomock.Matcher) *gomock.Call {
+	return r.ctrl.RecordCall(r.m, "BuildStreamOutbound", cfg, t, kit)
+}
+
 // anyKitMatcher verifies that an instance is a *Kit.
 type anyKitMatcher struct{}
 
@@ -149,6 +161,9 @@ var anyKit = anyKitMatcher{}
 // kitMatcher matches attributes of a kit
 type kitMatcher struct {
 	ServiceName string
+
+	// OutboundName is matched only if set.
+	OutboundName string
 }
 
 func (m kitMatcher) Matches(x interface{}) bool {
@@ -157,9 +172,15 @@ func (m kitMatcher) Matches(x interface{}) bool {
 		return false
 	}
 
+	if m.OutboundName != "" && k.OutboundName() != m.OutboundName {
+		return false
+	}
 	return k.ServiceName() == m.ServiceName
 }
 
 func (m kitMatcher) String() string {
+	if m.OutboundName != "" {
+		return fmt.Sprintf("kit{name: %q, outbound: %q}", m.ServiceName, m.OutboundName)
+	}
 	return fmt.Sprintf("kit{name: %q}", m.ServiceName)
 }
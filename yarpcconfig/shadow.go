@@ -0,0 +1,99 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcconfig
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/uber-go/mapdecode"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/config"
+	"go.uber.org/yarpc/x/shadow"
+)
+
+const shadowOutboundType = "shadow"
+
+// shadowOutboundConfig configures an outbound which mirrors requests from a
+// primary outbound to a shadow outbound.
+//
+// 	shadow:
+// 	  primary:
+// 	    tchannel:
+// 	      peer: 127.0.0.1:4040
+// 	  shadow:
+// 	    grpc:
+// 	      address: 127.0.0.1:5050
+// 	  percentage: 10
+// 	  compare: true
+type shadowOutboundConfig struct {
+	Primary *outbound `config:"primary"`
+	Shadow  *outbound `config:"shadow"`
+
+	// Percentage of requests mirrored to the shadow outbound. Defaults to
+	// 100.
+	Percentage *float64 `config:"percentage"`
+
+	// Compare the responses of the primary and shadow outbounds.
+	Compare bool `config:"compare"`
+
+	// MaxPending is the maximum number of concurrent shadow requests.
+	// Defaults to 100.
+	MaxPending int `config:"maxPending"`
+}
+
+func decodeShadowOutbound(attrs config.AttributeMap, opts ...mapdecode.Option) (*compositeOutbound, error) {
+	var cfg shadowOutboundConfig
+	if err := attrs.Decode(&cfg, opts...); err != nil {
+		return nil, fmt.Errorf("failed to decode shadow outbound configuration: %v", err)
+	}
+	if cfg.Primary == nil {
+		return nil, errors.New("a primary outbound is required for shadow outbounds")
+	}
+	if cfg.Shadow == nil {
+		return nil, errors.New("a shadow outbound is required for shadow outbounds")
+	}
+
+	var shadowOpts []shadow.Option
+	if p := cfg.Percentage; p != nil {
+		if *p < 0 || *p > 100 {
+			return nil, fmt.Errorf("shadow outbound percentage must be between 0 and 100, got %v", *p)
+		}
+		shadowOpts = append(shadowOpts, shadow.Percentage(*p))
+	}
+	if cfg.Compare {
+		shadowOpts = append(shadowOpts, shadow.CompareResponses())
+	}
+	if cfg.MaxPending > 0 {
+		shadowOpts = append(shadowOpts, shadow.MaxPending(cfg.MaxPending))
+	}
+
+	return &compositeOutbound{
+		Name:      shadowOutboundType,
+		Outbounds: []outbound{*cfg.Primary, *cfg.Shadow},
+		BuildUnary: func(outbounds []transport.UnaryOutbound) (transport.UnaryOutbound, error) {
+			return shadow.NewUnaryOutbound(outbounds[0], outbounds[1], shadowOpts...), nil
+		},
+		BuildOneway: func(outbounds []transport.OnewayOutbound) (transport.OnewayOutbound, error) {
+			return shadow.NewOnewayOutbound(outbounds[0], outbounds[1], shadowOpts...), nil
+		},
+	}, nil
+}
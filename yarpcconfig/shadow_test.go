@@ -0,0 +1,308 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcconfig
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/whitespace"
+	"go.uber.org/yarpc/x/shadow"
+)
+
+func TestShadowOutboundImplicit(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	http, grpc := compositeTestSpecs(mockCtrl)
+	cfg := New()
+	require.NoError(t, cfg.RegisterTransport(http.Spec()))
+	require.NoError(t, cfg.RegisterTransport(grpc.Spec()))
+
+	httpTransport := transporttest.NewMockTransport(mockCtrl)
+	grpcTransport := transporttest.NewMockTransport(mockCtrl)
+	http.EXPECT().BuildTransport(struct{}{}, kitMatcher{ServiceName: "myservice"}).Return(httpTransport, nil)
+	grpc.EXPECT().BuildTransport(struct{}{}, kitMatcher{ServiceName: "myservice"}).Return(grpcTransport, nil)
+
+	primary := transporttest.NewMockUnaryOutbound(mockCtrl)
+	mirror := transporttest.NewMockUnaryOutbound(mockCtrl)
+	http.EXPECT().
+		BuildUnaryOutbound(compositeTestOutboundConfig{Address: "127.0.0.1:8080"}, httpTransport, kitMatcher{ServiceName: "myservice", OutboundName: "keyvalue/0"}).
+		Return(primary, nil)
+	grpc.EXPECT().
+		BuildUnaryOutbound(compositeTestOutboundConfig{Address: "127.0.0.1:5050"}, grpcTransport, kitMatcher{ServiceName: "myservice", OutboundName: "keyvalue/1"}).
+		Return(mirror, nil)
+
+	got, err := cfg.LoadConfigFromYAML("myservice", strings.NewReader(whitespace.Expand(`
+		outbounds:
+			keyvalue:
+				shadow:
+					primary:
+						http:
+							address: 127.0.0.1:8080
+					shadow:
+						grpc:
+							address: 127.0.0.1:5050
+					percentage: 100
+					compare: true
+					maxPending: 10
+	`)))
+	require.NoError(t, err)
+
+	outbounds := got.Outbounds["keyvalue"]
+	assert.Nil(t, outbounds.Oneway, "oneway requests are not supported by the shadow transport")
+	assert.Nil(t, outbounds.Stream, "shadow outbounds do not support stream requests")
+	require.IsType(t, &shadow.UnaryOutbound{}, outbounds.Unary)
+
+	for _, o := range []*transporttest.MockUnaryOutbound{primary, mirror} {
+		o.EXPECT().Start().Return(nil)
+		o.EXPECT().Stop().Return(nil)
+		o.EXPECT().Call(gomock.Any(), gomock.Any()).Return(&transport.Response{}, nil)
+	}
+
+	require.NoError(t, outbounds.Unary.Start())
+	_, err = outbounds.Unary.Call(context.Background(), &transport.Request{Service: "keyvalue", Procedure: "get"})
+	require.NoError(t, err)
+	require.NoError(t, outbounds.Unary.Stop())
+}
+
+func TestShadowOutboundExplicit(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	http, grpc := compositeTestSpecs(mockCtrl)
+	cfg := New()
+	require.NoError(t, cfg.RegisterTransport(http.Spec()))
+	require.NoError(t, cfg.RegisterTransport(grpc.Spec()))
+
+	httpTransport := transporttest.NewMockTransport(mockCtrl)
+	http.EXPECT().BuildTransport(struct{}{}, kitMatcher{ServiceName: "myservice"}).Return(httpTransport, nil)
+
+	primary := transporttest.NewMockOnewayOutbound(mockCtrl)
+	mirror := transporttest.NewMockOnewayOutbound(mockCtrl)
+	http.EXPECT().
+		BuildOnewayOutbound(compositeTestOutboundConfig{Address: "127.0.0.1:8080"}, httpTransport, kitMatcher{ServiceName: "myservice"}).
+		Return(primary, nil)
+	http.EXPECT().
+		BuildOnewayOutbound(compositeTestOutboundConfig{Address: "127.0.0.1:8081"}, httpTransport, kitMatcher{ServiceName: "myservice"}).
+		Return(mirror, nil)
+
+	got, err := cfg.LoadConfigFromYAML("myservice", strings.NewReader(whitespace.Expand(`
+		outbounds:
+			keyvalue:
+				oneway:
+					shadow:
+						primary:
+							http:
+								address: 127.0.0.1:8080
+						shadow:
+							http:
+								address: 127.0.0.1:8081
+	`)))
+	require.NoError(t, err)
+
+	outbounds := got.Outbounds["keyvalue"]
+	assert.Nil(t, outbounds.Unary)
+	assert.IsType(t, &shadow.OnewayOutbound{}, outbounds.Oneway)
+}
+
+func TestShadowOutboundErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		give    string
+		wantErr []string
+	}{
+		{
+			desc: "missing primary",
+			give: `
+				shadow:
+					shadow:
+						http:
+							address: 127.0.0.1:8080
+			`,
+			wantErr: []string{"a primary outbound is required"},
+		},
+		{
+			desc: "missing shadow",
+			give: `
+				shadow:
+					primary:
+						http:
+							address: 127.0.0.1:8080
+			`,
+			wantErr: []string{"a shadow outbound is required"},
+		},
+		{
+			desc: "unknown transport",
+			give: `
+				shadow:
+					primary:
+						http:
+							address: 127.0.0.1:8080
+					shadow:
+						redis:
+							queue: requests
+			`,
+			wantErr: []string{`unknown transport "redis"`},
+		},
+		{
+			desc: "nested shadow",
+			give: `
+				shadow:
+					primary:
+						http:
+							address: 127.0.0.1:8080
+					shadow:
+						shadow:
+							primary:
+								http:
+									address: 127.0.0.1:8081
+			`,
+			wantErr: []string{"shadow outbounds cannot be built from shadow outbounds"},
+		},
+		{
+			desc: "invalid percentage",
+			give: `
+				shadow:
+					primary:
+						http:
+							address: 127.0.0.1:8080
+					shadow:
+						grpc:
+							address: 127.0.0.1:5050
+					percentage: 150
+			`,
+			wantErr: []string{"percentage must be between 0 and 100"},
+		},
+		{
+			desc: "unknown attribute",
+			give: `
+				shadow:
+					primary:
+						http:
+							address: 127.0.0.1:8080
+					shadow:
+						grpc:
+							address: 127.0.0.1:5050
+					sampleRate: 0.5
+			`,
+			wantErr: []string{"failed to decode shadow outbound configuration", "sampleRate"},
+		},
+		{
+			desc: "invalid outbound attribute",
+			give: `
+				shadow:
+					primary:
+						http:
+							address: 127.0.0.1:8080
+					shadow:
+						grpc:
+							url: 127.0.0.1:5050
+			`,
+			wantErr: []string{"failed to decode shadow outbound configuration", "url"},
+		},
+		{
+			desc: "stream",
+			give: `
+				stream:
+					shadow:
+						primary:
+							grpc:
+								address: 127.0.0.1:5050
+						shadow:
+							grpc:
+								address: 127.0.0.1:5051
+			`,
+			wantErr: []string{"shadow outbounds do not support streaming outbound requests"},
+		},
+		{
+			desc: "explicit oneway not supported by transport",
+			give: `
+				oneway:
+					shadow:
+						primary:
+							http:
+								address: 127.0.0.1:8080
+						shadow:
+							grpc:
+								address: 127.0.0.1:5050
+			`,
+			wantErr: []string{`transport "grpc" does not support oneway outbound requests`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			http, grpc := compositeTestSpecs(mockCtrl)
+			cfg := New()
+			require.NoError(t, cfg.RegisterTransport(http.Spec()))
+			require.NoError(t, cfg.RegisterTransport(grpc.Spec()))
+
+			give := "outbounds:\n  keyvalue:\n" + indent(whitespace.Expand(tt.give), "    ")
+			_, err := cfg.LoadConfigFromYAML("myservice", strings.NewReader(give))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), `failed to add outbound "keyvalue"`)
+			for _, msg := range tt.wantErr {
+				assert.Contains(t, err.Error(), msg)
+			}
+		})
+	}
+}
+
+func TestShadowOutboundNoCommonRPCType(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	redis := mockTransportSpecBuilder{
+		Name:                 "redis",
+		TransportConfig:      _typeOfEmptyStruct,
+		OnewayOutboundConfig: reflect.TypeOf(compositeTestOutboundConfig{}),
+	}.Build(mockCtrl)
+	_, grpc := compositeTestSpecs(mockCtrl)
+
+	cfg := New()
+	require.NoError(t, cfg.RegisterTransport(redis.Spec()))
+	require.NoError(t, cfg.RegisterTransport(grpc.Spec()))
+
+	_, err := cfg.LoadConfigFromYAML("myservice", strings.NewReader(whitespace.Expand(`
+		outbounds:
+			keyvalue:
+				shadow:
+					primary:
+						redis:
+							address: 127.0.0.1:6379
+					shadow:
+						grpc:
+							address: 127.0.0.1:5050
+	`)))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "shadow outbound does not support any RPC type supported by all of its outbounds")
+}
@@ -38,8 +38,8 @@ import (
 // parse the configuration for that transport and build instances of it.
 //
 // Every TransportSpec MUST have a BuildTransport function. The spec may
-// provide BuildInbound, BuildUnaryOutbound, and BuildOnewayOutbound functions
-// if the Transport supports that functionality. For example, if a transport
+// provide BuildInbound, BuildUnaryOutbound, BuildOnewayOutbound, and
+// BuildStreamOutbound functions if the Transport supports that functionality. For example, if a transport
 // only supports incoming and outgoing Oneway requests, its spec will provide a
 // BuildTransport, BuildInbound, and BuildOnewayOutbound function.
 //
@@ -56,8 +56,8 @@ import (
 //
 // 	func(C, transport.Transport, *config.Kit) (X, error)
 //
-// Where X is one of, transport.Inbound, transport.UnaryOutbound, or
-// transport.OnewayOutbound.
+// Where X is one of, transport.Inbound, transport.UnaryOutbound,
+// transport.OnewayOutbound, or transport.StreamOutbound.
 //
 // For example,
 //
@@ -96,22 +96,24 @@ type TransportSpec struct {
 	// transport.
 	BuildInbound interface{}
 
-	// The following two are functions in the shapes,
+	// The following three are functions in the shapes,
 	//
 	// 	func(C, transport.Transport, *config.Kit) (transport.UnaryOutbound, error)
 	// 	func(C, transport.Transport, *config.Kit) (transport.OnewayOutbound, error)
+	// 	func(C, transport.Transport, *config.Kit) (transport.StreamOutbound, error)
 	//
 	// Where C is a struct or pointer to a struct defining the configuration
 	// parameters for outbounds of that RPC type.
 	//
-	// Either value may be nil to indicate that the transport does not support
-	// unary or oneway outbounds.
+	// Any of these values may be nil to indicate that the transport does not
+	// support unary, oneway, or stream outbounds.
 	//
 	// These functions will be called with the parsed configurations and the
-	// transport built by BuildTransport to build the unary and oneway
-	// outbounds for this transport.
+	// transport built by BuildTransport to build the unary, oneway, and
+	// stream outbounds for this transport.
 	BuildUnaryOutbound  interface{}
 	BuildOnewayOutbound interface{}
+	BuildStreamOutbound interface{}
 
 	// Named presets.
 	//
@@ -222,6 +224,7 @@ var (
 	_typeOfInbound         = reflect.TypeOf((*transport.Inbound)(nil)).Elem()
 	_typeOfUnaryOutbound   = reflect.TypeOf((*transport.UnaryOutbound)(nil)).Elem()
 	_typeOfOnewayOutbound  = reflect.TypeOf((*transport.OnewayOutbound)(nil)).Elem()
+	_typeOfStreamOutbound  = reflect.TypeOf((*transport.StreamOutbound)(nil)).Elem()
 	_typeOfPeerTransport   = reflect.TypeOf((*peer.Transport)(nil)).Elem()
 	_typeOfPeerChooserList = reflect.TypeOf((*peer.ChooserList)(nil)).Elem()
 	_typeOfPeerChooser     = reflect.TypeOf((*peer.Chooser)(nil)).Elem()
@@ -241,6 +244,7 @@ type compiledTransportSpec struct {
 	Inbound        *configSpec
 	UnaryOutbound  *configSpec
 	OnewayOutbound *configSpec
+	StreamOutbound *configSpec
 
 	PeerChooserPresets map[string]*compiledPeerChooserPreset
 }
@@ -253,6 +257,10 @@ func (s *compiledTransportSpec) SupportsOnewayOutbound() bool {
 	return s.OnewayOutbound != nil
 }
 
+func (s *compiledTransportSpec) SupportsStreamOutbound() bool {
+	return s.StreamOutbound != nil
+}
+
 func compileTransportSpec(spec *TransportSpec) (*compiledTransportSpec, error) {
 	out := compiledTransportSpec{Name: spec.Name}
 
@@ -261,7 +269,7 @@ func compileTransportSpec(spec *TransportSpec) (*compiledTransportSpec, error) {
 	}
 
 	switch strings.ToLower(spec.Name) {
-	case "unary", "oneway":
+	case "unary", "oneway", "stream":
 		return nil, fmt.Errorf("transport name cannot be %q: %q is a reserved name", spec.Name, spec.Name)
 	}
 
@@ -287,6 +295,9 @@ func compileTransportSpec(spec *TransportSpec) (*compiledTransportSpec, error) {
 	if spec.BuildOnewayOutbound != nil {
 		out.OnewayOutbound = appendError(compileOnewayOutboundConfig(spec.BuildOnewayOutbound))
 	}
+	if spec.BuildStreamOutbound != nil {
+		out.StreamOutbound = appendError(compileStreamOutboundConfig(spec.BuildStreamOutbound))
+	}
 
 	if len(spec.PeerChooserPresets) == 0 {
 		return &out, err
@@ -388,6 +399,17 @@ func compileOnewayOutboundConfig(build interface{}) (*configSpec, error) {
 	return &configSpec{inputType: t.In(0), factory: v}, nil
 }
 
+func compileStreamOutboundConfig(build interface{}) (*configSpec, error) {
+	v := reflect.ValueOf(build)
+	t := v.Type()
+
+	if err := validateConfigFunc(t, _typeOfStreamOutbound); err != nil {
+		return nil, fmt.Errorf("invalid BuildStreamOutbound: %v", err)
+	}
+
+	return &configSpec{inputType: t.In(0), factory: v}, nil
+}
+
 // Common validation for all build functions except Tranport.
 func validateConfigFunc(t reflect.Type, outputType reflect.Type) error {
 	switch {
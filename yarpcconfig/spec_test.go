@@ -46,11 +46,13 @@ func TestCompileTransportSpec(t *testing.T) {
 
 		supportsUnary  bool
 		supportsOneway bool
+		supportsStream bool
 
 		transportInput      reflect.Type
 		inboundInput        reflect.Type
 		unaryOutboundInput  reflect.Type
 		onewayOutboundInput reflect.Type
+		streamOutboundInput reflect.Type
 
 		wantErr []string
 	}{
@@ -68,6 +70,11 @@ func TestCompileTransportSpec(t *testing.T) {
 			spec:    TransportSpec{Name: "Oneway"},
 			wantErr: []string{`transport name cannot be "Oneway"`},
 		},
+		{
+			desc:    "reserved name 3",
+			spec:    TransportSpec{Name: "Stream"},
+			wantErr: []string{`transport name cannot be "Stream"`},
+		},
 		{
 			desc:    "missing BuildTransport",
 			spec:    TransportSpec{Name: "foo"},
@@ -122,6 +129,28 @@ func TestCompileTransportSpec(t *testing.T) {
 			supportsOneway:      true,
 			onewayOutboundInput: _typeOfEmptyStruct,
 		},
+		{
+			desc: "stream outbound only",
+			spec: TransportSpec{
+				Name:                "babbling-brook",
+				BuildTransport:      func(struct{}, *Kit) (transport.Transport, error) { panic("kthxbye") },
+				BuildStreamOutbound: func(*phoneCall, transport.Transport, *Kit) (transport.StreamOutbound, error) { panic("kthxbye") },
+			},
+			transportInput:      _typeOfEmptyStruct,
+			supportsStream:      true,
+			streamOutboundInput: reflect.TypeOf(&phoneCall{}),
+		},
+		{
+			desc: "invalid stream outbound",
+			spec: TransportSpec{
+				Name:                "foo",
+				BuildTransport:      func(struct{}, *Kit) (transport.Transport, error) { panic("kthxbye") },
+				BuildStreamOutbound: func(struct{}, transport.Transport, *Kit) (transport.UnaryOutbound, error) { panic("kthxbye") },
+			},
+			wantErr: []string{
+				"invalid BuildStreamOutbound: must return a transport.StreamOutbound as its first result, found transport.UnaryOutbound",
+			},
+		},
 		{
 			desc: "bad peer chooser preset",
 			spec: TransportSpec{
@@ -187,6 +216,7 @@ func TestCompileTransportSpec(t *testing.T) {
 			assert.Equal(t, tt.transportInput, ts.Transport.inputType)
 			assert.Equal(t, tt.supportsUnary, ts.SupportsUnaryOutbound())
 			assert.Equal(t, tt.supportsOneway, ts.SupportsOnewayOutbound())
+			assert.Equal(t, tt.supportsStream, ts.SupportsStreamOutbound())
 
 			if ts.Inbound != nil {
 				assert.Equal(t, tt.inboundInput, ts.Inbound.inputType)
@@ -197,6 +227,9 @@ func TestCompileTransportSpec(t *testing.T) {
 			if ts.OnewayOutbound != nil {
 				assert.Equal(t, tt.onewayOutboundInput, ts.OnewayOutbound.inputType)
 			}
+			if ts.StreamOutbound != nil {
+				assert.Equal(t, tt.streamOutboundInput, ts.StreamOutbound.inputType)
+			}
 		})
 	}
 }
@@ -0,0 +1,119 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcconfig
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/uber-go/mapdecode"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/config"
+	"go.uber.org/yarpc/x/split"
+)
+
+const splitOutboundType = "split"
+
+// splitOutboundConfig configures an outbound which splits requests between
+// several named outbounds by weight.
+//
+// 	split:
+// 	  sticky: true
+// 	  outbounds:
+// 	    tchannel:
+// 	      weight: 90
+// 	      tchannel:
+// 	        peer: 127.0.0.1:4040
+// 	    grpc:
+// 	      weight: 10
+// 	      grpc:
+// 	        address: 127.0.0.1:5050
+type splitOutboundConfig struct {
+	// Sticky pins requests to outbounds by their shard or routing key.
+	Sticky bool `config:"sticky"`
+
+	Outbounds map[string]weightedOutbound `config:"outbounds"`
+}
+
+// weightedOutbound is an outbound configuration with a 'weight' attribute.
+type weightedOutbound struct {
+	Weight   int
+	Outbound outbound
+}
+
+func (w *weightedOutbound) Decode(into mapdecode.Into) error {
+	var attrs config.AttributeMap
+	if err := into(&attrs); err != nil {
+		return fmt.Errorf("failed to decode weighted outbound: %v", err)
+	}
+
+	if _, err := attrs.Pop("weight", &w.Weight); err != nil {
+		return fmt.Errorf(`failed to read attribute "weight" of outbound: %v`, err)
+	}
+	return attrs.Decode(&w.Outbound)
+}
+
+func decodeSplitOutbound(attrs config.AttributeMap, opts ...mapdecode.Option) (*compositeOutbound, error) {
+	var cfg splitOutboundConfig
+	if err := attrs.Decode(&cfg, opts...); err != nil {
+		return nil, fmt.Errorf("failed to decode split outbound configuration: %v", err)
+	}
+	if len(cfg.Outbounds) == 0 {
+		return nil, errors.New("at least one outbound is required for split outbounds")
+	}
+
+	var splitOpts []split.Option
+	if cfg.Sticky {
+		splitOpts = append(splitOpts, split.Sticky())
+	}
+
+	// Sort outbounds by name so that they are built in a predictable order.
+	names := make([]string, 0, len(cfg.Outbounds))
+	for name := range cfg.Outbounds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	outbounds := make([]outbound, len(names))
+	for i, name := range names {
+		outbounds[i] = cfg.Outbounds[name].Outbound
+	}
+
+	return &compositeOutbound{
+		Name:      splitOutboundType,
+		Outbounds: outbounds,
+		BuildUnary: func(outbounds []transport.UnaryOutbound) (transport.UnaryOutbound, error) {
+			targets := make([]split.UnaryTarget, len(names))
+			for i, name := range names {
+				targets[i] = split.UnaryTarget{Name: name, Weight: cfg.Outbounds[name].Weight, Outbound: outbounds[i]}
+			}
+			return split.NewUnaryOutbound(targets, splitOpts...)
+		},
+		BuildOneway: func(outbounds []transport.OnewayOutbound) (transport.OnewayOutbound, error) {
+			targets := make([]split.OnewayTarget, len(names))
+			for i, name := range names {
+				targets[i] = split.OnewayTarget{Name: name, Weight: cfg.Outbounds[name].Weight, Outbound: outbounds[i]}
+			}
+			return split.NewOnewayOutbound(targets, splitOpts...)
+		},
+	}, nil
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcconfig
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/whitespace"
+	"go.uber.org/yarpc/x/split"
+)
+
+func TestSplitOutbound(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	http, grpc := compositeTestSpecs(mockCtrl)
+	cfg := New()
+	require.NoError(t, cfg.RegisterTransport(http.Spec()))
+	require.NoError(t, cfg.RegisterTransport(grpc.Spec()))
+
+	httpTransport := transporttest.NewMockTransport(mockCtrl)
+	grpcTransport := transporttest.NewMockTransport(mockCtrl)
+	http.EXPECT().BuildTransport(struct{}{}, kitMatcher{ServiceName: "myservice"}).Return(httpTransport, nil)
+	grpc.EXPECT().BuildTransport(struct{}{}, kitMatcher{ServiceName: "myservice"}).Return(grpcTransport, nil)
+
+	httpOutbound := transporttest.NewMockUnaryOutbound(mockCtrl)
+	grpcOutbound := transporttest.NewMockUnaryOutbound(mockCtrl)
+	http.EXPECT().
+		BuildUnaryOutbound(compositeTestOutboundConfig{Address: "127.0.0.1:8080"}, httpTransport, kitMatcher{ServiceName: "myservice"}).
+		Return(httpOutbound, nil)
+	grpc.EXPECT().
+		BuildUnaryOutbound(compositeTestOutboundConfig{Address: "127.0.0.1:5050"}, grpcTransport, kitMatcher{ServiceName: "myservice"}).
+		Return(grpcOutbound, nil)
+
+	got, err := cfg.LoadConfigFromYAML("myservice", strings.NewReader(whitespace.Expand(`
+		outbounds:
+			keyvalue:
+				split:
+					sticky: true
+					outbounds:
+						old:
+							weight: 0
+							http:
+								address: 127.0.0.1:8080
+						new:
+							weight: 100
+							grpc:
+								address: 127.0.0.1:5050
+	`)))
+	require.NoError(t, err)
+
+	outbounds := got.Outbounds["keyvalue"]
+	assert.Nil(t, outbounds.Oneway, "oneway requests are not supported by all transports")
+	assert.Nil(t, outbounds.Stream, "split outbounds do not support stream requests")
+	require.IsType(t, &split.UnaryOutbound{}, outbounds.Unary)
+
+	o := outbounds.Unary.(*split.UnaryOutbound)
+	assert.Equal(t, map[string]int{"old": 0, "new": 100}, o.Weights())
+
+	grpcOutbound.EXPECT().Call(gomock.Any(), gomock.Any()).Return(&transport.Response{}, nil)
+	_, err = o.Call(context.Background(), &transport.Request{ShardKey: "foo"})
+	require.NoError(t, err)
+
+	require.NoError(t, o.SetWeights(map[string]int{"old": 100, "new": 0}))
+	httpOutbound.EXPECT().Call(gomock.Any(), gomock.Any()).Return(&transport.Response{}, nil)
+	_, err = o.Call(context.Background(), &transport.Request{ShardKey: "foo"})
+	require.NoError(t, err)
+
+	status := o.Introspect()
+	require.Len(t, status.Outbounds, 2)
+	assert.Equal(t, "new", status.Outbounds[0].Name, "outbounds must be sorted by name")
+	assert.Equal(t, 0, status.Outbounds[0].Weight)
+	assert.Equal(t, "old", status.Outbounds[1].Name)
+	assert.Equal(t, 100, status.Outbounds[1].Weight)
+	assert.Equal(t, "Introspection not supported", status.Outbounds[1].Outbound.Transport)
+}
+
+func TestSplitOutboundErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		give    string
+		wantErr []string
+	}{
+		{
+			desc: "no outbounds",
+			give: `
+				split:
+					sticky: true
+			`,
+			wantErr: []string{"at least one outbound is required for split outbounds"},
+		},
+		{
+			desc: "invalid weight",
+			give: `
+				split:
+					outbounds:
+						old:
+							weight: heavy
+							http:
+								address: 127.0.0.1:8080
+			`,
+			wantErr: []string{`failed to read attribute "weight"`},
+		},
+		{
+			desc: "missing transport",
+			give: `
+				split:
+					outbounds:
+						old:
+							weight: 10
+			`,
+			wantErr: []string{"an outbound type is required"},
+		},
+		{
+			desc: "nested shadow",
+			give: `
+				split:
+					outbounds:
+						old:
+							weight: 10
+							shadow:
+								primary:
+									http:
+										address: 127.0.0.1:8080
+			`,
+			wantErr: []string{"split outbounds cannot be built from shadow outbounds"},
+		},
+		{
+			desc: "unknown attribute",
+			give: `
+				split:
+					weights: {}
+					outbounds:
+						old:
+							weight: 10
+							http:
+								address: 127.0.0.1:8080
+			`,
+			wantErr: []string{"failed to decode split outbound configuration", "weights"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			http, grpc := compositeTestSpecs(mockCtrl)
+			cfg := New()
+			require.NoError(t, cfg.RegisterTransport(http.Spec()))
+			require.NoError(t, cfg.RegisterTransport(grpc.Spec()))
+
+			give := "outbounds:\n  keyvalue:\n" + indent(whitespace.Expand(tt.give), "    ")
+			_, err := cfg.LoadConfigFromYAML("myservice", strings.NewReader(give))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), `failed to add outbound "keyvalue"`)
+			for _, msg := range tt.wantErr {
+				assert.Contains(t, err.Error(), msg)
+			}
+		})
+	}
+}
+
+func TestSplitOutboundInvalidWeights(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	http, _ := compositeTestSpecs(mockCtrl)
+	cfg := New()
+	require.NoError(t, cfg.RegisterTransport(http.Spec()))
+
+	httpTransport := transporttest.NewMockTransport(mockCtrl)
+	http.EXPECT().BuildTransport(struct{}{}, kitMatcher{ServiceName: "myservice"}).Return(httpTransport, nil)
+	http.EXPECT().
+		BuildUnaryOutbound(compositeTestOutboundConfig{Address: "127.0.0.1:8080"}, httpTransport, kitMatcher{ServiceName: "myservice"}).
+		Return(transporttest.NewMockUnaryOutbound(mockCtrl), nil)
+
+	_, err := cfg.LoadConfigFromYAML("myservice", strings.NewReader(whitespace.Expand(`
+		outbounds:
+			keyvalue:
+				unary:
+					split:
+						outbounds:
+							old:
+								weight: -1
+								http:
+									address: 127.0.0.1:8080
+	`)))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `failed to configure unary outbound for "keyvalue"`)
+	assert.Contains(t, err.Error(), "must not be negative")
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcconfig
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"go.uber.org/yarpc/yarpctls"
+)
+
+var _clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify-if-given":    tls.VerifyClientCertIfGiven,
+	"require-and-verify": tls.RequireAndVerifyClientCert,
+}
+
+// TLS configures TLS for inbounds or outbounds of a transport.
+//
+//  tls:
+//    certFile: /etc/certs/myservice.pem
+//    keyFile: /etc/certs/myservice-key.pem
+//    caFile: /etc/certs/ca.pem
+//    clientAuth: require-and-verify
+//
+// Inbounds require certFile and keyFile, and verify client certificates
+// against caFile according to clientAuth, which is one of "none" (the
+// default), "request", "require", "verify-if-given" or "require-and-verify".
+//
+// Outbounds verify server certificates against caFile, or the host's root
+// CAs if it is omitted, and present the certificate in certFile and keyFile
+// if specified. serverName overrides the name used to verify the server's
+// certificate.
+//
+// If reloadInterval is specified, the files are checked for changes at most
+// once per interval and new connections use the latest certificates. Note
+// that outbounds only pick up changes to caFile if serverName is specified.
+//
+//  tls:
+//    certFile: /etc/certs/myservice.pem
+//    keyFile: /etc/certs/myservice-key.pem
+//    caFile: /etc/certs/ca.pem
+//    reloadInterval: 1m
+//
+// See the yarpctls package for details.
+type TLS struct {
+	CertFile       string        `config:"certFile,interpolate"`
+	KeyFile        string        `config:"keyFile,interpolate"`
+	CAFile         string        `config:"caFile,interpolate"`
+	ClientAuth     string        `config:"clientAuth"`
+	ServerName     string        `config:"serverName,interpolate"`
+	ReloadInterval time.Duration `config:"reloadInterval"`
+}
+
+// Empty returns true if TLS was not configured.
+func (c TLS) Empty() bool {
+	return c == TLS{}
+}
+
+// ServerConfig builds a TLS configuration for inbounds, or returns nil if
+// TLS was not configured.
+func (c TLS) ServerConfig() (*tls.Config, error) {
+	if c.Empty() {
+		return nil, nil
+	}
+	opts, err := c.options()
+	if err != nil {
+		return nil, err
+	}
+	return yarpctls.ServerConfig(opts...)
+}
+
+// ClientConfig builds a TLS configuration for outbounds, or returns nil if
+// TLS was not configured.
+func (c TLS) ClientConfig() (*tls.Config, error) {
+	if c.Empty() {
+		return nil, nil
+	}
+	opts, err := c.options()
+	if err != nil {
+		return nil, err
+	}
+	return yarpctls.ClientConfig(opts...)
+}
+
+func (c TLS) options() ([]yarpctls.Option, error) {
+	opts := []yarpctls.Option{yarpctls.ServerName(c.ServerName)}
+	if c.ReloadInterval > 0 {
+		source, err := yarpctls.NewFileSource(c.CertFile, c.KeyFile, c.CAFile,
+			yarpctls.ReloadInterval(c.ReloadInterval))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, yarpctls.CertificateSource(source))
+	} else {
+		opts = append(opts,
+			yarpctls.CertificateFiles(c.CertFile, c.KeyFile),
+			yarpctls.CAFile(c.CAFile),
+		)
+	}
+	if c.ClientAuth != "" {
+		clientAuth, ok := _clientAuthTypes[c.ClientAuth]
+		if !ok {
+			return nil, fmt.Errorf("unknown TLS client auth type %q", c.ClientAuth)
+		}
+		opts = append(opts, yarpctls.ClientAuth(clientAuth))
+	}
+	return opts, nil
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcconfig
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/internal/tlstest"
+)
+
+func TestTLSEmpty(t *testing.T) {
+	var c TLS
+	assert.True(t, c.Empty())
+
+	serverConfig, err := c.ServerConfig()
+	assert.NoError(t, err)
+	assert.Nil(t, serverConfig)
+
+	clientConfig, err := c.ClientConfig()
+	assert.NoError(t, err)
+	assert.Nil(t, clientConfig)
+}
+
+func TestTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpcconfig")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := tlstest.WriteFiles(t, dir)
+
+	tests := []struct {
+		desc           string
+		give           TLS
+		wantClientAuth tls.ClientAuthType
+		wantErr        string
+	}{
+		{
+			desc: "defaults",
+			give: TLS{
+				CertFile: files.ServerCertFile,
+				KeyFile:  files.ServerKeyFile,
+			},
+			wantClientAuth: tls.NoClientCert,
+		},
+		{
+			desc: "mutual TLS",
+			give: TLS{
+				CertFile:   files.ServerCertFile,
+				KeyFile:    files.ServerKeyFile,
+				CAFile:     files.CAFile,
+				ClientAuth: "require-and-verify",
+			},
+			wantClientAuth: tls.RequireAndVerifyClientCert,
+		},
+		{
+			desc: "unknown client auth",
+			give: TLS{
+				CertFile:   files.ServerCertFile,
+				KeyFile:    files.ServerKeyFile,
+				ClientAuth: "always",
+			},
+			wantErr: `unknown TLS client auth type "always"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.False(t, tt.give.Empty())
+
+			serverConfig, err := tt.give.ServerConfig()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+
+				_, err = tt.give.ClientConfig()
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantClientAuth, serverConfig.ClientAuth)
+
+			clientConfig, err := tt.give.ClientConfig()
+			require.NoError(t, err)
+			assert.Len(t, clientConfig.Certificates, 1)
+		})
+	}
+}
+
+func TestTLSReloadInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpcconfig")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := tlstest.WriteFiles(t, dir)
+
+	c := TLS{
+		CertFile:       files.ServerCertFile,
+		KeyFile:        files.ServerKeyFile,
+		CAFile:         files.CAFile,
+		ServerName:     "localhost",
+		ReloadInterval: time.Minute,
+	}
+
+	serverConfig, err := c.ServerConfig()
+	require.NoError(t, err)
+	assert.NotNil(t, serverConfig.GetConfigForClient, "server certificates must be loaded for each handshake")
+
+	clientConfig, err := c.ClientConfig()
+	require.NoError(t, err)
+	assert.NotNil(t, clientConfig.GetClientCertificate, "client certificates must be loaded for each handshake")
+	assert.NotNil(t, clientConfig.VerifyPeerCertificate, "server certificates must be verified against the latest CAs")
+
+	c.CAFile = filepath.Join(dir, "missing.pem")
+	_, err = c.ServerConfig()
+	assert.Error(t, err)
+}
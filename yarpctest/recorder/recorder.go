@@ -47,6 +47,20 @@
 //    })
 //  }
 //
+// Oneway requests may be recorded as well by also using the Recorder as a
+// oneway outbound middleware. The acknowledgement of a oneway request is
+// recorded in place of its response.
+//
+// Requests are matched to their records by a hash of the whole request. Parts
+// of requests which vary from run to run, such as tracing headers, may be
+// ignored with the IgnoreHeaders option. JSON bodies may be normalized before
+// they are hashed with NormalizeJSONBodies, and RequestHashFunc replaces the
+// hash altogether.
+//
+// By default, identical requests share a single record. With StrictOrder,
+// each occurrence of an identical request gets a record of its own, and
+// replaying returns the recorded responses in the order they were recorded.
+//
 // Running the tests in append mode:
 //  $ go test -v ./... --recorder=append
 //
@@ -58,6 +72,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"hash/fnv"
@@ -66,6 +81,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"unicode"
 
 	"go.uber.org/yarpc/api/transport"
@@ -90,6 +106,15 @@ type Recorder struct {
 	mode       Mode
 	logger     TestingT
 	recordsDir string
+
+	ignoredHeaders map[string]struct{}
+	normalizeJSON  bool
+	hashFunc       HashFunc
+	strictOrder    bool
+
+	// number of times each request hash was seen in strict order mode
+	lock        sync.Mutex
+	occurrences map[string]int
 }
 
 const defaultRecorderDir = "testdata/recordings"
@@ -157,8 +182,8 @@ type TestingT interface {
 // NewRecorder returns a Recorder in whatever mode specified via the
 // `--recorder` flag.
 //
-// The new Recorder instance is a yarpc unary outbound middleware. It takes a
-// logger as argument compatible with `testing.T`.
+// The new Recorder instance is a yarpc unary and oneway outbound middleware.
+// It takes a logger as argument compatible with `testing.T`.
 //
 // See package documentation for more details.
 func NewRecorder(logger TestingT, opts ...Option) *Recorder {
@@ -167,7 +192,9 @@ func NewRecorder(logger TestingT, opts ...Option) *Recorder {
 		logger.Fatal(err)
 	}
 	recorder := &Recorder{
-		logger: logger,
+		logger:         logger,
+		ignoredHeaders: make(map[string]struct{}),
+		occurrences:    make(map[string]int),
 	}
 
 	var cfg config
@@ -175,6 +202,13 @@ func NewRecorder(logger TestingT, opts ...Option) *Recorder {
 		opt(&cfg)
 	}
 
+	for _, header := range cfg.IgnoredHeaders {
+		recorder.ignoredHeaders[transport.CanonicalizeHeaderKey(header)] = struct{}{}
+	}
+	recorder.normalizeJSON = cfg.NormalizeJSON
+	recorder.hashFunc = cfg.HashFunc
+	recorder.strictOrder = cfg.StrictOrder
+
 	if cfg.RecordsPath != "" {
 		recorder.recordsDir = cfg.RecordsPath
 	} else {
@@ -206,12 +240,62 @@ func RecordsPath(path string) Option {
 	}
 }
 
+// IgnoreHeaders excludes the given request headers from the hash used to
+// match requests to their records. The headers are still recorded.
+//
+// This is useful for headers which change from run to run, such as tracing
+// headers.
+func IgnoreHeaders(headers ...string) Option {
+	return func(cfg *config) {
+		cfg.IgnoredHeaders = append(cfg.IgnoredHeaders, headers...)
+	}
+}
+
+// NormalizeJSONBodies hashes the bodies of JSON-encoded requests in a
+// canonical form, so that requests differing only by whitespace or by the
+// order of their object keys match the same record.
+func NormalizeJSONBodies() Option {
+	return func(cfg *config) {
+		cfg.NormalizeJSON = true
+	}
+}
+
+// HashFunc computes the hash which identifies the record of a request. The
+// body of the given request may be read freely.
+//
+// Requests with the same service, procedure and hash share a record.
+type HashFunc func(request *transport.Request) string
+
+// RequestHashFunc replaces the hash used to match requests to their records.
+// IgnoreHeaders and NormalizeJSONBodies have no effect when a custom hash
+// function is used.
+func RequestHashFunc(f HashFunc) Option {
+	return func(cfg *config) {
+		cfg.HashFunc = f
+	}
+}
+
+// StrictOrder records every occurrence of identical requests separately, and
+// replays them in the order they were recorded. Replaying more occurrences of
+// a request than were recorded aborts the test.
+//
+// By default, all occurrences of identical requests share a single record.
+func StrictOrder() Option {
+	return func(cfg *config) {
+		cfg.StrictOrder = true
+	}
+}
+
 // Option is the type used for the functional options pattern.
 type Option func(*config)
 
 type config struct {
-	Mode        Mode
-	RecordsPath string
+	Mode           Mode
+	RecordsPath    string
+	IgnoredHeaders []string
+	NormalizeJSON  bool
+	HashFunc       HashFunc
+	StrictOrder    bool
 }
 
 // SetMode let you choose enable the different replay and recording modes,
@@ -259,6 +343,9 @@ func (r *Recorder) hashRequestRecord(requestRecord *requestRecord) string {
 
 	orderedHeadersKeys := make([]string, 0, len(requestRecord.Headers))
 	for k := range requestRecord.Headers {
+		if _, ignored := r.ignoredHeaders[k]; ignored {
+			continue
+		}
 		orderedHeadersKeys = append(orderedHeadersKeys, k)
 	}
 	sort.Strings(orderedHeadersKeys)
@@ -270,62 +357,157 @@ func (r *Recorder) hashRequestRecord(requestRecord *requestRecord) string {
 	ha(requestRecord.ShardKey)
 	ha(requestRecord.RoutingKey)
 	ha(requestRecord.RoutingDelegate)
+	// Only hashed for oneway requests to keep the hashes of unary requests
+	// stable.
+	if requestRecord.Oneway {
+		ha("oneway")
+	}
 
-	_, err := hash.Write(requestRecord.Body)
+	body := []byte(requestRecord.Body)
+	if r.normalizeJSON && requestRecord.Encoding == "json" {
+		body = normalizeJSON(body)
+	}
+	_, err := hash.Write(body)
 	if err != nil {
 		log.Fatal(err)
 	}
 	return fmt.Sprintf("%x", hash.Sum64())
 }
 
+// normalizeJSON returns the given JSON document with its whitespace removed
+// and its object keys sorted. Invalid documents are returned as-is.
+func normalizeJSON(body []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return body
+	}
+	normalized, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+	return normalized
+}
+
+// requestHash returns the hash identifying the record of the given request.
+// In strict order mode, the number of previous occurrences of the same
+// request is appended to the hash.
+func (r *Recorder) requestHash(request *transport.Request, requestRecord *requestRecord) string {
+	var hash string
+	if r.hashFunc != nil {
+		hashedRequest := *request
+		hashedRequest.Body = bytes.NewReader(requestRecord.Body)
+		hash = r.hashFunc(&hashedRequest)
+	} else {
+		hash = r.hashRequestRecord(requestRecord)
+	}
+	if !r.strictOrder {
+		return hash
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	occurrence := r.occurrences[hash]
+	r.occurrences[hash]++
+	if occurrence == 0 {
+		// The first occurrence shares its record with non-strict recorders.
+		return hash
+	}
+	return fmt.Sprintf("%s.%d", hash, occurrence)
+}
+
 func (r *Recorder) makeFilePath(request *transport.Request, hash string) string {
 	s := fmt.Sprintf("%s.%s.%s.yaml", request.Service, request.Procedure, hash)
 	return filepath.Join(r.recordsDir, sanitizeFilename(s))
 }
 
-// Call implements the yarpc transport outbound middleware interface
-func (r *Recorder) Call(
-	ctx context.Context,
-	request *transport.Request,
-	out transport.UnaryOutbound) (*transport.Response, error) {
-	log := r.logger
-
-	requestRecord := r.requestToRequestRecord(request)
-
-	requestHash := r.hashRequestRecord(&requestRecord)
-	filepath := r.makeFilePath(request, requestHash)
+// lookup returns the path of the record for the given request, and the record
+// itself if the request should be replayed from it. Otherwise, the request
+// must be sent and its response recorded.
+func (r *Recorder) lookup(request *transport.Request, requestRecord *requestRecord) (string, *record) {
+	filepath := r.makeFilePath(request, r.requestHash(request, requestRecord))
 
 	switch r.mode {
 	case Replay:
 		cachedRecord, err := r.loadRecord(filepath)
 		if err != nil {
-			log.Fatal(err)
+			r.logger.Fatal(err)
 		}
-		response := r.recordToResponse(cachedRecord)
-		return &response, nil
+		return filepath, cachedRecord
 	case Append:
 		cachedRecord, err := r.loadRecord(filepath)
 		if err == nil {
-			response := r.recordToResponse(cachedRecord)
-			return &response, nil
+			return filepath, cachedRecord
 		}
-		fallthrough
+		return filepath, nil
 	case Overwrite:
-		response, err := out.Call(ctx, request)
-		if err == nil {
-			cachedRecord := record{
-				Version:  currentRecordVersion,
-				Request:  requestRecord,
-				Response: r.responseToResponseRecord(response),
-			}
-			r.saveRecord(filepath, &cachedRecord)
-		}
-		return response, err
+		return filepath, nil
 	default:
 		panic(fmt.Sprintf("invalid record mode: %v", r.mode))
 	}
 }
 
+// Call implements the yarpc transport outbound middleware interface
+func (r *Recorder) Call(
+	ctx context.Context,
+	request *transport.Request,
+	out transport.UnaryOutbound) (*transport.Response, error) {
+	requestRecord := r.requestToRequestRecord(request)
+
+	filepath, cachedRecord := r.lookup(request, &requestRecord)
+	if cachedRecord != nil {
+		response := r.recordToResponse(cachedRecord)
+		return &response, nil
+	}
+
+	response, err := out.Call(ctx, request)
+	if err == nil {
+		cachedRecord := record{
+			Version:  currentRecordVersion,
+			Request:  requestRecord,
+			Response: r.responseToResponseRecord(response),
+		}
+		r.saveRecord(filepath, &cachedRecord)
+	}
+	return response, err
+}
+
+// CallOneway implements the yarpc transport oneway outbound middleware
+// interface.
+func (r *Recorder) CallOneway(
+	ctx context.Context,
+	request *transport.Request,
+	out transport.OnewayOutbound) (transport.Ack, error) {
+	requestRecord := r.requestToRequestRecord(request)
+	requestRecord.Oneway = true
+
+	filepath, cachedRecord := r.lookup(request, &requestRecord)
+	if cachedRecord != nil {
+		return recordedAck(cachedRecord.Response.Ack), nil
+	}
+
+	ack, err := out.CallOneway(ctx, request)
+	if err == nil {
+		cachedRecord := record{
+			Version: currentRecordVersion,
+			Request: requestRecord,
+		}
+		if ack != nil {
+			cachedRecord.Response.Ack = ack.String()
+		}
+		r.saveRecord(filepath, &cachedRecord)
+	}
+	return ack, err
+}
+
+// recordedAck is the acknowledgement of a replayed oneway request.
+type recordedAck string
+
+func (a recordedAck) String() string {
+	return string(a)
+}
+
 func (r *Recorder) recordToResponse(cachedRecord *record) transport.Response {
 	response := transport.Response{
 		Headers: transport.HeadersFromMap(cachedRecord.Response.Headers),
@@ -437,11 +619,13 @@ type requestRecord struct {
 	RoutingKey      string
 	RoutingDelegate string
 	Body            base64blob
+	Oneway          bool `yaml:"oneway,omitempty"`
 }
 
 type responseRecord struct {
 	Headers map[string]string
 	Body    base64blob
+	Ack     string `yaml:"ack,omitempty"`
 }
 
 type record struct {
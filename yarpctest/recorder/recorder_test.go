@@ -374,3 +374,189 @@ func TestReplaying(t *testing.T) {
 		assert.Equal(t, rbody, []byte("Hello, World"))
 	})
 }
+
+// fakeOutbound numbers its responses and acknowledgements.
+type fakeOutbound struct {
+	calls int
+}
+
+type fakeAck string
+
+func (a fakeAck) String() string { return string(a) }
+
+func (o *fakeOutbound) Transports() []transport.Transport { return nil }
+func (o *fakeOutbound) Start() error                      { return nil }
+func (o *fakeOutbound) Stop() error                       { return nil }
+func (o *fakeOutbound) IsRunning() bool                   { return true }
+
+func (o *fakeOutbound) Call(context.Context, *transport.Request) (*transport.Response, error) {
+	o.calls++
+	return &transport.Response{
+		Headers: transport.NewHeaders(),
+		Body:    ioutil.NopCloser(bytes.NewReader([]byte(fmt.Sprintf("response %d", o.calls)))),
+	}, nil
+}
+
+func (o *fakeOutbound) CallOneway(context.Context, *transport.Request) (transport.Ack, error) {
+	o.calls++
+	return fakeAck(fmt.Sprintf("ack %d", o.calls)), nil
+}
+
+func newTestRequest(body string) *transport.Request {
+	return &transport.Request{
+		Caller:    "client",
+		Service:   "server",
+		Encoding:  raw.Encoding,
+		Procedure: "hello",
+		Headers:   transport.NewHeaders().With("foo", "bar"),
+		Body:      bytes.NewReader([]byte(body)),
+	}
+}
+
+func callRecorder(t *testing.T, recorder *Recorder, out transport.UnaryOutbound, request *transport.Request) string {
+	response, err := recorder.Call(context.Background(), request, out)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(response.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestOneway(t *testing.T) {
+	tMock := testingTMock{t, 0}
+
+	dir, err := ioutil.TempDir("", "yarpcgorecorder")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // clean up
+
+	out := &fakeOutbound{}
+	recorder := NewRecorder(&tMock, RecordMode(Overwrite), RecordsPath(dir))
+	ack, err := recorder.CallOneway(context.Background(), newTestRequest("Hello"), out)
+	require.NoError(t, err)
+	assert.Equal(t, "ack 1", ack.String())
+
+	// Unary requests do not share records with identical oneway requests.
+	assert.Equal(t, "response 2", callRecorder(t, recorder, out, newTestRequest("Hello")))
+
+	recorder = NewRecorder(&tMock, RecordMode(Replay), RecordsPath(dir))
+	ack, err = recorder.CallOneway(context.Background(), newTestRequest("Hello"), out)
+	require.NoError(t, err)
+	assert.Equal(t, "ack 1", ack.String())
+	assert.Equal(t, "response 2", callRecorder(t, recorder, out, newTestRequest("Hello")))
+	assert.Equal(t, 2, out.calls, "replayed requests must not be sent")
+
+	require.Panics(t, func() {
+		recorder.CallOneway(context.Background(), newTestRequest("Goodbye"), out)
+	})
+	assert.Equal(t, 1, tMock.fatalCount)
+}
+
+func TestIgnoreHeaders(t *testing.T) {
+	recorder := NewRecorder(t)
+	ignoring := NewRecorder(t, IgnoreHeaders("X-Trace-ID"))
+
+	hash := func(recorder *Recorder, traceID string) string {
+		request := newTestRequest("Hello")
+		request.Headers = request.Headers.With("x-trace-id", traceID)
+		requestRecord := recorder.requestToRequestRecord(request)
+		return recorder.hashRequestRecord(&requestRecord)
+	}
+
+	assert.NotEqual(t, hash(recorder, "1"), hash(recorder, "2"))
+	assert.Equal(t, hash(ignoring, "1"), hash(ignoring, "2"))
+
+	// Other headers still count.
+	request := newTestRequest("Hello")
+	request.Headers = request.Headers.With("foo", "baz")
+	requestRecord := ignoring.requestToRequestRecord(request)
+	assert.NotEqual(t, hash(ignoring, "1"), ignoring.hashRequestRecord(&requestRecord))
+}
+
+func TestNormalizeJSONBodies(t *testing.T) {
+	hash := func(recorder *Recorder, encoding transport.Encoding, body string) string {
+		request := newTestRequest(body)
+		request.Encoding = encoding
+		requestRecord := recorder.requestToRequestRecord(request)
+		return recorder.hashRequestRecord(&requestRecord)
+	}
+
+	recorder := NewRecorder(t)
+	normalizing := NewRecorder(t, NormalizeJSONBodies())
+
+	a := `{"name": "foo", "values": [1, 2.50, {"y": 1, "x": 2}]}`
+	b := `{"values":[1,2.50,{"x":2,"y":1}],"name":"foo"}`
+	assert.NotEqual(t, hash(recorder, "json", a), hash(recorder, "json", b))
+	assert.Equal(t, hash(normalizing, "json", a), hash(normalizing, "json", b))
+
+	// Numbers are kept as they were written.
+	assert.NotEqual(t, hash(normalizing, "json", `{"x": 2.5}`), hash(normalizing, "json", `{"x": 2.50}`))
+
+	// Only JSON requests are normalized.
+	assert.NotEqual(t, hash(normalizing, raw.Encoding, a), hash(normalizing, raw.Encoding, b))
+
+	// Invalid documents are hashed as-is.
+	assert.Equal(t, hash(recorder, "json", `{"x": `), hash(normalizing, "json", `{"x": `))
+	assert.Equal(t, hash(recorder, "json", `{} {}`), hash(normalizing, "json", `{} {}`))
+}
+
+func TestRequestHashFunc(t *testing.T) {
+	tMock := testingTMock{t, 0}
+
+	dir, err := ioutil.TempDir("", "yarpcgorecorder")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // clean up
+
+	hashByBody := func(request *transport.Request) string {
+		body, err := ioutil.ReadAll(request.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	out := &fakeOutbound{}
+	recorder := NewRecorder(&tMock, RecordMode(Overwrite), RecordsPath(dir), RequestHashFunc(hashByBody))
+	assert.Equal(t, "response 1", callRecorder(t, recorder, out, newTestRequest("Hello")))
+
+	_, err = os.Stat(path.Join(dir, "server.hello.Hello.yaml"))
+	require.NoError(t, err)
+
+	// Requests with the same body match regardless of their headers.
+	recorder = NewRecorder(&tMock, RecordMode(Replay), RecordsPath(dir), RequestHashFunc(hashByBody))
+	request := newTestRequest("Hello")
+	request.Headers = transport.NewHeaders().With("foo", "baz")
+	assert.Equal(t, "response 1", callRecorder(t, recorder, out, request))
+	assert.Equal(t, 1, out.calls)
+}
+
+func TestStrictOrder(t *testing.T) {
+	tMock := testingTMock{t, 0}
+
+	dir, err := ioutil.TempDir("", "yarpcgorecorder")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // clean up
+
+	out := &fakeOutbound{}
+	recorder := NewRecorder(&tMock, RecordMode(Overwrite), RecordsPath(dir), StrictOrder())
+	assert.Equal(t, "response 1", callRecorder(t, recorder, out, newTestRequest("Hello")))
+	assert.Equal(t, "response 2", callRecorder(t, recorder, out, newTestRequest("Hello")))
+	assert.Equal(t, "response 3", callRecorder(t, recorder, out, newTestRequest("Goodbye")))
+
+	recorder = NewRecorder(&tMock, RecordMode(Replay), RecordsPath(dir), StrictOrder())
+	assert.Equal(t, "response 1", callRecorder(t, recorder, out, newTestRequest("Hello")))
+	assert.Equal(t, "response 3", callRecorder(t, recorder, out, newTestRequest("Goodbye")))
+	assert.Equal(t, "response 2", callRecorder(t, recorder, out, newTestRequest("Hello")))
+	require.Panics(t, func() {
+		callRecorder(t, recorder, out, newTestRequest("Hello"))
+	})
+	assert.Equal(t, 1, tMock.fatalCount)
+
+	// Without strict order, identical requests share the first record.
+	recorder = NewRecorder(&tMock, RecordMode(Replay), RecordsPath(dir))
+	assert.Equal(t, "response 1", callRecorder(t, recorder, out, newTestRequest("Hello")))
+	assert.Equal(t, "response 1", callRecorder(t, recorder, out, newTestRequest("Hello")))
+
+	// Appending records only the occurrences which are missing.
+	recorder = NewRecorder(&tMock, RecordMode(Append), RecordsPath(dir), StrictOrder())
+	assert.Equal(t, "response 1", callRecorder(t, recorder, out, newTestRequest("Hello")))
+	assert.Equal(t, "response 2", callRecorder(t, recorder, out, newTestRequest("Hello")))
+	assert.Equal(t, "response 4", callRecorder(t, recorder, out, newTestRequest("Hello")))
+	assert.Equal(t, 4, out.calls)
+}
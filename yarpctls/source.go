@@ -0,0 +1,197 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpctls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+)
+
+const _defaultReloadInterval = 10 * time.Second
+
+// Certificates holds the certificates supplied by a Source.
+type Certificates struct {
+	// Certificate presented to the other side of the connection. This is
+	// required for servers and optional for clients.
+	Certificate *tls.Certificate
+
+	// CAs used to verify the certificate of the other side of the
+	// connection. Clients fall back to the host's root CAs if this is nil.
+	CAs *x509.CertPool
+}
+
+// Source supplies certificates to TLS configurations built with
+// CertificateSource.
+//
+// Sources may return different certificates over time. Every new TLS
+// handshake uses the certificates returned by the Source at that time, while
+// existing connections are unaffected. Certificates is called for every
+// handshake so it must be fast and safe for concurrent use.
+type Source interface {
+	Certificates() (*Certificates, error)
+}
+
+// SourceFunc is a Source backed by a function.
+//
+// 	source := yarpctls.SourceFunc(func() (*yarpctls.Certificates, error) {
+// 		return secretStore.Certificates()
+// 	})
+type SourceFunc func() (*Certificates, error)
+
+// Certificates calls the function.
+func (f SourceFunc) Certificates() (*Certificates, error) {
+	return f()
+}
+
+// FileSourceOption customizes a FileSource.
+type FileSourceOption func(*FileSource)
+
+// ReloadInterval specifies how often a FileSource checks whether its files
+// have changed.
+//
+// Defaults to 10 seconds.
+func ReloadInterval(interval time.Duration) FileSourceOption {
+	return func(s *FileSource) {
+		s.interval = interval
+	}
+}
+
+// FileSource is a Source which reads PEM-encoded certificates from files
+// and reloads them when the files change.
+//
+// The files are checked for changes at most once per ReloadInterval, when a
+// new connection needs certificates. If the files cannot be loaded, for
+// example because a certificate was replaced but its key wasn't yet, the
+// previous certificates continue to be used and loading is retried later.
+type FileSource struct {
+	certFile string
+	keyFile  string
+	caFile   string
+	interval time.Duration
+	clock    clock.Clock
+
+	mu        sync.Mutex
+	certs     *Certificates
+	stats     [3]fileStat
+	lastCheck time.Time
+}
+
+type fileStat struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewFileSource builds a FileSource for the given certificate, private key
+// and CA bundle. The certificate and key may be empty for clients which
+// don't present a certificate, and the CA bundle may be empty to use the
+// host's root CAs.
+//
+// The files are loaded immediately, and an error is returned if they are
+// invalid.
+func NewFileSource(certFile, keyFile, caFile string, opts ...FileSourceOption) (*FileSource, error) {
+	s := &FileSource{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		interval: _defaultReloadInterval,
+		clock:    clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	stats, err := s.stat()
+	if err != nil {
+		return nil, err
+	}
+	certs, err := loadCertificates(s.certFile, s.keyFile, s.caFile)
+	if err != nil {
+		return nil, err
+	}
+	s.certs = certs
+	s.stats = stats
+	s.lastCheck = s.clock.Now()
+	return s, nil
+}
+
+// Certificates returns the latest certificates loaded from the files.
+func (s *FileSource) Certificates() (*Certificates, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	if now.Sub(s.lastCheck) < s.interval {
+		return s.certs, nil
+	}
+	s.lastCheck = now
+
+	stats, err := s.stat()
+	if err != nil || stats == s.stats {
+		return s.certs, nil
+	}
+	certs, err := loadCertificates(s.certFile, s.keyFile, s.caFile)
+	if err != nil {
+		return s.certs, nil
+	}
+	s.certs = certs
+	s.stats = stats
+	return s.certs, nil
+}
+
+func (s *FileSource) stat() ([3]fileStat, error) {
+	var stats [3]fileStat
+	for i, name := range []string{s.certFile, s.keyFile, s.caFile} {
+		if name == "" {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return stats, fmt.Errorf("failed to read %q: %v", name, err)
+		}
+		stats[i] = fileStat{modTime: info.ModTime(), size: info.Size()}
+	}
+	return stats, nil
+}
+
+func loadCertificates(certFile, keyFile, caFile string) (*Certificates, error) {
+	var certs Certificates
+	if certFile != "" || keyFile != "" {
+		cert, err := loadCertificate(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		certs.Certificate = cert
+	}
+	if caFile != "" {
+		pool, err := loadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		certs.CAs = pool
+	}
+	return &certs, nil
+}
@@ -0,0 +1,279 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpctls
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/tlstest"
+)
+
+// rotatingSource is a Source whose certificates can be changed by tests.
+type rotatingSource struct {
+	mu    sync.Mutex
+	certs *Certificates
+}
+
+func (s *rotatingSource) Certificates() (*Certificates, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.certs, nil
+}
+
+func (s *rotatingSource) set(certs *Certificates) {
+	s.mu.Lock()
+	s.certs = certs
+	s.mu.Unlock()
+}
+
+type certificateFiles struct {
+	tlstest.Files
+
+	NotAfter time.Time
+}
+
+// writeFiles writes a new set of certificates, which expire at distinct
+// times, to the given directory.
+func writeFiles(t *testing.T, dir string, notAfter time.Time) certificateFiles {
+	notAfter = notAfter.UTC().Truncate(time.Second)
+	return certificateFiles{
+		Files:    tlstest.WriteFilesWithExpiry(t, dir, notAfter),
+		NotAfter: notAfter,
+	}
+}
+
+func mustLoad(t *testing.T, certFile, keyFile, caFile string) *Certificates {
+	certs, err := loadCertificates(certFile, keyFile, caFile)
+	require.NoError(t, err)
+	return certs
+}
+
+// handshake performs a TLS handshake between the given configurations and
+// returns the state of the server and the client.
+func handshake(t *testing.T, serverConfig, clientConfig *tls.Config) (server, client tls.ConnectionState, err error) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	serverTLS := tls.Server(serverConn, serverConfig)
+	clientTLS := tls.Client(clientConn, clientConfig)
+
+	errc := make(chan error, 1)
+	go func() {
+		err := serverTLS.Handshake()
+		if err != nil {
+			// Unblock the client.
+			serverConn.Close()
+		}
+		errc <- err
+	}()
+	clientErr := clientTLS.Handshake()
+	if clientErr != nil {
+		clientConn.Close()
+	}
+	serverErr := <-errc
+
+	if clientErr != nil {
+		return server, client, clientErr
+	}
+	if serverErr != nil {
+		return server, client, serverErr
+	}
+	return serverTLS.ConnectionState(), clientTLS.ConnectionState(), nil
+}
+
+func TestFileSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpctls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	first := writeFiles(t, dir, time.Now().Add(time.Hour))
+
+	fakeClock := clock.NewFake()
+	source, err := NewFileSource(first.ServerCertFile, first.ServerKeyFile, first.CAFile,
+		ReloadInterval(time.Minute))
+	require.NoError(t, err)
+	source.clock = fakeClock
+	source.lastCheck = fakeClock.Now()
+
+	certs, err := source.Certificates()
+	require.NoError(t, err)
+	require.NotNil(t, certs.Certificate)
+	assert.Equal(t, first.NotAfter, certs.Certificate.Leaf.NotAfter)
+	assert.NotNil(t, certs.CAs)
+
+	second := writeFiles(t, dir, time.Now().Add(2*time.Hour))
+	touch(t, second.ServerCertFile, second.ServerKeyFile, second.CAFile)
+
+	certs, err = source.Certificates()
+	require.NoError(t, err)
+	assert.Equal(t, first.NotAfter, certs.Certificate.Leaf.NotAfter,
+		"files must not be reloaded before the reload interval")
+
+	fakeClock.Add(time.Minute)
+	certs, err = source.Certificates()
+	require.NoError(t, err)
+	assert.Equal(t, second.NotAfter, certs.Certificate.Leaf.NotAfter,
+		"files must be reloaded after the reload interval")
+
+	// A certificate without its key can't be loaded so the previous
+	// certificates must be kept.
+	require.NoError(t, ioutil.WriteFile(second.ServerKeyFile, []byte("garbage"), 0600))
+	touch(t, second.ServerKeyFile)
+	fakeClock.Add(time.Minute)
+	certs, err = source.Certificates()
+	require.NoError(t, err)
+	assert.Equal(t, second.NotAfter, certs.Certificate.Leaf.NotAfter)
+
+	require.NoError(t, os.Remove(second.ServerCertFile))
+	fakeClock.Add(time.Minute)
+	certs, err = source.Certificates()
+	require.NoError(t, err)
+	assert.Equal(t, second.NotAfter, certs.Certificate.Leaf.NotAfter)
+}
+
+// touch moves the modification time of the given files forward so that
+// changes are noticed regardless of the precision of the file system.
+func touch(t *testing.T, files ...string) {
+	future := time.Now().Add(time.Hour)
+	for _, f := range files {
+		require.NoError(t, os.Chtimes(f, future, future))
+	}
+}
+
+func TestNewFileSourceErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpctls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := tlstest.WriteFiles(t, dir)
+	missing := filepath.Join(dir, "missing.pem")
+
+	_, err = NewFileSource(missing, files.ServerKeyFile, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read")
+
+	_, err = NewFileSource(files.ServerCertFile, files.ClientKeyFile, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load certificate")
+
+	_, err = NewFileSource("", "", files.ServerKeyFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no certificates found in CA bundle")
+}
+
+func TestCertificateSourceWithFiles(t *testing.T) {
+	source := SourceFunc(func() (*Certificates, error) { return &Certificates{}, nil })
+
+	_, err := ServerConfig(CertificateSource(source), CAFile("ca.pem"))
+	assert.Equal(t, errSourceWithFiles, err)
+
+	_, err = ClientConfig(CertificateSource(source), CertificateFiles("cert.pem", "key.pem"))
+	assert.Equal(t, errSourceWithFiles, err)
+}
+
+func TestServerConfigSourceRequiresCertificate(t *testing.T) {
+	source := SourceFunc(func() (*Certificates, error) { return &Certificates{}, nil })
+	_, err := ServerConfig(CertificateSource(source))
+	assert.Equal(t, errServerCertificateRequired, err)
+}
+
+func TestCertificateSourceRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpctls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	firstDir := filepath.Join(dir, "first")
+	secondDir := filepath.Join(dir, "second")
+	require.NoError(t, os.Mkdir(firstDir, 0700))
+	require.NoError(t, os.Mkdir(secondDir, 0700))
+
+	// The two sets of certificates are issued by different CAs, so a
+	// handshake only succeeds if both sides use the same set.
+	first := writeFiles(t, firstDir, time.Now().Add(time.Hour))
+	second := writeFiles(t, secondDir, time.Now().Add(2*time.Hour))
+
+	serverSource := &rotatingSource{certs: mustLoad(t, first.ServerCertFile, first.ServerKeyFile, first.CAFile)}
+	clientSource := &rotatingSource{certs: mustLoad(t, first.ClientCertFile, first.ClientKeyFile, first.CAFile)}
+
+	serverConfig, err := ServerConfig(
+		CertificateSource(serverSource),
+		ClientAuth(tls.RequireAndVerifyClientCert),
+	)
+	require.NoError(t, err)
+	clientConfig, err := ClientConfig(
+		CertificateSource(clientSource),
+		ServerName("localhost"),
+	)
+	require.NoError(t, err)
+
+	serverState, clientState, err := handshake(t, serverConfig, clientConfig)
+	require.NoError(t, err, "handshake with the first certificates failed")
+	assert.Equal(t, first.NotAfter, clientState.PeerCertificates[0].NotAfter)
+	assert.Equal(t, first.NotAfter, VerifiedPeerCertificate(&serverState).NotAfter)
+
+	serverSource.set(mustLoad(t, second.ServerCertFile, second.ServerKeyFile, second.CAFile))
+	_, _, err = handshake(t, serverConfig, clientConfig)
+	assert.Error(t, err, "handshake with certificates from different CAs must fail")
+
+	clientSource.set(mustLoad(t, second.ClientCertFile, second.ClientKeyFile, second.CAFile))
+	serverState, clientState, err = handshake(t, serverConfig, clientConfig)
+	require.NoError(t, err, "handshake with the second certificates failed")
+	assert.Equal(t, second.NotAfter, clientState.PeerCertificates[0].NotAfter)
+	assert.Equal(t, second.NotAfter, VerifiedPeerCertificate(&serverState).NotAfter)
+}
+
+func TestClientConfigSourceWithoutServerName(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpctls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := tlstest.WriteFiles(t, dir)
+	source, err := NewFileSource(files.ClientCertFile, files.ClientKeyFile, files.CAFile)
+	require.NoError(t, err)
+
+	config, err := ClientConfig(CertificateSource(source))
+	require.NoError(t, err)
+	assert.False(t, config.InsecureSkipVerify, "standard verification must be used without a server name")
+	assert.NotNil(t, config.RootCAs)
+
+	serverConfig, err := ServerConfig(
+		CertificateFiles(files.ServerCertFile, files.ServerKeyFile),
+		CAFile(files.CAFile),
+		ClientAuth(tls.RequireAndVerifyClientCert),
+	)
+	require.NoError(t, err)
+
+	// Standard verification uses the ServerName set on the connection.
+	config.ServerName = "localhost"
+	_, _, err = handshake(t, serverConfig, config)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,360 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package yarpctls builds TLS configurations for YARPC transports.
+//
+// The same set of options is used to configure the HTTP, gRPC and TChannel
+// transports. Build a configuration for inbounds with ServerConfig and one
+// for outbounds with ClientConfig, and pass the result to the TLS option of
+// the transport.
+//
+// 	serverTLS, err := yarpctls.ServerConfig(
+// 		yarpctls.CertificateFiles("/etc/certs/myservice.pem", "/etc/certs/myservice-key.pem"),
+// 		yarpctls.CAFile("/etc/certs/ca.pem"),
+// 		yarpctls.ClientAuth(tls.RequireAndVerifyClientCert),
+// 	)
+// 	if err != nil {
+// 		return err
+// 	}
+// 	inbound := httpTransport.NewInbound(":8080", http.InboundTLSConfig(serverTLS))
+//
+// When an inbound verifies client certificates, the certificate of the
+// caller is available to handlers through yarpc.CallFromContext.
+//
+// 	cert := yarpc.CallFromContext(ctx).PeerCertificate()
+//
+// Certificates which rotate while the process is running can be supplied by
+// a Source instead. Configurations built with CertificateSource pick up new
+// certificates for every new connection without affecting existing ones.
+//
+// 	source, err := yarpctls.NewFileSource(
+// 		"/etc/certs/myservice.pem",
+// 		"/etc/certs/myservice-key.pem",
+// 		"/etc/certs/ca.pem",
+// 	)
+// 	if err != nil {
+// 		return err
+// 	}
+// 	serverTLS, err := yarpctls.ServerConfig(
+// 		yarpctls.CertificateSource(source),
+// 		yarpctls.ClientAuth(tls.RequireAndVerifyClientCert),
+// 	)
+//
+// The expiration time of the certificates presented by transports is reported
+// by the Dispatchers using them as the tls_certificate_expiration metric.
+package yarpctls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+var (
+	errServerCertificateRequired = errors.New("a certificate and private key are required for a TLS server")
+	errSourceWithFiles           = errors.New("CertificateSource cannot be used with CertificateFiles or CAFile")
+)
+
+// Option customizes a TLS configuration built with ServerConfig or
+// ClientConfig.
+type Option func(*options)
+
+type options struct {
+	certFile   string
+	keyFile    string
+	caFile     string
+	clientAuth tls.ClientAuthType
+	serverName string
+	source     Source
+}
+
+// CertificateFiles specifies the PEM-encoded certificate and private key
+// presented to the other side of the connection.
+//
+// This is required for servers. Clients only need it for mutual TLS.
+func CertificateFiles(certFile, keyFile string) Option {
+	return func(o *options) {
+		o.certFile = certFile
+		o.keyFile = keyFile
+	}
+}
+
+// CAFile specifies a PEM-encoded bundle of CA certificates.
+//
+// Servers use it to verify client certificates. Clients use it to verify
+// server certificates, and fall back to the host's root CAs if it is not
+// specified.
+func CAFile(caFile string) Option {
+	return func(o *options) {
+		o.caFile = caFile
+	}
+}
+
+// ClientAuth specifies the policy a server follows for client certificates.
+// It has no effect on clients.
+//
+// Defaults to tls.NoClientCert.
+func ClientAuth(clientAuth tls.ClientAuthType) Option {
+	return func(o *options) {
+		o.clientAuth = clientAuth
+	}
+}
+
+// ServerName specifies the name a client uses to verify the server's
+// certificate. It is also sent to the server for SNI. It has no effect on
+// servers.
+//
+// Defaults to the host of the address being dialed.
+func ServerName(serverName string) Option {
+	return func(o *options) {
+		o.serverName = serverName
+	}
+}
+
+// CertificateSource specifies a Source which supplies the certificate and CA
+// bundle for every new connection. It cannot be combined with
+// CertificateFiles or CAFile.
+//
+// Clients only pick up changes to the CA bundle of a Source if they specify
+// a ServerName, because the name of the server is not otherwise available
+// when verifying its certificate. Without a ServerName, the CA bundle
+// returned by the Source when the configuration is built is used.
+func CertificateSource(source Source) Option {
+	return func(o *options) {
+		o.source = source
+	}
+}
+
+func newOptions(opts []Option) (options, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.source != nil && (o.certFile != "" || o.keyFile != "" || o.caFile != "") {
+		return o, errSourceWithFiles
+	}
+	return o, nil
+}
+
+// ServerConfig builds a TLS configuration for inbounds.
+func ServerConfig(opts ...Option) (*tls.Config, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if o.source != nil {
+		return sourceServerConfig(o)
+	}
+
+	if o.certFile == "" || o.keyFile == "" {
+		return nil, errServerCertificateRequired
+	}
+	cert, err := loadCertificate(o.certFile, o.keyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{*cert},
+		ClientAuth:   o.clientAuth,
+		MinVersion:   tls.VersionTLS12,
+	}
+	if o.caFile != "" {
+		if config.ClientCAs, err = loadCertPool(o.caFile); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+func sourceServerConfig(o options) (*tls.Config, error) {
+	// Fail early if the source can't provide a certificate.
+	if _, err := serverCertificates(o.source); err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		ClientAuth: o.clientAuth,
+		MinVersion: tls.VersionTLS12,
+	}
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		certs, err := serverCertificates(o.source)
+		if err != nil {
+			return nil, err
+		}
+		// Transports may have made changes to the configuration, so we start
+		// from a copy of it rather than a new one.
+		handshakeConfig := config.Clone()
+		handshakeConfig.GetConfigForClient = nil
+		handshakeConfig.Certificates = []tls.Certificate{*certs.Certificate}
+		handshakeConfig.ClientCAs = certs.CAs
+		return handshakeConfig, nil
+	}
+	return config, nil
+}
+
+func serverCertificates(source Source) (*Certificates, error) {
+	certs, err := source.Certificates()
+	if err != nil {
+		return nil, err
+	}
+	if certs == nil || certs.Certificate == nil {
+		return nil, errServerCertificateRequired
+	}
+	return certs, nil
+}
+
+// ClientConfig builds a TLS configuration for outbounds.
+func ClientConfig(opts ...Option) (*tls.Config, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if o.source != nil {
+		return sourceClientConfig(o)
+	}
+
+	config := &tls.Config{
+		ServerName: o.serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if o.certFile != "" || o.keyFile != "" {
+		cert, err := loadCertificate(o.certFile, o.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{*cert}
+	}
+	if o.caFile != "" {
+		if config.RootCAs, err = loadCertPool(o.caFile); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+func sourceClientConfig(o options) (*tls.Config, error) {
+	certs, err := clientCertificates(o.source)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		ServerName: o.serverName,
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    certs.CAs,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			certs, err := clientCertificates(o.source)
+			if err != nil {
+				return nil, err
+			}
+			if certs.Certificate == nil {
+				// Don't send a certificate.
+				return &tls.Certificate{}, nil
+			}
+			return certs.Certificate, nil
+		},
+	}
+
+	if o.serverName != "" {
+		// The standard verification only uses the CAs in the configuration,
+		// which would never change. Verify servers against the latest CAs
+		// ourselves instead.
+		config.RootCAs = nil
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			certs, err := clientCertificates(o.source)
+			if err != nil {
+				return err
+			}
+			return verifyServer(rawCerts, o.serverName, certs.CAs)
+		}
+	}
+	return config, nil
+}
+
+func clientCertificates(source Source) (*Certificates, error) {
+	certs, err := source.Certificates()
+	if err != nil {
+		return nil, err
+	}
+	if certs == nil {
+		return &Certificates{}, nil
+	}
+	return certs, nil
+}
+
+func verifyServer(rawCerts [][]byte, serverName string, roots *x509.CertPool) error {
+	if len(rawCerts) == 0 {
+		return errors.New("server did not present a certificate")
+	}
+	chain := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse server certificate: %v", err)
+		}
+		chain[i] = cert
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+func loadCertificate(certFile, keyFile string) (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %v", err)
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %v", err)
+	}
+	return &cert, nil
+}
+
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %q", caFile)
+	}
+	return pool, nil
+}
+
+// VerifiedPeerCertificate returns the leaf certificate of the peer on the
+// given connection if it was verified during the handshake, or nil
+// otherwise.
+func VerifiedPeerCertificate(state *tls.ConnectionState) *x509.Certificate {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return state.VerifiedChains[0][0]
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpctls
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/internal/tlstest"
+)
+
+func TestServerConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpctls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := tlstest.WriteFiles(t, dir)
+
+	tests := []struct {
+		desc    string
+		opts    []Option
+		wantErr string
+	}{
+		{
+			desc:    "no certificate",
+			opts:    []Option{CAFile(files.CAFile)},
+			wantErr: "a certificate and private key are required for a TLS server",
+		},
+		{
+			desc:    "missing key",
+			opts:    []Option{CertificateFiles(files.ServerCertFile, filepath.Join(dir, "missing.pem"))},
+			wantErr: "failed to load certificate",
+		},
+		{
+			desc: "missing CA bundle",
+			opts: []Option{
+				CertificateFiles(files.ServerCertFile, files.ServerKeyFile),
+				CAFile(filepath.Join(dir, "missing.pem")),
+			},
+			wantErr: "failed to read CA bundle",
+		},
+		{
+			desc: "empty CA bundle",
+			opts: []Option{
+				CertificateFiles(files.ServerCertFile, files.ServerKeyFile),
+				CAFile(files.ServerKeyFile),
+			},
+			wantErr: "no certificates found in CA bundle",
+		},
+		{
+			desc: "success",
+			opts: []Option{
+				CertificateFiles(files.ServerCertFile, files.ServerKeyFile),
+				CAFile(files.CAFile),
+				ClientAuth(tls.RequireAndVerifyClientCert),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			config, err := ServerConfig(tt.opts...)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, config.Certificates, 1)
+			assert.NotNil(t, config.ClientCAs)
+			assert.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+		})
+	}
+}
+
+func TestClientConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpctls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := tlstest.WriteFiles(t, dir)
+
+	t.Run("defaults", func(t *testing.T) {
+		config, err := ClientConfig()
+		require.NoError(t, err)
+		assert.Empty(t, config.Certificates)
+		assert.Nil(t, config.RootCAs)
+		assert.Empty(t, config.ServerName)
+	})
+
+	t.Run("missing certificate", func(t *testing.T) {
+		_, err := ClientConfig(CertificateFiles("", files.ClientKeyFile))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to load certificate")
+	})
+
+	t.Run("missing CA bundle", func(t *testing.T) {
+		_, err := ClientConfig(CAFile(filepath.Join(dir, "missing.pem")))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read CA bundle")
+	})
+
+	t.Run("mutual TLS", func(t *testing.T) {
+		config, err := ClientConfig(
+			CertificateFiles(files.ClientCertFile, files.ClientKeyFile),
+			CAFile(files.CAFile),
+			ServerName("localhost"),
+		)
+		require.NoError(t, err)
+		assert.Len(t, config.Certificates, 1)
+		assert.NotNil(t, config.RootCAs)
+		assert.Equal(t, "localhost", config.ServerName)
+	})
+}
+
+func TestVerifiedPeerCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpctls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := tlstest.WriteFiles(t, dir)
+
+	serverConfig, err := ServerConfig(
+		CertificateFiles(files.ServerCertFile, files.ServerKeyFile),
+		CAFile(files.CAFile),
+		ClientAuth(tls.RequireAndVerifyClientCert),
+	)
+	require.NoError(t, err)
+
+	clientConfig, err := ClientConfig(
+		CertificateFiles(files.ClientCertFile, files.ClientKeyFile),
+		CAFile(files.CAFile),
+		ServerName("localhost"),
+	)
+	require.NoError(t, err)
+
+	clientConn, serverConn := net.Pipe()
+	server := tls.Server(serverConn, serverConfig)
+	client := tls.Client(clientConn, clientConfig)
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	errc := make(chan error, 1)
+	go func() { errc <- client.Handshake() }()
+	require.NoError(t, server.Handshake(), "server handshake failed")
+	require.NoError(t, <-errc, "client handshake failed")
+
+	state := server.ConnectionState()
+	cert := VerifiedPeerCertificate(&state)
+	require.NotNil(t, cert)
+	assert.Equal(t, tlstest.ClientCommonName, cert.Subject.CommonName)
+
+	assert.Nil(t, VerifiedPeerCertificate(nil))
+	assert.Nil(t, VerifiedPeerCertificate(&tls.ConnectionState{}))
+}